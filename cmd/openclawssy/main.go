@@ -7,24 +7,35 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"openclawssy/internal/approval"
 	"openclawssy/internal/channels/chat"
 	"openclawssy/internal/channels/cli"
 	"openclawssy/internal/channels/dashboard"
 	"openclawssy/internal/channels/discord"
+	"openclawssy/internal/channels/email"
 	httpchannel "openclawssy/internal/channels/http"
+	"openclawssy/internal/channels/openai"
+	"openclawssy/internal/channels/slack"
+	"openclawssy/internal/channels/telegram"
 	"openclawssy/internal/chatstore"
 	"openclawssy/internal/config"
+	"openclawssy/internal/mcp"
+	"openclawssy/internal/policy"
 	"openclawssy/internal/runtime"
 	"openclawssy/internal/scheduler"
 	"openclawssy/internal/secrets"
@@ -44,6 +55,11 @@ func main() {
 		printUsage(os.Stderr)
 		os.Exit(2)
 	}
+	installPassphrasePrompt()
+	if err := secrets.CaptureEnvironmentPassphrase(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	var code int
 	switch os.Args[1] {
@@ -61,18 +77,310 @@ func main() {
 		code = handlers.HandleCron(ctx, os.Args[2:])
 	case "serve":
 		code = handleServe(ctx, engine, os.Args[2:])
+	case "mcp":
+		code = handleMCP(ctx, engine, os.Args[2:])
+	case "policy":
+		code = handlePolicy(engine, os.Args[2:], os.Stdout)
+	case "audit":
+		code = handleAudit(engine, os.Args[2:], os.Stdout)
+	case "secrets":
+		code = handleSecrets(os.Args[2:], os.Stdout)
 	default:
 		fmt.Fprintf(os.Stderr, "unknown subcommand: %s\n\n", os.Args[1])
 		printUsage(os.Stderr)
 		code = 2
 	}
 
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	if err := engine.Close(closeCtx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	cancel()
 	os.Exit(code)
 }
 
 func printUsage(w *os.File) {
 	fmt.Fprintln(w, "usage: openclawssy <subcommand> [flags]")
-	fmt.Fprintln(w, "subcommands: init, setup, ask, run, serve, cron, doctor")
+	fmt.Fprintln(w, "subcommands: init, setup, ask, run, serve, mcp, policy, audit, secrets, cron, doctor")
+}
+
+func handleMCP(ctx context.Context, engine *runtime.Engine, args []string) int {
+	if len(args) == 0 || args[0] != "serve" {
+		fmt.Fprintln(os.Stderr, "usage: openclawssy mcp serve [-transport stdio|http] [-addr host:port] [-token TOKEN] [-agent ID] [-tools a,b]")
+		return 2
+	}
+	input, err := cli.ParseMCPServeArgs(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	cfg, err := config.LoadOrDefault(filepath.Join(".openclawssy", "config.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	agentID := input.AgentID
+	if agentID == "" {
+		agentID = cfg.MCP.Serve.AgentID
+	}
+	exposed := input.Tools
+	if len(exposed) == 0 {
+		exposed = cfg.MCP.Serve.Tools
+	}
+
+	backend, err := engine.NewMCPServeBackend(ctx, agentID, exposed)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer backend.Close()
+	server := mcp.NewServer(backend, "openclawssy", "0.1.0")
+
+	if input.Transport == "stdio" {
+		if err := server.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", server.Handler(input.Token))
+	fmt.Fprintf(os.Stderr, "mcp server for agent %q listening on http://%s/mcp\n", agentID, input.Addr)
+	if err := http.ListenAndServe(input.Addr, mux); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// handlePolicy runs `policy check`: it validates the policy, evaluates one
+// hypothetical call and runs fixture files. It exits 1 when the call is
+// denied or any fixture case fails.
+func handlePolicy(engine *runtime.Engine, args []string, out io.Writer) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: openclawssy policy check [-file policy.json] [-agent ID -tool NAME [-args JSON]] [-json] [fixture.json ...]")
+		return 2
+	}
+	input, err := cli.ParsePolicyCheckArgs(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	docPath := input.File
+	if docPath == "" {
+		docPath = engine.PolicyDocumentPath()
+	} else if _, err := os.Stat(docPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	evaluator, err := engine.PolicyEvaluator(docPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	switch {
+	case input.JSON:
+	case evaluator.Document == nil:
+		fmt.Fprintf(out, "no policy document at %s; using grants and config\n", docPath)
+	default:
+		fmt.Fprintf(out, "policy %s is valid (version %d, %d agents)\n", docPath, evaluator.Document.Version, len(evaluator.Document.Agents))
+	}
+
+	code := 0
+	if input.Tool != "" {
+		decision := evaluator.Evaluate(policy.Call{AgentID: input.AgentID, Tool: input.Tool, Args: input.Args})
+		if input.JSON {
+			raw, _ := json.MarshalIndent(decision, "", "  ")
+			fmt.Fprintln(out, string(raw))
+		} else {
+			verdict := "deny"
+			if decision.Allowed {
+				verdict = "allow"
+			}
+			fmt.Fprintf(out, "%s %s %s\n", verdict, decision.AgentID, decision.Tool)
+			for _, step := range decision.Steps {
+				fmt.Fprintf(out, "  %s: %s\n", step.Check, step.Detail)
+			}
+		}
+		if !decision.Allowed {
+			code = 1
+		}
+	}
+
+	for _, path := range input.Fixtures {
+		fixture, err := policy.LoadFixture(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		passed, failed := 0, 0
+		for _, result := range evaluator.RunFixture(fixture) {
+			if result.Passed {
+				passed++
+				fmt.Fprintf(out, "PASS %s\n", result.Case.Name)
+				continue
+			}
+			failed++
+			fmt.Fprintf(out, "FAIL %s: %s\n", result.Case.Name, result.Message)
+		}
+		fmt.Fprintf(out, "%s: %d passed, %d failed\n", path, passed, failed)
+		if failed > 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// handleAudit runs `audit verify` and exits 1 when any chain is broken.
+func handleAudit(engine *runtime.Engine, args []string, out io.Writer) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, "usage: openclawssy audit verify [-agent a,b] [-pubkey FILE] [-json]")
+		return 2
+	}
+	input, err := cli.ParseAuditVerifyArgs(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	reports, err := engine.VerifyAudit(input.AgentIDs, input.PublicKey)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if input.JSON {
+		raw, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Fprintln(out, string(raw))
+	}
+
+	code := 0
+	agentIDs := make([]string, 0, len(reports))
+	for agentID := range reports {
+		agentIDs = append(agentIDs, agentID)
+	}
+	sort.Strings(agentIDs)
+	for _, agentID := range agentIDs {
+		report := reports[agentID]
+		if !report.OK() {
+			code = 1
+		}
+		if input.JSON {
+			continue
+		}
+		if !report.OK() {
+			fmt.Fprintf(out, "BROKEN %s: %s line %d (seq %d): %s\n", agentID, report.Break.File, report.Break.Line, report.Break.Seq, report.Break.Reason)
+			continue
+		}
+		fmt.Fprintf(out, "ok %s: %d records through seq %d in %d files", agentID, report.Records, report.LastSeq, len(report.Files))
+		if report.Legacy > 0 {
+			fmt.Fprintf(out, ", %d unchained legacy records", report.Legacy)
+		}
+		fmt.Fprintf(out, ", %d checkpoints (%d signature-verified)", report.Checkpoints, report.VerifiedCheckpoints)
+		if report.Checkpoints > 0 && report.LastSeq > report.LastCheckpointSeq {
+			fmt.Fprintf(out, ", %d records after the last checkpoint", report.LastSeq-report.LastCheckpointSeq)
+		}
+		fmt.Fprintln(out)
+	}
+	return code
+}
+
+func handleSecrets(args []string, out io.Writer) int {
+	input, err := cli.ParseSecretsArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "usage: openclawssy secrets list|show -name N|rollback -name N -version V|acl -name N [-agents a,b]|rotate-master-key|wrap-master-key [-with passphrase|command|<wrapper>]")
+		return 2
+	}
+	cfg, err := config.LoadOrDefault(filepath.Join(".openclawssy", "config.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if input.Action == "wrap-master-key" {
+		if err := wrapMasterKey(cfg.Secrets, input.With); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(out, "master key in %s wrapped with %s\n", cfg.Secrets.MasterKeyFile, input.With)
+		return 0
+	}
+	store, err := secrets.NewStore(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch input.Action {
+	case "list", "show":
+		var items []secrets.Metadata
+		if input.Action == "list" {
+			items, err = store.List()
+		} else {
+			meta, found, merr := store.Metadata(input.Name)
+			if merr == nil && !found {
+				merr = fmt.Errorf("%w: %s", secrets.ErrNoSuchSecret, input.Name)
+			}
+			items, err = []secrets.Metadata{meta}, merr
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if input.JSON {
+			raw, _ := json.MarshalIndent(items, "", "  ")
+			fmt.Fprintln(out, string(raw))
+			return 0
+		}
+		for _, meta := range items {
+			if meta.Backend != config.SecretsBackendStore {
+				fmt.Fprintf(out, "%s (%s) acl=%s\n", meta.Name, meta.Backend, formatSecretACL(meta.ACL))
+				continue
+			}
+			fmt.Fprintf(out, "%s v%d rotated %s acl=%s\n", meta.Name, meta.Version, formatSecretTime(meta.RotatedAt), formatSecretACL(meta.ACL))
+			if input.Action == "show" {
+				for _, v := range meta.Versions {
+					marker := " "
+					if v.Version == meta.Version {
+						marker = "*"
+					}
+					fmt.Fprintf(out, "  %s v%d created %s\n", marker, v.Version, formatSecretTime(v.CreatedAt))
+				}
+			}
+		}
+	case "rollback":
+		if err := store.Rollback(input.Name, input.Version); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(out, "%s is now at version %d\n", input.Name, input.Version)
+	case "acl":
+		acl := secrets.ACL{Agents: input.Agents}
+		if err := store.SetACL(input.Name, acl); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(out, "%s acl=%s\n", input.Name, formatSecretACL(acl))
+	case "rotate-master-key":
+		if err := store.RotateMasterKey(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(out, "store re-encrypted under a new master key in %s\n", cfg.Secrets.MasterKeyFile)
+	}
+	return 0
+}
+
+func formatSecretTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatSecretACL(acl secrets.ACL) string {
+	if acl.Empty() {
+		return "any-agent"
+	}
+	return "agents:" + strings.Join(acl.Agents, ",")
 }
 
 func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int {
@@ -96,9 +404,33 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 		return 1
 	}
 	if secretStore, serr := secrets.NewStore(runtimeCfg); serr == nil {
+		if rerr := secretStore.RefreshRedaction(); rerr != nil {
+			fmt.Fprintf(os.Stderr, "warning: secret redaction not loaded: %v\n", rerr)
+		}
 		if token, ok, _ := secretStore.Get("discord/bot_token"); ok && strings.TrimSpace(token) != "" {
 			runtimeCfg.Discord.Token = token
 		}
+		if token, ok, _ := secretStore.Get("slack/bot_token"); ok && strings.TrimSpace(token) != "" {
+			runtimeCfg.Slack.BotToken = token
+		}
+		if token, ok, _ := secretStore.Get("slack/app_token"); ok && strings.TrimSpace(token) != "" {
+			runtimeCfg.Slack.AppToken = token
+		}
+		if secret, ok, _ := secretStore.Get("slack/signing_secret"); ok && strings.TrimSpace(secret) != "" {
+			runtimeCfg.Slack.SigningSecret = secret
+		}
+		if token, ok, _ := secretStore.Get("telegram/bot_token"); ok && strings.TrimSpace(token) != "" {
+			runtimeCfg.Telegram.Token = token
+		}
+		if secret, ok, _ := secretStore.Get("telegram/webhook_secret"); ok && strings.TrimSpace(secret) != "" {
+			runtimeCfg.Telegram.WebhookSecret = secret
+		}
+		if password, ok, _ := secretStore.Get("email/imap_password"); ok && strings.TrimSpace(password) != "" {
+			runtimeCfg.Email.IMAP.Password = password
+		}
+		if password, ok, _ := secretStore.Get("email/smtp_password"); ok && strings.TrimSpace(password) != "" {
+			runtimeCfg.Email.SMTP.Password = password
+		}
 	}
 
 	jobsStore, err := scheduler.NewStore(serveCfg.JobsFile)
@@ -106,14 +438,15 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
-	if err := ensureDefaultMemoryCheckpointJob(runtimeCfg, jobsStore); err != nil {
+	removedJobs, err := removeLegacyMemoryJobs(jobsStore)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, "scheduler setup warning:", err)
 	}
-	if err := ensureDefaultMemoryMaintenanceJob(runtimeCfg, jobsStore); err != nil {
-		fmt.Fprintln(os.Stderr, "scheduler setup warning:", err)
+	for _, job := range removedJobs {
+		fmt.Fprintf(os.Stderr, "scheduler: removed legacy job %s (%s %q); memory housekeeping now covers it\n", job.ID, job.Schedule, job.Message)
 	}
 	var schedulerChatStore *chatstore.Store
-	if runtimeCfg.Chat.Enabled || runtimeCfg.Discord.Enabled {
+	if runtimeCfg.Chat.Enabled || runtimeCfg.Discord.Enabled || runtimeCfg.Slack.Enabled || runtimeCfg.Telegram.Enabled || runtimeCfg.Email.Enabled {
 		schedulerChatStore, err = chatstore.NewStore(filepath.Join(".openclawssy", "agents"))
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "failed to initialize scheduler chat delivery:", err)
@@ -150,7 +483,20 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 	schedulerExec.Start()
 	defer schedulerExec.Stop()
 
-	sharedChat, err := buildSharedChatConnector(runtimeCfg, runStore, exec, eventBus)
+	housekeeper := runtime.NewMemoryHousekeeper(engine, time.Minute)
+	housekeeper.Start()
+	defer housekeeper.Stop()
+
+	var approvals *approval.Broker
+	if runtimeCfg.Approvals.Enabled {
+		approvals, err = engine.Approvals()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	sharedChat, err := buildSharedChatConnector(runtimeCfg, runStore, exec, eventBus, approvals)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 1
@@ -168,6 +514,10 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 				}
 				return discord.RunStatus{Status: run.Status, Output: run.Output, Error: run.Error, ArtifactPath: run.ArtifactPath, Trace: run.Trace}, nil
 			},
+			discordRunEvents(eventBus),
+			func(sessionID string) ([]chat.OutboundFile, error) {
+				return sharedChat.TakeOutbox("discord", sessionID)
+			},
 		)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "discord disabled:", err)
@@ -178,7 +528,103 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 		}
 	}
 
+	var slackEvents http.Handler
+	if runtimeCfg.Slack.Enabled {
+		sBot, err := slack.New(
+			runtimeCfg,
+			buildSlackMessageHandler(sharedChat, runtimeCfg.Slack.DefaultAgentID),
+			func(ctx context.Context, runID string) (slack.RunStatus, error) {
+				run, err := runStore.Get(ctx, runID)
+				if err != nil {
+					return slack.RunStatus{}, err
+				}
+				return slack.RunStatus{Status: run.Status, Output: run.Output, Error: run.Error, ArtifactPath: run.ArtifactPath}, nil
+			},
+			slackRunEvents(eventBus),
+		)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "slack disabled:", err)
+		} else if err := sBot.Start(); err != nil {
+			fmt.Fprintln(os.Stderr, "slack start failed:", err)
+		} else {
+			defer sBot.Stop()
+			if runtimeCfg.Slack.Mode == "events" {
+				slackEvents = sBot.EventsHandler()
+			}
+		}
+	}
+
+	var telegramWebhook http.Handler
+	if runtimeCfg.Telegram.Enabled {
+		tBot, err := telegram.New(
+			runtimeCfg,
+			buildTelegramMessageHandler(sharedChat, runtimeCfg.Telegram.DefaultAgentID),
+			func(ctx context.Context, runID string) (telegram.RunStatus, error) {
+				run, err := runStore.Get(ctx, runID)
+				if err != nil {
+					return telegram.RunStatus{}, err
+				}
+				return telegram.RunStatus{Status: run.Status, Output: run.Output, Error: run.Error, ArtifactPath: run.ArtifactPath}, nil
+			},
+			telegramRunEvents(eventBus),
+		)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "telegram disabled:", err)
+		} else if err := tBot.Start(); err != nil {
+			fmt.Fprintln(os.Stderr, "telegram start failed:", err)
+		} else {
+			defer tBot.Stop()
+			if runtimeCfg.Telegram.Mode == "webhook" {
+				telegramWebhook = tBot.WebhookHandler()
+			}
+		}
+	}
+
+	if runtimeCfg.Email.Enabled {
+		eBot, err := email.New(
+			runtimeCfg,
+			buildEmailMessageHandler(sharedChat, runtimeCfg.Email.DefaultAgentID),
+			func(ctx context.Context, runID string) (email.RunStatus, error) {
+				run, err := runStore.Get(ctx, runID)
+				if err != nil {
+					return email.RunStatus{}, err
+				}
+				return email.RunStatus{Status: run.Status, Output: run.Output, Error: run.Error, ArtifactPath: run.ArtifactPath}, nil
+			},
+			func(sessionID string) ([]chat.OutboundFile, error) {
+				return sharedChat.TakeOutbox("email", sessionID)
+			},
+		)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "email disabled:", err)
+		} else if err := eBot.Start(); err != nil {
+			fmt.Fprintln(os.Stderr, "email start failed:", err)
+		} else {
+			defer eBot.Stop()
+		}
+	}
+
+	var openaiHandler *openai.Handler
+	if runtimeCfg.OpenAI.Enabled {
+		openaiHandler, err = buildOpenAIHandler(runtimeCfg, runStore, exec, eventBus)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "openai compat disabled:", err)
+		}
+	}
+
+	var publicPaths []string
+	if slackEvents != nil {
+		publicPaths = append(publicPaths, runtimeCfg.Slack.EventsPath)
+	}
+	if telegramWebhook != nil {
+		publicPaths = append(publicPaths, runtimeCfg.Telegram.WebhookPath)
+	}
+
 	dash := dashboard.New(".", runStore, jobsStore)
+	if approvals != nil {
+		dash.SetApprovals(approvals)
+	}
+	dash.SetAuditLogger(engine.OpenAuditLogger)
 	server := httpchannel.NewServer(httpchannel.Config{
 		Addr:        serveCfg.Addr,
 		BearerToken: serveCfg.Token,
@@ -186,10 +632,20 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 		Executor:    exec,
 		Chat:        buildDashboardChatConnector(runtimeCfg, sharedChat),
 		EventBus:    eventBus,
+		PublicPaths: publicPaths,
 		RegisterMux: func(mux *http.ServeMux) {
 			if runtimeCfg.Server.Dashboard {
 				dash.Register(mux)
 			}
+			if slackEvents != nil {
+				mux.Handle(runtimeCfg.Slack.EventsPath, slackEvents)
+			}
+			if telegramWebhook != nil {
+				mux.Handle(runtimeCfg.Telegram.WebhookPath, telegramWebhook)
+			}
+			if openaiHandler != nil {
+				openaiHandler.Register(mux)
+			}
 		},
 	})
 
@@ -209,54 +665,32 @@ func handleServe(ctx context.Context, engine *runtime.Engine, args []string) int
 	return 0
 }
 
-func ensureDefaultMemoryCheckpointJob(cfg config.Config, jobsStore *scheduler.Store) error {
+// removeLegacyMemoryJobs drops the memory jobs older versions generated, now
+// replaced by the housekeeping loop. A job is only removed while it still has
+// the generated schedule and message, so one an operator edited is kept.
+func removeLegacyMemoryJobs(jobsStore *scheduler.Store) ([]scheduler.Job, error) {
 	if jobsStore == nil {
-		return nil
+		return nil, nil
 	}
-	if !cfg.Memory.Enabled || !cfg.Memory.AutoCheckpoint {
-		return nil
+	legacy := map[string]scheduler.Job{
+		"memory-checkpoint-default":  {Schedule: "@every 6h", Message: "/tool memory.checkpoint {}"},
+		"memory-maintenance-default": {Schedule: "@every 168h", Message: "/tool memory.maintenance {}"},
 	}
-	const defaultCheckpointJobID = "memory-checkpoint-default"
+	removed := []scheduler.Job{}
 	for _, job := range jobsStore.List() {
-		if strings.TrimSpace(job.ID) == defaultCheckpointJobID {
-			return nil
+		generated, ok := legacy[strings.TrimSpace(job.ID)]
+		if !ok || strings.TrimSpace(job.Schedule) != generated.Schedule || strings.TrimSpace(job.Message) != generated.Message {
+			continue
 		}
-	}
-	return jobsStore.Add(scheduler.Job{
-		ID:       defaultCheckpointJobID,
-		AgentID:  "default",
-		Schedule: "@every 6h",
-		Message:  "/tool memory.checkpoint {}",
-		Channel:  "system",
-		UserID:   "system",
-		RoomID:   "maintenance",
-		Enabled:  true,
-	})
-}
-
-func ensureDefaultMemoryMaintenanceJob(cfg config.Config, jobsStore *scheduler.Store) error {
-	if jobsStore == nil {
-		return nil
-	}
-	if !cfg.Memory.Enabled {
-		return nil
-	}
-	const defaultMaintenanceJobID = "memory-maintenance-default"
-	for _, job := range jobsStore.List() {
-		if strings.TrimSpace(job.ID) == defaultMaintenanceJobID {
-			return nil
+		if agentID := strings.TrimSpace(job.AgentID); agentID != "" && agentID != "default" {
+			continue
+		}
+		if err := jobsStore.Remove(job.ID); err != nil {
+			return removed, err
 		}
+		removed = append(removed, job)
 	}
-	return jobsStore.Add(scheduler.Job{
-		ID:       defaultMaintenanceJobID,
-		AgentID:  "default",
-		Schedule: "@every 168h",
-		Message:  "/tool memory.maintenance {}",
-		Channel:  "system",
-		UserID:   "system",
-		RoomID:   "maintenance",
-		Enabled:  true,
-	})
+	return removed, nil
 }
 
 func resolveScheduledJobSession(store *chatstore.Store, job scheduler.Job) (string, error) {
@@ -404,21 +838,49 @@ func (doctorService) Doctor(_ context.Context, input cli.DoctorInput) (string, e
 		}
 	}
 
+	keyState, keyWarning := "missing", ""
+	if cfgErr == nil {
+		keyState, keyWarning = masterKeyStateForDoctor(cfg.Secrets)
+	}
+
 	if input.Verbose {
 		setup := []string{
 			"1) openclawssy setup",
-			"2) export OPENCLAWSSY_MASTER_KEY if not using local master key file",
+			"2) export OPENCLAWSSY_MASTER_KEY if not using local master key file, or wrap it: openclawssy secrets wrap-master-key",
 			"3) store provider key via dashboard or wizard",
 			"4) run `openclawssy serve --token <token>` and open https dashboard",
 		}
 		if cfgErr != nil {
 			return fmt.Sprintf("doctor: workspace=%s (%s) model=%s secrets=%s\nsetup:\n- %s", workspace, state, providerState, secretState, strings.Join(setup, "\n- ")), nil
 		}
-		return fmt.Sprintf("doctor: workspace=%s (%s) model=%s secrets=%s", workspace, state, providerState, secretState), nil
+		report := fmt.Sprintf("doctor: workspace=%s (%s) model=%s secrets=%s master_key=%s", workspace, state, providerState, secretState, keyState)
+		if keyWarning != "" {
+			report += "\n" + keyWarning
+		}
+		return report, nil
+	}
+	if keyWarning != "" {
+		return "doctor: ok\n" + keyWarning, nil
 	}
 	return "doctor: ok", nil
 }
 
+func masterKeyStateForDoctor(cfg config.SecretsConfig) (string, string) {
+	status, err := secrets.MasterKeyStatus(cfg)
+	switch {
+	case err != nil:
+		return "error", "warning: " + err.Error()
+	case status.FromEnv:
+		return "env", ""
+	case !status.Exists:
+		return "missing", ""
+	case status.Wrapped:
+		return "wrapped(" + status.Wrap + ")", ""
+	default:
+		return "unwrapped", fmt.Sprintf("warning: master key %s is stored unwrapped next to the secrets it protects; run `openclawssy secrets wrap-master-key`", cfg.MasterKeyFile)
+	}
+}
+
 type cronService struct{}
 
 func (cronService) Cron(_ context.Context, input cli.CronInput) (string, error) {
@@ -539,14 +1001,18 @@ func (e runtimeExecutor) Execute(ctx context.Context, input httpchannel.Executio
 	return httpchannel.ExecutionResult{Output: res.FinalText, ArtifactPath: res.ArtifactPath, DurationMS: res.DurationMS, ToolCalls: res.ToolCalls, Provider: res.Provider, Model: res.Model, Trace: res.Trace}, nil
 }
 
-func buildSharedChatConnector(cfg config.Config, store httpchannel.RunStore, exec httpchannel.RunExecutor, eventBus *httpchannel.RunEventBus) (*chat.Connector, error) {
-	if !cfg.Chat.Enabled && !cfg.Discord.Enabled {
+func buildSharedChatConnector(cfg config.Config, store httpchannel.RunStore, exec httpchannel.RunExecutor, eventBus *httpchannel.RunEventBus, approvals *approval.Broker) (*chat.Connector, error) {
+	if !cfg.Chat.Enabled && !cfg.Discord.Enabled && !cfg.Slack.Enabled && !cfg.Telegram.Enabled && !cfg.Email.Enabled {
 		return nil, nil
 	}
 	chatStore, err := chatstore.NewStore(filepath.Join(".openclawssy", "agents"))
 	if err != nil {
 		return nil, fmt.Errorf("create chat store: %w", err)
 	}
+	workspaceDir, err := filepath.Abs("workspace")
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace: %w", err)
+	}
 	defaultAgentID := strings.TrimSpace(cfg.Chat.DefaultAgentID)
 	if defaultAgentID == "" {
 		defaultAgentID = strings.TrimSpace(cfg.Discord.DefaultAgentID)
@@ -554,11 +1020,24 @@ func buildSharedChatConnector(cfg config.Config, store httpchannel.RunStore, exe
 	if defaultAgentID == "" {
 		defaultAgentID = "default"
 	}
+	var approve chat.ApproveFunc
+	if approvals != nil && cfg.Approvals.AllowChat {
+		approve = func(_ context.Context, approvalID, sessionID, approver string, approved bool, reason string) error {
+			_, err := approvals.Decide(approvalID, sessionID, approval.Decision{Approved: approved, Approver: approver, Reason: reason})
+			return err
+		}
+	}
 	return &chat.Connector{
 		DefaultAgentID: defaultAgentID,
 		Store:          chatStore,
 		HistoryLimit:   30,
 		GlobalLimiter:  chat.NewRateLimiter(cfg.Chat.GlobalRateLimitPerMin, time.Minute),
+		Cancel: func(_ context.Context, runID string) error {
+			return httpchannel.CancelQueuedRun(runID)
+		},
+		Approve:            approve,
+		WorkspaceDir:       workspaceDir,
+		MaxAttachmentBytes: int64(max(cfg.Discord.AttachmentMaxMB, cfg.Email.AttachmentMaxMB)) * 1024 * 1024,
 		Queue: func(ctx context.Context, agentID, message, source, sessionID, thinkingMode string) (chat.QueuedRun, error) {
 			run, err := httpchannel.QueueRunWithOptions(
 				ctx,
@@ -604,11 +1083,148 @@ func buildDiscordMessageHandler(connector *chat.Connector, defaultAgentID string
 		if agentID == "" {
 			agentID = "default"
 		}
-		queued, err := connector.HandleMessage(ctx, chat.Message{UserID: msg.UserID, RoomID: msg.RoomID, AgentID: agentID, Source: "discord", Text: msg.Text, ThinkingMode: msg.ThinkingMode})
+		queued, err := connector.HandleMessage(ctx, chat.Message{UserID: msg.UserID, RoomID: msg.RoomID, AgentID: agentID, Source: "discord", Text: msg.Text, ThinkingMode: msg.ThinkingMode, Attachments: msg.Attachments, Outbox: true})
 		if err != nil {
 			return discord.Response{}, err
 		}
-		return discord.Response{ID: queued.ID, Status: queued.Status, Response: queued.Response}, nil
+		return discord.Response{ID: queued.ID, Status: queued.Status, Response: queued.Response, SessionID: queued.SessionID}, nil
+	}
+}
+
+func discordRunEvents(bus *httpchannel.RunEventBus) discord.RunEventsFunc {
+	return func(runID string) (<-chan discord.RunEvent, func()) {
+		return relayRunEvents(bus, runID, func(evt httpchannel.RunEvent) discord.RunEvent {
+			return discord.RunEvent{Type: string(evt.Type), Data: evt.Data}
+		})
+	}
+}
+
+func buildSlackMessageHandler(connector *chat.Connector, defaultAgentID string) slack.MessageHandler {
+	return func(ctx context.Context, msg slack.Message) (slack.Response, error) {
+		if connector == nil {
+			return slack.Response{}, errors.New("chat connector is disabled")
+		}
+		agentID := strings.TrimSpace(msg.AgentID)
+		if agentID == "" {
+			agentID = strings.TrimSpace(defaultAgentID)
+		}
+		if agentID == "" {
+			agentID = "default"
+		}
+		queued, err := connector.HandleMessage(ctx, chat.Message{UserID: msg.UserID, RoomID: msg.RoomID, AgentID: agentID, Source: "slack", Text: msg.Text, ThinkingMode: msg.ThinkingMode})
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.Response{ID: queued.ID, Status: queued.Status, Response: queued.Response, SessionID: queued.SessionID}, nil
+	}
+}
+
+func slackRunEvents(bus *httpchannel.RunEventBus) slack.RunEventsFunc {
+	return func(runID string) (<-chan slack.RunEvent, func()) {
+		return relayRunEvents(bus, runID, func(evt httpchannel.RunEvent) slack.RunEvent {
+			return slack.RunEvent{Type: string(evt.Type), Data: evt.Data}
+		})
+	}
+}
+
+func buildTelegramMessageHandler(connector *chat.Connector, defaultAgentID string) telegram.MessageHandler {
+	return func(ctx context.Context, msg telegram.Message) (telegram.Response, error) {
+		if connector == nil {
+			return telegram.Response{}, errors.New("chat connector is disabled")
+		}
+		agentID := strings.TrimSpace(msg.AgentID)
+		if agentID == "" && connector.Store != nil {
+			// Honor an agent picked with /agent <id> in this chat.
+			if active, err := connector.Store.GetActiveAgentPointer("telegram", msg.UserID, msg.RoomID); err == nil {
+				agentID = strings.TrimSpace(active)
+			}
+		}
+		if agentID == "" {
+			agentID = strings.TrimSpace(defaultAgentID)
+		}
+		if agentID == "" {
+			agentID = "default"
+		}
+		queued, err := connector.HandleMessage(ctx, chat.Message{UserID: msg.UserID, RoomID: msg.RoomID, AgentID: agentID, Source: "telegram", Text: msg.Text, ThinkingMode: msg.ThinkingMode})
+		if err != nil {
+			return telegram.Response{}, err
+		}
+		return telegram.Response{ID: queued.ID, Status: queued.Status, Response: queued.Response, SessionID: queued.SessionID}, nil
+	}
+}
+
+func buildEmailMessageHandler(connector *chat.Connector, defaultAgentID string) email.MessageHandler {
+	return func(ctx context.Context, msg email.Message) (email.Response, error) {
+		if connector == nil {
+			return email.Response{}, errors.New("chat connector is disabled")
+		}
+		agentID := strings.TrimSpace(msg.AgentID)
+		if agentID == "" {
+			agentID = strings.TrimSpace(defaultAgentID)
+		}
+		if agentID == "" {
+			agentID = "default"
+		}
+		queued, err := connector.HandleMessage(ctx, chat.Message{UserID: msg.UserID, RoomID: msg.RoomID, AgentID: agentID, Source: "email", Text: msg.Text, Attachments: msg.Attachments, Outbox: true})
+		if err != nil {
+			return email.Response{}, err
+		}
+		return email.Response{ID: queued.ID, Status: queued.Status, Response: queued.Response, SessionID: queued.SessionID}, nil
+	}
+}
+
+func telegramRunEvents(bus *httpchannel.RunEventBus) telegram.RunEventsFunc {
+	return func(runID string) (<-chan telegram.RunEvent, func()) {
+		return relayRunEvents(bus, runID, func(evt httpchannel.RunEvent) telegram.RunEvent {
+			return telegram.RunEvent{Type: string(evt.Type), Data: evt.Data}
+		})
+	}
+}
+
+func buildOpenAIHandler(cfg config.Config, store httpchannel.RunStore, exec httpchannel.RunExecutor, eventBus *httpchannel.RunEventBus) (*openai.Handler, error) {
+	chatStore, err := chatstore.NewStore(filepath.Join(".openclawssy", "agents"))
+	if err != nil {
+		return nil, fmt.Errorf("create chat store: %w", err)
+	}
+	return openai.New(cfg, openai.Options{
+		Queue: func(ctx context.Context, agentID, message, sessionID string) (string, error) {
+			run, err := httpchannel.QueueRunWithOptions(ctx, store, exec, agentID, message, "openai", sessionID, "", httpchannel.QueueRunOptions{EventBus: eventBus})
+			if err != nil {
+				return "", err
+			}
+			return run.ID, nil
+		},
+		RunEvents: func(runID string) (<-chan openai.RunEvent, func()) {
+			return relayRunEvents(eventBus, runID, func(evt httpchannel.RunEvent) openai.RunEvent {
+				return openai.RunEvent{Type: string(evt.Type), Data: evt.Data}
+			})
+		},
+		Cancel:   httpchannel.CancelQueuedRun,
+		Agents:   chatStore.ListAgents,
+		Sessions: chatStore,
+	}), nil
+}
+
+// relayRunEvents forwards a run's bus events to a channel adapter until the
+// returned stop func is called.
+func relayRunEvents[T any](bus *httpchannel.RunEventBus, runID string, convert func(httpchannel.RunEvent) T) (<-chan T, func()) {
+	source, unsubscribe := bus.Subscribe(runID, 0)
+	out := make(chan T, 32)
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		for evt := range source {
+			select {
+			case out <- convert(evt):
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return out, func() {
+		once.Do(func() { close(done) })
+		unsubscribe()
 	}
 }
 
@@ -679,7 +1295,7 @@ func handleSetup(args []string) int {
 		return 1
 	}
 
-	in := bufio.NewReader(os.Stdin)
+	in := stdinReader
 	fmt.Println("Openclawssy guided setup (ZAI Coding Plan Edition)")
 	fmt.Println("Default: ZAI provider with GLM-4.7 model")
 	fmt.Println("Get your API key at: https://z.ai/subscribe")
@@ -736,6 +1352,17 @@ func handleSetup(args []string) int {
 		}
 	}
 
+	if status, err := secrets.MasterKeyStatus(cfg.Secrets); err == nil && status.Exists && !status.Wrapped {
+		wrap := prompt(in, "Master key is stored unwrapped. Wrap it with a passphrase? [y/N]", "N")
+		if strings.EqualFold(wrap, "y") {
+			if err := wrapMasterKey(cfg.Secrets, secrets.WrapPassphrase); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			fmt.Println("Master key wrapped; serve will ask for the passphrase or read OPENCLAWSSY_MASTER_PASSPHRASE(_FD).")
+		}
+	}
+
 	if err := config.Save(cfgPath, cfg); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 1
@@ -761,6 +1388,19 @@ func prompt(r *bufio.Reader, label, def string) string {
 	return v
 }
 
+// wrapMasterKey wraps the key file, asking twice for a new passphrase when
+// none is supplied through the environment.
+func wrapMasterKey(cfg config.SecretsConfig, with string) error {
+	if with == secrets.WrapPassphrase && !secrets.HasPassphrase() {
+		if !stdinIsTerminal() {
+			return errors.New("no terminal for a passphrase prompt: set OPENCLAWSSY_MASTER_PASSPHRASE or OPENCLAWSSY_MASTER_PASSPHRASE_FD")
+		}
+		secrets.SetPassphraseSource(promptNewPassphrase)
+		defer installPassphrasePrompt()
+	}
+	return secrets.WrapMasterKeyFile(cfg, with)
+}
+
 func ensureMasterKey(path string) error {
 	if _, err := os.Stat(path); err == nil {
 		return nil
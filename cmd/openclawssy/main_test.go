@@ -16,6 +16,7 @@ import (
 	"openclawssy/internal/chatstore"
 	"openclawssy/internal/config"
 	"openclawssy/internal/scheduler"
+	"openclawssy/internal/secrets"
 )
 
 func TestChatAdaptersRouteBySource(t *testing.T) {
@@ -203,71 +204,53 @@ func TestResolveScheduledJobSessionCreatesSessionWhenMissing(t *testing.T) {
 	}
 }
 
-func TestEnsureDefaultMemoryCheckpointJob(t *testing.T) {
+func TestRemoveLegacyMemoryJobs(t *testing.T) {
 	store, err := scheduler.NewStore(filepath.Join(t.TempDir(), "jobs.json"))
 	if err != nil {
 		t.Fatalf("new scheduler store: %v", err)
 	}
-	cfg := config.Default()
-	cfg.Memory.Enabled = true
-	cfg.Memory.AutoCheckpoint = true
-
-	if err := ensureDefaultMemoryCheckpointJob(cfg, store); err != nil {
-		t.Fatalf("ensure default checkpoint job: %v", err)
-	}
-	jobs := store.List()
-	if len(jobs) != 1 {
-		t.Fatalf("expected one scheduler job, got %d", len(jobs))
-	}
-	job := jobs[0]
-	if job.ID != "memory-checkpoint-default" {
-		t.Fatalf("unexpected job id %q", job.ID)
-	}
-	if job.Schedule != "@every 6h" {
-		t.Fatalf("unexpected schedule %q", job.Schedule)
-	}
-	if job.Message != "/tool memory.checkpoint {}" {
-		t.Fatalf("unexpected message %q", job.Message)
-	}
-
-	if err := ensureDefaultMemoryCheckpointJob(cfg, store); err != nil {
-		t.Fatalf("ensure idempotent checkpoint job: %v", err)
-	}
-	if len(store.List()) != 1 {
-		t.Fatalf("expected idempotent setup to keep one job, got %d", len(store.List()))
+	for _, job := range []scheduler.Job{
+		{ID: "memory-checkpoint-default", AgentID: "default", Schedule: "@every 6h", Message: "/tool memory.checkpoint {}", Enabled: true},
+		{ID: "memory-maintenance-default", AgentID: "default", Schedule: "@every 24h", Message: "/tool memory.maintenance {}", Enabled: true},
+		{ID: "user-job", AgentID: "default", Schedule: "@every 1h", Message: "hello", Enabled: true},
+	} {
+		if err := store.Add(job); err != nil {
+			t.Fatalf("add job %s: %v", job.ID, err)
+		}
 	}
-}
 
-func TestEnsureDefaultMemoryMaintenanceJob(t *testing.T) {
-	store, err := scheduler.NewStore(filepath.Join(t.TempDir(), "jobs.json"))
+	removed, err := removeLegacyMemoryJobs(store)
 	if err != nil {
-		t.Fatalf("new scheduler store: %v", err)
+		t.Fatalf("remove legacy memory jobs: %v", err)
 	}
-	cfg := config.Default()
-	cfg.Memory.Enabled = true
-
-	if err := ensureDefaultMemoryMaintenanceJob(cfg, store); err != nil {
-		t.Fatalf("ensure default maintenance job: %v", err)
+	if len(removed) != 1 || removed[0].ID != "memory-checkpoint-default" {
+		t.Fatalf("expected only the unmodified generated job to be removed, got %#v", removed)
 	}
 	jobs := store.List()
-	if len(jobs) != 1 {
-		t.Fatalf("expected one scheduler job, got %d", len(jobs))
-	}
-	job := jobs[0]
-	if job.ID != "memory-maintenance-default" {
-		t.Fatalf("unexpected job id %q", job.ID)
-	}
-	if job.Schedule != "@every 168h" {
-		t.Fatalf("unexpected schedule %q", job.Schedule)
-	}
-	if job.Message != "/tool memory.maintenance {}" {
-		t.Fatalf("unexpected message %q", job.Message)
+	if len(jobs) != 2 {
+		t.Fatalf("expected the edited maintenance job and the user job to remain, got %#v", jobs)
 	}
+}
 
-	if err := ensureDefaultMemoryMaintenanceJob(cfg, store); err != nil {
-		t.Fatalf("ensure idempotent maintenance job: %v", err)
-	}
-	if len(store.List()) != 1 {
-		t.Fatalf("expected idempotent setup to keep one job, got %d", len(store.List()))
+func TestMasterKeyStateForDoctorWarnsWhenUnwrapped(t *testing.T) {
+	t.Setenv("OPENCLAWSSY_MASTER_KEY", "")
+	cfg := config.Default().Secrets
+	cfg.MasterKeyFile = filepath.Join(t.TempDir(), "master.key")
+	if state, warning := masterKeyStateForDoctor(cfg); state != "missing" || warning != "" {
+		t.Fatalf("missing key: %q %q", state, warning)
+	}
+	if _, err := secrets.GenerateAndWriteMasterKey(cfg.MasterKeyFile); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	state, warning := masterKeyStateForDoctor(cfg)
+	if state != "unwrapped" || !strings.Contains(warning, "wrap-master-key") {
+		t.Fatalf("unwrapped key: %q %q", state, warning)
+	}
+	t.Setenv("OPENCLAWSSY_MASTER_PASSPHRASE", "doctor-pass")
+	if err := secrets.WrapMasterKeyFile(cfg, secrets.WrapPassphrase); err != nil {
+		t.Fatalf("wrap: %v", err)
+	}
+	if state, warning := masterKeyStateForDoctor(cfg); state != "wrapped(passphrase)" || warning != "" {
+		t.Fatalf("wrapped key: %q %q", state, warning)
 	}
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"openclawssy/internal/secrets"
+)
+
+// stdinReader is shared by setup prompts and the passphrase prompt so neither
+// loses input the other has buffered.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// installPassphrasePrompt lets a passphrase-wrapped master key be unlocked
+// interactively when neither passphrase environment variable is set.
+func installPassphrasePrompt() {
+	if !stdinIsTerminal() {
+		return
+	}
+	secrets.SetPassphraseSource(func() ([]byte, error) {
+		return promptPassphrase("Master key passphrase: ")
+	})
+}
+
+func promptPassphrase(label string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, label)
+	restore, err := disableEcho(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	line, readErr := stdinReader.ReadBytes('\n')
+	restore()
+	fmt.Fprintln(os.Stderr)
+	if readErr != nil && len(line) == 0 {
+		return nil, fmt.Errorf("read passphrase: %w", readErr)
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// promptNewPassphrase asks twice and rejects empty or mismatched input.
+func promptNewPassphrase() ([]byte, error) {
+	first, err := promptPassphrase("New master key passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(first) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	second, err := promptPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(first, second) {
+		return nil, errors.New("passphrases do not match")
+	}
+	return first, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// disableEcho is not implemented here; the passphrase is read with echo on.
+// Prefer OPENCLAWSSY_MASTER_PASSPHRASE_FD on these platforms.
+func disableEcho(f *os.File) (func(), error) {
+	fmt.Fprintln(os.Stderr, "(warning: input will be visible)")
+	return func() {}, nil
+}
@@ -194,7 +194,7 @@ func (s *runState) executeTools(ctx context.Context, r Runner, toolCalls []ToolC
 
 		if callKey != "|" {
 			if strings.TrimSpace(result.Error) == "" {
-				s.cachedToolResults[callKey] = ToolCallResult{Output: result.Output}
+				s.cachedToolResults[callKey] = ToolCallResult{Output: result.Output, Taint: result.Taint}
 				delete(s.cachedFailedToolResults, callKey)
 				delete(s.failedToolCallCounts, callKey)
 				delete(s.failedToolCallErrors, callKey)
@@ -367,7 +367,7 @@ func (r Runner) Run(ctx context.Context, input RunInput) (RunOutput, error) {
 			if callKey != "|" {
 				if strings.TrimSpace(result.Error) == "" {
 					successfulCallKeyCounts[callKey]++
-					cachedToolResults[callKey] = ToolCallResult{Output: result.Output}
+					cachedToolResults[callKey] = ToolCallResult{Output: result.Output, Taint: result.Taint}
 					delete(cachedFailedToolResults, callKey)
 					delete(failedToolCallCounts, callKey)
 					delete(failedToolCallErrors, callKey)
@@ -86,6 +86,9 @@ type ToolCallResult struct {
 	ID     string `json:"id"`
 	Output string `json:"output"`
 	Error  string `json:"error,omitempty"`
+	// Taint names the untrusted source of Output (e.g. "http.request
+	// example.com"); such output is quoted as data, never as instructions.
+	Taint string `json:"taint,omitempty"`
 }
 
 // ToolCallRecord stores request + result for audit/output.
@@ -0,0 +1,280 @@
+// Package approval holds tool calls that policy puts in ask mode until an
+// operator approves or denies them, and remembers standing approvals.
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("approval: request not found")
+	ErrSessionMismatch = errors.New("approval: request belongs to another session")
+)
+
+// Request is a tool call waiting for an operator decision.
+type Request struct {
+	ID        string         `json:"id"`
+	AgentID   string         `json:"agent_id"`
+	RunID     string         `json:"run_id,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Tool      string         `json:"tool"`
+	Subject   string         `json:"subject"`
+	Args      map[string]any `json:"args,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// Decision resolves a Request. Remember stores an approval rule for the
+// request's agent and tool; Pattern defaults to the exact subject.
+type Decision struct {
+	Approved  bool      `json:"approved"`
+	Approver  string    `json:"approver"`
+	Reason    string    `json:"reason,omitempty"`
+	Remember  bool      `json:"remember,omitempty"`
+	Pattern   string    `json:"pattern,omitempty"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Rule is a remembered approval for calls whose subject matches Pattern.
+type Rule struct {
+	AgentID   string    `json:"agent_id"`
+	Tool      string    `json:"tool"`
+	Pattern   string    `json:"pattern"`
+	Approver  string    `json:"approver,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type rulesFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+type pending struct {
+	req      Request
+	decision chan Decision
+}
+
+type Broker struct {
+	path string
+
+	mu      sync.Mutex
+	pending map[string]*pending
+	rules   []Rule
+}
+
+// NewBroker loads remembered rules from path. A missing file starts empty.
+func NewBroker(path string) (*Broker, error) {
+	b := &Broker{path: path, pending: make(map[string]*pending)}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return b, nil
+		}
+		return nil, fmt.Errorf("approval: read rules: %w", err)
+	}
+	var file rulesFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("approval: parse rules: %w", err)
+	}
+	b.rules = file.Rules
+	return b, nil
+}
+
+// Await blocks until req is decided, ctx ends or timeout passes. A remembered
+// rule approves immediately without calling onPending; otherwise onPending is
+// called once the request is visible to Pending and Decide. On timeout the
+// request resolves to timeoutApprove with approver "timeout".
+//
+// A request with a Reason was forced by the taint gate: remembered rules were
+// granted for clean runs, so they are skipped and a timeout always denies.
+func (b *Broker) Await(ctx context.Context, req Request, timeout time.Duration, timeoutApprove bool, onPending func(Request)) (Request, Decision, error) {
+	if req.Subject == "" {
+		req.Subject = Subject(req.Tool, req.Args)
+	}
+	if req.Reason != "" {
+		timeoutApprove = false
+	} else if rule, ok := b.match(req.AgentID, req.Tool, req.Subject); ok {
+		return req, Decision{Approved: true, Approver: rule.Approver, Reason: "remembered approval " + rule.Pattern, DecidedAt: time.Now().UTC()}, nil
+	}
+
+	req.ID = newRequestID()
+	req.CreatedAt = time.Now().UTC()
+	req.ExpiresAt = req.CreatedAt.Add(timeout)
+	p := &pending{req: req, decision: make(chan Decision, 1)}
+	b.mu.Lock()
+	b.pending[req.ID] = p
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.pending, req.ID)
+		b.mu.Unlock()
+	}()
+	if onPending != nil {
+		onPending(req)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case decision := <-p.decision:
+		return req, decision, nil
+	case <-timer.C:
+		return req, Decision{Approved: timeoutApprove, Approver: "timeout", Reason: fmt.Sprintf("no decision within %s", timeout), DecidedAt: time.Now().UTC()}, nil
+	case <-ctx.Done():
+		return req, Decision{}, ctx.Err()
+	}
+}
+
+// Decide resolves a pending request. A non-empty sessionID restricts the
+// decision to requests from that chat session.
+func (b *Broker) Decide(id, sessionID string, decision Decision) (Request, error) {
+	b.mu.Lock()
+	p, ok := b.pending[strings.TrimSpace(id)]
+	if ok && sessionID != "" && p.req.SessionID != sessionID {
+		b.mu.Unlock()
+		return Request{}, ErrSessionMismatch
+	}
+	if ok {
+		delete(b.pending, p.req.ID)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return Request{}, ErrNotFound
+	}
+
+	decision.DecidedAt = time.Now().UTC()
+	if p.req.Reason != "" {
+		// Taint-gated requests never consult remembered rules, so a rule
+		// recorded from one would only widen ordinary approvals.
+		decision.Remember = false
+		decision.Pattern = ""
+	}
+	if decision.Approved && decision.Remember {
+		pattern := strings.TrimSpace(decision.Pattern)
+		if pattern == "" {
+			pattern = p.req.Subject
+		}
+		decision.Pattern = pattern
+		if err := b.remember(Rule{AgentID: p.req.AgentID, Tool: p.req.Tool, Pattern: pattern, Approver: decision.Approver, CreatedAt: decision.DecidedAt}); err != nil {
+			p.decision <- Decision{Approved: false, Approver: decision.Approver, Reason: err.Error(), DecidedAt: decision.DecidedAt}
+			return p.req, err
+		}
+	}
+	p.decision <- decision
+	return p.req, nil
+}
+
+// Pending lists waiting requests, oldest first.
+func (b *Broker) Pending() []Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Request, 0, len(b.pending))
+	for _, p := range b.pending {
+		out = append(out, p.req)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// PendingForSession lists waiting requests raised by runs in sessionID.
+func (b *Broker) PendingForSession(sessionID string) []Request {
+	out := make([]Request, 0)
+	for _, req := range b.Pending() {
+		if req.SessionID == sessionID {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+func (b *Broker) Rules() []Rule {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Rule(nil), b.rules...)
+}
+
+// Forget removes remembered rules matching agent, tool and pattern exactly.
+func (b *Broker) Forget(agentID, tool, pattern string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	kept := make([]Rule, 0, len(b.rules))
+	for _, rule := range b.rules {
+		if rule.AgentID == agentID && rule.Tool == tool && rule.Pattern == pattern {
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	removed := len(b.rules) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := b.saveLocked(kept); err != nil {
+		return 0, err
+	}
+	b.rules = kept
+	return removed, nil
+}
+
+func (b *Broker) match(agentID, tool, subject string) (Rule, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, rule := range b.rules {
+		if rule.AgentID == agentID && rule.Tool == tool && MatchPattern(rule.Pattern, subject) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (b *Broker) remember(rule Rule) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, existing := range b.rules {
+		if existing.AgentID == rule.AgentID && existing.Tool == rule.Tool && existing.Pattern == rule.Pattern {
+			return nil
+		}
+	}
+	next := append(append([]Rule(nil), b.rules...), rule)
+	if err := b.saveLocked(next); err != nil {
+		return err
+	}
+	b.rules = next
+	return nil
+}
+
+func (b *Broker) saveLocked(rules []Rule) error {
+	if b.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(rulesFile{Rules: rules}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return fmt.Errorf("approval: create rules dir: %w", err)
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o600); err != nil {
+		return fmt.Errorf("approval: write rules: %w", err)
+	}
+	return os.Rename(tmp, b.path)
+}
+
+func newRequestID() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("apr_%d", time.Now().UTC().UnixNano())
+	}
+	return "apr_" + hex.EncodeToString(buf)
+}
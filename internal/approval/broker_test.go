@@ -0,0 +1,139 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBrokerDecideRememberAndTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy", "approvals.json")
+	b, err := NewBroker(path)
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+
+	req := Request{AgentID: "default", SessionID: "chat_1", Tool: "shell.exec", Args: map[string]any{"command": "git", "args": []any{"push", "origin"}}}
+	done := make(chan Decision, 1)
+	pendingCh := make(chan Request, 1)
+	go func() {
+		_, decision, err := b.Await(context.Background(), req, time.Minute, false, func(r Request) { pendingCh <- r })
+		if err != nil {
+			t.Errorf("await: %v", err)
+		}
+		done <- decision
+	}()
+	pending := <-pendingCh
+	if pending.Subject != "git push origin" || len(b.PendingForSession("chat_1")) != 1 {
+		t.Fatalf("unexpected pending request %+v", pending)
+	}
+	if _, err := b.Decide(pending.ID, "chat_2", Decision{Approved: true}); !errors.Is(err, ErrSessionMismatch) {
+		t.Fatalf("expected session mismatch, got %v", err)
+	}
+	if _, err := b.Decide(pending.ID, "chat_1", Decision{Approved: true, Approver: "chat:alice", Remember: true, Pattern: "git push *"}); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if decision := <-done; !decision.Approved || decision.Approver != "chat:alice" {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+	if _, err := b.Decide(pending.ID, "", Decision{Approved: true}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected decided request to be gone, got %v", err)
+	}
+
+	reloaded, err := NewBroker(path)
+	if err != nil {
+		t.Fatalf("reload broker: %v", err)
+	}
+	if rules := reloaded.Rules(); len(rules) != 1 || rules[0].Pattern != "git push *" {
+		t.Fatalf("expected persisted rule, got %+v", rules)
+	}
+	req.Args = map[string]any{"command": "git", "args": []any{"push", "upstream"}}
+	_, decision, err := reloaded.Await(context.Background(), req, time.Minute, false, func(Request) { t.Fatal("remembered call should not pend") })
+	if err != nil || !decision.Approved {
+		t.Fatalf("expected remembered approval, got %+v %v", decision, err)
+	}
+
+	gated := req
+	gated.Reason = "run is tainted by http.request example.com; shell.exec needs review"
+	pendingCh = make(chan Request, 1)
+	_, decision, err = reloaded.Await(context.Background(), gated, 20*time.Millisecond, true, func(r Request) { pendingCh <- r })
+	if err != nil || decision.Approved || decision.Approver != "timeout" || len(pendingCh) != 1 {
+		t.Fatalf("expected taint-gated call to skip the remembered rule and deny on timeout, got %+v %v", decision, err)
+	}
+
+	req.Args = map[string]any{"command": "rm", "args": []any{"-rf", "build"}}
+	_, decision, err = reloaded.Await(context.Background(), req, 20*time.Millisecond, false, nil)
+	if err != nil || decision.Approved || decision.Approver != "timeout" {
+		t.Fatalf("expected timeout denial, got %+v %v", decision, err)
+	}
+	if len(reloaded.Pending()) != 0 {
+		t.Fatal("expected timed out request to be cleared")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := reloaded.Await(ctx, req, time.Minute, true, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation error, got %v", err)
+	}
+}
+
+func TestBrokerDecideDoesNotRememberTaintGatedApproval(t *testing.T) {
+	b, err := NewBroker(filepath.Join(t.TempDir(), "approvals.json"))
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+	req := Request{AgentID: "default", Tool: "shell.exec", Args: map[string]any{"command": "curl"}, Reason: "run is tainted by http.request example.com; shell.exec needs review"}
+	done := make(chan Decision, 1)
+	pendingCh := make(chan Request, 1)
+	go func() {
+		_, decision, _ := b.Await(context.Background(), req, time.Minute, false, func(r Request) { pendingCh <- r })
+		done <- decision
+	}()
+	pending := <-pendingCh
+	if _, err := b.Decide(pending.ID, "", Decision{Approved: true, Approver: "chat:alice", Remember: true, Pattern: "*"}); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if decision := <-done; !decision.Approved || decision.Remember {
+		t.Fatalf("expected a one-off approval, got %+v", decision)
+	}
+	if rules := b.Rules(); len(rules) != 0 {
+		t.Fatalf("expected no rule from a taint-gated approval, got %+v", rules)
+	}
+}
+
+func TestSubjectAndMatchPattern(t *testing.T) {
+	cases := []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"fs.delete", map[string]any{"path": "build/out.txt"}, "build/out.txt"},
+		{"secrets.set", map[string]any{"key": "provider/x/api_key", "value": "[REDACTED]"}, "provider/x/api_key"},
+		{"fs.move", map[string]any{"src": "a", "dst": "b"}, "a -> b"},
+		{"http.request", map[string]any{"url": "https://x", "method": "GET"}, `{"method":"GET","url":"https://x"}`},
+	}
+	for _, tc := range cases {
+		if got := Subject(tc.tool, tc.args); got != tc.want {
+			t.Fatalf("Subject(%s) = %q, want %q", tc.tool, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"*", "anything", true},
+		{"build/*", "build/a/b.txt", true},
+		{"build/*", "src/build/a", false},
+		{"git * origin", "git push origin", true},
+		{"git * origin", "git push upstream", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	} {
+		if got := MatchPattern(tc.pattern, tc.subject); got != tc.want {
+			t.Fatalf("MatchPattern(%q, %q) = %v, want %v", tc.pattern, tc.subject, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Subject is the argument string remembered approvals are matched against:
+// the command line for shell.exec, the target path for filesystem tools, the
+// key for secrets tools and compact JSON of the arguments otherwise.
+func Subject(tool string, args map[string]any) string {
+	switch {
+	case tool == "shell.exec":
+		parts := []string{stringArg(args, "command")}
+		switch raw := args["args"].(type) {
+		case []any:
+			for _, item := range raw {
+				parts = append(parts, fmt.Sprintf("%v", item))
+			}
+		case []string:
+			parts = append(parts, raw...)
+		}
+		return strings.TrimSpace(strings.Join(parts, " "))
+	case tool == "fs.move":
+		return strings.TrimSpace(stringArg(args, "src") + " -> " + stringArg(args, "dst"))
+	case strings.HasPrefix(tool, "fs."):
+		return stringArg(args, "path")
+	case strings.HasPrefix(tool, "secrets."):
+		return stringArg(args, "key")
+	case tool == "skill.run":
+		name := stringArg(args, "name")
+		if name == "" {
+			name = stringArg(args, "path")
+		}
+		return name
+	}
+	return canonicalJSON(args)
+}
+
+// MatchPattern reports whether subject matches pattern, where `*` matches any
+// run of characters (including `/` and spaces) and everything else is literal.
+func MatchPattern(pattern, subject string) bool {
+	if pattern == "*" {
+		return true
+	}
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == subject
+	}
+	if !strings.HasPrefix(subject, parts[0]) {
+		return false
+	}
+	rest := subject[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
+
+func stringArg(args map[string]any, key string) string {
+	value, _ := args[key].(string)
+	return strings.TrimSpace(value)
+}
+
+func canonicalJSON(args map[string]any) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		rawKey, _ := json.Marshal(key)
+		rawValue, err := json.Marshal(args[key])
+		if err != nil {
+			rawValue = []byte(`null`)
+		}
+		b.Write(rawKey)
+		b.WriteByte(':')
+		b.Write(rawValue)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
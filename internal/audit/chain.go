@@ -0,0 +1,395 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventCheckpoint = "audit.checkpoint"
+
+	checkpointMessagePrefix = "openclawssy-audit-checkpoint/v1"
+	hashField               = `,"hash":"`
+	lockSuffix              = ".lock"
+	tailReadBytes           = 64 * 1024
+)
+
+// Options tunes the chained log behind a Logger. Every event is hash chained;
+// rotation, retention, signed checkpoints and export are optional.
+type Options struct {
+	// MaxFileBytes rotates the active file once it would grow past this size,
+	// and MaxFileAge once its first record is this old. Rotated files are
+	// renamed `<name>-<first seq>.jsonl` (gzipped when Compress is set) and
+	// the chain continues in the new file. Zero disables either trigger.
+	MaxFileBytes int64
+	MaxFileAge   time.Duration
+	Compress     bool
+	// RetainFiles and RetainAge delete the oldest rotated files beyond the
+	// count or older than the age when a file rotates. Zero keeps everything.
+	RetainFiles int
+	RetainAge   time.Duration
+	// CheckpointEvery appends a checkpoint signed by Signer after this many
+	// events. Zero or a nil Signer disables checkpoints.
+	CheckpointEvery int
+	Signer          ed25519.PrivateKey
+	// Exporters receive every record once it is written.
+	Exporters []Exporter
+}
+
+// sink is the single writer for one audit file. Loggers opened on the same
+// path in this process share it so sequence numbers and hash links are
+// assigned in the order lines reach the file. Other processes (a `run` next
+// to `serve`) have their own sink; appends take a lock on `<name>.lock` and
+// pick up whatever the others wrote, so the chain stays linear.
+type sink struct {
+	path string
+	refs int
+	opts Options
+
+	mu        sync.Mutex
+	lock      *os.File
+	file      *os.File
+	size      int64
+	firstSeq  uint64
+	firstAt   time.Time
+	seq       uint64
+	head      string
+	sinceMark int
+
+	flushInterval time.Duration
+	lastFlushAt   time.Time
+}
+
+var sinks = struct {
+	mu     sync.Mutex
+	byPath map[string]*sink
+}{byPath: map[string]*sink{}}
+
+func acquireSink(path string, opts Options, flushInterval time.Duration) (*sink, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	sinks.mu.Lock()
+	defer sinks.mu.Unlock()
+	if s, ok := sinks.byPath[abs]; ok {
+		s.refs++
+		return s, nil
+	}
+	s := &sink{path: abs, refs: 1, opts: opts, flushInterval: flushInterval}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.openLockFile(); err != nil {
+		return nil, err
+	}
+	unlock, err := lockFile(s.lock)
+	if err != nil {
+		_ = s.lock.Close()
+		return nil, err
+	}
+	defer unlock()
+	if err := s.resumeLocked(); err != nil {
+		_ = s.lock.Close()
+		return nil, err
+	}
+	if err := s.openLocked(); err != nil {
+		_ = s.lock.Close()
+		return nil, err
+	}
+	sinks.byPath[abs] = s
+	return s, nil
+}
+
+func (s *sink) openLockFile() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), defaultDirMode); err != nil {
+		return err
+	}
+	lockPath := strings.TrimSuffix(s.path, filepath.Ext(s.path)) + lockSuffix
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, defaultFileMode)
+	if err != nil {
+		return err
+	}
+	s.lock = f
+	return nil
+}
+
+func (s *sink) release() error {
+	sinks.mu.Lock()
+	s.refs--
+	last := s.refs <= 0
+	if last {
+		delete(sinks.byPath, s.path)
+	}
+	sinks.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !last {
+		return s.fsyncLocked()
+	}
+	err := s.closeLocked()
+	if s.lock != nil {
+		if closeErr := s.lock.Close(); err == nil {
+			err = closeErr
+		}
+		s.lock = nil
+	}
+	return err
+}
+
+// resumeLocked picks up the chain from the newest record on disk, looking at
+// the newest rotated file when the active file is empty.
+func (s *sink) resumeLocked() error {
+	files, err := chainFiles(s.path)
+	if err != nil {
+		return err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		last, first, err := tailRecord(files[i])
+		if err != nil {
+			return err
+		}
+		if files[i] == s.path && first != nil {
+			s.firstSeq, s.firstAt = first.Seq, first.Timestamp
+		}
+		if last != nil {
+			s.seq, s.head = last.Seq, last.Hash
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *sink) openLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), defaultDirMode); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, defaultFileMode)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.size = info.Size()
+	if s.size > 0 {
+		// A crash can leave a partial last line; start a fresh line so the
+		// damage stays confined to that record.
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, s.size-1); err == nil && last[0] != defaultLineBreak {
+			if _, err := f.Write([]byte{defaultLineBreak}); err != nil {
+				_ = f.Close()
+				return err
+			}
+			s.size++
+		}
+	}
+	s.file = f
+	s.lastFlushAt = time.Now().UTC()
+	return nil
+}
+
+// append writes e straight to the file while holding the cross-process lock;
+// only the fsync is deferred to run end or the flush interval.
+func (s *sink) append(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(s.lock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := s.syncLocked(); err != nil {
+		return err
+	}
+	if err := s.writeLocked(e); err != nil {
+		return err
+	}
+	if s.opts.Signer != nil && s.opts.CheckpointEvery > 0 && s.sinceMark >= s.opts.CheckpointEvery {
+		if err := s.checkpointLocked(); err != nil {
+			return err
+		}
+	}
+	if e.Type == EventRunEnd || s.shouldPeriodicFlushLocked(time.Now().UTC()) {
+		return s.fsyncLocked()
+	}
+	return nil
+}
+
+// syncLocked reloads the chain head when another process appended to or
+// rotated the active file since this sink last wrote it.
+func (s *sink) syncLocked() error {
+	if s.file != nil {
+		open, err := s.file.Stat()
+		if err != nil {
+			return err
+		}
+		disk, err := os.Stat(s.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil && os.SameFile(open, disk) && disk.Size() == s.size {
+			return nil
+		}
+		if err := s.closeLocked(); err != nil {
+			return err
+		}
+	}
+	s.seq, s.head, s.firstSeq, s.firstAt = 0, "", 0, time.Time{}
+	if err := s.resumeLocked(); err != nil {
+		return err
+	}
+	return s.openLocked()
+}
+
+func (s *sink) writeLocked(e Event) error {
+	line, hash, err := s.chainLocked(&e)
+	if err != nil {
+		return err
+	}
+	if s.shouldRotateLocked(e.Timestamp, len(line)) {
+		if err := s.rotateLocked(e.Timestamp); err != nil {
+			return err
+		}
+		// Retention may have appended a record, so link to the new head.
+		if line, hash, err = s.chainLocked(&e); err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	if s.firstSeq == 0 {
+		s.firstSeq, s.firstAt = e.Seq, e.Timestamp
+	}
+	s.size += int64(len(line))
+	s.seq, s.head = e.Seq, hash
+	if e.Type == EventCheckpoint {
+		s.sinceMark = 0
+	} else {
+		s.sinceMark++
+	}
+	if len(s.opts.Exporters) > 0 {
+		e.Hash = hash
+		for _, exporter := range s.opts.Exporters {
+			exporter.Export(e)
+		}
+	}
+	return nil
+}
+
+func (s *sink) chainLocked(e *Event) ([]byte, string, error) {
+	e.Seq = s.seq + 1
+	e.PrevHash = s.head
+	return chainLine(*e)
+}
+
+func (s *sink) shouldRotateLocked(now time.Time, lineBytes int) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.opts.MaxFileBytes > 0 && s.size+int64(lineBytes) > s.opts.MaxFileBytes {
+		return true
+	}
+	return s.opts.MaxFileAge > 0 && !s.firstAt.IsZero() && now.Sub(s.firstAt) >= s.opts.MaxFileAge
+}
+
+func (s *sink) checkpointLocked() error {
+	signature := ed25519.Sign(s.opts.Signer, checkpointMessage(s.seq, s.head))
+	return s.writeLocked(Event{
+		Type:      EventCheckpoint,
+		Timestamp: time.Now().UTC(),
+		Payload: map[string]any{
+			"head_seq":  s.seq,
+			"head":      s.head,
+			"key_id":    KeyID(s.opts.Signer.Public().(ed25519.PublicKey)),
+			"signature": base64.StdEncoding.EncodeToString(signature),
+		},
+	})
+}
+
+func (s *sink) shouldPeriodicFlushLocked(now time.Time) bool {
+	if s.flushInterval <= 0 {
+		return false
+	}
+	if s.lastFlushAt.IsZero() {
+		return true
+	}
+	return now.Sub(s.lastFlushAt) >= s.flushInterval
+}
+
+func (s *sink) fsyncLocked() error {
+	if s.file != nil {
+		if err := s.file.Sync(); err != nil {
+			return err
+		}
+	}
+	s.lastFlushAt = time.Now().UTC()
+	return nil
+}
+
+func (s *sink) closeLocked() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.fsyncLocked()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
+
+// chainLine renders e (without its hash) and appends the SHA-256 of exactly
+// those bytes as the final "hash" field, so verification can recompute it from
+// the line without re-encoding.
+func chainLine(e Event) ([]byte, string, error) {
+	e.Hash = ""
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	line := make([]byte, 0, len(body)+len(hashField)+len(hash)+3)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, hashField...)
+	line = append(line, hash...)
+	line = append(line, '"', '}', defaultLineBreak)
+	return line, hash, nil
+}
+
+// splitChainLine returns the hashed body of a chained line and its recorded
+// hash. ok is false for lines written before chaining existed.
+func splitChainLine(line []byte) (body []byte, hash string, ok bool) {
+	idx := bytes.LastIndex(line, []byte(hashField))
+	if idx < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, "", false
+	}
+	hash = string(line[idx+len(hashField) : len(line)-2])
+	body = append(append([]byte(nil), line[:idx]...), '}')
+	return body, hash, true
+}
+
+func checkpointMessage(seq uint64, head string) []byte {
+	return []byte(checkpointMessagePrefix + "\n" + strconv.FormatUint(seq, 10) + "\n" + head)
+}
+
+// KeyID is a short fingerprint of a checkpoint public key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,375 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter receives audit records after they are written. Export must not
+// block the audit writer.
+type Exporter interface {
+	Export(e Event)
+}
+
+const (
+	defaultExportQueue    = 10000
+	defaultExportAttempts = 3
+	syslogEnterpriseID    = "openclawssy@32473"
+)
+
+// ExportStats counts what a Forwarder delivered. Dropped events did not fit in
+// the queue; failed events were given up on after retries.
+type ExportStats struct {
+	Sent      uint64 `json:"sent"`
+	Dropped   uint64 `json:"dropped"`
+	Failed    uint64 `json:"failed"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Forwarder queues records in memory and delivers them in batches from a
+// background goroutine, so a slow or unreachable collector never stalls runs.
+type Forwarder struct {
+	name      string
+	queue     chan Event
+	batchSize int
+	interval  time.Duration
+	send      func(context.Context, []Event) error
+	timeout   time.Duration
+	flushes   chan chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	sent, dropped, failed atomic.Uint64
+	mu                    sync.Mutex
+	lastErr               string
+}
+
+func newForwarder(name string, batchSize int, interval, timeout time.Duration, send func(context.Context, []Event) error) *Forwarder {
+	f := &Forwarder{
+		name:      name,
+		queue:     make(chan Event, defaultExportQueue),
+		batchSize: batchSize,
+		interval:  interval,
+		send:      send,
+		timeout:   timeout,
+		flushes:   make(chan chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+func (f *Forwarder) Export(e Event) {
+	select {
+	case f.queue <- e:
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+// Flush delivers everything queued so far, waiting until ctx is done.
+func (f *Forwarder) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case f.flushes <- ack:
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close delivers queued records and stops the forwarder.
+func (f *Forwarder) Close(ctx context.Context) error {
+	f.closeOnce.Do(func() { close(f.stop) })
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Forwarder) Name() string {
+	return f.name
+}
+
+func (f *Forwarder) Stats() ExportStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return ExportStats{Sent: f.sent.Load(), Dropped: f.dropped.Load(), Failed: f.failed.Load(), LastError: f.lastErr}
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	batch := make([]Event, 0, f.batchSize)
+	drain := func() {
+		for {
+			select {
+			case e := <-f.queue:
+				batch = append(batch, e)
+				if len(batch) >= f.batchSize {
+					batch = f.deliver(batch)
+				}
+			default:
+				batch = f.deliver(batch)
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case e := <-f.queue:
+			batch = append(batch, e)
+			if len(batch) >= f.batchSize {
+				batch = f.deliver(batch)
+			}
+		case <-ticker.C:
+			batch = f.deliver(batch)
+		case ack := <-f.flushes:
+			drain()
+			close(ack)
+		case <-f.stop:
+			drain()
+			return
+		}
+	}
+}
+
+func (f *Forwarder) deliver(batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+	var err error
+	for attempt := 0; attempt < defaultExportAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+		err = f.send(ctx, batch)
+		cancel()
+		if err == nil {
+			f.sent.Add(uint64(len(batch)))
+			return batch[:0]
+		}
+	}
+	f.failed.Add(uint64(len(batch)))
+	f.mu.Lock()
+	f.lastErr = err.Error()
+	f.mu.Unlock()
+	return batch[:0]
+}
+
+// SyslogConfig sends records as RFC 5424 messages. Network is "udp" or "tcp";
+// TCP uses octet-counting framing (RFC 6587).
+type SyslogConfig struct {
+	Network  string
+	Address  string
+	AppName  string
+	Hostname string
+	Facility int
+	Timeout  time.Duration
+}
+
+func NewSyslogExporter(cfg SyslogConfig) (*Forwarder, error) {
+	if cfg.Network != "udp" && cfg.Network != "tcp" {
+		return nil, fmt.Errorf("audit: syslog network must be udp or tcp, got %q", cfg.Network)
+	}
+	if strings.TrimSpace(cfg.Address) == "" {
+		return nil, errors.New("audit: syslog address is required")
+	}
+	if cfg.Facility < 0 || cfg.Facility > 23 {
+		return nil, fmt.Errorf("audit: syslog facility %d out of range 0..23", cfg.Facility)
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "openclawssy"
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	w := &syslogWriter{cfg: cfg, procID: strconv.Itoa(os.Getpid())}
+	return newForwarder("syslog", 100, time.Second, cfg.Timeout, w.send), nil
+}
+
+type syslogWriter struct {
+	cfg    SyslogConfig
+	procID string
+	conn   net.Conn
+}
+
+func (w *syslogWriter) send(ctx context.Context, batch []Event) error {
+	if w.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, w.cfg.Network, w.cfg.Address)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = w.conn.SetWriteDeadline(deadline)
+	}
+	for len(batch) > 0 {
+		msg, err := FormatSyslog(batch[0], w.cfg.Facility, w.cfg.Hostname, w.cfg.AppName, w.procID)
+		if err != nil {
+			return err
+		}
+		if w.cfg.Network == "tcp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := w.conn.Write(msg); err != nil {
+			_ = w.conn.Close()
+			w.conn = nil
+			return err
+		}
+		batch = batch[1:]
+	}
+	return nil
+}
+
+// FormatSyslog renders e as an RFC 5424 message: the event type is the
+// MSGID, chain and run identifiers are structured data, and the record's JSON
+// is the message.
+func FormatSyslog(e Event, facility int, hostname, appName, procID string) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var sd strings.Builder
+	sd.WriteString("[" + syslogEnterpriseID)
+	param := func(name, value string) {
+		if value != "" {
+			sd.WriteString(" " + name + `="` + escapeSDValue(value) + `"`)
+		}
+	}
+	if e.Seq > 0 {
+		param("seq", strconv.FormatUint(e.Seq, 10))
+	}
+	param("agent", e.AgentID)
+	param("run", e.RunID)
+	param("tool", e.Tool)
+	param("hash", e.Hash)
+	sd.WriteString("]")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s %s ",
+		facility*8+syslogSeverity(e.Type),
+		e.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(hostname, 255),
+		syslogField(appName, 48),
+		syslogField(procID, 128),
+		syslogField(e.Type, 32),
+		sd.String(),
+	)
+	b.Write(body)
+	return b.Bytes(), nil
+}
+
+func syslogSeverity(eventType string) int {
+	switch eventType {
+	case EventPolicyDeny, EventToolCallbackError:
+		return 4 // warning
+	case EventCheckpoint, EventRetention:
+		return 5 // notice
+	}
+	return 6 // informational
+}
+
+// syslogField keeps printable US-ASCII without spaces, as RFC 5424 header
+// fields require, and uses the nil value "-" when nothing is left.
+func syslogField(value string, max int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+		if b.Len() == max {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+func escapeSDValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// HTTPExportConfig posts batches as `{"events": [...]}` JSON to URL.
+type HTTPExportConfig struct {
+	URL           string
+	Headers       map[string]string
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+	Client        *http.Client
+}
+
+func NewHTTPExporter(cfg HTTPExportConfig) (*Forwarder, error) {
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("audit: http export url must be http(s), got %q", cfg.URL)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	send := func(ctx context.Context, batch []Event) error {
+		body, err := json.Marshal(map[string]any{"events": batch})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("audit: http export returned %s", resp.Status)
+		}
+		return nil
+	}
+	return newForwarder("http", cfg.BatchSize, cfg.FlushInterval, cfg.Timeout, send), nil
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFormatSyslogRFC5424(t *testing.T) {
+	e := Event{Seq: 7, Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC), Type: EventPolicyDeny, AgentID: "ops", RunID: `run"]1`, Tool: "shell.exec", Hash: "abc"}
+	msg, err := FormatSyslog(e, 13, "host one", "openclawssy", "42")
+	if err != nil {
+		t.Fatalf("format: %v", err)
+	}
+	want := `<108>1 2026-01-02T03:04:05.123456Z hostone openclawssy 42 policy.denied [openclawssy@32473 seq="7" agent="ops" run="run\"\]1" tool="shell.exec" hash="abc"] {`
+	if !strings.HasPrefix(string(msg), want) {
+		t.Fatalf("unexpected syslog message:\n%s\nwant prefix:\n%s", msg, want)
+	}
+}
+
+func TestSyslogExporterFramesTCPMessages(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan string, 4)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	exporter, err := NewSyslogExporter(SyslogConfig{Network: "tcp", Address: ln.Addr().String(), Facility: 13})
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	logger, err := NewLoggerWithOptions(path, nil, Options{Exporters: []Exporter{exporter}})
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	writeEvents(t, logger, 2)
+	_ = logger.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := exporter.Close(ctx); err != nil {
+		t.Fatalf("close exporter: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		select {
+		case msg := <-received:
+			if !strings.Contains(msg, ` tool.call [openclawssy@32473 seq="`+strconv.Itoa(i)+`"`) {
+				t.Fatalf("unexpected message %d: %s", i, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for message %d", i)
+		}
+	}
+	if stats := exporter.Stats(); stats.Sent != 2 || stats.Failed != 0 {
+		t.Fatalf("expected two sent records, got %+v", stats)
+	}
+}
+
+func TestHTTPExporterBatchesAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]Event
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer siem" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		batches = append(batches, body.Events)
+	}))
+	defer server.Close()
+
+	exporter, err := NewHTTPExporter(HTTPExportConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer siem"}, BatchSize: 3, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	logger, err := NewLoggerWithOptions(path, nil, Options{Exporters: []Exporter{exporter}})
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	writeEvents(t, logger, 4)
+	_ = logger.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := exporter.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 1 {
+		t.Fatalf("expected batches of 3 and 1, got %d batches", len(batches))
+	}
+	if batches[0][0].Seq != 1 || batches[0][0].Hash == "" {
+		t.Fatalf("expected chained records, got %+v", batches[0][0])
+	}
+	if stats := exporter.Stats(); stats.Sent != 4 || stats.Failed != 0 {
+		t.Fatalf("expected four sent records after retry, got %+v", stats)
+	}
+}
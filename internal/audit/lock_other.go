@@ -0,0 +1,14 @@
+//go:build !unix
+
+package audit
+
+import "os"
+
+// lockFile is a no-op where flock is unavailable. Loggers in one process still
+// serialize through their shared sink, but two processes appending to the same
+// log (a `run` next to `serve`) can interleave and fork the hash chain, which
+// verify then reports as a break. Run a single writer per audit file on these
+// platforms.
+func lockFile(_ *os.File) (func(), error) {
+	return func() {}, nil
+}
@@ -1,11 +1,7 @@
 package audit
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"os"
-	"path/filepath"
 	"sync"
 	"time"
 )
@@ -17,58 +13,65 @@ const (
 	EventToolResult        = "tool.result"
 	EventToolCallbackError = "tool.callback_error"
 	EventPolicyDeny        = "policy.denied"
+	EventSecretRead        = "secret.read"
+	EventSecretDenied      = "secret.denied"
+	EventMemoryReview      = "memory.review"
 	defaultFileMode        = 0o600
 	defaultDirMode         = 0o755
 	defaultLineBreak       = '\n'
 	defaultFlushInterval   = 2 * time.Second
 )
 
+// Event is one audit record. Seq, PrevHash and Hash link records into a
+// chain; Hash is always the last field so it can be stripped and recomputed.
 type Event struct {
+	Seq       uint64         `json:"seq,omitempty"`
+	PrevHash  string         `json:"prev_hash,omitempty"`
 	Timestamp time.Time      `json:"ts"`
 	Type      string         `json:"type"`
 	RunID     string         `json:"run_id,omitempty"`
 	AgentID   string         `json:"agent_id,omitempty"`
 	Tool      string         `json:"tool,omitempty"`
 	Payload   map[string]any `json:"payload,omitempty"`
+	Hash      string         `json:"hash,omitempty"`
 }
 
 type Logger struct {
-	path   string
-	redact func(any) any
-	mu     sync.Mutex
-	file   *os.File
-	writer *bufio.Writer
-
+	path          string
+	redact        func(any) any
+	opts          Options
 	flushInterval time.Duration
-	lastFlushAt   time.Time
+	mu            sync.Mutex
+	sink          *sink
 }
 
 func NewLogger(path string, redact func(any) any) (*Logger, error) {
-	return newLoggerWithFlushInterval(path, redact, defaultFlushInterval)
+	return NewLoggerWithOptions(path, redact, Options{})
+}
+
+// NewLoggerWithOptions opens a chained audit log with rotation and signed
+// checkpoints. Options only take effect for the first logger opened on a path;
+// later loggers share its writer until every logger is closed.
+func NewLoggerWithOptions(path string, redact func(any) any, opts Options) (*Logger, error) {
+	return newLogger(path, redact, opts, defaultFlushInterval)
 }
 
 func newLoggerWithFlushInterval(path string, redact func(any) any, flushInterval time.Duration) (*Logger, error) {
-	if err := os.MkdirAll(filepath.Dir(path), defaultDirMode); err != nil {
-		return nil, err
-	}
+	return newLogger(path, redact, Options{}, flushInterval)
+}
+
+func newLogger(path string, redact func(any) any, opts Options, flushInterval time.Duration) (*Logger, error) {
 	if redact == nil {
 		redact = func(v any) any { return v }
 	}
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, defaultFileMode)
-	if err != nil {
-		return nil, err
-	}
 	if flushInterval <= 0 {
 		flushInterval = defaultFlushInterval
 	}
-	return &Logger{
-		path:          path,
-		redact:        redact,
-		file:          f,
-		writer:        bufio.NewWriterSize(f, 32*1024),
-		flushInterval: flushInterval,
-		lastFlushAt:   time.Now().UTC(),
-	}, nil
+	s, err := acquireSink(path, opts, flushInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{path: path, redact: redact, opts: opts, flushInterval: flushInterval, sink: s}, nil
 }
 
 func (l *Logger) LogEvent(ctx context.Context, eventType string, fields map[string]any) error {
@@ -101,60 +104,16 @@ func (l *Logger) LogEvent(ctx context.Context, eventType string, fields map[stri
 		}
 	}
 
-	line, err := json.Marshal(e)
-	if err != nil {
-		return err
-	}
-	line = append(line, defaultLineBreak)
-
 	l.mu.Lock()
 	defer l.mu.Unlock()
-
-	if l.file == nil || l.writer == nil {
-		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, defaultFileMode)
+	if l.sink == nil {
+		s, err := acquireSink(l.path, l.opts, l.flushInterval)
 		if err != nil {
 			return err
 		}
-		l.file = f
-		l.writer = bufio.NewWriterSize(f, 32*1024)
-		l.lastFlushAt = time.Now().UTC()
+		l.sink = s
 	}
-
-	if _, err := l.writer.Write(line); err != nil {
-		return err
-	}
-	if eventType == EventRunEnd {
-		return l.flushLocked(true)
-	}
-	if l.shouldPeriodicFlushLocked(time.Now().UTC()) {
-		return l.flushLocked(true)
-	}
-	return nil
-}
-
-func (l *Logger) shouldPeriodicFlushLocked(now time.Time) bool {
-	if l.flushInterval <= 0 {
-		return false
-	}
-	if l.lastFlushAt.IsZero() {
-		return true
-	}
-	return now.Sub(l.lastFlushAt) >= l.flushInterval
-}
-
-func (l *Logger) flushLocked(syncDisk bool) error {
-	if l.writer != nil {
-		if err := l.writer.Flush(); err != nil {
-			return err
-		}
-	}
-	if syncDisk && l.file != nil {
-		if err := l.file.Sync(); err != nil {
-			return err
-		}
-	}
-	l.lastFlushAt = time.Now().UTC()
-	return nil
+	return l.sink.append(e)
 }
 
 func (l *Logger) Close() error {
@@ -162,18 +121,11 @@ func (l *Logger) Close() error {
 		return nil
 	}
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.file == nil {
+	s := l.sink
+	l.sink = nil
+	l.mu.Unlock()
+	if s == nil {
 		return nil
 	}
-	if err := l.flushLocked(true); err != nil {
-		_ = l.file.Close()
-		l.file = nil
-		l.writer = nil
-		return err
-	}
-	err := l.file.Close()
-	l.file = nil
-	l.writer = nil
-	return err
+	return s.release()
 }
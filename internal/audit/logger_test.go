@@ -99,12 +99,14 @@ func TestLoggerPeriodicFlush(t *testing.T) {
 	if err := logger.LogEvent(context.Background(), EventToolCall, map[string]any{"tool": "fs.list"}); err != nil {
 		t.Fatalf("log first event: %v", err)
 	}
+	// Lines reach the file before the cross-process lock is released so other
+	// writers link to them; only the fsync waits for the interval.
 	raw, err := os.ReadFile(path)
 	if err != nil {
 		t.Fatalf("read audit before flush: %v", err)
 	}
-	if strings.TrimSpace(string(raw)) != "" {
-		t.Fatalf("expected no flushed data yet, got %q", string(raw))
+	if lines := strings.Split(strings.TrimSpace(string(raw)), "\n"); len(lines) != 1 {
+		t.Fatalf("expected the first line to be written through, got %q", string(raw))
 	}
 
 	time.Sleep(10 * time.Millisecond)
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// Query filters audit records. Zero fields match everything. Types match an
+// event type exactly, or a family with a trailing `.*` (`approval.*`).
+// BeforeSeq pages backwards through one log.
+type Query struct {
+	Since     time.Time
+	Until     time.Time
+	RunID     string
+	Tool      string
+	Types     []string
+	BeforeSeq uint64
+	Limit     int
+}
+
+// EffectiveLimit is Limit clamped to 1..MaxQueryLimit, defaulting to
+// DefaultQueryLimit.
+func (q Query) EffectiveLimit() int {
+	if q.Limit <= 0 {
+		return DefaultQueryLimit
+	}
+	if q.Limit > MaxQueryLimit {
+		return MaxQueryLimit
+	}
+	return q.Limit
+}
+
+// Match reports whether e passes the filters.
+func (q Query) Match(e Event) bool {
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.RunID != "" && e.RunID != q.RunID {
+		return false
+	}
+	if q.Tool != "" && e.Tool != q.Tool {
+		return false
+	}
+	if q.BeforeSeq > 0 && (e.Seq == 0 || e.Seq >= q.BeforeSeq) {
+		return false
+	}
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, want := range q.Types {
+		if family, ok := strings.CutSuffix(want, ".*"); ok && strings.HasPrefix(e.Type, family+".") {
+			return true
+		}
+		if e.Type == want {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the newest records matching q from the audit log at path and
+// its rotated files, newest first. Rotated files last written before q.Since
+// are skipped without reading them.
+func Search(path string, q Query) ([]Event, error) {
+	files, err := chainFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.EffectiveLimit()
+	var matches []Event
+	for _, file := range files {
+		if !q.Since.IsZero() && file != path {
+			if info, err := os.Stat(file); err == nil && info.ModTime().Before(q.Since) {
+				continue
+			}
+		}
+		if err := scanFile(file, func(e Event) {
+			if !q.Match(e) {
+				return
+			}
+			matches = append(matches, e)
+			if len(matches) > 2*limit {
+				matches = append(matches[:0], matches[len(matches)-limit:]...)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if len(matches) > limit {
+		matches = matches[len(matches)-limit:]
+	}
+	for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+		matches[i], matches[j] = matches[j], matches[i]
+	}
+	return matches, nil
+}
+
+// scanFile calls fn for every parseable record, chained or legacy.
+func scanFile(path string, fn func(Event)) error {
+	r, err := openChainFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if rec, ok := parseChained(line); ok {
+			fn(rec)
+			continue
+		}
+		var rec Event
+		if err := json.Unmarshal(line, &rec); err == nil {
+			fn(rec)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,336 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	EventRetention = "audit.retention"
+
+	gzipSuffix   = ".gz"
+	prunedSuffix = ".pruned.json"
+)
+
+// Anchor records where the chain continues after retention deleted the oldest
+// rotated files. It lives next to the active file as `<name>.pruned.json` and
+// must match an `audit.retention` record in the remaining chain.
+type Anchor struct {
+	ThroughSeq  uint64    `json:"through_seq"`
+	ThroughHash string    `json:"through_hash"`
+	PrunedAt    time.Time `json:"pruned_at"`
+}
+
+func (s *sink) rotateLocked(now time.Time) error {
+	if err := s.closeLocked(); err != nil {
+		return err
+	}
+	rotated := rotatedName(s.path, s.firstSeq)
+	for _, existing := range []string{rotated, rotated + gzipSuffix} {
+		if _, err := os.Stat(existing); err == nil {
+			return fmt.Errorf("audit: rotated file %s already exists", existing)
+		}
+	}
+	if err := os.Rename(s.path, rotated); err != nil {
+		return err
+	}
+	if s.opts.Compress {
+		if err := compressFile(rotated); err != nil {
+			return err
+		}
+	}
+	s.firstSeq, s.firstAt = 0, time.Time{}
+	if err := s.openLocked(); err != nil {
+		return err
+	}
+	return s.pruneLocked(now)
+}
+
+// pruneLocked deletes the oldest rotated files past the retention limits. Only
+// a leading run of files is removed so the remaining chain stays contiguous;
+// the anchor is written before any file is deleted.
+func (s *sink) pruneLocked(now time.Time) error {
+	if s.opts.RetainFiles <= 0 && s.opts.RetainAge <= 0 {
+		return nil
+	}
+	files, err := chainFiles(s.path)
+	if err != nil {
+		return err
+	}
+	rotated := files
+	if len(rotated) > 0 && rotated[len(rotated)-1] == s.path {
+		rotated = rotated[:len(rotated)-1]
+	}
+	var remove []string
+	for i, file := range rotated {
+		excess := s.opts.RetainFiles > 0 && len(rotated)-i > s.opts.RetainFiles
+		expired := false
+		if s.opts.RetainAge > 0 {
+			info, err := os.Stat(file)
+			if err != nil {
+				return err
+			}
+			expired = now.Sub(info.ModTime()) > s.opts.RetainAge
+		}
+		if !excess && !expired {
+			break
+		}
+		remove = append(remove, file)
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+	last, _, err := tailRecord(remove[len(remove)-1])
+	if err != nil || last == nil {
+		return err
+	}
+	anchor := Anchor{ThroughSeq: last.Seq, ThroughHash: last.Hash, PrunedAt: now.UTC()}
+	if err := writeAnchor(anchorPath(s.path), anchor); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(remove))
+	for _, file := range remove {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		names = append(names, filepath.Base(file))
+	}
+	return s.writeLocked(Event{
+		Type:      EventRetention,
+		Timestamp: now.UTC(),
+		Payload: map[string]any{
+			"through_seq":  anchor.ThroughSeq,
+			"through_hash": anchor.ThroughHash,
+			"files":        names,
+		},
+	})
+}
+
+func rotatedName(path string, firstSeq uint64) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%012d%s", strings.TrimSuffix(path, ext), firstSeq, ext)
+}
+
+func anchorPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + prunedSuffix
+}
+
+func writeAnchor(path string, anchor Anchor) error {
+	raw, err := json.Marshal(anchor)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, defaultLineBreak), defaultFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readAnchor returns nil when no files were ever pruned.
+func readAnchor(path string) (*Anchor, error) {
+	raw, err := os.ReadFile(anchorPath(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var anchor Anchor
+	if err := json.Unmarshal(raw, &anchor); err != nil {
+		return nil, fmt.Errorf("audit: %s: %w", anchorPath(path), err)
+	}
+	return &anchor, nil
+}
+
+// compressFile gzips path to `<path>.gz` and removes the original once the
+// compressed copy is in place.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	gzw := gzip.NewWriter(tmp)
+	if _, err := io.Copy(gzw, src); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(defaultFileMode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path+gzipSuffix); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// openChainFile opens an audit file for reading, decompressing rotated
+// `.gz` files.
+func openChainFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipSuffix) {
+		return f, nil
+	}
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("audit: %s: %w", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gzr, f}, nil
+}
+
+// chainFiles lists rotated files in chain order followed by the active file.
+// When a crash left both a rotated file and its gzipped copy, the copy wins.
+func chainFiles(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	pattern := strings.TrimSuffix(path, ext) + "-*" + ext
+	plain, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := filepath.Glob(pattern + gzipSuffix)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]string, len(plain)+len(compressed))
+	for _, file := range plain {
+		byName[file] = file
+	}
+	for _, file := range compressed {
+		byName[strings.TrimSuffix(file, gzipSuffix)] = file
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		files = append(files, byName[name])
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// tailRecord returns the last and first chained records in path, reading
+// only the head and tail of large uncompressed files.
+func tailRecord(path string) (*Event, *Event, error) {
+	if strings.HasSuffix(path, gzipSuffix) {
+		return scanFirstLast(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var first *Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if rec, ok := parseChained(scanner.Bytes()); ok {
+			first = &rec
+			break
+		}
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	offset := info.Size() - tailReadBytes
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	tail, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines := bytes.Split(bytes.TrimRight(tail, "\n"), []byte{defaultLineBreak})
+	for i := len(lines) - 1; i >= 0; i-- {
+		if rec, ok := parseChained(lines[i]); ok {
+			return &rec, first, nil
+		}
+	}
+	return nil, first, nil
+}
+
+func scanFirstLast(path string) (*Event, *Event, error) {
+	r, err := openChainFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	var first, last *Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if rec, ok := parseChained(scanner.Bytes()); ok {
+			if first == nil {
+				first = &rec
+			}
+			last = &rec
+		}
+	}
+	return last, first, scanner.Err()
+}
+
+func parseChained(line []byte) (Event, bool) {
+	body, hash, ok := splitChainLine(bytes.TrimSpace(line))
+	if !ok {
+		return Event{}, false
+	}
+	var rec Event
+	if err := json.Unmarshal(body, &rec); err != nil || rec.Seq == 0 {
+		return Event{}, false
+	}
+	rec.Hash = hash
+	return rec, true
+}
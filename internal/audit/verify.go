@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Break describes the first record where the chain no longer holds.
+type Break struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Seq    uint64 `json:"seq,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Report summarizes a verified chain across the rotated files and the active
+// file of one audit log.
+type Report struct {
+	Files               []string `json:"files"`
+	Records             int      `json:"records"`
+	Legacy              int      `json:"legacy"`
+	Pruned              uint64   `json:"pruned,omitempty"`
+	LastSeq             uint64   `json:"last_seq"`
+	Head                string   `json:"head,omitempty"`
+	Checkpoints         int      `json:"checkpoints"`
+	VerifiedCheckpoints int      `json:"verified_checkpoints"`
+	LastCheckpointSeq   uint64   `json:"last_checkpoint_seq,omitempty"`
+	Break               *Break   `json:"break,omitempty"`
+}
+
+func (r Report) OK() bool {
+	return r.Break == nil
+}
+
+// Verify walks the chain of the audit log at path, including rotated files,
+// and stops at the first broken link. Lines written before chaining existed
+// are counted as legacy when they precede the first chained record. After
+// retention pruned the oldest files, the chain may start after the pruned
+// anchor, which an `audit.retention` record must confirm. With a public key,
+// checkpoint signatures are checked too; without one they are only counted.
+func Verify(path string, pub ed25519.PublicKey) (Report, error) {
+	files, err := chainFiles(path)
+	if err != nil {
+		return Report{}, err
+	}
+	anchor, err := readAnchor(path)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{Files: files}
+	state := verifyState{anchor: anchor}
+	for _, file := range files {
+		if err := verifyFile(file, pub, &report, &state); err != nil {
+			return report, err
+		}
+		if report.Break != nil {
+			return report, nil
+		}
+	}
+	if report.Pruned > 0 && !state.anchorConfirmed {
+		report.Break = &Break{File: anchorPath(path), Seq: report.Pruned, Reason: "pruned anchor is not confirmed by an audit.retention record"}
+	}
+	return report, nil
+}
+
+type verifyState struct {
+	anchor          *Anchor
+	anchorConfirmed bool
+}
+
+func verifyFile(path string, pub ed25519.PublicKey, report *Report, state *verifyState) error {
+	f, err := openChainFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	broken := func(line int, seq uint64, format string, args ...any) {
+		report.Break = &Break{File: path, Line: line, Seq: seq, Reason: fmt.Sprintf(format, args...)}
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		body, hash, chained := splitChainLine(raw)
+		if !chained {
+			if report.LastSeq == 0 && json.Valid(raw) {
+				report.Legacy++
+				continue
+			}
+			broken(lineNo, report.LastSeq+1, "record is not chained (missing hash)")
+			return nil
+		}
+		var rec Event
+		if err := json.Unmarshal(body, &rec); err != nil || rec.Seq == 0 {
+			broken(lineNo, report.LastSeq+1, "record is not valid chained JSON")
+			return nil
+		}
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != hash {
+			broken(lineNo, rec.Seq, "hash mismatch: record was modified")
+			return nil
+		}
+		if report.LastSeq == 0 && rec.Seq > 1 && state.anchor != nil && rec.Seq == state.anchor.ThroughSeq+1 {
+			report.Pruned = state.anchor.ThroughSeq
+			report.LastSeq, report.Head = state.anchor.ThroughSeq, state.anchor.ThroughHash
+		}
+		if rec.Seq != report.LastSeq+1 {
+			if report.LastSeq == 0 {
+				broken(lineNo, rec.Seq, "chain starts at seq %d, expected 1: earlier records are missing", rec.Seq)
+			} else {
+				broken(lineNo, rec.Seq, "seq %d follows %d: records are missing or reordered", rec.Seq, report.LastSeq)
+			}
+			return nil
+		}
+		if rec.PrevHash != report.Head {
+			broken(lineNo, rec.Seq, "prev_hash does not match the previous record")
+			return nil
+		}
+		if rec.Type == EventCheckpoint {
+			if reason := verifyCheckpoint(rec, report, pub); reason != "" {
+				broken(lineNo, rec.Seq, "%s", reason)
+				return nil
+			}
+		}
+		if rec.Type == EventRetention && state.anchor != nil {
+			throughSeq, _ := rec.Payload["through_seq"].(float64)
+			throughHash, _ := rec.Payload["through_hash"].(string)
+			if uint64(throughSeq) == state.anchor.ThroughSeq && throughHash == state.anchor.ThroughHash {
+				state.anchorConfirmed = true
+			}
+		}
+		report.Records++
+		report.LastSeq = rec.Seq
+		report.Head = hash
+	}
+	return scanner.Err()
+}
+
+func verifyCheckpoint(rec Event, report *Report, pub ed25519.PublicKey) string {
+	headSeq, _ := rec.Payload["head_seq"].(float64)
+	head, _ := rec.Payload["head"].(string)
+	if uint64(headSeq) != report.LastSeq || head != report.Head {
+		return "checkpoint does not cover the preceding record"
+	}
+	report.Checkpoints++
+	report.LastCheckpointSeq = rec.Seq
+	if pub == nil {
+		return ""
+	}
+	if keyID, _ := rec.Payload["key_id"].(string); keyID != KeyID(pub) {
+		return fmt.Sprintf("checkpoint key %q does not match the verification key %q", keyID, KeyID(pub))
+	}
+	encoded, _ := rec.Payload["signature"].(string)
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !ed25519.Verify(pub, checkpointMessage(report.LastSeq, report.Head), signature) {
+		return "checkpoint signature is invalid"
+	}
+	report.VerifiedCheckpoints++
+	return ""
+}
+
+// LoadOrCreateSigningKey reads a base64 Ed25519 seed from path, creating it
+// (mode 0600) together with `<path>.pub` when missing.
+func LoadOrCreateSigningKey(path string) (ed25519.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("audit: %s is not a base64 Ed25519 seed", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key.Seed())+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+".pub", []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0o644); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// LoadPublicKey reads a base64 Ed25519 public key, as written to `<key>.pub`.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("audit: %s is not a base64 Ed25519 public key", path)
+	}
+	return ed25519.PublicKey(pub), nil
+}
@@ -0,0 +1,263 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeEvents(t *testing.T, logger *Logger, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := logger.LogEvent(context.Background(), EventToolCall, map[string]any{"tool": "fs.read", "i": i, "note": strings.Repeat("x", 40)}); err != nil {
+			t.Fatalf("log event %d: %v", i, err)
+		}
+	}
+}
+
+func TestVerifyDetectsEditedAndDeletedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(path, []byte(`{"ts":"2024-01-01T00:00:00Z","type":"run.start"}`+"\n"), 0o600); err != nil {
+		t.Fatalf("write legacy line: %v", err)
+	}
+	logger, err := NewLogger(path, nil)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	writeEvents(t, logger, 5)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	report, err := Verify(path, nil)
+	if err != nil || !report.OK() || report.Records != 5 || report.Legacy != 1 || report.LastSeq != 5 {
+		t.Fatalf("expected intact chain, got %+v %v", report, err)
+	}
+
+	raw, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	edited := append([]string(nil), lines...)
+	edited[3] = strings.Replace(edited[3], `"i":2`, `"i":9`, 1)
+	if err := os.WriteFile(path, []byte(strings.Join(edited, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("write edited: %v", err)
+	}
+	report, _ = Verify(path, nil)
+	if report.OK() || report.Break.Line != 4 || !strings.Contains(report.Break.Reason, "hash mismatch") {
+		t.Fatalf("expected hash mismatch on line 4, got %+v", report.Break)
+	}
+
+	deleted := append(append([]string(nil), lines[:2]...), lines[3:]...)
+	if err := os.WriteFile(path, []byte(strings.Join(deleted, "\n")+"\n"), 0o600); err != nil {
+		t.Fatalf("write deleted: %v", err)
+	}
+	report, _ = Verify(path, nil)
+	if report.OK() || report.Break.Seq != 3 || !strings.Contains(report.Break.Reason, "missing or reordered") {
+		t.Fatalf("expected gap at seq 3, got %+v", report.Break)
+	}
+}
+
+func TestRotationKeepsChainAcrossFilesAndRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	opts := Options{MaxFileBytes: 1024}
+	logger, err := NewLoggerWithOptions(path, nil, opts)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	writeEvents(t, logger, 20)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	logger, err = NewLoggerWithOptions(path, nil, opts)
+	if err != nil {
+		t.Fatalf("reopen logger: %v", err)
+	}
+	writeEvents(t, logger, 5)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	report, err := Verify(path, nil)
+	if err != nil || !report.OK() {
+		t.Fatalf("expected chain intact across rotation, got %+v %v", report.Break, err)
+	}
+	if report.LastSeq != 25 || len(report.Files) < 3 {
+		t.Fatalf("expected 25 records over several files, got %+v", report)
+	}
+
+	if err := os.Remove(report.Files[0]); err != nil {
+		t.Fatalf("remove oldest: %v", err)
+	}
+	report, _ = Verify(path, nil)
+	if report.OK() || !strings.Contains(report.Break.Reason, "earlier records are missing") {
+		t.Fatalf("expected missing head to be reported, got %+v", report.Break)
+	}
+}
+
+func TestSignedCheckpointsVerifyWithKey(t *testing.T) {
+	dir := t.TempDir()
+	key, err := LoadOrCreateSigningKey(filepath.Join(dir, "keys", "audit.key"))
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	pub, err := LoadPublicKey(filepath.Join(dir, "keys", "audit.key.pub"))
+	if err != nil {
+		t.Fatalf("load public key: %v", err)
+	}
+	path := filepath.Join(dir, "events.jsonl")
+	logger, err := NewLoggerWithOptions(path, nil, Options{CheckpointEvery: 3, Signer: key})
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	writeEvents(t, logger, 7)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	report, err := Verify(path, pub)
+	if err != nil || !report.OK() || report.Checkpoints != 2 || report.VerifiedCheckpoints != 2 {
+		t.Fatalf("expected two verified checkpoints, got %+v %v", report, err)
+	}
+	if report.LastSeq-report.LastCheckpointSeq != 1 {
+		t.Fatalf("expected one record after the last checkpoint, got %+v", report)
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	report, _ = Verify(path, other)
+	if report.OK() || !strings.Contains(report.Break.Reason, "does not match the verification key") {
+		t.Fatalf("expected wrong key to fail, got %+v", report.Break)
+	}
+}
+
+func TestLoggersOnSamePathShareChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	first, err := NewLogger(path, nil)
+	if err != nil {
+		t.Fatalf("first logger: %v", err)
+	}
+	second, err := NewLogger(path, nil)
+	if err != nil {
+		t.Fatalf("second logger: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		writeEvents(t, first, 1)
+		writeEvents(t, second, 1)
+	}
+	_ = first.Close()
+	writeEvents(t, second, 1)
+	_ = second.Close()
+
+	report, err := Verify(path, nil)
+	if err != nil || !report.OK() || report.LastSeq != 9 {
+		t.Fatalf("expected shared chain of 9 records, got %+v %v", report, err)
+	}
+}
+
+func TestRetentionPrunesCompressedFilesAndKeepsChainVerifiable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	logger, err := NewLoggerWithOptions(path, nil, Options{MaxFileBytes: 1024, Compress: true, RetainFiles: 2})
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	writeEvents(t, logger, 40)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	report, err := Verify(path, nil)
+	if err != nil || !report.OK() {
+		t.Fatalf("expected pruned chain to verify, got %+v %v", report.Break, err)
+	}
+	if report.Pruned == 0 || len(report.Files) != 3 {
+		t.Fatalf("expected two retained rotated files and pruned records, got %+v", report)
+	}
+	for _, file := range report.Files[:2] {
+		if !strings.HasSuffix(file, ".jsonl.gz") {
+			t.Fatalf("expected rotated files to be gzipped, got %v", report.Files)
+		}
+	}
+
+	events, err := Search(path, Query{Types: []string{EventRetention}, Limit: 1})
+	if err != nil || len(events) != 1 || events[0].Payload["through_seq"].(float64) > float64(report.Pruned) {
+		t.Fatalf("expected newest retention record, got %+v %v", events, err)
+	}
+
+	if err := os.WriteFile(anchorPath(path), []byte(`{"through_seq":1,"through_hash":"x"}`), 0o600); err != nil {
+		t.Fatalf("write anchor: %v", err)
+	}
+	report, _ = Verify(path, nil)
+	if report.OK() {
+		t.Fatal("expected a forged anchor to break verification")
+	}
+}
+
+func TestMaxFileAgeRotatesAndSearchReadsAllFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	logger, err := NewLoggerWithOptions(path, nil, Options{MaxFileAge: time.Nanosecond, Compress: true})
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	for i, tool := range []string{"fs.read", "shell.exec", "fs.read", "fs.write"} {
+		if err := logger.LogEvent(context.Background(), EventToolCall, map[string]any{"run_id": "r1", "tool": tool, "i": i}); err != nil {
+			t.Fatalf("log event: %v", err)
+		}
+	}
+	_ = logger.Close()
+
+	report, err := Verify(path, nil)
+	if err != nil || !report.OK() || len(report.Files) != 4 {
+		t.Fatalf("expected one file per record, got %+v %v", report, err)
+	}
+	events, err := Search(path, Query{Tool: "fs.read", RunID: "r1"})
+	if err != nil || len(events) != 2 || events[0].Seq != 3 || events[1].Seq != 1 {
+		t.Fatalf("expected fs.read records newest first, got %+v %v", events, err)
+	}
+	events, _ = Search(path, Query{BeforeSeq: 3, Limit: 1})
+	if len(events) != 1 || events[0].Seq != 2 {
+		t.Fatalf("expected paging before seq 3, got %+v", events)
+	}
+}
+
+func TestSeparateWritersKeepOneChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	opts := Options{MaxFileBytes: 1024}
+	first, err := NewLoggerWithOptions(path, nil, opts)
+	if err != nil {
+		t.Fatalf("new logger: %v", err)
+	}
+	// A second process gets its own sink; forget the shared one to get the
+	// same here.
+	sinks.mu.Lock()
+	delete(sinks.byPath, first.sink.path)
+	sinks.mu.Unlock()
+	second, err := NewLoggerWithOptions(path, nil, opts)
+	if err != nil {
+		t.Fatalf("second logger: %v", err)
+	}
+	if first.sink == second.sink {
+		t.Fatal("expected independent writers")
+	}
+	for i := 0; i < 10; i++ {
+		writeEvents(t, first, 1)
+		writeEvents(t, second, 2)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("close first: %v", err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("close second: %v", err)
+	}
+
+	report, err := Verify(path, nil)
+	if err != nil || !report.OK() || report.Records != 30 || report.LastSeq != 30 {
+		t.Fatalf("expected one intact chain across writers, got %+v %v", report, err)
+	}
+	if files, _ := chainFiles(path); len(files) < 2 {
+		t.Fatalf("expected rotation during the test, got %v", files)
+	}
+}
@@ -0,0 +1,195 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	inboxDirName  = "inbox"
+	outboxDirName = "outbox"
+	outboxSentDir = "sent"
+)
+
+var ErrAttachmentTooLarge = errors.New("chat attachment exceeds size limit")
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Open        func(ctx context.Context) (io.ReadCloser, error)
+}
+
+type OutboundFile struct {
+	Name string
+	Path string
+	Size int64
+}
+
+func InboxPath(source, sessionID string) string {
+	return filepath.ToSlash(filepath.Join(inboxDirName, source, sessionID))
+}
+
+func OutboxPath(source, sessionID string) string {
+	return filepath.ToSlash(filepath.Join(outboxDirName, source, sessionID))
+}
+
+func (c *Connector) saveAttachments(ctx context.Context, source, sessionID string, attachments []Attachment) ([]string, error) {
+	if strings.TrimSpace(c.WorkspaceDir) == "" {
+		return nil, errors.New("chat workspace is not configured for attachments")
+	}
+	rel := InboxPath(source, sessionID)
+	dir := filepath.Join(c.WorkspaceDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("chat: create inbox: %w", err)
+	}
+	lines := make([]string, 0, len(attachments))
+	for _, att := range attachments {
+		if att.Open == nil {
+			continue
+		}
+		name, err := saveAttachment(ctx, dir, att, c.MaxAttachmentBytes)
+		if err != nil {
+			return nil, fmt.Errorf("chat: save attachment %q: %w", att.Name, err)
+		}
+		line := "- " + rel + "/" + name
+		meta := []string{}
+		if ct := strings.TrimSpace(att.ContentType); ct != "" {
+			meta = append(meta, ct)
+		}
+		if att.Size > 0 {
+			meta = append(meta, formatBytes(att.Size))
+		}
+		if len(meta) > 0 {
+			line += " (" + strings.Join(meta, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func saveAttachment(ctx context.Context, dir string, att Attachment, maxBytes int64) (string, error) {
+	if maxBytes > 0 && att.Size > maxBytes {
+		return "", ErrAttachmentTooLarge
+	}
+	src, err := att.Open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	name := uniqueFileName(dir, sanitizeFileName(att.Name))
+	path := filepath.Join(dir, name)
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	var r io.Reader = src
+	if maxBytes > 0 {
+		r = io.LimitReader(src, maxBytes+1)
+	}
+	written, err := io.Copy(dst, r)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && maxBytes > 0 && written > maxBytes {
+		err = ErrAttachmentTooLarge
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return name, nil
+}
+
+func (c *Connector) TakeOutbox(source, sessionID string) ([]OutboundFile, error) {
+	if strings.TrimSpace(c.WorkspaceDir) == "" || strings.TrimSpace(sessionID) == "" {
+		return nil, nil
+	}
+	dir := filepath.Join(c.WorkspaceDir, filepath.FromSlash(OutboxPath(source, sessionID)))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sentDir := filepath.Join(dir, outboxSentDir)
+	out := []OutboundFile{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return out, err
+		}
+		if err := os.MkdirAll(sentDir, 0o755); err != nil {
+			return out, err
+		}
+		target := filepath.Join(sentDir, uniqueFileName(sentDir, entry.Name()))
+		if err := os.Rename(filepath.Join(dir, entry.Name()), target); err != nil {
+			return out, err
+		}
+		out = append(out, OutboundFile{Name: entry.Name(), Path: target, Size: info.Size()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	clean := strings.TrimLeft(b.String(), ".")
+	if clean == "" {
+		clean = "attachment"
+	}
+	if len(clean) > 120 {
+		ext := filepath.Ext(clean)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		clean = clean[:120-len(ext)] + ext
+	}
+	return clean
+}
+
+func uniqueFileName(dir, name string) string {
+	if _, err := os.Lstat(filepath.Join(dir, name)); errors.Is(err, os.ErrNotExist) {
+		return name
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := base + "-" + strconv.Itoa(i) + ext
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
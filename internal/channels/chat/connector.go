@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"openclawssy/internal/chatstore"
 )
@@ -22,6 +23,8 @@ type Message struct {
 	Source       string
 	Text         string
 	ThinkingMode string
+	Attachments  []Attachment
+	Outbox       bool
 }
 
 type Result struct {
@@ -38,6 +41,14 @@ type QueuedRun struct {
 
 type QueueFunc func(ctx context.Context, agentID, message, source, sessionID, thinkingMode string) (QueuedRun, error)
 
+type CancelFunc func(ctx context.Context, runID string) error
+
+// ApproveFunc resolves a tool call paused for approval. sessionID restricts the
+// decision to calls raised in that chat session. Chat decisions never store
+// remembered rules: the chat user is usually the one who asked for the call,
+// and a rule outlives the session.
+type ApproveFunc func(ctx context.Context, approvalID, sessionID, approver string, approved bool, reason string) error
+
 type Connector struct {
 	Allowlist      *Allowlist
 	RateLimiter    *RateLimiter
@@ -46,6 +57,14 @@ type Connector struct {
 	DefaultAgentID string
 	Store          *chatstore.Store
 	HistoryLimit   int
+	Cancel         CancelFunc
+	Approve        ApproveFunc
+
+	WorkspaceDir       string
+	MaxAttachmentBytes int64
+
+	lastRunMu sync.Mutex
+	lastRuns  map[string]string
 }
 
 func (c *Connector) HandleMessage(ctx context.Context, msg Message) (Result, error) {
@@ -55,7 +74,7 @@ func (c *Connector) HandleMessage(ctx context.Context, msg Message) (Result, err
 	if c.Store == nil {
 		return Result{}, errors.New("chat store is not configured")
 	}
-	if strings.TrimSpace(msg.UserID) == "" || strings.TrimSpace(msg.Text) == "" {
+	if strings.TrimSpace(msg.UserID) == "" || (strings.TrimSpace(msg.Text) == "" && len(msg.Attachments) == 0) {
 		return Result{}, errors.New("user id and text are required")
 	}
 	if c.Allowlist != nil && !c.Allowlist.MessageAllowed(msg.UserID, msg.RoomID) {
@@ -202,19 +221,46 @@ func (c *Connector) HandleMessage(ctx context.Context, msg Message) (Result, err
 		return Result{Response: strings.Join(lines, "\n")}, nil
 	}
 
+	if text == "/cancel" || strings.HasPrefix(text, "/cancel ") {
+		return c.handleCancel(ctx, agentID, source, msg.UserID, roomID, text)
+	}
+
+	if text == "/approve" || strings.HasPrefix(text, "/approve ") || text == "/deny" || strings.HasPrefix(text, "/deny ") {
+		return c.handleApproval(ctx, agentID, source, msg.UserID, roomID, text)
+	}
+
 	session, err := c.resolveOrCreateActiveSession(agentID, source, msg.UserID, roomID)
 	if err != nil {
 		return Result{}, err
 	}
 
-	if err := c.Store.AppendMessage(session.SessionID, chatstore.Message{Role: "user", Content: msg.Text}); err != nil {
+	content := msg.Text
+	if len(msg.Attachments) > 0 {
+		lines, err := c.saveAttachments(ctx, source, session.SessionID, msg.Attachments)
+		if err != nil {
+			return Result{}, err
+		}
+		if strings.TrimSpace(content) == "" {
+			content = "Please review the attached files."
+		}
+		if len(lines) > 0 {
+			content += "\n\nAttached files (saved in the workspace):\n" + strings.Join(lines, "\n")
+		}
+	}
+
+	if msg.Outbox && strings.TrimSpace(c.WorkspaceDir) != "" {
+		content += "\n\n(To send files back in this chat, write them under " + OutboxPath(source, session.SessionID) + "/.)"
+	}
+
+	if err := c.Store.AppendMessage(session.SessionID, chatstore.Message{Role: "user", Content: content}); err != nil {
 		return Result{}, err
 	}
 
-	queued, err := c.Queue(ctx, agentID, msg.Text, source, session.SessionID, msg.ThinkingMode)
+	queued, err := c.Queue(ctx, agentID, content, source, session.SessionID, msg.ThinkingMode)
 	if err != nil {
 		return Result{}, err
 	}
+	c.rememberRun(session.SessionID, queued.ID)
 
 	return Result{
 		ID:        queued.ID,
@@ -224,6 +270,81 @@ func (c *Connector) HandleMessage(ctx context.Context, msg Message) (Result, err
 	}, nil
 }
 
+func (c *Connector) handleCancel(ctx context.Context, agentID, source, userID, roomID, text string) (Result, error) {
+	if c.Cancel == nil {
+		return Result{Response: "Cancel is not supported in this chat"}, nil
+	}
+	parts := strings.Fields(text)
+	if len(parts) > 2 {
+		return Result{Response: "Usage: /cancel [run_id]"}, nil
+	}
+	sessionID := ""
+	if activeID, err := c.Store.GetActiveSessionPointer(agentID, source, userID, roomID); err == nil {
+		sessionID = activeID
+	}
+	runID := ""
+	if len(parts) == 2 {
+		runID = strings.TrimSpace(parts[1])
+	} else {
+		runID = c.lastRun(sessionID)
+	}
+	if runID == "" {
+		return Result{Response: "No active run to cancel"}, nil
+	}
+	if err := c.Cancel(ctx, runID); err != nil {
+		return Result{Response: "Run " + runID + " is not running"}, nil
+	}
+	return Result{ID: runID, Status: "canceling", SessionID: sessionID, Response: "Cancel requested for run " + runID}, nil
+}
+
+func (c *Connector) handleApproval(ctx context.Context, agentID, source, userID, roomID, text string) (Result, error) {
+	if c.Approve == nil {
+		return Result{Response: "Approvals are not enabled in this chat"}, nil
+	}
+	parts := strings.Fields(text)
+	approved := parts[0] == "/approve"
+	if approved && len(parts) == 3 && parts[2] == "remember" {
+		return Result{Response: "Remembered approvals can only be created from the dashboard or the admin API"}, nil
+	}
+	if len(parts) < 2 || (approved && len(parts) > 2) {
+		return Result{Response: "Usage: /approve <approval_id> or /deny <approval_id> [reason]"}, nil
+	}
+	approvalID := parts[1]
+	reason := ""
+	if !approved {
+		reason = strings.Join(parts[2:], " ")
+	}
+	sessionID, err := c.Store.GetActiveSessionPointer(agentID, source, userID, roomID)
+	if err != nil || strings.TrimSpace(sessionID) == "" {
+		return Result{Response: "No active chat to approve for"}, nil
+	}
+	if err := c.Approve(ctx, approvalID, sessionID, source+":"+userID, approved, reason); err != nil {
+		return Result{Response: "Approval " + approvalID + " is not pending in this chat"}, nil
+	}
+	if !approved {
+		return Result{ID: approvalID, Status: "denied", SessionID: sessionID, Response: "Denied " + approvalID}, nil
+	}
+	return Result{ID: approvalID, Status: "approved", SessionID: sessionID, Response: "Approved " + approvalID}, nil
+}
+
+func (c *Connector) rememberRun(sessionID, runID string) {
+	if strings.TrimSpace(runID) == "" {
+		return
+	}
+	c.lastRunMu.Lock()
+	defer c.lastRunMu.Unlock()
+	if c.lastRuns == nil {
+		c.lastRuns = make(map[string]string)
+	}
+	c.lastRuns[sessionID] = runID
+}
+
+func (c *Connector) lastRun(sessionID string) string {
+	c.lastRunMu.Lock()
+	defer c.lastRunMu.Unlock()
+	return c.lastRuns[sessionID]
+}
+
 func queuedStatusMessage(runID, status string) string {
 	runID = strings.TrimSpace(runID)
 	status = strings.TrimSpace(status)
@@ -3,6 +3,8 @@ package chat
 import (
 	"context"
 	"errors"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -321,3 +323,176 @@ func TestConnectorAgentSwitchCommand(t *testing.T) {
 		t.Fatalf("expected queued agent alpha, got %q", queuedAgent)
 	}
 }
+
+func TestConnectorCancelTargetsLatestRunInChat(t *testing.T) {
+	store, err := chatstore.NewStore(filepath.Join(t.TempDir(), ".openclawssy", "agents"))
+	if err != nil {
+		t.Fatalf("new chat store: %v", err)
+	}
+	canceled := []string{}
+	connector := &Connector{
+		DefaultAgentID: "default",
+		Store:          store,
+		Queue: func(ctx context.Context, agentID, message, source, sessionID, thinkingMode string) (QueuedRun, error) {
+			return QueuedRun{ID: "run-" + message, Status: "queued"}, nil
+		},
+		Cancel: func(ctx context.Context, runID string) error {
+			if runID == "run-missing" {
+				return errors.New("not running")
+			}
+			canceled = append(canceled, runID)
+			return nil
+		},
+	}
+
+	res, err := connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/cancel"})
+	if err != nil || res.Response != "No active run to cancel" {
+		t.Fatalf("expected no-run response, got %+v err=%v", res, err)
+	}
+	if _, err := connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "one"}); err != nil {
+		t.Fatalf("queue first: %v", err)
+	}
+	if _, err := connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "two"}); err != nil {
+		t.Fatalf("queue second: %v", err)
+	}
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/cancel"})
+	if err != nil || res.ID != "run-two" || !strings.Contains(res.Response, "run-two") {
+		t.Fatalf("expected cancel of latest run, got %+v err=%v", res, err)
+	}
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/cancel run-missing"})
+	if err != nil || res.Response != "Run run-missing is not running" {
+		t.Fatalf("expected not-running response, got %+v err=%v", res, err)
+	}
+	if len(canceled) != 1 || canceled[0] != "run-two" {
+		t.Fatalf("unexpected cancel calls: %#v", canceled)
+	}
+}
+
+func TestConnectorApproveAndDenyUseActiveSession(t *testing.T) {
+	store, err := chatstore.NewStore(filepath.Join(t.TempDir(), ".openclawssy", "agents"))
+	if err != nil {
+		t.Fatalf("new chat store: %v", err)
+	}
+	type call struct {
+		id, sessionID, approver, reason string
+		approved                        bool
+	}
+	var calls []call
+	connector := &Connector{
+		DefaultAgentID: "default",
+		Store:          store,
+		Queue: func(ctx context.Context, agentID, message, source, sessionID, thinkingMode string) (QueuedRun, error) {
+			return QueuedRun{ID: "run-1", Status: "queued"}, nil
+		},
+		Approve: func(ctx context.Context, approvalID, sessionID, approver string, approved bool, reason string) error {
+			if approvalID == "apr_other" {
+				return errors.New("belongs to another session")
+			}
+			calls = append(calls, call{approvalID, sessionID, approver, reason, approved})
+			return nil
+		},
+	}
+
+	res, err := connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/approve apr_1"})
+	if err != nil || res.Response != "No active chat to approve for" {
+		t.Fatalf("expected no-session response, got %+v err=%v", res, err)
+	}
+	queued, err := connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "delete the build"})
+	if err != nil {
+		t.Fatalf("queue: %v", err)
+	}
+
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/approve apr_1 remember"})
+	if err != nil || res.Status != "" || !strings.Contains(res.Response, "dashboard") {
+		t.Fatalf("expected remember to be refused in chat, got %+v err=%v", res, err)
+	}
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/approve apr_1"})
+	if err != nil || res.Status != "approved" {
+		t.Fatalf("expected approval, got %+v err=%v", res, err)
+	}
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/deny apr_2 wrong branch"})
+	if err != nil || res.Status != "denied" {
+		t.Fatalf("expected denial, got %+v err=%v", res, err)
+	}
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/approve apr_other"})
+	if err != nil || res.Response != "Approval apr_other is not pending in this chat" {
+		t.Fatalf("expected not-pending response, got %+v err=%v", res, err)
+	}
+	res, err = connector.HandleMessage(context.Background(), Message{UserID: "u1", RoomID: "r1", Source: "discord", Text: "/approve apr_1 always"})
+	if err != nil || !strings.HasPrefix(res.Response, "Usage:") {
+		t.Fatalf("expected usage response, got %+v err=%v", res, err)
+	}
+
+	want := []call{
+		{"apr_1", queued.SessionID, "discord:u1", "", true},
+		{"apr_2", queued.SessionID, "discord:u1", "wrong branch", false},
+	}
+	if len(calls) != len(want) || calls[0] != want[0] || calls[1] != want[1] {
+		t.Fatalf("unexpected approve calls: %+v", calls)
+	}
+}
+
+func TestConnectorSavesAttachmentsToSessionInboxAndDrainsOutbox(t *testing.T) {
+	workspace := t.TempDir()
+	store, err := chatstore.NewStore(filepath.Join(t.TempDir(), ".openclawssy", "agents"))
+	if err != nil {
+		t.Fatalf("new chat store: %v", err)
+	}
+	var queuedMessage string
+	connector := &Connector{
+		DefaultAgentID:     "default",
+		Store:              store,
+		WorkspaceDir:       workspace,
+		MaxAttachmentBytes: 16,
+		Queue: func(ctx context.Context, agentID, message, source, sessionID, thinkingMode string) (QueuedRun, error) {
+			queuedMessage = message
+			return QueuedRun{ID: "run-1", Status: "queued"}, nil
+		},
+	}
+	open := func(body string) func(context.Context) (io.ReadCloser, error) {
+		return func(context.Context) (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(body)), nil }
+	}
+
+	res, err := connector.HandleMessage(context.Background(), Message{
+		UserID: "u1", RoomID: "t1", Source: "discord", Outbox: true,
+		Attachments: []Attachment{{Name: "../error log.txt", ContentType: "text/plain", Size: 5, Open: open("boom\n")}},
+	})
+	if err != nil {
+		t.Fatalf("handle message: %v", err)
+	}
+	inbox := InboxPath("discord", res.SessionID)
+	raw, err := os.ReadFile(filepath.Join(workspace, inbox, "error_log.txt"))
+	if err != nil || string(raw) != "boom\n" {
+		t.Fatalf("expected attachment saved in session inbox, got %q err=%v", raw, err)
+	}
+	for _, want := range []string{"Please review the attached files.", "- " + inbox + "/error_log.txt (text/plain, 5 B)", OutboxPath("discord", res.SessionID) + "/"} {
+		if !strings.Contains(queuedMessage, want) {
+			t.Fatalf("expected queued message to contain %q, got %q", want, queuedMessage)
+		}
+	}
+
+	if _, err := connector.HandleMessage(context.Background(), Message{
+		UserID: "u1", RoomID: "t1", Source: "discord", Text: "too big",
+		Attachments: []Attachment{{Name: "big.bin", Open: open(strings.Repeat("x", 32))}},
+	}); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+
+	outbox := filepath.Join(workspace, OutboxPath("discord", res.SessionID))
+	if err := os.MkdirAll(outbox, 0o755); err != nil {
+		t.Fatalf("mkdir outbox: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outbox, "report.md"), []byte("# report"), 0o644); err != nil {
+		t.Fatalf("write outbox file: %v", err)
+	}
+	files, err := connector.TakeOutbox("discord", res.SessionID)
+	if err != nil || len(files) != 1 || files[0].Name != "report.md" || files[0].Size != 8 {
+		t.Fatalf("unexpected outbox files: %#v err=%v", files, err)
+	}
+	if _, err := os.Stat(files[0].Path); err != nil {
+		t.Fatalf("expected sent copy at %s: %v", files[0].Path, err)
+	}
+	if again, _ := connector.TakeOutbox("discord", res.SessionID); len(again) != 0 {
+		t.Fatalf("expected outbox to be drained, got %#v", again)
+	}
+}
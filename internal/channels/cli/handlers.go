@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -45,6 +46,38 @@ type ServeInput struct {
 	JobsFile string
 }
 
+type MCPServeInput struct {
+	Transport string
+	Addr      string
+	Token     string
+	AgentID   string
+	Tools     []string
+}
+
+type PolicyCheckInput struct {
+	File     string
+	AgentID  string
+	Tool     string
+	Args     map[string]any
+	JSON     bool
+	Fixtures []string
+}
+
+type AuditVerifyInput struct {
+	AgentIDs  []string
+	PublicKey string
+	JSON      bool
+}
+
+type SecretsInput struct {
+	Action  string
+	Name    string
+	Version int
+	Agents  []string
+	JSON    bool
+	With    string
+}
+
 type InitService interface {
 	Init(ctx context.Context, input InitInput) error
 }
@@ -229,6 +262,155 @@ func ParseServeArgs(args []string) (ServeInput, error) {
 	return input, nil
 }
 
+// ParseMCPServeArgs parses `mcp serve` flags. Empty AgentID and Tools mean the
+// mcp.serve config defaults apply.
+func ParseMCPServeArgs(args []string) (MCPServeInput, error) {
+	input := MCPServeInput{}
+	var toolList string
+	fs := flag.NewFlagSet("mcp serve", flag.ContinueOnError)
+	fs.StringVar(&input.Transport, "transport", "stdio", "transport: stdio|http")
+	fs.StringVar(&input.Addr, "addr", "127.0.0.1:8765", "listen address for http transport")
+	fs.StringVar(&input.Token, "token", "", "bearer token (required for http transport)")
+	fs.StringVar(&input.AgentID, "agent", "", "agent to expose (default from mcp.serve.agent_id)")
+	fs.StringVar(&toolList, "tools", "", "comma-separated tools to expose (default from mcp.serve.tools)")
+	if err := fs.Parse(args); err != nil {
+		return MCPServeInput{}, err
+	}
+
+	input.Transport = strings.ToLower(strings.TrimSpace(input.Transport))
+	switch input.Transport {
+	case "stdio":
+	case "http":
+		if input.Token == "" {
+			return MCPServeInput{}, errors.New("-token is required for http transport")
+		}
+	default:
+		return MCPServeInput{}, fmt.Errorf("unsupported -transport %q (want stdio or http)", input.Transport)
+	}
+	input.AgentID = strings.TrimSpace(input.AgentID)
+	for _, name := range strings.Split(toolList, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			input.Tools = append(input.Tools, name)
+		}
+	}
+	return input, nil
+}
+
+// ParsePolicyCheckArgs parses `policy check` flags. A call needs both -agent
+// and -tool; remaining arguments are fixture files. With neither, the check
+// only validates the policy.
+func ParsePolicyCheckArgs(args []string) (PolicyCheckInput, error) {
+	input := PolicyCheckInput{}
+	var callArgs string
+	fs := flag.NewFlagSet("policy check", flag.ContinueOnError)
+	fs.StringVar(&input.File, "file", "", "policy document to evaluate (default .openclawssy/policy/policy.json)")
+	fs.StringVar(&input.AgentID, "agent", "", "agent making the call")
+	fs.StringVar(&input.Tool, "tool", "", "tool to call")
+	fs.StringVar(&callArgs, "args", "", "tool arguments as a JSON object")
+	fs.BoolVar(&input.JSON, "json", false, "print decisions as JSON")
+	if err := fs.Parse(args); err != nil {
+		return PolicyCheckInput{}, err
+	}
+
+	input.AgentID = strings.TrimSpace(input.AgentID)
+	input.Tool = strings.TrimSpace(input.Tool)
+	if (input.AgentID == "") != (input.Tool == "") {
+		return PolicyCheckInput{}, errors.New("-agent and -tool must be used together")
+	}
+	if strings.TrimSpace(callArgs) != "" {
+		if input.Tool == "" {
+			return PolicyCheckInput{}, errors.New("-args requires -agent and -tool")
+		}
+		if err := json.Unmarshal([]byte(callArgs), &input.Args); err != nil {
+			return PolicyCheckInput{}, fmt.Errorf("-args must be a JSON object: %w", err)
+		}
+	}
+	input.File = strings.TrimSpace(input.File)
+	input.Fixtures = fs.Args()
+	return input, nil
+}
+
+// ParseAuditVerifyArgs parses `audit verify` flags. No -agent verifies every
+// agent with an audit log.
+func ParseAuditVerifyArgs(args []string) (AuditVerifyInput, error) {
+	input := AuditVerifyInput{}
+	var agents string
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.StringVar(&agents, "agent", "", "comma-separated agents to verify (default all)")
+	fs.StringVar(&input.PublicKey, "pubkey", "", "Ed25519 public key for checkpoints (default <audit.signing_key_file>.pub)")
+	fs.BoolVar(&input.JSON, "json", false, "print reports as JSON")
+	if err := fs.Parse(args); err != nil {
+		return AuditVerifyInput{}, err
+	}
+	if fs.NArg() > 0 {
+		return AuditVerifyInput{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	for _, agentID := range strings.Split(agents, ",") {
+		if agentID = strings.TrimSpace(agentID); agentID != "" {
+			input.AgentIDs = append(input.AgentIDs, agentID)
+		}
+	}
+	input.PublicKey = strings.TrimSpace(input.PublicKey)
+	return input, nil
+}
+
+// ParseSecretsArgs parses `secrets <action>` where action is list, show,
+// rollback, acl or rotate-master-key. `acl` without -agents clears the ACL.
+func ParseSecretsArgs(args []string) (SecretsInput, error) {
+	if len(args) == 0 {
+		return SecretsInput{}, errors.New("missing action: list, show, rollback, acl, rotate-master-key or wrap-master-key")
+	}
+	input := SecretsInput{Action: args[0]}
+	var agents string
+	fs := flag.NewFlagSet("secrets "+input.Action, flag.ContinueOnError)
+	fs.StringVar(&input.Name, "name", "", "secret name")
+	switch input.Action {
+	case "list", "show":
+		fs.BoolVar(&input.JSON, "json", false, "print metadata as JSON")
+	case "rollback":
+		fs.IntVar(&input.Version, "version", 0, "version to make current")
+	case "acl":
+		fs.StringVar(&agents, "agents", "", "comma-separated agents allowed to read (* for all)")
+	case "rotate-master-key":
+	case "wrap-master-key":
+		fs.StringVar(&input.With, "with", "passphrase", "wrapper: passphrase, command or a registered plugin")
+	default:
+		return SecretsInput{}, fmt.Errorf("unknown secrets action %q", input.Action)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return SecretsInput{}, err
+	}
+	if fs.NArg() > 0 {
+		return SecretsInput{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	switch input.Action {
+	case "show", "rollback", "acl":
+		if input.Name == "" {
+			return SecretsInput{}, fmt.Errorf("-name is required for secrets %s", input.Action)
+		}
+	}
+	if input.Action == "rollback" && input.Version <= 0 {
+		return SecretsInput{}, errors.New("-version must be positive")
+	}
+	input.With = strings.TrimSpace(input.With)
+	if input.Action == "wrap-master-key" && input.With == "" {
+		return SecretsInput{}, errors.New("-with must name a wrapper")
+	}
+	input.Agents = splitList(agents)
+	return input, nil
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func (h Handlers) outWriter() io.Writer {
 	if h.Out != nil {
 		return h.Out
@@ -41,3 +41,83 @@ func TestHandleAskRejectsInvalidThinkingOverride(t *testing.T) {
 		t.Fatalf("expected failure code 1, got %d", code)
 	}
 }
+
+func TestParseMCPServeArgs(t *testing.T) {
+	input, err := ParseMCPServeArgs([]string{"-agent", "ops", "-tools", "agent.run, fs.read,,"})
+	if err != nil {
+		t.Fatalf("parse stdio args: %v", err)
+	}
+	if input.Transport != "stdio" || input.AgentID != "ops" || len(input.Tools) != 2 || input.Tools[1] != "fs.read" {
+		t.Fatalf("unexpected input: %+v", input)
+	}
+	if _, err := ParseMCPServeArgs([]string{"-transport", "http"}); err == nil {
+		t.Fatal("expected http transport without token to fail")
+	}
+	if _, err := ParseMCPServeArgs([]string{"-transport", "sse"}); err == nil {
+		t.Fatal("expected unsupported transport to fail")
+	}
+	input, err = ParseMCPServeArgs([]string{"-transport", "HTTP", "-token", "secret"})
+	if err != nil || input.Transport != "http" || input.Addr != "127.0.0.1:8765" {
+		t.Fatalf("unexpected http input: %+v %v", input, err)
+	}
+}
+
+func TestParsePolicyCheckArgs(t *testing.T) {
+	input, err := ParsePolicyCheckArgs([]string{"-agent", "ops", "-tool", "shell.exec", "-args", `{"command":"go","args":["test","./..."]}`, "policy_test.json"})
+	if err != nil {
+		t.Fatalf("parse check args: %v", err)
+	}
+	if input.AgentID != "ops" || input.Tool != "shell.exec" || input.Args["command"] != "go" || len(input.Fixtures) != 1 {
+		t.Fatalf("unexpected input: %+v", input)
+	}
+	if _, err := ParsePolicyCheckArgs([]string{"-agent", "ops"}); err == nil {
+		t.Fatal("expected -agent without -tool to fail")
+	}
+	if _, err := ParsePolicyCheckArgs([]string{"-agent", "ops", "-tool", "fs.read", "-args", "[1]"}); err == nil {
+		t.Fatal("expected non-object args to fail")
+	}
+	input, err = ParsePolicyCheckArgs(nil)
+	if err != nil || input.Tool != "" || len(input.Fixtures) != 0 {
+		t.Fatalf("expected validate-only input, got %+v %v", input, err)
+	}
+}
+
+func TestParseAuditVerifyArgs(t *testing.T) {
+	input, err := ParseAuditVerifyArgs([]string{"-agent", "default, ops", "-pubkey", "audit.pub", "-json"})
+	if err != nil {
+		t.Fatalf("parse verify args: %v", err)
+	}
+	if len(input.AgentIDs) != 2 || input.AgentIDs[1] != "ops" || input.PublicKey != "audit.pub" || !input.JSON {
+		t.Fatalf("unexpected input: %+v", input)
+	}
+	if _, err := ParseAuditVerifyArgs([]string{"extra"}); err == nil {
+		t.Fatal("expected positional arguments to be rejected")
+	}
+}
+
+func TestParseSecretsArgs(t *testing.T) {
+	input, err := ParseSecretsArgs([]string{"acl", "-name", "deploy/token", "-agents", "ops, default"})
+	if err != nil {
+		t.Fatalf("parse acl args: %v", err)
+	}
+	if input.Action != "acl" || input.Name != "deploy/token" || len(input.Agents) != 2 || input.Agents[1] != "default" {
+		t.Fatalf("unexpected input: %+v", input)
+	}
+	input, err = ParseSecretsArgs([]string{"rollback", "-name", "api", "-version", "2"})
+	if err != nil || input.Version != 2 {
+		t.Fatalf("parse rollback args: %+v %v", input, err)
+	}
+	input, err = ParseSecretsArgs([]string{"wrap-master-key"})
+	if err != nil || input.With != "passphrase" {
+		t.Fatalf("parse wrap-master-key args: %+v %v", input, err)
+	}
+	input, err = ParseSecretsArgs([]string{"wrap-master-key", "-with", "command"})
+	if err != nil || input.With != "command" {
+		t.Fatalf("parse wrap-master-key -with: %+v %v", input, err)
+	}
+	for _, args := range [][]string{nil, {"rollback", "-name", "api"}, {"show"}, {"purge"}, {"rotate-master-key", "extra"}, {"wrap-master-key", "-with", " "}} {
+		if _, err := ParseSecretsArgs(args); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+}
@@ -14,11 +14,14 @@ import (
 	"strings"
 	"time"
 
+	"openclawssy/internal/approval"
+	"openclawssy/internal/audit"
 	httpchannel "openclawssy/internal/channels/http"
 	"openclawssy/internal/chatstore"
 	"openclawssy/internal/config"
 	"openclawssy/internal/memory"
 	memorystore "openclawssy/internal/memory/store"
+	"openclawssy/internal/policy"
 	"openclawssy/internal/scheduler"
 	"openclawssy/internal/secrets"
 )
@@ -27,6 +30,8 @@ type Handler struct {
 	rootDir        string
 	store          httpchannel.RunStore
 	schedulerStore *scheduler.Store
+	approvals      *approval.Broker
+	auditLogger    func(agentID string) (*audit.Logger, error)
 }
 
 type agentDocPayload struct {
@@ -58,6 +63,24 @@ func New(rootDir string, store httpchannel.RunStore, schedulerStore ...*schedule
 	return &Handler{rootDir: rootDir, store: store, schedulerStore: jobs}
 }
 
+// SetApprovals enables the approvals API backed by the runtime's broker.
+func (h *Handler) SetApprovals(broker *approval.Broker) {
+	h.approvals = broker
+}
+
+// SetAuditLogger makes dashboard actions write through the runtime's audit
+// logger, so they follow the configured rotation and export.
+func (h *Handler) SetAuditLogger(open func(agentID string) (*audit.Logger, error)) {
+	h.auditLogger = open
+}
+
+func (h *Handler) openAuditLogger(agentID string) (*audit.Logger, error) {
+	if h.auditLogger != nil {
+		return h.auditLogger(agentID)
+	}
+	return audit.NewLogger(filepath.Join(h.rootDir, ".openclawssy", "agents", agentID, "audit", "events.jsonl"), policy.RedactValue)
+}
+
 func (h *Handler) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/dashboard", h.serveDashboard)
 	mux.HandleFunc("/dashboard-legacy", h.serveLegacyDashboard)
@@ -74,6 +97,232 @@ func (h *Handler) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/api/admin/agent/docs", h.handleAgentDocs)
 	mux.HandleFunc("/api/admin/debug/runs/", h.getRunTrace)
 	mux.HandleFunc("/api/admin/memory/", h.getAgentMemory)
+	mux.HandleFunc("/api/admin/approvals", h.listApprovals)
+	mux.HandleFunc("/api/admin/approvals/", h.handleApprovalByID)
+	mux.HandleFunc("/api/admin/audit", h.queryAudit)
+}
+
+func (h *Handler) listApprovals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.approvals == nil {
+		writeJSON(w, map[string]any{"enabled": false, "pending": []approval.Request{}, "rules": []approval.Rule{}})
+		return
+	}
+	rules := h.approvals.Rules()
+	if rules == nil {
+		rules = []approval.Rule{}
+	}
+	writeJSON(w, map[string]any{"enabled": true, "pending": h.approvals.Pending(), "rules": rules})
+}
+
+func (h *Handler) handleApprovalByID(w http.ResponseWriter, r *http.Request) {
+	if h.approvals == nil {
+		http.Error(w, "approvals are not enabled", http.StatusNotFound)
+		return
+	}
+	id := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/api/admin/approvals/"))
+	if id == "" || strings.Contains(id, "/") {
+		http.Error(w, "invalid approval id", http.StatusBadRequest)
+		return
+	}
+	if id == "rules" {
+		h.forgetApprovalRule(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Decision string `json:"decision"`
+		Remember bool   `json:"remember"`
+		Pattern  string `json:"pattern"`
+		Reason   string `json:"reason"`
+		Approver string `json:"approver"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	decision := strings.ToLower(strings.TrimSpace(req.Decision))
+	if decision != "approve" && decision != "deny" {
+		http.Error(w, "decision must be approve or deny", http.StatusBadRequest)
+		return
+	}
+	approver := "dashboard"
+	if name := strings.TrimSpace(req.Approver); name != "" {
+		approver += ":" + name
+	}
+	pending, err := h.approvals.Decide(id, "", approval.Decision{
+		Approved: decision == "approve",
+		Approver: approver,
+		Reason:   strings.TrimSpace(req.Reason),
+		Remember: decision == "approve" && req.Remember,
+		Pattern:  req.Pattern,
+	})
+	if err != nil {
+		if errors.Is(err, approval.ErrNotFound) {
+			http.Error(w, "approval not pending", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true, "id": pending.ID, "decision": decision, "approver": approver, "run_id": pending.RunID})
+}
+
+func (h *Handler) forgetApprovalRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		AgentID string `json:"agent_id"`
+		Tool    string `json:"tool"`
+		Pattern string `json:"pattern"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	removed, err := h.approvals.Forget(req.AgentID, req.Tool, req.Pattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if removed == 0 {
+		http.Error(w, "rule not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true, "removed": removed})
+}
+
+// auditEntry is an audit record tagged with the agent log it came from.
+type auditEntry struct {
+	Agent string `json:"agent"`
+	audit.Event
+}
+
+// queryAudit searches agent audit logs. Filters: agent (comma-separated,
+// default all), run_id, tool, type (comma-separated, `family.*` allowed),
+// since/until (RFC3339), before (seq, single agent only) and limit.
+func (h *Handler) queryAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	q := audit.Query{
+		RunID: strings.TrimSpace(params.Get("run_id")),
+		Tool:  strings.TrimSpace(params.Get("tool")),
+		Types: splitQueryList(params.Get("type")),
+	}
+	for name, target := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		raw := strings.TrimSpace(params.Get(name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, name+" must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*target = parsed
+	}
+	if raw := strings.TrimSpace(params.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	agentsDir := filepath.Join(h.rootDir, ".openclawssy", "agents")
+	available, err := auditAgents(agentsDir)
+	if err != nil {
+		http.Error(w, "failed to list audit logs", http.StatusInternalServerError)
+		return
+	}
+	agentIDs := available
+	if requested := splitQueryList(params.Get("agent")); len(requested) > 0 {
+		agentIDs = make([]string, 0, len(requested))
+		for _, raw := range requested {
+			agentID, err := normalizeDashboardAgentID(raw)
+			if err != nil {
+				http.Error(w, "invalid agent id", http.StatusBadRequest)
+				return
+			}
+			agentIDs = append(agentIDs, agentID)
+		}
+	}
+	if raw := strings.TrimSpace(params.Get("before")); raw != "" {
+		before, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || before == 0 {
+			http.Error(w, "before must be a positive sequence number", http.StatusBadRequest)
+			return
+		}
+		if len(agentIDs) != 1 {
+			http.Error(w, "before requires a single agent", http.StatusBadRequest)
+			return
+		}
+		q.BeforeSeq = before
+	}
+
+	entries := []auditEntry{}
+	for _, agentID := range agentIDs {
+		events, err := audit.Search(filepath.Join(agentsDir, agentID, "audit", "events.jsonl"), q)
+		if err != nil {
+			http.Error(w, "failed to read audit log", http.StatusInternalServerError)
+			return
+		}
+		for _, event := range events {
+			entries = append(entries, auditEntry{Agent: agentID, Event: event})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	limit := q.EffectiveLimit()
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	writeJSON(w, map[string]any{"events": entries, "agents": available, "limit": limit})
+}
+
+// auditAgents lists agents that have an audit directory.
+func auditAgents(agentsDir string) ([]string, error) {
+	entries, err := os.ReadDir(agentsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	agentIDs := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(agentsDir, entry.Name(), "audit")); err == nil && info.IsDir() {
+			agentIDs = append(agentIDs, entry.Name())
+		}
+	}
+	sort.Strings(agentIDs)
+	return agentIDs, nil
+}
+
+func splitQueryList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
 }
 
 func (h *Handler) schedulerStoreOrDefault() (*scheduler.Store, error) {
@@ -335,12 +584,16 @@ func (h *Handler) handleSecrets(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodGet {
-		keys, err := store.ListKeys()
+		items, err := store.List()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		writeJSON(w, map[string]any{"keys": keys})
+		keys := make([]string, 0, len(items))
+		for _, meta := range items {
+			keys = append(keys, meta.Name)
+		}
+		writeJSON(w, map[string]any{"keys": keys, "secrets": items})
 		return
 	}
 
@@ -406,6 +659,10 @@ func (h *Handler) getRunTrace(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) getAgentMemory(w http.ResponseWriter, r *http.Request) {
+	if agentPart, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/admin/memory/"), "/review"); ok && agentPart != r.URL.Path {
+		h.handleMemoryReview(w, r, agentPart)
+		return
+	}
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -447,6 +704,10 @@ func (h *Handler) getAgentMemory(w http.ResponseWriter, r *http.Request) {
 	}
 	cfgPath := filepath.Join(h.rootDir, ".openclawssy", "config.json")
 	cfg, _ := config.LoadOrDefault(cfgPath)
+	var housekeeping any
+	if status, ok, err := memory.LoadHousekeepingStatus(filepath.Join(h.rootDir, ".openclawssy", "agents"), agentID); err == nil && ok {
+		housekeeping = status
+	}
 	coverageRatio := 0.0
 	if health.ActiveItems > 0 {
 		coverageRatio = float64(activeVectorCount) / float64(health.ActiveItems)
@@ -458,6 +719,7 @@ func (h *Handler) getAgentMemory(w http.ResponseWriter, r *http.Request) {
 		"active_items":   activeItems,
 		"active_count":   len(activeItems),
 		"memory_enabled": true,
+		"housekeeping":   housekeeping,
 		"embedding_stats": map[string]any{
 			"enabled":                   cfg.Memory.Enabled && cfg.Memory.EmbeddingsEnabled,
 			"provider":                  cfg.Memory.EmbeddingProvider,
@@ -852,6 +1114,132 @@ func resolveDashboardDocNames(raw string) (displayName string, resolvedName stri
 	}
 }
 
+func (h *Handler) handleMemoryReview(w http.ResponseWriter, r *http.Request, rawAgentID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	agentID, err := normalizeDashboardAgentID(rawAgentID)
+	if err != nil || strings.TrimSpace(rawAgentID) == "" {
+		http.Error(w, "invalid agent id", http.StatusBadRequest)
+		return
+	}
+	agentsDir := filepath.Join(h.rootDir, ".openclawssy", "agents")
+	store, err := memorystore.OpenSQLite(filepath.Join(agentsDir, agentID, "memory", "memory.db"), agentID)
+	if err != nil {
+		http.Error(w, "failed to open memory store", http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = store.Close() }()
+
+	if r.Method == http.MethodGet {
+		items, err := store.List(r.Context(), memory.MemoryStatusPendingReview, 200)
+		if err != nil {
+			http.Error(w, "failed to load pending items", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"agent_id": agentID, "items": items, "count": len(items)})
+		return
+	}
+
+	var req struct {
+		ID         string `json:"id"`
+		Action     string `json:"action"`
+		Kind       string `json:"kind"`
+		Title      string `json:"title"`
+		Content    string `json:"content"`
+		Importance int    `json:"importance"`
+		Reviewer   string `json:"reviewer"`
+		Note       string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+	req.ID = strings.TrimSpace(req.ID)
+	req.Action = strings.ToLower(strings.TrimSpace(req.Action))
+	if req.ID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	item, found, err := store.Get(r.Context(), req.ID)
+	if err != nil {
+		http.Error(w, "failed to load memory item", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "memory item not found", http.StatusNotFound)
+		return
+	}
+	if item.Status != memory.MemoryStatusPendingReview {
+		http.Error(w, "memory item is not pending review", http.StatusConflict)
+		return
+	}
+
+	before := item
+	switch req.Action {
+	case "approve":
+		item.Status = memory.MemoryStatusActive
+	case "edit":
+		if kind := strings.TrimSpace(req.Kind); kind != "" {
+			item.Kind = kind
+		}
+		if title := strings.TrimSpace(req.Title); title != "" {
+			item.Title = title
+		}
+		if content := strings.TrimSpace(req.Content); content != "" {
+			item.Content = content
+		}
+		if req.Importance > 0 {
+			item.Importance = req.Importance
+		}
+		item.Status = memory.MemoryStatusActive
+	case "reject":
+		item.Status = memory.MemoryStatusForgotten
+	default:
+		http.Error(w, "action must be approve, edit, or reject", http.StatusBadRequest)
+		return
+	}
+	saved, err := store.Update(r.Context(), item)
+	if err != nil {
+		http.Error(w, "failed to save review decision", http.StatusInternalServerError)
+		return
+	}
+	if req.Action == "edit" && saved.Content != before.Content {
+		_ = store.DeleteEmbedding(r.Context(), saved.ID)
+	}
+
+	auditPayload := map[string]any{
+		"agent_id":   agentID,
+		"memory_id":  saved.ID,
+		"action":     req.Action,
+		"kind":       saved.Kind,
+		"title":      saved.Title,
+		"status":     saved.Status,
+		"reviewer":   strings.TrimSpace(req.Reviewer),
+		"note":       strings.TrimSpace(req.Note),
+		"provenance": saved.Provenance,
+	}
+	if req.Action == "edit" {
+		auditPayload["previous_title"] = before.Title
+		auditPayload["previous_content"] = before.Content
+		auditPayload["content"] = saved.Content
+	}
+	aud, err := h.openAuditLogger(agentID)
+	if err != nil {
+		http.Error(w, "failed to open audit log", http.StatusInternalServerError)
+		return
+	}
+	logErr := aud.LogEvent(r.Context(), audit.EventMemoryReview, auditPayload)
+	closeErr := aud.Close()
+	if logErr != nil || closeErr != nil {
+		http.Error(w, "failed to write audit log", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{"agent_id": agentID, "action": req.Action, "item": saved})
+}
+
 func normalizeDashboardAgentID(raw string) (string, error) {
 	id := strings.TrimSpace(raw)
 	if id == "" {
@@ -12,6 +12,8 @@ import (
 	"testing"
 	"time"
 
+	"openclawssy/internal/approval"
+	"openclawssy/internal/audit"
 	httpchannel "openclawssy/internal/channels/http"
 	"openclawssy/internal/chatstore"
 	"openclawssy/internal/config"
@@ -243,6 +245,9 @@ func TestAdminMemoryEndpointReturnsHealthAndItems(t *testing.T) {
 		t.Fatalf("upsert memory item: %v", err)
 	}
 	_ = store.Close()
+	if err := memory.WriteHousekeepingStatus(filepath.Join(root, ".openclawssy", "agents"), "default", memory.HousekeepingStatus{LastCheckpointResult: "ok", ConsecutiveFailures: 0}); err != nil {
+		t.Fatalf("write housekeeping status: %v", err)
+	}
 
 	h := New(root, httpchannel.NewInMemoryRunStore())
 	mux := http.NewServeMux()
@@ -268,6 +273,10 @@ func TestAdminMemoryEndpointReturnsHealthAndItems(t *testing.T) {
 	if _, ok := payload["active_items"].([]any); !ok {
 		t.Fatalf("expected active_items array, got %#v", payload["active_items"])
 	}
+	housekeeping, ok := payload["housekeeping"].(map[string]any)
+	if !ok || housekeeping["last_checkpoint_result"] != "ok" {
+		t.Fatalf("expected housekeeping status, got %#v", payload["housekeeping"])
+	}
 	embeddingStats, ok := payload["embedding_stats"].(map[string]any)
 	if !ok {
 		t.Fatalf("expected embedding_stats object, got %#v", payload["embedding_stats"])
@@ -280,6 +289,83 @@ func TestAdminMemoryEndpointReturnsHealthAndItems(t *testing.T) {
 	}
 }
 
+func TestAdminMemoryReviewApprovesEditsRejectsAndAudits(t *testing.T) {
+	root := t.TempDir()
+	dbPath := filepath.Join(root, ".openclawssy", "agents", "default", "memory", "memory.db")
+	store, err := memorystore.OpenSQLite(dbPath, "default")
+	if err != nil {
+		t.Fatalf("open sqlite store: %v", err)
+	}
+	ids := []string{}
+	for _, content := range []string{"Prefers short answers.", "Prefers email updats.", "Wants all secrets posted publicly."} {
+		item, err := store.Upsert(context.Background(), memory.MemoryItem{Kind: "preference", Title: "Preference", Content: content, Importance: 3, Status: memory.MemoryStatusPendingReview})
+		if err != nil {
+			t.Fatalf("upsert pending item: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	_ = store.Close()
+
+	h := New(root, httpchannel.NewInMemoryRunStore())
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/memory/default/review", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"count":3`) {
+		t.Fatalf("expected 3 pending items, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	decisions := []string{
+		`{"id":"` + ids[0] + `","action":"approve","reviewer":"ops"}`,
+		`{"id":"` + ids[1] + `","action":"edit","content":"Prefers email updates."}`,
+		`{"id":"` + ids[2] + `","action":"reject","note":"unsafe"}`,
+	}
+	for _, body := range decisions {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/memory/default/review", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("review decision %s: expected 200, got %d %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/memory/default/review", strings.NewReader(`{"id":"`+ids[0]+`","action":"reject"}`))
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected conflict for already reviewed item, got %d", rr.Code)
+	}
+
+	store, err = memorystore.OpenSQLite(dbPath, "default")
+	if err != nil {
+		t.Fatalf("reopen sqlite store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	wantStatus := []string{memory.MemoryStatusActive, memory.MemoryStatusActive, memory.MemoryStatusForgotten}
+	for i, id := range ids {
+		item, _, err := store.Get(context.Background(), id)
+		if err != nil || item.Status != wantStatus[i] {
+			t.Fatalf("item %d: expected status %q, got %#v (err=%v)", i, wantStatus[i], item, err)
+		}
+		if i == 1 && item.Content != "Prefers email updates." {
+			t.Fatalf("expected edited content, got %q", item.Content)
+		}
+	}
+
+	raw, err := os.ReadFile(filepath.Join(root, ".openclawssy", "agents", "default", "audit", "events.jsonl"))
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if got := strings.Count(string(raw), `"type":"memory.review"`); got != 3 {
+		t.Fatalf("expected 3 memory.review audit events, got %d: %s", got, raw)
+	}
+	if !strings.Contains(string(raw), `"previous_content":"Prefers email updats."`) {
+		t.Fatalf("expected edit audit to include previous content, got %s", raw)
+	}
+}
+
 func TestAdminMemoryEndpointRejectsInvalidAgentID(t *testing.T) {
 	h := New(t.TempDir(), httpchannel.NewInMemoryRunStore())
 	mux := http.NewServeMux()
@@ -371,6 +457,134 @@ func TestAdminSecretsEndpointSetAndList(t *testing.T) {
 	}
 }
 
+func TestAdminAuditEndpointFiltersAcrossAgents(t *testing.T) {
+	root := t.TempDir()
+	for _, agentID := range []string{"default", "ops"} {
+		logger, err := audit.NewLogger(filepath.Join(root, ".openclawssy", "agents", agentID, "audit", "events.jsonl"), nil)
+		if err != nil {
+			t.Fatalf("new logger: %v", err)
+		}
+		for _, tool := range []string{"fs.read", "shell.exec", "fs.read"} {
+			if err := logger.LogEvent(context.Background(), audit.EventToolCall, map[string]any{"run_id": "run-" + agentID, "tool": tool}); err != nil {
+				t.Fatalf("log event: %v", err)
+			}
+		}
+		_ = logger.LogEvent(context.Background(), audit.EventPolicyDeny, map[string]any{"run_id": "run-" + agentID, "tool": "fs.delete"})
+		_ = logger.Close()
+	}
+	h := New(root, httpchannel.NewInMemoryRunStore())
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	query := func(rawQuery string) (int, map[string]any) {
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+rawQuery, nil))
+		var payload map[string]any
+		_ = json.Unmarshal(resp.Body.Bytes(), &payload)
+		return resp.Code, payload
+	}
+
+	code, payload := query("tool=fs.read")
+	events, _ := payload["events"].([]any)
+	if code != http.StatusOK || len(events) != 4 {
+		t.Fatalf("expected 4 fs.read events across agents, got %d %v", code, payload)
+	}
+	if agents, _ := payload["agents"].([]any); len(agents) != 2 {
+		t.Fatalf("expected both agents listed, got %v", payload["agents"])
+	}
+
+	_, payload = query("agent=ops&type=policy.*")
+	events, _ = payload["events"].([]any)
+	if len(events) != 1 || events[0].(map[string]any)["agent"] != "ops" || events[0].(map[string]any)["tool"] != "fs.delete" {
+		t.Fatalf("expected one ops policy denial, got %v", payload)
+	}
+
+	_, payload = query("agent=default&limit=2")
+	events, _ = payload["events"].([]any)
+	if len(events) != 2 || events[0].(map[string]any)["seq"].(float64) != 4 {
+		t.Fatalf("expected newest two default events, got %v", payload)
+	}
+	_, payload = query("agent=default&limit=2&before=3")
+	events, _ = payload["events"].([]any)
+	if len(events) != 2 || events[0].(map[string]any)["seq"].(float64) != 2 {
+		t.Fatalf("expected events before seq 3, got %v", payload)
+	}
+
+	if code, _ := query("before=3"); code != http.StatusBadRequest {
+		t.Fatalf("expected before without a single agent to be rejected, got %d", code)
+	}
+	if code, _ := query("since=yesterday"); code != http.StatusBadRequest {
+		t.Fatalf("expected invalid since to be rejected, got %d", code)
+	}
+	_, payload = query("since=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	if events, _ := payload["events"].([]any); len(events) != 0 {
+		t.Fatalf("expected no events in the future, got %v", events)
+	}
+}
+
+func TestAdminApprovalsEndpointListsAndDecides(t *testing.T) {
+	root := t.TempDir()
+	h := New(root, httpchannel.NewInMemoryRunStore())
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	listResp := httptest.NewRecorder()
+	mux.ServeHTTP(listResp, httptest.NewRequest(http.MethodGet, "/api/admin/approvals", nil))
+	if listResp.Code != http.StatusOK || !strings.Contains(listResp.Body.String(), `"enabled":false`) {
+		t.Fatalf("expected disabled approvals listing, got %d %s", listResp.Code, listResp.Body.String())
+	}
+
+	broker, err := approval.NewBroker(filepath.Join(root, ".openclawssy", "policy", "approvals.json"))
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+	h.SetApprovals(broker)
+	pendingCh := make(chan approval.Request, 1)
+	decided := make(chan approval.Decision, 1)
+	go func() {
+		req := approval.Request{AgentID: "default", RunID: "run_1", Tool: "fs.delete", Args: map[string]any{"path": "build/out.bin"}}
+		_, decision, _ := broker.Await(context.Background(), req, time.Minute, false, func(r approval.Request) { pendingCh <- r })
+		decided <- decision
+	}()
+	pending := <-pendingCh
+
+	listResp = httptest.NewRecorder()
+	mux.ServeHTTP(listResp, httptest.NewRequest(http.MethodGet, "/api/admin/approvals", nil))
+	var listing struct {
+		Pending []approval.Request `json:"pending"`
+	}
+	if err := json.Unmarshal(listResp.Body.Bytes(), &listing); err != nil || len(listing.Pending) != 1 || listing.Pending[0].Subject != "build/out.bin" {
+		t.Fatalf("expected one pending approval, got %s (%v)", listResp.Body.String(), err)
+	}
+
+	badResp := httptest.NewRecorder()
+	mux.ServeHTTP(badResp, httptest.NewRequest(http.MethodPost, "/api/admin/approvals/"+pending.ID, bytes.NewBufferString(`{"decision":"maybe"}`)))
+	if badResp.Code != http.StatusBadRequest {
+		t.Fatalf("expected bad request for invalid decision, got %d", badResp.Code)
+	}
+
+	decideResp := httptest.NewRecorder()
+	mux.ServeHTTP(decideResp, httptest.NewRequest(http.MethodPost, "/api/admin/approvals/"+pending.ID, bytes.NewBufferString(`{"decision":"approve","remember":true,"pattern":"build/*","approver":"ops"}`)))
+	if decideResp.Code != http.StatusOK {
+		t.Fatalf("expected approve status 200, got %d (%s)", decideResp.Code, decideResp.Body.String())
+	}
+	if decision := <-decided; !decision.Approved || decision.Approver != "dashboard:ops" {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+
+	againResp := httptest.NewRecorder()
+	mux.ServeHTTP(againResp, httptest.NewRequest(http.MethodPost, "/api/admin/approvals/"+pending.ID, bytes.NewBufferString(`{"decision":"deny"}`)))
+	if againResp.Code != http.StatusNotFound {
+		t.Fatalf("expected not found for decided approval, got %d", againResp.Code)
+	}
+
+	forgetResp := httptest.NewRecorder()
+	mux.ServeHTTP(forgetResp, httptest.NewRequest(http.MethodDelete, "/api/admin/approvals/rules", bytes.NewBufferString(`{"agent_id":"default","tool":"fs.delete","pattern":"build/*"}`)))
+	if forgetResp.Code != http.StatusOK || len(broker.Rules()) != 0 {
+		t.Fatalf("expected remembered rule to be removed, got %d %s", forgetResp.Code, forgetResp.Body.String())
+	}
+}
+
 func TestAdminAgentDocsEndpointListAndSave(t *testing.T) {
 	root := t.TempDir()
 	agentDir := filepath.Join(root, ".openclawssy", "agents", "default")
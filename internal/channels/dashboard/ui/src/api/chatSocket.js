@@ -0,0 +1,205 @@
+const SOCKET_PATH = "/v1/chat/ws";
+const SOCKET_PROTOCOL = "openclawssy.chat.v1";
+const REQUEST_TIMEOUT_MS = 15000;
+const RECONNECT_MIN_MS = 1000;
+const RECONNECT_MAX_MS = 30000;
+
+function socketURL(path) {
+  const scheme = window.location.protocol === "https:" ? "wss:" : "ws:";
+  return `${scheme}//${window.location.host}${path}`;
+}
+
+// createChatSocket keeps one multiplexed chat socket open. It reconnects with
+// backoff and, after a reconnect, resubscribes every tracked run from the last
+// event ID it saw and re-watches sessions, so no run events are lost.
+export function createChatSocket(options = {}) {
+  const {
+    path = SOCKET_PATH,
+    tokenResolver = () => "",
+    WebSocketImpl = window.WebSocket,
+    onEvent = () => {},
+    onStateChange = () => {},
+  } = options;
+
+  let socket = null;
+  let closedByUser = false;
+  let reconnectDelay = RECONNECT_MIN_MS;
+  let reconnectTimer = 0;
+  let nextRequestID = 0;
+  const pending = new Map();
+  const runs = new Map();
+  const sessions = new Set();
+
+  function isOpen() {
+    return Boolean(socket) && socket.readyState === WebSocketImpl.OPEN;
+  }
+
+  function rejectPending(reason) {
+    pending.forEach(({ reject, timer }) => {
+      window.clearTimeout(timer);
+      reject(new Error(reason));
+    });
+    pending.clear();
+  }
+
+  function sendFrame(frame) {
+    if (!isOpen()) {
+      return false;
+    }
+    socket.send(JSON.stringify(frame));
+    return true;
+  }
+
+  function handleFrame(frame) {
+    if (!frame || typeof frame !== "object") {
+      return;
+    }
+    if (frame.type === "event" && frame.event) {
+      const runID = String(frame.event.run_id || "");
+      const eventID = Number(frame.event.id) || 0;
+      if (runID && eventID > (runs.get(runID) || 0)) {
+        runs.set(runID, eventID);
+      }
+      if (frame.event.type === "completed" || frame.event.type === "failed") {
+        runs.delete(runID);
+      }
+      onEvent(frame.event);
+      return;
+    }
+    if (frame.type === "ack" && frame.run_id && !runs.has(frame.run_id)) {
+      runs.set(frame.run_id, 0);
+    }
+    const request = frame.id ? pending.get(frame.id) : null;
+    if (!request) {
+      return;
+    }
+    pending.delete(frame.id);
+    window.clearTimeout(request.timer);
+    if (frame.type === "error") {
+      const err = new Error(frame.error?.message || "chat socket request failed");
+      err.code = frame.error?.code || "socket.error";
+      err.retryAfterSeconds = frame.error?.retry_after_seconds || 0;
+      request.reject(err);
+      return;
+    }
+    request.resolve(frame);
+  }
+
+  function resume() {
+    runs.forEach((lastEventID, runID) => {
+      sendFrame({ type: "subscribe", run_id: runID, last_event_id: lastEventID });
+    });
+    sessions.forEach((sessionID) => {
+      sendFrame({ type: "watch", session_id: sessionID });
+    });
+  }
+
+  function scheduleReconnect() {
+    if (closedByUser || reconnectTimer) {
+      return;
+    }
+    reconnectTimer = window.setTimeout(() => {
+      reconnectTimer = 0;
+      connect();
+    }, reconnectDelay);
+    reconnectDelay = Math.min(reconnectDelay * 2, RECONNECT_MAX_MS);
+  }
+
+  function connect() {
+    if (socket && socket.readyState !== WebSocketImpl.CLOSED) {
+      return;
+    }
+    if (typeof WebSocketImpl !== "function") {
+      return;
+    }
+    closedByUser = false;
+    const token = String(tokenResolver() || "").trim();
+    const protocols = token ? [SOCKET_PROTOCOL, `bearer.${token}`] : [SOCKET_PROTOCOL];
+    try {
+      socket = new WebSocketImpl(socketURL(path), protocols);
+    } catch (_err) {
+      socket = null;
+      scheduleReconnect();
+      return;
+    }
+    socket.onopen = () => {
+      reconnectDelay = RECONNECT_MIN_MS;
+      resume();
+      onStateChange("open");
+    };
+    socket.onmessage = (message) => {
+      try {
+        handleFrame(JSON.parse(message.data));
+      } catch (_err) {
+        // Ignore malformed frames; the server only sends JSON.
+      }
+    };
+    socket.onclose = () => {
+      socket = null;
+      rejectPending("chat socket closed");
+      onStateChange("closed");
+      scheduleReconnect();
+    };
+  }
+
+  function request(frame) {
+    return new Promise((resolve, reject) => {
+      nextRequestID += 1;
+      const id = `req_${nextRequestID}`;
+      if (!sendFrame({ ...frame, id })) {
+        reject(new Error("chat socket is not connected"));
+        return;
+      }
+      const timer = window.setTimeout(() => {
+        pending.delete(id);
+        reject(new Error("chat socket request timed out"));
+      }, REQUEST_TIMEOUT_MS);
+      pending.set(id, { resolve, reject, timer });
+    });
+  }
+
+  function subscribe(runID, lastEventID = 0) {
+    const id = String(runID || "").trim();
+    if (!id) {
+      return false;
+    }
+    if (runs.has(id)) {
+      // Already streaming, or queued for resubscription on reconnect; a
+      // second subscribe would replay events the caller has seen.
+      return true;
+    }
+    runs.set(id, Number(lastEventID) || 0);
+    return sendFrame({ type: "subscribe", run_id: id, last_event_id: runs.get(id) });
+  }
+
+  function watch(sessionID) {
+    const id = String(sessionID || "").trim();
+    if (!id || sessions.has(id)) {
+      return;
+    }
+    sessions.add(id);
+    sendFrame({ type: "watch", session_id: id });
+  }
+
+  function close() {
+    closedByUser = true;
+    if (reconnectTimer) {
+      window.clearTimeout(reconnectTimer);
+      reconnectTimer = 0;
+    }
+    if (socket) {
+      socket.close();
+    }
+  }
+
+  return {
+    connect,
+    close,
+    isOpen,
+    request,
+    subscribe,
+    watch,
+    send: (message) => request({ type: "send", ...message }),
+    cancel: (runID) => request({ type: "cancel", run_id: runID }),
+  };
+}
@@ -8,6 +8,9 @@ import { schedulerPage } from "./pages/scheduler.js";
 import { settingsPage } from "./pages/settings.js";
 import { secretsPage } from "./pages/secrets.js";
 import { docsPage } from "./pages/docs.js";
+import { memoryReviewPage } from "./pages/memory_review.js";
+import { approvalsPage } from "./pages/approvals.js";
+import { auditPage } from "./pages/audit.js";
 import { toolInspector } from "./inspectors/tool_inspector.js";
 import { traceInspector } from "./inspectors/trace_inspector.js";
 import { toolSchemaInspector } from "./inspectors/tool_schema_inspector.js";
@@ -23,6 +26,9 @@ const ROUTES = [
   { path: "/settings", label: "Settings", page: settingsPage },
   { path: "/docs", label: "Docs", page: docsPage },
   { path: "/secrets", label: "Secrets", page: secretsPage },
+  { path: "/memory-review", label: "Memory Review", page: memoryReviewPage },
+  { path: "/approvals", label: "Approvals", page: approvalsPage },
+  { path: "/audit", label: "Audit", page: auditPage },
 ];
 
 const INSPECTORS = [toolInspector, traceInspector, toolSchemaInspector, fixSuggestionsInspector, pythonEnvInspector];
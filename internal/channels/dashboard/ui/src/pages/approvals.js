@@ -0,0 +1,284 @@
+import { captureFocusSnapshot, restoreFocusSnapshot } from "../ui/focus_restore.js";
+
+const approvalsState = {
+  container: null,
+  apiClient: null,
+  loading: false,
+  loadError: "",
+  enabled: false,
+  requests: [],
+  rules: [],
+  patterns: {},
+  pending: new Set(),
+  notice: "",
+  noticeKind: "",
+};
+
+function extractErrorMessage(error) {
+  if (error instanceof Error && error.message) {
+    return error.message;
+  }
+  return String(error || "Unknown approvals error");
+}
+
+function rerender(options = {}) {
+  if (!approvalsState.container || !approvalsState.container.isConnected) {
+    return;
+  }
+  const focusSnapshot = options.preserveFocus ? captureFocusSnapshot(approvalsState.container) : null;
+  renderApprovalsPage();
+  if (focusSnapshot) {
+    restoreFocusSnapshot(approvalsState.container, focusSnapshot);
+  }
+}
+
+async function loadApprovals() {
+  approvalsState.loading = true;
+  approvalsState.loadError = "";
+  rerender();
+  try {
+    const payload = await approvalsState.apiClient.get("/api/admin/approvals");
+    approvalsState.enabled = Boolean(payload?.enabled);
+    approvalsState.requests = Array.isArray(payload?.pending) ? payload.pending : [];
+    approvalsState.rules = Array.isArray(payload?.rules) ? payload.rules : [];
+  } catch (error) {
+    approvalsState.loadError = extractErrorMessage(error);
+    approvalsState.requests = [];
+  } finally {
+    approvalsState.loading = false;
+    rerender();
+  }
+}
+
+async function submitDecision(request, decision, remember) {
+  const key = `${request.id}:${decision}`;
+  approvalsState.pending.add(key);
+  approvalsState.notice = "";
+  approvalsState.noticeKind = "";
+  rerender();
+  const body = { decision, remember };
+  if (remember) {
+    body.pattern = approvalsState.patterns[request.id] ?? request.subject;
+  }
+  try {
+    await approvalsState.apiClient.post(`/api/admin/approvals/${encodeURIComponent(request.id)}`, body);
+    approvalsState.notice = `${decision === "deny" ? "Denied" : "Approved"}: ${request.tool} (${request.id})`;
+    approvalsState.noticeKind = "success";
+    await loadApprovals();
+  } catch (error) {
+    approvalsState.notice = extractErrorMessage(error);
+    approvalsState.noticeKind = "error";
+  } finally {
+    approvalsState.pending.delete(key);
+    rerender();
+  }
+}
+
+async function forgetRule(rule) {
+  approvalsState.notice = "";
+  try {
+    await approvalsState.apiClient.delete("/api/admin/approvals/rules", {
+      body: { agent_id: rule.agent_id, tool: rule.tool, pattern: rule.pattern },
+    });
+    approvalsState.notice = `Forgot rule: ${rule.tool} ${rule.pattern}`;
+    approvalsState.noticeKind = "success";
+    await loadApprovals();
+  } catch (error) {
+    approvalsState.notice = extractErrorMessage(error);
+    approvalsState.noticeKind = "error";
+    rerender();
+  }
+}
+
+function createRequestCard(request) {
+  const card = document.createElement("li");
+  card.className = "memory-review-item";
+
+  const meta = document.createElement("p");
+  meta.className = "muted";
+  const parts = [`agent ${request.agent_id}`];
+  if (request.run_id) {
+    parts.push(`run ${request.run_id}`);
+  }
+  if (request.session_id) {
+    parts.push(`session ${request.session_id}`);
+  }
+  if (request.expires_at) {
+    parts.push(`expires ${String(request.expires_at).slice(11, 19)}`);
+  }
+  meta.textContent = parts.join(" · ");
+
+  const subject = document.createElement("p");
+  const tool = document.createElement("code");
+  tool.textContent = request.tool;
+  const args = document.createElement("code");
+  args.textContent = request.subject || "(no arguments)";
+  subject.append(tool, " ", args);
+
+  const patternField = document.createElement("label");
+  patternField.className = "secrets-form-field";
+  const patternLabel = document.createElement("span");
+  patternLabel.textContent = "Remember pattern (* matches anything)";
+  const patternInput = document.createElement("input");
+  patternInput.type = "text";
+  patternInput.className = "settings-input";
+  patternInput.setAttribute("data-focus-id", `approvals:pattern:${request.id}`);
+  patternInput.value = approvalsState.patterns[request.id] ?? request.subject ?? "";
+  patternInput.addEventListener("input", () => {
+    approvalsState.patterns[request.id] = patternInput.value;
+  });
+  patternField.append(patternLabel, patternInput);
+
+  const actions = document.createElement("div");
+  actions.className = "memory-review-actions";
+  [
+    { decision: "approve", remember: false, label: "Approve" },
+    { decision: "approve", remember: true, label: "Approve & remember" },
+    { decision: "deny", remember: false, label: "Deny" },
+  ].forEach(({ decision, remember, label }) => {
+    const button = document.createElement("button");
+    button.type = "button";
+    button.className = decision === "approve" && !remember ? "chat-send-button" : "layout-toggle";
+    button.textContent = label;
+    button.disabled = approvalsState.pending.has(`${request.id}:${decision}`);
+    button.addEventListener("click", () => {
+      void submitDecision(request, decision, remember);
+    });
+    actions.append(button);
+  });
+
+  card.append(meta, subject, patternField, actions);
+  return card;
+}
+
+function createPendingPanel() {
+  const panel = document.createElement("section");
+  panel.className = "secrets-keys";
+
+  const titleRow = document.createElement("div");
+  titleRow.className = "secrets-panel-title";
+  const title = document.createElement("h3");
+  title.textContent = "Waiting for approval";
+  const refresh = document.createElement("button");
+  refresh.type = "button";
+  refresh.className = "layout-toggle";
+  refresh.textContent = approvalsState.loading ? "Loading..." : "Refresh";
+  refresh.disabled = approvalsState.loading;
+  refresh.addEventListener("click", () => {
+    void loadApprovals();
+  });
+  titleRow.append(title, refresh);
+  panel.append(titleRow);
+
+  if (approvalsState.loadError) {
+    const error = document.createElement("p");
+    error.className = "settings-inline-error";
+    error.textContent = `Failed to load approvals: ${approvalsState.loadError}`;
+    panel.append(error);
+    return panel;
+  }
+
+  if (!approvalsState.enabled && !approvalsState.loading) {
+    const disabled = document.createElement("p");
+    disabled.className = "muted";
+    disabled.textContent = "Approvals are disabled. Set approvals.enabled in config and restart serve.";
+    panel.append(disabled);
+    return panel;
+  }
+
+  if (!approvalsState.requests.length) {
+    const empty = document.createElement("p");
+    empty.className = "muted";
+    empty.textContent = "No tool calls are waiting.";
+    panel.append(empty);
+    return panel;
+  }
+
+  const list = document.createElement("ul");
+  list.className = "memory-review-list";
+  approvalsState.requests.forEach((request) => list.append(createRequestCard(request)));
+  panel.append(list);
+  return panel;
+}
+
+function createRulesPanel() {
+  const panel = document.createElement("section");
+  panel.className = "secrets-keys";
+
+  const titleRow = document.createElement("div");
+  titleRow.className = "secrets-panel-title";
+  const title = document.createElement("h3");
+  title.textContent = "Remembered approvals";
+  const count = document.createElement("p");
+  count.className = "muted";
+  count.textContent = `${approvalsState.rules.length} rules`;
+  titleRow.append(title, count);
+  panel.append(titleRow);
+
+  if (!approvalsState.rules.length) {
+    const empty = document.createElement("p");
+    empty.className = "muted";
+    empty.textContent = "No remembered approvals.";
+    panel.append(empty);
+    return panel;
+  }
+
+  const list = document.createElement("ul");
+  list.className = "secrets-keys-list";
+  approvalsState.rules.forEach((rule) => {
+    const item = document.createElement("li");
+    const code = document.createElement("code");
+    code.textContent = `${rule.agent_id} ${rule.tool} ${rule.pattern}`;
+    const by = document.createElement("span");
+    by.className = "muted";
+    by.textContent = rule.approver ? `by ${rule.approver}` : "";
+    const forget = document.createElement("button");
+    forget.type = "button";
+    forget.className = "layout-toggle";
+    forget.textContent = "Forget";
+    forget.addEventListener("click", () => {
+      void forgetRule(rule);
+    });
+    item.append(code, by, forget);
+    list.append(item);
+  });
+  panel.append(list);
+  return panel;
+}
+
+function renderApprovalsPage() {
+  const container = approvalsState.container;
+  container.innerHTML = "";
+
+  const heading = document.createElement("h2");
+  heading.textContent = "Approvals";
+  container.append(heading);
+
+  const subtitle = document.createElement("p");
+  subtitle.className = "muted";
+  subtitle.textContent = "Approve or deny tool calls paused by approvals.tools. Runs resume as soon as a decision is made; unanswered requests fall back to approvals.timeout_decision. Decisions are written to the agent audit log.";
+  container.append(subtitle);
+
+  if (approvalsState.notice) {
+    const notice = document.createElement("p");
+    notice.className = approvalsState.noticeKind === "error" ? "settings-inline-error" : "settings-save-success";
+    notice.textContent = approvalsState.notice;
+    container.append(notice);
+  }
+
+  const layout = document.createElement("section");
+  layout.className = "secrets-layout";
+  layout.append(createPendingPanel(), createRulesPanel());
+  container.append(layout);
+}
+
+export const approvalsPage = {
+  key: "approvals",
+  title: "Approvals",
+  async render({ container, apiClient }) {
+    approvalsState.container = container;
+    approvalsState.apiClient = apiClient;
+    renderApprovalsPage();
+    await loadApprovals();
+  },
+};
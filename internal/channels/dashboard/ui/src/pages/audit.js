@@ -0,0 +1,319 @@
+import { captureFocusSnapshot, restoreFocusSnapshot } from "../ui/focus_restore.js";
+
+const LIMIT_OPTIONS = [50, 100, 250, 500];
+
+const auditState = {
+  container: null,
+  apiClient: null,
+  loading: false,
+  loadError: "",
+  agents: [],
+  events: [],
+  filters: {
+    agent: "",
+    type: "",
+    tool: "",
+    runID: "",
+    since: "",
+    until: "",
+    limit: 100,
+  },
+  before: 0,
+  expanded: new Set(),
+};
+
+function extractErrorMessage(error) {
+  if (error instanceof Error && error.message) {
+    return error.message;
+  }
+  return String(error || "Unknown audit error");
+}
+
+function rerender(options = {}) {
+  if (!auditState.container || !auditState.container.isConnected) {
+    return;
+  }
+  const focusSnapshot = options.preserveFocus ? captureFocusSnapshot(auditState.container) : null;
+  renderAuditPage();
+  if (focusSnapshot) {
+    restoreFocusSnapshot(auditState.container, focusSnapshot);
+  }
+}
+
+function toRFC3339(localValue) {
+  if (!localValue) {
+    return "";
+  }
+  const parsed = new Date(localValue);
+  return Number.isNaN(parsed.getTime()) ? "" : parsed.toISOString().replace(/\.\d{3}Z$/, "Z");
+}
+
+function buildQuery() {
+  const { filters } = auditState;
+  const params = new URLSearchParams();
+  const pairs = [
+    ["agent", filters.agent],
+    ["type", filters.type.trim()],
+    ["tool", filters.tool.trim()],
+    ["run_id", filters.runID.trim()],
+    ["since", toRFC3339(filters.since)],
+    ["until", toRFC3339(filters.until)],
+    ["limit", String(filters.limit)],
+  ];
+  for (const [key, value] of pairs) {
+    if (value) {
+      params.set(key, value);
+    }
+  }
+  if (auditState.before > 0 && filters.agent) {
+    params.set("before", String(auditState.before));
+  }
+  return params.toString();
+}
+
+async function loadAudit() {
+  auditState.loading = true;
+  auditState.loadError = "";
+  rerender({ preserveFocus: true });
+  try {
+    const payload = await auditState.apiClient.get(`/api/admin/audit?${buildQuery()}`);
+    auditState.agents = Array.isArray(payload?.agents) ? payload.agents : [];
+    auditState.events = Array.isArray(payload?.events) ? payload.events : [];
+  } catch (error) {
+    auditState.loadError = extractErrorMessage(error);
+    auditState.events = [];
+  } finally {
+    auditState.loading = false;
+    rerender({ preserveFocus: true });
+  }
+}
+
+function applyFilters() {
+  auditState.before = 0;
+  auditState.expanded.clear();
+  void loadAudit();
+}
+
+function createTextFilter(label, key, placeholder) {
+  const field = document.createElement("label");
+  field.className = "sessions-control";
+  field.textContent = label;
+  const input = document.createElement("input");
+  input.type = "text";
+  input.placeholder = placeholder;
+  input.value = auditState.filters[key];
+  input.setAttribute("data-focus-id", `audit:${key}`);
+  input.addEventListener("input", () => {
+    auditState.filters[key] = input.value;
+  });
+  input.addEventListener("keydown", (event) => {
+    if (event.key === "Enter") {
+      applyFilters();
+    }
+  });
+  field.append(input);
+  return field;
+}
+
+function createTimeFilter(label, key) {
+  const field = document.createElement("label");
+  field.className = "sessions-control";
+  field.textContent = label;
+  const input = document.createElement("input");
+  input.type = "datetime-local";
+  input.value = auditState.filters[key];
+  input.setAttribute("data-focus-id", `audit:${key}`);
+  input.addEventListener("change", () => {
+    auditState.filters[key] = input.value;
+  });
+  field.append(input);
+  return field;
+}
+
+function createSelectFilter(label, key, options) {
+  const field = document.createElement("label");
+  field.className = "runs-control";
+  field.textContent = label;
+  const select = document.createElement("select");
+  select.setAttribute("data-focus-id", `audit:${key}`);
+  for (const option of options) {
+    const item = document.createElement("option");
+    item.value = String(option.value);
+    item.textContent = option.label;
+    item.selected = String(auditState.filters[key]) === String(option.value);
+    select.append(item);
+  }
+  select.addEventListener("change", () => {
+    auditState.filters[key] = key === "limit" ? Number(select.value) : select.value;
+    applyFilters();
+  });
+  field.append(select);
+  return field;
+}
+
+function createControls() {
+  const controls = document.createElement("div");
+  controls.className = "runs-controls";
+
+  const agentOptions = [{ value: "", label: "All agents" }, ...auditState.agents.map((id) => ({ value: id, label: id }))];
+  const limitOptions = LIMIT_OPTIONS.map((value) => ({ value, label: String(value) }));
+
+  const search = document.createElement("button");
+  search.type = "button";
+  search.className = "chat-send-button";
+  search.textContent = auditState.loading ? "Loading..." : "Search";
+  search.disabled = auditState.loading;
+  search.addEventListener("click", applyFilters);
+
+  controls.append(
+    createSelectFilter("Agent", "agent", agentOptions),
+    createTextFilter("Event type", "type", "tool.call, approval.*"),
+    createTextFilter("Tool", "tool", "shell.exec"),
+    createTextFilter("Run", "runID", "run_..."),
+    createTimeFilter("Since", "since"),
+    createTimeFilter("Until", "until"),
+    createSelectFilter("Limit", "limit", limitOptions),
+    search,
+  );
+  return controls;
+}
+
+function eventKey(event) {
+  return `${event.agent}:${event.seq || event.ts}:${event.type}`;
+}
+
+function createEventsTable() {
+  const table = document.createElement("table");
+  table.className = "runs-table";
+
+  const thead = document.createElement("thead");
+  const headerRow = document.createElement("tr");
+  for (const label of ["Time", "Agent", "Seq", "Type", "Tool", "Run", "Payload"]) {
+    const th = document.createElement("th");
+    th.textContent = label;
+    headerRow.append(th);
+  }
+  thead.append(headerRow);
+
+  const tbody = document.createElement("tbody");
+  for (const event of auditState.events) {
+    const key = eventKey(event);
+    const row = document.createElement("tr");
+    const cells = [
+      String(event.ts || "").replace("T", " ").replace(/\.\d+Z$/, "Z"),
+      event.agent || "-",
+      event.seq ? String(event.seq) : "-",
+      event.type || "-",
+      event.tool || "-",
+      event.run_id || "-",
+    ];
+    for (const value of cells) {
+      const cell = document.createElement("td");
+      cell.textContent = value;
+      row.append(cell);
+    }
+
+    const payloadCell = document.createElement("td");
+    if (event.payload && Object.keys(event.payload).length) {
+      const toggle = document.createElement("button");
+      toggle.type = "button";
+      toggle.textContent = auditState.expanded.has(key) ? "Hide" : "Show";
+      toggle.addEventListener("click", () => {
+        if (auditState.expanded.has(key)) {
+          auditState.expanded.delete(key);
+        } else {
+          auditState.expanded.add(key);
+        }
+        rerender();
+      });
+      payloadCell.append(toggle);
+      if (auditState.expanded.has(key)) {
+        const pre = document.createElement("pre");
+        pre.className = "runs-tool-args";
+        pre.textContent = JSON.stringify(event.payload, null, 2);
+        payloadCell.append(pre);
+      }
+    } else {
+      payloadCell.textContent = "-";
+    }
+    row.append(payloadCell);
+    tbody.append(row);
+  }
+
+  table.append(thead, tbody);
+  return table;
+}
+
+function createResults() {
+  const wrap = document.createElement("div");
+  wrap.className = "runs-list";
+  wrap.setAttribute("aria-live", "polite");
+
+  if (auditState.loadError) {
+    const error = document.createElement("p");
+    error.className = "settings-inline-error";
+    error.textContent = `Failed to load audit events: ${auditState.loadError}`;
+    wrap.append(error);
+    return wrap;
+  }
+  if (!auditState.events.length) {
+    const empty = document.createElement("p");
+    empty.className = "muted";
+    empty.textContent = auditState.loading ? "Loading audit events..." : "No audit events match these filters.";
+    wrap.append(empty);
+    return wrap;
+  }
+
+  wrap.append(createEventsTable());
+
+  const pagination = document.createElement("div");
+  pagination.className = "runs-pagination";
+  const meta = document.createElement("span");
+  meta.className = "runs-page-meta muted";
+  meta.textContent = `${auditState.events.length} events, newest first`;
+  const older = document.createElement("button");
+  older.type = "button";
+  older.textContent = "Older";
+  const seqs = auditState.events.map((event) => Number(event.seq) || 0).filter((seq) => seq > 0);
+  older.disabled =
+    auditState.loading || !auditState.filters.agent || !seqs.length || auditState.events.length < auditState.filters.limit;
+  older.title = auditState.filters.agent ? "" : "Select one agent to page through its log";
+  older.addEventListener("click", () => {
+    auditState.before = Math.min(...seqs);
+    void loadAudit();
+  });
+  const newest = document.createElement("button");
+  newest.type = "button";
+  newest.textContent = "Newest";
+  newest.disabled = auditState.loading || auditState.before === 0;
+  newest.addEventListener("click", applyFilters);
+  pagination.append(newest, older, meta);
+  wrap.append(pagination);
+  return wrap;
+}
+
+function renderAuditPage() {
+  const container = auditState.container;
+  container.innerHTML = "";
+
+  const heading = document.createElement("h2");
+  heading.textContent = "Audit";
+  container.append(heading);
+
+  const subtitle = document.createElement("p");
+  subtitle.className = "muted";
+  subtitle.textContent =
+    "Search agent audit logs across rotated files. Payloads are redacted when written. Use openclawssy audit verify to check the hash chain.";
+  container.append(subtitle, createControls(), createResults());
+}
+
+export const auditPage = {
+  key: "audit",
+  title: "Audit",
+  async render({ container, apiClient }) {
+    auditState.container = container;
+    auditState.apiClient = apiClient;
+    renderAuditPage();
+    await loadAudit();
+  },
+};
@@ -1,3 +1,5 @@
+import { createChatSocket } from "../api/chatSocket.js";
+
 const CHAT_DEFAULTS = {
   userID: "dashboard_user",
   roomID: "dashboard",
@@ -52,6 +54,7 @@ const chatViewState = {
   sessionPollIntervalMS: SESSION_POLL_MS,
 
   streamActive: false,
+  streamViaSocket: false,
   streamAbortController: null,
   streamLastEventID: 0,
   currentStreamingText: "",
@@ -62,6 +65,7 @@ const chatViewState = {
   transcriptScrollTop: 0,
 
   routeUnsubscribe: null,
+  socket: null,
   container: null,
   apiClient: null,
   store: null,
@@ -632,6 +636,7 @@ function resetStreamingState(options = {}) {
   const { resetLastEventID = false, keepStreamingText = false } = options;
   clearStreamingRenderTimer();
   chatViewState.streamActive = false;
+  chatViewState.streamViaSocket = false;
   if (!keepStreamingText) {
     chatViewState.currentStreamingText = "";
   }
@@ -1148,6 +1153,86 @@ async function connectRunEventStream(runID) {
   }
 }
 
+function ensureChatSocket() {
+  if (chatViewState.socket || !chatViewState.apiClient) {
+    return;
+  }
+  chatViewState.socket = createChatSocket({
+    tokenResolver: () => chatViewState.apiClient.resolveBearerToken(),
+    onEvent: handleSocketRunEvent,
+    onStateChange: handleSocketStateChange,
+  });
+  chatViewState.socket.connect();
+}
+
+function isChatSocketOpen() {
+  return Boolean(chatViewState.socket) && chatViewState.socket.isOpen();
+}
+
+function handleSocketRunEvent(event) {
+  const runID = safeText(event?.run_id);
+  if (!runID || runID !== safeText(chatViewState.currentRunID) || !chatViewState.streamViaSocket) {
+    // Another run in a watched session (another tab, a channel, the
+    // scheduler) finished: refresh the transcript from the session.
+    const type = safeText(event?.type).toLowerCase();
+    if ((type === "completed" || type === "failed") && isChatRouteActive()) {
+      scheduleIdleSessionPoll(true);
+    }
+    return;
+  }
+  noteStreamEventID(event.id);
+  handleRunStreamEvent(event.type, event);
+}
+
+function handleSocketStateChange(state) {
+  const runID = safeText(chatViewState.currentRunID);
+  const activeRun = runID && !isTerminalStatus(chatViewState.currentRunStatus);
+  if (state === "closed" && chatViewState.streamViaSocket) {
+    chatViewState.streamActive = false;
+    chatViewState.streamViaSocket = false;
+    chatViewState.currentStreamRunID = "";
+    if (activeRun && isChatRouteActive()) {
+      restorePollingFallback(runID);
+    }
+    return;
+  }
+  if (state === "open" && activeRun && !chatViewState.streamActive && isChatRouteActive()) {
+    // Runs the socket already tracks were resubscribed from their last event
+    // ID on open; this only covers a run that was streaming over SSE.
+    chatViewState.socket.subscribe(runID, chatViewState.streamLastEventID);
+    attachSocketStream(runID);
+  }
+}
+
+function attachSocketStream(runID) {
+  chatViewState.streamActive = true;
+  chatViewState.streamViaSocket = true;
+  chatViewState.currentStreamRunID = runID;
+  enableStreamPollingMode();
+}
+
+function connectRunEvents(runID, { subscribed = false } = {}) {
+  const targetRunID = safeText(runID);
+  if (!targetRunID) {
+    return;
+  }
+  if (!isChatSocketOpen()) {
+    void connectRunEventStream(targetRunID);
+    return;
+  }
+  if (chatViewState.streamActive && chatViewState.currentStreamRunID === targetRunID) {
+    return;
+  }
+  resetStreamingState({ keepStreamingText: true });
+  if (!subscribed) {
+    chatViewState.socket.subscribe(targetRunID, chatViewState.streamLastEventID);
+  }
+  if (chatViewState.currentSessionID) {
+    chatViewState.socket.watch(chatViewState.currentSessionID);
+  }
+  attachSocketStream(targetRunID);
+}
+
 function ensureRouteWatcher() {
   if (chatViewState.routeUnsubscribe || !chatViewState.store) {
     return;
@@ -1388,12 +1473,22 @@ async function sendMessage() {
   rerenderIfActive();
 
   try {
-    const payload = await chatViewState.apiClient.post("/v1/chat/messages", {
+    const request = {
       user_id: CHAT_DEFAULTS.userID,
       room_id: CHAT_DEFAULTS.roomID,
       agent_id: safeText(chatViewState.selectedAgentID) || CHAT_DEFAULTS.agentID,
       message,
-    });
+    };
+    // The socket subscribes the new run before acking, so its events are
+    // already on the way; plain POST needs a separate event stream.
+    const viaSocket = isChatSocketOpen();
+    let payload;
+    if (viaSocket) {
+      const ack = await chatViewState.socket.send(request);
+      payload = { id: ack.run_id, status: ack.status, session_id: ack.session_id, response: ack.response };
+    } else {
+      payload = await chatViewState.apiClient.post("/v1/chat/messages", request);
+    }
 
     const runID = safeText(payload?.id);
     const sessionID = safeText(payload?.session_id);
@@ -1416,7 +1511,7 @@ async function sendMessage() {
           `Working on it now. Run ${runID} is ${normalizedStatus || "queued"}. I will follow up when it completes or fails.`
       );
       startPolling({ runID, sessionID });
-      void connectRunEventStream(runID);
+      connectRunEvents(runID, { subscribed: viaSocket });
     } else {
       const directResponse = safeText(payload?.response);
       replacePendingAssistant(directResponse || "Request accepted.");
@@ -1798,6 +1893,7 @@ export const chatPage = {
     chatViewState.apiClient = apiClient;
     chatViewState.store = store;
     ensureRouteWatcher();
+    ensureChatSocket();
 
     await refreshAvailableAgents();
     renderChatPage();
@@ -1808,7 +1904,7 @@ export const chatPage = {
         startPolling({ runID: chatViewState.currentRunID, sessionID: chatViewState.currentSessionID });
       }
       if (!chatViewState.streamActive || chatViewState.currentStreamRunID !== chatViewState.currentRunID) {
-        void connectRunEventStream(chatViewState.currentRunID);
+        connectRunEvents(chatViewState.currentRunID);
       }
       return;
     }
@@ -0,0 +1,271 @@
+import { captureFocusSnapshot, restoreFocusSnapshot } from "../ui/focus_restore.js";
+
+const reviewState = {
+  container: null,
+  apiClient: null,
+  agentID: "default",
+  loading: false,
+  loadError: "",
+  items: [],
+  drafts: {},
+  pending: new Set(),
+  notice: "",
+  noticeKind: "",
+};
+
+function extractErrorMessage(error) {
+  if (error instanceof Error && error.message) {
+    return error.message;
+  }
+  return String(error || "Unknown memory review error");
+}
+
+function normalizeItems(payload) {
+  const items = Array.isArray(payload?.items) ? payload.items : [];
+  return items
+    .filter((item) => item && typeof item === "object" && String(item.id || "").trim())
+    .map((item) => ({
+      id: String(item.id).trim(),
+      kind: String(item.kind || "").trim(),
+      title: String(item.title || "").trim(),
+      content: String(item.content || "").trim(),
+      importance: Number(item.importance) || 0,
+      source: String(item?.provenance?.source || "").trim(),
+      runIDs: Array.isArray(item?.provenance?.run_ids) ? item.provenance.run_ids : [],
+      updatedAt: String(item.updated_at || "").trim(),
+    }));
+}
+
+function rerender(options = {}) {
+  if (!reviewState.container || !reviewState.container.isConnected) {
+    return;
+  }
+  const focusSnapshot = options.preserveFocus ? captureFocusSnapshot(reviewState.container) : null;
+  renderMemoryReviewPage();
+  if (focusSnapshot) {
+    restoreFocusSnapshot(reviewState.container, focusSnapshot);
+  }
+}
+
+function reviewPath() {
+  return `/api/admin/memory/${encodeURIComponent(reviewState.agentID || "default")}/review`;
+}
+
+async function loadPending() {
+  reviewState.loading = true;
+  reviewState.loadError = "";
+  rerender();
+  try {
+    const payload = await reviewState.apiClient.get(reviewPath());
+    reviewState.items = normalizeItems(payload);
+    reviewState.drafts = {};
+  } catch (error) {
+    reviewState.loadError = extractErrorMessage(error);
+    reviewState.items = [];
+  } finally {
+    reviewState.loading = false;
+    rerender();
+  }
+}
+
+async function submitDecision(item, action) {
+  const key = `${item.id}:${action}`;
+  reviewState.pending.add(key);
+  reviewState.notice = "";
+  reviewState.noticeKind = "";
+  rerender();
+  const body = { id: item.id, action };
+  if (action === "edit") {
+    const draft = reviewState.drafts[item.id] || {};
+    body.title = draft.title ?? item.title;
+    body.content = draft.content ?? item.content;
+  }
+  try {
+    await reviewState.apiClient.post(reviewPath(), body);
+    reviewState.notice = `${action === "reject" ? "Rejected" : "Approved"}: ${item.title || item.id}`;
+    reviewState.noticeKind = "success";
+    await loadPending();
+  } catch (error) {
+    reviewState.notice = extractErrorMessage(error);
+    reviewState.noticeKind = "error";
+  } finally {
+    reviewState.pending.delete(key);
+    rerender();
+  }
+}
+
+function createAgentPicker() {
+  const form = document.createElement("form");
+  form.className = "memory-review-agent";
+  form.addEventListener("submit", (event) => {
+    event.preventDefault();
+    void loadPending();
+  });
+
+  const field = document.createElement("label");
+  field.className = "secrets-form-field";
+  const label = document.createElement("span");
+  label.textContent = "Agent ID";
+  const input = document.createElement("input");
+  input.type = "text";
+  input.className = "settings-input";
+  input.setAttribute("data-focus-id", "memory-review:agent");
+  input.value = reviewState.agentID;
+  input.addEventListener("input", () => {
+    reviewState.agentID = input.value.trim();
+  });
+  field.append(label, input);
+
+  const load = document.createElement("button");
+  load.type = "submit";
+  load.className = "layout-toggle";
+  load.textContent = reviewState.loading ? "Loading..." : "Load queue";
+  load.disabled = reviewState.loading;
+
+  form.append(field, load);
+  return form;
+}
+
+function createItemCard(item) {
+  const card = document.createElement("li");
+  card.className = "memory-review-item";
+
+  const meta = document.createElement("p");
+  meta.className = "muted";
+  const sourceParts = [item.kind, `importance ${item.importance}`];
+  if (item.source) {
+    sourceParts.push(`src: ${item.source}`);
+  }
+  if (item.runIDs.length) {
+    sourceParts.push(`run ${item.runIDs[item.runIDs.length - 1]}`);
+  }
+  if (item.updatedAt) {
+    sourceParts.push(item.updatedAt.slice(0, 10));
+  }
+  meta.textContent = sourceParts.join(" · ");
+
+  const draft = reviewState.drafts[item.id] || {};
+  const titleInput = document.createElement("input");
+  titleInput.type = "text";
+  titleInput.className = "settings-input";
+  titleInput.setAttribute("data-focus-id", `memory-review:title:${item.id}`);
+  titleInput.value = draft.title ?? item.title;
+  titleInput.addEventListener("input", () => {
+    reviewState.drafts[item.id] = { ...(reviewState.drafts[item.id] || {}), title: titleInput.value };
+  });
+
+  const contentInput = document.createElement("textarea");
+  contentInput.className = "settings-input";
+  contentInput.rows = 3;
+  contentInput.setAttribute("data-focus-id", `memory-review:content:${item.id}`);
+  contentInput.value = draft.content ?? item.content;
+  contentInput.addEventListener("input", () => {
+    reviewState.drafts[item.id] = { ...(reviewState.drafts[item.id] || {}), content: contentInput.value };
+  });
+
+  const actions = document.createElement("div");
+  actions.className = "memory-review-actions";
+  [
+    { action: "approve", label: "Approve" },
+    { action: "edit", label: "Save & approve" },
+    { action: "reject", label: "Reject" },
+  ].forEach(({ action, label }) => {
+    const button = document.createElement("button");
+    button.type = "button";
+    button.className = action === "approve" ? "chat-send-button" : "layout-toggle";
+    button.textContent = label;
+    button.disabled = reviewState.pending.has(`${item.id}:${action}`);
+    button.addEventListener("click", () => {
+      void submitDecision(item, action);
+    });
+    actions.append(button);
+  });
+
+  card.append(meta, titleInput, contentInput, actions);
+  return card;
+}
+
+function createQueuePanel() {
+  const panel = document.createElement("section");
+  panel.className = "secrets-keys";
+
+  const titleRow = document.createElement("div");
+  titleRow.className = "secrets-panel-title";
+  const title = document.createElement("h3");
+  title.textContent = "Pending review";
+  const count = document.createElement("p");
+  count.className = "muted";
+  count.textContent = `${reviewState.items.length} items`;
+  titleRow.append(title, count);
+  panel.append(titleRow);
+
+  if (reviewState.loading) {
+    const loading = document.createElement("p");
+    loading.className = "muted";
+    loading.textContent = "Loading pending items...";
+    panel.append(loading);
+    return panel;
+  }
+
+  if (reviewState.loadError) {
+    const error = document.createElement("p");
+    error.className = "settings-inline-error";
+    error.textContent = `Failed to load queue: ${reviewState.loadError}`;
+    panel.append(error);
+    return panel;
+  }
+
+  if (!reviewState.items.length) {
+    const empty = document.createElement("p");
+    empty.className = "muted";
+    empty.textContent = "Nothing is waiting for review.";
+    panel.append(empty);
+    return panel;
+  }
+
+  const list = document.createElement("ul");
+  list.className = "memory-review-list";
+  reviewState.items.forEach((item) => list.append(createItemCard(item)));
+  panel.append(list);
+  return panel;
+}
+
+function renderMemoryReviewPage() {
+  const container = reviewState.container;
+  container.innerHTML = "";
+
+  const heading = document.createElement("h2");
+  heading.textContent = "Memory Review";
+  container.append(heading);
+
+  const subtitle = document.createElement("p");
+  subtitle.className = "muted";
+  subtitle.textContent = "Approve, edit or reject memory items held for review by memory.kind_policies. Pending items are never recalled. Every decision is written to the agent audit log.";
+  container.append(subtitle);
+
+  if (reviewState.notice) {
+    const notice = document.createElement("p");
+    notice.className = reviewState.noticeKind === "error" ? "settings-inline-error" : "settings-save-success";
+    notice.textContent = reviewState.notice;
+    container.append(notice);
+  }
+
+  const layout = document.createElement("section");
+  layout.className = "secrets-layout";
+  layout.append(createAgentPicker(), createQueuePanel());
+  container.append(layout);
+}
+
+export const memoryReviewPage = {
+  key: "memory-review",
+  title: "Memory Review",
+  async render({ container, apiClient }) {
+    const firstLoad = reviewState.container !== container;
+    reviewState.container = container;
+    reviewState.apiClient = apiClient;
+    renderMemoryReviewPage();
+    if (firstLoad) {
+      await loadPending();
+    }
+  },
+};
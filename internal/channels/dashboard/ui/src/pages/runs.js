@@ -4,6 +4,7 @@ const STATUS_FILTERS = [
   { value: "", label: "All statuses" },
   { value: "queued", label: "Queued" },
   { value: "running", label: "Running" },
+  { value: "awaiting_approval", label: "Awaiting approval" },
   { value: "completed", label: "Completed" },
   { value: "failed", label: "Failed" },
   { value: "canceled", label: "Canceled" },
@@ -0,0 +1,149 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"openclawssy/internal/channels/chat"
+)
+
+const maxFilesPerMessage = 10
+
+type OutboxFunc func(sessionID string) ([]chat.OutboundFile, error)
+
+func (b *Bot) collectAttachments(items []*discordgo.MessageAttachment) ([]chat.Attachment, []string) {
+	accepted := []chat.Attachment{}
+	skipped := []string{}
+	maxBytes := b.maxAttachmentBytes()
+	for _, item := range items {
+		if item == nil || strings.TrimSpace(item.URL) == "" {
+			continue
+		}
+		name := strings.TrimSpace(item.Filename)
+		if name == "" {
+			name = "attachment"
+		}
+		if maxBytes > 0 && int64(item.Size) > maxBytes {
+			skipped = append(skipped, fmt.Sprintf("%s (over %d MB)", name, b.cfg.AttachmentMaxMB))
+			continue
+		}
+		if !attachmentTypeAllowed(b.cfg.AttachmentTypes, name, item.ContentType) {
+			skipped = append(skipped, name+" (type not allowed)")
+			continue
+		}
+		url := item.URL
+		accepted = append(accepted, chat.Attachment{
+			Name:        name,
+			ContentType: strings.TrimSpace(item.ContentType),
+			Size:        int64(item.Size),
+			Open: func(ctx context.Context) (io.ReadCloser, error) {
+				return b.download(ctx, url)
+			},
+		})
+	}
+	return accepted, skipped
+}
+
+func (b *Bot) download(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := b.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("download attachment: unexpected status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (b *Bot) maxAttachmentBytes() int64 {
+	if b.cfg.AttachmentMaxMB <= 0 {
+		return 0
+	}
+	return int64(b.cfg.AttachmentMaxMB) * 1024 * 1024
+}
+
+func attachmentTypeAllowed(allowed []string, name, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	ext := strings.ToLower(path.Ext(name))
+	for _, rule := range allowed {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		switch {
+		case rule == "":
+			continue
+		case strings.HasPrefix(rule, "."):
+			if ext == rule {
+				return true
+			}
+		case strings.HasSuffix(rule, "/"):
+			if strings.HasPrefix(contentType, rule) {
+				return true
+			}
+		case contentType == rule:
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bot) sendOutbox(target replyTarget, sessionID string) {
+	if b.outbox == nil || strings.TrimSpace(sessionID) == "" {
+		return
+	}
+	files, err := b.outbox(sessionID)
+	if err != nil {
+		_ = target.Send("failed to collect outbox files: " + err.Error())
+		return
+	}
+	maxBytes := b.maxAttachmentBytes()
+	batch := []*discordgo.File{}
+	closers := []io.Closer{}
+	flush := func() {
+		if len(batch) > 0 {
+			_ = target.SendFiles("", batch)
+		}
+		for _, c := range closers {
+			_ = c.Close()
+		}
+		batch = batch[:0]
+		closers = closers[:0]
+	}
+	skipped := []string{}
+	for _, file := range files {
+		if maxBytes > 0 && file.Size > maxBytes {
+			skipped = append(skipped, fmt.Sprintf("`%s` (over %d MB)", file.Name, b.cfg.AttachmentMaxMB))
+			continue
+		}
+		f, err := os.Open(file.Path)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("`%s` (%v)", file.Name, err))
+			continue
+		}
+		batch = append(batch, &discordgo.File{Name: file.Name, Reader: f})
+		closers = append(closers, f)
+		if len(batch) == maxFilesPerMessage {
+			flush()
+		}
+	}
+	flush()
+	if len(skipped) > 0 {
+		_ = target.Send("not uploaded: " + strings.Join(skipped, ", "))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -15,9 +16,14 @@ import (
 )
 
 const (
-	defaultPollInterval   = 1200 * time.Millisecond
-	defaultPollTimeout    = 2 * time.Minute
-	defaultDiscordMaxSize = 1900
+	defaultPollInterval         = 1200 * time.Millisecond
+	defaultPollTimeout          = 2 * time.Minute
+	defaultDiscordMaxSize       = 1900
+	defaultStreamEditInterval   = 1500 * time.Millisecond
+	defaultStreamTimeout        = 14 * time.Minute
+	defaultDownloadTimeout      = time.Minute
+	defaultThreadArchiveMinutes = 1440
+	maxThreadNameRunes          = 90
 )
 
 type Message struct {
@@ -27,12 +33,14 @@ type Message struct {
 	Source       string
 	Text         string
 	ThinkingMode string
+	Attachments  []chat.Attachment
 }
 
 type Response struct {
-	ID       string
-	Status   string
-	Response string
+	ID        string
+	Status    string
+	Response  string
+	SessionID string
 }
 
 type RunStatus struct {
@@ -43,20 +51,66 @@ type RunStatus struct {
 	Trace        map[string]any
 }
 
+var (
+	errMessageRequired     = errors.New("message is required")
+	errInvalidThinkingMode = errors.New("request.invalid_thinking_mode: thinking must be one of never|on_error|always")
+	errUnknownCommand      = errors.New("unknown command")
+)
+
+type RunEvent struct {
+	Type string
+	Data map[string]any
+}
+
 type MessageHandler func(ctx context.Context, msg Message) (Response, error)
 type RunStatusFunc func(ctx context.Context, runID string) (RunStatus, error)
+type RunEventsFunc func(runID string) (<-chan RunEvent, func())
+
+type discordAPI interface {
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendReply(channelID string, content string, reference *discordgo.MessageReference, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ThreadStartComplex(channelID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+}
 
 type Bot struct {
-	cfg       config.DiscordConfig
-	allow     *chat.Allowlist
-	limiter   *chat.RateLimiter
-	handler   MessageHandler
-	runStatus RunStatusFunc
-	session   *discordgo.Session
-	closeOnce sync.Once
+	cfg          config.DiscordConfig
+	allow        *chat.Allowlist
+	limiter      *chat.RateLimiter
+	handler      MessageHandler
+	runStatus    RunStatusFunc
+	runEvents    RunEventsFunc
+	outbox       OutboxFunc
+	httpClient   *http.Client
+	session      *discordgo.Session
+	api          discordAPI
+	editInterval time.Duration
+	closeOnce    sync.Once
+
+	channelMu    sync.Mutex
+	channelCache map[string]*discordgo.Channel
 }
 
-func New(cfg config.Config, handler MessageHandler, runStatus RunStatusFunc) (*Bot, error) {
+type inbound struct {
+	userID       string
+	guildID      string
+	channelID    string
+	message      *discordgo.MessageCreate
+	interaction  *discordgo.Interaction
+	text         string
+	thinkingMode string
+	attachments  []chat.Attachment
+	skipped      []string
+}
+
+func New(cfg config.Config, handler MessageHandler, runStatus RunStatusFunc, runEvents RunEventsFunc, outbox OutboxFunc) (*Bot, error) {
 	token := strings.TrimSpace(cfg.Discord.Token)
 	if token == "" && cfg.Discord.TokenEnv != "" {
 		token = strings.TrimSpace(os.Getenv(cfg.Discord.TokenEnv))
@@ -70,8 +124,23 @@ func New(cfg config.Config, handler MessageHandler, runStatus RunStatusFunc) (*B
 	if err != nil {
 		return nil, err
 	}
-	b := &Bot{cfg: cfg.Discord, allow: allow, limiter: limiter, handler: handler, runStatus: runStatus, session: s}
+	b := &Bot{
+		cfg:          cfg.Discord,
+		allow:        allow,
+		limiter:      limiter,
+		handler:      handler,
+		runStatus:    runStatus,
+		runEvents:    runEvents,
+		outbox:       outbox,
+		httpClient:   &http.Client{Timeout: defaultDownloadTimeout},
+		session:      s,
+		api:          s,
+		editInterval: defaultStreamEditInterval,
+		channelCache: make(map[string]*discordgo.Channel),
+	}
 	s.AddHandler(b.onMessage)
+	s.AddHandler(b.onReady)
+	s.AddHandler(b.onInteraction)
 	s.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
 	return b, nil
 }
@@ -86,70 +155,193 @@ func (b *Bot) Stop() error {
 	return err
 }
 
-func (b *Bot) onMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *Bot) onMessage(_ *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.Author == nil || m.Author.Bot {
 		return
 	}
-	content := normalizeInboundMessage(strings.TrimSpace(m.Content), b.cfg.CommandPrefix)
+	raw := strings.TrimSpace(m.Content)
+	content := normalizeInboundMessage(raw, b.cfg.CommandPrefix)
+	thinkingMode := ""
 	if content == "" {
-		return
+		if len(m.Attachments) == 0 || !strings.HasPrefix(raw, b.cfg.CommandPrefix) {
+			return
+		}
+	} else {
+		var parseErr error
+		content, thinkingMode, parseErr = parseThinkingOverride(content)
+		if parseErr != nil {
+			_, _ = b.api.ChannelMessageSendReply(m.ChannelID, formatDiscordError(parseErr), m.Reference())
+			return
+		}
 	}
-	content, thinkingMode, parseErr := parseThinkingOverride(content)
-	if parseErr != nil {
-		_, _ = s.ChannelMessageSendReply(m.ChannelID, formatDiscordError(parseErr), m.Reference())
+	attachments, skipped := b.collectAttachments(m.Attachments)
+	if content == "" && len(attachments) == 0 {
+		_, _ = b.api.ChannelMessageSendReply(m.ChannelID, "skipped attachments: "+strings.Join(skipped, ", "), m.Reference())
 		return
 	}
+	b.dispatch(inbound{
+		userID:       m.Author.ID,
+		guildID:      m.GuildID,
+		channelID:    m.ChannelID,
+		message:      m,
+		text:         content,
+		thinkingMode: thinkingMode,
+		attachments:  attachments,
+		skipped:      skipped,
+	})
+}
 
-	if len(b.cfg.AllowGuilds) > 0 && !contains(b.cfg.AllowGuilds, m.GuildID) {
+func (b *Bot) dispatch(in inbound) {
+	if len(b.cfg.AllowGuilds) > 0 && !contains(b.cfg.AllowGuilds, in.guildID) {
+		b.rejectInteraction(in, "not allowed in this server")
 		return
 	}
-	if b.allow != nil && !b.allow.MessageAllowed(m.Author.ID, m.ChannelID) {
-		return
+	channel := b.channel(in.channelID)
+	inThread := channel != nil && channel.IsThread()
+	if b.allow != nil && !b.allow.MessageAllowed(in.userID, in.channelID) {
+		if !inThread || !b.allow.MessageAllowed(in.userID, channel.ParentID) {
+			b.rejectInteraction(in, formatDiscordError(chat.ErrNotAllowlisted))
+			return
+		}
 	}
 	if b.limiter != nil {
-		if allowed, retryAfter := b.limiter.AllowWithDetails(m.Author.ID + ":" + m.ChannelID); !allowed {
-			_, _ = s.ChannelMessageSendReply(m.ChannelID, formatDiscordRateLimit(retryAfter), m.Reference())
+		if allowed, retryAfter := b.limiter.AllowWithDetails(in.userID + ":" + in.channelID); !allowed {
+			b.reply(in, formatDiscordRateLimit(retryAfter))
 			return
 		}
 	}
 	if b.handler == nil {
-		_, _ = s.ChannelMessageSendReply(m.ChannelID, "chat handler is not configured", m.Reference())
+		b.reply(in, "chat handler is not configured")
 		return
 	}
 
+	command := ""
+	if strings.HasPrefix(in.text, "/") {
+		command = strings.ToLower(strings.Fields(in.text)[0])
+	}
+	roomID := in.channelID
+	if !inThread && in.guildID != "" && (command == "" || command == "/new") {
+		if thread, err := b.startThread(in, command); err == nil && thread != nil {
+			roomID = thread.ID
+		}
+	}
+
 	agentID := b.cfg.DefaultAgentID
 	if agentID == "" {
 		agentID = "default"
 	}
 	res, err := b.handler(context.Background(), Message{
-		UserID:       m.Author.ID,
-		RoomID:       m.ChannelID,
+		UserID:       in.userID,
+		RoomID:       roomID,
 		AgentID:      agentID,
 		Source:       "discord",
-		Text:         content,
-		ThinkingMode: thinkingMode,
+		Text:         in.text,
+		ThinkingMode: in.thinkingMode,
+		Attachments:  in.attachments,
 	})
 	if err != nil {
-		_, _ = s.ChannelMessageSendReply(m.ChannelID, formatDiscordError(err), m.Reference())
+		b.reply(in, formatDiscordError(err))
 		return
 	}
 
-	if strings.TrimSpace(res.Response) != "" {
-		b.sendChunked(s, m, res.Response)
+	text := strings.TrimSpace(res.Response)
+	if text == "" && strings.TrimSpace(res.ID) != "" {
+		text = "queued run `" + res.ID + "`"
+	}
+	if len(in.skipped) > 0 {
+		text += "\n\nskipped attachments: " + strings.Join(in.skipped, ", ")
+	}
+	var target replyTarget
+	if roomID != in.channelID {
+		if in.interaction != nil {
+			b.reply(in, "Continuing in <#"+roomID+">")
+		}
+		msg, err := b.api.ChannelMessageSend(roomID, text)
+		if err == nil && msg != nil {
+			target = channelReply{api: b.api, channelID: roomID, messageID: msg.ID}
+		}
+	} else {
+		target = b.reply(in, text)
 	}
 
-	if strings.TrimSpace(res.ID) == "" {
+	if command != "" || strings.TrimSpace(res.ID) == "" || target == nil {
 		return
 	}
+	go b.streamRun(target, res.ID, res.SessionID)
+}
 
-	if strings.TrimSpace(res.Response) == "" {
-		_, _ = s.ChannelMessageSendReply(m.ChannelID, "queued run `"+res.ID+"`", m.Reference())
+func (b *Bot) reply(in inbound, content string) replyTarget {
+	parts := splitDiscordMessage(content, defaultDiscordMaxSize)
+	var target replyTarget
+	if in.interaction != nil {
+		target = interactionReply{api: b.api, interaction: in.interaction}
+		if err := target.Edit(parts[0]); err != nil {
+			return nil
+		}
+	} else {
+		msg, err := b.api.ChannelMessageSendReply(in.channelID, parts[0], in.message.Reference())
+		if err != nil || msg == nil {
+			return nil
+		}
+		target = channelReply{api: b.api, channelID: in.channelID, messageID: msg.ID}
 	}
-	if b.runStatus == nil {
-		return
+	for _, part := range parts[1:] {
+		_ = target.Send(part)
 	}
+	return target
+}
 
-	go b.awaitAndPostResult(s, m, res.ID)
+func (b *Bot) rejectInteraction(in inbound, content string) {
+	if in.interaction != nil {
+		b.reply(in, content)
+	}
+}
+
+func (b *Bot) channel(channelID string) *discordgo.Channel {
+	b.channelMu.Lock()
+	if ch, ok := b.channelCache[channelID]; ok {
+		b.channelMu.Unlock()
+		return ch
+	}
+	b.channelMu.Unlock()
+
+	if b.session != nil && b.session.State != nil {
+		if ch, err := b.session.State.Channel(channelID); err == nil {
+			return ch
+		}
+	}
+	ch, err := b.api.Channel(channelID)
+	if err != nil {
+		return nil
+	}
+	b.channelMu.Lock()
+	if b.channelCache == nil {
+		b.channelCache = make(map[string]*discordgo.Channel)
+	}
+	b.channelCache[channelID] = ch
+	b.channelMu.Unlock()
+	return ch
+}
+
+func (b *Bot) startThread(in inbound, command string) (*discordgo.Channel, error) {
+	name := threadName(in.text)
+	if command == "/new" {
+		name = "New chat"
+	}
+	data := &discordgo.ThreadStart{Name: name, AutoArchiveDuration: defaultThreadArchiveMinutes}
+	if in.message != nil {
+		return b.api.MessageThreadStartComplex(in.channelID, in.message.ID, data)
+	}
+	data.Type = discordgo.ChannelTypeGuildPublicThread
+	return b.api.ThreadStartComplex(in.channelID, data)
+}
+
+func threadName(text string) string {
+	name := strings.Join(strings.Fields(text), " ")
+	if name == "" {
+		return "Chat"
+	}
+	return truncateRunes(name, maxThreadNameRunes-3)
 }
 
 func contains(items []string, value string) bool {
@@ -180,7 +372,7 @@ func normalizeInboundMessage(content, commandPrefix string) string {
 func parseThinkingOverride(content string) (string, string, error) {
 	clean := strings.TrimSpace(content)
 	if clean == "" {
-		return "", "", errors.New("message is required")
+		return "", "", errMessageRequired
 	}
 	lower := strings.ToLower(clean)
 	if strings.HasPrefix(clean, "/") && !strings.HasPrefix(lower, "/ask") {
@@ -190,11 +382,11 @@ func parseThinkingOverride(content string) (string, string, error) {
 		clean = strings.TrimSpace(strings.TrimSpace(clean[4:]))
 	}
 	if clean == "" {
-		return "", "", errors.New("message is required")
+		return "", "", errMessageRequired
 	}
 	parts := strings.Fields(clean)
 	if len(parts) == 0 {
-		return "", "", errors.New("message is required")
+		return "", "", errMessageRequired
 	}
 	first := strings.ToLower(strings.TrimSpace(parts[0]))
 	if !strings.HasPrefix(first, "thinking=") {
@@ -203,11 +395,11 @@ func parseThinkingOverride(content string) (string, string, error) {
 	rawMode := strings.TrimSpace(strings.TrimPrefix(parts[0], "thinking="))
 	normalized := config.NormalizeThinkingMode(rawMode)
 	if !config.IsValidThinkingMode(normalized) {
-		return "", "", fmt.Errorf("request.invalid_thinking_mode: thinking must be one of never|on_error|always")
+		return "", "", errInvalidThinkingMode
 	}
 	clean = strings.TrimSpace(strings.TrimPrefix(clean, parts[0]))
 	if clean == "" {
-		return "", "", errors.New("message is required")
+		return "", "", errMessageRequired
 	}
 	return clean, normalized, nil
 }
@@ -265,42 +457,6 @@ func formatDiscordRateLimit(retryAfter time.Duration) string {
 	return fmt.Sprintf("rate limited, retry in %ds", seconds)
 }
 
-func (b *Bot) awaitAndPostResult(s *discordgo.Session, m *discordgo.MessageCreate, runID string) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultPollTimeout)
-	defer cancel()
-
-	run, err := waitForTerminalRun(ctx, runID, b.runStatus, defaultPollInterval)
-	if err != nil {
-		_, _ = s.ChannelMessageSendReply(m.ChannelID, "failed to fetch run `"+runID+"`: "+err.Error(), m.Reference())
-		return
-	}
-
-	if strings.EqualFold(strings.TrimSpace(run.Status), "failed") {
-		msg := "run `" + runID + "` failed"
-		if strings.TrimSpace(run.Error) != "" {
-			msg += ": " + run.Error
-		}
-		if toolSummary := formatToolActivity(runID, run.Trace); toolSummary != "" {
-			b.sendChunked(s, m, toolSummary)
-		}
-		_, _ = s.ChannelMessageSendReply(m.ChannelID, msg, m.Reference())
-		return
-	}
-
-	if toolSummary := formatToolActivity(runID, run.Trace); toolSummary != "" {
-		b.sendChunked(s, m, toolSummary)
-	}
-
-	final := strings.TrimSpace(run.Output)
-	if final == "" {
-		final = "run completed without assistant output; check run trace/tool activity for details"
-	}
-	if strings.TrimSpace(run.ArtifactPath) != "" {
-		final = fmt.Sprintf("%s\n\nartifact: `%s`", final, run.ArtifactPath)
-	}
-	b.sendChunked(s, m, final)
-}
-
 func formatToolActivity(runID string, trace map[string]any) string {
 	if len(trace) == 0 {
 		return ""
@@ -399,14 +555,3 @@ func splitDiscordMessage(text string, maxLen int) []string {
 	}
 	return out
 }
-
-func (b *Bot) sendChunked(s *discordgo.Session, m *discordgo.MessageCreate, text string) {
-	parts := splitDiscordMessage(text, defaultDiscordMaxSize)
-	for i, part := range parts {
-		if i == 0 {
-			_, _ = s.ChannelMessageSendReply(m.ChannelID, part, m.Reference())
-			continue
-		}
-		_, _ = s.ChannelMessageSend(m.ChannelID, part)
-	}
-}
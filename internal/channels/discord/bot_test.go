@@ -3,13 +3,139 @@ package discord
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"openclawssy/internal/channels/chat"
+	"openclawssy/internal/config"
 )
 
+type fakeDiscordAPI struct {
+	mu           sync.Mutex
+	channels     map[string]*discordgo.Channel
+	sent         []string
+	edits        []string
+	interactions []string
+	threads      []string
+	files        []string
+	nextID       int
+	registered   map[string]int
+}
+
+func newFakeDiscordAPI() *fakeDiscordAPI {
+	return &fakeDiscordAPI{channels: map[string]*discordgo.Channel{}, registered: map[string]int{}}
+}
+
+func (f *fakeDiscordAPI) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+func (f *fakeDiscordAPI) Channel(channelID string, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ch, ok := f.channels[channelID]; ok {
+		return ch, nil
+	}
+	return &discordgo.Channel{ID: channelID, Type: discordgo.ChannelTypeGuildText}, nil
+}
+
+func (f *fakeDiscordAPI) ChannelMessageSend(channelID string, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, channelID+": "+content)
+	return &discordgo.Message{ID: f.id("msg"), ChannelID: channelID}, nil
+}
+
+func (f *fakeDiscordAPI) ChannelMessageSendReply(channelID string, content string, _ *discordgo.MessageReference, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.ChannelMessageSend(channelID, content)
+}
+
+func (f *fakeDiscordAPI) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, file := range data.Files {
+		body, _ := io.ReadAll(file.Reader)
+		f.files = append(f.files, channelID+"/"+file.Name+": "+string(body))
+	}
+	return &discordgo.Message{ID: f.id("msg"), ChannelID: channelID}, nil
+}
+
+func (f *fakeDiscordAPI) ChannelMessageEdit(channelID, messageID, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edits = append(f.edits, channelID+"/"+messageID+": "+content)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func (f *fakeDiscordAPI) MessageThreadStartComplex(channelID, _ string, data *discordgo.ThreadStart, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return f.ThreadStartComplex(channelID, data)
+}
+
+func (f *fakeDiscordAPI) ThreadStartComplex(channelID string, data *discordgo.ThreadStart, _ ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	thread := &discordgo.Channel{ID: f.id("thread"), ParentID: channelID, Name: data.Name, Type: discordgo.ChannelTypeGuildPublicThread}
+	f.channels[thread.ID] = thread
+	f.threads = append(f.threads, thread.ID+": "+data.Name)
+	return thread, nil
+}
+
+func (f *fakeDiscordAPI) InteractionRespond(_ *discordgo.Interaction, resp *discordgo.InteractionResponse, _ ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.interactions = append(f.interactions, fmt.Sprintf("respond:%d", resp.Type))
+	return nil
+}
+
+func (f *fakeDiscordAPI) InteractionResponseEdit(_ *discordgo.Interaction, newresp *discordgo.WebhookEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.interactions = append(f.interactions, "edit:"+*newresp.Content)
+	return &discordgo.Message{}, nil
+}
+
+func (f *fakeDiscordAPI) FollowupMessageCreate(_ *discordgo.Interaction, _ bool, data *discordgo.WebhookParams, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.interactions = append(f.interactions, "followup:"+data.Content)
+	return &discordgo.Message{}, nil
+}
+
+func (f *fakeDiscordAPI) ApplicationCommandBulkOverwrite(_ string, guildID string, commands []*discordgo.ApplicationCommand, _ ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.registered[guildID] = len(commands)
+	return commands, nil
+}
+
+func (f *fakeDiscordAPI) snapshot() (sent, edits, interactions, threads []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...), append([]string(nil), f.edits...), append([]string(nil), f.interactions...), append([]string(nil), f.threads...)
+}
+
+func newTestBot(api *fakeDiscordAPI, handler MessageHandler, events RunEventsFunc) *Bot {
+	return &Bot{
+		cfg:          config.DiscordConfig{DefaultAgentID: "default"},
+		allow:        chat.NewAllowlist([]string{"u1"}, []string{"c1"}),
+		handler:      handler,
+		runEvents:    events,
+		api:          api,
+		editInterval: time.Millisecond,
+		channelCache: map[string]*discordgo.Channel{},
+	}
+}
+
 func TestNormalizeInboundMessage(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -177,3 +303,275 @@ func TestFormatDiscordErrorRateLimited(t *testing.T) {
 		t.Fatalf("unexpected queue-full format: %q", msg)
 	}
 }
+
+func TestSlashAskStartsThreadAndStreamsEdits(t *testing.T) {
+	api := newFakeDiscordAPI()
+	events := make(chan RunEvent, 8)
+	var got Message
+	bot := newTestBot(api, func(ctx context.Context, msg Message) (Response, error) {
+		got = msg
+		return Response{ID: "run-1", Status: "queued", Response: "Working on it now."}, nil
+	}, func(runID string) (<-chan RunEvent, func()) {
+		if runID != "run-1" {
+			t.Fatalf("unexpected run subscription %q", runID)
+		}
+		return events, func() {}
+	})
+
+	bot.handleInteraction(&discordgo.Interaction{
+		GuildID:   "g1",
+		ChannelID: "c1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "u1"}},
+		Type:      discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "ask", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "prompt", Type: discordgo.ApplicationCommandOptionString, Value: "summarize the logs"},
+			{Name: "thinking", Type: discordgo.ApplicationCommandOptionString, Value: "always"},
+		}},
+	})
+
+	_, _, interactions, threads := api.snapshot()
+	if len(threads) != 1 || !strings.HasSuffix(threads[0], ": summarize the logs") {
+		t.Fatalf("expected one thread named after the prompt, got %#v", threads)
+	}
+	threadID := strings.SplitN(threads[0], ":", 2)[0]
+	if got.RoomID != threadID || got.Text != "summarize the logs" || got.ThinkingMode != "always" || got.Source != "discord" {
+		t.Fatalf("unexpected handler message: %+v", got)
+	}
+	if len(interactions) != 2 || interactions[1] != "edit:Continuing in <#"+threadID+">" {
+		t.Fatalf("expected deferred response pointing at thread, got %#v", interactions)
+	}
+
+	events <- RunEvent{Type: "tool_end", Data: map[string]any{"tool": "fs.read", "summary": "read 2 lines"}}
+	events <- RunEvent{Type: "model_text", Data: map[string]any{"text": "The logs ", "partial": true}}
+	events <- RunEvent{Type: "model_text", Data: map[string]any{"text": "look fine", "partial": true}}
+	waitFor(t, func() bool {
+		_, edits, _, _ := api.snapshot()
+		return len(edits) > 0 && strings.Contains(edits[len(edits)-1], "The logs look fine")
+	})
+	events <- RunEvent{Type: "completed", Data: map[string]any{"output": "The logs look fine.", "artifact_path": "runs/run-1.md"}}
+	waitFor(t, func() bool {
+		_, edits, _, _ := api.snapshot()
+		return len(edits) > 0 && strings.Contains(edits[len(edits)-1], "artifact: `runs/run-1.md`")
+	})
+
+	sent, edits, _, _ := api.snapshot()
+	if len(sent) != 1 || sent[0] != threadID+": Working on it now." {
+		t.Fatalf("expected a single placeholder reply in the thread, got %#v", sent)
+	}
+	final := edits[len(edits)-1]
+	if !strings.HasPrefix(final, threadID+"/") || !strings.Contains(final, "> `fs.read` -> read 2 lines") || !strings.Contains(final, "The logs look fine.") || strings.Contains(final, "in progress") {
+		t.Fatalf("unexpected final edit: %q", final)
+	}
+	for _, edit := range edits[:len(edits)-1] {
+		if !strings.Contains(edit, "_run `run-1` in progress_") {
+			t.Fatalf("expected in-progress footer on streaming edits, got %q", edit)
+		}
+	}
+}
+
+func TestMessageInThreadReusesThreadAndAllowsParentChannel(t *testing.T) {
+	api := newFakeDiscordAPI()
+	api.channels["t1"] = &discordgo.Channel{ID: "t1", ParentID: "c1", Type: discordgo.ChannelTypeGuildPublicThread}
+	var got Message
+	bot := newTestBot(api, func(ctx context.Context, msg Message) (Response, error) {
+		got = msg
+		return Response{Response: "Resumed chat: chat_1"}, nil
+	}, nil)
+
+	bot.onMessage(nil, &discordgo.MessageCreate{Message: &discordgo.Message{ID: "m1", GuildID: "g1", ChannelID: "t1", Content: "/resume chat_1", Author: &discordgo.User{ID: "u1"}}})
+
+	sent, _, _, threads := api.snapshot()
+	if len(threads) != 0 {
+		t.Fatalf("expected no new thread inside an existing thread, got %#v", threads)
+	}
+	if got.RoomID != "t1" || got.Text != "/resume chat_1" {
+		t.Fatalf("unexpected handler message: %+v", got)
+	}
+	if len(sent) != 1 || sent[0] != "t1: Resumed chat: chat_1" {
+		t.Fatalf("unexpected replies: %#v", sent)
+	}
+}
+
+func TestSlashNewOpensThreadForFreshSession(t *testing.T) {
+	api := newFakeDiscordAPI()
+	var got Message
+	bot := newTestBot(api, func(ctx context.Context, msg Message) (Response, error) {
+		got = msg
+		return Response{Response: "Started new chat: chat_2"}, nil
+	}, nil)
+
+	bot.handleInteraction(&discordgo.Interaction{Type: discordgo.InteractionApplicationCommand, GuildID: "g1", ChannelID: "c1", User: &discordgo.User{ID: "u1"}, Data: discordgo.ApplicationCommandInteractionData{Name: "new"}})
+
+	sent, _, _, threads := api.snapshot()
+	if len(threads) != 1 || !strings.HasSuffix(threads[0], ": New chat") {
+		t.Fatalf("expected a new thread, got %#v", threads)
+	}
+	threadID := strings.SplitN(threads[0], ":", 2)[0]
+	if got.Text != "/new" || got.RoomID != threadID {
+		t.Fatalf("unexpected handler message: %+v", got)
+	}
+	if len(sent) != 1 || sent[0] != threadID+": Started new chat: chat_2" {
+		t.Fatalf("unexpected thread reply: %#v", sent)
+	}
+}
+
+func TestInteractionTextMapsSlashCommands(t *testing.T) {
+	opt := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+	}
+	tests := []struct {
+		data discordgo.ApplicationCommandInteractionData
+		want string
+	}{
+		{data: discordgo.ApplicationCommandInteractionData{Name: "agent"}, want: "/agent"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "agent", Options: []*discordgo.ApplicationCommandInteractionDataOption{opt("agent_id", "ops")}}, want: "/agent ops"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "resume", Options: []*discordgo.ApplicationCommandInteractionDataOption{opt("session_id", "chat_1")}}, want: "/resume chat_1"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "sessions"}, want: "/sessions"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "cancel"}, want: "/cancel"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "cancel", Options: []*discordgo.ApplicationCommandInteractionDataOption{opt("run_id", "run-9")}}, want: "/cancel run-9"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "approve", Options: []*discordgo.ApplicationCommandInteractionDataOption{opt("approval_id", "apr_1")}}, want: "/approve apr_1"},
+		{data: discordgo.ApplicationCommandInteractionData{Name: "deny", Options: []*discordgo.ApplicationCommandInteractionDataOption{opt("approval_id", "apr_1"), opt("reason", "too broad")}}, want: "/deny apr_1 too broad"},
+	}
+	for _, tc := range tests {
+		got, _, err := interactionText(tc.data)
+		if err != nil || got != tc.want {
+			t.Fatalf("interactionText(%s) = %q, %v; want %q", tc.data.Name, got, err, tc.want)
+		}
+	}
+	if _, _, err := interactionText(discordgo.ApplicationCommandInteractionData{Name: "ask", Options: []*discordgo.ApplicationCommandInteractionDataOption{opt("prompt", "hi"), opt("thinking", "maybe")}}); !errors.Is(err, errInvalidThinkingMode) {
+		t.Fatalf("expected invalid thinking mode error, got %v", err)
+	}
+}
+
+func TestRegisterCommandsPerAllowedGuild(t *testing.T) {
+	api := newFakeDiscordAPI()
+	bot := newTestBot(api, nil, nil)
+	bot.cfg.AllowGuilds = []string{"g1", "g2"}
+	if err := bot.registerCommands("app"); err != nil {
+		t.Fatalf("register commands: %v", err)
+	}
+	if api.registered["g1"] != 8 || api.registered["g2"] != 8 || len(api.registered) != 2 {
+		t.Fatalf("unexpected registrations: %#v", api.registered)
+	}
+}
+
+func TestStreamReplySplitsLongFinalOutput(t *testing.T) {
+	api := newFakeDiscordAPI()
+	stream := newStreamReply(channelReply{api: api, channelID: "t1", messageID: "m1"}, "run-2", 0)
+	stream.apply(RunEvent{Type: "model_text", Data: map[string]any{"text": strings.Repeat("x", 5000), "partial": true}})
+	if rendered := stream.render(); len(rendered) > defaultDiscordMaxSize || !strings.HasPrefix(rendered, "...") {
+		t.Fatalf("expected tail-truncated streaming render within limit, got %d chars", len(rendered))
+	}
+	stream.finish(RunEvent{Type: "failed", Data: map[string]any{"error": "boom"}})
+	_, edits, _, _ := api.snapshot()
+	if len(edits) != 1 || edits[0] != "t1/m1: run `run-2` failed: boom" {
+		t.Fatalf("unexpected failure edit: %#v", edits)
+	}
+
+	stream = newStreamReply(channelReply{api: api, channelID: "t1", messageID: "m2"}, "run-3", 0)
+	stream.finish(RunEvent{Type: "completed", Data: map[string]any{"output": strings.Repeat("line\n", 600)}})
+	sent, _, _, _ := api.snapshot()
+	if len(sent) == 0 {
+		t.Fatal("expected overflow chunks to be sent as follow-up messages")
+	}
+}
+
+func TestStreamReplyPromptsForApproval(t *testing.T) {
+	api := newFakeDiscordAPI()
+	stream := newStreamReply(channelReply{api: api, channelID: "t1", messageID: "m1"}, "run-4", 0)
+	stream.apply(RunEvent{Type: "status", Data: map[string]any{"status": "running"}})
+	stream.apply(RunEvent{Type: "status", Data: map[string]any{"status": "awaiting_approval", "approval_id": "apr_9", "tool": "shell.exec", "subject": "git push"}})
+	sent, _, _, _ := api.snapshot()
+	if len(sent) != 1 || !strings.Contains(sent[0], "`shell.exec`: `git push`") || !strings.Contains(sent[0], "/approve apr_9") || !strings.Contains(sent[0], "/deny apr_9") {
+		t.Fatalf("unexpected approval prompt: %#v", sent)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMessageAttachmentsAreFilteredAndDownloaded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "panic: boom")
+	}))
+	defer server.Close()
+
+	api := newFakeDiscordAPI()
+	api.channels["t1"] = &discordgo.Channel{ID: "t1", ParentID: "c1", Type: discordgo.ChannelTypeGuildPublicThread}
+	var got Message
+	bot := newTestBot(api, func(ctx context.Context, msg Message) (Response, error) {
+		got = msg
+		return Response{Response: "ok"}, nil
+	}, nil)
+	bot.cfg.CommandPrefix = "!ask"
+	bot.cfg.AttachmentMaxMB = 1
+	bot.cfg.AttachmentTypes = []string{"text/", ".log"}
+	bot.httpClient = server.Client()
+
+	bot.onMessage(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID: "m1", GuildID: "g1", ChannelID: "t1", Content: "!ask", Author: &discordgo.User{ID: "u1"},
+		Attachments: []*discordgo.MessageAttachment{
+			{Filename: "crash.log", URL: server.URL + "/crash.log", Size: 11},
+			{Filename: "tool.exe", URL: server.URL + "/tool.exe", ContentType: "application/octet-stream", Size: 10},
+			{Filename: "dump.txt", URL: server.URL + "/dump.txt", ContentType: "text/plain", Size: 2 * 1024 * 1024},
+		},
+	}})
+
+	if got.Text != "" || len(got.Attachments) != 1 || got.Attachments[0].Name != "crash.log" {
+		t.Fatalf("expected only crash.log to be forwarded, got %+v", got)
+	}
+	rc, err := got.Attachments[0].Open(context.Background())
+	if err != nil {
+		t.Fatalf("open attachment: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(body) != "panic: boom" {
+		t.Fatalf("unexpected attachment body %q", body)
+	}
+	sent, _, _, _ := api.snapshot()
+	if len(sent) != 1 || !strings.Contains(sent[0], "skipped attachments: tool.exe (type not allowed), dump.txt (over 1 MB)") {
+		t.Fatalf("expected skipped attachment note, got %#v", sent)
+	}
+}
+
+func TestLongAnswerAndOutboxFilesAreUploaded(t *testing.T) {
+	outboxDir := t.TempDir()
+	report := filepath.Join(outboxDir, "report.csv")
+	if err := os.WriteFile(report, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatalf("write outbox file: %v", err)
+	}
+	api := newFakeDiscordAPI()
+	events := make(chan RunEvent, 1)
+	bot := newTestBot(api, nil, func(string) (<-chan RunEvent, func()) { return events, func() {} })
+	bot.cfg.FileReplyChars = 500
+	bot.outbox = func(sessionID string) ([]chat.OutboundFile, error) {
+		if sessionID != "chat_1" {
+			t.Fatalf("unexpected outbox session %q", sessionID)
+		}
+		return []chat.OutboundFile{{Name: "report.csv", Path: report, Size: 8}}, nil
+	}
+
+	long := strings.Repeat("word ", 200)
+	events <- RunEvent{Type: "completed", Data: map[string]any{"output": long}}
+	bot.streamRun(channelReply{api: api, channelID: "t1", messageID: "m1"}, "run-4", "chat_1")
+
+	_, edits, _, _ := api.snapshot()
+	if len(edits) != 1 || !strings.Contains(edits[0], "full answer attached as `answer-run-4.md`") {
+		t.Fatalf("expected preview edit pointing at the attachment, got %#v", edits)
+	}
+	api.mu.Lock()
+	files := append([]string(nil), api.files...)
+	api.mu.Unlock()
+	if len(files) != 2 || files[0] != "t1/answer-run-4.md: "+strings.TrimSpace(long) || files[1] != "t1/report.csv: a,b\n1,2\n" {
+		t.Fatalf("unexpected uploads: %#v", files)
+	}
+}
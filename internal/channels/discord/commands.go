@@ -0,0 +1,191 @@
+package discord
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"openclawssy/internal/config"
+)
+
+func slashCommands() []*discordgo.ApplicationCommand {
+	thinkingChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "never", Value: "never"},
+		{Name: "on_error", Value: "on_error"},
+		{Name: "always", Value: "always"},
+	}
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        "ask",
+			Description: "Ask the agent; starts a new thread when used outside one",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "prompt", Description: "What to ask", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "thinking", Description: "Thinking output mode", Choices: thinkingChoices},
+				{Type: discordgo.ApplicationCommandOptionAttachment, Name: "file", Description: "File for the agent to read"},
+			},
+		},
+		{
+			Name:        "agent",
+			Description: "Show or switch the active agent",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "agent_id", Description: "Agent to switch to"},
+			},
+		},
+		{Name: "new", Description: "Start a new chat in its own thread"},
+		{
+			Name:        "resume",
+			Description: "Resume a chat session in this thread",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "session_id", Description: "Session to resume", Required: true},
+			},
+		},
+		{Name: "sessions", Description: "List recent chat sessions in this thread"},
+		{
+			Name:        "cancel",
+			Description: "Cancel the running reply",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "run_id", Description: "Run to cancel (defaults to the latest)"},
+			},
+		},
+		{
+			Name:        "approve",
+			Description: "Approve a tool call the running reply is waiting on",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "approval_id", Description: "Approval to grant", Required: true},
+			},
+		},
+		{
+			Name:        "deny",
+			Description: "Deny a tool call the running reply is waiting on",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "approval_id", Description: "Approval to deny", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Why the call is denied"},
+			},
+		},
+	}
+}
+
+func (b *Bot) onReady(_ *discordgo.Session, r *discordgo.Ready) {
+	appID := ""
+	if r.Application != nil {
+		appID = r.Application.ID
+	}
+	if appID == "" && r.User != nil {
+		appID = r.User.ID
+	}
+	if appID == "" {
+		return
+	}
+	if err := b.registerCommands(appID); err != nil {
+		log.Printf("discord: register slash commands: %v", err)
+	}
+}
+
+func (b *Bot) registerCommands(appID string) error {
+	guilds := b.cfg.AllowGuilds
+	if len(guilds) == 0 {
+		guilds = []string{""}
+	}
+	for _, guildID := range guilds {
+		if _, err := b.api.ApplicationCommandBulkOverwrite(appID, guildID, slashCommands()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bot) onInteraction(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Interaction == nil || i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	b.handleInteraction(i.Interaction)
+}
+
+func (b *Bot) handleInteraction(i *discordgo.Interaction) {
+	userID := ""
+	switch {
+	case i.Member != nil && i.Member.User != nil:
+		userID = i.Member.User.ID
+	case i.User != nil:
+		userID = i.User.ID
+	}
+	if userID == "" {
+		return
+	}
+	if err := b.api.InteractionRespond(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredChannelMessageWithSource}); err != nil {
+		return
+	}
+	in := inbound{userID: userID, guildID: i.GuildID, channelID: i.ChannelID, interaction: i}
+	data := i.ApplicationCommandData()
+	text, thinkingMode, err := interactionText(data)
+	if err != nil {
+		b.reply(in, formatDiscordError(err))
+		return
+	}
+	in.text = text
+	in.thinkingMode = thinkingMode
+	in.attachments, in.skipped = b.collectAttachments(interactionAttachments(data))
+	b.dispatch(in)
+}
+
+func interactionText(data discordgo.ApplicationCommandInteractionData) (string, string, error) {
+	options := map[string]string{}
+	for _, opt := range data.Options {
+		if opt == nil {
+			continue
+		}
+		switch value := opt.Value.(type) {
+		case string:
+			options[opt.Name] = strings.TrimSpace(value)
+		case bool:
+			if value {
+				options[opt.Name] = "true"
+			}
+		}
+	}
+	switch data.Name {
+	case "ask":
+		if options["prompt"] == "" {
+			return "", "", errMessageRequired
+		}
+		mode := ""
+		if raw := options["thinking"]; raw != "" {
+			mode = config.NormalizeThinkingMode(raw)
+			if !config.IsValidThinkingMode(mode) {
+				return "", "", errInvalidThinkingMode
+			}
+		}
+		return options["prompt"], mode, nil
+	case "agent":
+		return strings.TrimSpace("/agent " + options["agent_id"]), "", nil
+	case "resume":
+		return strings.TrimSpace("/resume " + options["session_id"]), "", nil
+	case "cancel":
+		return strings.TrimSpace("/cancel " + options["run_id"]), "", nil
+	case "approve":
+		return "/approve " + options["approval_id"], "", nil
+	case "deny":
+		return strings.TrimSpace("/deny " + options["approval_id"] + " " + options["reason"]), "", nil
+	case "new", "sessions":
+		return "/" + data.Name, "", nil
+	default:
+		return "", "", errUnknownCommand
+	}
+}
+
+func interactionAttachments(data discordgo.ApplicationCommandInteractionData) []*discordgo.MessageAttachment {
+	if data.Resolved == nil || len(data.Resolved.Attachments) == 0 {
+		return nil
+	}
+	out := []*discordgo.MessageAttachment{}
+	for _, opt := range data.Options {
+		if opt == nil || opt.Type != discordgo.ApplicationCommandOptionAttachment {
+			continue
+		}
+		id, _ := opt.Value.(string)
+		if att, ok := data.Resolved.Attachments[id]; ok {
+			out = append(out, att)
+		}
+	}
+	return out
+}
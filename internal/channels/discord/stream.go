@@ -0,0 +1,307 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	runEventStatus    = "status"
+	runEventModelText = "model_text"
+	runEventToolEnd   = "tool_end"
+	runEventCompleted = "completed"
+	runEventFailed    = "failed"
+
+	maxStreamToolLines      = 6
+	defaultFilePreviewRunes = 1500
+)
+
+type replyTarget interface {
+	Edit(content string) error
+	Send(content string) error
+	SendFiles(content string, files []*discordgo.File) error
+}
+
+type channelReply struct {
+	api       discordAPI
+	channelID string
+	messageID string
+}
+
+func (r channelReply) Edit(content string) error {
+	_, err := r.api.ChannelMessageEdit(r.channelID, r.messageID, content)
+	return err
+}
+
+func (r channelReply) Send(content string) error {
+	_, err := r.api.ChannelMessageSend(r.channelID, content)
+	return err
+}
+
+func (r channelReply) SendFiles(content string, files []*discordgo.File) error {
+	_, err := r.api.ChannelMessageSendComplex(r.channelID, &discordgo.MessageSend{Content: content, Files: files})
+	return err
+}
+
+type interactionReply struct {
+	api         discordAPI
+	interaction *discordgo.Interaction
+}
+
+func (r interactionReply) Edit(content string) error {
+	_, err := r.api.InteractionResponseEdit(r.interaction, &discordgo.WebhookEdit{Content: &content})
+	return err
+}
+
+func (r interactionReply) Send(content string) error {
+	_, err := r.api.FollowupMessageCreate(r.interaction, true, &discordgo.WebhookParams{Content: content})
+	return err
+}
+
+func (r interactionReply) SendFiles(content string, files []*discordgo.File) error {
+	_, err := r.api.FollowupMessageCreate(r.interaction, true, &discordgo.WebhookParams{Content: content, Files: files})
+	return err
+}
+
+type streamReply struct {
+	target         replyTarget
+	runID          string
+	text           string
+	tools          []string
+	rendered       string
+	fileReplyChars int
+}
+
+func newStreamReply(target replyTarget, runID string, fileReplyChars int) *streamReply {
+	return &streamReply{target: target, runID: strings.TrimSpace(runID), fileReplyChars: fileReplyChars}
+}
+
+func (r *streamReply) apply(evt RunEvent) {
+	switch evt.Type {
+	case runEventModelText:
+		text, _ := evt.Data["text"].(string)
+		if partial, ok := evt.Data["partial"].(bool); ok && !partial {
+			r.text = text
+			return
+		}
+		r.text += text
+	case runEventToolEnd:
+		r.tools = append(r.tools, formatStreamTool(evt.Data))
+	case runEventStatus:
+		if status, _ := evt.Data["status"].(string); status == "awaiting_approval" {
+			_ = r.target.Send(formatApprovalPrompt(r.runID, evt.Data))
+		}
+	}
+}
+
+func formatApprovalPrompt(runID string, data map[string]any) string {
+	id, _ := data["approval_id"].(string)
+	tool, _ := data["tool"].(string)
+	line := "run `" + runID + "` is waiting for approval to call `" + tool + "`"
+	if subject, _ := data["subject"].(string); strings.TrimSpace(subject) != "" {
+		line += ": `" + truncateRunes(subject, 300) + "`"
+	}
+	if reason, _ := data["reason"].(string); strings.TrimSpace(reason) != "" {
+		line += "\n" + truncateRunes(reason, 300)
+	}
+	return line + "\nreply `/approve " + id + "` or `/deny " + id + "`"
+}
+
+func (r *streamReply) render() string {
+	sections := []string{}
+	if tools := r.toolBlock(maxStreamToolLines); tools != "" {
+		sections = append(sections, tools)
+	}
+	footer := "_run `" + r.runID + "` in progress_"
+	if text := strings.TrimSpace(r.text); text != "" {
+		budget := defaultDiscordMaxSize - len(footer) - len(strings.Join(sections, "\n\n")) - 4
+		sections = append(sections, tailTruncate(text, budget))
+	}
+	sections = append(sections, footer)
+	return strings.Join(sections, "\n\n")
+}
+
+func (r *streamReply) flush() {
+	if len(r.tools) == 0 && strings.TrimSpace(r.text) == "" {
+		return
+	}
+	content := r.render()
+	if content == r.rendered {
+		return
+	}
+	if err := r.target.Edit(content); err == nil {
+		r.rendered = content
+	}
+}
+
+func (r *streamReply) finish(evt RunEvent) {
+	var final string
+	if evt.Type == runEventFailed {
+		final = "run `" + r.runID + "` failed"
+		if errText := strings.TrimSpace(fmt.Sprintf("%v", evt.Data["error"])); errText != "" && errText != "<nil>" {
+			final += ": " + errText
+		}
+	} else {
+		final = strings.TrimSpace(fmt.Sprintf("%v", evt.Data["output"]))
+		if final == "" || final == "<nil>" {
+			final = strings.TrimSpace(r.text)
+		}
+		if final == "" {
+			final = "run completed without assistant output; check run trace/tool activity for details"
+		}
+		if artifact, _ := evt.Data["artifact_path"].(string); strings.TrimSpace(artifact) != "" {
+			final = fmt.Sprintf("%s\n\nartifact: `%s`", final, artifact)
+		}
+	}
+	if tools := r.toolBlock(0); tools != "" {
+		final = tools + "\n\n" + final
+	}
+	r.finishText(final)
+}
+
+func (r *streamReply) finishText(final string) {
+	if r.fileReplyChars > 0 && len(final) > r.fileReplyChars {
+		name := "answer-" + r.runID + ".md"
+		preview := truncateRunes(strings.TrimSpace(final), defaultFilePreviewRunes)
+		preview += "\n\nfull answer attached as `" + name + "`"
+		_ = r.target.Edit(preview)
+		r.rendered = preview
+		_ = r.target.SendFiles("", []*discordgo.File{{Name: name, ContentType: "text/markdown", Reader: strings.NewReader(final)}})
+		return
+	}
+	parts := splitDiscordMessage(final, defaultDiscordMaxSize)
+	_ = r.target.Edit(parts[0])
+	r.rendered = parts[0]
+	for _, part := range parts[1:] {
+		_ = r.target.Send(part)
+	}
+}
+
+func (r *streamReply) toolBlock(limit int) string {
+	tools := r.tools
+	skipped := 0
+	if limit > 0 && len(tools) > limit {
+		skipped = len(tools) - limit
+		tools = tools[skipped:]
+	}
+	if len(tools) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(tools)+1)
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("> ... %d earlier tool calls", skipped))
+	}
+	for _, tool := range tools {
+		lines = append(lines, "> "+tool)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatStreamTool(data map[string]any) string {
+	tool := strings.TrimSpace(fmt.Sprintf("%v", data["tool"]))
+	if tool == "" || tool == "<nil>" {
+		tool = "unknown.tool"
+	}
+	line := "`" + tool + "`"
+	if errText := strings.TrimSpace(fmt.Sprintf("%v", data["error"])); errText != "" && errText != "<nil>" {
+		return line + " -> error: " + truncateRunes(errText, 160)
+	}
+	if summary := strings.TrimSpace(fmt.Sprintf("%v", data["summary"])); summary != "" && summary != "<nil>" {
+		return line + " -> " + truncateRunes(summary, 160)
+	}
+	return line
+}
+
+func (b *Bot) streamRun(target replyTarget, runID, sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultStreamTimeout)
+	defer cancel()
+	defer b.sendOutbox(target, sessionID)
+
+	stream := newStreamReply(target, runID, b.cfg.FileReplyChars)
+	if b.runEvents != nil {
+		events, unsubscribe := b.runEvents(runID)
+		defer unsubscribe()
+		interval := b.editInterval
+		if interval <= 0 {
+			interval = defaultStreamEditInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case evt, ok := <-events:
+				if !ok {
+					break loop
+				}
+				if evt.Type == runEventCompleted || evt.Type == runEventFailed {
+					stream.finish(evt)
+					return
+				}
+				stream.apply(evt)
+			case <-ticker.C:
+				stream.flush()
+			case <-ctx.Done():
+				break loop
+			}
+		}
+	}
+	b.finishFromRunStatus(ctx, stream)
+}
+
+func (b *Bot) finishFromRunStatus(ctx context.Context, stream *streamReply) {
+	if b.runStatus == nil {
+		stream.finishText("run `" + stream.runID + "` status is unavailable")
+		return
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, defaultPollTimeout)
+	defer cancel()
+	run, err := waitForTerminalRun(pollCtx, stream.runID, b.runStatus, defaultPollInterval)
+	if err != nil {
+		stream.finishText("failed to fetch run `" + stream.runID + "`: " + err.Error())
+		return
+	}
+	final := ""
+	if strings.EqualFold(strings.TrimSpace(run.Status), "failed") {
+		final = "run `" + stream.runID + "` failed"
+		if strings.TrimSpace(run.Error) != "" {
+			final += ": " + run.Error
+		}
+	} else {
+		final = strings.TrimSpace(run.Output)
+		if final == "" {
+			final = "run completed without assistant output; check run trace/tool activity for details"
+		}
+		if strings.TrimSpace(run.ArtifactPath) != "" {
+			final = fmt.Sprintf("%s\n\nartifact: `%s`", final, run.ArtifactPath)
+		}
+	}
+	if toolSummary := formatToolActivity(stream.runID, run.Trace); toolSummary != "" {
+		final = toolSummary + "\n\n" + final
+	}
+	stream.finishText(final)
+}
+
+func tailTruncate(text string, maxLen int) string {
+	if maxLen <= 3 || len(text) <= maxLen {
+		return text
+	}
+	start := len(text) - maxLen + 3
+	for start < len(text) && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	return "..." + text[start:]
+}
+
+func truncateRunes(text string, maxRunes int) string {
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes]) + "..."
+}
@@ -0,0 +1,547 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"openclawssy/internal/channels/chat"
+	"openclawssy/internal/config"
+)
+
+const (
+	defaultDialTimeout  = 30 * time.Second
+	defaultPollTimeout  = 2 * time.Minute
+	defaultRunTimeout   = 30 * time.Minute
+	defaultRunPollEvery = 2 * time.Second
+)
+
+type Message struct {
+	UserID      string
+	RoomID      string
+	AgentID     string
+	Source      string
+	Text        string
+	Attachments []chat.Attachment
+}
+
+type Response struct {
+	ID        string
+	Status    string
+	Response  string
+	SessionID string
+}
+
+type RunStatus struct {
+	Status       string
+	Output       string
+	Error        string
+	ArtifactPath string
+}
+
+type MessageHandler func(ctx context.Context, msg Message) (Response, error)
+type RunStatusFunc func(ctx context.Context, runID string) (RunStatus, error)
+type OutboxFunc func(sessionID string) ([]chat.OutboundFile, error)
+
+// Bot turns inbound mail into chat connector messages and answers each thread
+// by SMTP once its run finishes. Mail arrives either by polling an IMAP
+// mailbox or through the built-in SMTP listener.
+type Bot struct {
+	cfg          config.EmailConfig
+	limiter      *chat.RateLimiter
+	handler      MessageHandler
+	runStatus    RunStatusFunc
+	outbox       OutboxFunc
+	imapPassword string
+	smtpAuth     smtp.Auth
+	pollInterval time.Duration
+	runPollEvery time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	listener *smtpServer
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func New(cfg config.Config, handler MessageHandler, runStatus RunStatusFunc, outbox OutboxFunc) (*Bot, error) {
+	if strings.TrimSpace(cfg.Email.FromAddress) == "" {
+		return nil, errors.New("email from_address is required")
+	}
+	if strings.TrimSpace(cfg.Email.SMTP.Addr) == "" {
+		return nil, errors.New("email smtp addr is required")
+	}
+	b := &Bot{
+		cfg:          cfg.Email,
+		limiter:      chat.NewRateLimiter(cfg.Email.RateLimitPerMin, time.Minute),
+		handler:      handler,
+		runStatus:    runStatus,
+		outbox:       outbox,
+		imapPassword: credential(cfg.Email.IMAP.Password, cfg.Email.IMAP.PasswordEnv),
+		pollInterval: time.Duration(cfg.Email.IMAP.PollSeconds) * time.Second,
+		runPollEvery: defaultRunPollEvery,
+		now:          time.Now,
+	}
+	if username := strings.TrimSpace(cfg.Email.SMTP.Username); username != "" {
+		host, _, _ := net.SplitHostPort(cfg.Email.SMTP.Addr)
+		b.smtpAuth = smtp.PlainAuth("", username, credential(cfg.Email.SMTP.Password, cfg.Email.SMTP.PasswordEnv), host)
+	}
+	if cfg.Email.Mode == "imap" && strings.TrimSpace(cfg.Email.IMAP.Addr) == "" {
+		return nil, errors.New("email imap addr is required in imap mode")
+	}
+	return b, nil
+}
+
+func credential(value, envName string) string {
+	value = strings.TrimSpace(value)
+	if value == "" && envName != "" {
+		value = strings.TrimSpace(os.Getenv(envName))
+	}
+	return value
+}
+
+func (b *Bot) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cfg.Mode == "listen" {
+		maxBytes := int64(b.cfg.Listen.MaxSizeMB) * 1024 * 1024
+		listener, err := listenSMTP(b.cfg.Listen.Addr, addressDomain(b.cfg.FromAddress), maxBytes, b.deliver)
+		if err != nil {
+			return fmt.Errorf("email listen: %w", err)
+		}
+		b.listener = listener
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+	go b.pollLoop(ctx, b.done)
+	return nil
+}
+
+func (b *Bot) Stop() error {
+	b.mu.Lock()
+	listener, cancel, done := b.listener, b.cancel, b.done
+	b.mu.Unlock()
+	var err error
+	if listener != nil {
+		err = listener.Close()
+	}
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return err
+}
+
+// ListenAddr reports the bound SMTP listener address in listen mode.
+func (b *Bot) ListenAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.listener == nil {
+		return ""
+	}
+	return b.listener.Addr()
+}
+
+func (b *Bot) deliver(_ string, _ []string, data []byte) error {
+	return b.handleMail(data)
+}
+
+func (b *Bot) pollLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	interval := b.pollInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	for {
+		if err := b.pollOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("email: imap poll: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (b *Bot) pollOnce(ctx context.Context) error {
+	pollCtx, cancel := context.WithTimeout(ctx, defaultPollTimeout)
+	defer cancel()
+	client, err := dialIMAP(pollCtx, b.cfg.IMAP.Addr, b.cfg.IMAP.Insecure)
+	if err != nil {
+		return err
+	}
+	defer client.logout()
+	if err := client.login(b.cfg.IMAP.Username, b.imapPassword); err != nil {
+		return err
+	}
+	if err := client.selectMailbox(b.cfg.IMAP.Mailbox); err != nil {
+		return err
+	}
+	uids, err := client.searchUnseen()
+	if err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		raw, err := client.fetch(uid)
+		if err != nil {
+			return err
+		}
+		if err := b.handleMail(raw); err != nil {
+			log.Printf("email: message %d: %v", uid, err)
+		}
+		// Mark seen even when the message was rejected so it is not
+		// reprocessed on every poll.
+		if err := client.markSeen(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleMail queues one inbound message. Rejections (unknown senders,
+// automated mail, loops) are dropped silently to avoid backscatter; only
+// parse failures are reported to the caller.
+func (b *Bot) handleMail(raw []byte) error {
+	in, err := parseMail(raw)
+	if err != nil {
+		return err
+	}
+	if in.from == "" || in.automated || strings.EqualFold(in.from, b.fromAddress()) {
+		return nil
+	}
+	if !senderAllowed(b.cfg.AllowSenders, in.from) {
+		log.Printf("email: dropped message from non-allowlisted sender %s", in.from)
+		return nil
+	}
+	if !senderAuthenticated(in.authResults, b.cfg.AuthservID, in.from) {
+		log.Printf("email: dropped message from %s without a passing Authentication-Results from %s", in.from, b.cfg.AuthservID)
+		return nil
+	}
+	if b.limiter != nil {
+		if allowed, retryAfter := b.limiter.AllowWithDetails(in.from); !allowed {
+			b.reply(in, formatEmailRateLimit(retryAfter), nil)
+			return nil
+		}
+	}
+	if b.handler == nil {
+		return errors.New("chat handler is not configured")
+	}
+
+	attachments, skipped := b.collectAttachments(in.parts)
+	text := in.text
+	if len(in.references) == 0 && in.subject != "" && !strings.HasPrefix(text, "/") {
+		text = strings.TrimSpace("Subject: " + in.subject + "\n\n" + text)
+	}
+	if strings.TrimSpace(text) == "" && len(attachments) == 0 {
+		return nil
+	}
+	res, err := b.handler(context.Background(), Message{
+		UserID:      in.from,
+		RoomID:      threadRoom(in),
+		AgentID:     b.cfg.DefaultAgentID,
+		Source:      "email",
+		Text:        text,
+		Attachments: attachments,
+	})
+	if err != nil {
+		b.reply(in, formatEmailError(err), nil)
+		return nil
+	}
+	if strings.HasPrefix(text, "/") || strings.TrimSpace(res.ID) == "" {
+		b.reply(in, strings.TrimSpace(res.Response), nil)
+		return nil
+	}
+	go b.replyWhenDone(in, res, skipped)
+	return nil
+}
+
+func (b *Bot) replyWhenDone(in inboundMail, res Response, skipped []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRunTimeout)
+	defer cancel()
+	body := ""
+	run, err := waitForTerminalRun(ctx, res.ID, b.runStatus, b.runPollEvery)
+	switch {
+	case err != nil:
+		body = "failed to fetch run " + res.ID + ": " + err.Error()
+	case strings.EqualFold(strings.TrimSpace(run.Status), "failed"):
+		body = "run " + res.ID + " failed"
+		if strings.TrimSpace(run.Error) != "" {
+			body += ": " + run.Error
+		}
+	default:
+		body = strings.TrimSpace(run.Output)
+		if body == "" {
+			body = "run completed without assistant output; check run trace/tool activity for details"
+		}
+		if strings.TrimSpace(run.ArtifactPath) != "" {
+			body += "\n\nartifact: " + run.ArtifactPath
+		}
+	}
+	if len(skipped) > 0 {
+		body += "\n\nskipped attachments: " + strings.Join(skipped, ", ")
+	}
+	var files []chat.OutboundFile
+	if b.outbox != nil && strings.TrimSpace(res.SessionID) != "" {
+		if out, err := b.outbox(res.SessionID); err == nil {
+			files = out
+		} else {
+			body += "\n\n(failed to collect outbox files: " + err.Error() + ")"
+		}
+	}
+	b.reply(in, body, files)
+}
+
+func (b *Bot) reply(in inboundMail, body string, files []chat.OutboundFile) {
+	if strings.TrimSpace(body) == "" {
+		return
+	}
+	to := in.replyTo
+	if to == "" {
+		to = in.from
+	}
+	references := append([]string(nil), in.references...)
+	if in.messageID != "" {
+		references = append(references, in.messageID)
+	}
+	msg, err := buildMail(outboundMail{
+		from:       b.cfg.FromAddress,
+		to:         to,
+		subject:    in.subject,
+		inReplyTo:  in.messageID,
+		references: references,
+		body:       body,
+		files:      files,
+	}, b.now())
+	if err != nil {
+		log.Printf("email: build reply: %v", err)
+		return
+	}
+	if err := smtp.SendMail(b.cfg.SMTP.Addr, b.smtpAuth, b.fromAddress(), []string{to}, msg); err != nil {
+		log.Printf("email: send reply to %s: %v", to, err)
+	}
+}
+
+func (b *Bot) fromAddress() string {
+	if addr, err := mail.ParseAddress(b.cfg.FromAddress); err == nil {
+		return strings.ToLower(addr.Address)
+	}
+	return strings.ToLower(strings.TrimSpace(b.cfg.FromAddress))
+}
+
+func (b *Bot) collectAttachments(parts []mailPart) ([]chat.Attachment, []string) {
+	accepted := []chat.Attachment{}
+	skipped := []string{}
+	maxBytes := int64(b.cfg.AttachmentMaxMB) * 1024 * 1024
+	for _, part := range parts {
+		if maxBytes > 0 && int64(len(part.data)) > maxBytes {
+			skipped = append(skipped, fmt.Sprintf("%s (over %d MB)", part.name, b.cfg.AttachmentMaxMB))
+			continue
+		}
+		if !attachmentTypeAllowed(b.cfg.AttachmentTypes, part.name, part.contentType) {
+			skipped = append(skipped, part.name+" (type not allowed)")
+			continue
+		}
+		data := part.data
+		accepted = append(accepted, chat.Attachment{
+			Name:        part.name,
+			ContentType: part.contentType,
+			Size:        int64(len(data)),
+			Open: func(context.Context) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			},
+		})
+	}
+	return accepted, skipped
+}
+
+// senderAllowed matches exact addresses and "@domain" (or bare "domain")
+// entries. An empty list admits nobody.
+func senderAllowed(allowed []string, sender string) bool {
+	sender = strings.ToLower(strings.TrimSpace(sender))
+	domain := addressDomain(sender)
+	for _, rule := range allowed {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		switch {
+		case rule == "":
+			continue
+		case strings.Contains(strings.TrimPrefix(rule, "@"), "@"):
+			if rule == sender {
+				return true
+			}
+		case strings.TrimPrefix(rule, "@") == domain:
+			return true
+		}
+	}
+	return false
+}
+
+// senderAuthenticated reports whether an Authentication-Results header added
+// by authservID shows sender's domain passing DMARC, or SPF or DKIM for an
+// aligned domain. Headers naming any other server are ignored: the sender can
+// write those.
+func senderAuthenticated(results []string, authservID, sender string) bool {
+	authservID = strings.TrimSpace(authservID)
+	domain := addressDomain(sender)
+	if authservID == "" || domain == "" {
+		return false
+	}
+	for _, header := range results {
+		parts := strings.Split(authCommentPattern.ReplaceAllString(header, " "), ";")
+		if id := strings.Fields(parts[0]); len(id) == 0 || !strings.EqualFold(id[0], authservID) {
+			continue
+		}
+		for _, part := range parts[1:] {
+			fields := strings.Fields(strings.ToLower(part))
+			if len(fields) == 0 {
+				continue
+			}
+			method, result, _ := strings.Cut(fields[0], "=")
+			if result != "pass" {
+				continue
+			}
+			props := map[string]string{}
+			for _, field := range fields[1:] {
+				if key, value, ok := strings.Cut(field, "="); ok {
+					value = strings.Trim(value, `"`)
+					if at := strings.LastIndex(value, "@"); at >= 0 {
+						value = value[at+1:]
+					}
+					props[key] = value
+				}
+			}
+			switch method {
+			case "dmarc":
+				if from, ok := props["header.from"]; !ok || from == domain {
+					return true
+				}
+			case "spf":
+				if domainsAligned(props["smtp.mailfrom"], domain) {
+					return true
+				}
+			case "dkim":
+				if domainsAligned(props["header.d"], domain) || domainsAligned(props["header.i"], domain) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// domainsAligned is DMARC's relaxed alignment, approximated without a public
+// suffix list: one domain equals or is a subdomain of the other.
+func domainsAligned(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
+
+func addressDomain(address string) string {
+	if addr, err := mail.ParseAddress(address); err == nil {
+		address = addr.Address
+	}
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return strings.ToLower(strings.TrimSpace(address[at+1:]))
+	}
+	return ""
+}
+
+func attachmentTypeAllowed(allowed []string, name, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	contentType = strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	ext := strings.ToLower(path.Ext(name))
+	for _, rule := range allowed {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		switch {
+		case rule == "":
+			continue
+		case strings.HasPrefix(rule, "."):
+			if ext == rule {
+				return true
+			}
+		case strings.HasSuffix(rule, "/"):
+			if strings.HasPrefix(contentType, rule) {
+				return true
+			}
+		case contentType == rule:
+			return true
+		}
+	}
+	return false
+}
+
+func formatEmailError(err error) string {
+	if err == nil {
+		return "request failed"
+	}
+	var cooldown interface{ RetryAfter() time.Duration }
+	if errors.As(err, &cooldown) && cooldown.RetryAfter() > 0 {
+		return formatEmailRateLimit(cooldown.RetryAfter())
+	}
+	msg := strings.TrimSpace(err.Error())
+	lower := strings.ToLower(msg)
+	switch {
+	case msg == "":
+		return "request failed"
+	case strings.Contains(lower, "rate limited"):
+		return "rate limited, try again soon"
+	case strings.Contains(lower, "run queue is full"):
+		return "run queue is full, retry shortly"
+	}
+	return "request failed: " + msg
+}
+
+func formatEmailRateLimit(retryAfter time.Duration) string {
+	if retryAfter <= 0 {
+		return "rate limited, try again soon"
+	}
+	seconds := int(retryAfter / time.Second)
+	if retryAfter%time.Second != 0 {
+		seconds++
+	}
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("rate limited, retry in %ds", seconds)
+}
+
+func waitForTerminalRun(ctx context.Context, runID string, runStatus RunStatusFunc, interval time.Duration) (RunStatus, error) {
+	if runStatus == nil {
+		return RunStatus{}, errors.New("run status lookup is not configured")
+	}
+	if interval <= 0 {
+		interval = defaultRunPollEvery
+	}
+	for {
+		run, err := runStatus(ctx, runID)
+		if err != nil {
+			return RunStatus{}, err
+		}
+		switch strings.ToLower(strings.TrimSpace(run.Status)) {
+		case "completed", "failed":
+			return run, nil
+		}
+		select {
+		case <-ctx.Done():
+			return RunStatus{}, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
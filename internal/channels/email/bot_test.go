@@ -0,0 +1,407 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"openclawssy/internal/channels/chat"
+	"openclawssy/internal/config"
+)
+
+type fakeIMAP struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	messages map[uint64][]byte
+	seen     map[uint64]bool
+	next     uint64
+}
+
+func newFakeIMAP(t *testing.T) *fakeIMAP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeIMAP{ln: ln, messages: map[uint64][]byte{}, seen: map[uint64]bool{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = ln.Close() })
+	return f
+}
+
+func (f *fakeIMAP) add(raw string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	f.messages[f.next] = []byte(strings.ReplaceAll(raw, "\n", "\r\n"))
+}
+
+func (f *fakeIMAP) isSeen(uid uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seen[uid]
+}
+
+func (f *fakeIMAP) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK fake IMAP ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, rest, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		fields := strings.Fields(rest)
+		f.mu.Lock()
+		switch {
+		case fields[0] == "LOGIN":
+			if rest != `LOGIN "agent@example.com" "s3cret"` {
+				fmt.Fprintf(conn, "%s NO invalid credentials\r\n", tag)
+				f.mu.Unlock()
+				continue
+			}
+			fmt.Fprintf(conn, "%s OK LOGIN completed\r\n", tag)
+		case fields[0] == "SELECT":
+			fmt.Fprintf(conn, "* %d EXISTS\r\n%s OK [READ-WRITE] SELECT completed\r\n", len(f.messages), tag)
+		case rest == "UID SEARCH UNSEEN":
+			var uids []string
+			for uid := uint64(1); uid <= f.next; uid++ {
+				if !f.seen[uid] {
+					uids = append(uids, fmt.Sprint(uid))
+				}
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n%s OK SEARCH completed\r\n", strings.Join(uids, " "), tag)
+		case len(fields) >= 3 && fields[0] == "UID" && fields[1] == "FETCH":
+			var uid uint64
+			fmt.Sscan(fields[2], &uid)
+			raw := f.messages[uid]
+			fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n%s OK FETCH completed\r\n", uid, uid, len(raw), raw, tag)
+		case len(fields) >= 3 && fields[0] == "UID" && fields[1] == "STORE":
+			var uid uint64
+			fmt.Sscan(fields[2], &uid)
+			f.seen[uid] = true
+			fmt.Fprintf(conn, "%s OK STORE completed\r\n", tag)
+		case fields[0] == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+			f.mu.Unlock()
+			return
+		default:
+			fmt.Fprintf(conn, "%s BAD unknown command\r\n", tag)
+		}
+		f.mu.Unlock()
+	}
+}
+
+type capturedMail struct {
+	from string
+	to   []string
+	msg  *mail.Message
+	raw  string
+}
+
+type smtpSink struct {
+	server *smtpServer
+	mu     sync.Mutex
+	mails  []capturedMail
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	sink := &smtpSink{}
+	server, err := listenSMTP("127.0.0.1:0", "mx.test", 1<<20, func(from string, to []string, data []byte) error {
+		msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+		if err != nil {
+			return err
+		}
+		sink.mu.Lock()
+		sink.mails = append(sink.mails, capturedMail{from: from, to: to, msg: msg, raw: string(data)})
+		sink.mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("listen smtp: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+	sink.server = server
+	return sink
+}
+
+func (s *smtpSink) snapshot() []capturedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]capturedMail(nil), s.mails...)
+}
+
+func testConfig(sink *smtpSink) config.Config {
+	cfg := config.Default()
+	cfg.Email.Enabled = true
+	cfg.Email.FromAddress = "Agent <agent@example.com>"
+	cfg.Email.AllowSenders = []string{"@example.com", "grace@partner.test"}
+	cfg.Email.AuthservID = "mx.example.com"
+	cfg.Email.SMTP.Addr = sink.server.Addr()
+	cfg.Email.IMAP.Username = "agent@example.com"
+	cfg.Email.IMAP.Password = "s3cret"
+	cfg.Email.IMAP.Insecure = true
+	return cfg
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+const firstMail = `Authentication-Results: mx.example.com; dmarc=pass (p=reject) header.from=example.com
+From: Ada <ada@example.com>
+To: agent@example.com
+Subject: Build report
+Message-ID: <m1@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary=XYZ
+
+--XYZ
+Content-Type: text/plain; charset=utf-8
+
+Please summarize the attached notes.
+--XYZ
+Content-Type: text/plain; name=notes.txt
+Content-Disposition: attachment; filename=notes.txt
+Content-Transfer-Encoding: base64
+
+bm90ZXMgYm9keQ==
+--XYZ
+Content-Type: application/octet-stream
+Content-Disposition: attachment; filename=tool.exe
+
+MZ
+--XYZ--
+`
+
+const followUpMail = `Authentication-Results: mx.example.com; spf=pass smtp.mailfrom=ada@example.com
+From: Ada <ada@example.com>
+To: agent@example.com
+Subject: Re: Build report
+Message-ID: <m2@example.com>
+In-Reply-To: <r1@example.com>
+References: <m1@example.com> <r1@example.com>
+Content-Type: text/plain; charset=utf-8
+
+Thanks, and the failing test?
+
+On Mon, Agent wrote:
+> All green
+`
+
+func TestIMAPPollingQueuesThreadsAndRepliesWithThreading(t *testing.T) {
+	imap := newFakeIMAP(t)
+	sink := newSMTPSink(t)
+	imap.add(firstMail)
+
+	outboxDir := t.TempDir()
+	reportPath := filepath.Join(outboxDir, "report.csv")
+	if err := os.WriteFile(reportPath, []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatalf("write outbox: %v", err)
+	}
+
+	var mu sync.Mutex
+	var got []Message
+	var saved []string
+	cfg := testConfig(sink)
+	cfg.Email.IMAP.Addr = imap.ln.Addr().String()
+	bot, err := New(cfg, func(ctx context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, msg)
+		for _, att := range msg.Attachments {
+			rc, err := att.Open(ctx)
+			if err != nil {
+				return Response{}, err
+			}
+			data, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			saved = append(saved, att.Name+"="+string(data))
+		}
+		return Response{ID: fmt.Sprintf("run_%d", len(got)), Status: "queued", SessionID: "chat_1"}, nil
+	}, func(_ context.Context, runID string) (RunStatus, error) {
+		return RunStatus{Status: "completed", Output: "All green for " + runID}, nil
+	}, func(sessionID string) ([]chat.OutboundFile, error) {
+		if sessionID != "chat_1" {
+			t.Errorf("unexpected outbox session %q", sessionID)
+		}
+		return []chat.OutboundFile{{Name: "report.csv", Path: reportPath, Size: 8}}, nil
+	})
+	if err != nil {
+		t.Fatalf("new bot: %v", err)
+	}
+	bot.pollInterval = 20 * time.Millisecond
+	bot.runPollEvery = 10 * time.Millisecond
+	if err := bot.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() { _ = bot.Stop() })
+
+	waitFor(t, func() bool { return len(sink.snapshot()) == 1 })
+	if !imap.isSeen(1) {
+		t.Fatal("expected processed message to be marked seen")
+	}
+	mu.Lock()
+	if len(got) != 1 || got[0].Source != "email" || got[0].UserID != "ada@example.com" || !strings.HasPrefix(got[0].Text, "Subject: Build report\n\nPlease summarize") {
+		mu.Unlock()
+		t.Fatalf("unexpected handler messages: %+v", got)
+	}
+	if len(saved) != 1 || saved[0] != "notes.txt=notes body" {
+		mu.Unlock()
+		t.Fatalf("expected only the allowed attachment, got %v", saved)
+	}
+	room := got[0].RoomID
+	mu.Unlock()
+
+	reply := sink.snapshot()[0]
+	if len(reply.to) != 1 || reply.to[0] != "ada@example.com" || reply.from != "agent@example.com" {
+		t.Fatalf("unexpected envelope: from=%q to=%v", reply.from, reply.to)
+	}
+	if reply.msg.Header.Get("Subject") != "Re: Build report" || reply.msg.Header.Get("In-Reply-To") != "<m1@example.com>" || reply.msg.Header.Get("References") != "<m1@example.com>" {
+		t.Fatalf("unexpected threading headers: %v", reply.msg.Header)
+	}
+	for _, want := range []string{"All green for run_1", "skipped attachments: tool.exe (type not allowed)", `filename=report.csv`} {
+		if !strings.Contains(reply.raw, want) {
+			t.Fatalf("expected reply to contain %q, got:\n%s", want, reply.raw)
+		}
+	}
+
+	imap.add(followUpMail)
+	waitFor(t, func() bool { return len(sink.snapshot()) == 2 })
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[1].RoomID != room || got[1].Text != "Thanks, and the failing test?" {
+		t.Fatalf("expected follow-up in the same thread room %q, got %+v", room, got)
+	}
+	second := sink.snapshot()[1].msg.Header
+	if second.Get("Subject") != "Re: Build report" || second.Get("References") != "<m1@example.com> <r1@example.com> <m2@example.com>" {
+		t.Fatalf("unexpected follow-up headers: %v", second)
+	}
+}
+
+func TestSMTPListenerDropsUnknownSendersAndAnswersCommands(t *testing.T) {
+	sink := newSMTPSink(t)
+	cfg := testConfig(sink)
+	cfg.Email.Mode = "listen"
+	cfg.Email.Listen.Addr = "127.0.0.1:0"
+	var mu sync.Mutex
+	var got []Message
+	bot, err := New(cfg, func(_ context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		got = append(got, msg)
+		mu.Unlock()
+		return Response{Response: "Started new chat: chat_9"}, nil
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("new bot: %v", err)
+	}
+	if err := bot.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() { _ = bot.Stop() })
+
+	send := func(from, authResults, body string) {
+		t.Helper()
+		msg := "Authentication-Results: " + authResults + "\r\nFrom: " + from + "\r\nTo: agent@example.com\r\nSubject: hello\r\nMessage-ID: <" + from + ".1>\r\n\r\n" + body + "\r\n"
+		if err := smtp.SendMail(bot.ListenAddr(), nil, from, []string{"agent@example.com"}, []byte(msg)); err != nil {
+			t.Fatalf("send mail: %v", err)
+		}
+	}
+	send("mallory@evil.test", "mx.example.com; dmarc=pass header.from=evil.test", "/new")
+	send("grace@partner.test", "mx.evil.test; dmarc=pass header.from=partner.test", "/forged")
+	send("grace@partner.test", "mx.example.com; dmarc=fail header.from=partner.test", "/spoofed")
+	send("grace@partner.test", "mx.example.com; dkim=pass header.d=partner.test; dmarc=pass header.from=partner.test", "/new")
+
+	waitFor(t, func() bool { return len(sink.snapshot()) == 1 })
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0].UserID != "grace@partner.test" || got[0].Text != "/new" {
+		t.Fatalf("expected only the allowlisted command, got %+v", got)
+	}
+	reply := sink.snapshot()[0]
+	if reply.to[0] != "grace@partner.test" || !strings.Contains(reply.raw, "Started new chat: chat_9") || reply.msg.Header.Get("Auto-Submitted") != "auto-replied" {
+		t.Fatalf("unexpected reply: %s", reply.raw)
+	}
+}
+
+func TestParseMailHandlesHTMLQuotedPrintableAndAutomatedMail(t *testing.T) {
+	raw := "From: ada@example.com\r\nSubject: =?utf-8?q?Caf=C3=A9?=\r\nAuto-Submitted: auto-replied\r\nContent-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n<p>Hello=20<b>world</b></p><div>&amp; more</div>\r\n"
+	in, err := parseMail([]byte(raw))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if in.subject != "Café" || in.text != "Hello world\n\n& more" || !in.automated {
+		t.Fatalf("unexpected parse result: %+v", in)
+	}
+	if threadRoom(in) == threadRoom(inboundMail{from: "ada@example.com", subject: "Other"}) {
+		t.Fatal("expected distinct rooms for distinct threads")
+	}
+}
+
+func TestSenderAuthenticated(t *testing.T) {
+	for header, want := range map[string]bool{
+		"mx.example.com; dmarc=pass header.from=example.com":                                       true,
+		"MX.example.com 1; spf=pass (sender SPF authorized) smtp.mailfrom=bounce@mail.example.com": true,
+		"mx.example.com; dkim=pass header.d=example.com; dmarc=fail header.from=example.com":       true,
+		"mx.example.com; spf=pass smtp.mailfrom=evil.test":                                         false,
+		"mx.example.com; dmarc=pass header.from=evil.test":                                         false,
+		"mx.example.com; dkim=fail header.d=example.com":                                           false,
+		"relay.evil.test; dmarc=pass header.from=example.com":                                      false,
+		"mx.example.com; none": false,
+	} {
+		if got := senderAuthenticated([]string{header}, "mx.example.com", "ada@example.com"); got != want {
+			t.Fatalf("senderAuthenticated(%q) = %v, want %v", header, got, want)
+		}
+	}
+	if senderAuthenticated([]string{"mx.example.com; dmarc=pass"}, "", "ada@example.com") {
+		t.Fatal("expected no trusted server to authenticate nobody")
+	}
+}
+
+func TestSenderAllowed(t *testing.T) {
+	allowed := []string{"@example.com", "partner.test", "solo@other.test"}
+	for sender, want := range map[string]bool{
+		"ada@example.com":     true,
+		"bob@partner.test":    true,
+		"solo@other.test":     true,
+		"eve@other.test":      false,
+		"eve@notexample.com":  false,
+		"eve@sub.example.com": false,
+	} {
+		if got := senderAllowed(allowed, sender); got != want {
+			t.Fatalf("senderAllowed(%q) = %v, want %v", sender, got, want)
+		}
+	}
+	if senderAllowed(nil, "ada@example.com") {
+		t.Fatal("expected empty allowlist to admit nobody")
+	}
+}
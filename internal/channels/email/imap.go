@@ -0,0 +1,174 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const maxIMAPLiteralBytes = 64 << 20
+
+var imapLiteralPattern = regexp.MustCompile(`\{(\d+)\}$`)
+
+// imapClient speaks just enough IMAP4rev1 to poll a mailbox: LOGIN, SELECT,
+// UID SEARCH, UID FETCH BODY.PEEK[] and UID STORE.
+type imapClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapData is one untagged response line with its literals split out.
+type imapData struct {
+	text     string
+	literals [][]byte
+}
+
+func dialIMAP(ctx context.Context, addr string, insecure bool) (*imapClient, error) {
+	dialer := &net.Dialer{Timeout: defaultDialTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if insecure {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("imap: dial %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c := &imapClient{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		_ = conn.Close()
+		return nil, fmt.Errorf("imap: unexpected greeting %q", greeting.text)
+	}
+	return c, nil
+}
+
+func (c *imapClient) login(username, password string) error {
+	_, err := c.command("LOGIN " + quoteIMAP(username) + " " + quoteIMAP(password))
+	return err
+}
+
+func (c *imapClient) selectMailbox(name string) error {
+	_, err := c.command("SELECT " + quoteIMAP(name))
+	return err
+}
+
+func (c *imapClient) searchUnseen() ([]uint64, error) {
+	data, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint64
+	for _, item := range data {
+		if !strings.HasPrefix(item.text, "* SEARCH") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(item.text, "* SEARCH")) {
+			if uid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				uids = append(uids, uid)
+			}
+		}
+	}
+	return uids, nil
+}
+
+func (c *imapClient) fetch(uid uint64) ([]byte, error) {
+	data, err := c.command(fmt.Sprintf("UID FETCH %d BODY.PEEK[]", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range data {
+		if strings.Contains(item.text, "FETCH") && len(item.literals) > 0 {
+			return item.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("imap: message %d has no body", uid)
+}
+
+func (c *imapClient) markSeen(uid uint64) error {
+	_, err := c.command(fmt.Sprintf(`UID STORE %d +FLAGS.SILENT (\Seen)`, uid))
+	return err
+}
+
+func (c *imapClient) logout() {
+	_, _ = c.command("LOGOUT")
+	_ = c.conn.Close()
+}
+
+func (c *imapClient) command(line string) ([]imapData, error) {
+	c.tag++
+	tag := fmt.Sprintf("A%03d", c.tag)
+	if _, err := io.WriteString(c.conn, tag+" "+line+"\r\n"); err != nil {
+		return nil, fmt.Errorf("imap: write: %w", err)
+	}
+	name := strings.Fields(line)[0]
+	var out []imapData
+	for {
+		data, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(data.text, tag+" "); ok {
+			status, detail, _ := strings.Cut(rest, " ")
+			if !strings.EqualFold(status, "OK") {
+				return nil, fmt.Errorf("imap: %s: %s %s", name, status, detail)
+			}
+			return out, nil
+		}
+		if strings.HasPrefix(data.text, "* ") {
+			out = append(out, data)
+		}
+	}
+}
+
+func (c *imapClient) readResponse() (imapData, error) {
+	var data imapData
+	var text strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return imapData{}, fmt.Errorf("imap: read: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		text.WriteString(line)
+		match := imapLiteralPattern.FindStringSubmatch(line)
+		if match == nil {
+			data.text = text.String()
+			return data, nil
+		}
+		size, err := strconv.Atoi(match[1])
+		if err != nil || size > maxIMAPLiteralBytes {
+			return imapData{}, fmt.Errorf("imap: literal too large")
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return imapData{}, fmt.Errorf("imap: read literal: %w", err)
+		}
+		data.literals = append(data.literals, literal)
+	}
+}
+
+func quoteIMAP(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
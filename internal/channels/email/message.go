@@ -0,0 +1,295 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"openclawssy/internal/channels/chat"
+)
+
+const maxMIMEDepth = 8
+
+var (
+	messageIDPattern   = regexp.MustCompile(`<[^<>\s]+>`)
+	htmlTagPattern     = regexp.MustCompile(`(?s)<[^>]*>`)
+	authCommentPattern = regexp.MustCompile(`\([^()]*\)`)
+	wordDecoder        = new(mime.WordDecoder)
+)
+
+type inboundMail struct {
+	from        string
+	replyTo     string
+	subject     string
+	messageID   string
+	references  []string
+	authResults []string
+	text        string
+	html        string
+	parts       []mailPart
+	automated   bool
+}
+
+type mailPart struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+func parseMail(raw []byte) (inboundMail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return inboundMail{}, fmt.Errorf("parse message: %w", err)
+	}
+	var in inboundMail
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		in.from = strings.ToLower(from.Address)
+	}
+	if replyTo, err := mail.ParseAddress(msg.Header.Get("Reply-To")); err == nil {
+		in.replyTo = replyTo.Address
+	}
+	in.subject = decodeHeader(msg.Header.Get("Subject"))
+	in.messageID = strings.TrimSpace(msg.Header.Get("Message-ID"))
+	in.references = messageIDPattern.FindAllString(msg.Header.Get("References"), -1)
+	if parent := messageIDPattern.FindString(msg.Header.Get("In-Reply-To")); parent != "" {
+		if len(in.references) == 0 || in.references[len(in.references)-1] != parent {
+			in.references = append(in.references, parent)
+		}
+	}
+	in.authResults = msg.Header["Authentication-Results"]
+	auto := strings.ToLower(strings.TrimSpace(msg.Header.Get("Auto-Submitted")))
+	precedence := strings.ToLower(strings.TrimSpace(msg.Header.Get("Precedence")))
+	in.automated = (auto != "" && auto != "no") || precedence == "bulk" || precedence == "list" || precedence == "junk"
+
+	if err := in.walk(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return inboundMail{}, err
+	}
+	if strings.TrimSpace(in.text) == "" && in.html != "" {
+		in.text = htmlToText(in.html)
+	}
+	in.text = stripQuotedReply(in.text)
+	return in, nil
+}
+
+func (in *inboundMail) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth || params["boundary"] == "" {
+			return nil
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("parse multipart: %w", err)
+			}
+			if err := in.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransferEncoding(body, header.Get("Content-Transfer-Encoding")))
+	if err != nil {
+		return fmt.Errorf("decode part: %w", err)
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := decodeHeader(dispositionParams["filename"])
+	if name == "" {
+		name = decodeHeader(params["name"])
+	}
+	switch {
+	case name != "" || strings.EqualFold(disposition, "attachment"):
+		if name == "" {
+			name = "attachment"
+		}
+		in.parts = append(in.parts, mailPart{name: name, contentType: mediaType, data: data})
+	case mediaType == "text/plain" && in.text == "":
+		in.text = string(data)
+	case mediaType == "text/html" && in.html == "":
+		in.html = string(data)
+	}
+	return nil
+}
+
+func decodeTransferEncoding(body io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+func htmlToText(value string) string {
+	value = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</div>", "\n").Replace(value)
+	return html.UnescapeString(htmlTagPattern.ReplaceAllString(value, ""))
+}
+
+// stripQuotedReply drops the quoted history most clients append below a
+// reply, plus a trailing "-- " signature, so each turn only carries new text.
+func stripQuotedReply(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || trimmed == "-----Original Message-----" || (strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:")) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// threadRoom maps a mail thread to a chat room id. The root of the thread is
+// the first References entry, or the message itself when it starts a thread;
+// it is hashed because Message-IDs may contain characters room ids reject.
+func threadRoom(in inboundMail) string {
+	root := in.messageID
+	if len(in.references) > 0 {
+		root = in.references[0]
+	}
+	if root == "" {
+		root = in.from + "\n" + in.subject
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(root))))
+	return "thread-" + hex.EncodeToString(sum[:8])
+}
+
+type outboundMail struct {
+	from       string
+	to         string
+	subject    string
+	inReplyTo  string
+	references []string
+	body       string
+	files      []chat.OutboundFile
+}
+
+func buildMail(out outboundMail, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+		}
+	}
+	subject := strings.TrimSpace(out.subject)
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	header("From", out.from)
+	header("To", out.to)
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", newMessageID(out.from))
+	header("In-Reply-To", out.inReplyTo)
+	header("References", strings.Join(out.references, " "))
+	header("Auto-Submitted", "auto-replied")
+	header("MIME-Version", "1.0")
+
+	if len(out.files) == 0 {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, out.body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	writer := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+	buf.WriteString("\r\n")
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintable(textPart, out.body); err != nil {
+		return nil, err
+	}
+	for _, file := range out.files {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read outbox file %s: %w", file.Name, err)
+		}
+		contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(file.Name)))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": file.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": file.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for len(encoded) > 76 {
+			_, _ = io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		_, _ = io.WriteString(part, encoded+"\r\n")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "<> ")
+	}
+	randBytes := make([]byte, 12)
+	_, _ = rand.Read(randBytes)
+	return "<" + hex.EncodeToString(randBytes) + "@" + domain + ">"
+}
@@ -0,0 +1,189 @@
+package email
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	smtpCommandTimeout = 5 * time.Minute
+	maxSMTPRecipients  = 100
+)
+
+type deliverFunc func(from string, to []string, data []byte) error
+
+// smtpServer is a minimal SMTP receiver for the listen mode. It performs no
+// authentication or relaying; it is meant to sit on a private address behind
+// an MTA that already enforces SPF/DKIM.
+type smtpServer struct {
+	ln       net.Listener
+	domain   string
+	maxBytes int64
+	deliver  deliverFunc
+	wg       sync.WaitGroup
+}
+
+func listenSMTP(addr, domain string, maxBytes int64, deliver deliverFunc) (*smtpServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if domain == "" {
+		domain = "localhost"
+	}
+	s := &smtpServer{ln: ln, domain: domain, maxBytes: maxBytes, deliver: deliver}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *smtpServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *smtpServer) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *smtpServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("email: smtp accept: %v", err)
+			}
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(smtpCommandTimeout))
+		return tp.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 %s ESMTP openclawssy", s.domain) {
+		return
+	}
+	var from string
+	var to []string
+	inTransaction := false
+	reset := func() { from, to, inTransaction = "", nil, false }
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(smtpCommandTimeout))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reset()
+			if !reply("250-%s\r\n250-SIZE %d\r\n250 8BITMIME", s.domain, s.maxBytes) {
+				return
+			}
+		case "HELO":
+			reset()
+			reply("250 %s", s.domain)
+		case "MAIL":
+			addr, ok := smtpPath(arg, "FROM:")
+			if !ok {
+				reply("501 syntax: MAIL FROM:<address>")
+				continue
+			}
+			from, to, inTransaction = addr, nil, true
+			reply("250 OK")
+		case "RCPT":
+			addr, ok := smtpPath(arg, "TO:")
+			switch {
+			case !inTransaction:
+				reply("503 need MAIL first")
+			case !ok || addr == "":
+				reply("501 syntax: RCPT TO:<address>")
+			case len(to) >= maxSMTPRecipients:
+				reply("452 too many recipients")
+			default:
+				to = append(to, addr)
+				reply("250 OK")
+			}
+		case "DATA":
+			if len(to) == 0 {
+				reply("503 need RCPT first")
+				continue
+			}
+			if !reply("354 end data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(smtpCommandTimeout))
+			dot := tp.DotReader()
+			data, err := io.ReadAll(io.LimitReader(dot, s.maxBytes+1))
+			if err != nil {
+				return
+			}
+			if int64(len(data)) > s.maxBytes {
+				_, _ = io.Copy(io.Discard, dot)
+				reply("552 message exceeds size limit")
+				reset()
+				continue
+			}
+			if err := s.deliver(from, to, data); err != nil {
+				reply("554 %s", strings.ReplaceAll(err.Error(), "\n", " "))
+			} else {
+				reply("250 OK queued")
+			}
+			reset()
+		case "RSET":
+			reset()
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// smtpPath extracts the address from "FROM:<addr> PARAMS" style arguments.
+// The null reverse-path "<>" is returned as an empty address.
+func smtpPath(arg, prefix string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if i := strings.IndexByte(path, ' '); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", false
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "<"), ">")
+	if path == "" {
+		return "", true
+	}
+	parsed, err := mail.ParseAddress("<" + path + ">")
+	if err != nil {
+		return "", false
+	}
+	return parsed.Address, true
+}
@@ -26,8 +26,14 @@ const queuedRunMaxAttempts = 2
 
 const defaultQueuedRunMaxInFlight = 64
 
+// RunStatusAwaitingApproval marks a running run paused on a tool call that
+// needs an operator decision.
+const RunStatusAwaitingApproval = "awaiting_approval"
+
 var ErrQueueFull = errors.New("httpchannel: run queue is full")
 
+var ErrRunNotActive = errors.New("httpchannel: run is not active")
+
 type QueueRunOptions struct {
 	EventBus *RunEventBus
 }
@@ -63,6 +69,9 @@ func QueueRunWithOptions(ctx context.Context, store RunStore, executor RunExecut
 
 func executeQueuedRun(ctx context.Context, store RunStore, executor RunExecutor, run Run, opts QueueRunOptions) {
 	defer defaultQueuedRunTracker.done()
+	ctx, cancel := context.WithCancel(ctx)
+	defaultQueuedRunTracker.track(run.ID, cancel)
+	defer defaultQueuedRunTracker.untrack(run.ID)
 	if opts.EventBus != nil {
 		defer opts.EventBus.Close(run.ID)
 	}
@@ -85,6 +94,9 @@ func executeQueuedRun(ctx context.Context, store RunStore, executor RunExecutor,
 			if !ok {
 				return
 			}
+			if eventKind == RunEventStatus {
+				updateQueuedRunStatus(ctx, store, run, data)
+			}
 			publishQueueRunEvent(opts.EventBus, run.ID, eventKind, cloneProgressData(data))
 		}
 	}
@@ -93,6 +105,9 @@ func executeQueuedRun(ctx context.Context, store RunStore, executor RunExecutor,
 	if err != nil {
 		run.Status = "failed"
 		run.Error = err.Error()
+		if errors.Is(err, context.Canceled) {
+			run.Error = "run canceled"
+		}
 		run.Trace = result.Trace
 		run.Provider = result.Provider
 		run.Model = result.Model
@@ -131,6 +146,21 @@ func executeQueuedRun(ctx context.Context, store RunStore, executor RunExecutor,
 	_ = store.Update(ctx, run)
 }
 
+// updateQueuedRunStatus records mid-run status changes, such as a run pausing
+// for tool approval, so polling clients see them. It writes a copy so the
+// final update after execution is unaffected.
+func updateQueuedRunStatus(ctx context.Context, store RunStore, run Run, data map[string]any) {
+	status, _ := data["status"].(string)
+	switch status {
+	case RunStatusAwaitingApproval, "running":
+	default:
+		return
+	}
+	run.Status = status
+	run.UpdatedAt = time.Now().UTC()
+	_ = store.Update(ctx, run)
+}
+
 func publishQueueRunEvent(bus *RunEventBus, runID string, eventType RunEventType, data map[string]any) {
 	if bus == nil {
 		return
@@ -218,17 +248,51 @@ func WaitForQueuedRuns(ctx context.Context) error {
 	return defaultQueuedRunTracker.wait(ctx)
 }
 
+func CancelQueuedRun(runID string) error {
+	if !defaultQueuedRunTracker.cancel(strings.TrimSpace(runID)) {
+		return ErrRunNotActive
+	}
+	return nil
+}
+
 type queuedRunTracker struct {
 	mu          sync.Mutex
 	inFlight    int
 	maxInFlight int
 	waitCh      chan struct{}
+	cancels     map[string]context.CancelFunc
 }
 
 func newQueuedRunTracker() *queuedRunTracker {
 	ch := make(chan struct{})
 	close(ch)
-	return &queuedRunTracker{waitCh: ch, maxInFlight: defaultQueuedRunMaxInFlight}
+	return &queuedRunTracker{waitCh: ch, maxInFlight: defaultQueuedRunMaxInFlight, cancels: make(map[string]context.CancelFunc)}
+}
+
+func (t *queuedRunTracker) track(runID string, cancel context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancels[runID] = cancel
+}
+
+func (t *queuedRunTracker) untrack(runID string) {
+	t.mu.Lock()
+	cancel, ok := t.cancels[runID]
+	delete(t.cancels, runID)
+	t.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (t *queuedRunTracker) cancel(runID string) bool {
+	t.mu.Lock()
+	cancel, ok := t.cancels[runID]
+	t.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
 }
 
 func (t *queuedRunTracker) tryBegin() bool {
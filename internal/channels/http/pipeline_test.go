@@ -3,6 +3,7 @@ package httpchannel
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -24,6 +25,13 @@ type flakyRetryableExecutor struct {
 
 type progressPublishingExecutor struct{}
 
+type contextBlockingExecutor struct{}
+
+func (contextBlockingExecutor) Execute(ctx context.Context, _ ExecutionInput) (ExecutionResult, error) {
+	<-ctx.Done()
+	return ExecutionResult{}, ctx.Err()
+}
+
 func (f *flakyRetryableExecutor) Execute(_ context.Context, _ ExecutionInput) (ExecutionResult, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -132,6 +140,44 @@ func TestWaitForQueuedRunsBlocksUntilCompletion(t *testing.T) {
 	}
 }
 
+func TestCancelQueuedRunStopsExecution(t *testing.T) {
+	store := NewInMemoryRunStore()
+	queued, err := QueueRun(context.Background(), store, contextBlockingExecutor{}, "agent-1", "hello", "discord", "chat_123", "")
+	if err != nil {
+		t.Fatalf("queue run: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for CancelQueuedRun(queued.ID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("run never became cancellable")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for {
+		run, getErr := store.Get(context.Background(), queued.ID)
+		if getErr != nil {
+			t.Fatalf("get run: %v", getErr)
+		}
+		if run.Status == "failed" {
+			if run.Error != "run canceled" {
+				t.Fatalf("expected canceled error, got %q", run.Error)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run did not stop in time, last status=%q", run.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for !errors.Is(CancelQueuedRun(queued.ID), ErrRunNotActive) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected run to be untracked after completion")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
 func TestQueueRunRetriesRetryableExecutorFailureOnce(t *testing.T) {
 	store := NewInMemoryRunStore()
 	exec := &flakyRetryableExecutor{}
@@ -335,6 +381,60 @@ func TestQueueRunWithOptionsPublishesProgressEvents(t *testing.T) {
 	}
 }
 
+type approvalPausingExecutor struct {
+	release chan struct{}
+}
+
+func (e approvalPausingExecutor) Execute(_ context.Context, input ExecutionInput) (ExecutionResult, error) {
+	input.OnProgress("status", map[string]any{"status": RunStatusAwaitingApproval, "approval_id": "apr_1", "tool": "shell.exec"})
+	<-e.release
+	input.OnProgress("status", map[string]any{"status": "running", "approval_id": "apr_1"})
+	return ExecutionResult{Output: "ok"}, nil
+}
+
+func TestQueueRunWithOptionsRecordsAwaitingApprovalStatus(t *testing.T) {
+	store := NewInMemoryRunStore()
+	eventBus := NewRunEventBus(16)
+	executor := approvalPausingExecutor{release: make(chan struct{})}
+
+	queued, err := QueueRunWithOptions(context.Background(), store, executor, "agent-1", "hello", "dashboard", "", "", QueueRunOptions{EventBus: eventBus})
+	if err != nil {
+		t.Fatalf("queue run: %v", err)
+	}
+
+	waitForStatus := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			run, getErr := store.Get(context.Background(), queued.ID)
+			if getErr != nil {
+				t.Fatalf("get run: %v", getErr)
+			}
+			if run.Status == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("run never reached status %q", want)
+	}
+	waitForStatus(RunStatusAwaitingApproval)
+	close(executor.release)
+	waitForStatus("completed")
+
+	ch, unsubscribe := eventBus.Subscribe(queued.ID, 0)
+	defer unsubscribe()
+	var statuses []string
+	for event := range ch {
+		if event.Type == RunEventStatus {
+			status, _ := event.Data["status"].(string)
+			statuses = append(statuses, status)
+		}
+	}
+	if strings.Join(statuses, ",") != "running,awaiting_approval,running" {
+		t.Fatalf("unexpected status events %v", statuses)
+	}
+}
+
 func TestQueueRunWithOptionsPublishesFailedTerminalEvent(t *testing.T) {
 	store := NewInMemoryRunStore()
 	eventBus := NewRunEventBus(16)
@@ -23,3 +23,30 @@ func TestSecurity_PathTraversalBypass(t *testing.T) {
 		t.Fatalf("Vulnerability confirmed: expected 401 Unauthorized, got %d. Response: %s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestSecurity_PublicPathsSkipBearerAuthOnlyForExactPath(t *testing.T) {
+	s := NewServer(Config{
+		BearerToken: "secret",
+		Store:       NewInMemoryRunStore(),
+		PublicPaths: []string{"/hooks/chat"},
+		RegisterMux: func(mux *http.ServeMux) {
+			mux.HandleFunc("/hooks/chat", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks/chat", nil)
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected public path to reach handler, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/hooks/chat/../../v1/runs", nil)
+	rr = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected traversal from public path to require auth, got %d", rr.Code)
+	}
+}
@@ -27,6 +27,8 @@ type Config struct {
 	Chat        ChatConnector
 	EventBus    *RunEventBus
 	RegisterMux func(mux *http.ServeMux)
+	// PublicPaths bypass bearer auth; their handlers must authenticate requests themselves.
+	PublicPaths []string
 }
 
 type Server struct {
@@ -36,7 +38,11 @@ type Server struct {
 	executor    RunExecutor
 	chat        ChatConnector
 	eventBus    *RunEventBus
+	publicPaths map[string]struct{}
 	httpServer  *http.Server
+
+	socketHeartbeat time.Duration
+	socketScan      time.Duration
 }
 
 type ChatMessage struct {
@@ -123,6 +129,13 @@ func NewServer(cfg Config) *Server {
 		executor:    executor,
 		chat:        cfg.Chat,
 		eventBus:    eventBus,
+		publicPaths: make(map[string]struct{}, len(cfg.PublicPaths)),
+
+		socketHeartbeat: sseHeartbeatInterval,
+		socketScan:      chatSocketScanInterval,
+	}
+	for _, p := range cfg.PublicPaths {
+		s.publicPaths[path.Clean(p)] = struct{}{}
 	}
 
 	mux := http.NewServeMux()
@@ -130,6 +143,7 @@ func NewServer(cfg Config) *Server {
 	mux.HandleFunc("/v1/runs", s.handleRuns)
 	mux.HandleFunc("/v1/runs/", s.handleRunByID)
 	mux.HandleFunc("/v1/chat/messages", s.handleChatMessage)
+	mux.HandleFunc(chatSocketPath, s.handleChatSocket)
 	if cfg.RegisterMux != nil {
 		cfg.RegisterMux(mux)
 	}
@@ -157,15 +171,38 @@ func (s *Server) handleChatMessage(w http.ResponseWriter, r *http.Request) {
 		writeErrorJSON(w, http.StatusBadRequest, "request.invalid_json", "invalid json body", 0)
 		return
 	}
-	if req.UserID == "" || req.Message == "" {
-		writeErrorJSON(w, http.StatusBadRequest, "request.invalid_input", "user_id and message are required", 0)
+	req, invalid := normalizeChatMessage(req)
+	if invalid != nil {
+		writeErrorJSON(w, http.StatusBadRequest, invalid.Code, invalid.Message, 0)
 		return
 	}
+
+	result, err := s.chat.HandleMessage(r.Context(), req)
+	if err != nil {
+		status, body := chatErrorBody(err)
+		writeErrorJSON(w, status, body.Code, body.Message, retryAfterFromError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	statusCode := http.StatusAccepted
+	if result.ID == "" {
+		statusCode = http.StatusOK
+	}
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// normalizeChatMessage validates a chat request and fills in the dashboard
+// room and default agent.
+func normalizeChatMessage(req ChatMessage) (ChatMessage, *errorBody) {
+	if req.UserID == "" || req.Message == "" {
+		return req, &errorBody{Code: "request.invalid_input", Message: "user_id and message are required"}
+	}
 	if strings.TrimSpace(req.ThinkingMode) != "" {
 		normalized := config.NormalizeThinkingMode(req.ThinkingMode)
 		if !config.IsValidThinkingMode(normalized) {
-			writeErrorJSON(w, http.StatusBadRequest, "request.invalid_thinking_mode", "thinking_mode must be one of never|on_error|always", 0)
-			return
+			return req, &errorBody{Code: "request.invalid_thinking_mode", Message: "thinking_mode must be one of never|on_error|always"}
 		}
 		req.ThinkingMode = normalized
 	}
@@ -178,24 +215,14 @@ func (s *Server) handleChatMessage(w http.ResponseWriter, r *http.Request) {
 	if req.AgentID == "" {
 		req.AgentID = "default"
 	}
+	return req, nil
+}
 
-	result, err := s.chat.HandleMessage(r.Context(), req)
-	if err != nil {
-		if isRateLimitedError(err) {
-			writeErrorJSON(w, http.StatusTooManyRequests, "chat.rate_limited", err.Error(), retryAfterFromError(err))
-			return
-		}
-		writeErrorJSON(w, http.StatusForbidden, "chat.rejected", err.Error(), 0)
-		return
+func chatErrorBody(err error) (int, errorBody) {
+	if isRateLimitedError(err) {
+		return http.StatusTooManyRequests, errorBody{Code: "chat.rate_limited", Message: err.Error()}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	statusCode := http.StatusAccepted
-	if result.ID == "" {
-		statusCode = http.StatusOK
-	}
-	w.WriteHeader(statusCode)
-	_ = json.NewEncoder(w).Encode(result)
+	return http.StatusForbidden, errorBody{Code: "chat.rejected", Message: err.Error()}
 }
 
 func (s *Server) ListenAndServe(ctx context.Context) error {
@@ -561,8 +588,17 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		if _, ok := s.publicPaths[path.Clean(r.URL.Path)]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
 
 		auth := r.Header.Get("Authorization")
+		if auth == "" && path.Clean(r.URL.Path) == chatSocketPath {
+			if token := chatSocketToken(r); token != "" {
+				auth = "Bearer " + token
+			}
+		}
 		if auth == "" {
 			http.Error(w, "missing bearer token", http.StatusUnauthorized)
 			return
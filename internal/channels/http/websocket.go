@@ -0,0 +1,352 @@
+package httpchannel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	chatSocketPath = "/v1/chat/ws"
+	// ChatSocketProtocol is the subprotocol the chat socket speaks. Browsers
+	// cannot set headers on a websocket handshake, so they offer the bearer
+	// token as a second "bearer.<token>" subprotocol instead.
+	ChatSocketProtocol     = "openclawssy.chat.v1"
+	chatSocketTokenPrefix  = "bearer."
+	chatSocketWriteTimeout = 10 * time.Second
+	chatSocketMaxFrame     = 1 << 20
+)
+
+var chatSocketScanInterval = time.Second
+
+var chatSocketUpgrader = websocket.Upgrader{
+	Subprotocols: []string{ChatSocketProtocol},
+}
+
+// chatSocketFrame is the envelope for both directions of the chat socket.
+// Clients send "send", "subscribe", "unsubscribe", "watch", "cancel" and
+// "ping"; the server answers with "ack", "event", "heartbeat", "pong" and
+// "error" frames. ID correlates a reply with the client frame that caused it.
+type chatSocketFrame struct {
+	Type         string     `json:"type"`
+	ID           string     `json:"id,omitempty"`
+	UserID       string     `json:"user_id,omitempty"`
+	RoomID       string     `json:"room_id,omitempty"`
+	AgentID      string     `json:"agent_id,omitempty"`
+	Message      string     `json:"message,omitempty"`
+	ThinkingMode string     `json:"thinking_mode,omitempty"`
+	RunID        string     `json:"run_id,omitempty"`
+	SessionID    string     `json:"session_id,omitempty"`
+	LastEventID  int64      `json:"last_event_id,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Response     string     `json:"response,omitempty"`
+	Event        *RunEvent  `json:"event,omitempty"`
+	Error        *errorBody `json:"error,omitempty"`
+	Timestamp    *time.Time `json:"ts,omitempty"`
+}
+
+type chatSocketSub struct {
+	stop func()
+}
+
+type chatSocket struct {
+	server *Server
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	heartbeatInterval time.Duration
+	scanInterval      time.Duration
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	subs     map[string]*chatSocketSub
+	seen     map[string]struct{}
+	sessions map[string]time.Time
+}
+
+func (s *Server) handleChatSocket(w http.ResponseWriter, r *http.Request) {
+	if s.eventBus == nil {
+		http.Error(w, "run event stream is disabled", http.StatusNotFound)
+		return
+	}
+	conn, err := chatSocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := &chatSocket{
+		server:   s,
+		conn:     conn,
+		ctx:      ctx,
+		cancel:   cancel,
+		subs:     make(map[string]*chatSocketSub),
+		seen:     make(map[string]struct{}),
+		sessions: make(map[string]time.Time),
+
+		heartbeatInterval: s.socketHeartbeat,
+		scanInterval:      s.socketScan,
+	}
+	cs.serve()
+}
+
+func (c *chatSocket) serve() {
+	defer c.close()
+	c.conn.SetReadLimit(chatSocketMaxFrame)
+	readTimeout := 3 * c.heartbeatInterval
+	_ = c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	go c.keepalive()
+
+	for {
+		var frame chatSocketFrame
+		if err := c.conn.ReadJSON(&frame); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				c.writeError("", "request.invalid_json", "invalid json frame")
+				continue
+			}
+			return
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		c.handleFrame(frame)
+	}
+}
+
+func (c *chatSocket) close() {
+	c.cancel()
+	c.mu.Lock()
+	for runID, sub := range c.subs {
+		sub.stop()
+		delete(c.subs, runID)
+	}
+	c.mu.Unlock()
+	_ = c.conn.Close()
+}
+
+// keepalive sends heartbeats and pings, and picks up new runs in watched
+// sessions that were started elsewhere (another tab, a channel, the scheduler).
+func (c *chatSocket) keepalive() {
+	heartbeat := time.NewTicker(c.heartbeatInterval)
+	defer heartbeat.Stop()
+	scan := time.NewTicker(c.scanInterval)
+	defer scan.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-heartbeat.C:
+			now := time.Now().UTC()
+			if err := c.write(chatSocketFrame{Type: "heartbeat", Timestamp: &now}); err != nil {
+				c.cancel()
+				return
+			}
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(chatSocketWriteTimeout)); err != nil {
+				c.cancel()
+				return
+			}
+		case <-scan.C:
+			c.scanSessions()
+		}
+	}
+}
+
+func (c *chatSocket) handleFrame(frame chatSocketFrame) {
+	switch strings.ToLower(strings.TrimSpace(frame.Type)) {
+	case "send":
+		c.handleSend(frame)
+	case "subscribe":
+		if !isValidRunID(frame.RunID) {
+			c.writeError(frame.ID, "request.invalid_run_id", "invalid run id")
+			return
+		}
+		if frame.LastEventID < 0 {
+			c.writeError(frame.ID, "request.invalid_input", "last_event_id must be >= 0")
+			return
+		}
+		c.subscribe(frame.RunID, frame.LastEventID, true)
+		c.write(chatSocketFrame{Type: "ack", ID: frame.ID, RunID: frame.RunID})
+	case "unsubscribe":
+		c.unsubscribe(frame.RunID)
+		c.write(chatSocketFrame{Type: "ack", ID: frame.ID, RunID: frame.RunID})
+	case "watch":
+		sessionID := strings.TrimSpace(frame.SessionID)
+		if sessionID == "" {
+			c.writeError(frame.ID, "request.invalid_input", "session_id is required")
+			return
+		}
+		c.watch(sessionID)
+		c.write(chatSocketFrame{Type: "ack", ID: frame.ID, SessionID: sessionID})
+	case "cancel":
+		if !isValidRunID(frame.RunID) {
+			c.writeError(frame.ID, "request.invalid_run_id", "invalid run id")
+			return
+		}
+		if err := CancelQueuedRun(frame.RunID); err != nil {
+			c.writeError(frame.ID, "run.not_active", "run is not active")
+			return
+		}
+		c.write(chatSocketFrame{Type: "ack", ID: frame.ID, RunID: frame.RunID, Status: "canceling"})
+	case "ping":
+		c.write(chatSocketFrame{Type: "pong", ID: frame.ID})
+	default:
+		c.writeError(frame.ID, "request.invalid_type", "unknown frame type")
+	}
+}
+
+func (c *chatSocket) handleSend(frame chatSocketFrame) {
+	if c.server.chat == nil {
+		c.writeError(frame.ID, "chat.disabled", "chat connector is disabled")
+		return
+	}
+	req, invalid := normalizeChatMessage(ChatMessage{
+		UserID:       frame.UserID,
+		RoomID:       frame.RoomID,
+		AgentID:      frame.AgentID,
+		Message:      frame.Message,
+		ThinkingMode: frame.ThinkingMode,
+	})
+	if invalid != nil {
+		c.writeError(frame.ID, invalid.Code, invalid.Message)
+		return
+	}
+	result, err := c.server.chat.HandleMessage(c.ctx, req)
+	if err != nil {
+		_, body := chatErrorBody(err)
+		if retryAfter := retryAfterFromError(err); retryAfter > 0 {
+			body.RetryAfterSeconds = int((retryAfter + time.Second - 1) / time.Second)
+		}
+		c.write(chatSocketFrame{Type: "error", ID: frame.ID, Error: &body})
+		return
+	}
+	// Subscribe before acking so no event of the new run can slip past the
+	// client between the ack and its own subscribe.
+	if result.ID != "" {
+		c.subscribe(result.ID, 0, false)
+	}
+	if result.SessionID != "" {
+		c.watch(result.SessionID)
+	}
+	c.write(chatSocketFrame{
+		Type:      "ack",
+		ID:        frame.ID,
+		RunID:     result.ID,
+		Status:    result.Status,
+		Response:  result.Response,
+		SessionID: result.SessionID,
+	})
+}
+
+// subscribe streams a run's events to the client. An explicit subscribe
+// replaces an existing subscription so clients can resume from a later event
+// ID; automatic ones (after a send, from a session watch) only start a run once.
+func (c *chatSocket) subscribe(runID string, lastEventID int64, replace bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.seen[runID]; ok && !replace {
+		return
+	}
+	events, stop := c.server.eventBus.Subscribe(runID, lastEventID)
+	sub := &chatSocketSub{stop: stop}
+	if previous, ok := c.subs[runID]; ok {
+		previous.stop()
+	}
+	c.subs[runID] = sub
+	c.seen[runID] = struct{}{}
+
+	go func() {
+		for event := range events {
+			if err := c.write(chatSocketFrame{Type: "event", Event: &event}); err != nil {
+				c.cancel()
+				return
+			}
+		}
+		c.mu.Lock()
+		// A resubscribe replaces the entry; only drop it if it is still ours.
+		if c.subs[runID] == sub {
+			delete(c.subs, runID)
+		}
+		c.mu.Unlock()
+	}()
+}
+
+func (c *chatSocket) unsubscribe(runID string) {
+	c.mu.Lock()
+	sub, ok := c.subs[runID]
+	delete(c.subs, runID)
+	c.mu.Unlock()
+	if ok {
+		sub.stop()
+	}
+}
+
+func (c *chatSocket) watch(sessionID string) {
+	c.mu.Lock()
+	if _, ok := c.sessions[sessionID]; !ok {
+		c.sessions[sessionID] = time.Now().UTC()
+	}
+	c.mu.Unlock()
+	c.scanSessions()
+}
+
+// scanSessions subscribes to runs of watched sessions that are still active or
+// were created after the watch began.
+func (c *chatSocket) scanSessions() {
+	c.mu.Lock()
+	if len(c.sessions) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	sessions := make(map[string]time.Time, len(c.sessions))
+	for id, since := range c.sessions {
+		sessions[id] = since
+	}
+	c.mu.Unlock()
+
+	runs, err := c.server.store.List(c.ctx)
+	if err != nil {
+		return
+	}
+	for _, run := range runs {
+		since, ok := sessions[run.SessionID]
+		if !ok || run.SessionID == "" {
+			continue
+		}
+		active := run.Status == "queued" || run.Status == "running" || run.Status == RunStatusAwaitingApproval
+		if !active && run.CreatedAt.Before(since) {
+			continue
+		}
+		c.subscribe(run.ID, 0, false)
+	}
+}
+
+func (c *chatSocket) writeError(id, code, message string) {
+	c.write(chatSocketFrame{Type: "error", ID: id, Error: &errorBody{Code: code, Message: message}})
+}
+
+func (c *chatSocket) write(frame chatSocketFrame) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(chatSocketWriteTimeout))
+	return c.conn.WriteJSON(frame)
+}
+
+// chatSocketToken extracts the bearer token offered as a subprotocol.
+func chatSocketToken(r *http.Request) string {
+	for _, protocol := range websocket.Subprotocols(r) {
+		if token, ok := strings.CutPrefix(protocol, chatSocketTokenPrefix); ok {
+			return token
+		}
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+package httpchannel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func dialChatSocket(t *testing.T, s *Server, protocols ...string) *websocket.Conn {
+	t.Helper()
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(httpServer.Close)
+	dialer := websocket.Dialer{Subprotocols: protocols}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+chatSocketPath, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial chat socket: %v (status %d)", err, status)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if conn.Subprotocol() != ChatSocketProtocol {
+		t.Fatalf("expected subprotocol %q, got %q", ChatSocketProtocol, conn.Subprotocol())
+	}
+	return conn
+}
+
+func readChatFrame(t *testing.T, conn *websocket.Conn) chatSocketFrame {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var frame chatSocketFrame
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	return frame
+}
+
+// readUntil skips heartbeats and unrelated frames until match returns true.
+func readUntil(t *testing.T, conn *websocket.Conn, match func(chatSocketFrame) bool) chatSocketFrame {
+	t.Helper()
+	for i := 0; i < 50; i++ {
+		frame := readChatFrame(t, conn)
+		if match(frame) {
+			return frame
+		}
+	}
+	t.Fatal("expected frame was not received")
+	return chatSocketFrame{}
+}
+
+func TestChatSocket_RequiresBearerToken(t *testing.T) {
+	s := NewServer(Config{BearerToken: "secret", Store: NewInMemoryRunStore()})
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + chatSocketPath
+	dialer := websocket.Dialer{Subprotocols: []string{ChatSocketProtocol, "bearer.wrong"}}
+	_, resp, err := dialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("expected handshake with wrong token to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %+v", resp)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	conn, _, err := (&websocket.Dialer{Subprotocols: []string{ChatSocketProtocol}}).Dial(url, header)
+	if err != nil {
+		t.Fatalf("expected authorization header to be accepted: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestChatSocket_SendAcksAndStreamsRunEvents(t *testing.T) {
+	bus := NewRunEventBus(16)
+	s := NewServer(Config{BearerToken: "secret", Store: NewInMemoryRunStore(), EventBus: bus, Chat: testChatConnector{}})
+	conn := dialChatSocket(t, s, ChatSocketProtocol, "bearer.secret")
+
+	if err := conn.WriteJSON(map[string]any{"type": "send", "id": "c1", "user_id": "u1", "message": "hello"}); err != nil {
+		t.Fatalf("write send: %v", err)
+	}
+	ack := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "ack" })
+	if ack.ID != "c1" || ack.RunID != "run-chat" || ack.Status != "queued" {
+		t.Fatalf("unexpected ack: %+v", ack)
+	}
+
+	bus.Publish("run-chat", RunEvent{Type: RunEventModelText, Data: map[string]any{"text": "hi", "partial": true}})
+	bus.Publish("run-chat", RunEvent{Type: RunEventToolEnd, Data: map[string]any{"tool": "fs.list"}})
+	bus.Publish("run-chat", RunEvent{Type: RunEventCompleted, Data: map[string]any{"output": "hi"}})
+	bus.Close("run-chat")
+
+	var got []RunEventType
+	for len(got) < 3 {
+		frame := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "event" })
+		if frame.Event.RunID != "run-chat" {
+			t.Fatalf("unexpected run in event: %+v", frame.Event)
+		}
+		got = append(got, frame.Event.Type)
+	}
+	if got[0] != RunEventModelText || got[1] != RunEventToolEnd || got[2] != RunEventCompleted {
+		t.Fatalf("unexpected event order: %v", got)
+	}
+}
+
+func TestChatSocket_SendReportsChatErrors(t *testing.T) {
+	s := NewServer(Config{BearerToken: "secret", Store: NewInMemoryRunStore(), Chat: testChatConnector{err: rateLimitedTestError{retryAfter: 2 * time.Second}}})
+	conn := dialChatSocket(t, s, ChatSocketProtocol, "bearer.secret")
+
+	if err := conn.WriteJSON(map[string]any{"type": "send", "id": "c1", "user_id": "u1", "message": "hello"}); err != nil {
+		t.Fatalf("write send: %v", err)
+	}
+	frame := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "error" })
+	if frame.ID != "c1" || frame.Error.Code != "chat.rate_limited" || frame.Error.RetryAfterSeconds != 2 {
+		t.Fatalf("unexpected error frame: %+v %+v", frame, frame.Error)
+	}
+
+	if err := conn.WriteJSON(map[string]any{"type": "send", "id": "c2", "message": "hello"}); err != nil {
+		t.Fatalf("write send: %v", err)
+	}
+	frame = readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "error" })
+	if frame.ID != "c2" || frame.Error.Code != "request.invalid_input" {
+		t.Fatalf("unexpected validation error: %+v", frame.Error)
+	}
+}
+
+func TestChatSocket_SubscribeResumesAfterLastEventID(t *testing.T) {
+	bus := NewRunEventBus(16)
+	runID := "run_resume"
+	bus.Publish(runID, RunEvent{Type: RunEventStatus, Data: map[string]any{"status": "running"}})
+	bus.Publish(runID, RunEvent{Type: RunEventModelText, Data: map[string]any{"text": "a", "partial": true}})
+	bus.Publish(runID, RunEvent{Type: RunEventModelText, Data: map[string]any{"text": "b", "partial": true}})
+
+	s := NewServer(Config{BearerToken: "secret", Store: NewInMemoryRunStore(), EventBus: bus})
+	conn := dialChatSocket(t, s, ChatSocketProtocol, "bearer.secret")
+
+	if err := conn.WriteJSON(map[string]any{"type": "subscribe", "id": "s1", "run_id": runID, "last_event_id": 2}); err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+	frame := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "event" })
+	if frame.Event.ID != 3 || frame.Event.Data["text"] != "b" {
+		t.Fatalf("expected replay to resume at event 3, got %+v", frame.Event)
+	}
+}
+
+func TestChatSocket_WatchPicksUpNewSessionRuns(t *testing.T) {
+	originalScan := chatSocketScanInterval
+	chatSocketScanInterval = 20 * time.Millisecond
+	defer func() { chatSocketScanInterval = originalScan }()
+
+	bus := NewRunEventBus(16)
+	store := NewInMemoryRunStore()
+	s := NewServer(Config{BearerToken: "secret", Store: store, EventBus: bus})
+	conn := dialChatSocket(t, s, ChatSocketProtocol, "bearer.secret")
+
+	if err := conn.WriteJSON(map[string]any{"type": "watch", "id": "w1", "session_id": "chat_1"}); err != nil {
+		t.Fatalf("write watch: %v", err)
+	}
+	readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "ack" && f.ID == "w1" })
+
+	now := time.Now().UTC()
+	if _, err := store.Create(context.Background(), Run{ID: "run_other", SessionID: "chat_2", Status: "running", CreatedAt: now}); err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	if _, err := store.Create(context.Background(), Run{ID: "run_scheduled", SessionID: "chat_1", Status: "queued", CreatedAt: now}); err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	bus.Publish("run_other", RunEvent{Type: RunEventStatus, Data: map[string]any{"status": "running"}})
+	bus.Publish("run_scheduled", RunEvent{Type: RunEventStatus, Data: map[string]any{"status": "running"}})
+
+	frame := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "event" })
+	if frame.Event.RunID != "run_scheduled" {
+		t.Fatalf("expected event from watched session run, got %+v", frame.Event)
+	}
+}
+
+func TestChatSocket_HeartbeatAndPing(t *testing.T) {
+	original := sseHeartbeatInterval
+	sseHeartbeatInterval = 20 * time.Millisecond
+	defer func() { sseHeartbeatInterval = original }()
+
+	s := NewServer(Config{BearerToken: "secret", Store: NewInMemoryRunStore()})
+	conn := dialChatSocket(t, s, ChatSocketProtocol, "bearer.secret")
+
+	frame := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "heartbeat" })
+	if frame.Timestamp == nil || frame.Timestamp.IsZero() {
+		t.Fatalf("expected heartbeat timestamp, got %+v", frame)
+	}
+	if err := conn.WriteJSON(map[string]any{"type": "ping", "id": "p1"}); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "pong" && f.ID == "p1" })
+}
+
+func TestChatSocket_CancelAndUnknownFrames(t *testing.T) {
+	s := NewServer(Config{BearerToken: "secret", Store: NewInMemoryRunStore()})
+	conn := dialChatSocket(t, s, ChatSocketProtocol, "bearer.secret")
+
+	if err := conn.WriteJSON(map[string]any{"type": "cancel", "id": "x1", "run_id": "run_missing"}); err != nil {
+		t.Fatalf("write cancel: %v", err)
+	}
+	frame := readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "error" })
+	if frame.ID != "x1" || frame.Error.Code != "run.not_active" {
+		t.Fatalf("unexpected cancel error: %+v", frame.Error)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("{not json")); err != nil {
+		t.Fatalf("write garbage: %v", err)
+	}
+	frame = readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "error" })
+	if frame.Error.Code != "request.invalid_json" {
+		t.Fatalf("unexpected json error: %+v", frame.Error)
+	}
+
+	if err := conn.WriteJSON(map[string]any{"type": "bogus", "id": "b1"}); err != nil {
+		t.Fatalf("write bogus: %v", err)
+	}
+	frame = readUntil(t, conn, func(f chatSocketFrame) bool { return f.Type == "error" })
+	if frame.ID != "b1" || frame.Error.Code != "request.invalid_type" {
+		t.Fatalf("unexpected type error: %+v", frame.Error)
+	}
+}
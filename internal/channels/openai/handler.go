@@ -0,0 +1,519 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"openclawssy/internal/chatstore"
+	"openclawssy/internal/config"
+)
+
+const (
+	// SessionHeader pins a request to an existing chat session. The session
+	// keeps the conversation history, so only the last user message is used.
+	SessionHeader = "X-Openclawssy-Session"
+	RunHeader     = "X-Openclawssy-Run"
+
+	runEventModelText = "model_text"
+	runEventCompleted = "completed"
+	runEventFailed    = "failed"
+
+	source           = "openai"
+	maxRequestBytes  = 4 << 20
+	modelOwner       = "openclawssy"
+	completionObject = "chat.completion"
+	chunkObject      = "chat.completion.chunk"
+)
+
+type RunEvent struct {
+	Type string
+	Data map[string]any
+}
+
+type QueueFunc func(ctx context.Context, agentID, message, sessionID string) (string, error)
+
+type RunEventsFunc func(runID string) (<-chan RunEvent, func())
+
+type CancelFunc func(runID string) error
+
+type AgentsFunc func() ([]string, error)
+
+type SessionStore interface {
+	GetSession(sessionID string) (chatstore.Session, error)
+	AppendMessage(sessionID string, msg chatstore.Message) error
+}
+
+type Options struct {
+	Queue     QueueFunc
+	RunEvents RunEventsFunc
+	Cancel    CancelFunc
+	Agents    AgentsFunc
+	Sessions  SessionStore
+}
+
+// Handler serves an OpenAI-compatible facade where the requested model names
+// an agent. Requests are queued as ordinary runs, so the agent keeps its full
+// tool loop and policy.
+type Handler struct {
+	allowAgents map[string]struct{}
+	queue       QueueFunc
+	runEvents   RunEventsFunc
+	cancel      CancelFunc
+	agents      AgentsFunc
+	sessions    SessionStore
+	now         func() time.Time
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type completionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+func New(cfg config.Config, opts Options) *Handler {
+	allow := make(map[string]struct{}, len(cfg.OpenAI.AllowAgents))
+	for _, id := range cfg.OpenAI.AllowAgents {
+		if id = strings.TrimSpace(id); id != "" {
+			allow[id] = struct{}{}
+		}
+	}
+	return &Handler{
+		allowAgents: allow,
+		queue:       opts.Queue,
+		runEvents:   opts.RunEvents,
+		cancel:      opts.Cancel,
+		agents:      opts.Agents,
+		sessions:    opts.Sessions,
+		now:         time.Now,
+	}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/chat/completions", h.handleChatCompletions)
+	mux.HandleFunc("/v1/models", h.handleModels)
+}
+
+func (h *Handler) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method_not_allowed", "method not allowed")
+		return
+	}
+	ids, err := h.listModels()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "agents_unavailable", "failed to list agents")
+		return
+	}
+	data := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		data = append(data, map[string]any{"id": id, "object": "model", "created": 0, "owned_by": modelOwner})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+func (h *Handler) listModels() ([]string, error) {
+	if h.agents == nil {
+		return nil, nil
+	}
+	ids, err := h.agents()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if h.agentAllowed(id) {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func (h *Handler) agentAllowed(agentID string) bool {
+	if len(h.allowAgents) == 0 {
+		return true
+	}
+	_, ok := h.allowAgents[agentID]
+	return ok
+}
+
+func (h *Handler) modelExists(agentID string) (bool, error) {
+	ids, err := h.listModels()
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == agentID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (h *Handler) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method_not_allowed", "method not allowed")
+		return
+	}
+	var req completionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid_json", "invalid json body")
+		return
+	}
+	agentID := strings.TrimSpace(req.Model)
+	if agentID == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "model_required", "model is required")
+		return
+	}
+	exists, err := h.modelExists(agentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "agents_unavailable", "failed to list agents")
+		return
+	}
+	if !exists {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found", fmt.Sprintf("model %q does not exist", agentID))
+		return
+	}
+
+	sessionID := strings.TrimSpace(r.Header.Get(SessionHeader))
+	var message string
+	if sessionID != "" {
+		last, err := lastUserMessage(req.Messages)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid_messages", err.Error())
+			return
+		}
+		if status, code, err := h.pinSession(sessionID, agentID, last); err != nil {
+			writeError(w, status, "invalid_request_error", code, err.Error())
+			return
+		}
+		message = last
+	} else {
+		message, err = flattenMessages(req.Messages)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid_messages", err.Error())
+			return
+		}
+	}
+
+	if h.queue == nil || h.runEvents == nil {
+		writeError(w, http.StatusServiceUnavailable, "server_error", "runs_unavailable", "run queue is unavailable")
+		return
+	}
+	runID, err := h.queue(r.Context(), agentID, message, sessionID)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "server_error", "queue_failed", err.Error())
+		return
+	}
+	events, stop := h.runEvents(runID)
+	defer stop()
+
+	w.Header().Set(RunHeader, runID)
+	if sessionID != "" {
+		w.Header().Set(SessionHeader, sessionID)
+	}
+	completion := completionMeta{ID: "chatcmpl-" + newCompletionSuffix(), Created: h.now().Unix(), Model: agentID}
+	if req.Stream {
+		h.streamCompletion(w, r, runID, completion, events)
+		return
+	}
+	h.waitCompletion(w, r, runID, completion, events)
+}
+
+func (h *Handler) pinSession(sessionID, agentID, message string) (int, string, error) {
+	if h.sessions == nil {
+		return http.StatusBadRequest, "session_unavailable", errors.New("session pinning is unavailable")
+	}
+	session, err := h.sessions.GetSession(sessionID)
+	if err != nil {
+		if errors.Is(err, chatstore.ErrSessionNotFound) {
+			return http.StatusNotFound, "session_not_found", fmt.Errorf("session %q does not exist", sessionID)
+		}
+		return http.StatusBadRequest, "session_invalid", fmt.Errorf("session %q is invalid", sessionID)
+	}
+	if session.AgentID != agentID {
+		return http.StatusBadRequest, "session_agent_mismatch", fmt.Errorf("session %q belongs to agent %q", sessionID, session.AgentID)
+	}
+	if session.IsClosed() {
+		return http.StatusConflict, "session_closed", fmt.Errorf("session %q is closed", sessionID)
+	}
+	if err := h.sessions.AppendMessage(sessionID, chatstore.Message{Role: "user", Content: message}); err != nil {
+		return http.StatusInternalServerError, "session_write_failed", errors.New("failed to record message in session")
+	}
+	return 0, "", nil
+}
+
+type completionMeta struct {
+	ID      string
+	Created int64
+	Model   string
+}
+
+func (h *Handler) waitCompletion(w http.ResponseWriter, r *http.Request, runID string, meta completionMeta, events <-chan RunEvent) {
+	for {
+		select {
+		case <-r.Context().Done():
+			h.cancelRun(runID)
+			return
+		case evt, ok := <-events:
+			if !ok {
+				writeError(w, http.StatusBadGateway, "server_error", "run_incomplete", "run ended without a result")
+				return
+			}
+			switch evt.Type {
+			case runEventCompleted:
+				output, _ := evt.Data["output"].(string)
+				writeJSON(w, http.StatusOK, map[string]any{
+					"id":      meta.ID,
+					"object":  completionObject,
+					"created": meta.Created,
+					"model":   meta.Model,
+					"choices": []map[string]any{{
+						"index":         0,
+						"message":       map[string]any{"role": "assistant", "content": output},
+						"finish_reason": "stop",
+					}},
+				})
+				return
+			case runEventFailed:
+				writeError(w, http.StatusBadGateway, "server_error", "run_failed", runFailure(evt.Data))
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) streamCompletion(w http.ResponseWriter, r *http.Request, runID string, meta completionMeta, events <-chan RunEvent) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.cancelRun(runID)
+		writeError(w, http.StatusInternalServerError, "server_error", "streaming_unsupported", "streaming unsupported")
+		return
+	}
+	headers := w.Header()
+	headers.Set("Content-Type", "text/event-stream")
+	headers.Set("Cache-Control", "no-cache")
+	headers.Set("Connection", "keep-alive")
+	headers.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(payload any) bool {
+		if err := writeSSEData(w, payload); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+	chunk := func(delta map[string]any, finish any) map[string]any {
+		return map[string]any{
+			"id":      meta.ID,
+			"object":  chunkObject,
+			"created": meta.Created,
+			"model":   meta.Model,
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+	}
+	finish := func() {
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+		flusher.Flush()
+	}
+
+	if !send(chunk(map[string]any{"role": "assistant"}, nil)) {
+		h.cancelRun(runID)
+		return
+	}
+	streamed := ""
+	for {
+		select {
+		case <-r.Context().Done():
+			h.cancelRun(runID)
+			return
+		case evt, ok := <-events:
+			if !ok {
+				send(errorResponse{Error: errorBody{Message: "run ended without a result", Type: "server_error", Code: "run_incomplete"}})
+				finish()
+				return
+			}
+			switch evt.Type {
+			case runEventModelText:
+				if partial, ok := evt.Data["partial"].(bool); ok && !partial {
+					continue
+				}
+				text, _ := evt.Data["text"].(string)
+				if text == "" {
+					continue
+				}
+				if !send(chunk(map[string]any{"content": text}, nil)) {
+					h.cancelRun(runID)
+					return
+				}
+				streamed += text
+			case runEventCompleted:
+				output, _ := evt.Data["output"].(string)
+				// Deltas cover every model turn, so the final answer is usually
+				// already on the wire; only send what the deltas missed.
+				if rest, ok := strings.CutPrefix(output, streamed); ok && rest != "" {
+					send(chunk(map[string]any{"content": rest}, nil))
+				}
+				send(chunk(map[string]any{}, "stop"))
+				finish()
+				return
+			case runEventFailed:
+				send(errorResponse{Error: errorBody{Message: runFailure(evt.Data), Type: "server_error", Code: "run_failed"}})
+				finish()
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) cancelRun(runID string) {
+	if h.cancel != nil {
+		_ = h.cancel(runID)
+	}
+}
+
+func runFailure(data map[string]any) string {
+	if msg, _ := data["error"].(string); strings.TrimSpace(msg) != "" {
+		return msg
+	}
+	return "run failed"
+}
+
+// messageText accepts both the plain string form and the array-of-parts form
+// of message content; non-text parts are ignored.
+func messageText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", errors.New("message content must be a string or an array of parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+func lastUserMessage(messages []chatMessage) (string, error) {
+	if len(messages) == 0 {
+		return "", errors.New("messages must not be empty")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != "user" {
+		return "", errors.New("the last message must have role user")
+	}
+	text, err := messageText(last.Content)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("the last user message must not be empty")
+	}
+	return text, nil
+}
+
+// flattenMessages folds a stateless request into a single run message. The
+// agent keeps its own system prompt, so client system messages are passed on
+// as instructions and earlier turns as a transcript.
+func flattenMessages(messages []chatMessage) (string, error) {
+	last, err := lastUserMessage(messages)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 1 {
+		return last, nil
+	}
+	var instructions, transcript []string
+	for _, msg := range messages[:len(messages)-1] {
+		text, err := messageText(msg.Content)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		switch msg.Role {
+		case "system", "developer":
+			instructions = append(instructions, text)
+		case "user":
+			transcript = append(transcript, "User: "+text)
+		case "assistant":
+			transcript = append(transcript, "Assistant: "+text)
+		case "tool":
+			transcript = append(transcript, "Tool result: "+text)
+		default:
+			return "", fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+	sections := []string{}
+	if len(instructions) > 0 {
+		sections = append(sections, "Instructions:\n"+strings.Join(instructions, "\n\n"))
+	}
+	if len(transcript) > 0 {
+		sections = append(sections, "Conversation so far:\n"+strings.Join(transcript, "\n\n"))
+	}
+	if len(sections) == 0 {
+		return last, nil
+	}
+	sections = append(sections, "Current message:\n"+last)
+	return strings.Join(sections, "\n\n"), nil
+}
+
+func writeSSEData(w http.ResponseWriter, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func writeError(w http.ResponseWriter, status int, errType, code, message string) {
+	writeJSON(w, status, errorResponse{Error: errorBody{Message: message, Type: errType, Code: code}})
+}
+
+func newCompletionSuffix() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UTC().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,308 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"openclawssy/internal/chatstore"
+	"openclawssy/internal/config"
+)
+
+type fakeRuns struct {
+	mu       sync.Mutex
+	queued   []queuedRun
+	events   []RunEvent
+	canceled []string
+}
+
+type queuedRun struct {
+	agentID   string
+	message   string
+	sessionID string
+}
+
+func (f *fakeRuns) options() Options {
+	return Options{
+		Queue: func(_ context.Context, agentID, message, sessionID string) (string, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.queued = append(f.queued, queuedRun{agentID: agentID, message: message, sessionID: sessionID})
+			return "run_1", nil
+		},
+		RunEvents: func(string) (<-chan RunEvent, func()) {
+			ch := make(chan RunEvent, len(f.events))
+			for _, evt := range f.events {
+				ch <- evt
+			}
+			close(ch)
+			return ch, func() {}
+		},
+		Cancel: func(runID string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.canceled = append(f.canceled, runID)
+			return nil
+		},
+		Agents: func() ([]string, error) { return []string{"default", "ops"}, nil },
+	}
+}
+
+func newTestServer(t *testing.T, cfg config.Config, opts Options) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	New(cfg, opts).Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postCompletion(t *testing.T, srv *httptest.Server, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestModelsListsAllowedAgents(t *testing.T) {
+	runs := &fakeRuns{}
+	cfg := config.Default()
+	cfg.OpenAI.AllowAgents = []string{"ops"}
+	srv := newTestServer(t, cfg, runs.options())
+
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("get models: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID     string `json:"id"`
+			Object string `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Object != "list" || len(body.Data) != 1 || body.Data[0].ID != "ops" || body.Data[0].Object != "model" {
+		t.Fatalf("unexpected models response: %+v", body)
+	}
+}
+
+func TestChatCompletionReturnsRunOutput(t *testing.T) {
+	runs := &fakeRuns{events: []RunEvent{
+		{Type: "status", Data: map[string]any{"status": "running"}},
+		{Type: runEventModelText, Data: map[string]any{"text": "hel", "partial": true}},
+		{Type: runEventCompleted, Data: map[string]any{"output": "hello"}},
+	}}
+	srv := newTestServer(t, config.Default(), runs.options())
+
+	resp := postCompletion(t, srv, `{"model":"ops","messages":[{"role":"user","content":"hi"}]}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(RunHeader); got != "run_1" {
+		t.Fatalf("expected run header, got %q", got)
+	}
+	var body struct {
+		Object  string `json:"object"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Object != completionObject || body.Model != "ops" || len(body.Choices) != 1 {
+		t.Fatalf("unexpected completion: %+v", body)
+	}
+	if body.Choices[0].Message.Role != "assistant" || body.Choices[0].Message.Content != "hello" || body.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected choice: %+v", body.Choices[0])
+	}
+	if len(runs.queued) != 1 || runs.queued[0].agentID != "ops" || runs.queued[0].message != "hi" || runs.queued[0].sessionID != "" {
+		t.Fatalf("unexpected queued runs: %+v", runs.queued)
+	}
+}
+
+func TestChatCompletionRejectsUnknownModel(t *testing.T) {
+	runs := &fakeRuns{}
+	srv := newTestServer(t, config.Default(), runs.options())
+
+	resp := postCompletion(t, srv, `{"model":"ghost","messages":[{"role":"user","content":"hi"}]}`, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+	var body errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Error.Code != "model_not_found" {
+		t.Fatalf("unexpected error: %+v", body.Error)
+	}
+	if len(runs.queued) != 0 {
+		t.Fatalf("expected no queued runs, got %+v", runs.queued)
+	}
+}
+
+func TestChatCompletionReportsRunFailure(t *testing.T) {
+	runs := &fakeRuns{events: []RunEvent{{Type: runEventFailed, Data: map[string]any{"error": "policy denied"}}}}
+	srv := newTestServer(t, config.Default(), runs.options())
+
+	resp := postCompletion(t, srv, `{"model":"ops","messages":[{"role":"user","content":"hi"}]}`, nil)
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", resp.StatusCode)
+	}
+	var body errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Error.Message != "policy denied" || body.Error.Code != "run_failed" {
+		t.Fatalf("unexpected error: %+v", body.Error)
+	}
+}
+
+func TestChatCompletionStreamsModelTextAsChunks(t *testing.T) {
+	runs := &fakeRuns{events: []RunEvent{
+		{Type: runEventModelText, Data: map[string]any{"text": "Hel", "partial": true}},
+		{Type: "tool_end", Data: map[string]any{"tool": "fs.read"}},
+		{Type: runEventModelText, Data: map[string]any{"text": "lo", "partial": true}},
+		{Type: runEventModelText, Data: map[string]any{"text": "Hello", "partial": false}},
+		{Type: runEventCompleted, Data: map[string]any{"output": "Hello!"}},
+	}}
+	srv := newTestServer(t, config.Default(), runs.options())
+
+	resp := postCompletion(t, srv, `{"model":"ops","stream":true,"messages":[{"role":"user","content":"hi"}]}`, nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected event stream, got %q", ct)
+	}
+	var deltas []string
+	var finish string
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if line == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk struct {
+			Object  string `json:"object"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			t.Fatalf("decode chunk %q: %v", line, err)
+		}
+		if chunk.Object != chunkObject || len(chunk.Choices) != 1 {
+			t.Fatalf("unexpected chunk: %s", line)
+		}
+		if c := chunk.Choices[0].Delta.Content; c != "" {
+			deltas = append(deltas, c)
+		}
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+	if !done {
+		t.Fatal("expected [DONE] terminator")
+	}
+	if got := strings.Join(deltas, "|"); got != "Hel|lo|!" {
+		t.Fatalf("unexpected deltas: %q", got)
+	}
+	if finish != "stop" {
+		t.Fatalf("expected stop finish reason, got %q", finish)
+	}
+}
+
+func TestChatCompletionFlattensStatelessHistory(t *testing.T) {
+	runs := &fakeRuns{events: []RunEvent{{Type: runEventCompleted, Data: map[string]any{"output": "ok"}}}}
+	srv := newTestServer(t, config.Default(), runs.options())
+
+	body := `{"model":"ops","messages":[
+		{"role":"system","content":"Be terse."},
+		{"role":"user","content":"What is 2+2?"},
+		{"role":"assistant","content":"4"},
+		{"role":"user","content":[{"type":"text","text":"And doubled?"},{"type":"image_url","image_url":{"url":"x"}}]}
+	]}`
+	resp := postCompletion(t, srv, body, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	msg := runs.queued[0].message
+	for _, want := range []string{"Instructions:\nBe terse.", "User: What is 2+2?", "Assistant: 4", "Current message:\nAnd doubled?"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in flattened message %q", want, msg)
+		}
+	}
+}
+
+func TestChatCompletionPinsSessionFromHeader(t *testing.T) {
+	store, err := chatstore.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	session, err := store.CreateSession(chatstore.CreateSessionInput{AgentID: "ops", Channel: "openai", UserID: "u1", RoomID: "editor"})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	runs := &fakeRuns{events: []RunEvent{{Type: runEventCompleted, Data: map[string]any{"output": "ok"}}}}
+	opts := runs.options()
+	opts.Sessions = store
+	srv := newTestServer(t, config.Default(), opts)
+
+	body := `{"model":"ops","messages":[{"role":"user","content":"earlier"},{"role":"assistant","content":"reply"},{"role":"user","content":"latest"}]}`
+	resp := postCompletion(t, srv, body, map[string]string{SessionHeader: session.SessionID})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(SessionHeader); got != session.SessionID {
+		t.Fatalf("expected session header echo, got %q", got)
+	}
+	if runs.queued[0].sessionID != session.SessionID || runs.queued[0].message != "latest" {
+		t.Fatalf("unexpected queued run: %+v", runs.queued[0])
+	}
+	msgs, err := store.ReadRecentMessages(session.SessionID, 10)
+	if err != nil {
+		t.Fatalf("read messages: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Role != "user" || msgs[0].Content != "latest" {
+		t.Fatalf("expected latest user message in session, got %+v", msgs)
+	}
+
+	resp = postCompletion(t, srv, `{"model":"default","messages":[{"role":"user","content":"hi"}]}`, map[string]string{SessionHeader: session.SessionID})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected agent mismatch to be rejected, got %d", resp.StatusCode)
+	}
+	resp = postCompletion(t, srv, `{"model":"ops","messages":[{"role":"user","content":"hi"}]}`, map[string]string{SessionHeader: "chat_missing"})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected unknown session to be rejected, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type apiClient struct {
+	baseURL    string
+	botToken   string
+	appToken   string
+	httpClient *http.Client
+}
+
+type apiResponse struct {
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+	TS     string `json:"ts,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+func (c *apiClient) call(ctx context.Context, method, token string, payload any) (apiResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.baseURL, "/")+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return apiResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return apiResponse{}, fmt.Errorf("slack %s: rate limited (retry after %ss)", method, resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return apiResponse{}, fmt.Errorf("slack %s: unexpected status %d", method, resp.StatusCode)
+	}
+	var out apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return apiResponse{}, fmt.Errorf("slack %s: decode response: %w", method, err)
+	}
+	if !out.OK {
+		return out, fmt.Errorf("slack %s: %s", method, out.Error)
+	}
+	return out, nil
+}
+
+func (c *apiClient) authTest(ctx context.Context) (string, error) {
+	out, err := c.call(ctx, "auth.test", c.botToken, map[string]any{})
+	if err != nil {
+		return "", err
+	}
+	return out.UserID, nil
+}
+
+func (c *apiClient) postMessage(ctx context.Context, channel, threadTS, text string) (string, error) {
+	payload := map[string]any{"channel": channel, "text": text}
+	if threadTS != "" {
+		payload["thread_ts"] = threadTS
+	}
+	out, err := c.call(ctx, "chat.postMessage", c.botToken, payload)
+	if err != nil {
+		return "", err
+	}
+	return out.TS, nil
+}
+
+func (c *apiClient) updateMessage(ctx context.Context, channel, ts, text string) error {
+	_, err := c.call(ctx, "chat.update", c.botToken, map[string]any{"channel": channel, "ts": ts, "text": text})
+	return err
+}
+
+func (c *apiClient) openConnection(ctx context.Context) (string, error) {
+	out, err := c.call(ctx, "apps.connections.open", c.appToken, map[string]any{})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out.URL) == "" {
+		return "", fmt.Errorf("slack apps.connections.open: empty url")
+	}
+	return out.URL, nil
+}
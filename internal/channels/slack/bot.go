@@ -0,0 +1,472 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"openclawssy/internal/channels/chat"
+	"openclawssy/internal/config"
+)
+
+const (
+	defaultPollInterval     = 1200 * time.Millisecond
+	defaultPollTimeout      = 2 * time.Minute
+	defaultSlackMaxSize     = 3900
+	defaultStreamInterval   = 1500 * time.Millisecond
+	defaultStreamTimeout    = 14 * time.Minute
+	defaultAPITimeout       = 15 * time.Second
+	defaultEventsBodyLimit  = 1 << 20
+	maxSignatureClockSkew   = 5 * time.Minute
+	maxRememberedEvents     = 2048
+	eventIDTTL              = 10 * time.Minute
+	maxTrackedThreads       = 4096
+	defaultReconnectBackoff = time.Second
+	maxReconnectBackoff     = time.Minute
+)
+
+type Message struct {
+	UserID       string
+	RoomID       string
+	AgentID      string
+	Source       string
+	Text         string
+	ThinkingMode string
+}
+
+type Response struct {
+	ID        string
+	Status    string
+	Response  string
+	SessionID string
+}
+
+type RunStatus struct {
+	Status       string
+	Output       string
+	Error        string
+	ArtifactPath string
+}
+
+var (
+	errMessageRequired     = errors.New("message is required")
+	errInvalidThinkingMode = errors.New("request.invalid_thinking_mode: thinking must be one of never|on_error|always")
+	mentionPattern         = regexp.MustCompile(`<@[A-Z0-9]+>`)
+)
+
+type RunEvent struct {
+	Type string
+	Data map[string]any
+}
+
+type MessageHandler func(ctx context.Context, msg Message) (Response, error)
+type RunStatusFunc func(ctx context.Context, runID string) (RunStatus, error)
+type RunEventsFunc func(runID string) (<-chan RunEvent, func())
+
+type Bot struct {
+	cfg            config.SlackConfig
+	allow          *chat.Allowlist
+	limiter        *chat.RateLimiter
+	handler        MessageHandler
+	runStatus      RunStatusFunc
+	runEvents      RunEventsFunc
+	api            *apiClient
+	signingSecret  string
+	streamInterval time.Duration
+	now            func() time.Time
+
+	mu           sync.Mutex
+	botUserID    string
+	seen         map[string]struct{}
+	seenOrder    []string
+	eventIDs     map[string]time.Time
+	eventIDOrder []string
+	threads      map[string]struct{}
+	threadIDs    []string
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// slackEvent is the subset of the Events API "event" object the bot reads.
+type slackEvent struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id"`
+	Text        string `json:"text"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+}
+
+func New(cfg config.Config, handler MessageHandler, runStatus RunStatusFunc, runEvents RunEventsFunc) (*Bot, error) {
+	botToken := credential(cfg.Slack.BotToken, cfg.Slack.BotTokenEnv)
+	if botToken == "" {
+		return nil, errors.New("slack bot token is required")
+	}
+	appToken := credential(cfg.Slack.AppToken, cfg.Slack.AppTokenEnv)
+	signingSecret := credential(cfg.Slack.SigningSecret, cfg.Slack.SigningSecretEnv)
+	if cfg.Slack.Mode == "events" {
+		if signingSecret == "" {
+			return nil, errors.New("slack signing secret is required in events mode")
+		}
+	} else if appToken == "" {
+		return nil, errors.New("slack app token is required in socket mode")
+	}
+	return &Bot{
+		cfg:     cfg.Slack,
+		allow:   chat.NewAllowlist(cfg.Slack.AllowUsers, cfg.Slack.AllowChannels),
+		limiter: chat.NewRateLimiter(cfg.Slack.RateLimitPerMin, time.Minute),
+		handler: handler,
+		api: &apiClient{
+			baseURL:    cfg.Slack.APIBaseURL,
+			botToken:   botToken,
+			appToken:   appToken,
+			httpClient: &http.Client{Timeout: defaultAPITimeout},
+		},
+		runStatus:      runStatus,
+		runEvents:      runEvents,
+		signingSecret:  signingSecret,
+		streamInterval: defaultStreamInterval,
+		now:            time.Now,
+		seen:           make(map[string]struct{}),
+		eventIDs:       make(map[string]time.Time),
+		threads:        make(map[string]struct{}),
+		stopCh:         make(chan struct{}),
+	}, nil
+}
+
+func credential(value, envName string) string {
+	value = strings.TrimSpace(value)
+	if value == "" && envName != "" {
+		value = strings.TrimSpace(os.Getenv(envName))
+	}
+	return value
+}
+
+// Start resolves the bot user and, in socket mode, opens the websocket
+// connection loop. In events mode the caller mounts EventsHandler instead.
+func (b *Bot) Start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	userID, err := b.api.authTest(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.botUserID = userID
+	b.mu.Unlock()
+	if b.cfg.Mode == "events" {
+		return nil
+	}
+	done := make(chan struct{})
+	b.mu.Lock()
+	b.doneCh = done
+	b.mu.Unlock()
+	go b.runSocketMode(done)
+	return nil
+}
+
+func (b *Bot) Stop() error {
+	b.stopOnce.Do(func() { close(b.stopCh) })
+	b.mu.Lock()
+	done := b.doneCh
+	b.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	return nil
+}
+
+func (b *Bot) handleEvent(evt slackEvent) {
+	if evt.BotID != "" || evt.User == "" || (evt.Subtype != "" && evt.Subtype != "file_share") {
+		return
+	}
+	b.mu.Lock()
+	botUserID := b.botUserID
+	b.mu.Unlock()
+	if evt.User == botUserID {
+		return
+	}
+	direct := evt.ChannelType == "im"
+	switch evt.Type {
+	case "app_mention":
+	case "message":
+		// Channel messages are only picked up inside threads the bot is
+		// already part of; the mention that started the thread arrives as
+		// app_mention.
+		if !direct && (evt.ThreadTS == "" || !b.threadTracked(evt.Channel, evt.ThreadTS)) {
+			return
+		}
+	default:
+		return
+	}
+	// Slack delivers mentions both as app_mention and message events and
+	// retries deliveries; the channel/ts pair identifies the message.
+	if !b.markSeen(evt.Channel + ":" + evt.TS) {
+		return
+	}
+	text := strings.TrimSpace(mentionPattern.ReplaceAllString(evt.Text, ""))
+	if text == "" {
+		return
+	}
+	threadTS := evt.ThreadTS
+	if threadTS == "" && !direct {
+		threadTS = evt.TS
+	}
+	b.dispatch(evt.User, evt.Channel, threadTS, text)
+}
+
+func (b *Bot) dispatch(userID, channelID, threadTS, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+
+	if b.allow != nil && !b.allow.MessageAllowed(userID, channelID) {
+		return
+	}
+	if b.limiter != nil {
+		if allowed, retryAfter := b.limiter.AllowWithDetails(userID + ":" + channelID); !allowed {
+			_, _ = b.api.postMessage(ctx, channelID, threadTS, formatSlackRateLimit(retryAfter))
+			return
+		}
+	}
+	if b.handler == nil {
+		_, _ = b.api.postMessage(ctx, channelID, threadTS, "chat handler is not configured")
+		return
+	}
+	content, thinkingMode, err := parseThinkingOverride(text)
+	if err != nil {
+		_, _ = b.api.postMessage(ctx, channelID, threadTS, formatSlackError(err))
+		return
+	}
+
+	roomID := channelID
+	if threadTS != "" {
+		roomID = channelID + ":" + threadTS
+		b.trackThread(channelID, threadTS)
+	}
+	agentID := b.cfg.DefaultAgentID
+	if agentID == "" {
+		agentID = "default"
+	}
+	res, err := b.handler(context.Background(), Message{
+		UserID:       userID,
+		RoomID:       roomID,
+		AgentID:      agentID,
+		Source:       "slack",
+		Text:         content,
+		ThinkingMode: thinkingMode,
+	})
+	if err != nil {
+		_, _ = b.api.postMessage(ctx, channelID, threadTS, formatSlackError(err))
+		return
+	}
+
+	reply := strings.TrimSpace(res.Response)
+	if reply == "" && strings.TrimSpace(res.ID) != "" {
+		reply = "queued run `" + res.ID + "`"
+	}
+	if reply == "" {
+		return
+	}
+	parts := splitSlackMessage(reply, defaultSlackMaxSize)
+	ts, err := b.api.postMessage(ctx, channelID, threadTS, parts[0])
+	if err != nil {
+		return
+	}
+	for _, part := range parts[1:] {
+		_, _ = b.api.postMessage(ctx, channelID, threadTS, part)
+	}
+	if strings.HasPrefix(content, "/") || strings.TrimSpace(res.ID) == "" {
+		return
+	}
+	go b.streamRun(newStreamReply(b.api, channelID, threadTS, ts, res.ID))
+}
+
+func (b *Bot) markSeen(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.seen[key]; ok {
+		return false
+	}
+	b.seen[key] = struct{}{}
+	b.seenOrder = append(b.seenOrder, key)
+	if len(b.seenOrder) > maxRememberedEvents {
+		delete(b.seen, b.seenOrder[0])
+		b.seenOrder = b.seenOrder[1:]
+	}
+	return true
+}
+
+// firstDelivery reports whether eventID has not been seen in the last
+// eventIDTTL, which covers Slack's retry schedule. Events without an ID are
+// always new.
+func (b *Bot) firstDelivery(eventID string) bool {
+	if eventID == "" {
+		return true
+	}
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.eventIDOrder) > 0 {
+		oldest := b.eventIDOrder[0]
+		if now.Sub(b.eventIDs[oldest]) < eventIDTTL && len(b.eventIDOrder) <= maxRememberedEvents {
+			break
+		}
+		delete(b.eventIDs, oldest)
+		b.eventIDOrder = b.eventIDOrder[1:]
+	}
+	if _, ok := b.eventIDs[eventID]; ok {
+		return false
+	}
+	b.eventIDs[eventID] = now
+	b.eventIDOrder = append(b.eventIDOrder, eventID)
+	return true
+}
+
+func (b *Bot) trackThread(channelID, threadTS string) {
+	key := channelID + ":" + threadTS
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.threads[key]; ok {
+		return
+	}
+	b.threads[key] = struct{}{}
+	b.threadIDs = append(b.threadIDs, key)
+	if len(b.threadIDs) > maxTrackedThreads {
+		delete(b.threads, b.threadIDs[0])
+		b.threadIDs = b.threadIDs[1:]
+	}
+}
+
+func (b *Bot) threadTracked(channelID, threadTS string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.threads[channelID+":"+threadTS]
+	return ok
+}
+
+func parseThinkingOverride(content string) (string, string, error) {
+	clean := strings.TrimSpace(content)
+	parts := strings.Fields(clean)
+	if len(parts) == 0 {
+		return "", "", errMessageRequired
+	}
+	first := strings.ToLower(parts[0])
+	if !strings.HasPrefix(first, "thinking=") {
+		return clean, "", nil
+	}
+	normalized := config.NormalizeThinkingMode(strings.TrimPrefix(first, "thinking="))
+	if !config.IsValidThinkingMode(normalized) {
+		return "", "", errInvalidThinkingMode
+	}
+	clean = strings.TrimSpace(strings.TrimPrefix(clean, parts[0]))
+	if clean == "" {
+		return "", "", errMessageRequired
+	}
+	return clean, normalized, nil
+}
+
+func formatSlackError(err error) string {
+	if err == nil {
+		return "request failed"
+	}
+	var cooldown interface{ RetryAfter() time.Duration }
+	if errors.As(err, &cooldown) && cooldown.RetryAfter() > 0 {
+		return formatSlackRateLimit(cooldown.RetryAfter())
+	}
+	msg := strings.TrimSpace(err.Error())
+	if msg == "" {
+		msg = "request failed"
+	}
+	lower := strings.ToLower(msg)
+	if strings.Contains(lower, "rate limited") {
+		return "rate limited, try again soon"
+	}
+	if strings.Contains(lower, "not allowlisted") {
+		return "not allowed in this channel or user scope"
+	}
+	if strings.Contains(lower, "run queue is full") {
+		return "run queue is full, retry shortly"
+	}
+	if strings.Contains(msg, "request.invalid_thinking_mode") {
+		return "error[request.invalid_thinking_mode]: thinking must be one of never|on_error|always"
+	}
+	return "request failed: " + msg
+}
+
+func formatSlackRateLimit(retryAfter time.Duration) string {
+	if retryAfter <= 0 {
+		return "rate limited, try again soon"
+	}
+	seconds := int(retryAfter / time.Second)
+	if retryAfter%time.Second != 0 {
+		seconds++
+	}
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("rate limited, retry in %ds", seconds)
+}
+
+func waitForTerminalRun(ctx context.Context, runID string, runStatus RunStatusFunc, interval time.Duration) (RunStatus, error) {
+	if runStatus == nil {
+		return RunStatus{}, errors.New("run status lookup is not configured")
+	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		run, err := runStatus(ctx, runID)
+		if err != nil {
+			return RunStatus{}, err
+		}
+		switch strings.ToLower(strings.TrimSpace(run.Status)) {
+		case "completed", "failed":
+			return run, nil
+		}
+		select {
+		case <-ctx.Done():
+			return RunStatus{}, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func splitSlackMessage(text string, maxLen int) []string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return []string{"(empty)"}
+	}
+	if maxLen <= 0 {
+		maxLen = defaultSlackMaxSize
+	}
+	var out []string
+	remaining := trimmed
+	for len(remaining) > maxLen {
+		cut := strings.LastIndex(remaining[:maxLen], "\n")
+		if cut <= 0 {
+			cut = maxLen
+		}
+		if part := strings.TrimSpace(remaining[:cut]); part != "" {
+			out = append(out, part)
+		}
+		remaining = strings.TrimSpace(remaining[cut:])
+	}
+	if remaining != "" {
+		out = append(out, remaining)
+	}
+	if len(out) == 0 {
+		return []string{"(empty)"}
+	}
+	return out
+}
@@ -0,0 +1,394 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"openclawssy/internal/config"
+)
+
+type fakeSlackCall struct {
+	Method  string
+	Token   string
+	Payload map[string]any
+}
+
+type fakeSlackAPI struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	calls    []fakeSlackCall
+	nextTS   int
+	acks     []string
+	sockets  chan *websocket.Conn
+	upgrader websocket.Upgrader
+}
+
+func newFakeSlackAPI(t *testing.T) *fakeSlackAPI {
+	t.Helper()
+	f := &fakeSlackAPI{sockets: make(chan *websocket.Conn, 4)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/api/")
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		f.mu.Lock()
+		f.calls = append(f.calls, fakeSlackCall{Method: method, Token: strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), Payload: payload})
+		f.nextTS++
+		ts := fmt.Sprintf("1700000000.%06d", f.nextTS)
+		f.mu.Unlock()
+		out := map[string]any{"ok": true}
+		switch method {
+		case "auth.test":
+			out["user_id"] = "UBOT"
+		case "chat.postMessage", "chat.update":
+			out["ts"] = ts
+			if method == "chat.update" {
+				out["ts"] = payload["ts"]
+			}
+		case "apps.connections.open":
+			out["url"] = "ws" + strings.TrimPrefix(f.server.URL, "http") + "/socket"
+		default:
+			out = map[string]any{"ok": false, "error": "unknown_method"}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc("/socket", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := f.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.sockets <- conn
+		go func() {
+			for {
+				var ack map[string]string
+				if err := conn.ReadJSON(&ack); err != nil {
+					return
+				}
+				f.mu.Lock()
+				f.acks = append(f.acks, ack["envelope_id"])
+				f.mu.Unlock()
+			}
+		}()
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeSlackAPI) callsFor(method string) []fakeSlackCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []fakeSlackCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			out = append(out, call)
+		}
+	}
+	return out
+}
+
+func newTestBot(t *testing.T, api *fakeSlackAPI, mode string, handler MessageHandler, events RunEventsFunc) *Bot {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Slack.Enabled = true
+	cfg.Slack.Mode = mode
+	cfg.Slack.BotToken = "xoxb-test"
+	cfg.Slack.AppToken = "xapp-test"
+	cfg.Slack.SigningSecret = "shh"
+	cfg.Slack.APIBaseURL = api.server.URL + "/api"
+	cfg.Slack.AllowUsers = []string{"U1"}
+	bot, err := New(cfg, handler, nil, events)
+	if err != nil {
+		t.Fatalf("new bot: %v", err)
+	}
+	bot.streamInterval = 10 * time.Millisecond
+	t.Cleanup(func() { _ = bot.Stop() })
+	return bot
+}
+
+func signedEventRequest(t *testing.T, secret string, at time.Time, body []byte) *http.Request {
+	t.Helper()
+	ts := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", computeSignature(secret, ts, body))
+	return req
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestEventsHandlerVerifiesSignatureAndAnswersChallenge(t *testing.T) {
+	api := newFakeSlackAPI(t)
+	bot := newTestBot(t, api, "events", nil, nil)
+	handler := bot.EventsHandler()
+	body := []byte(`{"type":"url_verification","challenge":"abc123"}`)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, signedEventRequest(t, "wrong", time.Now(), body))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad signature, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, signedEventRequest(t, "shh", time.Now().Add(-10*time.Minute), body))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for stale timestamp, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, signedEventRequest(t, "shh", time.Now(), body))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var out map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil || out["challenge"] != "abc123" {
+		t.Fatalf("unexpected challenge response %q (%v)", rr.Body.String(), err)
+	}
+}
+
+func TestAppMentionStartsThreadSessionAndStreamsUpdates(t *testing.T) {
+	api := newFakeSlackAPI(t)
+	events := make(chan RunEvent, 8)
+	var mu sync.Mutex
+	var got []Message
+	bot := newTestBot(t, api, "events", func(_ context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		got = append(got, msg)
+		mu.Unlock()
+		return Response{ID: "run_1", Status: "queued", Response: "Working on it", SessionID: "chat_1"}, nil
+	}, func(runID string) (<-chan RunEvent, func()) {
+		if runID != "run_1" {
+			t.Errorf("unexpected run subscription %q", runID)
+		}
+		return events, func() {}
+	})
+	if err := bot.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	body := []byte(`{"type":"event_callback","event":{"type":"app_mention","user":"U1","text":"<@UBOT> summarize the repo","channel":"C1","channel_type":"channel","ts":"111.000"}}`)
+	rr := httptest.NewRecorder()
+	bot.EventsHandler().ServeHTTP(rr, signedEventRequest(t, "shh", time.Now(), body))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	waitFor(t, func() bool { return len(api.callsFor("chat.postMessage")) == 1 })
+
+	mu.Lock()
+	if len(got) != 1 || got[0].RoomID != "C1:111.000" || got[0].Text != "summarize the repo" || got[0].Source != "slack" || got[0].UserID != "U1" {
+		t.Fatalf("unexpected handler messages: %+v", got)
+	}
+	mu.Unlock()
+	post := api.callsFor("chat.postMessage")[0]
+	if post.Payload["thread_ts"] != "111.000" || post.Payload["channel"] != "C1" || post.Token != "xoxb-test" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+
+	events <- RunEvent{Type: "tool_end", Data: map[string]any{"tool": "fs.list", "summary": "3 entries"}}
+	events <- RunEvent{Type: "model_text", Data: map[string]any{"text": "Partial answer", "partial": true}}
+	waitFor(t, func() bool {
+		for _, call := range api.callsFor("chat.update") {
+			text, _ := call.Payload["text"].(string)
+			if strings.Contains(text, "Partial answer") && strings.Contains(text, "in progress") {
+				return true
+			}
+		}
+		return false
+	})
+	events <- RunEvent{Type: "completed", Data: map[string]any{"output": "Final answer"}}
+	waitFor(t, func() bool {
+		updates := api.callsFor("chat.update")
+		last, _ := updates[len(updates)-1].Payload["text"].(string)
+		return strings.Contains(last, "Final answer") && strings.Contains(last, "`fs.list` -> 3 entries")
+	})
+
+	// A follow-up in the same thread needs no mention and keeps the thread room.
+	reply := []byte(`{"type":"event_callback","event":{"type":"message","user":"U1","text":"and the tests?","channel":"C1","channel_type":"channel","ts":"112.000","thread_ts":"111.000"}}`)
+	rr = httptest.NewRecorder()
+	bot.EventsHandler().ServeHTTP(rr, signedEventRequest(t, "shh", time.Now(), reply))
+	// Redelivery of the same message is ignored.
+	bot.EventsHandler().ServeHTTP(httptest.NewRecorder(), signedEventRequest(t, "shh", time.Now(), reply))
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) >= 2
+	})
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[1].RoomID != "C1:111.000" {
+		t.Fatalf("expected one threaded follow-up, got %+v", got)
+	}
+}
+
+func TestUntrackedChannelMessagesAndDisallowedUsersAreIgnored(t *testing.T) {
+	api := newFakeSlackAPI(t)
+	calls := 0
+	var mu sync.Mutex
+	bot := newTestBot(t, api, "events", func(_ context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return Response{Response: "ok"}, nil
+	}, nil)
+	bot.handleEvent(slackEvent{Type: "message", User: "U1", Text: "hello", Channel: "C1", ChannelType: "channel", TS: "1.0"})
+	bot.handleEvent(slackEvent{Type: "message", BotID: "B1", User: "U2", Text: "hello", Channel: "D1", ChannelType: "im", TS: "2.0"})
+	bot.handleEvent(slackEvent{Type: "message", User: "U1", Text: "hello", Channel: "D1", ChannelType: "im", TS: "3.0"})
+	mu.Lock()
+	if calls != 1 {
+		t.Fatalf("expected only the direct message to be handled, got %d", calls)
+	}
+	mu.Unlock()
+	if post := api.callsFor("chat.postMessage"); len(post) != 1 || post[0].Payload["thread_ts"] != nil {
+		t.Fatalf("expected unthreaded DM reply, got %+v", post)
+	}
+
+	cfg := config.Default()
+	cfg.Slack.Mode = "events"
+	cfg.Slack.BotToken = "xoxb-test"
+	cfg.Slack.SigningSecret = "shh"
+	cfg.Slack.APIBaseURL = api.server.URL + "/api"
+	cfg.Slack.AllowUsers = []string{"U1"}
+	restricted, err := New(cfg, bot.handler, nil, nil)
+	if err != nil {
+		t.Fatalf("new bot: %v", err)
+	}
+	restricted.handleEvent(slackEvent{Type: "app_mention", User: "U9", Text: "<@UBOT> hi", Channel: "C1", TS: "4.0"})
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("expected disallowed user to be ignored, got %d calls", calls)
+	}
+}
+
+func TestEventsHandlerDropsRetriesAndRepeatedEventIDs(t *testing.T) {
+	api := newFakeSlackAPI(t)
+	var mu sync.Mutex
+	var got []string
+	bot := newTestBot(t, api, "events", func(_ context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		got = append(got, msg.Text)
+		mu.Unlock()
+		return Response{Response: "ok"}, nil
+	}, nil)
+	dm := func(eventID, ts, text string) []byte {
+		return []byte(`{"type":"event_callback","event_id":"` + eventID + `","event":{"type":"message","user":"U1","text":"` + text + `","channel":"D1","channel_type":"im","ts":"` + ts + `"}}`)
+	}
+
+	serve := func(body []byte, retry string) {
+		req := signedEventRequest(t, "shh", time.Now(), body)
+		if retry != "" {
+			req.Header.Set("X-Slack-Retry-Num", retry)
+		}
+		rr := httptest.NewRecorder()
+		bot.EventsHandler().ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+	}
+	serve(dm("Ev1", "1.0", "first"), "")
+	serve(dm("Ev1", "1.1", "same event again"), "")
+	serve(dm("Ev2", "2.0", "retried"), "1")
+	serve(dm("Ev3", "3.0", "second"), "")
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) >= 2
+	})
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[0]+got[1] != "firstsecond" && got[0]+got[1] != "secondfirst" {
+		t.Fatalf("expected only first deliveries to be dispatched, got %v", got)
+	}
+
+	bot.now = func() time.Time { return time.Now().Add(eventIDTTL + time.Minute) }
+	if !bot.firstDelivery("Ev1") {
+		t.Fatal("expected event IDs to expire after the retry window")
+	}
+}
+
+func TestSocketModeAcksEnvelopesAndDispatchesEvents(t *testing.T) {
+	api := newFakeSlackAPI(t)
+	handled := make(chan Message, 1)
+	bot := newTestBot(t, api, "socket", func(_ context.Context, msg Message) (Response, error) {
+		handled <- msg
+		return Response{Response: "hi"}, nil
+	}, nil)
+	if err := bot.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	var conn *websocket.Conn
+	select {
+	case conn = <-api.sockets:
+	case <-time.After(3 * time.Second):
+		t.Fatal("bot did not open a socket mode connection")
+	}
+	if open := api.callsFor("apps.connections.open"); len(open) != 1 || open[0].Token != "xapp-test" {
+		t.Fatalf("expected connections.open with app token, got %+v", open)
+	}
+	envelope := map[string]any{
+		"type":        "events_api",
+		"envelope_id": "env-1",
+		"payload": map[string]any{
+			"type":  "event_callback",
+			"event": map[string]any{"type": "message", "user": "U1", "text": "ping", "channel": "D1", "channel_type": "im", "ts": "5.0"},
+		},
+	}
+	if err := conn.WriteJSON(envelope); err != nil {
+		t.Fatalf("write envelope: %v", err)
+	}
+	select {
+	case msg := <-handled:
+		if msg.RoomID != "D1" || msg.Text != "ping" {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("socket event was not dispatched")
+	}
+	waitFor(t, func() bool {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		return len(api.acks) == 1 && api.acks[0] == "env-1"
+	})
+}
+
+func TestNewRequiresModeCredentials(t *testing.T) {
+	cfg := config.Default()
+	cfg.Slack.BotTokenEnv = ""
+	cfg.Slack.AppTokenEnv = ""
+	cfg.Slack.SigningSecretEnv = ""
+	if _, err := New(cfg, nil, nil, nil); err == nil {
+		t.Fatal("expected missing bot token error")
+	}
+	cfg.Slack.BotToken = "xoxb-test"
+	if _, err := New(cfg, nil, nil, nil); err == nil || !strings.Contains(err.Error(), "app token") {
+		t.Fatalf("expected app token error, got %v", err)
+	}
+	cfg.Slack.Mode = "events"
+	if _, err := New(cfg, nil, nil, nil); err == nil || !strings.Contains(err.Error(), "signing secret") {
+		t.Fatalf("expected signing secret error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errMissingSignature = errors.New("missing slack signature headers")
+	errStaleTimestamp   = errors.New("slack request timestamp is outside the allowed window")
+	errBadSignature     = errors.New("slack signature mismatch")
+)
+
+type eventsEnvelope struct {
+	Type      string     `json:"type"`
+	Challenge string     `json:"challenge"`
+	EventID   string     `json:"event_id"`
+	Event     slackEvent `json:"event"`
+}
+
+// EventsHandler serves the Events API request URL. Requests are verified with
+// the app signing secret; event callbacks are acknowledged immediately and
+// processed in the background so Slack does not retry slow runs. Since the
+// first delivery is always acknowledged at once, a retry (X-Slack-Retry-Num)
+// only means the ack was slow; it is answered without dispatching, and repeated
+// event IDs are dropped as well.
+func (b *Bot) EventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, defaultEventsBodyLimit))
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		if err := verifySignature(b.signingSecret, r.Header, body, b.now()); err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Slack-Retry-Num") != "" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var envelope eventsEnvelope
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		switch envelope.Type {
+		case "url_verification":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{"challenge": envelope.Challenge})
+			return
+		case "event_callback":
+			if b.firstDelivery(envelope.EventID) {
+				go b.handleEvent(envelope.Event)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func verifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := strings.TrimSpace(header.Get("X-Slack-Request-Timestamp"))
+	signature := strings.TrimSpace(header.Get("X-Slack-Signature"))
+	if secret == "" || timestamp == "" || signature == "" {
+		return errMissingSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errStaleTimestamp
+	}
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > maxSignatureClockSkew || skew < -maxSignatureClockSkew {
+		return errStaleTimestamp
+	}
+	if !hmac.Equal([]byte(signature), []byte(computeSignature(secret, timestamp, body))) {
+		return errBadSignature
+	}
+	return nil
+}
+
+func computeSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
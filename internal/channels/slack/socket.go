@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type socketEnvelope struct {
+	Type       string          `json:"type"`
+	EnvelopeID string          `json:"envelope_id"`
+	Reason     string          `json:"reason"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// runSocketMode keeps a Socket Mode connection open until Stop is called,
+// reconnecting with exponential backoff when Slack drops or refreshes it.
+func (b *Bot) runSocketMode(done chan struct{}) {
+	defer close(done)
+	backoff := defaultReconnectBackoff
+	for {
+		err := b.serveSocket()
+		if err != nil {
+			log.Printf("slack: socket mode: %v", err)
+		} else {
+			backoff = defaultReconnectBackoff
+		}
+		select {
+		case <-b.stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		if err != nil {
+			backoff *= 2
+			if backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+		}
+	}
+}
+
+func (b *Bot) serveSocket() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	url, err := b.api.openConnection(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return err
+	}
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-b.stopCh:
+			_ = conn.Close()
+		case <-closed:
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		var envelope socketEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			select {
+			case <-b.stopCh:
+				return nil
+			default:
+				return err
+			}
+		}
+		if envelope.EnvelopeID != "" {
+			if err := conn.WriteJSON(map[string]string{"envelope_id": envelope.EnvelopeID}); err != nil {
+				return err
+			}
+		}
+		switch envelope.Type {
+		case "events_api":
+			var payload eventsEnvelope
+			if err := json.Unmarshal(envelope.Payload, &payload); err == nil && payload.Type == "event_callback" && b.firstDelivery(payload.EventID) {
+				go b.handleEvent(payload.Event)
+			}
+		case "disconnect":
+			return nil
+		}
+	}
+}
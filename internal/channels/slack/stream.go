@@ -0,0 +1,218 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	runEventModelText = "model_text"
+	runEventToolEnd   = "tool_end"
+	runEventCompleted = "completed"
+	runEventFailed    = "failed"
+
+	maxStreamToolLines = 6
+)
+
+// streamReply edits a single threaded Slack message with chat.update while a
+// run is in flight and replaces it with the final answer.
+type streamReply struct {
+	api       *apiClient
+	channelID string
+	threadTS  string
+	ts        string
+	runID     string
+	text      string
+	tools     []string
+	rendered  string
+}
+
+func newStreamReply(api *apiClient, channelID, threadTS, ts, runID string) *streamReply {
+	return &streamReply{api: api, channelID: channelID, threadTS: threadTS, ts: ts, runID: strings.TrimSpace(runID)}
+}
+
+func (r *streamReply) apply(evt RunEvent) {
+	switch evt.Type {
+	case runEventModelText:
+		text, _ := evt.Data["text"].(string)
+		if partial, ok := evt.Data["partial"].(bool); ok && !partial {
+			r.text = text
+			return
+		}
+		r.text += text
+	case runEventToolEnd:
+		r.tools = append(r.tools, formatStreamTool(evt.Data))
+	}
+}
+
+func (r *streamReply) render() string {
+	sections := []string{}
+	if tools := r.toolBlock(maxStreamToolLines); tools != "" {
+		sections = append(sections, tools)
+	}
+	footer := "_run `" + r.runID + "` in progress_"
+	if text := strings.TrimSpace(r.text); text != "" {
+		budget := defaultSlackMaxSize - len(footer) - len(strings.Join(sections, "\n\n")) - 4
+		sections = append(sections, tailTruncate(text, budget))
+	}
+	sections = append(sections, footer)
+	return strings.Join(sections, "\n\n")
+}
+
+func (r *streamReply) flush(ctx context.Context) {
+	if len(r.tools) == 0 && strings.TrimSpace(r.text) == "" {
+		return
+	}
+	content := r.render()
+	if content == r.rendered {
+		return
+	}
+	if err := r.api.updateMessage(ctx, r.channelID, r.ts, content); err == nil {
+		r.rendered = content
+	}
+}
+
+func (r *streamReply) finish(ctx context.Context, evt RunEvent) {
+	var final string
+	if evt.Type == runEventFailed {
+		final = "run `" + r.runID + "` failed"
+		if errText := strings.TrimSpace(fmt.Sprintf("%v", evt.Data["error"])); errText != "" && errText != "<nil>" {
+			final += ": " + errText
+		}
+	} else {
+		final = strings.TrimSpace(fmt.Sprintf("%v", evt.Data["output"]))
+		if final == "" || final == "<nil>" {
+			final = strings.TrimSpace(r.text)
+		}
+		if final == "" {
+			final = "run completed without assistant output; check run trace/tool activity for details"
+		}
+		if artifact, _ := evt.Data["artifact_path"].(string); strings.TrimSpace(artifact) != "" {
+			final = fmt.Sprintf("%s\n\nartifact: `%s`", final, artifact)
+		}
+	}
+	if tools := r.toolBlock(0); tools != "" {
+		final = tools + "\n\n" + final
+	}
+	r.finishText(ctx, final)
+}
+
+func (r *streamReply) finishText(ctx context.Context, final string) {
+	parts := splitSlackMessage(final, defaultSlackMaxSize)
+	_ = r.api.updateMessage(ctx, r.channelID, r.ts, parts[0])
+	r.rendered = parts[0]
+	for _, part := range parts[1:] {
+		_, _ = r.api.postMessage(ctx, r.channelID, r.threadTS, part)
+	}
+}
+
+func (r *streamReply) toolBlock(limit int) string {
+	tools := r.tools
+	skipped := 0
+	if limit > 0 && len(tools) > limit {
+		skipped = len(tools) - limit
+		tools = tools[skipped:]
+	}
+	if len(tools) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(tools)+1)
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("> ... %d earlier tool calls", skipped))
+	}
+	for _, tool := range tools {
+		lines = append(lines, "> "+tool)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatStreamTool(data map[string]any) string {
+	tool := strings.TrimSpace(fmt.Sprintf("%v", data["tool"]))
+	if tool == "" || tool == "<nil>" {
+		tool = "unknown.tool"
+	}
+	line := "`" + tool + "`"
+	if errText := strings.TrimSpace(fmt.Sprintf("%v", data["error"])); errText != "" && errText != "<nil>" {
+		return line + " -> error: " + truncateRunes(errText, 160)
+	}
+	if summary := strings.TrimSpace(fmt.Sprintf("%v", data["summary"])); summary != "" && summary != "<nil>" {
+		return line + " -> " + truncateRunes(summary, 160)
+	}
+	return line
+}
+
+func (b *Bot) streamRun(stream *streamReply) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultStreamTimeout)
+	defer cancel()
+
+	if b.runEvents != nil {
+		events, unsubscribe := b.runEvents(stream.runID)
+		defer unsubscribe()
+		interval := b.streamInterval
+		if interval <= 0 {
+			interval = defaultStreamInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case evt, ok := <-events:
+				if !ok {
+					break loop
+				}
+				if evt.Type == runEventCompleted || evt.Type == runEventFailed {
+					stream.finish(ctx, evt)
+					return
+				}
+				stream.apply(evt)
+			case <-ticker.C:
+				stream.flush(ctx)
+			case <-ctx.Done():
+				break loop
+			}
+		}
+	}
+	b.finishFromRunStatus(ctx, stream)
+}
+
+func (b *Bot) finishFromRunStatus(ctx context.Context, stream *streamReply) {
+	if b.runStatus == nil {
+		stream.finishText(ctx, "run `"+stream.runID+"` status is unavailable")
+		return
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, defaultPollTimeout)
+	defer cancel()
+	run, err := waitForTerminalRun(pollCtx, stream.runID, b.runStatus, defaultPollInterval)
+	if err != nil {
+		stream.finishText(ctx, "failed to fetch run `"+stream.runID+"`: "+err.Error())
+		return
+	}
+	evt := RunEvent{Type: runEventCompleted, Data: map[string]any{"output": run.Output, "artifact_path": run.ArtifactPath}}
+	if strings.EqualFold(strings.TrimSpace(run.Status), "failed") {
+		evt = RunEvent{Type: runEventFailed, Data: map[string]any{"error": run.Error}}
+	}
+	stream.finish(ctx, evt)
+}
+
+func tailTruncate(text string, maxLen int) string {
+	if maxLen <= 3 || len(text) <= maxLen {
+		return text
+	}
+	start := len(text) - maxLen + 3
+	for start < len(text) && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	return "..." + text[start:]
+}
+
+func truncateRunes(text string, maxRunes int) string {
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes]) + "..."
+}
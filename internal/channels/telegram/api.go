@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type apiClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after,omitempty"`
+	} `json:"parameters,omitempty"`
+}
+
+// apiError carries the Bot API description and, for 429 responses, the
+// server-provided retry delay.
+type apiError struct {
+	method      string
+	description string
+	retryAfter  time.Duration
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("telegram %s: %s", e.method, e.description)
+}
+
+func (e *apiError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+type botCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+type user struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+}
+
+type chatInfo struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+type message struct {
+	MessageID      int64    `json:"message_id"`
+	From           *user    `json:"from,omitempty"`
+	Chat           chatInfo `json:"chat"`
+	Text           string   `json:"text,omitempty"`
+	ReplyToMessage *message `json:"reply_to_message,omitempty"`
+}
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message,omitempty"`
+}
+
+func (c *apiClient) call(ctx context.Context, method string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(c.baseURL, "/") + "/bot" + c.token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// The request URL embeds the bot token; never surface it.
+		return fmt.Errorf("telegram %s: request failed", method)
+	}
+	defer resp.Body.Close()
+	var decoded apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("telegram %s: decode response (status %d): %w", method, resp.StatusCode, err)
+	}
+	if !decoded.OK {
+		description := strings.TrimSpace(decoded.Description)
+		if description == "" {
+			description = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		}
+		return &apiError{method: method, description: description, retryAfter: time.Duration(decoded.Parameters.RetryAfter) * time.Second}
+	}
+	if out != nil && len(decoded.Result) > 0 {
+		if err := json.Unmarshal(decoded.Result, out); err != nil {
+			return fmt.Errorf("telegram %s: decode result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func (c *apiClient) getMe(ctx context.Context) (user, error) {
+	var me user
+	err := c.call(ctx, "getMe", map[string]any{}, &me)
+	return me, err
+}
+
+func (c *apiClient) getUpdates(ctx context.Context, offset int64, timeout int) ([]update, error) {
+	var updates []update
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+func (c *apiClient) sendMessage(ctx context.Context, chatID, replyTo int64, text string) (int64, error) {
+	payload := map[string]any{"chat_id": chatID, "text": text}
+	if replyTo != 0 {
+		payload["reply_parameters"] = map[string]any{"message_id": replyTo, "allow_sending_without_reply": true}
+	}
+	var sent message
+	if err := c.call(ctx, "sendMessage", payload, &sent); err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+func (c *apiClient) editMessageText(ctx context.Context, chatID, messageID int64, text string) error {
+	err := c.call(ctx, "editMessageText", map[string]any{"chat_id": chatID, "message_id": messageID, "text": text}, nil)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.description, "message is not modified") {
+		return nil
+	}
+	return err
+}
+
+func (c *apiClient) setMyCommands(ctx context.Context, commands []botCommand) error {
+	return c.call(ctx, "setMyCommands", map[string]any{"commands": commands}, nil)
+}
+
+func (c *apiClient) setWebhook(ctx context.Context, url, secret string) error {
+	payload := map[string]any{"url": url, "allowed_updates": []string{"message"}}
+	if secret != "" {
+		payload["secret_token"] = secret
+	}
+	return c.call(ctx, "setWebhook", payload, nil)
+}
+
+func (c *apiClient) deleteWebhook(ctx context.Context) error {
+	return c.call(ctx, "deleteWebhook", map[string]any{}, nil)
+}
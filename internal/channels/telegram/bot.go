@@ -0,0 +1,427 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"openclawssy/internal/channels/chat"
+	"openclawssy/internal/config"
+)
+
+const (
+	defaultPollInterval     = 1200 * time.Millisecond
+	defaultPollTimeout      = 2 * time.Minute
+	defaultTelegramMaxSize  = 4000
+	defaultStreamInterval   = 1500 * time.Millisecond
+	defaultStreamTimeout    = 14 * time.Minute
+	defaultAPITimeout       = 15 * time.Second
+	defaultWebhookBodyLimit = 1 << 20
+	defaultReconnectBackoff = time.Second
+	maxReconnectBackoff     = time.Minute
+	maxRememberedUpdates    = 1024
+)
+
+type Message struct {
+	UserID       string
+	RoomID       string
+	AgentID      string
+	Source       string
+	Text         string
+	ThinkingMode string
+}
+
+type Response struct {
+	ID        string
+	Status    string
+	Response  string
+	SessionID string
+}
+
+type RunStatus struct {
+	Status       string
+	Output       string
+	Error        string
+	ArtifactPath string
+}
+
+var (
+	errMessageRequired     = errors.New("message is required")
+	errInvalidThinkingMode = errors.New("request.invalid_thinking_mode: thinking must be one of never|on_error|always")
+)
+
+type RunEvent struct {
+	Type string
+	Data map[string]any
+}
+
+type MessageHandler func(ctx context.Context, msg Message) (Response, error)
+type RunStatusFunc func(ctx context.Context, runID string) (RunStatus, error)
+type RunEventsFunc func(runID string) (<-chan RunEvent, func())
+
+// botCommands are registered with setMyCommands so Telegram clients offer
+// them in the command menu; they map directly onto chat.Connector commands.
+var botCommands = []botCommand{
+	{Command: "new", Description: "Start a new chat session"},
+	{Command: "resume", Description: "Resume a chat session by id"},
+	{Command: "agent", Description: "Show or switch the active agent"},
+	{Command: "sessions", Description: "List recent chat sessions"},
+	{Command: "cancel", Description: "Cancel the running request"},
+	{Command: "approve", Description: "Approve a paused tool call by id"},
+	{Command: "deny", Description: "Deny a paused tool call by id"},
+}
+
+const helpText = "Send a message to talk to the agent.\n\nCommands:\n/new - start a new chat session\n/resume <session_id> - resume a chat session\n/agent [agent_id] - show or switch the active agent\n/sessions - list recent chat sessions\n/cancel - cancel the running request\n/approve <approval_id> - approve a paused tool call\n/deny <approval_id> [reason] - deny a paused tool call"
+
+type Bot struct {
+	cfg            config.TelegramConfig
+	allow          *chat.Allowlist
+	limiter        *chat.RateLimiter
+	handler        MessageHandler
+	runStatus      RunStatusFunc
+	runEvents      RunEventsFunc
+	api            *apiClient
+	webhookSecret  string
+	streamInterval time.Duration
+
+	mu          sync.Mutex
+	username    string
+	botID       int64
+	seen        map[int64]struct{}
+	seenOrder   []int64
+	doneCh      chan struct{}
+	cancelPolls context.CancelFunc
+}
+
+func New(cfg config.Config, handler MessageHandler, runStatus RunStatusFunc, runEvents RunEventsFunc) (*Bot, error) {
+	token := strings.TrimSpace(cfg.Telegram.Token)
+	if token == "" && cfg.Telegram.TokenEnv != "" {
+		token = strings.TrimSpace(os.Getenv(cfg.Telegram.TokenEnv))
+	}
+	if token == "" {
+		return nil, errors.New("telegram token is required")
+	}
+	secret := strings.TrimSpace(cfg.Telegram.WebhookSecret)
+	if secret == "" && cfg.Telegram.WebhookSecretEnv != "" {
+		secret = strings.TrimSpace(os.Getenv(cfg.Telegram.WebhookSecretEnv))
+	}
+	if cfg.Telegram.Mode == "webhook" && secret == "" {
+		return nil, errors.New("telegram webhook secret is required in webhook mode")
+	}
+	// Long polls hold the request open for the poll timeout, so the client
+	// timeout has to outlast it.
+	clientTimeout := defaultAPITimeout + time.Duration(cfg.Telegram.PollTimeoutSeconds)*time.Second
+	return &Bot{
+		cfg:     cfg.Telegram,
+		allow:   chat.NewAllowlist(cfg.Telegram.AllowUsers, cfg.Telegram.AllowChats),
+		limiter: chat.NewRateLimiter(cfg.Telegram.RateLimitPerMin, time.Minute),
+		handler: handler,
+		api: &apiClient{
+			baseURL:    cfg.Telegram.APIBaseURL,
+			token:      token,
+			httpClient: &http.Client{Timeout: clientTimeout},
+		},
+		runStatus:      runStatus,
+		runEvents:      runEvents,
+		webhookSecret:  secret,
+		streamInterval: defaultStreamInterval,
+		seen:           make(map[int64]struct{}),
+	}, nil
+}
+
+// Start identifies the bot, registers its native commands and either starts
+// long polling or points Telegram at the configured webhook URL.
+func (b *Bot) Start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+	me, err := b.api.getMe(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.username = me.Username
+	b.botID = me.ID
+	b.mu.Unlock()
+	if err := b.api.setMyCommands(ctx, botCommands); err != nil {
+		return err
+	}
+	if b.cfg.Mode == "webhook" {
+		if url := strings.TrimSpace(b.cfg.WebhookURL); url != "" {
+			return b.api.setWebhook(ctx, url, b.webhookSecret)
+		}
+		return nil
+	}
+	// getUpdates is rejected while a webhook is registered.
+	if err := b.api.deleteWebhook(ctx); err != nil {
+		return err
+	}
+	pollCtx, stopPolls := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	b.mu.Lock()
+	b.doneCh = done
+	b.cancelPolls = stopPolls
+	b.mu.Unlock()
+	go b.poll(pollCtx, done)
+	return nil
+}
+
+func (b *Bot) Stop() error {
+	b.mu.Lock()
+	done, stopPolls := b.doneCh, b.cancelPolls
+	b.mu.Unlock()
+	if stopPolls != nil {
+		stopPolls()
+	}
+	if done != nil {
+		<-done
+	}
+	return nil
+}
+
+func (b *Bot) handleUpdate(upd update) {
+	if !b.markSeen(upd.UpdateID) {
+		return
+	}
+	b.mu.Lock()
+	username, botID := b.username, b.botID
+	b.mu.Unlock()
+
+	msg := upd.Message
+	if msg == nil || msg.From == nil || msg.From.IsBot {
+		return
+	}
+	text, ok := addressedText(msg, username, botID)
+	if !ok {
+		return
+	}
+	b.dispatch(msg, text)
+}
+
+// markSeen drops webhook redeliveries and updates that were already fetched.
+func (b *Bot) markSeen(updateID int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.seen[updateID]; ok {
+		return false
+	}
+	b.seen[updateID] = struct{}{}
+	b.seenOrder = append(b.seenOrder, updateID)
+	if len(b.seenOrder) > maxRememberedUpdates {
+		delete(b.seen, b.seenOrder[0])
+		b.seenOrder = b.seenOrder[1:]
+	}
+	return true
+}
+
+// addressedText returns the message text with bot addressing removed. In
+// private chats every message is for the bot; in groups only commands,
+// @mentions and replies to the bot are picked up.
+func addressedText(msg *message, username string, botID int64) (string, bool) {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		return "", false
+	}
+	if strings.HasPrefix(text, "/") {
+		fields := strings.Fields(text)
+		command, target, addressed := strings.Cut(fields[0], "@")
+		if addressed && !strings.EqualFold(target, username) {
+			return "", false
+		}
+		return strings.TrimSpace(command + strings.TrimPrefix(text, fields[0])), true
+	}
+	if msg.Chat.Type == "private" {
+		return text, true
+	}
+	if mention := "@" + username; username != "" && strings.Contains(strings.ToLower(text), strings.ToLower(mention)) {
+		idx := strings.Index(strings.ToLower(text), strings.ToLower(mention))
+		return strings.TrimSpace(text[:idx] + text[idx+len(mention):]), true
+	}
+	if reply := msg.ReplyToMessage; reply != nil && reply.From != nil && reply.From.ID == botID {
+		return text, true
+	}
+	return "", false
+}
+
+func (b *Bot) dispatch(msg *message, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultAPITimeout)
+	defer cancel()
+
+	chatID := msg.Chat.ID
+	userID := strconv.FormatInt(msg.From.ID, 10)
+	roomID := strconv.FormatInt(chatID, 10)
+	if b.allow != nil && !b.allow.MessageAllowed(userID, roomID) {
+		return
+	}
+	if b.limiter != nil {
+		if allowed, retryAfter := b.limiter.AllowWithDetails(userID + ":" + roomID); !allowed {
+			_, _ = b.api.sendMessage(ctx, chatID, msg.MessageID, formatTelegramRateLimit(retryAfter))
+			return
+		}
+	}
+	if text == "/start" || text == "/help" {
+		_, _ = b.api.sendMessage(ctx, chatID, msg.MessageID, helpText)
+		return
+	}
+	if b.handler == nil {
+		_, _ = b.api.sendMessage(ctx, chatID, msg.MessageID, "chat handler is not configured")
+		return
+	}
+	content, thinkingMode, err := parseThinkingOverride(text)
+	if err != nil {
+		_, _ = b.api.sendMessage(ctx, chatID, msg.MessageID, formatTelegramError(err))
+		return
+	}
+	res, err := b.handler(context.Background(), Message{
+		UserID:       userID,
+		RoomID:       roomID,
+		Source:       "telegram",
+		Text:         content,
+		ThinkingMode: thinkingMode,
+	})
+	if err != nil {
+		_, _ = b.api.sendMessage(ctx, chatID, msg.MessageID, formatTelegramError(err))
+		return
+	}
+
+	reply := strings.TrimSpace(res.Response)
+	if reply == "" && strings.TrimSpace(res.ID) != "" {
+		reply = "queued run " + res.ID
+	}
+	if reply == "" {
+		return
+	}
+	parts := splitTelegramMessage(reply, defaultTelegramMaxSize)
+	messageID, err := b.api.sendMessage(ctx, chatID, msg.MessageID, parts[0])
+	if err != nil {
+		return
+	}
+	for _, part := range parts[1:] {
+		_, _ = b.api.sendMessage(ctx, chatID, 0, part)
+	}
+	if strings.HasPrefix(content, "/") || strings.TrimSpace(res.ID) == "" {
+		return
+	}
+	go b.streamRun(newStreamReply(b.api, chatID, messageID, res.ID))
+}
+
+func parseThinkingOverride(content string) (string, string, error) {
+	clean := strings.TrimSpace(content)
+	parts := strings.Fields(clean)
+	if len(parts) == 0 {
+		return "", "", errMessageRequired
+	}
+	first := strings.ToLower(parts[0])
+	if !strings.HasPrefix(first, "thinking=") {
+		return clean, "", nil
+	}
+	normalized := config.NormalizeThinkingMode(strings.TrimPrefix(first, "thinking="))
+	if !config.IsValidThinkingMode(normalized) {
+		return "", "", errInvalidThinkingMode
+	}
+	clean = strings.TrimSpace(strings.TrimPrefix(clean, parts[0]))
+	if clean == "" {
+		return "", "", errMessageRequired
+	}
+	return clean, normalized, nil
+}
+
+func formatTelegramError(err error) string {
+	if err == nil {
+		return "request failed"
+	}
+	var cooldown interface{ RetryAfter() time.Duration }
+	if errors.As(err, &cooldown) && cooldown.RetryAfter() > 0 {
+		return formatTelegramRateLimit(cooldown.RetryAfter())
+	}
+	msg := strings.TrimSpace(err.Error())
+	if msg == "" {
+		msg = "request failed"
+	}
+	lower := strings.ToLower(msg)
+	if strings.Contains(lower, "rate limited") {
+		return "rate limited, try again soon"
+	}
+	if strings.Contains(lower, "not allowlisted") {
+		return "not allowed in this chat or user scope"
+	}
+	if strings.Contains(lower, "run queue is full") {
+		return "run queue is full, retry shortly"
+	}
+	if strings.Contains(msg, "request.invalid_thinking_mode") {
+		return "error[request.invalid_thinking_mode]: thinking must be one of never|on_error|always"
+	}
+	return "request failed: " + msg
+}
+
+func formatTelegramRateLimit(retryAfter time.Duration) string {
+	if retryAfter <= 0 {
+		return "rate limited, try again soon"
+	}
+	seconds := int(retryAfter / time.Second)
+	if retryAfter%time.Second != 0 {
+		seconds++
+	}
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("rate limited, retry in %ds", seconds)
+}
+
+func waitForTerminalRun(ctx context.Context, runID string, runStatus RunStatusFunc, interval time.Duration) (RunStatus, error) {
+	if runStatus == nil {
+		return RunStatus{}, errors.New("run status lookup is not configured")
+	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		run, err := runStatus(ctx, runID)
+		if err != nil {
+			return RunStatus{}, err
+		}
+		switch strings.ToLower(strings.TrimSpace(run.Status)) {
+		case "completed", "failed":
+			return run, nil
+		}
+		select {
+		case <-ctx.Done():
+			return RunStatus{}, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func splitTelegramMessage(text string, maxLen int) []string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return []string{"(empty)"}
+	}
+	if maxLen <= 0 {
+		maxLen = defaultTelegramMaxSize
+	}
+	var out []string
+	remaining := trimmed
+	for len(remaining) > maxLen {
+		cut := strings.LastIndex(remaining[:maxLen], "\n")
+		if cut <= 0 {
+			cut = maxLen
+		}
+		if part := strings.TrimSpace(remaining[:cut]); part != "" {
+			out = append(out, part)
+		}
+		remaining = strings.TrimSpace(remaining[cut:])
+	}
+	if remaining != "" {
+		out = append(out, remaining)
+	}
+	if len(out) == 0 {
+		return []string{"(empty)"}
+	}
+	return out
+}
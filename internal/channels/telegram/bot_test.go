@@ -0,0 +1,319 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"openclawssy/internal/config"
+)
+
+type fakeBotCall struct {
+	Method  string
+	Payload map[string]any
+}
+
+type fakeBotAPI struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	calls   []fakeBotCall
+	updates []update
+	nextID  int64
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	t.Helper()
+	f := &fakeBotAPI{nextID: 100}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/bot123:abc/") {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "description": "Unauthorized"})
+			return
+		}
+		method := strings.TrimPrefix(r.URL.Path, "/bot123:abc/")
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		var result any = true
+		switch method {
+		case "getMe":
+			result = user{ID: 999, IsBot: true, Username: "clawbot"}
+		case "getUpdates":
+			offset := int64(payload["offset"].(float64))
+			deadline := time.Now().Add(100 * time.Millisecond)
+			var pending []update
+			for time.Now().Before(deadline) && r.Context().Err() == nil {
+				f.mu.Lock()
+				pending = pending[:0]
+				for _, upd := range f.updates {
+					if upd.UpdateID >= offset {
+						pending = append(pending, upd)
+					}
+				}
+				f.mu.Unlock()
+				if len(pending) > 0 {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			result = pending
+		case "sendMessage":
+			f.mu.Lock()
+			f.nextID++
+			result = message{MessageID: f.nextID}
+			f.mu.Unlock()
+		}
+		if method != "getUpdates" {
+			f.mu.Lock()
+			f.calls = append(f.calls, fakeBotCall{Method: method, Payload: payload})
+			f.mu.Unlock()
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeBotAPI) push(upd update) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, upd)
+}
+
+func (f *fakeBotAPI) callsFor(method string) []fakeBotCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []fakeBotCall
+	for _, call := range f.calls {
+		if call.Method == method {
+			out = append(out, call)
+		}
+	}
+	return out
+}
+
+func newTestBot(t *testing.T, api *fakeBotAPI, mode string, handler MessageHandler, events RunEventsFunc) *Bot {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Telegram.Enabled = true
+	cfg.Telegram.Mode = mode
+	cfg.Telegram.Token = "123:abc"
+	cfg.Telegram.WebhookSecret = "hook-secret"
+	cfg.Telegram.APIBaseURL = api.server.URL
+	cfg.Telegram.AllowUsers = []string{"42"}
+	cfg.Telegram.PollTimeoutSeconds = 1
+	bot, err := New(cfg, handler, nil, events)
+	if err != nil {
+		t.Fatalf("new bot: %v", err)
+	}
+	bot.streamInterval = 10 * time.Millisecond
+	t.Cleanup(func() { _ = bot.Stop() })
+	return bot
+}
+
+func privateMessage(updateID, messageID int64, text string) update {
+	return update{UpdateID: updateID, Message: &message{
+		MessageID: messageID,
+		From:      &user{ID: 42, FirstName: "Ada"},
+		Chat:      chatInfo{ID: 42, Type: "private"},
+		Text:      text,
+	}}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestPollingRegistersCommandsAndStreamsEdits(t *testing.T) {
+	api := newFakeBotAPI(t)
+	events := make(chan RunEvent, 8)
+	var mu sync.Mutex
+	var got []Message
+	bot := newTestBot(t, api, "polling", func(_ context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		got = append(got, msg)
+		mu.Unlock()
+		if strings.HasPrefix(msg.Text, "/") {
+			return Response{Response: "Started new chat: chat_2"}, nil
+		}
+		return Response{ID: "run_1", Status: "queued", Response: "Working on it"}, nil
+	}, func(runID string) (<-chan RunEvent, func()) {
+		return events, func() {}
+	})
+	if err := bot.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if len(api.callsFor("deleteWebhook")) != 1 {
+		t.Fatal("expected polling mode to clear any webhook")
+	}
+	commands := api.callsFor("setMyCommands")
+	if len(commands) != 1 || !strings.Contains(mustJSON(t, commands[0].Payload), `"command":"resume"`) {
+		t.Fatalf("expected native commands registration, got %+v", commands)
+	}
+
+	api.push(privateMessage(1, 10, "/new@clawbot"))
+	api.push(privateMessage(2, 11, "thinking=always explain the build"))
+	waitFor(t, func() bool { return len(api.callsFor("sendMessage")) == 2 })
+	mu.Lock()
+	if len(got) != 2 || got[0].Text != "/new" || got[1].Text != "explain the build" || got[1].ThinkingMode != "always" || got[1].RoomID != "42" || got[1].UserID != "42" || got[1].Source != "telegram" {
+		mu.Unlock()
+		t.Fatalf("unexpected handler messages: %+v", got)
+	}
+	mu.Unlock()
+
+	events <- RunEvent{Type: "tool_end", Data: map[string]any{"tool": "fs.read", "summary": "read go.mod"}}
+	events <- RunEvent{Type: "model_text", Data: map[string]any{"text": "Partial", "partial": true}}
+	waitFor(t, func() bool {
+		for _, call := range api.callsFor("editMessageText") {
+			if text, _ := call.Payload["text"].(string); strings.Contains(text, "Partial") && strings.Contains(text, "in progress") {
+				return true
+			}
+		}
+		return false
+	})
+	events <- RunEvent{Type: "completed", Data: map[string]any{"output": "Final answer"}}
+	waitFor(t, func() bool {
+		edits := api.callsFor("editMessageText")
+		last, _ := edits[len(edits)-1].Payload["text"].(string)
+		return strings.Contains(last, "Final answer") && strings.Contains(last, "fs.read -> read go.mod")
+	})
+	edits := api.callsFor("editMessageText")
+	if edits[0].Payload["message_id"].(float64) != 102 {
+		t.Fatalf("expected edits to target the run reply, got %+v", edits[0].Payload)
+	}
+}
+
+func TestWebhookRequiresSecretAndDispatches(t *testing.T) {
+	api := newFakeBotAPI(t)
+	handled := make(chan Message, 1)
+	bot := newTestBot(t, api, "webhook", func(_ context.Context, msg Message) (Response, error) {
+		handled <- msg
+		return Response{Response: "Active agent: default"}, nil
+	}, nil)
+	bot.cfg.WebhookURL = "https://example.test/telegram/webhook"
+	if err := bot.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	hooks := api.callsFor("setWebhook")
+	if len(hooks) != 1 || hooks[0].Payload["secret_token"] != "hook-secret" || hooks[0].Payload["url"] != "https://example.test/telegram/webhook" {
+		t.Fatalf("unexpected setWebhook calls: %+v", hooks)
+	}
+
+	body, _ := json.Marshal(privateMessage(7, 70, "/agent"))
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", bytes.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "wrong")
+	bot.WebhookHandler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for wrong secret, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/telegram/webhook", bytes.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "hook-secret")
+	bot.WebhookHandler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	select {
+	case msg := <-handled:
+		if msg.Text != "/agent" || msg.RoomID != "42" {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("webhook update was not dispatched")
+	}
+	waitFor(t, func() bool {
+		sent := api.callsFor("sendMessage")
+		return len(sent) == 1 && sent[0].Payload["text"] == "Active agent: default"
+	})
+}
+
+func TestDisallowedUsersAreIgnoredAndStartShowsHelp(t *testing.T) {
+	api := newFakeBotAPI(t)
+	calls := 0
+	var mu sync.Mutex
+	bot := newTestBot(t, api, "webhook", func(_ context.Context, msg Message) (Response, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return Response{Response: "ok"}, nil
+	}, nil)
+
+	stranger := privateMessage(1, 1, "hello")
+	stranger.Message.From.ID = 7
+	stranger.Message.Chat.ID = 7
+	bot.handleUpdate(stranger)
+	bot.handleUpdate(privateMessage(2, 2, "/start"))
+	bot.handleUpdate(privateMessage(2, 2, "/start"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 0 {
+		t.Fatalf("expected handler not to be called, got %d", calls)
+	}
+	sent := api.callsFor("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Payload["text"].(string), "/resume <session_id>") {
+		t.Fatalf("expected a single help reply, got %+v", sent)
+	}
+}
+
+func TestAddressedTextInGroups(t *testing.T) {
+	group := chatInfo{ID: -100, Type: "supergroup"}
+	cases := []struct {
+		name string
+		msg  message
+		want string
+		ok   bool
+	}{
+		{name: "plain group chatter", msg: message{Chat: group, Text: "hello all"}},
+		{name: "command for another bot", msg: message{Chat: group, Text: "/new@otherbot"}},
+		{name: "command for us", msg: message{Chat: group, Text: "/resume@ClawBot chat_1"}, want: "/resume chat_1", ok: true},
+		{name: "mention", msg: message{Chat: group, Text: "@clawbot what changed?"}, want: "what changed?", ok: true},
+		{name: "reply to bot", msg: message{Chat: group, Text: "and then?", ReplyToMessage: &message{From: &user{ID: 999}}}, want: "and then?", ok: true},
+		{name: "private", msg: message{Chat: chatInfo{ID: 1, Type: "private"}, Text: "hi"}, want: "hi", ok: true},
+	}
+	for _, tc := range cases {
+		got, ok := addressedText(&tc.msg, "clawbot", 999)
+		if ok != tc.ok || got != tc.want {
+			t.Fatalf("%s: got (%q, %v), want (%q, %v)", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestNewRequiresTokenAndWebhookSecret(t *testing.T) {
+	cfg := config.Default()
+	cfg.Telegram.TokenEnv = ""
+	cfg.Telegram.WebhookSecretEnv = ""
+	if _, err := New(cfg, nil, nil, nil); err == nil {
+		t.Fatal("expected missing token error")
+	}
+	cfg.Telegram.Token = "123:abc"
+	cfg.Telegram.Mode = "webhook"
+	if _, err := New(cfg, nil, nil, nil); err == nil || !strings.Contains(err.Error(), "webhook secret") {
+		t.Fatalf("expected webhook secret error, got %v", err)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(raw)
+}
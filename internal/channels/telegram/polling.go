@@ -0,0 +1,41 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// poll long-polls getUpdates until ctx is canceled, backing off after errors.
+// Updates are handled in order so a chat's messages queue in the order sent.
+func (b *Bot) poll(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	backoff := defaultReconnectBackoff
+	var offset int64
+	for {
+		updates, err := b.api.getUpdates(ctx, offset, b.cfg.PollTimeoutSeconds)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("telegram: poll updates: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+			continue
+		}
+		backoff = defaultReconnectBackoff
+		for _, upd := range updates {
+			if upd.UpdateID >= offset {
+				offset = upd.UpdateID + 1
+			}
+			b.handleUpdate(upd)
+		}
+	}
+}
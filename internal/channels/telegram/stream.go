@@ -0,0 +1,241 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	runEventStatus    = "status"
+	runEventModelText = "model_text"
+	runEventToolEnd   = "tool_end"
+	runEventCompleted = "completed"
+	runEventFailed    = "failed"
+
+	maxStreamToolLines = 6
+)
+
+// streamReply progressively edits the bot's reply with editMessageText while
+// a run is in flight. Replies are sent as plain text so model output never
+// trips Telegram's entity parser.
+type streamReply struct {
+	api       *apiClient
+	chatID    int64
+	messageID int64
+	runID     string
+	text      string
+	tools     []string
+	rendered  string
+}
+
+func newStreamReply(api *apiClient, chatID, messageID int64, runID string) *streamReply {
+	return &streamReply{api: api, chatID: chatID, messageID: messageID, runID: strings.TrimSpace(runID)}
+}
+
+func (r *streamReply) apply(evt RunEvent) {
+	switch evt.Type {
+	case runEventModelText:
+		text, _ := evt.Data["text"].(string)
+		if partial, ok := evt.Data["partial"].(bool); ok && !partial {
+			r.text = text
+			return
+		}
+		r.text += text
+	case runEventToolEnd:
+		r.tools = append(r.tools, formatStreamTool(evt.Data))
+	}
+}
+
+// promptApproval posts a separate message when the run pauses for approval so
+// the user is notified even though progress edits are silent.
+func (r *streamReply) promptApproval(ctx context.Context, evt RunEvent) {
+	if status, _ := evt.Data["status"].(string); status != "awaiting_approval" {
+		return
+	}
+	id, _ := evt.Data["approval_id"].(string)
+	tool, _ := evt.Data["tool"].(string)
+	text := "run " + r.runID + " is waiting for approval to call " + tool
+	if subject, _ := evt.Data["subject"].(string); strings.TrimSpace(subject) != "" {
+		text += ": " + truncateRunes(subject, 300)
+	}
+	if reason, _ := evt.Data["reason"].(string); strings.TrimSpace(reason) != "" {
+		text += "\n" + truncateRunes(reason, 300)
+	}
+	text += "\nreply /approve " + id + " or /deny " + id
+	_, _ = r.api.sendMessage(ctx, r.chatID, r.messageID, text)
+}
+
+func (r *streamReply) render() string {
+	sections := []string{}
+	if tools := r.toolBlock(maxStreamToolLines); tools != "" {
+		sections = append(sections, tools)
+	}
+	footer := "(run " + r.runID + " in progress)"
+	if text := strings.TrimSpace(r.text); text != "" {
+		budget := defaultTelegramMaxSize - len(footer) - len(strings.Join(sections, "\n\n")) - 4
+		sections = append(sections, tailTruncate(text, budget))
+	}
+	sections = append(sections, footer)
+	return strings.Join(sections, "\n\n")
+}
+
+func (r *streamReply) flush(ctx context.Context) {
+	if len(r.tools) == 0 && strings.TrimSpace(r.text) == "" {
+		return
+	}
+	content := r.render()
+	if content == r.rendered {
+		return
+	}
+	if err := r.api.editMessageText(ctx, r.chatID, r.messageID, content); err == nil {
+		r.rendered = content
+	}
+}
+
+func (r *streamReply) finish(ctx context.Context, evt RunEvent) {
+	var final string
+	if evt.Type == runEventFailed {
+		final = "run " + r.runID + " failed"
+		if errText := strings.TrimSpace(fmt.Sprintf("%v", evt.Data["error"])); errText != "" && errText != "<nil>" {
+			final += ": " + errText
+		}
+	} else {
+		final = strings.TrimSpace(fmt.Sprintf("%v", evt.Data["output"]))
+		if final == "" || final == "<nil>" {
+			final = strings.TrimSpace(r.text)
+		}
+		if final == "" {
+			final = "run completed without assistant output; check run trace/tool activity for details"
+		}
+		if artifact, _ := evt.Data["artifact_path"].(string); strings.TrimSpace(artifact) != "" {
+			final = fmt.Sprintf("%s\n\nartifact: %s", final, artifact)
+		}
+	}
+	if tools := r.toolBlock(0); tools != "" {
+		final = tools + "\n\n" + final
+	}
+	r.finishText(ctx, final)
+}
+
+func (r *streamReply) finishText(ctx context.Context, final string) {
+	parts := splitTelegramMessage(final, defaultTelegramMaxSize)
+	_ = r.api.editMessageText(ctx, r.chatID, r.messageID, parts[0])
+	r.rendered = parts[0]
+	for _, part := range parts[1:] {
+		_, _ = r.api.sendMessage(ctx, r.chatID, 0, part)
+	}
+}
+
+func (r *streamReply) toolBlock(limit int) string {
+	tools := r.tools
+	skipped := 0
+	if limit > 0 && len(tools) > limit {
+		skipped = len(tools) - limit
+		tools = tools[skipped:]
+	}
+	if len(tools) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(tools)+1)
+	if skipped > 0 {
+		lines = append(lines, fmt.Sprintf("... %d earlier tool calls", skipped))
+	}
+	for _, tool := range tools {
+		lines = append(lines, "> "+tool)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatStreamTool(data map[string]any) string {
+	tool := strings.TrimSpace(fmt.Sprintf("%v", data["tool"]))
+	if tool == "" || tool == "<nil>" {
+		tool = "unknown.tool"
+	}
+	if errText := strings.TrimSpace(fmt.Sprintf("%v", data["error"])); errText != "" && errText != "<nil>" {
+		return tool + " -> error: " + truncateRunes(errText, 160)
+	}
+	if summary := strings.TrimSpace(fmt.Sprintf("%v", data["summary"])); summary != "" && summary != "<nil>" {
+		return tool + " -> " + truncateRunes(summary, 160)
+	}
+	return tool
+}
+
+func (b *Bot) streamRun(stream *streamReply) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultStreamTimeout)
+	defer cancel()
+
+	if b.runEvents != nil {
+		events, unsubscribe := b.runEvents(stream.runID)
+		defer unsubscribe()
+		interval := b.streamInterval
+		if interval <= 0 {
+			interval = defaultStreamInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case evt, ok := <-events:
+				if !ok {
+					break loop
+				}
+				if evt.Type == runEventCompleted || evt.Type == runEventFailed {
+					stream.finish(ctx, evt)
+					return
+				}
+				if evt.Type == runEventStatus {
+					stream.promptApproval(ctx, evt)
+					continue
+				}
+				stream.apply(evt)
+			case <-ticker.C:
+				stream.flush(ctx)
+			case <-ctx.Done():
+				break loop
+			}
+		}
+	}
+	b.finishFromRunStatus(ctx, stream)
+}
+
+func (b *Bot) finishFromRunStatus(ctx context.Context, stream *streamReply) {
+	if b.runStatus == nil {
+		stream.finishText(ctx, "run "+stream.runID+" status is unavailable")
+		return
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, defaultPollTimeout)
+	defer cancel()
+	run, err := waitForTerminalRun(pollCtx, stream.runID, b.runStatus, defaultPollInterval)
+	if err != nil {
+		stream.finishText(ctx, "failed to fetch run "+stream.runID+": "+err.Error())
+		return
+	}
+	evt := RunEvent{Type: runEventCompleted, Data: map[string]any{"output": run.Output, "artifact_path": run.ArtifactPath}}
+	if strings.EqualFold(strings.TrimSpace(run.Status), "failed") {
+		evt = RunEvent{Type: runEventFailed, Data: map[string]any{"error": run.Error}}
+	}
+	stream.finish(ctx, evt)
+}
+
+func tailTruncate(text string, maxLen int) string {
+	if maxLen <= 3 || len(text) <= maxLen {
+		return text
+	}
+	start := len(text) - maxLen + 3
+	for start < len(text) && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	return "..." + text[start:]
+}
+
+func truncateRunes(text string, maxRunes int) string {
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes]) + "..."
+}
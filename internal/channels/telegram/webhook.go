@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// WebhookHandler serves the Bot API webhook. Telegram echoes the secret_token
+// given to setWebhook in X-Telegram-Bot-Api-Secret-Token; requests without a
+// matching secret are rejected. Updates are processed in the background.
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		got := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+		if b.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(b.webhookSecret)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, defaultWebhookBodyLimit))
+		if err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		var upd update
+		if err := json.Unmarshal(body, &upd); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		go b.handleUpdate(upd)
+		w.WriteHeader(http.StatusOK)
+	})
+}
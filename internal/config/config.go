@@ -24,6 +24,14 @@ type Config struct {
 	Agents    AgentsConfig    `json:"agents"`
 	Chat      ChatConfig      `json:"chat"`
 	Discord   DiscordConfig   `json:"discord"`
+	Slack     SlackConfig     `json:"slack"`
+	Telegram  TelegramConfig  `json:"telegram"`
+	Email     EmailConfig     `json:"email"`
+	OpenAI    OpenAIConfig    `json:"openai_compat"`
+	MCP       MCPConfig       `json:"mcp"`
+	Approvals ApprovalsConfig `json:"approvals"`
+	Taint     TaintConfig     `json:"taint"`
+	Audit     AuditConfig     `json:"audit"`
 	Secrets   SecretsConfig   `json:"secrets"`
 	Memory    MemoryConfig    `json:"memory"`
 }
@@ -106,9 +114,20 @@ type ModelConfig struct {
 }
 
 type AgentProfile struct {
-	Enabled         *bool       `json:"enabled,omitempty"`
-	Model           ModelConfig `json:"model,omitempty"`
-	SelfImprovement bool        `json:"self_improvement,omitempty"`
+	Enabled         *bool               `json:"enabled,omitempty"`
+	Model           ModelConfig         `json:"model,omitempty"`
+	SelfImprovement bool                `json:"self_improvement,omitempty"`
+	Taint           *AgentTaintOverride `json:"taint,omitempty"`
+}
+
+// AgentTaintOverride replaces the fields of the global TaintConfig that it
+// sets, for one agent.
+type AgentTaintOverride struct {
+	Enabled        *bool    `json:"enabled,omitempty"`
+	Action         string   `json:"action,omitempty"`
+	UntrustedTools []string `json:"untrusted_tools,omitempty"`
+	UntrustedPaths []string `json:"untrusted_paths,omitempty"`
+	HighRiskTools  []string `json:"high_risk_tools,omitempty"`
 }
 
 type AgentsConfig struct {
@@ -153,23 +172,306 @@ type DiscordConfig struct {
 	AllowUsers      []string `json:"allow_users,omitempty"`
 	CommandPrefix   string   `json:"command_prefix,omitempty"`
 	RateLimitPerMin int      `json:"rate_limit_per_min,omitempty"`
+	AttachmentMaxMB int      `json:"attachment_max_mb,omitempty"`
+	AttachmentTypes []string `json:"attachment_types,omitempty"`
+	FileReplyChars  int      `json:"file_reply_chars,omitempty"`
+}
+
+type SlackConfig struct {
+	Enabled          bool     `json:"enabled"`
+	Mode             string   `json:"mode"`
+	BotToken         string   `json:"bot_token,omitempty"`
+	BotTokenEnv      string   `json:"bot_token_env,omitempty"`
+	AppToken         string   `json:"app_token,omitempty"`
+	AppTokenEnv      string   `json:"app_token_env,omitempty"`
+	SigningSecret    string   `json:"signing_secret,omitempty"`
+	SigningSecretEnv string   `json:"signing_secret_env,omitempty"`
+	EventsPath       string   `json:"events_path,omitempty"`
+	APIBaseURL       string   `json:"api_base_url,omitempty"`
+	DefaultAgentID   string   `json:"default_agent_id"`
+	AllowChannels    []string `json:"allow_channels,omitempty"`
+	AllowUsers       []string `json:"allow_users,omitempty"`
+	RateLimitPerMin  int      `json:"rate_limit_per_min,omitempty"`
+}
+
+type TelegramConfig struct {
+	Enabled            bool     `json:"enabled"`
+	Mode               string   `json:"mode"`
+	Token              string   `json:"token,omitempty"`
+	TokenEnv           string   `json:"token_env,omitempty"`
+	WebhookPath        string   `json:"webhook_path,omitempty"`
+	WebhookURL         string   `json:"webhook_url,omitempty"`
+	WebhookSecret      string   `json:"webhook_secret,omitempty"`
+	WebhookSecretEnv   string   `json:"webhook_secret_env,omitempty"`
+	APIBaseURL         string   `json:"api_base_url,omitempty"`
+	DefaultAgentID     string   `json:"default_agent_id"`
+	AllowChats         []string `json:"allow_chats,omitempty"`
+	AllowUsers         []string `json:"allow_users,omitempty"`
+	RateLimitPerMin    int      `json:"rate_limit_per_min,omitempty"`
+	PollTimeoutSeconds int      `json:"poll_timeout_seconds,omitempty"`
+}
+
+type EmailConfig struct {
+	Enabled         bool              `json:"enabled"`
+	Mode            string            `json:"mode"`
+	FromAddress     string            `json:"from_address,omitempty"`
+	DefaultAgentID  string            `json:"default_agent_id"`
+	AllowSenders    []string          `json:"allow_senders,omitempty"`
+	AuthservID      string            `json:"authserv_id,omitempty"`
+	RateLimitPerMin int               `json:"rate_limit_per_min,omitempty"`
+	AttachmentMaxMB int               `json:"attachment_max_mb,omitempty"`
+	AttachmentTypes []string          `json:"attachment_types,omitempty"`
+	IMAP            EmailIMAPConfig   `json:"imap"`
+	Listen          EmailListenConfig `json:"listen"`
+	SMTP            EmailSMTPConfig   `json:"smtp"`
+}
+
+type EmailIMAPConfig struct {
+	Addr        string `json:"addr,omitempty"`
+	Insecure    bool   `json:"insecure,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+	Mailbox     string `json:"mailbox,omitempty"`
+	PollSeconds int    `json:"poll_seconds,omitempty"`
+}
+
+type EmailListenConfig struct {
+	Addr      string `json:"addr,omitempty"`
+	MaxSizeMB int    `json:"max_size_mb,omitempty"`
+}
+
+type EmailSMTPConfig struct {
+	Addr        string `json:"addr,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+}
+
+type OpenAIConfig struct {
+	Enabled     bool     `json:"enabled"`
+	AllowAgents []string `json:"allow_agents,omitempty"`
 }
 
+type MCPConfig struct {
+	Enabled        bool              `json:"enabled"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+	Servers        []MCPServerConfig `json:"servers,omitempty"`
+	Serve          MCPServeConfig    `json:"serve"`
+}
+
+type MCPServeConfig struct {
+	AgentID string   `json:"agent_id"`
+	Tools   []string `json:"tools,omitempty"`
+}
+
+type MCPServerConfig struct {
+	Name           string            `json:"name"`
+	Transport      string            `json:"transport"`
+	Disabled       bool              `json:"disabled,omitempty"`
+	Command        string            `json:"command,omitempty"`
+	Args           []string          `json:"args,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	URL            string            `json:"url,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	BearerTokenEnv string            `json:"bearer_token_env,omitempty"`
+}
+
+// ApprovalsConfig puts the listed tools in ask mode: a call pauses its run
+// until an operator approves or denies it, or TimeoutSeconds passes and
+// TimeoutDecision applies.
+type ApprovalsConfig struct {
+	Enabled         bool     `json:"enabled"`
+	Tools           []string `json:"tools,omitempty"`
+	TimeoutSeconds  int      `json:"timeout_seconds,omitempty"`
+	TimeoutDecision string   `json:"timeout_decision,omitempty"`
+	AllowChat       bool     `json:"allow_chat"`
+	RulesFile       string   `json:"rules_file,omitempty"`
+}
+
+// TaintConfig guards runs against prompt injection. Output of UntrustedTools,
+// and of fs.read on UntrustedPaths, is marked untrusted and taints the run;
+// from then on HighRiskTools need an operator's approval (Action "approve")
+// or are refused ("deny") until the run ends. http.request is high risk only
+// for hosts the run had not contacted before it was tainted.
+type TaintConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Action         string   `json:"action,omitempty"`
+	UntrustedTools []string `json:"untrusted_tools,omitempty"`
+	UntrustedPaths []string `json:"untrusted_paths,omitempty"`
+	HighRiskTools  []string `json:"high_risk_tools,omitempty"`
+}
+
+const (
+	TaintActionApprove = "approve"
+	TaintActionDeny    = "deny"
+)
+
+// TaintFor returns the taint settings for agentID with its profile override
+// applied.
+func (c Config) TaintFor(agentID string) TaintConfig {
+	out := c.Taint
+	profile, ok := c.Agents.Profiles[strings.TrimSpace(agentID)]
+	if !ok || profile.Taint == nil {
+		return out
+	}
+	override := profile.Taint
+	if override.Enabled != nil {
+		out.Enabled = *override.Enabled
+	}
+	if override.Action != "" {
+		out.Action = override.Action
+	}
+	if override.UntrustedTools != nil {
+		out.UntrustedTools = override.UntrustedTools
+	}
+	if override.UntrustedPaths != nil {
+		out.UntrustedPaths = override.UntrustedPaths
+	}
+	if override.HighRiskTools != nil {
+		out.HighRiskTools = override.HighRiskTools
+	}
+	return out
+}
+
+// AuditConfig tunes the hash-chained agent audit logs. MaxFileBytes and
+// MaxFileAgeHours rotate the active file (0 disables either); rotated files
+// are gzipped with Compress and pruned past RetainFiles or RetainDays.
+// Checkpoints appends an Ed25519-signed checkpoint every CheckpointEvery
+// events using SigningKeyFile, which is created on first use.
+type AuditConfig struct {
+	MaxFileBytes    int64             `json:"max_file_bytes,omitempty"`
+	MaxFileAgeHours int               `json:"max_file_age_hours,omitempty"`
+	Compress        bool              `json:"compress"`
+	RetainFiles     int               `json:"retain_files,omitempty"`
+	RetainDays      int               `json:"retain_days,omitempty"`
+	Checkpoints     bool              `json:"checkpoints"`
+	CheckpointEvery int               `json:"checkpoint_every,omitempty"`
+	SigningKeyFile  string            `json:"signing_key_file,omitempty"`
+	Export          AuditExportConfig `json:"export"`
+}
+
+// AuditExportConfig forwards every audit record to a SIEM in addition to the
+// local log.
+type AuditExportConfig struct {
+	Syslog AuditSyslogConfig `json:"syslog"`
+	HTTP   AuditHTTPConfig   `json:"http"`
+}
+
+// AuditSyslogConfig sends RFC 5424 messages over UDP or TCP. Facility
+// defaults to 13 (log audit).
+type AuditSyslogConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Network  string `json:"network,omitempty"`
+	Address  string `json:"address,omitempty"`
+	AppName  string `json:"app_name,omitempty"`
+	Facility int    `json:"facility,omitempty"`
+}
+
+// AuditHTTPConfig posts batches of records as JSON to URL.
+type AuditHTTPConfig struct {
+	Enabled        bool              `json:"enabled"`
+	URL            string            `json:"url,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	BatchSize      int               `json:"batch_size,omitempty"`
+	FlushSeconds   int               `json:"flush_seconds,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+// SecretsConfig lists secret backends in resolution order: the first backend
+// holding a name supplies its value. "store" is the encrypted local store and
+// the only one that accepts writes.
 type SecretsConfig struct {
-	StoreFile     string `json:"store_file"`
-	MasterKeyFile string `json:"master_key_file"`
+	StoreFile     string               `json:"store_file"`
+	MasterKeyFile string               `json:"master_key_file"`
+	Backends      []string             `json:"backends,omitempty"`
+	Vault         SecretsVaultConfig   `json:"vault"`
+	Files         SecretsFilesConfig   `json:"files"`
+	Env           SecretsEnvConfig     `json:"env"`
+	KeyWrap       SecretsKeyWrapConfig `json:"key_wrap"`
+}
+
+// SecretsVaultConfig reads a HashiCorp Vault compatible KV v2 engine. A secret
+// name maps to <mount>/data/<prefix>/<name> and its value is Field.
+type SecretsVaultConfig struct {
+	Address        string `json:"address,omitempty"`
+	Mount          string `json:"mount,omitempty"`
+	Prefix         string `json:"prefix,omitempty"`
+	Field          string `json:"field,omitempty"`
+	Namespace      string `json:"namespace,omitempty"`
+	TokenEnv       string `json:"token_env,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
 }
 
+// SecretsFilesConfig reads one file per secret under Dir, as mounted by
+// Kubernetes or Docker.
+type SecretsFilesConfig struct {
+	Dir string `json:"dir,omitempty"`
+}
+
+// SecretsEnvConfig reads secrets from environment variables named Prefix plus
+// the upper-cased name.
+type SecretsEnvConfig struct {
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// SecretsKeyWrapConfig wraps the master key with external commands, e.g. a
+// TPM or OS keyring helper. WrapCommand reads the base64 key on stdin and
+// writes an opaque blob; UnwrapCommand reverses it.
+type SecretsKeyWrapConfig struct {
+	WrapCommand   []string `json:"wrap_command,omitempty"`
+	UnwrapCommand []string `json:"unwrap_command,omitempty"`
+}
+
+const (
+	SecretsBackendStore = "store"
+	SecretsBackendVault = "vault"
+	SecretsBackendFiles = "files"
+	SecretsBackendEnv   = "env"
+)
+
 type MemoryConfig struct {
-	Enabled           bool   `json:"enabled"`
-	MaxWorkingItems   int    `json:"max_working_items,omitempty"`
-	MaxPromptTokens   int    `json:"max_prompt_tokens,omitempty"`
-	AutoCheckpoint    bool   `json:"auto_checkpoint"`
-	ProactiveEnabled  bool   `json:"proactive_enabled"`
-	EmbeddingsEnabled bool   `json:"embeddings_enabled"`
-	EmbeddingProvider string `json:"embedding_provider,omitempty"`
-	EmbeddingModel    string `json:"embedding_model,omitempty"`
-	EventBufferSize   int    `json:"event_buffer_size,omitempty"`
+	Enabled           bool                        `json:"enabled"`
+	MaxWorkingItems   int                         `json:"max_working_items,omitempty"`
+	MaxPromptTokens   int                         `json:"max_prompt_tokens,omitempty"`
+	AutoCheckpoint    bool                        `json:"auto_checkpoint"`
+	ProactiveEnabled  bool                        `json:"proactive_enabled"`
+	EmbeddingsEnabled bool                        `json:"embeddings_enabled"`
+	EmbeddingProvider string                      `json:"embedding_provider,omitempty"`
+	EmbeddingModel    string                      `json:"embedding_model,omitempty"`
+	EventBufferSize   int                         `json:"event_buffer_size,omitempty"`
+	KindPolicies      map[string]MemoryKindPolicy `json:"kind_policies,omitempty"`
+	EventRetention    MemoryEventRetentionConfig  `json:"event_retention"`
+	Housekeeping      MemoryHousekeepingConfig    `json:"housekeeping"`
+}
+
+type MemoryHousekeepingConfig struct {
+	CheckpointEventThreshold int `json:"checkpoint_event_threshold,omitempty"`
+	CheckpointIdleMinutes    int `json:"checkpoint_idle_minutes,omitempty"`
+	MaintenanceIntervalHours int `json:"maintenance_interval_hours,omitempty"`
+}
+
+type MemoryEventRetentionConfig struct {
+	MaxAgeDays        int `json:"max_age_days,omitempty"`
+	MaxMB             int `json:"max_mb,omitempty"`
+	CompressAfterDays int `json:"compress_after_days,omitempty"`
+}
+
+type MemoryKindPolicy struct {
+	NeverExpire      bool `json:"never_expire,omitempty"`
+	ArchiveAfterDays int  `json:"archive_after_days,omitempty"`
+	HalfLifeDays     int  `json:"half_life_days,omitempty"`
+	RequireReview    bool `json:"require_review,omitempty"`
+}
+
+func DefaultMemoryKindPolicies() map[string]MemoryKindPolicy {
+	return map[string]MemoryKindPolicy{
+		"preference": {NeverExpire: true},
+		"decision":   {HalfLifeDays: 180},
+		"incident":   {ArchiveAfterDays: 30, HalfLifeDays: 7},
+		"issue":      {ArchiveAfterDays: 30, HalfLifeDays: 7},
+		"summary":    {ArchiveAfterDays: 60, HalfLifeDays: 14},
+	}
 }
 
 func Default() Config {
@@ -254,10 +556,91 @@ func Default() Config {
 			DefaultAgentID:  "default",
 			CommandPrefix:   "!ask",
 			RateLimitPerMin: 20,
+			AttachmentMaxMB: 8,
+			AttachmentTypes: []string{"text/", "image/", "application/json", "application/pdf", ".log", ".md", ".csv", ".yaml", ".yml"},
+			FileReplyChars:  6000,
+		},
+		Slack: SlackConfig{
+			Enabled:          false,
+			Mode:             "socket",
+			BotTokenEnv:      "SLACK_BOT_TOKEN",
+			AppTokenEnv:      "SLACK_APP_TOKEN",
+			SigningSecretEnv: "SLACK_SIGNING_SECRET",
+			EventsPath:       "/slack/events",
+			APIBaseURL:       "https://slack.com/api",
+			DefaultAgentID:   "default",
+			RateLimitPerMin:  20,
+		},
+		Telegram: TelegramConfig{
+			Enabled:            false,
+			Mode:               "polling",
+			TokenEnv:           "TELEGRAM_BOT_TOKEN",
+			WebhookPath:        "/telegram/webhook",
+			WebhookSecretEnv:   "TELEGRAM_WEBHOOK_SECRET",
+			APIBaseURL:         "https://api.telegram.org",
+			DefaultAgentID:     "default",
+			RateLimitPerMin:    20,
+			PollTimeoutSeconds: 30,
+		},
+		Email: EmailConfig{
+			Enabled:         false,
+			Mode:            "imap",
+			DefaultAgentID:  "default",
+			RateLimitPerMin: 10,
+			AttachmentMaxMB: 8,
+			AttachmentTypes: []string{"text/", "image/", "application/json", "application/pdf", ".log", ".md", ".csv", ".yaml", ".yml"},
+			IMAP: EmailIMAPConfig{
+				PasswordEnv: "EMAIL_IMAP_PASSWORD",
+				Mailbox:     "INBOX",
+				PollSeconds: 60,
+			},
+			Listen: EmailListenConfig{
+				Addr:      "127.0.0.1:2525",
+				MaxSizeMB: 25,
+			},
+			SMTP: EmailSMTPConfig{
+				PasswordEnv: "EMAIL_SMTP_PASSWORD",
+			},
+		},
+		MCP: MCPConfig{
+			Enabled:        false,
+			TimeoutSeconds: 30,
+			Serve: MCPServeConfig{
+				AgentID: "default",
+				Tools:   []string{"agent.run", "memory.search", "fs.read", "fs.list", "code.search"},
+			},
+		},
+		Approvals: ApprovalsConfig{
+			Enabled:         false,
+			Tools:           []string{"shell.exec", "fs.delete", "secrets.set"},
+			TimeoutSeconds:  300,
+			TimeoutDecision: "deny",
+			AllowChat:       false,
+			RulesFile:       ".openclawssy/policy/approvals.json",
+		},
+		Taint: TaintConfig{
+			Enabled:        false,
+			Action:         TaintActionApprove,
+			UntrustedTools: []string{"http.request", "agent.message.inbox"},
+			HighRiskTools:  []string{"secrets.get", "shell.exec", "skill.run", "policy.grant", "http.request", "agent.run", "agent.message.send", "memory.write"},
+		},
+		Audit: AuditConfig{
+			MaxFileBytes:    0,
+			Checkpoints:     false,
+			CheckpointEvery: 100,
+			SigningKeyFile:  ".openclawssy/keys/audit_ed25519.key",
+			Export: AuditExportConfig{
+				Syslog: AuditSyslogConfig{Network: "udp", AppName: "openclawssy", Facility: 13},
+				HTTP:   AuditHTTPConfig{BatchSize: 100, FlushSeconds: 5, TimeoutSeconds: 10},
+			},
 		},
 		Secrets: SecretsConfig{
 			StoreFile:     ".openclawssy/secrets.enc",
 			MasterKeyFile: ".openclawssy/master.key",
+			Backends:      []string{SecretsBackendStore},
+			Vault:         SecretsVaultConfig{Mount: "secret", Prefix: "openclawssy", Field: "value", TokenEnv: "VAULT_TOKEN", TimeoutSeconds: 10},
+			Files:         SecretsFilesConfig{Dir: "/run/secrets"},
+			Env:           SecretsEnvConfig{Prefix: "OPENCLAWSSY_SECRET_"},
 		},
 		Memory: MemoryConfig{
 			Enabled:           false,
@@ -269,6 +652,17 @@ func Default() Config {
 			EmbeddingProvider: "openrouter",
 			EmbeddingModel:    "text-embedding-3-small",
 			EventBufferSize:   256,
+			KindPolicies:      DefaultMemoryKindPolicies(),
+			EventRetention: MemoryEventRetentionConfig{
+				MaxAgeDays:        30,
+				MaxMB:             256,
+				CompressAfterDays: 2,
+			},
+			Housekeeping: MemoryHousekeepingConfig{
+				CheckpointEventThreshold: 50,
+				CheckpointIdleMinutes:    30,
+				MaintenanceIntervalHours: 168,
+			},
 		},
 	}
 }
@@ -340,12 +734,197 @@ func (c *Config) ApplyDefaults() {
 	if c.Discord.RateLimitPerMin == 0 {
 		c.Discord.RateLimitPerMin = d.Discord.RateLimitPerMin
 	}
+	if c.Discord.AttachmentMaxMB == 0 {
+		c.Discord.AttachmentMaxMB = d.Discord.AttachmentMaxMB
+	}
+	if c.Discord.AttachmentTypes == nil {
+		c.Discord.AttachmentTypes = append([]string(nil), d.Discord.AttachmentTypes...)
+	}
+	if c.Discord.FileReplyChars == 0 {
+		c.Discord.FileReplyChars = d.Discord.FileReplyChars
+	}
+	if c.Slack.Mode == "" {
+		c.Slack.Mode = d.Slack.Mode
+	}
+	if c.Slack.BotTokenEnv == "" {
+		c.Slack.BotTokenEnv = d.Slack.BotTokenEnv
+	}
+	if c.Slack.AppTokenEnv == "" {
+		c.Slack.AppTokenEnv = d.Slack.AppTokenEnv
+	}
+	if c.Slack.SigningSecretEnv == "" {
+		c.Slack.SigningSecretEnv = d.Slack.SigningSecretEnv
+	}
+	if c.Slack.EventsPath == "" {
+		c.Slack.EventsPath = d.Slack.EventsPath
+	}
+	if c.Slack.APIBaseURL == "" {
+		c.Slack.APIBaseURL = d.Slack.APIBaseURL
+	}
+	if c.Slack.DefaultAgentID == "" {
+		c.Slack.DefaultAgentID = d.Slack.DefaultAgentID
+	}
+	if c.Slack.RateLimitPerMin == 0 {
+		c.Slack.RateLimitPerMin = d.Slack.RateLimitPerMin
+	}
+	if c.Telegram.Mode == "" {
+		c.Telegram.Mode = d.Telegram.Mode
+	}
+	if c.Telegram.TokenEnv == "" {
+		c.Telegram.TokenEnv = d.Telegram.TokenEnv
+	}
+	if c.Telegram.WebhookPath == "" {
+		c.Telegram.WebhookPath = d.Telegram.WebhookPath
+	}
+	if c.Telegram.WebhookSecretEnv == "" {
+		c.Telegram.WebhookSecretEnv = d.Telegram.WebhookSecretEnv
+	}
+	if c.Telegram.APIBaseURL == "" {
+		c.Telegram.APIBaseURL = d.Telegram.APIBaseURL
+	}
+	if c.Telegram.DefaultAgentID == "" {
+		c.Telegram.DefaultAgentID = d.Telegram.DefaultAgentID
+	}
+	if c.Telegram.RateLimitPerMin == 0 {
+		c.Telegram.RateLimitPerMin = d.Telegram.RateLimitPerMin
+	}
+	if c.Telegram.PollTimeoutSeconds == 0 {
+		c.Telegram.PollTimeoutSeconds = d.Telegram.PollTimeoutSeconds
+	}
+	if c.Email.Mode == "" {
+		c.Email.Mode = d.Email.Mode
+	}
+	if c.Email.DefaultAgentID == "" {
+		c.Email.DefaultAgentID = d.Email.DefaultAgentID
+	}
+	if c.Email.RateLimitPerMin == 0 {
+		c.Email.RateLimitPerMin = d.Email.RateLimitPerMin
+	}
+	if c.Email.AttachmentMaxMB == 0 {
+		c.Email.AttachmentMaxMB = d.Email.AttachmentMaxMB
+	}
+	if c.Email.AttachmentTypes == nil {
+		c.Email.AttachmentTypes = append([]string(nil), d.Email.AttachmentTypes...)
+	}
+	if c.Email.IMAP.PasswordEnv == "" {
+		c.Email.IMAP.PasswordEnv = d.Email.IMAP.PasswordEnv
+	}
+	if c.Email.IMAP.Mailbox == "" {
+		c.Email.IMAP.Mailbox = d.Email.IMAP.Mailbox
+	}
+	if c.Email.IMAP.PollSeconds == 0 {
+		c.Email.IMAP.PollSeconds = d.Email.IMAP.PollSeconds
+	}
+	if c.Email.Listen.Addr == "" {
+		c.Email.Listen.Addr = d.Email.Listen.Addr
+	}
+	if c.Email.Listen.MaxSizeMB == 0 {
+		c.Email.Listen.MaxSizeMB = d.Email.Listen.MaxSizeMB
+	}
+	if c.Email.SMTP.PasswordEnv == "" {
+		c.Email.SMTP.PasswordEnv = d.Email.SMTP.PasswordEnv
+	}
+	if c.MCP.TimeoutSeconds == 0 {
+		c.MCP.TimeoutSeconds = d.MCP.TimeoutSeconds
+	}
+	if strings.TrimSpace(c.MCP.Serve.AgentID) == "" {
+		c.MCP.Serve.AgentID = d.MCP.Serve.AgentID
+	}
+	if c.MCP.Serve.Tools == nil {
+		c.MCP.Serve.Tools = append([]string(nil), d.MCP.Serve.Tools...)
+	}
+	for i := range c.MCP.Servers {
+		if c.MCP.Servers[i].Transport == "" {
+			c.MCP.Servers[i].Transport = "stdio"
+		}
+	}
+	if c.Approvals.Tools == nil {
+		c.Approvals.Tools = append([]string(nil), d.Approvals.Tools...)
+	}
+	if c.Approvals.TimeoutSeconds == 0 {
+		c.Approvals.TimeoutSeconds = d.Approvals.TimeoutSeconds
+	}
+	c.Approvals.TimeoutDecision = strings.ToLower(strings.TrimSpace(c.Approvals.TimeoutDecision))
+	if c.Approvals.TimeoutDecision == "" {
+		c.Approvals.TimeoutDecision = d.Approvals.TimeoutDecision
+	}
+	if strings.TrimSpace(c.Approvals.RulesFile) == "" {
+		c.Approvals.RulesFile = d.Approvals.RulesFile
+	}
+	c.Taint.Action = strings.ToLower(strings.TrimSpace(c.Taint.Action))
+	if c.Taint.Action == "" {
+		c.Taint.Action = d.Taint.Action
+	}
+	if c.Taint.UntrustedTools == nil {
+		c.Taint.UntrustedTools = append([]string(nil), d.Taint.UntrustedTools...)
+	}
+	if c.Taint.HighRiskTools == nil {
+		c.Taint.HighRiskTools = append([]string(nil), d.Taint.HighRiskTools...)
+	}
+	for id, profile := range c.Agents.Profiles {
+		if profile.Taint != nil {
+			profile.Taint.Action = strings.ToLower(strings.TrimSpace(profile.Taint.Action))
+			c.Agents.Profiles[id] = profile
+		}
+	}
+	if c.Audit.CheckpointEvery == 0 {
+		c.Audit.CheckpointEvery = d.Audit.CheckpointEvery
+	}
+	if strings.TrimSpace(c.Audit.SigningKeyFile) == "" {
+		c.Audit.SigningKeyFile = d.Audit.SigningKeyFile
+	}
+	c.Audit.Export.Syslog.Network = strings.ToLower(strings.TrimSpace(c.Audit.Export.Syslog.Network))
+	if c.Audit.Export.Syslog.Network == "" {
+		c.Audit.Export.Syslog.Network = d.Audit.Export.Syslog.Network
+	}
+	if strings.TrimSpace(c.Audit.Export.Syslog.AppName) == "" {
+		c.Audit.Export.Syslog.AppName = d.Audit.Export.Syslog.AppName
+	}
+	if c.Audit.Export.Syslog.Facility == 0 {
+		c.Audit.Export.Syslog.Facility = d.Audit.Export.Syslog.Facility
+	}
+	if c.Audit.Export.HTTP.BatchSize == 0 {
+		c.Audit.Export.HTTP.BatchSize = d.Audit.Export.HTTP.BatchSize
+	}
+	if c.Audit.Export.HTTP.FlushSeconds == 0 {
+		c.Audit.Export.HTTP.FlushSeconds = d.Audit.Export.HTTP.FlushSeconds
+	}
+	if c.Audit.Export.HTTP.TimeoutSeconds == 0 {
+		c.Audit.Export.HTTP.TimeoutSeconds = d.Audit.Export.HTTP.TimeoutSeconds
+	}
 	if c.Secrets.StoreFile == "" {
 		c.Secrets.StoreFile = d.Secrets.StoreFile
 	}
 	if c.Secrets.MasterKeyFile == "" {
 		c.Secrets.MasterKeyFile = d.Secrets.MasterKeyFile
 	}
+	if len(c.Secrets.Backends) == 0 {
+		c.Secrets.Backends = append([]string(nil), d.Secrets.Backends...)
+	}
+	for i, backend := range c.Secrets.Backends {
+		c.Secrets.Backends[i] = strings.ToLower(strings.TrimSpace(backend))
+	}
+	c.Secrets.Vault.Address = strings.TrimRight(strings.TrimSpace(c.Secrets.Vault.Address), "/")
+	c.Secrets.Vault.Mount = strings.Trim(strings.TrimSpace(c.Secrets.Vault.Mount), "/")
+	if c.Secrets.Vault.Mount == "" {
+		c.Secrets.Vault.Mount = d.Secrets.Vault.Mount
+	}
+	c.Secrets.Vault.Prefix = strings.Trim(strings.TrimSpace(c.Secrets.Vault.Prefix), "/")
+	if strings.TrimSpace(c.Secrets.Vault.Field) == "" {
+		c.Secrets.Vault.Field = d.Secrets.Vault.Field
+	}
+	if strings.TrimSpace(c.Secrets.Vault.TokenEnv) == "" {
+		c.Secrets.Vault.TokenEnv = d.Secrets.Vault.TokenEnv
+	}
+	if c.Secrets.Vault.TimeoutSeconds == 0 {
+		c.Secrets.Vault.TimeoutSeconds = d.Secrets.Vault.TimeoutSeconds
+	}
+	if strings.TrimSpace(c.Secrets.Files.Dir) == "" {
+		c.Secrets.Files.Dir = d.Secrets.Files.Dir
+	}
+	if strings.TrimSpace(c.Secrets.Env.Prefix) == "" {
+		c.Secrets.Env.Prefix = d.Secrets.Env.Prefix
+	}
 	if c.Memory.MaxWorkingItems <= 0 {
 		c.Memory.MaxWorkingItems = d.Memory.MaxWorkingItems
 	}
@@ -361,6 +940,27 @@ func (c *Config) ApplyDefaults() {
 	if strings.TrimSpace(c.Memory.EmbeddingModel) == "" {
 		c.Memory.EmbeddingModel = d.Memory.EmbeddingModel
 	}
+	if c.Memory.KindPolicies == nil {
+		c.Memory.KindPolicies = d.Memory.KindPolicies
+	}
+	if c.Memory.EventRetention.MaxAgeDays <= 0 {
+		c.Memory.EventRetention.MaxAgeDays = d.Memory.EventRetention.MaxAgeDays
+	}
+	if c.Memory.EventRetention.MaxMB <= 0 {
+		c.Memory.EventRetention.MaxMB = d.Memory.EventRetention.MaxMB
+	}
+	if c.Memory.EventRetention.CompressAfterDays <= 0 {
+		c.Memory.EventRetention.CompressAfterDays = d.Memory.EventRetention.CompressAfterDays
+	}
+	if c.Memory.Housekeeping.CheckpointEventThreshold <= 0 {
+		c.Memory.Housekeeping.CheckpointEventThreshold = d.Memory.Housekeeping.CheckpointEventThreshold
+	}
+	if c.Memory.Housekeeping.CheckpointIdleMinutes <= 0 {
+		c.Memory.Housekeeping.CheckpointIdleMinutes = d.Memory.Housekeeping.CheckpointIdleMinutes
+	}
+	if c.Memory.Housekeeping.MaintenanceIntervalHours <= 0 {
+		c.Memory.Housekeeping.MaintenanceIntervalHours = d.Memory.Housekeeping.MaintenanceIntervalHours
+	}
 
 	if c.Providers.OpenAI.BaseURL == "" {
 		c.Providers.OpenAI = d.Providers.OpenAI
@@ -499,6 +1099,161 @@ func (c Config) Validate() error {
 	if c.Discord.RateLimitPerMin < 1 {
 		return errors.New("discord.rate_limit_per_min must be >= 1")
 	}
+	if c.Discord.AttachmentMaxMB < 1 || c.Discord.AttachmentMaxMB > 100 {
+		return errors.New("discord.attachment_max_mb must be in range 1..100")
+	}
+	if c.Discord.FileReplyChars < 500 {
+		return errors.New("discord.file_reply_chars must be >= 500")
+	}
+	if c.Slack.Mode != "socket" && c.Slack.Mode != "events" {
+		return errors.New("slack.mode must be socket or events")
+	}
+	if c.Slack.RateLimitPerMin < 1 {
+		return errors.New("slack.rate_limit_per_min must be >= 1")
+	}
+	if !strings.HasPrefix(c.Slack.EventsPath, "/") {
+		return errors.New("slack.events_path must start with /")
+	}
+	if c.Telegram.Mode != "polling" && c.Telegram.Mode != "webhook" {
+		return errors.New("telegram.mode must be polling or webhook")
+	}
+	if c.Telegram.RateLimitPerMin < 1 {
+		return errors.New("telegram.rate_limit_per_min must be >= 1")
+	}
+	if !strings.HasPrefix(c.Telegram.WebhookPath, "/") {
+		return errors.New("telegram.webhook_path must start with /")
+	}
+	if c.Telegram.PollTimeoutSeconds < 1 || c.Telegram.PollTimeoutSeconds > 50 {
+		return errors.New("telegram.poll_timeout_seconds must be in range 1..50")
+	}
+	if c.Email.Mode != "imap" && c.Email.Mode != "listen" {
+		return errors.New("email.mode must be imap or listen")
+	}
+	if c.Email.RateLimitPerMin < 1 {
+		return errors.New("email.rate_limit_per_min must be >= 1")
+	}
+	if c.Email.AttachmentMaxMB < 1 || c.Email.AttachmentMaxMB > 100 {
+		return errors.New("email.attachment_max_mb must be in range 1..100")
+	}
+	if c.Email.IMAP.PollSeconds < 1 {
+		return errors.New("email.imap.poll_seconds must be >= 1")
+	}
+	if c.Email.Listen.MaxSizeMB < 1 || c.Email.Listen.MaxSizeMB > 100 {
+		return errors.New("email.listen.max_size_mb must be in range 1..100")
+	}
+	if c.Email.Enabled {
+		if strings.TrimSpace(c.Email.FromAddress) == "" {
+			return errors.New("email.from_address is required when email is enabled")
+		}
+		if strings.TrimSpace(c.Email.SMTP.Addr) == "" {
+			return errors.New("email.smtp.addr is required when email is enabled")
+		}
+		if c.Email.Mode == "imap" && strings.TrimSpace(c.Email.IMAP.Addr) == "" {
+			return errors.New("email.imap.addr is required in imap mode")
+		}
+		// From is trivially forged, so senders are only trusted on the word of
+		// the receiving MTA; it must be the only way mail reaches the listener.
+		if strings.TrimSpace(c.Email.AuthservID) == "" {
+			return errors.New("email.authserv_id is required when email is enabled")
+		}
+		if c.Email.Mode == "listen" && !loopbackAddr(c.Email.Listen.Addr) {
+			return errors.New("email.listen.addr must be a loopback address")
+		}
+	}
+	if c.MCP.TimeoutSeconds < 1 || c.MCP.TimeoutSeconds > 600 {
+		return errors.New("mcp.timeout_seconds must be in range 1..600")
+	}
+	if err := validateAgentID(c.MCP.Serve.AgentID); err != nil {
+		return fmt.Errorf("mcp.serve.agent_id: %w", err)
+	}
+	for _, tool := range c.MCP.Serve.Tools {
+		if strings.TrimSpace(tool) == "" {
+			return errors.New("mcp.serve.tools cannot contain empty entries")
+		}
+	}
+	mcpNames := make(map[string]bool, len(c.MCP.Servers))
+	for _, server := range c.MCP.Servers {
+		if !isValidMCPServerName(server.Name) {
+			return fmt.Errorf("invalid mcp server name: %q", server.Name)
+		}
+		if mcpNames[server.Name] {
+			return fmt.Errorf("duplicate mcp server name: %q", server.Name)
+		}
+		mcpNames[server.Name] = true
+		switch server.Transport {
+		case "stdio":
+			if strings.TrimSpace(server.Command) == "" {
+				return fmt.Errorf("mcp.servers.%s.command is required for stdio transport", server.Name)
+			}
+		case "http":
+			if !strings.HasPrefix(server.URL, "http://") && !strings.HasPrefix(server.URL, "https://") {
+				return fmt.Errorf("mcp.servers.%s.url must be an http(s) url", server.Name)
+			}
+		default:
+			return fmt.Errorf("mcp.servers.%s.transport must be stdio or http", server.Name)
+		}
+	}
+	if c.Approvals.TimeoutSeconds < 10 || c.Approvals.TimeoutSeconds > 86400 {
+		return errors.New("approvals.timeout_seconds must be in range 10..86400")
+	}
+	if c.Approvals.TimeoutDecision != "deny" && c.Approvals.TimeoutDecision != "allow" {
+		return errors.New("approvals.timeout_decision must be deny or allow")
+	}
+	for _, tool := range c.Approvals.Tools {
+		if strings.TrimSpace(tool) == "" {
+			return errors.New("approvals.tools cannot contain empty entries")
+		}
+	}
+	if err := validateTaint("taint", c.Taint.Action, c.Taint.UntrustedTools, c.Taint.UntrustedPaths, c.Taint.HighRiskTools); err != nil {
+		return err
+	}
+	if err := c.validateTaintApprovals("taint", c.Taint); err != nil {
+		return err
+	}
+	for id, profile := range c.Agents.Profiles {
+		if override := profile.Taint; override != nil {
+			if err := validateTaint("agents.profiles."+id+".taint", override.Action, override.UntrustedTools, override.UntrustedPaths, override.HighRiskTools); err != nil {
+				return err
+			}
+			if err := c.validateTaintApprovals("agents.profiles."+id+".taint", c.TaintFor(id)); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Audit.MaxFileBytes != 0 && c.Audit.MaxFileBytes < 64*1024 {
+		return errors.New("audit.max_file_bytes must be 0 (no rotation) or at least 65536")
+	}
+	if c.Audit.CheckpointEvery < 1 || c.Audit.CheckpointEvery > 100000 {
+		return errors.New("audit.checkpoint_every must be in range 1..100000")
+	}
+	if c.Audit.MaxFileAgeHours < 0 || c.Audit.RetainFiles < 0 || c.Audit.RetainDays < 0 {
+		return errors.New("audit.max_file_age_hours, audit.retain_files and audit.retain_days cannot be negative")
+	}
+	if syslog := c.Audit.Export.Syslog; syslog.Enabled {
+		if syslog.Network != "udp" && syslog.Network != "tcp" {
+			return errors.New("audit.export.syslog.network must be udp or tcp")
+		}
+		if _, _, err := net.SplitHostPort(syslog.Address); err != nil {
+			return fmt.Errorf("audit.export.syslog.address must be host:port: %w", err)
+		}
+		if syslog.Facility < 0 || syslog.Facility > 23 {
+			return errors.New("audit.export.syslog.facility must be in range 0..23")
+		}
+	}
+	if export := c.Audit.Export.HTTP; export.Enabled {
+		if !strings.HasPrefix(export.URL, "http://") && !strings.HasPrefix(export.URL, "https://") {
+			return errors.New("audit.export.http.url must be an http(s) url")
+		}
+		if export.BatchSize < 1 || export.BatchSize > 10000 {
+			return errors.New("audit.export.http.batch_size must be in range 1..10000")
+		}
+		if export.FlushSeconds < 1 || export.FlushSeconds > 3600 {
+			return errors.New("audit.export.http.flush_seconds must be in range 1..3600")
+		}
+		if export.TimeoutSeconds < 1 || export.TimeoutSeconds > 300 {
+			return errors.New("audit.export.http.timeout_seconds must be in range 1..300")
+		}
+	}
 	if c.Server.TLSEnabled {
 		if strings.TrimSpace(c.Server.TLSCertFile) == "" || strings.TrimSpace(c.Server.TLSKeyFile) == "" {
 			return errors.New("tls requires server.tls_cert_file and server.tls_key_file")
@@ -507,6 +1262,37 @@ func (c Config) Validate() error {
 	if strings.TrimSpace(c.Secrets.StoreFile) == "" || strings.TrimSpace(c.Secrets.MasterKeyFile) == "" {
 		return errors.New("secrets.store_file and secrets.master_key_file are required")
 	}
+	if len(c.Secrets.Backends) == 0 {
+		return errors.New("secrets.backends must list at least one backend")
+	}
+	seenBackends := map[string]bool{}
+	for _, backend := range c.Secrets.Backends {
+		switch backend {
+		case SecretsBackendStore, SecretsBackendFiles, SecretsBackendEnv:
+		case SecretsBackendVault:
+			vault := c.Secrets.Vault
+			if !strings.HasPrefix(vault.Address, "http://") && !strings.HasPrefix(vault.Address, "https://") {
+				return errors.New("secrets.vault.address must be an http(s) url when the vault backend is enabled")
+			}
+			if vault.TimeoutSeconds < 1 || vault.TimeoutSeconds > 300 {
+				return errors.New("secrets.vault.timeout_seconds must be in range 1..300")
+			}
+		default:
+			return fmt.Errorf("secrets.backends: unknown backend %q (want store, vault, files or env)", backend)
+		}
+		if seenBackends[backend] {
+			return fmt.Errorf("secrets.backends lists %q twice", backend)
+		}
+		seenBackends[backend] = true
+	}
+	if (len(c.Secrets.KeyWrap.WrapCommand) == 0) != (len(c.Secrets.KeyWrap.UnwrapCommand) == 0) {
+		return errors.New("secrets.key_wrap.wrap_command and unwrap_command must be set together")
+	}
+	for _, argv := range [][]string{c.Secrets.KeyWrap.WrapCommand, c.Secrets.KeyWrap.UnwrapCommand} {
+		if len(argv) > 0 && strings.TrimSpace(argv[0]) == "" {
+			return errors.New("secrets.key_wrap commands must start with a program name")
+		}
+	}
 	if c.Memory.MaxWorkingItems < 1 || c.Memory.MaxWorkingItems > 100000 {
 		return errors.New("memory.max_working_items must be between 1 and 100000")
 	}
@@ -516,6 +1302,24 @@ func (c Config) Validate() error {
 	if c.Memory.EventBufferSize < 1 || c.Memory.EventBufferSize > 10000 {
 		return errors.New("memory.event_buffer_size must be between 1 and 10000")
 	}
+	if c.Memory.EventRetention.MaxAgeDays < 1 || c.Memory.EventRetention.MaxAgeDays > 3650 {
+		return errors.New("memory.event_retention.max_age_days must be between 1 and 3650")
+	}
+	if c.Memory.EventRetention.MaxMB < 1 || c.Memory.EventRetention.MaxMB > 102400 {
+		return errors.New("memory.event_retention.max_mb must be between 1 and 102400")
+	}
+	if c.Memory.EventRetention.CompressAfterDays < 1 || c.Memory.EventRetention.CompressAfterDays > 3650 {
+		return errors.New("memory.event_retention.compress_after_days must be between 1 and 3650")
+	}
+	if c.Memory.Housekeeping.CheckpointEventThreshold < 1 || c.Memory.Housekeeping.CheckpointEventThreshold > 10000 {
+		return errors.New("memory.housekeeping.checkpoint_event_threshold must be between 1 and 10000")
+	}
+	if c.Memory.Housekeeping.CheckpointIdleMinutes < 1 || c.Memory.Housekeeping.CheckpointIdleMinutes > 10080 {
+		return errors.New("memory.housekeeping.checkpoint_idle_minutes must be between 1 and 10080")
+	}
+	if c.Memory.Housekeeping.MaintenanceIntervalHours < 1 || c.Memory.Housekeeping.MaintenanceIntervalHours > 8760 {
+		return errors.New("memory.housekeeping.maintenance_interval_hours must be between 1 and 8760")
+	}
 	embeddingProvider := strings.ToLower(strings.TrimSpace(c.Memory.EmbeddingProvider))
 	supportedEmbeddingProviders := map[string]bool{"openai": true, "openrouter": true, "requesty": true, "zai": true, "generic": true}
 	if !supportedEmbeddingProviders[embeddingProvider] {
@@ -524,10 +1328,36 @@ func (c Config) Validate() error {
 	if strings.TrimSpace(c.Memory.EmbeddingModel) == "" {
 		return errors.New("memory.embedding_model is required")
 	}
+	for kind, kindPolicy := range c.Memory.KindPolicies {
+		if strings.TrimSpace(kind) == "" {
+			return errors.New("memory.kind_policies cannot contain empty kinds")
+		}
+		if kindPolicy.ArchiveAfterDays < 0 || kindPolicy.ArchiveAfterDays > 3650 {
+			return fmt.Errorf("memory.kind_policies.%s.archive_after_days must be between 0 and 3650", kind)
+		}
+		if kindPolicy.HalfLifeDays < 0 || kindPolicy.HalfLifeDays > 3650 {
+			return fmt.Errorf("memory.kind_policies.%s.half_life_days must be between 0 and 3650", kind)
+		}
+		if kindPolicy.NeverExpire && kindPolicy.ArchiveAfterDays > 0 {
+			return fmt.Errorf("memory.kind_policies.%s cannot set archive_after_days with never_expire", kind)
+		}
+	}
 
 	return nil
 }
 
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func validateAgentID(raw string) error {
 	agentID := strings.TrimSpace(raw)
 	if agentID == "" {
@@ -539,6 +1369,20 @@ func validateAgentID(raw string) error {
 	return nil
 }
 
+// isValidMCPServerName keeps server names usable as the middle segment of
+// mcp.<server>.<tool> tool names.
+func isValidMCPServerName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
 func (c Config) Redacted() Config {
 	redacted := c
 	redacted.Providers.OpenAI.APIKey = ""
@@ -547,9 +1391,61 @@ func (c Config) Redacted() Config {
 	redacted.Providers.ZAI.APIKey = ""
 	redacted.Providers.Generic.APIKey = ""
 	redacted.Discord.Token = ""
+	redacted.Slack.BotToken = ""
+	redacted.Slack.AppToken = ""
+	redacted.Slack.SigningSecret = ""
+	redacted.Telegram.Token = ""
+	redacted.Telegram.WebhookSecret = ""
+	redacted.Email.IMAP.Password = ""
+	redacted.Email.SMTP.Password = ""
+	if len(c.MCP.Servers) > 0 {
+		redacted.MCP.Servers = make([]MCPServerConfig, len(c.MCP.Servers))
+		for i, server := range c.MCP.Servers {
+			server.Env = redactedValues(server.Env)
+			server.Headers = redactedValues(server.Headers)
+			redacted.MCP.Servers[i] = server
+		}
+	}
+	redacted.Audit.Export.HTTP.Headers = redactedValues(c.Audit.Export.HTTP.Headers)
 	return redacted
 }
 
+// redactedValues keeps the keys of env and header maps, which are useful for
+// debugging, and blanks the values that commonly carry credentials.
+func redactedValues(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k := range in {
+		out[k] = ""
+	}
+	return out
+}
+
+func validateTaint(prefix, action string, lists ...[]string) error {
+	if action != "" && action != TaintActionApprove && action != TaintActionDeny {
+		return fmt.Errorf("%s.action must be approve or deny", prefix)
+	}
+	for _, list := range lists {
+		for _, item := range list {
+			if strings.TrimSpace(item) == "" {
+				return fmt.Errorf("%s lists cannot contain empty entries", prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTaintApprovals rejects an approve action that could never be
+// granted: without approvals every gated call would be denied.
+func (c Config) validateTaintApprovals(prefix string, taint TaintConfig) error {
+	if taint.Enabled && taint.Action == TaintActionApprove && !c.Approvals.Enabled {
+		return fmt.Errorf("%s.action=approve requires approvals.enabled=true; enable approvals or use action deny", prefix)
+	}
+	return nil
+}
+
 func LoadOrDefault(path string) (Config, error) {
 	if _, err := os.Stat(path); err != nil {
 		if os.IsNotExist(err) {
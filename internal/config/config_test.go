@@ -139,6 +139,246 @@ func TestValidateRejectsInvalidMaxThinkingChars(t *testing.T) {
 	}
 }
 
+func TestValidateRequiresEmailAddressesWhenEnabled(t *testing.T) {
+	cfg := Default()
+	cfg.Email.Enabled = true
+	cfg.Email.SMTP.Addr = "smtp.example.com:587"
+	cfg.Email.IMAP.Addr = "imap.example.com:993"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "email.from_address") {
+		t.Fatalf("expected from_address validation error, got %v", err)
+	}
+	cfg.Email.FromAddress = "agent@example.com"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "email.authserv_id") {
+		t.Fatalf("expected authserv_id validation error, got %v", err)
+	}
+	cfg.Email.AuthservID = "mx.example.com"
+	cfg.Email.Mode = "listen"
+	cfg.Email.IMAP.Addr = ""
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected listen mode without imap addr to validate, got %v", err)
+	}
+	cfg.Email.Listen.Addr = "0.0.0.0:2525"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "loopback") {
+		t.Fatalf("expected non-loopback listener to be rejected, got %v", err)
+	}
+}
+
+func TestValidateMCPServers(t *testing.T) {
+	cfg := Default()
+	cfg.MCP.Servers = []MCPServerConfig{
+		{Name: "files", Transport: "stdio", Command: "mcp-files"},
+		{Name: "search", Transport: "http", URL: "https://mcp.example.com/mcp"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid mcp servers, got %v", err)
+	}
+
+	for _, server := range []MCPServerConfig{
+		{Name: "Bad.Name", Transport: "stdio", Command: "x"},
+		{Name: "files", Transport: "stdio"},
+		{Name: "web", Transport: "http", URL: "ftp://example.com"},
+		{Name: "ws", Transport: "websocket", URL: "https://example.com"},
+	} {
+		cfg := Default()
+		cfg.MCP.Servers = []MCPServerConfig{server}
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("expected validation error for %+v", server)
+		}
+	}
+
+	cfg = Default()
+	cfg.MCP.Servers = []MCPServerConfig{{Name: "a", Command: "x"}, {Name: "a", Command: "y"}}
+	cfg.ApplyDefaults()
+	if cfg.MCP.Servers[0].Transport != "stdio" {
+		t.Fatalf("expected stdio default transport, got %q", cfg.MCP.Servers[0].Transport)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected duplicate server error, got %v", err)
+	}
+
+	cfg = Default()
+	cfg.MCP.Serve.AgentID = "../etc"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected invalid mcp.serve.agent_id to be rejected")
+	}
+	cfg = Config{}
+	cfg.ApplyDefaults()
+	if cfg.MCP.Serve.AgentID != "default" || len(cfg.MCP.Serve.Tools) == 0 {
+		t.Fatalf("expected mcp serve defaults, got %+v", cfg.MCP.Serve)
+	}
+}
+
+func TestApprovalsDefaultsAndValidation(t *testing.T) {
+	cfg := Config{}
+	cfg.ApplyDefaults()
+	if cfg.Approvals.TimeoutSeconds != 300 || cfg.Approvals.TimeoutDecision != "deny" || len(cfg.Approvals.Tools) != 3 {
+		t.Fatalf("expected approvals defaults, got %+v", cfg.Approvals)
+	}
+
+	cfg = Default()
+	cfg.Approvals.TimeoutDecision = "Allow"
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected allow timeout decision to validate, got %v", err)
+	}
+	cfg.Approvals.TimeoutDecision = "maybe"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected invalid approvals.timeout_decision to be rejected")
+	}
+
+	cfg = Default()
+	cfg.Approvals.TimeoutSeconds = 5
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected short approvals.timeout_seconds to be rejected")
+	}
+}
+
+func TestAuditDefaultsAndValidation(t *testing.T) {
+	cfg := Config{}
+	cfg.ApplyDefaults()
+	if cfg.Audit.CheckpointEvery != 100 || cfg.Audit.SigningKeyFile == "" || cfg.Audit.Checkpoints {
+		t.Fatalf("expected audit defaults, got %+v", cfg.Audit)
+	}
+
+	cfg = Default()
+	cfg.Audit.MaxFileBytes = 1024
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected tiny audit.max_file_bytes to be rejected")
+	}
+	cfg.Audit.MaxFileBytes = 1 << 20
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected 1MiB rotation to validate, got %v", err)
+	}
+}
+
+func TestAuditExportValidationAndRedaction(t *testing.T) {
+	cfg := Config{}
+	cfg.ApplyDefaults()
+	if cfg.Audit.Export.Syslog.Network != "udp" || cfg.Audit.Export.Syslog.Facility != 13 || cfg.Audit.Export.HTTP.BatchSize != 100 {
+		t.Fatalf("expected audit export defaults, got %+v", cfg.Audit.Export)
+	}
+
+	cfg = Default()
+	cfg.Audit.Export.Syslog.Enabled = true
+	cfg.Audit.Export.Syslog.Address = "siem.internal"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected syslog address without port to be rejected")
+	}
+	cfg.Audit.Export.Syslog.Address = "siem.internal:514"
+	cfg.Audit.Export.Syslog.Network = "tls"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown syslog network to be rejected")
+	}
+	cfg.Audit.Export.Syslog.Network = "tcp"
+	cfg.Audit.Export.HTTP.Enabled = true
+	cfg.Audit.Export.HTTP.URL = "siem.internal/ingest"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected non-http export url to be rejected")
+	}
+	cfg.Audit.Export.HTTP.URL = "https://siem.internal/ingest"
+	cfg.Audit.Export.HTTP.Headers = map[string]string{"Authorization": "Bearer secret"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected export config to validate, got %v", err)
+	}
+	if got := cfg.Redacted().Audit.Export.HTTP.Headers["Authorization"]; got != "" {
+		t.Fatalf("expected export header to be redacted, got %q", got)
+	}
+}
+
+func TestSecretsBackendsDefaultsAndValidation(t *testing.T) {
+	cfg := Config{}
+	cfg.ApplyDefaults()
+	if len(cfg.Secrets.Backends) != 1 || cfg.Secrets.Backends[0] != SecretsBackendStore || cfg.Secrets.Vault.Mount != "secret" || cfg.Secrets.Env.Prefix == "" {
+		t.Fatalf("expected secrets backend defaults, got %+v", cfg.Secrets)
+	}
+
+	cfg = Default()
+	cfg.Secrets.Backends = []string{"env", "keychain"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected unknown backend to be rejected")
+	}
+	cfg.Secrets.Backends = []string{"env", "env"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected duplicate backend to be rejected")
+	}
+	cfg.Secrets.Backends = []string{" Vault ", "store"}
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected vault without address to be rejected")
+	}
+	cfg.Secrets.Vault.Address = "https://vault.internal:8200/"
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected vault backend to validate, got %v", err)
+	}
+	if cfg.Secrets.Backends[0] != "vault" || cfg.Secrets.Vault.Address != "https://vault.internal:8200" {
+		t.Fatalf("expected normalized backend config, got %+v", cfg.Secrets)
+	}
+}
+
+func TestTaintDefaultsAndAgentOverride(t *testing.T) {
+	cfg := Default()
+	taint := cfg.TaintFor("default")
+	if taint.Enabled || taint.Action != TaintActionApprove || len(taint.HighRiskTools) == 0 || len(taint.UntrustedTools) == 0 {
+		t.Fatalf("unexpected taint defaults: %+v", taint)
+	}
+
+	cfg.Taint.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "requires approvals.enabled") {
+		t.Fatalf("expected approve without approvals to be rejected, got %v", err)
+	}
+	cfg.Approvals.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected approve with approvals to validate, got %v", err)
+	}
+	cfg.Approvals.Enabled = false
+	cfg.Taint.Enabled = false
+	on, off := true, false
+	cfg.Agents.Profiles["research"] = AgentProfile{Taint: &AgentTaintOverride{Enabled: &on, Action: " DENY ", UntrustedPaths: []string{"downloads/**"}}}
+	cfg.Agents.Profiles["trusted"] = AgentProfile{Taint: &AgentTaintOverride{Enabled: &off}}
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected overrides to validate, got %v", err)
+	}
+	research := cfg.TaintFor("research")
+	if !research.Enabled || research.Action != TaintActionDeny || len(research.UntrustedPaths) != 1 || len(research.HighRiskTools) != len(cfg.Taint.HighRiskTools) {
+		t.Fatalf("unexpected research taint: %+v", research)
+	}
+	if cfg.TaintFor("trusted").Enabled {
+		t.Fatal("expected trusted agent to disable taint tracking")
+	}
+
+	cfg.Agents.Profiles["research"] = AgentProfile{Taint: &AgentTaintOverride{Action: "warn"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected invalid override action to be rejected")
+	}
+	cfg.Agents.Profiles["research"] = AgentProfile{Taint: &AgentTaintOverride{Enabled: &on}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agents.profiles.research.taint") {
+		t.Fatalf("expected override approve without approvals to be rejected, got %v", err)
+	}
+	cfg = Default()
+	cfg.Taint.HighRiskTools = []string{"shell.exec", " "}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected empty high_risk_tools entry to be rejected")
+	}
+}
+
+func TestSecretsKeyWrapValidation(t *testing.T) {
+	cfg := Default()
+	cfg.Secrets.KeyWrap.WrapCommand = []string{"systemd-creds", "encrypt", "-", "-"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected wrap_command without unwrap_command to be rejected")
+	}
+	cfg.Secrets.KeyWrap.UnwrapCommand = []string{" "}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected empty unwrap program to be rejected")
+	}
+	cfg.Secrets.KeyWrap.UnwrapCommand = []string{"systemd-creds", "decrypt", "-", "-"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected key_wrap commands to validate, got %v", err)
+	}
+}
+
 func TestValidateRejectsEmptyShellAllowedCommand(t *testing.T) {
 	cfg := Default()
 	cfg.Shell.AllowedCommands = []string{"git", "   "}
@@ -176,6 +416,14 @@ func TestRedactedClearsSensitiveFieldsOnly(t *testing.T) {
 	cfg.Providers.ZAI.APIKey = "zai-key"
 	cfg.Providers.Generic.APIKey = "generic-key"
 	cfg.Discord.Token = "discord-token"
+	cfg.Slack.BotToken = "xoxb-token"
+	cfg.Slack.AppToken = "xapp-token"
+	cfg.Slack.SigningSecret = "signing-secret"
+	cfg.Telegram.Token = "123:telegram"
+	cfg.Telegram.WebhookSecret = "hook-secret"
+	cfg.Email.IMAP.Password = "imap-pass"
+	cfg.Email.SMTP.Password = "smtp-pass"
+	cfg.MCP.Servers = []MCPServerConfig{{Name: "gh", Transport: "http", URL: "https://mcp.example.com", Headers: map[string]string{"X-Api-Key": "mcp-key"}}}
 	cfg.Model.Name = "kept-model"
 
 	redacted := cfg.Redacted()
@@ -186,6 +434,18 @@ func TestRedactedClearsSensitiveFieldsOnly(t *testing.T) {
 	if redacted.Discord.Token != "" {
 		t.Fatalf("expected discord token redacted, got %q", redacted.Discord.Token)
 	}
+	if redacted.Slack.BotToken != "" || redacted.Slack.AppToken != "" || redacted.Slack.SigningSecret != "" {
+		t.Fatalf("expected slack credentials redacted, got %+v", redacted.Slack)
+	}
+	if redacted.Telegram.Token != "" || redacted.Telegram.WebhookSecret != "" {
+		t.Fatalf("expected telegram credentials redacted, got %+v", redacted.Telegram)
+	}
+	if redacted.Email.IMAP.Password != "" || redacted.Email.SMTP.Password != "" {
+		t.Fatalf("expected email passwords redacted, got %+v", redacted.Email)
+	}
+	if got := redacted.MCP.Servers[0].Headers["X-Api-Key"]; got != "" || cfg.MCP.Servers[0].Headers["X-Api-Key"] != "mcp-key" {
+		t.Fatalf("expected mcp header value redacted without touching the original, got %q", got)
+	}
 	if redacted.Model.Name != "kept-model" {
 		t.Fatalf("expected non-sensitive model name preserved, got %q", redacted.Model.Name)
 	}
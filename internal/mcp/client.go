@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/sandbox"
+)
+
+// ProtocolVersion is the MCP revision this client negotiates.
+const ProtocolVersion = "2025-06-18"
+
+const (
+	clientName    = "openclawssy"
+	clientVersion = "0.1.0"
+
+	maxMessageBytes = 8 << 20
+)
+
+var ErrClosed = errors.New("mcp: connection closed")
+
+// message is a JSON-RPC 2.0 request, notification or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m message) isResponse() bool {
+	return len(m.ID) > 0 && m.Method == ""
+}
+
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp: rpc error %d: %s", e.Code, e.Message)
+}
+
+// transport carries JSON-RPC messages to one server. call sends a request and
+// waits for the response with the same ID.
+type transport interface {
+	call(ctx context.Context, req message) (message, error)
+	notify(ctx context.Context, msg message) error
+	close() error
+}
+
+// Tool is a tool advertised by an MCP server through tools/list.
+type Tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// Content is one item of a tools/call result.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Data     string `json:"data,omitempty"`
+	Resource *struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+		Text     string `json:"text,omitempty"`
+	} `json:"resource,omitempty"`
+}
+
+type CallResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// Client is a connection to one MCP server.
+type Client struct {
+	name       string
+	transport  transport
+	timeout    time.Duration
+	nextID     atomic.Int64
+	serverName string
+}
+
+// stdioPassthroughEnv are the only parent variables a stdio server inherits;
+// anything else, credentials included, must be listed in the server's env.
+var stdioPassthroughEnv = []string{"PATH", "HOME", "LANG", "TMPDIR"}
+
+func stdioEnv(extra map[string]string) []string {
+	env := make([]string, 0, len(stdioPassthroughEnv)+len(extra))
+	for _, key := range stdioPassthroughEnv {
+		if _, ok := extra[key]; ok {
+			continue
+		}
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	for k, v := range extra {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Dial connects to server and completes the initialize handshake. stdio
+// servers are launched through provider, so a provider that denies exec (or a
+// nil one) denies stdio servers as well.
+func Dial(ctx context.Context, server config.MCPServerConfig, provider sandbox.Provider, timeout time.Duration) (*Client, error) {
+	var t transport
+	switch server.Transport {
+	case "stdio", "":
+		proc, err := sandbox.StartProcess(provider, sandbox.Command{Name: server.Command, Args: server.Args, Env: stdioEnv(server.Env), CleanEnv: true})
+		if err != nil {
+			return nil, fmt.Errorf("mcp: start %s: %w", server.Name, err)
+		}
+		t = newStdioTransport(proc)
+	case "http":
+		headers := make(map[string]string, len(server.Headers)+1)
+		for k, v := range server.Headers {
+			headers[k] = v
+		}
+		if env := strings.TrimSpace(server.BearerTokenEnv); env != "" {
+			if token := strings.TrimSpace(os.Getenv(env)); token != "" {
+				headers["Authorization"] = "Bearer " + token
+			}
+		}
+		t = newHTTPTransport(server.URL, headers, timeout)
+	default:
+		return nil, fmt.Errorf("mcp: unsupported transport %q", server.Transport)
+	}
+
+	c := &Client{name: server.Name, transport: t, timeout: timeout}
+	if err := c.initialize(ctx); err != nil {
+		_ = t.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) Name() string { return c.name }
+
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	err := c.request(ctx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": clientName, "version": clientVersion},
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("mcp: initialize %s: %w", c.name, err)
+	}
+	if ht, ok := c.transport.(*httpTransport); ok {
+		ht.setProtocolVersion(result.ProtocolVersion)
+	}
+	c.serverName = result.ServerInfo.Name
+	notifyCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.transport.notify(notifyCtx, message{JSONRPC: "2.0", Method: "notifications/initialized"})
+}
+
+// ListTools returns every tool the server advertises, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var out []Tool
+	cursor := ""
+	for page := 0; page < 100; page++ {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var result struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.request(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("mcp: list tools on %s: %w", c.name, err)
+		}
+		out = append(out, result.Tools...)
+		if result.NextCursor == "" {
+			return out, nil
+		}
+		cursor = result.NextCursor
+	}
+	return out, nil
+}
+
+// CallTool invokes a tool by its server-side name.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (CallResult, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	var result CallResult
+	if err := c.request(ctx, "tools/call", map[string]any{"name": name, "arguments": args}, &result); err != nil {
+		return CallResult{}, err
+	}
+	return result, nil
+}
+
+func (c *Client) request(ctx context.Context, method string, params any, out any) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id := c.nextID.Add(1)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	resp, err := c.transport.call(ctx, message{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(fmt.Sprintf("%d", id)),
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if out == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("mcp: decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// sameID compares raw JSON-RPC IDs, ignoring surrounding whitespace.
+func sameID(a, b json.RawMessage) bool {
+	return strings.TrimSpace(string(a)) == strings.TrimSpace(string(b))
+}
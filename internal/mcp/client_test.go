@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/sandbox"
+	"openclawssy/internal/tools"
+)
+
+// fakeServer answers the subset of MCP a client needs. It backs both the
+// stdio helper process and the HTTP test server.
+func fakeServer(req message) (message, bool) {
+	if len(req.ID) == 0 {
+		return message{}, false
+	}
+	resp := message{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		resp.Result = json.RawMessage(`{"protocolVersion":"2025-06-18","capabilities":{"tools":{}},"serverInfo":{"name":"fake"}}`)
+	case "tools/list":
+		resp.Result = json.RawMessage(`{"tools":[
+			{"name":"Echo","description":"Echo text back.","inputSchema":{"type":"object","properties":{"text":{"type":"string"},"times":{"type":"integer"},"note":{"type":["string","null"]}},"required":["text"]}},
+			{"name":"fail","description":"Always fails.","inputSchema":{"type":"object"}}
+		]}`)
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		if params.Name == "fail" {
+			resp.Result = json.RawMessage(`{"content":[{"type":"text","text":"boom"}],"isError":true}`)
+			break
+		}
+		raw, _ := json.Marshal(map[string]any{
+			"content":           []map[string]any{{"type": "text", "text": params.Arguments["text"]}},
+			"structuredContent": map[string]any{"echoed": params.Arguments["text"]},
+		})
+		resp.Result = raw
+	default:
+		resp.Error = &RPCError{Code: -32601, Message: "method not found"}
+	}
+	return resp, true
+}
+
+// TestHelperStdioServer is not a real test; it is re-executed as a stdio MCP
+// server by TestStdioClientMountsAndCallsTools.
+func TestHelperStdioServer(t *testing.T) {
+	if os.Getenv("OPENCLAWSSY_MCP_HELPER") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req message
+		if json.Unmarshal(scanner.Bytes(), &req) != nil {
+			continue
+		}
+		if resp, ok := fakeServer(req); ok {
+			raw, _ := json.Marshal(resp)
+			fmt.Fprintf(os.Stdout, "%s\n", raw)
+		}
+	}
+	os.Exit(0)
+}
+
+type recordingAuditor struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (a *recordingAuditor) LogEvent(_ context.Context, eventType string, _ map[string]any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, eventType)
+	return nil
+}
+
+func mountIntoRegistry(t *testing.T, client *Client, grants []string) (*tools.Registry, []MountedTool, *recordingAuditor) {
+	t.Helper()
+	mounted, err := Mount(context.Background(), client)
+	if err != nil {
+		t.Fatalf("mount: %v", err)
+	}
+	aud := &recordingAuditor{}
+	reg := tools.NewRegistry(policy.NewEnforcer(t.TempDir(), map[string][]string{"agent": grants}), aud)
+	if err := Register(reg, mounted); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return reg, mounted, aud
+}
+
+func TestStdioClientMountsAndCallsTools(t *testing.T) {
+	provider, err := sandbox.NewLocalProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if err := provider.Start(context.Background()); err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	defer provider.Stop()
+
+	server := config.MCPServerConfig{
+		Name:      "local",
+		Transport: "stdio",
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=TestHelperStdioServer"},
+		Env:       map[string]string{"OPENCLAWSSY_MCP_HELPER": "1"},
+	}
+	client, err := Dial(context.Background(), server, provider, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	reg, mounted, aud := mountIntoRegistry(t, client, []string{"mcp.local.echo"})
+	if len(mounted) != 2 || mounted[0].Spec.Name != "mcp.local.echo" || mounted[0].Remote != "Echo" {
+		t.Fatalf("unexpected mounted tools: %+v", mounted)
+	}
+	spec := mounted[0].Spec
+	if spec.ArgTypes["text"] != tools.ArgTypeString || spec.ArgTypes["times"] != tools.ArgTypeNumber {
+		t.Fatalf("unexpected arg types: %+v", spec.ArgTypes)
+	}
+	if _, ok := spec.ArgTypes["note"]; ok || len(spec.Required) != 1 || spec.Required[0] != "text" {
+		t.Fatalf("unexpected schema mapping: %+v", spec)
+	}
+
+	res, err := reg.Execute(context.Background(), "agent", "mcp.local.echo", "", map[string]any{"text": "hi"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if res["content"] != "hi" || res["tool"] != "Echo" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if structured, _ := res["structured_content"].(map[string]any); structured["echoed"] != "hi" {
+		t.Fatalf("expected structured content, got %+v", res)
+	}
+
+	if _, err := reg.Execute(context.Background(), "agent", "mcp.local.echo", "", map[string]any{"text": 3}); err == nil {
+		t.Fatal("expected schema type mismatch to be rejected")
+	}
+	_, err = reg.Execute(context.Background(), "agent", "mcp.local.fail", "", nil)
+	var toolErr *tools.ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != tools.ErrCodePolicyDenied {
+		t.Fatalf("expected ungranted mcp tool to be denied by policy, got %v", err)
+	}
+	if got := strings.Join(aud.events, ","); !strings.Contains(got, "tool.call,tool.result") || !strings.Contains(got, "policy.denied") {
+		t.Fatalf("expected registry audit events, got %s", got)
+	}
+}
+
+func TestStdioClientDeniedWithoutExecProvider(t *testing.T) {
+	none := &sandbox.NoneProvider{}
+	_ = none.Start(context.Background())
+	_, err := Dial(context.Background(), config.MCPServerConfig{Name: "local", Transport: "stdio", Command: "true"}, none, time.Second)
+	if !errors.Is(err, sandbox.ErrExecDenied) {
+		t.Fatalf("expected exec denied, got %v", err)
+	}
+}
+
+func TestStdioEnvKeepsOnlyMinimalParentVariables(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("HOME", "/home/agent")
+	t.Setenv("OPENAI_API_KEY", "sk-parent")
+	env := strings.Join(stdioEnv(map[string]string{"HOME": "/srv/mcp", "TOKEN": "abc"}), ",")
+	if strings.Contains(env, "OPENAI_API_KEY") {
+		t.Fatalf("expected parent secrets to be dropped, got %s", env)
+	}
+	if !strings.Contains(env, "PATH=/usr/bin") || !strings.Contains(env, "TOKEN=abc") {
+		t.Fatalf("expected PATH and server env, got %s", env)
+	}
+	if strings.Contains(env, "HOME=/home/agent") || !strings.Contains(env, "HOME=/srv/mcp") {
+		t.Fatalf("expected server env to override passthrough, got %s", env)
+	}
+}
+
+func TestHTTPClientUsesSessionAndEventStream(t *testing.T) {
+	t.Setenv("FAKE_MCP_TOKEN", "tok")
+	var mu sync.Mutex
+	var deleted bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			deleted = r.Header.Get(sessionHeader) == "sess-1"
+			mu.Unlock()
+			return
+		}
+		var req message
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if req.Method != "initialize" && (r.Header.Get(sessionHeader) != "sess-1" || r.Header.Get(protocolVersionHeader) != ProtocolVersion) {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		resp, ok := fakeServer(req)
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		raw, _ := json.Marshal(resp)
+		switch req.Method {
+		case "initialize":
+			w.Header().Set(sessionHeader, "sess-1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(raw)
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := Dial(context.Background(), config.MCPServerConfig{Name: "remote", Transport: "http", URL: srv.URL, BearerTokenEnv: "FAKE_MCP_TOKEN"}, nil, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	reg, mounted, _ := mountIntoRegistry(t, client, []string{"mcp.remote.echo", "mcp.remote.fail"})
+	if len(mounted) != 2 {
+		t.Fatalf("expected two tools, got %+v", mounted)
+	}
+	res, err := reg.Execute(context.Background(), "agent", "mcp.remote.echo", "", map[string]any{"text": "over http"})
+	if err != nil || res["content"] != "over http" {
+		t.Fatalf("unexpected result %+v err=%v", res, err)
+	}
+	if _, err := reg.Execute(context.Background(), "agent", "mcp.remote.fail", "", nil); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected tool error to surface, got %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !deleted {
+		t.Fatal("expected session to be deleted on close")
+	}
+}
+
+func TestPromptDocListsMountedTools(t *testing.T) {
+	doc := PromptDoc([]MountedTool{{Spec: tools.ToolSpec{
+		Name:        "mcp.gh.search",
+		Description: "Search\n issues.",
+		Required:    []string{"query"},
+		ArgTypes:    map[string]tools.ArgType{"query": tools.ArgTypeString, "limit": tools.ArgTypeNumber},
+	}}})
+	want := "- `mcp.gh.search`: Search issues. Args: `query` (string, required), `limit` (number)\n"
+	if !strings.Contains(doc, want) {
+		t.Fatalf("expected %q in doc:\n%s", want, doc)
+	}
+	if PromptDoc(nil) != "" {
+		t.Fatal("expected empty doc without tools")
+	}
+	if got := ToolName("gh", "Search Issues!"); got != "mcp.gh.search_issues_" {
+		t.Fatalf("unexpected tool name: %q", got)
+	}
+}
@@ -0,0 +1,185 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionHeader         = "Mcp-Session-Id"
+	protocolVersionHeader = "MCP-Protocol-Version"
+)
+
+// httpTransport implements the streamable HTTP transport: every message is a
+// POST and the server answers with either a JSON body or an SSE stream that
+// eventually carries the response.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+}
+
+func newHTTPTransport(url string, headers map[string]string, timeout time.Duration) *httpTransport {
+	return &httpTransport{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (t *httpTransport) setProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = version
+}
+
+func (t *httpTransport) call(ctx context.Context, req message) (message, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return message{}, err
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, maxMessageBytes)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readSSEResponse(body, req.ID)
+	}
+	var msg message
+	if err := json.NewDecoder(body).Decode(&msg); err != nil {
+		return message{}, fmt.Errorf("mcp: decode response: %w", err)
+	}
+	if !msg.isResponse() || !sameID(msg.ID, req.ID) {
+		return message{}, fmt.Errorf("mcp: unexpected response id %s", msg.ID)
+	}
+	return msg, nil
+}
+
+func (t *httpTransport) notify(ctx context.Context, msg message) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxMessageBytes))
+	return resp.Body.Close()
+}
+
+// close ends the server-side session, if the server issued one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (t *httpTransport) post(ctx context.Context, msg message) (*http.Response, error) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: post %s: %w", msg.Method, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("mcp: %s returned http %d: %s", msg.Method, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	if sessionID := strings.TrimSpace(resp.Header.Get(sessionHeader)); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(sessionHeader, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(protocolVersionHeader, t.protocolVersion)
+	}
+}
+
+// readSSEResponse scans an event stream for the response to id, skipping
+// notifications and other messages the server interleaves before it.
+func readSSEResponse(body io.Reader, id json.RawMessage) (message, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	var data strings.Builder
+	flush := func() (message, bool) {
+		defer data.Reset()
+		if data.Len() == 0 {
+			return message{}, false
+		}
+		var msg message
+		if err := json.Unmarshal([]byte(data.String()), &msg); err != nil {
+			return message{}, false
+		}
+		return msg, msg.isResponse() && sameID(msg.ID, id)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if msg, ok := flush(); ok {
+				return msg, nil
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+		}
+	}
+	if msg, ok := flush(); ok {
+		return msg, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return message{}, fmt.Errorf("mcp: read event stream: %w", err)
+	}
+	return message{}, fmt.Errorf("mcp: event stream ended without response")
+}
@@ -0,0 +1,271 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"openclawssy/internal/tools"
+)
+
+// ErrUnknownTool is returned by a Backend for tools it does not expose.
+var ErrUnknownTool = errors.New("mcp: unknown tool")
+
+// Backend supplies the tools a Server exposes. CallTool errors other than
+// ErrUnknownTool are reported to the host as tool errors, not protocol errors.
+type Backend interface {
+	Tools() []tools.ToolSpec
+	CallTool(ctx context.Context, name string, args map[string]any) (map[string]any, error)
+}
+
+// Server answers MCP requests from hosts over stdio or streamable HTTP.
+type Server struct {
+	backend Backend
+	name    string
+	version string
+
+	mu       sync.Mutex
+	sessions map[string]struct{}
+}
+
+func NewServer(backend Backend, name, version string) *Server {
+	return &Server{backend: backend, name: name, version: version, sessions: make(map[string]struct{})}
+}
+
+// ServeStdio reads newline-delimited requests from r until EOF and writes
+// responses to w. Requests are handled one at a time, in order.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := readLine(reader)
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			resp, ok := s.handleRaw(ctx, []byte(trimmed))
+			if ok {
+				raw, mErr := json.Marshal(resp)
+				if mErr != nil {
+					return mErr
+				}
+				if _, wErr := w.Write(append(raw, '\n')); wErr != nil {
+					return wErr
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// Handler serves the streamable HTTP transport on a single endpoint. Every
+// request must carry token as a bearer token; responses are plain JSON, which
+// the transport allows in place of an event stream.
+func (s *Server) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bearerMatches(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		sessionID := strings.TrimSpace(r.Header.Get(sessionHeader))
+		switch r.Method {
+		case http.MethodPost:
+		case http.MethodDelete:
+			if !s.endSession(sessionID) {
+				http.Error(w, "unknown session", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		default:
+			w.Header().Set("Allow", "POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		raw, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+		if err != nil {
+			http.Error(w, "read body", http.StatusBadRequest)
+			return
+		}
+		var probe message
+		_ = json.Unmarshal(raw, &probe)
+		if probe.Method == "initialize" {
+			sessionID, err = s.newSession()
+			if err != nil {
+				http.Error(w, "create session", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set(sessionHeader, sessionID)
+		} else if !s.hasSession(sessionID) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+
+		resp, ok := s.handleRaw(r.Context(), raw)
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+func (s *Server) handleRaw(ctx context.Context, raw []byte) (message, bool) {
+	var req message
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: -32700, Message: "parse error"}}, true
+	}
+	return s.handle(ctx, req)
+}
+
+// handle answers one request. Notifications get no reply.
+func (s *Server) handle(ctx context.Context, req message) (message, bool) {
+	if len(req.ID) == 0 {
+		return message{}, false
+	}
+	resp := message{JSONRPC: "2.0", ID: req.ID}
+	var result any
+	var rpcErr *RPCError
+	switch req.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+			"serverInfo":      map[string]any{"name": s.name, "version": s.version},
+		}
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		result = map[string]any{"tools": s.listTools()}
+	case "tools/call":
+		result, rpcErr = s.callTool(ctx, req.Params)
+	default:
+		rpcErr = &RPCError{Code: -32601, Message: "method not found: " + req.Method}
+	}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp, true
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &RPCError{Code: -32603, Message: err.Error()}
+		return resp, true
+	}
+	resp.Result = raw
+	return resp, true
+}
+
+func (s *Server) listTools() []map[string]any {
+	specs := s.backend.Tools()
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	out := make([]map[string]any, 0, len(specs))
+	for _, spec := range specs {
+		out = append(out, map[string]any{
+			"name":        spec.Name,
+			"description": spec.Description,
+			"inputSchema": InputSchema(spec),
+		})
+	}
+	return out
+}
+
+func (s *Server) callTool(ctx context.Context, rawParams json.RawMessage) (any, *RPCError) {
+	var params struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal(rawParams, &params); err != nil || strings.TrimSpace(params.Name) == "" {
+		return nil, &RPCError{Code: -32602, Message: "invalid tools/call params"}
+	}
+	res, err := s.backend.CallTool(ctx, params.Name, params.Arguments)
+	if errors.Is(err, ErrUnknownTool) {
+		return nil, &RPCError{Code: -32602, Message: "unknown tool: " + params.Name}
+	}
+	if err != nil {
+		return CallResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	raw, mErr := json.Marshal(res)
+	if mErr != nil {
+		return nil, &RPCError{Code: -32603, Message: mErr.Error()}
+	}
+	return CallResult{Content: []Content{{Type: "text", Text: string(raw)}}, StructuredContent: res}, nil
+}
+
+// InputSchema renders a tool spec as the JSON schema MCP hosts expect.
+func InputSchema(spec tools.ToolSpec) map[string]any {
+	properties := make(map[string]any, len(spec.ArgTypes)+len(spec.Required))
+	for _, name := range spec.Required {
+		properties[name] = map[string]any{}
+	}
+	for name, argType := range spec.ArgTypes {
+		switch argType {
+		case tools.ArgTypeBool:
+			properties[name] = map[string]any{"type": "boolean"}
+		case "":
+			properties[name] = map[string]any{}
+		default:
+			properties[name] = map[string]any{"type": string(argType)}
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(spec.Required) > 0 {
+		schema["required"] = append([]string(nil), spec.Required...)
+	}
+	return schema
+}
+
+func (s *Server) newSession() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("mcp: session id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+	s.mu.Lock()
+	s.sessions[id] = struct{}{}
+	s.mu.Unlock()
+	return id, nil
+}
+
+func (s *Server) hasSession(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[id]
+	return ok
+}
+
+func (s *Server) endSession(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		return false
+	}
+	delete(s.sessions, id)
+	return true
+}
+
+func bearerMatches(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(token)) == 1
+}
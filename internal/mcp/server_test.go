@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/tools"
+)
+
+type fakeBackend struct{}
+
+func (fakeBackend) Tools() []tools.ToolSpec {
+	return []tools.ToolSpec{
+		{Name: "fs.read", Description: "Read a file", Required: []string{"path"}, ArgTypes: map[string]tools.ArgType{"path": tools.ArgTypeString, "max_bytes": tools.ArgTypeNumber}},
+		{Name: "agent.run", Description: "Run the agent", Required: []string{"message"}, ArgTypes: map[string]tools.ArgType{"message": tools.ArgTypeString, "new_session": tools.ArgTypeBool}},
+	}
+}
+
+func (fakeBackend) CallTool(_ context.Context, name string, args map[string]any) (map[string]any, error) {
+	switch name {
+	case "fs.read":
+		if args["path"] == "../secret" {
+			return nil, errors.New("policy.denied: path escapes workspace")
+		}
+		return map[string]any{"content": "hello"}, nil
+	case "agent.run":
+		return map[string]any{"output": "done: " + args["message"].(string)}, nil
+	default:
+		return nil, ErrUnknownTool
+	}
+}
+
+func TestServerStdioAnswersRequestsInOrder(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := NewServer(fakeBackend{}, "openclawssy", "test").ServeStdio(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("serve stdio: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected four responses (notification gets none), got %d:\n%s", len(lines), out.String())
+	}
+	var list struct {
+		Result struct {
+			Tools []struct {
+				Name        string         `json:"name"`
+				InputSchema map[string]any `json:"inputSchema"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &list); err != nil {
+		t.Fatalf("decode tools/list: %v", err)
+	}
+	if len(list.Result.Tools) != 2 || list.Result.Tools[0].Name != "agent.run" {
+		t.Fatalf("unexpected tools: %s", lines[1])
+	}
+	props := list.Result.Tools[0].InputSchema["properties"].(map[string]any)
+	if props["new_session"].(map[string]any)["type"] != "boolean" {
+		t.Fatalf("expected bool args to map to boolean schema, got %v", props)
+	}
+	if !strings.Contains(lines[2], `"code":-32700`) || !strings.Contains(lines[3], `"code":-32601`) {
+		t.Fatalf("expected parse and method errors, got %s / %s", lines[2], lines[3])
+	}
+}
+
+func TestServerHTTPInteropsWithClient(t *testing.T) {
+	srv := httptest.NewServer(NewServer(fakeBackend{}, "openclawssy", "test").Handler("secret"))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	t.Setenv("OPENCLAWSSY_MCP_TOKEN", "secret")
+	client, err := Dial(context.Background(), config.MCPServerConfig{Name: "claw", Transport: "http", URL: srv.URL, BearerTokenEnv: "OPENCLAWSSY_MCP_TOKEN"}, nil, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	mounted, err := Mount(context.Background(), client)
+	if err != nil {
+		t.Fatalf("mount: %v", err)
+	}
+	if len(mounted) != 2 || mounted[1].Spec.Name != "mcp.claw.fs.read" || mounted[1].Spec.ArgTypes["max_bytes"] != tools.ArgTypeNumber {
+		t.Fatalf("unexpected mounted tools: %+v", mounted)
+	}
+
+	res, err := client.CallTool(context.Background(), "agent.run", map[string]any{"message": "ship it"})
+	if err != nil || res.IsError {
+		t.Fatalf("call agent.run: %+v %v", res, err)
+	}
+	if structured, _ := res.StructuredContent.(map[string]any); structured["output"] != "done: ship it" {
+		t.Fatalf("unexpected structured content: %+v", res.StructuredContent)
+	}
+	res, err = client.CallTool(context.Background(), "fs.read", map[string]any{"path": "../secret"})
+	if err != nil || !res.IsError || !strings.Contains(contentText(res.Content), "policy.denied") {
+		t.Fatalf("expected tool error result, got %+v %v", res, err)
+	}
+	var rpcErr *RPCError
+	if _, err := client.CallTool(context.Background(), "shell.exec", nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Fatalf("expected unknown tool rpc error, got %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"tools/list"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(sessionHeader, "stale")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown session, got %d", resp.StatusCode)
+	}
+}
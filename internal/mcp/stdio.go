@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"openclawssy/internal/sandbox"
+)
+
+// stdioTransport speaks newline-delimited JSON-RPC over a child's stdin and
+// stdout. A single reader goroutine routes responses to waiting callers.
+type stdioTransport struct {
+	proc *sandbox.Process
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan message
+	done    chan struct{}
+	err     error
+}
+
+func newStdioTransport(proc *sandbox.Process) *stdioTransport {
+	t := &stdioTransport{
+		proc:    proc,
+		pending: make(map[string]chan message),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop()
+	return t
+}
+
+func (t *stdioTransport) call(ctx context.Context, req message) (message, error) {
+	ch := make(chan message, 1)
+	key := string(req.ID)
+	t.mu.Lock()
+	if t.err != nil {
+		err := t.err
+		t.mu.Unlock()
+		return message{}, err
+	}
+	t.pending[key] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		return message{}, err
+	}
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		return message{}, t.closedErr()
+	case <-ctx.Done():
+		return message{}, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(_ context.Context, msg message) error {
+	return t.write(msg)
+}
+
+func (t *stdioTransport) close() error {
+	err := t.proc.Stop()
+	<-t.done
+	return err
+}
+
+func (t *stdioTransport) write(msg message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.proc.Stdin.Write(append(raw, '\n')); err != nil {
+		return t.closedErrOr(err)
+	}
+	return nil
+}
+
+func (t *stdioTransport) readLoop() {
+	defer close(t.done)
+	reader := bufio.NewReaderSize(t.proc.Stdout, 64*1024)
+	for {
+		line, err := readLine(reader)
+		if len(bytes.TrimSpace(line)) > 0 {
+			var msg message
+			if json.Unmarshal(line, &msg) == nil {
+				t.dispatch(msg)
+			}
+		}
+		if err != nil {
+			t.mu.Lock()
+			t.err = ErrClosed
+			t.mu.Unlock()
+			return
+		}
+	}
+}
+
+// dispatch delivers responses to their callers. Servers may also send
+// requests of their own; ping is answered and anything else is refused since
+// this client advertises no capabilities.
+func (t *stdioTransport) dispatch(msg message) {
+	if msg.isResponse() {
+		t.mu.Lock()
+		ch, ok := t.pending[strings.TrimSpace(string(msg.ID))]
+		t.mu.Unlock()
+		if ok {
+			select {
+			case ch <- msg:
+			default:
+			}
+		}
+		return
+	}
+	if len(msg.ID) == 0 {
+		return
+	}
+	reply := message{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		reply.Result = json.RawMessage(`{}`)
+	} else {
+		reply.Error = &RPCError{Code: -32601, Message: "method not found"}
+	}
+	_ = t.write(reply)
+}
+
+func (t *stdioTransport) closedErr() error {
+	return t.closedErrOr(ErrClosed)
+}
+
+func (t *stdioTransport) closedErrOr(fallback error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	return fallback
+}
+
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		line = append(line, chunk...)
+		if len(line) > maxMessageBytes {
+			return nil, bufio.ErrBufferFull
+		}
+		if err != nil || !isPrefix {
+			return line, err
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"openclawssy/internal/tools"
+)
+
+// ToolPrefix namespaces mounted tools as mcp.<server>.<tool>.
+const ToolPrefix = "mcp."
+
+// MountedTool is an MCP tool exposed through the tool registry.
+type MountedTool struct {
+	Spec    tools.ToolSpec
+	Server  string
+	Remote  string
+	Handler tools.Handler
+}
+
+// ToolName returns the registry name for a server's tool. Tool names are
+// lowercased to match how tool calls are canonicalized, and characters outside
+// [a-z0-9_.-] are replaced with underscores.
+func ToolName(server, tool string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(tool)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '.' || r == '-' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteByte('_')
+	}
+	return ToolPrefix + server + "." + b.String()
+}
+
+// Mount lists the client's tools and wraps each one as a registry tool.
+// Tools whose names collide after normalization keep the first occurrence.
+func Mount(ctx context.Context, client *Client) ([]MountedTool, error) {
+	listed, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(listed))
+	out := make([]MountedTool, 0, len(listed))
+	for _, tool := range listed {
+		if strings.TrimSpace(tool.Name) == "" {
+			continue
+		}
+		name := ToolName(client.Name(), tool.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		argTypes, required := schemaArgTypes(tool.InputSchema)
+		description := strings.TrimSpace(tool.Description)
+		if description == "" {
+			description = strings.TrimSpace(tool.Title)
+		}
+		out = append(out, MountedTool{
+			Spec: tools.ToolSpec{
+				Name:        name,
+				Description: description,
+				Required:    required,
+				ArgTypes:    argTypes,
+			},
+			Server:  client.Name(),
+			Remote:  tool.Name,
+			Handler: callHandler(client, tool.Name),
+		})
+	}
+	return out, nil
+}
+
+// Register adds mounted tools to reg. Policy checks and audit events come from
+// the registry, exactly as for built-in tools.
+func Register(reg *tools.Registry, mounted []MountedTool) error {
+	for _, tool := range mounted {
+		if err := reg.Register(tool.Spec, tool.Handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callHandler(client *Client, remote string) tools.Handler {
+	return func(ctx context.Context, req tools.Request) (map[string]any, error) {
+		result, err := client.CallTool(ctx, remote, req.Args)
+		if err != nil {
+			return nil, err
+		}
+		text := contentText(result.Content)
+		if result.IsError {
+			if text == "" {
+				text = "tool reported an error"
+			}
+			return nil, errors.New(text)
+		}
+		out := map[string]any{
+			"server":  client.Name(),
+			"tool":    remote,
+			"content": text,
+		}
+		if result.StructuredContent != nil {
+			out["structured_content"] = result.StructuredContent
+		}
+		return out, nil
+	}
+}
+
+// contentText flattens result content to text. Binary items are summarized
+// rather than inlined, since tool output is fed back to the model as text.
+func contentText(items []Content) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		switch item.Type {
+		case "text":
+			parts = append(parts, item.Text)
+		case "resource":
+			if item.Resource != nil && item.Resource.Text != "" {
+				parts = append(parts, item.Resource.Text)
+			} else if item.Resource != nil {
+				parts = append(parts, fmt.Sprintf("[resource %s]", item.Resource.URI))
+			}
+		case "resource_link":
+			parts = append(parts, "[resource link]")
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content %s omitted]", item.Type, item.MimeType))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// schemaArgTypes maps a tool's JSON schema to registry argument types. Only
+// top-level properties with a single JSON type are checked; unions such as
+// ["string","null"] are left for the server to validate.
+func schemaArgTypes(raw json.RawMessage) (map[string]tools.ArgType, []string) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Type json.RawMessage `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, nil
+	}
+	argTypes := make(map[string]tools.ArgType, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		var typeName string
+		if json.Unmarshal(prop.Type, &typeName) != nil {
+			continue
+		}
+		switch typeName {
+		case "string":
+			argTypes[name] = tools.ArgTypeString
+		case "number", "integer":
+			argTypes[name] = tools.ArgTypeNumber
+		case "boolean":
+			argTypes[name] = tools.ArgTypeBool
+		case "object":
+			argTypes[name] = tools.ArgTypeObject
+		case "array":
+			argTypes[name] = tools.ArgTypeArray
+		}
+	}
+	return argTypes, schema.Required
+}
+
+// PromptDoc describes mounted tools for the system prompt.
+func PromptDoc(mounted []MountedTool) string {
+	if len(mounted) == 0 {
+		return ""
+	}
+	sorted := append([]MountedTool(nil), mounted...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Spec.Name < sorted[j].Spec.Name })
+
+	var b strings.Builder
+	b.WriteString("# MCP_TOOLS\n\nTools mounted from external MCP servers. Call them like core tools; arguments follow each tool's input schema.\n\n")
+	for _, tool := range sorted {
+		b.WriteString("- `")
+		b.WriteString(tool.Spec.Name)
+		b.WriteString("`")
+		if tool.Spec.Description != "" {
+			b.WriteString(": ")
+			b.WriteString(strings.Join(strings.Fields(tool.Spec.Description), " "))
+		}
+		if args := describeArgs(tool.Spec); args != "" {
+			b.WriteString(" Args: ")
+			b.WriteString(args)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func describeArgs(spec tools.ToolSpec) string {
+	required := make(map[string]bool, len(spec.Required))
+	names := make([]string, 0, len(spec.ArgTypes)+len(spec.Required))
+	for _, name := range spec.Required {
+		required[name] = true
+		names = append(names, name)
+	}
+	for name := range spec.ArgTypes {
+		if !required[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[len(spec.Required):])
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		part := "`" + name + "`"
+		if argType, ok := spec.ArgTypes[name]; ok {
+			part += " (" + string(argType)
+			if required[name] {
+				part += ", required"
+			}
+			part += ")"
+		} else if required[name] {
+			part += " (required)"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"openclawssy/internal/config"
+)
+
+const maxProvenanceIDs = 20
+
+type DecayPolicy struct {
+	NeverExpire      bool
+	ArchiveAfterDays int
+	HalfLifeDays     int
+	RequireReview    bool
+}
+
+type DecayPolicies map[string]DecayPolicy
+
+func DecayPoliciesFromConfig(cfg config.MemoryConfig) DecayPolicies {
+	out := make(DecayPolicies, len(cfg.KindPolicies))
+	for kind, p := range cfg.KindPolicies {
+		key := strings.ToLower(strings.TrimSpace(kind))
+		if key == "" {
+			continue
+		}
+		out[key] = DecayPolicy{NeverExpire: p.NeverExpire, ArchiveAfterDays: p.ArchiveAfterDays, HalfLifeDays: p.HalfLifeDays, RequireReview: p.RequireReview}
+	}
+	return out
+}
+
+func (p DecayPolicies) For(kind string) DecayPolicy {
+	if len(p) == 0 {
+		return DecayPolicy{}
+	}
+	if policy, ok := p[strings.ToLower(strings.TrimSpace(kind))]; ok {
+		return policy
+	}
+	return p["*"]
+}
+
+func (p DecayPolicies) ReviewStatus(item MemoryItem) string {
+	status := normalizeStatus(item.Status)
+	if status == MemoryStatusActive && p.For(item.Kind).RequireReview {
+		return MemoryStatusPendingReview
+	}
+	return status
+}
+
+func (p DecayPolicy) Expired(item MemoryItem, now time.Time) bool {
+	if p.NeverExpire || p.ArchiveAfterDays <= 0 || item.UpdatedAt.IsZero() {
+		return false
+	}
+	return item.UpdatedAt.Before(now.Add(-time.Duration(p.ArchiveAfterDays) * 24 * time.Hour))
+}
+
+func (p DecayPolicy) Weight(item MemoryItem, now time.Time) float64 {
+	if p.NeverExpire || p.HalfLifeDays <= 0 || item.UpdatedAt.IsZero() {
+		return 1
+	}
+	age := now.Sub(item.UpdatedAt)
+	if age <= 0 {
+		return 1
+	}
+	halfLife := time.Duration(p.HalfLifeDays) * 24 * time.Hour
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+func MergeProvenance(base, extra Provenance) Provenance {
+	out := Provenance{Source: base.Source}
+	if strings.TrimSpace(out.Source) == "" {
+		out.Source = strings.TrimSpace(extra.Source)
+	}
+	out.EventIDs = appendUniqueCapped(base.EventIDs, extra.EventIDs)
+	out.RunIDs = appendUniqueCapped(base.RunIDs, extra.RunIDs)
+	out.SessionIDs = appendUniqueCapped(base.SessionIDs, extra.SessionIDs)
+	return out
+}
+
+func ProvenanceFromEvents(source string, events []Event) Provenance {
+	out := Provenance{Source: strings.TrimSpace(source)}
+	for _, evt := range events {
+		out.EventIDs = appendUniqueCapped(out.EventIDs, []string{evt.ID})
+		out.RunIDs = appendUniqueCapped(out.RunIDs, []string{evt.RunID})
+		out.SessionIDs = appendUniqueCapped(out.SessionIDs, []string{evt.SessionID})
+	}
+	return out
+}
+
+func appendUniqueCapped(base, extra []string) []string {
+	if len(base) == 0 && len(extra) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(base)+len(extra))
+	out := make([]string, 0, len(base)+len(extra))
+	for _, list := range [][]string{base, extra} {
+		for _, value := range list {
+			value = strings.TrimSpace(value)
+			if value == "" || seen[value] {
+				continue
+			}
+			seen[value] = true
+			out = append(out, value)
+		}
+	}
+	if len(out) > maxProvenanceIDs {
+		out = out[len(out)-maxProvenanceIDs:]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -2,14 +2,17 @@ package memory
 
 import (
 	"bufio"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
+
+	"openclawssy/internal/policy"
 )
 
 func AppendEvent(agentsDir, agentID string, event Event) error {
@@ -17,7 +20,7 @@ func AppendEvent(agentsDir, agentID string, event Event) error {
 	if !validAgentID(agentID) {
 		return fmt.Errorf("%w: %q", ErrInvalidAgentID, agentID)
 	}
-	event = normalizeEvent(event)
+	event = redactEvent(normalizeEvent(event))
 	eventsDir := filepath.Join(agentsDir, agentID, "memory", "events")
 	if err := os.MkdirAll(eventsDir, defaultDirMode); err != nil {
 		return err
@@ -49,56 +52,135 @@ func ReadEventsSince(agentsDir, agentID string, since time.Time, maxEvents int)
 		maxEvents = 200
 	}
 	eventsDir := filepath.Join(agentsDir, agentID, "memory", "events")
-	entries, err := os.ReadDir(eventsDir)
+	files, err := listEventFiles(eventsDir)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	files := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
-			continue
+	sinceDay := ""
+	if !since.IsZero() {
+		since = since.UTC()
+		sinceDay = since.Format(eventDayLayout)
+	}
+	out := []Event{}
+	for i := len(files) - 1; i >= 0 && len(out) < maxEvents; i-- {
+		if sinceDay != "" && files[i].day < sinceDay {
+			break
 		}
-		files = append(files, filepath.Join(eventsDir, entry.Name()))
+		fileEvents := []Event{}
+		if err := scanEventFile(files[i], func(evt Event) {
+			if !since.IsZero() && !evt.Timestamp.After(since) {
+				return
+			}
+			fileEvents = append(fileEvents, evt)
+		}); err != nil {
+			return nil, err
+		}
+		out = append(fileEvents, out...)
 	}
-	sort.Strings(files)
+	if len(out) > maxEvents {
+		out = out[len(out)-maxEvents:]
+	}
+	return out, nil
+}
 
-	out := make([]Event, 0, min(maxEvents, 256))
-	for _, path := range files {
-		f, err := os.Open(path)
-		if err != nil {
-			return nil, err
+// ReadEventsFrom returns up to maxEvents events after cursor, oldest first,
+// and the cursor just past the last one returned. Earlier day files are
+// skipped by name and the cursor's own file from its offset, so a read costs
+// only the events it returns.
+func ReadEventsFrom(agentsDir, agentID string, cursor EventCursor, maxEvents int) ([]Event, EventCursor, error) {
+	agentID = strings.TrimSpace(agentID)
+	if !validAgentID(agentID) {
+		return nil, cursor, fmt.Errorf("%w: %q", ErrInvalidAgentID, agentID)
+	}
+	if maxEvents <= 0 {
+		maxEvents = 200
+	}
+	eventsDir := filepath.Join(agentsDir, agentID, "memory", "events")
+	files, err := listEventFiles(eventsDir)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	out := []Event{}
+	next := cursor
+	// A day can have a gzipped file followed by a plain one; the offset runs
+	// over both, and dayStart is where the current file begins within it.
+	dayStart := int64(0)
+	for _, f := range files {
+		if len(out) >= maxEvents {
+			break
 		}
-		scanner := bufio.NewScanner(f)
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 2*1024*1024)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
-			var evt Event
-			if err := json.Unmarshal([]byte(line), &evt); err != nil {
-				continue
-			}
-			evt = normalizeEvent(evt)
-			if !since.IsZero() && !evt.Timestamp.After(since.UTC()) {
-				continue
+		if f.day < cursor.Day {
+			continue
+		}
+		if f.day != next.Day {
+			next = EventCursor{Day: f.day, After: cursor.After}
+			dayStart = 0
+		}
+		read, err := readEventFileFrom(f, next.Offset-dayStart, func(evt Event) bool {
+			if !cursor.After.IsZero() && !evt.Timestamp.After(cursor.After) {
+				return true
 			}
 			out = append(out, evt)
-			if len(out) > maxEvents {
-				out = out[len(out)-maxEvents:]
-			}
+			return len(out) < maxEvents
+		})
+		if err != nil {
+			return nil, cursor, err
 		}
-		_ = f.Close()
-		if err := scanner.Err(); err != nil {
-			return nil, err
+		next.Offset = dayStart + read
+		dayStart = next.Offset
+	}
+	return out, next, nil
+}
+
+// readEventFileFrom calls fn for each event after the first skip bytes of f
+// until fn returns false, and returns the offset just past the last line it
+// consumed. A trailing line without a newline in a plain file may still be
+// being written and is left for the next read.
+func readEventFileFrom(f eventFile, skip int64, fn func(Event) bool) (int64, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return skip, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if f.gz {
+		gzr, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, err
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+	pos := int64(0)
+	if skip > 0 {
+		if pos, err = io.CopyN(io.Discard, r, skip); err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+	}
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && (!f.gz || len(line) == 0) {
+			return pos, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return pos, err
+		}
+		pos += int64(len(line))
+		var evt Event
+		if trimmed := strings.TrimSpace(string(line)); trimmed == "" || json.Unmarshal([]byte(trimmed), &evt) != nil {
+			continue
+		}
+		if !fn(evt) {
+			return pos, nil
 		}
 	}
-	return out, nil
 }
 
 func WriteCheckpointRecord(agentsDir, agentID string, record CheckpointRecord) (string, error) {
@@ -186,3 +268,54 @@ func WriteMaintenanceReport(agentsDir, agentID string, report MaintenanceReport)
 	}
 	return reportPath, nil
 }
+
+func WriteHousekeepingStatus(agentsDir, agentID string, status HousekeepingStatus) error {
+	agentID = strings.TrimSpace(agentID)
+	if !validAgentID(agentID) {
+		return fmt.Errorf("%w: %q", ErrInvalidAgentID, agentID)
+	}
+	status.AgentID = agentID
+	memoryDir := filepath.Join(agentsDir, agentID, "memory")
+	if err := os.MkdirAll(memoryDir, defaultDirMode); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+	tmpPath := filepath.Join(memoryDir, ".housekeeping.json.tmp")
+	if err := os.WriteFile(tmpPath, raw, defaultFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(memoryDir, "housekeeping.json"))
+}
+
+func LoadHousekeepingStatus(agentsDir, agentID string) (HousekeepingStatus, bool, error) {
+	agentID = strings.TrimSpace(agentID)
+	if !validAgentID(agentID) {
+		return HousekeepingStatus{}, false, fmt.Errorf("%w: %q", ErrInvalidAgentID, agentID)
+	}
+	raw, err := os.ReadFile(filepath.Join(agentsDir, agentID, "memory", "housekeeping.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return HousekeepingStatus{}, false, nil
+		}
+		return HousekeepingStatus{}, false, err
+	}
+	var status HousekeepingStatus
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return HousekeepingStatus{}, false, err
+	}
+	return status, true, nil
+}
+
+func redactEvent(event Event) Event {
+	event.Text = policy.RedactString(event.Text)
+	if len(event.Metadata) > 0 {
+		if redacted, ok := policy.RedactValue(event.Metadata).(map[string]any); ok {
+			event.Metadata = redacted
+		}
+	}
+	return event
+}
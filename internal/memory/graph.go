@@ -0,0 +1,93 @@
+package memory
+
+import "strings"
+
+const (
+	defaultEntityType     = "thing"
+	defaultGraphDepth     = 1
+	maxGraphDepth         = 3
+	defaultGraphLimit     = 50
+	maxGraphLimit         = 500
+	defaultRelationWeight = 0.8
+)
+
+func EntityKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func NormalizeRelationName(relation string) string {
+	fields := strings.FieldsFunc(strings.ToLower(strings.TrimSpace(relation)), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-' || r == '\t'
+	})
+	return strings.Join(fields, "_")
+}
+
+func NormalizeEntity(entity Entity) Entity {
+	entity.ID = strings.TrimSpace(entity.ID)
+	entity.AgentID = strings.TrimSpace(entity.AgentID)
+	entity.Name = strings.Join(strings.Fields(entity.Name), " ")
+	entity.Type = strings.ToLower(strings.TrimSpace(entity.Type))
+	if entity.Type == "" {
+		entity.Type = defaultEntityType
+	}
+	entity.Description = strings.TrimSpace(entity.Description)
+	return entity
+}
+
+func NormalizeRelation(rel Relation) Relation {
+	rel.ID = strings.TrimSpace(rel.ID)
+	rel.AgentID = strings.TrimSpace(rel.AgentID)
+	rel.SourceName = strings.Join(strings.Fields(rel.SourceName), " ")
+	rel.TargetName = strings.Join(strings.Fields(rel.TargetName), " ")
+	rel.Relation = NormalizeRelationName(rel.Relation)
+	if rel.Confidence <= 0 {
+		rel.Confidence = defaultRelationWeight
+	}
+	if rel.Confidence > 1 {
+		rel.Confidence = 1
+	}
+	return rel
+}
+
+func NormalizeGraphQueryParams(params GraphQueryParams) GraphQueryParams {
+	params.Entity = strings.TrimSpace(params.Entity)
+	params.Relation = NormalizeRelationName(params.Relation)
+	if params.Depth <= 0 {
+		params.Depth = defaultGraphDepth
+	}
+	if params.Depth > maxGraphDepth {
+		params.Depth = maxGraphDepth
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultGraphLimit
+	}
+	if params.Limit > maxGraphLimit {
+		params.Limit = maxGraphLimit
+	}
+	return params
+}
+
+func MentionsEntity(text, name string) bool {
+	key := EntityKey(name)
+	if key == "" {
+		return false
+	}
+	haystack := EntityKey(text)
+	for offset := 0; offset < len(haystack); {
+		idx := strings.Index(haystack[offset:], key)
+		if idx < 0 {
+			return false
+		}
+		start := offset + idx
+		end := start + len(key)
+		if (start == 0 || !isEntityWordByte(haystack[start-1])) && (end == len(haystack) || !isEntityWordByte(haystack[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isEntityWordByte(b byte) bool {
+	return b == '_' || b == '-' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z')
+}
@@ -79,7 +79,7 @@ func (m *Manager) IngestEvent(ctx context.Context, event Event) error {
 	default:
 	}
 
-	event = normalizeEvent(event)
+	event = redactEvent(normalizeEvent(event))
 
 	select {
 	case m.queue <- event:
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"openclawssy/internal/config"
 )
 
 func TestManagerWritesEventJSONL(t *testing.T) {
@@ -131,3 +134,116 @@ func TestAppendEventReadEventsAndCheckpointRecord(t *testing.T) {
 		t.Fatalf("expected event_count=2, got %d", loaded.EventCount)
 	}
 }
+
+func TestMentionsEntityMatchesWholeWords(t *testing.T) {
+	if !MentionsEntity("Does Checkout-API  depend on anything?", "checkout-api") {
+		t.Fatal("expected case-insensitive whole-word match")
+	}
+	if MentionsEntity("the checkout-api-v2 rollout", "checkout-api") {
+		t.Fatal("expected suffixed name not to match")
+	}
+	if got := NormalizeRelationName(" Depends-On "); got != "depends_on" {
+		t.Fatalf("expected depends_on, got %q", got)
+	}
+}
+
+func TestDecayPolicyExpiryAndWeight(t *testing.T) {
+	now := time.Now().UTC()
+	policies := DecayPoliciesFromConfig(config.MemoryConfig{KindPolicies: config.DefaultMemoryKindPolicies()})
+
+	old := MemoryItem{Kind: "incident", UpdatedAt: now.Add(-45 * 24 * time.Hour)}
+	if !policies.For("incident").Expired(old, now) {
+		t.Fatal("expected 45-day-old incident to be expired")
+	}
+	if policies.For("Preference").Expired(MemoryItem{Kind: "preference", UpdatedAt: now.Add(-5 * 365 * 24 * time.Hour)}, now) {
+		t.Fatal("expected preference to never expire")
+	}
+	if policies.For("note").Expired(old, now) {
+		t.Fatal("expected kinds without a policy to never expire")
+	}
+
+	weight := policies.For("incident").Weight(MemoryItem{UpdatedAt: now.Add(-7 * 24 * time.Hour)}, now)
+	if weight < 0.49 || weight > 0.51 {
+		t.Fatalf("expected half weight after one half-life, got %f", weight)
+	}
+
+	merged := MergeProvenance(Provenance{Source: "memory.write", RunIDs: []string{"run_1"}}, Provenance{Source: "memory.update", RunIDs: []string{"run_1", "run_2"}})
+	if merged.Source != "memory.write" || len(merged.RunIDs) != 2 {
+		t.Fatalf("unexpected merged provenance: %#v", merged)
+	}
+}
+
+func TestAppendEventRedactsBeforePersisting(t *testing.T) {
+	agentsDir := filepath.Join(t.TempDir(), ".openclawssy", "agents")
+	if err := AppendEvent(agentsDir, "default", Event{Type: EventTypeToolResult, Text: "api_key=sk-live-123", Metadata: map[string]any{"header": "Bearer abc.def"}}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(agentsDir, "default", "memory", "events"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one event file, entries=%v err=%v", entries, err)
+	}
+	raw, err := os.ReadFile(filepath.Join(agentsDir, "default", "memory", "events", entries[0].Name()))
+	if err != nil {
+		t.Fatalf("read event file: %v", err)
+	}
+	if strings.Contains(string(raw), "sk-live-123") || strings.Contains(string(raw), "abc.def") {
+		t.Fatalf("expected secrets to be redacted on disk, got %s", raw)
+	}
+}
+
+func TestEventRetentionCompactsCompressesAndPrunes(t *testing.T) {
+	agentsDir := filepath.Join(t.TempDir(), ".openclawssy", "agents")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, days := range []int{40, 5, 3, 3, 0} {
+		ts := now.AddDate(0, 0, -days)
+		if err := AppendEvent(agentsDir, "default", Event{Type: EventTypeUserMessage, Text: fmt.Sprintf("event %d days ago", days), Timestamp: ts}); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+	if err := AppendEvent(agentsDir, "default", Event{Type: EventTypeUserMessage, Text: "late on day 3", Timestamp: now.AddDate(0, 0, -3).Add(time.Hour)}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+
+	folded, cursor, err := ReadEventsFrom(agentsDir, "default", EventCursor{}, 4)
+	if err != nil || len(folded) != 4 || folded[0].Text != "event 40 days ago" || cursor.Day != "2026-03-07" {
+		t.Fatalf("expected the 4 oldest events, got %#v %+v %v", folded, cursor, err)
+	}
+	compacted, err := CompactEvents(agentsDir, "default", cursor, now)
+	if err != nil {
+		t.Fatalf("compact events: %v", err)
+	}
+	if compacted != 2 {
+		t.Fatalf("expected only the fully read days to be compacted, got %d", compacted)
+	}
+
+	report, err := ApplyEventRetention(agentsDir, "default", EventRetention{MaxAgeDays: 30, CompressAfterDays: 2}, now)
+	if err != nil {
+		t.Fatalf("apply retention: %v", err)
+	}
+	if len(report.CompressedFiles) != 1 || report.CompressedFiles[0] != "2026-03-07.jsonl" {
+		t.Fatalf("unexpected compressed files: %#v", report.CompressedFiles)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, "default", "memory", "events", "2026-03-07.jsonl.gz")); err != nil {
+		t.Fatalf("expected gzip event file: %v", err)
+	}
+
+	events, _, err := ReadEventsFrom(agentsDir, "default", cursor, 10)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if len(events) != 2 || events[0].Text != "late on day 3" || events[1].Text != "event 0 days ago" {
+		t.Fatalf("expected the cursor to survive compression, got %#v", events)
+	}
+
+	report, err = ApplyEventRetention(agentsDir, "default", EventRetention{MaxBytes: 1}, now)
+	if err != nil {
+		t.Fatalf("apply size retention: %v", err)
+	}
+	if len(report.DeletedFiles) != 1 || report.DeletedFiles[0] != "2026-03-07.jsonl.gz" {
+		t.Fatalf("expected oldest file pruned by size cap, got %#v", report.DeletedFiles)
+	}
+	events, err = ReadEventsSince(agentsDir, "default", time.Time{}, 10)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected today's file to survive size cap, events=%#v err=%v", events, err)
+	}
+}
@@ -25,22 +25,31 @@ type Event struct {
 }
 
 const (
-	MemoryStatusActive    = "active"
-	MemoryStatusForgotten = "forgotten"
-	MemoryStatusArchived  = "archived"
+	MemoryStatusActive        = "active"
+	MemoryStatusForgotten     = "forgotten"
+	MemoryStatusArchived      = "archived"
+	MemoryStatusPendingReview = "pending_review"
 )
 
 type MemoryItem struct {
-	ID         string    `json:"id"`
-	AgentID    string    `json:"agent_id"`
-	Kind       string    `json:"kind"`
-	Title      string    `json:"title"`
-	Content    string    `json:"content"`
-	Importance int       `json:"importance"`
-	Confidence float64   `json:"confidence"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string     `json:"id"`
+	AgentID    string     `json:"agent_id"`
+	Kind       string     `json:"kind"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Importance int        `json:"importance"`
+	Confidence float64    `json:"confidence"`
+	Status     string     `json:"status"`
+	Provenance Provenance `json:"provenance,omitzero"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type Provenance struct {
+	Source     string   `json:"source,omitempty"`
+	EventIDs   []string `json:"event_ids,omitempty"`
+	RunIDs     []string `json:"run_ids,omitempty"`
+	SessionIDs []string `json:"session_ids,omitempty"`
 }
 
 type SearchParams struct {
@@ -51,42 +60,105 @@ type SearchParams struct {
 }
 
 type Health struct {
-	DBPath         string `json:"db_path"`
-	DBSizeBytes    int64  `json:"db_size_bytes"`
-	TotalItems     int    `json:"total_items"`
-	ActiveItems    int    `json:"active_items"`
-	ForgottenItems int    `json:"forgotten_items"`
-	ArchivedItems  int    `json:"archived_items"`
+	DBPath             string `json:"db_path"`
+	DBSizeBytes        int64  `json:"db_size_bytes"`
+	TotalItems         int    `json:"total_items"`
+	ActiveItems        int    `json:"active_items"`
+	ForgottenItems     int    `json:"forgotten_items"`
+	ArchivedItems      int    `json:"archived_items"`
+	PendingReviewItems int    `json:"pending_review_items"`
+	EntityCount        int    `json:"entity_count"`
+	RelationCount      int    `json:"relation_count"`
+}
+
+type Entity struct {
+	ID          string    `json:"id"`
+	AgentID     string    `json:"agent_id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Relation struct {
+	ID         string    `json:"id"`
+	AgentID    string    `json:"agent_id"`
+	SourceID   string    `json:"source_id"`
+	SourceName string    `json:"source_name"`
+	Relation   string    `json:"relation"`
+	TargetID   string    `json:"target_id"`
+	TargetName string    `json:"target_name"`
+	Confidence float64   `json:"confidence"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type GraphQueryParams struct {
+	Entity   string `json:"entity,omitempty"`
+	Relation string `json:"relation,omitempty"`
+	Depth    int    `json:"depth,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+type GraphResult struct {
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
 }
 
 type CheckpointRecord struct {
-	ID                 string    `json:"id"`
-	AgentID            string    `json:"agent_id"`
-	CreatedAt          time.Time `json:"created_at"`
-	FromTimestamp      time.Time `json:"from_timestamp"`
-	ToTimestamp        time.Time `json:"to_timestamp"`
-	EventCount         int       `json:"event_count"`
-	NewItemCount       int       `json:"new_item_count"`
-	UpdatedItemCount   int       `json:"updated_item_count"`
-	Summary            string    `json:"summary"`
-	CheckpointFilePath string    `json:"checkpoint_file_path"`
+	ID                 string      `json:"id"`
+	AgentID            string      `json:"agent_id"`
+	CreatedAt          time.Time   `json:"created_at"`
+	FromTimestamp      time.Time   `json:"from_timestamp"`
+	ToTimestamp        time.Time   `json:"to_timestamp"`
+	Cursor             EventCursor `json:"cursor"`
+	EventCount         int         `json:"event_count"`
+	NewItemCount       int         `json:"new_item_count"`
+	UpdatedItemCount   int         `json:"updated_item_count"`
+	EntityCount        int         `json:"entity_count,omitempty"`
+	RelationCount      int         `json:"relation_count,omitempty"`
+	Summary            string      `json:"summary"`
+	CheckpointFilePath string      `json:"checkpoint_file_path"`
+}
+
+// EventCursor is a read position in the event log: the day file and the byte
+// offset (in the uncompressed file) just past the last event consumed. After,
+// when set, also skips events at or before it; it is only used for checkpoint
+// records written before cursors existed.
+type EventCursor struct {
+	Day    string    `json:"day,omitempty"`
+	Offset int64     `json:"offset,omitempty"`
+	After  time.Time `json:"-"`
+}
+
+// NextCursor is where the checkpoint after r starts reading.
+func (r CheckpointRecord) NextCursor() EventCursor {
+	if r.Cursor.Day != "" || r.ToTimestamp.IsZero() {
+		return r.Cursor
+	}
+	to := r.ToTimestamp.UTC()
+	return EventCursor{Day: to.Format(eventDayLayout), After: to}
 }
 
 type MaintenanceReport struct {
-	ID                   string         `json:"id"`
-	AgentID              string         `json:"agent_id"`
-	CreatedAt            time.Time      `json:"created_at"`
-	DeduplicatedCount    int            `json:"deduplicated_count"`
-	ArchivedStaleCount   int            `json:"archived_stale_count"`
-	VerificationCount    int            `json:"verification_count"`
-	Compacted            bool           `json:"compacted"`
-	Before               Health         `json:"before"`
-	After                Health         `json:"after"`
-	VerificationItemIDs  []string       `json:"verification_item_ids,omitempty"`
-	ArchivedDuplicateIDs []string       `json:"archived_duplicate_ids,omitempty"`
-	ArchivedStaleIDs     []string       `json:"archived_stale_ids,omitempty"`
-	ReportFilePath       string         `json:"report_file_path"`
-	Metadata             map[string]any `json:"metadata,omitempty"`
+	ID                   string               `json:"id"`
+	AgentID              string               `json:"agent_id"`
+	CreatedAt            time.Time            `json:"created_at"`
+	DeduplicatedCount    int                  `json:"deduplicated_count"`
+	ArchivedStaleCount   int                  `json:"archived_stale_count"`
+	ArchivedExpiredCount int                  `json:"archived_expired_count"`
+	VerificationCount    int                  `json:"verification_count"`
+	Compacted            bool                 `json:"compacted"`
+	Before               Health               `json:"before"`
+	After                Health               `json:"after"`
+	VerificationItemIDs  []string             `json:"verification_item_ids,omitempty"`
+	ArchivedDuplicateIDs []string             `json:"archived_duplicate_ids,omitempty"`
+	ArchivedStaleIDs     []string             `json:"archived_stale_ids,omitempty"`
+	ArchivedExpiredIDs   []string             `json:"archived_expired_ids,omitempty"`
+	EventLog             EventRetentionReport `json:"event_log"`
+	ReportFilePath       string               `json:"report_file_path"`
+	Metadata             map[string]any       `json:"metadata,omitempty"`
 }
 
 type Options struct {
@@ -97,3 +169,18 @@ type Options struct {
 type Stats struct {
 	DroppedEvents uint64 `json:"dropped_events"`
 }
+
+type HousekeepingStatus struct {
+	AgentID               string    `json:"agent_id"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	PendingEvents         int       `json:"pending_events"`
+	LastEventAt           time.Time `json:"last_event_at,omitzero"`
+	LastCheckpointAt      time.Time `json:"last_checkpoint_at,omitzero"`
+	LastCheckpointResult  string    `json:"last_checkpoint_result,omitempty"`
+	LastCheckpointTrigger string    `json:"last_checkpoint_trigger,omitempty"`
+	LastMaintenanceAt     time.Time `json:"last_maintenance_at,omitzero"`
+	NextMaintenanceAt     time.Time `json:"next_maintenance_at,omitzero"`
+	ConsecutiveFailures   int       `json:"consecutive_failures"`
+	BackoffUntil          time.Time `json:"backoff_until,omitzero"`
+	LastError             string    `json:"last_error,omitempty"`
+}
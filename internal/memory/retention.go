@@ -0,0 +1,328 @@
+package memory
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"openclawssy/internal/config"
+)
+
+const (
+	eventFileSuffix   = ".jsonl"
+	eventGzipSuffix   = ".jsonl.gz"
+	eventDayLayout    = "2006-01-02"
+	maxEventLineBytes = 2 * 1024 * 1024
+)
+
+type EventRetention struct {
+	MaxAgeDays        int
+	MaxBytes          int64
+	CompressAfterDays int
+}
+
+type EventRetentionReport struct {
+	CompactedEvents int      `json:"compacted_events"`
+	CompressedFiles []string `json:"compressed_files,omitempty"`
+	DeletedFiles    []string `json:"deleted_files,omitempty"`
+	BytesBefore     int64    `json:"bytes_before"`
+	BytesAfter      int64    `json:"bytes_after"`
+}
+
+type eventFile struct {
+	path string
+	name string
+	day  string
+	gz   bool
+	size int64
+}
+
+func EventRetentionFromConfig(cfg config.MemoryConfig) EventRetention {
+	return EventRetention{
+		MaxAgeDays:        cfg.EventRetention.MaxAgeDays,
+		MaxBytes:          int64(cfg.EventRetention.MaxMB) * 1024 * 1024,
+		CompressAfterDays: cfg.EventRetention.CompressAfterDays,
+	}
+}
+
+func ApplyEventRetention(agentsDir, agentID string, retention EventRetention, now time.Time) (EventRetentionReport, error) {
+	agentID = strings.TrimSpace(agentID)
+	if !validAgentID(agentID) {
+		return EventRetentionReport{}, fmt.Errorf("%w: %q", ErrInvalidAgentID, agentID)
+	}
+	eventsDir := filepath.Join(agentsDir, agentID, "memory", "events")
+	files, err := listEventFiles(eventsDir)
+	if err != nil {
+		return EventRetentionReport{}, err
+	}
+	report := EventRetentionReport{}
+	for _, f := range files {
+		report.BytesBefore += f.size
+	}
+
+	today := now.UTC().Format(eventDayLayout)
+	kept := make([]eventFile, 0, len(files))
+	for _, f := range files {
+		if retention.MaxAgeDays > 0 && f.day != today && f.day < dayBefore(now, retention.MaxAgeDays) {
+			if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return report, err
+			}
+			report.DeletedFiles = append(report.DeletedFiles, f.name)
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	if retention.CompressAfterDays > 0 {
+		cutoff := dayBefore(now, retention.CompressAfterDays-1)
+		for i, f := range kept {
+			if f.gz || f.day == today || f.day >= cutoff {
+				continue
+			}
+			compressed, err := compressEventFile(f.path)
+			if err != nil {
+				return report, err
+			}
+			report.CompressedFiles = append(report.CompressedFiles, f.name)
+			kept[i] = compressed
+		}
+		kept = mergeEventFiles(kept)
+	}
+
+	if retention.MaxBytes > 0 {
+		total := int64(0)
+		for _, f := range kept {
+			total += f.size
+		}
+		remaining := kept[:0]
+		for _, f := range kept {
+			if total > retention.MaxBytes && f.day != today {
+				if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return report, err
+				}
+				report.DeletedFiles = append(report.DeletedFiles, f.name)
+				total -= f.size
+				continue
+			}
+			remaining = append(remaining, f)
+		}
+		kept = remaining
+	}
+
+	for _, f := range kept {
+		report.BytesAfter += f.size
+	}
+	return report, nil
+}
+
+// CompactEvents drops the day files a checkpoint has read to the end, i.e.
+// every day before the cursor's. The cursor's own day is kept whole, so no
+// event is dropped before a checkpoint has folded it in.
+func CompactEvents(agentsDir, agentID string, cursor EventCursor, now time.Time) (int, error) {
+	agentID = strings.TrimSpace(agentID)
+	if !validAgentID(agentID) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAgentID, agentID)
+	}
+	if cursor.Day == "" {
+		return 0, nil
+	}
+	eventsDir := filepath.Join(agentsDir, agentID, "memory", "events")
+	files, err := listEventFiles(eventsDir)
+	if err != nil {
+		return 0, err
+	}
+	today := now.UTC().Format(eventDayLayout)
+	removed := 0
+	for _, f := range files {
+		if f.day == today || f.day >= cursor.Day {
+			continue
+		}
+		dropped := 0
+		if err := scanEventFile(f, func(Event) { dropped++ }); err != nil {
+			return removed, err
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed += dropped
+	}
+	return removed, nil
+}
+
+func listEventFiles(eventsDir string) ([]eventFile, error) {
+	entries, err := os.ReadDir(eventsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files := make([]eventFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		var day string
+		gz := false
+		switch {
+		case strings.HasSuffix(name, eventGzipSuffix):
+			day = strings.TrimSuffix(name, eventGzipSuffix)
+			gz = true
+		case strings.HasSuffix(name, eventFileSuffix):
+			day = strings.TrimSuffix(name, eventFileSuffix)
+		default:
+			continue
+		}
+		if _, err := time.Parse(eventDayLayout, day); err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, eventFile{path: filepath.Join(eventsDir, name), name: name, day: day, gz: gz, size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].day == files[j].day {
+			return files[i].gz && !files[j].gz
+		}
+		return files[i].day < files[j].day
+	})
+	return files, nil
+}
+
+func scanEventFile(f eventFile, fn func(Event)) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if f.gz {
+		gzr, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, maxEventLineBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var evt Event
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			continue
+		}
+		fn(normalizeEvent(evt))
+	}
+	return scanner.Err()
+}
+
+func compressEventFile(path string) (eventFile, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return eventFile{}, err
+	}
+	defer src.Close()
+
+	dir := filepath.Dir(path)
+	day := strings.TrimSuffix(filepath.Base(path), eventFileSuffix)
+	tmp, err := os.CreateTemp(dir, "."+day+"-*.gz.tmp")
+	if err != nil {
+		return eventFile{}, err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	gzw := gzip.NewWriter(tmp)
+	if _, err := io.Copy(gzw, src); err != nil {
+		_ = tmp.Close()
+		return eventFile{}, err
+	}
+	if err := gzw.Close(); err != nil {
+		_ = tmp.Close()
+		return eventFile{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return eventFile{}, err
+	}
+
+	dstPath := filepath.Join(dir, day+eventGzipSuffix)
+	if _, err := os.Stat(dstPath); errors.Is(err, os.ErrNotExist) {
+		if err := os.Rename(tmpPath, dstPath); err != nil {
+			return eventFile{}, err
+		}
+		if err := os.Chmod(dstPath, defaultFileMode); err != nil {
+			return eventFile{}, err
+		}
+	} else if err != nil {
+		return eventFile{}, err
+	} else if err := appendFile(dstPath, tmpPath); err != nil {
+		return eventFile{}, err
+	}
+	if err := os.Remove(path); err != nil {
+		return eventFile{}, err
+	}
+	info, err := os.Stat(dstPath)
+	if err != nil {
+		return eventFile{}, err
+	}
+	return eventFile{path: dstPath, name: filepath.Base(dstPath), day: day, gz: true, size: info.Size()}, nil
+}
+
+func appendFile(dstPath, srcPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(dstPath, os.O_APPEND|os.O_WRONLY, defaultFileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func mergeEventFiles(files []eventFile) []eventFile {
+	out := make([]eventFile, 0, len(files))
+	seen := map[string]int{}
+	for _, f := range files {
+		if idx, ok := seen[f.path]; ok {
+			out[idx] = f
+			continue
+		}
+		seen[f.path] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
+
+func dayBefore(now time.Time, days int) string {
+	return now.UTC().AddDate(0, 0, -days).Format(eventDayLayout)
+}
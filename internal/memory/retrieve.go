@@ -58,7 +58,7 @@ func NormalizeItem(item MemoryItem) MemoryItem {
 func normalizeStatus(status string) string {
 	value := strings.ToLower(strings.TrimSpace(status))
 	switch value {
-	case MemoryStatusActive, MemoryStatusForgotten, MemoryStatusArchived:
+	case MemoryStatusActive, MemoryStatusForgotten, MemoryStatusArchived, MemoryStatusPendingReview:
 		return value
 	default:
 		return defaultMemoryStatus
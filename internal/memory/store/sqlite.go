@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -94,6 +95,11 @@ func (s *SQLiteStore) Upsert(ctx context.Context, item memory.MemoryItem) (memor
 	item.CreatedAt = createdAt
 	item.UpdatedAt = now
 
+	provenanceJSON, err := json.Marshal(item.Provenance)
+	if err != nil {
+		return memory.MemoryItem{}, err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return memory.MemoryItem{}, err
@@ -102,8 +108,8 @@ func (s *SQLiteStore) Upsert(ctx context.Context, item memory.MemoryItem) (memor
 
 	if _, err := tx.ExecContext(ctx, `
 		INSERT INTO memory_items (
-			id, agent_id, kind, title, content, importance, confidence, status, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, agent_id, kind, title, content, importance, confidence, status, provenance_json, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			kind=excluded.kind,
 			title=excluded.title,
@@ -111,8 +117,9 @@ func (s *SQLiteStore) Upsert(ctx context.Context, item memory.MemoryItem) (memor
 			importance=excluded.importance,
 			confidence=excluded.confidence,
 			status=excluded.status,
+			provenance_json=excluded.provenance_json,
 			updated_at=excluded.updated_at
-	`, item.ID, item.AgentID, item.Kind, item.Title, item.Content, item.Importance, item.Confidence, item.Status, item.CreatedAt, item.UpdatedAt); err != nil {
+	`, item.ID, item.AgentID, item.Kind, item.Title, item.Content, item.Importance, item.Confidence, item.Status, string(provenanceJSON), item.CreatedAt, item.UpdatedAt); err != nil {
 		return memory.MemoryItem{}, err
 	}
 
@@ -146,12 +153,13 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (memory.MemoryItem, bo
 		return memory.MemoryItem{}, false, errors.New("memory store: id is required")
 	}
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, agent_id, kind, title, content, importance, confidence, status, created_at, updated_at
+		SELECT id, agent_id, kind, title, content, importance, confidence, status, provenance_json, created_at, updated_at
 		FROM memory_items
 		WHERE id = ? AND agent_id = ?
 		LIMIT 1
 	`, id, s.agentID)
 	var item memory.MemoryItem
+	var provenanceJSON string
 	if err := row.Scan(
 		&item.ID,
 		&item.AgentID,
@@ -161,6 +169,7 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (memory.MemoryItem, bo
 		&item.Importance,
 		&item.Confidence,
 		&item.Status,
+		&provenanceJSON,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	); err != nil {
@@ -169,6 +178,7 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (memory.MemoryItem, bo
 		}
 		return memory.MemoryItem{}, false, err
 	}
+	item.Provenance = decodeProvenance(provenanceJSON)
 	return item, true, nil
 }
 
@@ -255,7 +265,7 @@ func (s *SQLiteStore) Search(ctx context.Context, params memory.SearchParams) ([
 	}
 	query := buildFTSQuery(params.Query)
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT m.id, m.agent_id, m.kind, m.title, m.content, m.importance, m.confidence, m.status, m.created_at, m.updated_at
+		SELECT m.id, m.agent_id, m.kind, m.title, m.content, m.importance, m.confidence, m.status, m.provenance_json, m.created_at, m.updated_at
 		FROM memory_fts f
 		JOIN memory_items m ON m.id = f.id
 		WHERE m.agent_id = ?
@@ -278,17 +288,24 @@ func (s *SQLiteStore) Health(ctx context.Context) (memory.Health, error) {
 	if err != nil {
 		return memory.Health{}, err
 	}
+	entityCount, relationCount, err := s.graphCounts(ctx)
+	if err != nil {
+		return memory.Health{}, err
+	}
 	var sizeBytes int64
 	if info, err := os.Stat(s.path); err == nil {
 		sizeBytes = info.Size()
 	}
 	return memory.Health{
-		DBPath:         s.path,
-		DBSizeBytes:    sizeBytes,
-		TotalItems:     counts[memory.MemoryStatusActive] + counts[memory.MemoryStatusForgotten] + counts[memory.MemoryStatusArchived],
-		ActiveItems:    counts[memory.MemoryStatusActive],
-		ForgottenItems: counts[memory.MemoryStatusForgotten],
-		ArchivedItems:  counts[memory.MemoryStatusArchived],
+		DBPath:             s.path,
+		DBSizeBytes:        sizeBytes,
+		TotalItems:         counts[memory.MemoryStatusActive] + counts[memory.MemoryStatusForgotten] + counts[memory.MemoryStatusArchived] + counts[memory.MemoryStatusPendingReview],
+		ActiveItems:        counts[memory.MemoryStatusActive],
+		ForgottenItems:     counts[memory.MemoryStatusForgotten],
+		ArchivedItems:      counts[memory.MemoryStatusArchived],
+		PendingReviewItems: counts[memory.MemoryStatusPendingReview],
+		EntityCount:        entityCount,
+		RelationCount:      relationCount,
 	}, nil
 }
 
@@ -304,7 +321,7 @@ func (s *SQLiteStore) List(ctx context.Context, status string, limit int) ([]mem
 		limit = 20000
 	}
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, agent_id, kind, title, content, importance, confidence, status, created_at, updated_at
+		SELECT id, agent_id, kind, title, content, importance, confidence, status, provenance_json, created_at, updated_at
 		FROM memory_items
 		WHERE agent_id = ?
 		  AND status = ?
@@ -328,7 +345,7 @@ func (s *SQLiteStore) Vacuum(ctx context.Context) error {
 
 func (s *SQLiteStore) searchWithoutQuery(ctx context.Context, params memory.SearchParams, status string) ([]memory.MemoryItem, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, agent_id, kind, title, content, importance, confidence, status, created_at, updated_at
+		SELECT id, agent_id, kind, title, content, importance, confidence, status, provenance_json, created_at, updated_at
 		FROM memory_items
 		WHERE agent_id = ?
 		  AND status = ?
@@ -412,15 +429,75 @@ func (s *SQLiteStore) migrate(ctx context.Context) error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_memory_embeddings_agent_updated
 			ON memory_embeddings(agent_id, updated_at DESC)`,
+		`CREATE TABLE IF NOT EXISTS memory_entities (
+			id TEXT PRIMARY KEY,
+			agent_id TEXT NOT NULL,
+			name_key TEXT NOT NULL,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			UNIQUE(agent_id, name_key)
+		)`,
+		`CREATE TABLE IF NOT EXISTS memory_relations (
+			id TEXT PRIMARY KEY,
+			agent_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			relation TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			confidence REAL NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			UNIQUE(agent_id, source_id, relation, target_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_memory_relations_agent_source
+			ON memory_relations(agent_id, source_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_memory_relations_agent_target
+			ON memory_relations(agent_id, target_id)`,
 	}
 	for _, stmt := range stmts {
 		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
 			return fmt.Errorf("memory store: migrate: %w", err)
 		}
 	}
+	if err := s.ensureColumn(ctx, "memory_items", "provenance_json", `TEXT NOT NULL DEFAULT '{}'`); err != nil {
+		return fmt.Errorf("memory store: migrate: %w", err)
+	}
 	return nil
 }
 
+func (s *SQLiteStore) ensureColumn(ctx context.Context, table, column, definition string) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if strings.EqualFold(name, column) {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+column+` `+definition)
+	return err
+}
+
+func decodeProvenance(raw string) memory.Provenance {
+	var out memory.Provenance
+	if strings.TrimSpace(raw) == "" {
+		return out
+	}
+	_ = json.Unmarshal([]byte(raw), &out)
+	return out
+}
+
 func syncFTS(ctx context.Context, tx *sql.Tx, item memory.MemoryItem) error {
 	if _, err := tx.ExecContext(ctx, `DELETE FROM memory_fts WHERE id = ?`, item.ID); err != nil {
 		return err
@@ -436,6 +513,7 @@ func scanItems(rows *sql.Rows) ([]memory.MemoryItem, error) {
 	items := []memory.MemoryItem{}
 	for rows.Next() {
 		var item memory.MemoryItem
+		var provenanceJSON string
 		if err := rows.Scan(
 			&item.ID,
 			&item.AgentID,
@@ -445,11 +523,13 @@ func scanItems(rows *sql.Rows) ([]memory.MemoryItem, error) {
 			&item.Importance,
 			&item.Confidence,
 			&item.Status,
+			&provenanceJSON,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
+		item.Provenance = decodeProvenance(provenanceJSON)
 		items = append(items, item)
 	}
 	if err := rows.Err(); err != nil {
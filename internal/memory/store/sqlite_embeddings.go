@@ -40,6 +40,15 @@ func (s *SQLiteStore) UpsertEmbedding(ctx context.Context, memoryID, model strin
 	return err
 }
 
+func (s *SQLiteStore) DeleteEmbedding(ctx context.Context, memoryID string) error {
+	memoryID = strings.TrimSpace(memoryID)
+	if memoryID == "" {
+		return errors.New("memory store: memory id is required")
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM memory_embeddings WHERE memory_id = ? AND agent_id = ?`, memoryID, s.agentID)
+	return err
+}
+
 func (s *SQLiteStore) SearchByEmbedding(ctx context.Context, queryVector []float32, limit, minImportance int, status string) ([]memory.MemoryItem, error) {
 	if len(queryVector) == 0 {
 		return nil, nil
@@ -59,7 +68,7 @@ func (s *SQLiteStore) SearchByEmbedding(ctx context.Context, queryVector []float
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT m.id, m.agent_id, m.kind, m.title, m.content, m.importance, m.confidence, m.status, m.created_at, m.updated_at, e.vector_json
+		SELECT m.id, m.agent_id, m.kind, m.title, m.content, m.importance, m.confidence, m.status, m.provenance_json, m.created_at, m.updated_at, e.vector_json
 		FROM memory_items m
 		JOIN memory_embeddings e ON m.id = e.memory_id
 		WHERE m.agent_id = ?
@@ -82,6 +91,7 @@ func (s *SQLiteStore) SearchByEmbedding(ctx context.Context, queryVector []float
 	for rows.Next() {
 		var item memory.MemoryItem
 		var vectorJSON string
+		var provenanceJSON string
 		if err := rows.Scan(
 			&item.ID,
 			&item.AgentID,
@@ -91,12 +101,14 @@ func (s *SQLiteStore) SearchByEmbedding(ctx context.Context, queryVector []float
 			&item.Importance,
 			&item.Confidence,
 			&item.Status,
+			&provenanceJSON,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 			&vectorJSON,
 		); err != nil {
 			return nil, err
 		}
+		item.Provenance = decodeProvenance(provenanceJSON)
 		var vec []float32
 		if err := json.Unmarshal([]byte(vectorJSON), &vec); err != nil {
 			continue
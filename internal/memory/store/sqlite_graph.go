@@ -0,0 +1,419 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"openclawssy/internal/memory"
+)
+
+const maxEntityScan = 2000
+
+var graphIDSeq atomic.Uint64
+
+func (s *SQLiteStore) UpsertEntity(ctx context.Context, entity memory.Entity) (memory.Entity, error) {
+	if s == nil || s.db == nil {
+		return memory.Entity{}, errors.New("memory store: nil database")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return memory.Entity{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	saved, err := s.upsertEntityTx(ctx, tx, entity)
+	if err != nil {
+		return memory.Entity{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return memory.Entity{}, err
+	}
+	return saved, nil
+}
+
+func (s *SQLiteStore) Link(ctx context.Context, rel memory.Relation, sourceType, targetType string) (memory.Relation, error) {
+	if s == nil || s.db == nil {
+		return memory.Relation{}, errors.New("memory store: nil database")
+	}
+	rel = memory.NormalizeRelation(rel)
+	if rel.AgentID == "" {
+		rel.AgentID = s.agentID
+	}
+	if rel.AgentID != s.agentID {
+		return memory.Relation{}, errors.New("memory store: cross-agent write denied")
+	}
+	if rel.SourceName == "" || rel.TargetName == "" || rel.Relation == "" {
+		return memory.Relation{}, errors.New("memory store: source, relation, and target are required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return memory.Relation{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	source, err := s.upsertEntityTx(ctx, tx, memory.Entity{Name: rel.SourceName, Type: sourceType})
+	if err != nil {
+		return memory.Relation{}, err
+	}
+	target, err := s.upsertEntityTx(ctx, tx, memory.Entity{Name: rel.TargetName, Type: targetType})
+	if err != nil {
+		return memory.Relation{}, err
+	}
+
+	now := time.Now().UTC()
+	var existingID string
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, created_at FROM memory_relations
+		WHERE agent_id = ? AND source_id = ? AND relation = ? AND target_id = ?
+	`, s.agentID, source.ID, rel.Relation, target.ID).Scan(&existingID, &createdAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		existingID = newGraphID("rel")
+		createdAt = now
+	case err != nil:
+		return memory.Relation{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO memory_relations (id, agent_id, source_id, relation, target_id, confidence, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			confidence=excluded.confidence,
+			updated_at=excluded.updated_at
+	`, existingID, s.agentID, source.ID, rel.Relation, target.ID, rel.Confidence, createdAt, now); err != nil {
+		return memory.Relation{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return memory.Relation{}, err
+	}
+
+	rel.ID = existingID
+	rel.SourceID = source.ID
+	rel.SourceName = source.Name
+	rel.TargetID = target.ID
+	rel.TargetName = target.Name
+	rel.CreatedAt = createdAt
+	rel.UpdatedAt = now
+	return rel, nil
+}
+
+func (s *SQLiteStore) FindEntity(ctx context.Context, name string) (memory.Entity, bool, error) {
+	key := memory.EntityKey(name)
+	if key == "" {
+		return memory.Entity{}, false, errors.New("memory store: entity name is required")
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, agent_id, name, type, description, created_at, updated_at
+		FROM memory_entities
+		WHERE agent_id = ? AND name_key = ?
+		LIMIT 1
+	`, s.agentID, key)
+	var entity memory.Entity
+	if err := row.Scan(&entity.ID, &entity.AgentID, &entity.Name, &entity.Type, &entity.Description, &entity.CreatedAt, &entity.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return memory.Entity{}, false, nil
+		}
+		return memory.Entity{}, false, err
+	}
+	return entity, true, nil
+}
+
+func (s *SQLiteStore) ListEntities(ctx context.Context, limit int) ([]memory.Entity, error) {
+	if limit <= 0 || limit > maxEntityScan {
+		limit = maxEntityScan
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, agent_id, name, type, description, created_at, updated_at
+		FROM memory_entities
+		WHERE agent_id = ?
+		ORDER BY updated_at DESC
+		LIMIT ?
+	`, s.agentID, limit)
+	if err != nil {
+		if isNoSuchTable(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntities(rows)
+}
+
+func (s *SQLiteStore) MatchEntities(ctx context.Context, text string, limit int) ([]memory.Entity, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 8
+	}
+	entities, err := s.ListEntities(ctx, maxEntityScan)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]memory.Entity, 0, min(limit, len(entities)))
+	for _, entity := range entities {
+		if !memory.MentionsEntity(text, entity.Name) {
+			continue
+		}
+		out = append(out, entity)
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *SQLiteStore) RelationsForEntities(ctx context.Context, entityIDs []string, relation string, limit int) ([]memory.Relation, error) {
+	if len(entityIDs) == 0 {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(entityIDs)), ",")
+	args := make([]any, 0, len(entityIDs)*2+3)
+	args = append(args, s.agentID)
+	for _, id := range entityIDs {
+		args = append(args, id)
+	}
+	for _, id := range entityIDs {
+		args = append(args, id)
+	}
+	query := `
+		SELECT r.id, r.agent_id, r.source_id, src.name, r.relation, r.target_id, dst.name, r.confidence, r.created_at, r.updated_at
+		FROM memory_relations r
+		JOIN memory_entities src ON src.id = r.source_id
+		JOIN memory_entities dst ON dst.id = r.target_id
+		WHERE r.agent_id = ?
+		  AND (r.source_id IN (` + placeholders + `) OR r.target_id IN (` + placeholders + `))`
+	relation = memory.NormalizeRelationName(relation)
+	if relation != "" {
+		query += ` AND r.relation = ?`
+		args = append(args, relation)
+	}
+	query += ` ORDER BY r.confidence DESC, r.updated_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if isNoSuchTable(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRelations(rows)
+}
+
+func (s *SQLiteStore) QueryGraph(ctx context.Context, params memory.GraphQueryParams) (memory.GraphResult, error) {
+	params = memory.NormalizeGraphQueryParams(params)
+	if params.Entity == "" {
+		relations, err := s.recentRelations(ctx, params.Relation, params.Limit)
+		if err != nil {
+			return memory.GraphResult{}, err
+		}
+		return graphResultFromRelations(nil, relations), nil
+	}
+
+	root, found, err := s.FindEntity(ctx, params.Entity)
+	if err != nil {
+		return memory.GraphResult{}, err
+	}
+	if !found {
+		return memory.GraphResult{Entities: []memory.Entity{}, Relations: []memory.Relation{}}, nil
+	}
+
+	visited := map[string]bool{root.ID: true}
+	frontier := []string{root.ID}
+	seenRelations := map[string]bool{}
+	relations := []memory.Relation{}
+	for depth := 0; depth < params.Depth && len(frontier) > 0 && len(relations) < params.Limit; depth++ {
+		hop, err := s.RelationsForEntities(ctx, frontier, params.Relation, params.Limit-len(relations))
+		if err != nil {
+			return memory.GraphResult{}, err
+		}
+		next := []string{}
+		for _, rel := range hop {
+			if seenRelations[rel.ID] {
+				continue
+			}
+			seenRelations[rel.ID] = true
+			relations = append(relations, rel)
+			for _, id := range []string{rel.SourceID, rel.TargetID} {
+				if !visited[id] {
+					visited[id] = true
+					next = append(next, id)
+				}
+			}
+		}
+		frontier = next
+	}
+	return graphResultFromRelations(&root, relations), nil
+}
+
+func (s *SQLiteStore) recentRelations(ctx context.Context, relation string, limit int) ([]memory.Relation, error) {
+	query := `
+		SELECT r.id, r.agent_id, r.source_id, src.name, r.relation, r.target_id, dst.name, r.confidence, r.created_at, r.updated_at
+		FROM memory_relations r
+		JOIN memory_entities src ON src.id = r.source_id
+		JOIN memory_entities dst ON dst.id = r.target_id
+		WHERE r.agent_id = ?`
+	args := []any{s.agentID}
+	if relation != "" {
+		query += ` AND r.relation = ?`
+		args = append(args, relation)
+	}
+	query += ` ORDER BY r.updated_at DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRelations(rows)
+}
+
+func (s *SQLiteStore) upsertEntityTx(ctx context.Context, tx *sql.Tx, entity memory.Entity) (memory.Entity, error) {
+	explicitType := strings.TrimSpace(entity.Type) != ""
+	entity = memory.NormalizeEntity(entity)
+	if entity.AgentID == "" {
+		entity.AgentID = s.agentID
+	}
+	if entity.AgentID != s.agentID {
+		return memory.Entity{}, errors.New("memory store: cross-agent write denied")
+	}
+	key := memory.EntityKey(entity.Name)
+	if key == "" {
+		return memory.Entity{}, errors.New("memory store: entity name is required")
+	}
+
+	now := time.Now().UTC()
+	var existing memory.Entity
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, agent_id, name, type, description, created_at, updated_at
+		FROM memory_entities
+		WHERE agent_id = ? AND name_key = ?
+	`, s.agentID, key).Scan(&existing.ID, &existing.AgentID, &existing.Name, &existing.Type, &existing.Description, &existing.CreatedAt, &existing.UpdatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		entity.ID = newGraphID("ent")
+		entity.CreatedAt = now
+	case err != nil:
+		return memory.Entity{}, err
+	default:
+		entity.ID = existing.ID
+		entity.Name = existing.Name
+		entity.CreatedAt = existing.CreatedAt
+		if !explicitType {
+			entity.Type = existing.Type
+		}
+		if entity.Description == "" {
+			entity.Description = existing.Description
+		}
+	}
+	entity.UpdatedAt = now
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO memory_entities (id, agent_id, name_key, name, type, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			type=excluded.type,
+			description=excluded.description,
+			updated_at=excluded.updated_at
+	`, entity.ID, entity.AgentID, key, entity.Name, entity.Type, entity.Description, entity.CreatedAt, entity.UpdatedAt); err != nil {
+		return memory.Entity{}, err
+	}
+	return entity, nil
+}
+
+func (s *SQLiteStore) graphCounts(ctx context.Context) (int, int, error) {
+	var entities, relations int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_entities WHERE agent_id = ?`, s.agentID).Scan(&entities); err != nil {
+		if isNoSuchTable(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memory_relations WHERE agent_id = ?`, s.agentID).Scan(&relations); err != nil {
+		if isNoSuchTable(err) {
+			return entities, 0, nil
+		}
+		return entities, 0, err
+	}
+	return entities, relations, nil
+}
+
+func graphResultFromRelations(root *memory.Entity, relations []memory.Relation) memory.GraphResult {
+	entities := []memory.Entity{}
+	seen := map[string]bool{}
+	if root != nil {
+		entities = append(entities, *root)
+		seen[root.ID] = true
+	}
+	for _, rel := range relations {
+		if !seen[rel.SourceID] {
+			seen[rel.SourceID] = true
+			entities = append(entities, memory.Entity{ID: rel.SourceID, AgentID: rel.AgentID, Name: rel.SourceName})
+		}
+		if !seen[rel.TargetID] {
+			seen[rel.TargetID] = true
+			entities = append(entities, memory.Entity{ID: rel.TargetID, AgentID: rel.AgentID, Name: rel.TargetName})
+		}
+	}
+	if relations == nil {
+		relations = []memory.Relation{}
+	}
+	return memory.GraphResult{Entities: entities, Relations: relations}
+}
+
+func scanEntities(rows *sql.Rows) ([]memory.Entity, error) {
+	entities := []memory.Entity{}
+	for rows.Next() {
+		var entity memory.Entity
+		if err := rows.Scan(&entity.ID, &entity.AgentID, &entity.Name, &entity.Type, &entity.Description, &entity.CreatedAt, &entity.UpdatedAt); err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func scanRelations(rows *sql.Rows) ([]memory.Relation, error) {
+	relations := []memory.Relation{}
+	for rows.Next() {
+		var rel memory.Relation
+		if err := rows.Scan(
+			&rel.ID,
+			&rel.AgentID,
+			&rel.SourceID,
+			&rel.SourceName,
+			&rel.Relation,
+			&rel.TargetID,
+			&rel.TargetName,
+			&rel.Confidence,
+			&rel.CreatedAt,
+			&rel.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		relations = append(relations, rel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+func newGraphID(prefix string) string {
+	return fmt.Sprintf("%s_%d_%d", prefix, time.Now().UTC().UnixNano(), graphIDSeq.Add(1))
+}
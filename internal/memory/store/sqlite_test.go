@@ -155,3 +155,113 @@ func TestSQLiteStoreSearchByEmbedding(t *testing.T) {
 		t.Fatalf("expected item A to rank first, got %q", results[0].ID)
 	}
 }
+
+func TestSQLiteStoreGraphLinkAndQuery(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "memory.db"), "default")
+	if err != nil {
+		t.Fatalf("open sqlite store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	first, err := store.Link(ctx, memory.Relation{SourceName: "checkout-api", Relation: "Depends On", TargetName: "billing-db"}, "service", "database")
+	if err != nil {
+		t.Fatalf("link checkout-api: %v", err)
+	}
+	if first.Relation != "depends_on" {
+		t.Fatalf("expected normalized relation depends_on, got %q", first.Relation)
+	}
+	if _, err := store.Link(ctx, memory.Relation{SourceName: "billing-db", Relation: "owned_by", TargetName: "Payments Team"}, "", "team"); err != nil {
+		t.Fatalf("link billing-db: %v", err)
+	}
+	again, err := store.Link(ctx, memory.Relation{SourceName: "Checkout-API", Relation: "depends_on", TargetName: "billing-db", Confidence: 0.95}, "", "")
+	if err != nil {
+		t.Fatalf("relink checkout-api: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Fatalf("expected relink to reuse relation %q, got %q", first.ID, again.ID)
+	}
+
+	entity, found, err := store.FindEntity(ctx, "CHECKOUT-api")
+	if err != nil || !found {
+		t.Fatalf("find entity: found=%v err=%v", found, err)
+	}
+	if entity.Type != "service" {
+		t.Fatalf("expected relink without type to keep service type, got %q", entity.Type)
+	}
+
+	oneHop, err := store.QueryGraph(ctx, memory.GraphQueryParams{Entity: "checkout-api", Depth: 1})
+	if err != nil {
+		t.Fatalf("query graph depth 1: %v", err)
+	}
+	if len(oneHop.Relations) != 1 {
+		t.Fatalf("expected one relation at depth 1, got %+v", oneHop.Relations)
+	}
+	twoHop, err := store.QueryGraph(ctx, memory.GraphQueryParams{Entity: "checkout-api", Depth: 2})
+	if err != nil {
+		t.Fatalf("query graph depth 2: %v", err)
+	}
+	if len(twoHop.Relations) != 2 {
+		t.Fatalf("expected two relations at depth 2, got %+v", twoHop.Relations)
+	}
+
+	matched, err := store.MatchEntities(ctx, "why is checkout-api slow today?", 5)
+	if err != nil {
+		t.Fatalf("match entities: %v", err)
+	}
+	if len(matched) != 1 || matched[0].Name != "checkout-api" {
+		t.Fatalf("expected checkout-api to match, got %+v", matched)
+	}
+
+	health, err := store.Health(ctx)
+	if err != nil {
+		t.Fatalf("health: %v", err)
+	}
+	if health.EntityCount != 3 || health.RelationCount != 2 {
+		t.Fatalf("expected 3 entities and 2 relations, got %+v", health)
+	}
+}
+
+func TestSQLiteStorePersistsProvenance(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "memory.db")
+
+	store, err := OpenSQLite(dbPath, "default")
+	if err != nil {
+		t.Fatalf("open sqlite store: %v", err)
+	}
+	item, err := store.Upsert(ctx, memory.MemoryItem{
+		Kind:       "decision",
+		Title:      "Deploy window",
+		Content:    "Deploys happen on Tuesdays.",
+		Importance: 4,
+		Confidence: 0.9,
+		Provenance: memory.Provenance{Source: "checkpoint", EventIDs: []string{"evt_1"}, RunIDs: []string{"run_1"}, SessionIDs: []string{"sess_1"}},
+	})
+	if err != nil {
+		t.Fatalf("upsert item: %v", err)
+	}
+	_ = store.Close()
+
+	store, err = OpenSQLite(dbPath, "default")
+	if err != nil {
+		t.Fatalf("reopen sqlite store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	got, ok, err := store.Get(ctx, item.ID)
+	if err != nil || !ok {
+		t.Fatalf("get item: ok=%v err=%v", ok, err)
+	}
+	if got.Provenance.Source != "checkpoint" || len(got.Provenance.EventIDs) != 1 || got.Provenance.RunIDs[0] != "run_1" || got.Provenance.SessionIDs[0] != "sess_1" {
+		t.Fatalf("unexpected provenance: %#v", got.Provenance)
+	}
+
+	results, err := store.Search(ctx, memory.SearchParams{Query: "tuesdays", Limit: 5})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || results[0].Provenance.Source != "checkpoint" {
+		t.Fatalf("expected provenance on search results, got %#v", results)
+	}
+}
@@ -0,0 +1,289 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ArgRule constrains the arguments an agent may pass to a granted tool. Tool
+// is a canonical tool name or a `prefix.*` family. Every non-empty constraint
+// must match the call; when several rules cover the same tool, any one of them
+// matching is enough.
+//
+//   - Paths are workspace-relative globs where `*` stays within one path
+//     segment and `**` spans segments (`docs/**`).
+//   - Domains are host names; `*.example.com` matches any subdomain.
+//   - Commands match the shell.exec command line; `*` matches any run of
+//     characters except shell control characters.
+type ArgRule struct {
+	Tool     string   `json:"tool"`
+	Paths    []string `json:"paths,omitempty"`
+	Domains  []string `json:"domains,omitempty"`
+	Commands []string `json:"commands,omitempty"`
+}
+
+type ArgError struct {
+	AgentID string
+	Tool    string
+	Reason  string
+}
+
+func (e *ArgError) Error() string {
+	return fmt.Sprintf("argument denied: agent=%q tool=%q: %s", e.AgentID, e.Tool, e.Reason)
+}
+
+// shellControlChars never match a `*` in command rules or a policy shell
+// prefix, so an allowed command cannot be chained with another.
+const shellControlChars = ";&|`$()<>\n"
+
+// pathArgKeys are the arguments treated as workspace paths by path rules.
+var pathArgKeys = []string{"path", "src", "dst", "dir", "file"}
+
+func (r ArgRule) Validate() error {
+	tool := strings.TrimSpace(r.Tool)
+	if tool == "" {
+		return errors.New("rule tool is required")
+	}
+	if len(r.Paths) == 0 && len(r.Domains) == 0 && len(r.Commands) == 0 {
+		return fmt.Errorf("rule for %s needs paths, domains or commands", tool)
+	}
+	for _, group := range [][]string{r.Paths, r.Domains, r.Commands} {
+		for _, pattern := range group {
+			if strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("rule for %s has an empty pattern", tool)
+			}
+		}
+	}
+	return nil
+}
+
+func (r ArgRule) appliesTo(tool string) bool {
+	ruleTool := strings.TrimSpace(r.Tool)
+	if strings.HasSuffix(ruleTool, ".*") {
+		return strings.HasPrefix(tool, strings.TrimSuffix(ruleTool, "*"))
+	}
+	return CanonicalCapability(ruleTool) == tool
+}
+
+// SetArgRules replaces the argument rules for agentID.
+func (e *Enforcer) SetArgRules(agentID string, rules []ArgRule) {
+	if strings.TrimSpace(agentID) == "" {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ArgRules == nil {
+		e.ArgRules = map[string][]ArgRule{}
+	}
+	e.ArgRules[agentID] = append([]ArgRule(nil), rules...)
+}
+
+func (e *Enforcer) ListArgRules(agentID string) []ArgRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]ArgRule(nil), e.ArgRules[agentID]...)
+}
+
+// Step is one check in a policy evaluation, kept so denials and dry runs can
+// explain which rule decided the call.
+type Step struct {
+	Check   string `json:"check"`
+	Allowed bool   `json:"allowed"`
+	Detail  string `json:"detail"`
+}
+
+// CheckArgs enforces the document limits and the agent's argument rules for
+// tool. Tools without rules are unconstrained beyond CheckTool and the path
+// guards.
+func (e *Enforcer) CheckArgs(agentID, tool, workspace string, args map[string]any) error {
+	for _, step := range e.explainArgs(agentID, tool, workspace, args, "rules") {
+		if !step.Allowed {
+			return &ArgError{AgentID: agentID, Tool: canonicalCapabilityTool(tool), Reason: step.Detail}
+		}
+	}
+	return nil
+}
+
+// explainArgs returns the limit and rule steps for a call; label names the
+// rule list in explanations (for example `agents.ops.rules`).
+func (e *Enforcer) explainArgs(agentID, tool, workspace string, args map[string]any, label string) []Step {
+	canonical := canonicalCapabilityTool(tool)
+	e.mu.RLock()
+	rules := e.ArgRules[agentID]
+	limits := e.Limits
+	e.mu.RUnlock()
+
+	steps := limits.steps(canonical, args)
+	var reasons []string
+	matched := false
+	for i, rule := range rules {
+		if !rule.appliesTo(canonical) {
+			continue
+		}
+		matched = true
+		reason := rule.check(canonical, workspace, args)
+		if reason == "" {
+			return append(steps, Step{Check: "rule", Allowed: true, Detail: fmt.Sprintf("%s[%d] (%s) matched", label, i, rule.describe())})
+		}
+		reasons = append(reasons, reason)
+	}
+	if !matched {
+		return steps
+	}
+	return append(steps, Step{Check: "rule", Detail: strings.Join(reasons, "; ")})
+}
+
+func (r ArgRule) describe() string {
+	parts := []string{"tool " + strings.TrimSpace(r.Tool)}
+	if len(r.Paths) > 0 {
+		parts = append(parts, "paths "+strings.Join(r.Paths, ", "))
+	}
+	if len(r.Domains) > 0 {
+		parts = append(parts, "domains "+strings.Join(r.Domains, ", "))
+	}
+	if len(r.Commands) > 0 {
+		parts = append(parts, "commands "+strings.Join(r.Commands, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (r ArgRule) check(tool, workspace string, args map[string]any) string {
+	if len(r.Paths) > 0 {
+		found := false
+		for _, key := range pathArgKeys {
+			raw, ok := args[key].(string)
+			if !ok || strings.TrimSpace(raw) == "" {
+				continue
+			}
+			found = true
+			rel := workspaceRelative(workspace, raw)
+			if !matchAny(r.Paths, rel, matchPathGlob) {
+				return fmt.Sprintf("%s %q is outside allowed paths %s", key, rel, strings.Join(r.Paths, ", "))
+			}
+		}
+		if !found {
+			return "no path argument to check against allowed paths " + strings.Join(r.Paths, ", ")
+		}
+	}
+	if len(r.Domains) > 0 {
+		host := argHost(args)
+		if host == "" {
+			return "no url argument to check against allowed domains " + strings.Join(r.Domains, ", ")
+		}
+		if !matchAny(r.Domains, host, matchDomain) {
+			return fmt.Sprintf("host %q is not in allowed domains %s", host, strings.Join(r.Domains, ", "))
+		}
+	}
+	if len(r.Commands) > 0 {
+		line := CommandLine(args)
+		if !matchAny(r.Commands, line, matchCommand) {
+			return fmt.Sprintf("command %q is not in allowed commands %s", line, strings.Join(r.Commands, ", "))
+		}
+	}
+	return ""
+}
+
+// CommandLine renders shell.exec arguments as one command line. A `bash -lc`
+// or `sh -c` wrapper is unwrapped so rules match the script itself.
+func CommandLine(args map[string]any) string {
+	command, _ := args["command"].(string)
+	var rest []string
+	switch raw := args["args"].(type) {
+	case []any:
+		for _, item := range raw {
+			rest = append(rest, fmt.Sprintf("%v", item))
+		}
+	case []string:
+		rest = append(rest, raw...)
+	}
+	base := path.Base(strings.TrimSpace(command))
+	if (base == "bash" || base == "sh") && len(rest) == 2 && (rest[0] == "-c" || rest[0] == "-lc") {
+		return strings.TrimSpace(rest[1])
+	}
+	return strings.TrimSpace(strings.Join(append([]string{strings.TrimSpace(command)}, rest...), " "))
+}
+
+func workspaceRelative(workspace, target string) string {
+	target = strings.TrimSpace(target)
+	if filepath.IsAbs(target) && workspace != "" {
+		if rel, err := filepath.Rel(workspace, target); err == nil {
+			target = rel
+		}
+	}
+	cleaned := path.Clean(filepath.ToSlash(target))
+	return strings.TrimPrefix(cleaned, "./")
+}
+
+func argHost(args map[string]any) string {
+	raw, _ := args["url"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return ""
+	}
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+func matchAny(patterns []string, value string, match func(pattern, value string) bool) bool {
+	for _, pattern := range patterns {
+		if match(strings.TrimSpace(pattern), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:]) && len(host) > len(pattern)-1
+	}
+	return pattern == host
+}
+
+func matchPathGlob(pattern, target string) bool {
+	pattern = strings.TrimPrefix(path.Clean(filepath.ToSlash(pattern)), "./")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "/**"):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(target)
+}
+
+func matchCommand(pattern, line string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, part := range strings.SplitAfter(pattern, "*") {
+		literal := strings.TrimSuffix(part, "*")
+		b.WriteString(regexp.QuoteMeta(literal))
+		if strings.HasSuffix(part, "*") {
+			b.WriteString("[^" + regexp.QuoteMeta(shellControlChars) + "]*")
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(line)
+}
@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckArgsPathsDomainsAndCommands(t *testing.T) {
+	ws := t.TempDir()
+	e := NewEnforcer(ws, map[string][]string{
+		"docs": {"fs.write"},
+		"ops":  {"http.request", "shell.exec"},
+	})
+	e.SetArgRules("docs", []ArgRule{{Tool: "fs.write", Paths: []string{"docs/**"}}})
+	e.SetArgRules("ops", []ArgRule{
+		{Tool: "http.request", Domains: []string{"*.internal"}},
+		{Tool: "shell.exec", Commands: []string{"go test ./..."}},
+		{Tool: "shell.exec", Commands: []string{"git log *"}},
+	})
+
+	allowed := []struct {
+		agent string
+		tool  string
+		args  map[string]any
+	}{
+		{"docs", "fs.write", map[string]any{"path": "docs/guide/intro.md"}},
+		{"docs", "fs.write", map[string]any{"path": filepath.Join(ws, "docs", "a.md")}},
+		{"docs", "fs.read", map[string]any{"path": "src/main.go"}},
+		{"ops", "http.request", map[string]any{"url": "https://api.svc.internal/v1"}},
+		{"ops", "shell.exec", map[string]any{"command": "go", "args": []any{"test", "./..."}}},
+		{"ops", "shell.exec", map[string]any{"command": "bash", "args": []any{"-lc", "go test ./..."}}},
+		{"ops", "shell.exec", map[string]any{"command": "git", "args": []any{"log", "--oneline"}}},
+	}
+	for _, tc := range allowed {
+		if err := e.CheckArgs(tc.agent, tc.tool, ws, tc.args); err != nil {
+			t.Fatalf("expected %s %s %v allowed, got %v", tc.agent, tc.tool, tc.args, err)
+		}
+	}
+
+	denied := []struct {
+		agent string
+		tool  string
+		args  map[string]any
+		want  string
+	}{
+		{"docs", "fs.write", map[string]any{"path": "src/x.go"}, `path "src/x.go" is outside allowed paths docs/**`},
+		{"docs", "fs.write", map[string]any{"path": "docs/../src/x.go"}, `"src/x.go"`},
+		{"ops", "http.request", map[string]any{"url": "https://example.com"}, `host "example.com" is not in allowed domains *.internal`},
+		{"ops", "http.request", map[string]any{"url": "https://internal"}, "allowed domains"},
+		{"ops", "shell.exec", map[string]any{"command": "rm", "args": []any{"-rf", "/"}}, "allowed commands go test ./..."},
+		{"ops", "shell.exec", map[string]any{"command": "bash", "args": []any{"-lc", "git log x; rm -rf /"}}, "git log *"},
+	}
+	for _, tc := range denied {
+		err := e.CheckArgs(tc.agent, tc.tool, ws, tc.args)
+		var argErr *ArgError
+		if !errors.As(err, &argErr) {
+			t.Fatalf("expected ArgError for %s %v, got %v", tc.tool, tc.args, err)
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected %q in %q", tc.want, err.Error())
+		}
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		target  string
+		want    bool
+	}{
+		{"docs/**", "docs", true},
+		{"docs/**", "docs/a/b.md", true},
+		{"docs/**", "docsx/a.md", false},
+		{"docs/*.md", "docs/a.md", true},
+		{"docs/*.md", "docs/a/b.md", false},
+		{"**/*.go", "cmd/main.go", true},
+	}
+	for _, tc := range cases {
+		if got := matchPathGlob(tc.pattern, tc.target); got != tc.want {
+			t.Fatalf("matchPathGlob(%q, %q) = %v, want %v", tc.pattern, tc.target, got, tc.want)
+		}
+	}
+}
+
+func TestSaveGrantsPreservesArgRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capabilities.json")
+	raw := `{"agents":{"docs":["fs.write"]},"rules":{"docs":[{"tool":"fs.write","paths":["docs/**"]}]}}`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := SaveGrants(path, map[string][]string{"docs": {"fs.write", "fs.read"}}); err != nil {
+		t.Fatalf("save grants: %v", err)
+	}
+	rules, err := LoadArgRules(path)
+	if err != nil {
+		t.Fatalf("load rules: %v", err)
+	}
+	if len(rules["docs"]) != 1 || rules["docs"][0].Paths[0] != "docs/**" {
+		t.Fatalf("expected rules preserved, got %#v", rules)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"rules":{"docs":[{"tool":"fs.write"}]}}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadArgRules(path); err == nil {
+		t.Fatalf("expected rule without constraints to be rejected")
+	}
+}
@@ -30,7 +30,14 @@ func (e *PathError) Error() string {
 type Enforcer struct {
 	Workspace    string
 	Capabilities map[string]map[string]bool
-	mu           sync.RWMutex
+	// Approvals lists, per agent, granted tools whose calls need an
+	// operator decision before they run.
+	Approvals map[string]map[string]bool
+	// ArgRules constrains the arguments of granted tools per agent.
+	ArgRules map[string][]ArgRule
+	// Limits holds document-wide network, shell and sandbox constraints.
+	Limits Limits
+	mu     sync.RWMutex
 }
 
 func NewEnforcer(workspace string, grants map[string][]string) *Enforcer {
@@ -98,6 +105,35 @@ func (e *Enforcer) SetCapabilities(agentID string, capabilities []string) {
 	e.Capabilities[agentID] = set
 }
 
+// SetApprovalRequired puts tools in ask mode for agentID, replacing any
+// previous set. CheckTool still decides whether the agent holds the grant.
+func (e *Enforcer) SetApprovalRequired(agentID string, tools []string) {
+	if strings.TrimSpace(agentID) == "" {
+		return
+	}
+	set := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		canonical := canonicalCapabilityTool(tool)
+		if canonical == "" {
+			continue
+		}
+		set[canonical] = true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Approvals == nil {
+		e.Approvals = map[string]map[string]bool{}
+	}
+	e.Approvals[agentID] = set
+}
+
+func (e *Enforcer) RequiresApproval(agentID, tool string) bool {
+	canonical := canonicalCapabilityTool(tool)
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Approvals[agentID][canonical]
+}
+
 func (e *Enforcer) ListCapabilities(agentID string) []string {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
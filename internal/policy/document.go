@@ -0,0 +1,245 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// DocumentVersion is the only policy document version this build accepts.
+const DocumentVersion = 1
+
+// Document is the declarative policy file (.openclawssy/policy/policy.json).
+// It can only narrow what config and grants already allow: agents listed here
+// replace their persisted grants and argument rules, and the network, shell
+// and sandbox sections add limits on top of the matching config settings.
+type Document struct {
+	Version int                    `json:"version"`
+	Agents  map[string]AgentPolicy `json:"agents,omitempty"`
+	Network *NetworkPolicy         `json:"network,omitempty"`
+	Shell   *ShellPolicy           `json:"shell,omitempty"`
+	Sandbox *SandboxPolicy         `json:"sandbox,omitempty"`
+}
+
+type AgentPolicy struct {
+	Capabilities []string  `json:"capabilities"`
+	Rules        []ArgRule `json:"rules,omitempty"`
+}
+
+// NetworkPolicy limits http.request hosts in addition to network.allowed_domains.
+type NetworkPolicy struct {
+	AllowedDomains  []string `json:"allowed_domains"`
+	AllowLocalhosts bool     `json:"allow_localhosts,omitempty"`
+}
+
+// ShellPolicy limits shell.exec command prefixes in addition to
+// shell.allowed_commands.
+type ShellPolicy struct {
+	AllowedCommands []string `json:"allowed_commands"`
+}
+
+// SandboxPolicy lists tools that may only run while a sandbox is active.
+type SandboxPolicy struct {
+	RequiredFor []string `json:"required_for"`
+}
+
+// LoadDocument reads a policy document. A missing file returns nil without an
+// error; a present but invalid file is an error so policy never fails open.
+func LoadDocument(path string) (*Document, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var doc Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("policy document %s: %w", path, err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("policy document %s: %w", path, err)
+	}
+	return &doc, nil
+}
+
+func (d Document) Validate() error {
+	if d.Version != DocumentVersion {
+		return fmt.Errorf("unsupported version %d (want %d)", d.Version, DocumentVersion)
+	}
+	for agentID, agent := range d.Agents {
+		if strings.TrimSpace(agentID) == "" {
+			return errors.New("agents: empty agent id")
+		}
+		for _, capability := range agent.Capabilities {
+			if strings.TrimSpace(capability) == "" {
+				return fmt.Errorf("agents.%s.capabilities: empty capability", agentID)
+			}
+		}
+		for i, rule := range agent.Rules {
+			if err := rule.Validate(); err != nil {
+				return fmt.Errorf("agents.%s.rules[%d]: %w", agentID, i, err)
+			}
+		}
+	}
+	if d.Network != nil {
+		for _, domain := range d.Network.AllowedDomains {
+			if strings.TrimSpace(domain) == "" {
+				return errors.New("network.allowed_domains: empty domain")
+			}
+		}
+	}
+	if d.Shell != nil {
+		for _, command := range d.Shell.AllowedCommands {
+			if strings.TrimSpace(command) == "" {
+				return errors.New("shell.allowed_commands: empty command")
+			}
+		}
+	}
+	if d.Sandbox != nil {
+		for _, tool := range d.Sandbox.RequiredFor {
+			if strings.TrimSpace(tool) == "" {
+				return errors.New("sandbox.required_for: empty tool")
+			}
+		}
+	}
+	return nil
+}
+
+// Limits returns the document-wide limits to install on an Enforcer.
+func (d *Document) Limits(sandboxActive bool) Limits {
+	if d == nil {
+		return Limits{SandboxActive: sandboxActive}
+	}
+	limits := Limits{Network: d.Network, Shell: d.Shell, SandboxActive: sandboxActive}
+	if d.Sandbox != nil {
+		limits.SandboxRequiredFor = d.Sandbox.RequiredFor
+	}
+	return limits
+}
+
+// Limits are tool-independent constraints from a policy document, checked by
+// CheckArgs alongside the agent's argument rules.
+type Limits struct {
+	Network            *NetworkPolicy
+	Shell              *ShellPolicy
+	SandboxRequiredFor []string
+	SandboxActive      bool
+}
+
+// SetLimits replaces the document-wide limits.
+func (e *Enforcer) SetLimits(limits Limits) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Limits = limits
+}
+
+func (l Limits) steps(tool string, args map[string]any) []Step {
+	var steps []Step
+	for _, required := range l.SandboxRequiredFor {
+		if canonicalCapabilityTool(required) != tool {
+			continue
+		}
+		if l.SandboxActive {
+			steps = append(steps, Step{Check: "sandbox", Allowed: true, Detail: "sandbox.required_for lists " + tool + " and the sandbox is active"})
+		} else {
+			steps = append(steps, Step{Check: "sandbox", Detail: "sandbox.required_for lists " + tool + " but no sandbox is active"})
+		}
+		break
+	}
+	if l.Network != nil && tool == "http.request" {
+		steps = append(steps, l.Network.step(argHost(args)))
+	}
+	if l.Shell != nil && tool == "shell.exec" {
+		steps = append(steps, l.Shell.step(CommandLine(args)))
+	}
+	return steps
+}
+
+func (n NetworkPolicy) step(host string) Step {
+	if host == "" {
+		return Step{Check: "network", Detail: "no url host to check against policy network.allowed_domains"}
+	}
+	if IsLocalhost(host) {
+		if n.AllowLocalhosts {
+			return Step{Check: "network", Allowed: true, Detail: fmt.Sprintf("host %q is allowed by policy network.allow_localhosts", host)}
+		}
+		return Step{Check: "network", Detail: fmt.Sprintf("host %q is localhost and policy network.allow_localhosts is false", host)}
+	}
+	if domain, ok := MatchDomainList(host, n.AllowedDomains); ok {
+		return Step{Check: "network", Allowed: true, Detail: fmt.Sprintf("host %q is allowed by policy network.allowed_domains %q", host, domain)}
+	}
+	return Step{Check: "network", Detail: fmt.Sprintf("host %q is not in policy network.allowed_domains", host)}
+}
+
+func (s ShellPolicy) step(line string) Step {
+	if strings.ContainsAny(line, shellControlChars) && !containsString(s.AllowedCommands, "*") {
+		return Step{Check: "shell", Detail: fmt.Sprintf("command %q contains shell control characters, which policy shell.allowed_commands prefixes never allow", line)}
+	}
+	if prefix, ok := MatchCommandPrefix(line, s.AllowedCommands); ok {
+		return Step{Check: "shell", Allowed: true, Detail: fmt.Sprintf("command %q is allowed by policy shell.allowed_commands %q", line, prefix)}
+	}
+	return Step{Check: "shell", Detail: fmt.Sprintf("command %q is not in policy shell.allowed_commands", line)}
+}
+
+// MatchDomainList reports the allowlist entry that admits host, using the same
+// rules as network.allowed_domains: an entry matches itself and its
+// subdomains, with an optional leading `*.` or `.`.
+func MatchDomainList(host string, domains []string) (string, bool) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	for _, raw := range domains {
+		candidate := strings.ToLower(strings.TrimSpace(raw))
+		candidate = strings.TrimPrefix(strings.TrimPrefix(candidate, "*."), ".")
+		if candidate == "" {
+			continue
+		}
+		if host == candidate || strings.HasSuffix(host, "."+candidate) {
+			return raw, true
+		}
+	}
+	return "", false
+}
+
+// MatchCommandPrefix reports the allowlist prefix that admits a command line,
+// using the same word-boundary rules as shell.allowed_commands.
+func MatchCommandPrefix(line string, prefixes []string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", false
+	}
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if prefix == "*" || line == prefix || strings.HasPrefix(line, prefix+" ") {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func IsLocalhost(host string) bool {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func containsString(items []string, want string) bool {
+	for _, item := range items {
+		if strings.TrimSpace(item) == want {
+			return true
+		}
+	}
+	return false
+}
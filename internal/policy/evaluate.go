@@ -0,0 +1,310 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment is what config contributes to a policy decision: the tools it
+// enables and the global network, shell and sandbox settings.
+// GrantOnlyCapabilities are enabled tools that no agent gets by default, such
+// as mounted MCP tools; they are usable only through an explicit grant.
+type Environment struct {
+	Workspace             string
+	DefaultCapabilities   []string
+	GrantOnlyCapabilities []string
+	NetworkEnabled        bool
+	AllowedDomains        []string
+	AllowLocalhosts       bool
+	ShellEnabled          bool
+	ShellAllowedCommands  []string
+	SandboxActive         bool
+}
+
+// Evaluator combines config, persisted grants and rules, and an optional
+// policy document. The runtime builds its enforcer from it, and dry runs use
+// the same code path to explain decisions.
+type Evaluator struct {
+	Env      Environment
+	Grants   map[string][]string
+	Rules    map[string][]ArgRule
+	Document *Document
+}
+
+// Capabilities returns the agent's effective capabilities and where they came
+// from: "policy", "persisted" or "default". Grants never add tools that config
+// leaves disabled, except policy.admin. A grant ending in ".*" covers every
+// grant-only tool with that prefix, so "mcp.docs.*" grants a whole server.
+func (ev Evaluator) Capabilities(agentID string) ([]string, string) {
+	base := append([]string(nil), ev.Env.DefaultCapabilities...)
+	if agentID == "default" {
+		base = append(base, "policy.admin")
+	}
+	base = NormalizeCapabilities(base)
+
+	var stored []string
+	source := "default"
+	if agent, ok := ev.documentAgent(agentID); ok {
+		stored, source = agent.Capabilities, "policy"
+	} else if grants, ok := ev.Grants[agentID]; ok {
+		stored, source = grants, "persisted"
+	} else {
+		return base, source
+	}
+
+	allowed := make(map[string]bool, len(ev.Env.DefaultCapabilities)+len(ev.Env.GrantOnlyCapabilities))
+	for _, tool := range ev.Env.DefaultCapabilities {
+		if canonical := CanonicalCapability(tool); canonical != "" {
+			allowed[canonical] = true
+		}
+	}
+	grantOnly := NormalizeCapabilities(ev.Env.GrantOnlyCapabilities)
+	for _, tool := range grantOnly {
+		allowed[tool] = true
+	}
+	out := make([]string, 0, len(stored))
+	for _, capability := range stored {
+		canonical := CanonicalCapability(capability)
+		if canonical == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(canonical, "*"); ok && strings.HasSuffix(prefix, ".") {
+			for _, tool := range grantOnly {
+				if strings.HasPrefix(tool, prefix) {
+					out = append(out, tool)
+				}
+			}
+			continue
+		}
+		if canonical == "policy.admin" || allowed[canonical] {
+			out = append(out, canonical)
+		}
+	}
+	return NormalizeCapabilities(out), source
+}
+
+// ArgRules returns the agent's argument rules and the label used to cite them.
+func (ev Evaluator) ArgRules(agentID string) ([]ArgRule, string) {
+	if agent, ok := ev.documentAgent(agentID); ok {
+		return agent.Rules, "agents." + agentID + ".rules"
+	}
+	return ev.Rules[agentID], "rules." + agentID
+}
+
+// ManagedByDocument reports whether the policy document defines agentID, in
+// which case persisted grants for it are ignored.
+func (ev Evaluator) ManagedByDocument(agentID string) bool {
+	_, ok := ev.documentAgent(agentID)
+	return ok
+}
+
+func (ev Evaluator) documentAgent(agentID string) (AgentPolicy, bool) {
+	if ev.Document == nil {
+		return AgentPolicy{}, false
+	}
+	agent, ok := ev.Document.Agents[agentID]
+	return agent, ok
+}
+
+// Enforcer builds the enforcer a run for agentID uses.
+func (ev Evaluator) Enforcer(agentID string) *Enforcer {
+	caps, _ := ev.Capabilities(agentID)
+	rules, _ := ev.ArgRules(agentID)
+	enforcer := NewEnforcer(ev.Env.Workspace, map[string][]string{agentID: caps})
+	enforcer.SetArgRules(agentID, rules)
+	enforcer.SetLimits(ev.Document.Limits(ev.Env.SandboxActive))
+	return enforcer
+}
+
+// Call is a hypothetical tool call to evaluate.
+type Call struct {
+	AgentID string         `json:"agent"`
+	Tool    string         `json:"tool"`
+	Args    map[string]any `json:"args,omitempty"`
+}
+
+type Decision struct {
+	AgentID string `json:"agent"`
+	Tool    string `json:"tool"`
+	Allowed bool   `json:"allowed"`
+	Steps   []Step `json:"steps"`
+}
+
+// Reason is the detail of the step that denied the call, or of the last step
+// when it was allowed.
+func (d Decision) Reason() string {
+	for _, step := range d.Steps {
+		if !step.Allowed {
+			return step.Detail
+		}
+	}
+	if len(d.Steps) == 0 {
+		return ""
+	}
+	return d.Steps[len(d.Steps)-1].Detail
+}
+
+// Evaluate decides a call the way Registry.Execute would, stopping at the
+// first denying step. Path guards and approvals are not part of the result.
+func (ev Evaluator) Evaluate(call Call) Decision {
+	tool := CanonicalCapability(call.Tool)
+	decision := Decision{AgentID: call.AgentID, Tool: tool}
+	add := func(step Step) bool {
+		decision.Steps = append(decision.Steps, step)
+		return step.Allowed
+	}
+
+	caps, source := ev.Capabilities(call.AgentID)
+	if !containsString(caps, tool) {
+		add(Step{Check: "capability", Detail: fmt.Sprintf("agent %q is not granted %s (%s capabilities)", call.AgentID, tool, source)})
+		return decision
+	}
+	if !add(Step{Check: "capability", Allowed: true, Detail: fmt.Sprintf("agent %q is granted %s (%s capabilities)", call.AgentID, tool, source)}) {
+		return decision
+	}
+
+	for _, step := range ev.configSteps(tool, call.Args) {
+		if !add(step) {
+			return decision
+		}
+	}
+
+	rules, label := ev.ArgRules(call.AgentID)
+	enforcer := ev.Enforcer(call.AgentID)
+	steps := enforcer.explainArgs(call.AgentID, tool, ev.Env.Workspace, call.Args, label)
+	hasRule := false
+	for _, step := range steps {
+		hasRule = hasRule || step.Check == "rule"
+		if !add(step) {
+			return decision
+		}
+	}
+	if !hasRule {
+		detail := "no argument rules cover " + tool
+		if len(rules) > 0 {
+			detail = fmt.Sprintf("no %s entry covers %s", label, tool)
+		}
+		add(Step{Check: "rule", Allowed: true, Detail: detail})
+	}
+	decision.Allowed = true
+	return decision
+}
+
+// configSteps mirrors the checks tool handlers make against config.
+func (ev Evaluator) configSteps(tool string, args map[string]any) []Step {
+	switch tool {
+	case "http.request":
+		if !ev.Env.NetworkEnabled {
+			return []Step{{Check: "config", Detail: "network.enabled is false"}}
+		}
+		host := argHost(args)
+		switch {
+		case host == "":
+			return []Step{{Check: "config", Detail: "url host is required"}}
+		case IsLocalhost(host) && !ev.Env.AllowLocalhosts:
+			return []Step{{Check: "config", Detail: fmt.Sprintf("host %q is localhost and network.allow_localhosts is false", host)}}
+		case IsLocalhost(host):
+			return []Step{{Check: "config", Allowed: true, Detail: fmt.Sprintf("host %q is allowed by network.allow_localhosts", host)}}
+		}
+		domain, ok := MatchDomainList(host, ev.Env.AllowedDomains)
+		if !ok {
+			return []Step{{Check: "config", Detail: fmt.Sprintf("host %q is not in network.allowed_domains", host)}}
+		}
+		return []Step{{Check: "config", Allowed: true, Detail: fmt.Sprintf("host %q is allowed by network.allowed_domains %q", host, domain)}}
+	case "shell.exec":
+		if !ev.Env.ShellEnabled {
+			return []Step{{Check: "config", Detail: "shell.enable_exec is false or no sandbox is active"}}
+		}
+		invocation := rawCommandLine(args)
+		prefix, ok := MatchCommandPrefix(invocation, ev.Env.ShellAllowedCommands)
+		if !ok {
+			return []Step{{Check: "config", Detail: fmt.Sprintf("command %q is not in shell.allowed_commands", invocation)}}
+		}
+		return []Step{{Check: "config", Allowed: true, Detail: fmt.Sprintf("command %q is allowed by shell.allowed_commands %q", invocation, prefix)}}
+	}
+	return nil
+}
+
+// rawCommandLine joins command and args without unwrapping shells, matching
+// how shell.exec checks shell.allowed_commands.
+func rawCommandLine(args map[string]any) string {
+	command, _ := args["command"].(string)
+	parts := []string{strings.TrimSpace(command)}
+	switch raw := args["args"].(type) {
+	case []any:
+		for _, item := range raw {
+			parts = append(parts, fmt.Sprintf("%v", item))
+		}
+	case []string:
+		parts = append(parts, raw...)
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// Fixture is a file of policy regression cases, kept next to the policy so
+// `openclawssy policy check` can run them in CI.
+type Fixture struct {
+	Cases []FixtureCase `json:"cases"`
+}
+
+// FixtureCase expects a call to be allowed or denied. When Reason is set the
+// deciding step's detail must contain it.
+type FixtureCase struct {
+	Name   string         `json:"name"`
+	Agent  string         `json:"agent"`
+	Tool   string         `json:"tool"`
+	Args   map[string]any `json:"args,omitempty"`
+	Expect string         `json:"expect"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+type FixtureResult struct {
+	Case     FixtureCase
+	Decision Decision
+	Passed   bool
+	Message  string
+}
+
+func LoadFixture(path string) (Fixture, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("policy fixture %s: %w", path, err)
+	}
+	for i, tc := range fixture.Cases {
+		if strings.TrimSpace(tc.Agent) == "" || strings.TrimSpace(tc.Tool) == "" {
+			return Fixture{}, fmt.Errorf("policy fixture %s: cases[%d] needs agent and tool", path, i)
+		}
+		if tc.Expect != "allow" && tc.Expect != "deny" {
+			return Fixture{}, fmt.Errorf("policy fixture %s: cases[%d] expect must be allow or deny", path, i)
+		}
+	}
+	return fixture, nil
+}
+
+func (ev Evaluator) RunFixture(fixture Fixture) []FixtureResult {
+	results := make([]FixtureResult, 0, len(fixture.Cases))
+	for _, tc := range fixture.Cases {
+		decision := ev.Evaluate(Call{AgentID: tc.Agent, Tool: tc.Tool, Args: tc.Args})
+		result := FixtureResult{Case: tc, Decision: decision, Passed: true}
+		got := "deny"
+		if decision.Allowed {
+			got = "allow"
+		}
+		switch {
+		case got != tc.Expect:
+			result.Passed = false
+			result.Message = fmt.Sprintf("expected %s, got %s: %s", tc.Expect, got, decision.Reason())
+		case tc.Reason != "" && !strings.Contains(decision.Reason(), tc.Reason):
+			result.Passed = false
+			result.Message = fmt.Sprintf("expected reason containing %q, got %q", tc.Reason, decision.Reason())
+		}
+		results = append(results, result)
+	}
+	return results
+}
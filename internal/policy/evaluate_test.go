@@ -0,0 +1,154 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testEvaluator(t *testing.T) Evaluator {
+	t.Helper()
+	doc := &Document{
+		Version: DocumentVersion,
+		Agents: map[string]AgentPolicy{
+			"ops": {
+				Capabilities: []string{"http.request", "shell.exec", "fs.read"},
+				Rules:        []ArgRule{{Tool: "shell.exec", Commands: []string{"go test ./...", "go vet ./..."}}},
+			},
+		},
+		Network: &NetworkPolicy{AllowedDomains: []string{"svc.internal"}},
+		Shell:   &ShellPolicy{AllowedCommands: []string{"go"}},
+		Sandbox: &SandboxPolicy{RequiredFor: []string{"shell.exec"}},
+	}
+	return Evaluator{
+		Env: Environment{
+			Workspace:            t.TempDir(),
+			DefaultCapabilities:  []string{"fs.read", "fs.write", "http.request", "shell.exec"},
+			NetworkEnabled:       true,
+			AllowedDomains:       []string{"internal", "example.com"},
+			ShellEnabled:         true,
+			ShellAllowedCommands: []string{"go", "git"},
+			SandboxActive:        true,
+		},
+		Grants:   map[string][]string{"ops": {"fs.write"}, "docs": {"fs.read", "fs.write", "secrets.get"}},
+		Rules:    map[string][]ArgRule{"docs": {{Tool: "fs.write", Paths: []string{"docs/**"}}}},
+		Document: doc,
+	}
+}
+
+func TestEvaluatorCapabilitiesPreferDocument(t *testing.T) {
+	ev := testEvaluator(t)
+	caps, source := ev.Capabilities("ops")
+	if source != "policy" || strings.Join(caps, ",") != "fs.read,http.request,shell.exec" {
+		t.Fatalf("expected document capabilities, got %v from %s", caps, source)
+	}
+	caps, source = ev.Capabilities("docs")
+	if source != "persisted" || strings.Join(caps, ",") != "fs.read,fs.write" {
+		t.Fatalf("expected persisted grants limited to config tools, got %v from %s", caps, source)
+	}
+	if _, source = ev.Capabilities("other"); source != "default" {
+		t.Fatalf("expected default source, got %s", source)
+	}
+}
+
+func TestEvaluatorGrantOnlyCapabilitiesNeedExplicitGrant(t *testing.T) {
+	ev := testEvaluator(t)
+	ev.Env.GrantOnlyCapabilities = []string{"mcp.docs.lookup", "mcp.docs.search", "mcp.files.read"}
+	ev.Grants["reader"] = []string{"fs.read", "mcp.docs.*"}
+	ev.Grants["single"] = []string{"mcp.files.read", "mcp.other.*"}
+
+	if caps, _ := ev.Capabilities("other"); strings.Contains(strings.Join(caps, ","), "mcp.") {
+		t.Fatalf("expected grant-only tools to stay out of default capabilities, got %v", caps)
+	}
+	if caps, _ := ev.Capabilities("reader"); strings.Join(caps, ",") != "fs.read,mcp.docs.lookup,mcp.docs.search" {
+		t.Fatalf("expected server wildcard to expand to its tools, got %v", caps)
+	}
+	if caps, _ := ev.Capabilities("single"); strings.Join(caps, ",") != "mcp.files.read" {
+		t.Fatalf("expected only the granted tool, got %v", caps)
+	}
+}
+
+func TestEvaluateExplainsDecisions(t *testing.T) {
+	ev := testEvaluator(t)
+	cases := []struct {
+		call    Call
+		allowed bool
+		want    string
+	}{
+		{Call{AgentID: "ops", Tool: "shell.exec", Args: map[string]any{"command": "go", "args": []any{"test", "./..."}}}, true, "agents.ops.rules[0]"},
+		{Call{AgentID: "ops", Tool: "shell.exec", Args: map[string]any{"command": "go", "args": []any{"build"}}}, false, "not in allowed commands"},
+		{Call{AgentID: "ops", Tool: "shell.exec", Args: map[string]any{"command": "git", "args": []any{"log"}}}, false, "policy shell.allowed_commands"},
+		{Call{AgentID: "ops", Tool: "fs.write", Args: map[string]any{"path": "a.txt"}}, false, "not granted fs.write (policy capabilities)"},
+		{Call{AgentID: "ops", Tool: "http.request", Args: map[string]any{"url": "https://api.svc.internal/x"}}, true, "no agents.ops.rules entry covers http.request"},
+		{Call{AgentID: "ops", Tool: "http.request", Args: map[string]any{"url": "https://example.com"}}, false, "policy network.allowed_domains"},
+		{Call{AgentID: "ops", Tool: "http.request", Args: map[string]any{"url": "https://evil.test"}}, false, "not in network.allowed_domains"},
+		{Call{AgentID: "docs", Tool: "fs.write", Args: map[string]any{"path": "docs/a.md"}}, true, "rules.docs[0]"},
+		{Call{AgentID: "docs", Tool: "fs.write", Args: map[string]any{"path": "src/a.go"}}, false, "outside allowed paths"},
+	}
+	for _, tc := range cases {
+		decision := ev.Evaluate(tc.call)
+		if decision.Allowed != tc.allowed {
+			t.Fatalf("%s %s %v: expected allowed=%v, got %+v", tc.call.AgentID, tc.call.Tool, tc.call.Args, tc.allowed, decision)
+		}
+		if !strings.Contains(decision.Reason(), tc.want) {
+			t.Fatalf("%s %s: expected reason containing %q, got %q", tc.call.AgentID, tc.call.Tool, tc.want, decision.Reason())
+		}
+	}
+
+	ev.Env.SandboxActive = false
+	decision := ev.Evaluate(Call{AgentID: "ops", Tool: "shell.exec", Args: map[string]any{"command": "go", "args": []any{"test", "./..."}}})
+	if decision.Allowed || !strings.Contains(decision.Reason(), "no sandbox is active") {
+		t.Fatalf("expected sandbox requirement to deny, got %+v", decision)
+	}
+}
+
+func TestEnforcerFromEvaluatorAppliesLimits(t *testing.T) {
+	ev := testEvaluator(t)
+	enforcer := ev.Enforcer("ops")
+	if err := enforcer.CheckTool("ops", "fs.write"); err == nil {
+		t.Fatal("expected document to drop persisted fs.write grant")
+	}
+	if err := enforcer.CheckArgs("ops", "http.request", "", map[string]any{"url": "https://example.com"}); err == nil {
+		t.Fatal("expected document network limit to deny example.com")
+	}
+	err := enforcer.CheckArgs("ops", "shell.exec", "", map[string]any{"command": "bash", "args": []any{"-lc", "go test ./... && curl x"}})
+	if err == nil || !strings.Contains(err.Error(), "shell control characters") {
+		t.Fatalf("expected chained command denied, got %v", err)
+	}
+}
+
+func TestLoadDocumentAndFixtures(t *testing.T) {
+	dir := t.TempDir()
+	if doc, err := LoadDocument(filepath.Join(dir, "missing.json")); err != nil || doc != nil {
+		t.Fatalf("expected missing document to be nil, got %v %v", doc, err)
+	}
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"version":2}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadDocument(bad); err == nil || !strings.Contains(err.Error(), "unsupported version") {
+		t.Fatalf("expected version error, got %v", err)
+	}
+
+	fixturePath := filepath.Join(dir, "policy_test.json")
+	raw := `{"cases":[
+		{"name":"ops runs tests","agent":"ops","tool":"shell.exec","args":{"command":"go","args":["test","./..."]},"expect":"allow"},
+		{"name":"ops cannot write","agent":"ops","tool":"fs.write","args":{"path":"a"},"expect":"deny","reason":"not granted"},
+		{"name":"wrong expectation","agent":"docs","tool":"fs.write","args":{"path":"src/a.go"},"expect":"allow"}
+	]}`
+	if err := os.WriteFile(fixturePath, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	fixture, err := LoadFixture(fixturePath)
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	results := testEvaluator(t).RunFixture(fixture)
+	if !results[0].Passed || !results[1].Passed || results[2].Passed {
+		t.Fatalf("unexpected fixture results: %+v", results)
+	}
+	if !strings.Contains(results[2].Message, "expected allow, got deny") {
+		t.Fatalf("expected mismatch message, got %q", results[2].Message)
+	}
+}
@@ -3,6 +3,7 @@ package policy
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"sort"
 	"strings"
@@ -11,7 +12,8 @@ import (
 )
 
 type grantsFile struct {
-	Agents map[string][]string `json:"agents"`
+	Agents map[string][]string  `json:"agents"`
+	Rules  map[string][]ArgRule `json:"rules,omitempty"`
 }
 
 func LoadGrants(path string) (map[string][]string, error) {
@@ -44,11 +46,61 @@ func LoadGrants(path string) (map[string][]string, error) {
 	return out, nil
 }
 
+// LoadArgRules reads the per-agent argument rules stored next to the grants.
+func LoadArgRules(path string) (map[string][]ArgRule, error) {
+	doc, err := readGrantsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]ArgRule, len(doc.Rules))
+	for agentID, rules := range doc.Rules {
+		agentID = strings.TrimSpace(agentID)
+		if agentID == "" {
+			continue
+		}
+		for _, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				return nil, fmt.Errorf("rules.%s: %w", agentID, err)
+			}
+		}
+		out[agentID] = rules
+	}
+	return out, nil
+}
+
+func readGrantsFile(path string) (grantsFile, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return grantsFile{}, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return grantsFile{}, nil
+		}
+		return grantsFile{}, err
+	}
+	if len(raw) == 0 {
+		return grantsFile{}, nil
+	}
+	var doc grantsFile
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return grantsFile{}, err
+	}
+	return doc, nil
+}
+
+// SaveGrants rewrites the capability grants, keeping any argument rules
+// already stored in the file.
 func SaveGrants(path string, grants map[string][]string) error {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return nil
 	}
+	existing, err := readGrantsFile(path)
+	if err != nil {
+		return err
+	}
 	clean := make(map[string][]string, len(grants))
 	for agentID, capabilities := range grants {
 		agentID = strings.TrimSpace(agentID)
@@ -58,7 +110,7 @@ func SaveGrants(path string, grants map[string][]string) error {
 		clean[agentID] = NormalizeCapabilities(capabilities)
 	}
 
-	doc := grantsFile{Agents: clean}
+	doc := grantsFile{Agents: clean, Rules: existing.Rules}
 	raw, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
 		return err
@@ -1,26 +1,70 @@
 package policy
 
-import "regexp"
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
 
 var redactionRules = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)(api[_-]?key|token|secret|password)\s*[:=]\s*['\"]?[^\s'\"]+`),
 	regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-\._~\+/]+=*`),
 	regexp.MustCompile(`\b[A-Z][A-Z0-9_]*(TOKEN|KEY|SECRET|PASSWORD)\b\s*=\s*[^\s]+`),
-	regexp.MustCompile(`\b[a-zA-Z0-9_\-]{24,}\b`),
 }
 
+var (
+	longTokenRule = regexp.MustCompile(`\b[a-zA-Z0-9_\-]{24,}\b`)
+	uuidRule      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
 func RedactString(input string) string {
-	out := input
+	out := RedactSecrets(input)
 	for _, r := range redactionRules {
 		out = r.ReplaceAllString(out, "[REDACTED]")
 	}
-	return out
+	return longTokenRule.ReplaceAllStringFunc(out, func(token string) string {
+		if looksLikeIdentifier(token) {
+			return token
+		}
+		return "[REDACTED]"
+	})
+}
+
+// looksLikeIdentifier keeps long tokens that are plainly not credentials:
+// UUIDs and snake/kebab-case names made of short words or numbers.
+func looksLikeIdentifier(token string) bool {
+	if uuidRule.MatchString(token) {
+		return true
+	}
+	parts := strings.FieldsFunc(token, func(r rune) bool { return r == '_' || r == '-' })
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) > 16 {
+			return false
+		}
+		letters, digits := false, false
+		for _, r := range part {
+			if r >= '0' && r <= '9' {
+				digits = true
+			} else {
+				letters = true
+			}
+		}
+		if letters && digits && len(part) > 4 {
+			return false
+		}
+	}
+	return true
 }
 
 func RedactValue(v any) any {
 	switch t := v.(type) {
 	case string:
 		return RedactString(t)
+	case json.RawMessage:
+		return json.RawMessage(RedactSecrets(string(t)))
 	case map[string]any:
 		out := make(map[string]any, len(t))
 		for k, val := range t {
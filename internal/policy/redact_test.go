@@ -46,6 +46,21 @@ func TestRedactString(t *testing.T) {
 			input: "abcdefghijklmnopqrstuvwxyz123456",
 			want:  "[REDACTED]",
 		},
+		{
+			name:  "uuid kept",
+			input: "run 123e4567-e89b-12d3-a456-426614174000 done",
+			want:  "run 123e4567-e89b-12d3-a456-426614174000 done",
+		},
+		{
+			name:  "long snake case identifier kept",
+			input: "call summarize_workspace_file_contents now",
+			want:  "call summarize_workspace_file_contents now",
+		},
+		{
+			name:  "long token with separators",
+			input: "sk_live_51HxQ2aBcDeFgHiJkLmNoPq",
+			want:  "[REDACTED]",
+		},
 		{
 			name:  "multiple redactions",
 			input: "api-key: key1 and token = token2",
@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// MinSecretValueLength is the shortest stored value redacted by exact match.
+// Shorter values ("1", "yes") would shred unrelated text.
+const MinSecretValueLength = 6
+
+type secretMatcher struct {
+	replacer *strings.Replacer
+	count    int
+}
+
+var secretValues atomic.Pointer[secretMatcher]
+
+// SetSecretValues replaces the process-wide set of known secret values. Each
+// value is matched verbatim and in its base64, URL-escaped and JSON-escaped
+// forms. Passing nil clears the matcher.
+func SetSecretValues(values []string) {
+	seen := map[string]struct{}{}
+	for _, v := range values {
+		if len(v) < MinSecretValueLength {
+			continue
+		}
+		for _, variant := range secretVariants(v) {
+			if len(variant) >= MinSecretValueLength {
+				seen[variant] = struct{}{}
+			}
+		}
+	}
+	if len(seen) == 0 {
+		secretValues.Store(nil)
+		return
+	}
+	variants := make([]string, 0, len(seen))
+	for v := range seen {
+		variants = append(variants, v)
+	}
+	// strings.Replacer prefers earlier pairs at the same position, so longer
+	// variants win over their own prefixes.
+	sort.Slice(variants, func(i, j int) bool {
+		if len(variants[i]) != len(variants[j]) {
+			return len(variants[i]) > len(variants[j])
+		}
+		return variants[i] < variants[j]
+	})
+	pairs := make([]string, 0, 2*len(variants))
+	for _, v := range variants {
+		pairs = append(pairs, v, "[REDACTED]")
+	}
+	secretValues.Store(&secretMatcher{replacer: strings.NewReplacer(pairs...), count: len(variants)})
+}
+
+// SecretValueCount reports how many value variants the matcher holds.
+func SecretValueCount() int {
+	m := secretValues.Load()
+	if m == nil {
+		return 0
+	}
+	return m.count
+}
+
+// RedactSecrets replaces known secret values and their encodings in s. Unlike
+// RedactString it applies no heuristics, so structured text such as JSON keeps
+// its shape.
+func RedactSecrets(s string) string {
+	m := secretValues.Load()
+	if m == nil || s == "" {
+		return s
+	}
+	return m.replacer.Replace(s)
+}
+
+func secretVariants(v string) []string {
+	raw := []byte(v)
+	out := []string{
+		v,
+		base64.StdEncoding.EncodeToString(raw),
+		base64.RawStdEncoding.EncodeToString(raw),
+		base64.URLEncoding.EncodeToString(raw),
+		base64.RawURLEncoding.EncodeToString(raw),
+		url.QueryEscape(v),
+		url.PathEscape(v),
+	}
+	if b, err := json.Marshal(v); err == nil && len(b) >= 2 {
+		out = append(out, string(b[1:len(b)-1]))
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactSecretsMatchesValueAndEncodings(t *testing.T) {
+	SetSecretValues([]string{"hunter2!x", "abc"})
+	t.Cleanup(func() { SetSecretValues(nil) })
+
+	if got := SecretValueCount(); got == 0 {
+		t.Fatalf("expected variants to be registered")
+	}
+	inputs := []string{
+		"pw is hunter2!x here",
+		"b64 " + base64.StdEncoding.EncodeToString([]byte("hunter2!x")),
+		"url " + url.QueryEscape("hunter2!x"),
+		"raw " + base64.RawURLEncoding.EncodeToString([]byte("hunter2!x")),
+	}
+	for _, in := range inputs {
+		got := RedactSecrets(in)
+		if !strings.Contains(got, "[REDACTED]") || strings.Contains(got, "hunter2") {
+			t.Fatalf("RedactSecrets(%q) = %q", in, got)
+		}
+	}
+	if got := RedactSecrets("abc stays"); got != "abc stays" {
+		t.Fatalf("short values must not be matched, got %q", got)
+	}
+}
+
+func TestRedactSecretsKeepsJSONValid(t *testing.T) {
+	SetSecretValues([]string{`quote"and\slash`})
+	t.Cleanup(func() { SetSecretValues(nil) })
+
+	b, _ := json.Marshal(map[string]string{"v": `quote"and\slash`})
+	got := RedactSecrets(string(b))
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+		t.Fatalf("redacted JSON invalid: %v (%s)", err, got)
+	}
+	if decoded["v"] != "[REDACTED]" {
+		t.Fatalf("unexpected value %q", decoded["v"])
+	}
+}
+
+func TestRedactStringAppliesKnownSecrets(t *testing.T) {
+	SetSecretValues([]string{"s3cr3t"})
+	t.Cleanup(func() { SetSecretValues(nil) })
+
+	if got := RedactString("value s3cr3t ok"); got != "value [REDACTED] ok" {
+		t.Fatalf("unexpected %q", got)
+	}
+	SetSecretValues(nil)
+	if got := RedactString("value s3cr3t ok"); got != "value s3cr3t ok" {
+		t.Fatalf("cleared matcher still redacts: %q", got)
+	}
+}
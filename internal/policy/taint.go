@@ -0,0 +1,103 @@
+package policy
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	TaintActionApprove = "approve"
+	TaintActionDeny    = "deny"
+)
+
+// TaintRules says which tool results are untrusted and what becomes gated once
+// a run has seen one. Tool names may use * wildcards (e.g. "mcp.*"); paths are
+// workspace-relative globs checked against fs.read.
+type TaintRules struct {
+	Action         string
+	UntrustedTools []string
+	UntrustedPaths []string
+	HighRiskTools  []string
+}
+
+// TaintTracker follows a single run. Results from untrusted sources taint it,
+// and from then on calls to high-risk tools are gated. http.request is gated
+// only for hosts the run had not contacted before.
+type TaintTracker struct {
+	rules TaintRules
+
+	mu      sync.Mutex
+	source  string
+	domains map[string]bool
+}
+
+func NewTaintTracker(rules TaintRules) *TaintTracker {
+	if rules.Action == "" {
+		rules.Action = TaintActionApprove
+	}
+	return &TaintTracker{rules: rules, domains: map[string]bool{}}
+}
+
+// Source names what first tainted the run, or "" while it is clean.
+func (t *TaintTracker) Source() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.source
+}
+
+// Check reports whether a call must be gated. It returns the configured action
+// and a reason for the operator, or "" when the call may proceed as usual.
+func (t *TaintTracker) Check(tool, workspace string, args map[string]any) (action, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.source == "" || !matchAny(t.rules.HighRiskTools, tool, matchCommand) {
+		return "", ""
+	}
+	if tool == "http.request" {
+		host := argHost(args)
+		if host != "" && t.domains[host] {
+			return "", ""
+		}
+		return t.rules.Action, "run is tainted by " + t.source + "; " + tool + " to new host " + quoteHost(host) + " needs review"
+	}
+	return t.rules.Action, "run is tainted by " + t.source + "; " + tool + " needs review"
+}
+
+// Observe records a call that ran and returns the taint label for its output,
+// e.g. "http.request example.com" or "fs.read downloads/page.html", or "" if
+// the output is trusted.
+func (t *TaintTracker) Observe(tool, workspace string, args map[string]any) string {
+	label := ""
+	if matchAny(t.rules.UntrustedTools, tool, matchCommand) {
+		label = tool
+		if host := argHost(args); host != "" {
+			label += " " + host
+		}
+	} else if tool == "fs.read" && len(t.rules.UntrustedPaths) > 0 {
+		if raw, _ := args["path"].(string); strings.TrimSpace(raw) != "" {
+			rel := workspaceRelative(workspace, raw)
+			if matchAny(t.rules.UntrustedPaths, rel, matchPathGlob) {
+				label = tool + " " + rel
+			}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tool == "http.request" {
+		if host := argHost(args); host != "" {
+			t.domains[host] = true
+		}
+	}
+	if label != "" && t.source == "" {
+		t.source = label
+	}
+	return label
+}
+
+func quoteHost(host string) string {
+	if host == "" {
+		return "(unknown)"
+	}
+	return host
+}
@@ -0,0 +1,60 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaintTrackerGatesHighRiskToolsAfterUntrustedOutput(t *testing.T) {
+	tracker := NewTaintTracker(TaintRules{
+		UntrustedTools: []string{"http.request", "mcp.*"},
+		UntrustedPaths: []string{"downloads/**"},
+		HighRiskTools:  []string{"shell.exec", "secrets.get", "http.request"},
+	})
+	fetchDocs := map[string]any{"url": "https://docs.example.com/page"}
+	if action, _ := tracker.Check("http.request", "/ws", fetchDocs); action != "" {
+		t.Fatalf("clean run should not gate, got %q", action)
+	}
+	if label := tracker.Observe("fs.read", "/ws", map[string]any{"path": "notes/todo.md"}); label != "" {
+		t.Fatalf("trusted path tainted the run: %q", label)
+	}
+	if action, _ := tracker.Check("shell.exec", "/ws", nil); action != "" {
+		t.Fatalf("expected shell.exec to pass before taint, got %q", action)
+	}
+
+	label := tracker.Observe("http.request", "/ws", fetchDocs)
+	if label != "http.request docs.example.com" || tracker.Source() != label {
+		t.Fatalf("unexpected taint label %q source %q", label, tracker.Source())
+	}
+	action, reason := tracker.Check("shell.exec", "/ws", map[string]any{"command": "rm"})
+	if action != TaintActionApprove || !strings.Contains(reason, "docs.example.com") {
+		t.Fatalf("expected shell.exec to need approval, got %q %q", action, reason)
+	}
+	if action, _ := tracker.Check("fs.list", "/ws", nil); action != "" {
+		t.Fatalf("low-risk tool gated: %q", action)
+	}
+	if action, _ := tracker.Check("http.request", "/ws", map[string]any{"url": "https://docs.example.com/other"}); action != "" {
+		t.Fatalf("known host gated: %q", action)
+	}
+	if action, reason := tracker.Check("http.request", "/ws", map[string]any{"url": "https://attacker.test/x"}); action != TaintActionApprove || !strings.Contains(reason, "attacker.test") {
+		t.Fatalf("new host not gated: %q %q", action, reason)
+	}
+
+	if label := tracker.Observe("fs.read", "/ws", map[string]any{"path": "/ws/downloads/page.html"}); label != "fs.read downloads/page.html" {
+		t.Fatalf("untrusted path label %q", label)
+	}
+	if tracker.Source() != "http.request docs.example.com" {
+		t.Fatalf("first taint source should stick, got %q", tracker.Source())
+	}
+	if label := tracker.Observe("mcp.browser.fetch", "/ws", nil); label != "mcp.browser.fetch" {
+		t.Fatalf("wildcard untrusted tool label %q", label)
+	}
+}
+
+func TestTaintTrackerDenyAction(t *testing.T) {
+	tracker := NewTaintTracker(TaintRules{Action: TaintActionDeny, UntrustedTools: []string{"agent.message.inbox"}, HighRiskTools: []string{"policy.grant"}})
+	tracker.Observe("agent.message.inbox", "/ws", nil)
+	if action, _ := tracker.Check("policy.grant", "/ws", nil); action != TaintActionDeny {
+		t.Fatalf("expected deny, got %q", action)
+	}
+}
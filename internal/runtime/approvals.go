@@ -0,0 +1,135 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"openclawssy/internal/approval"
+	"openclawssy/internal/audit"
+	"openclawssy/internal/config"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/tools"
+)
+
+const (
+	eventApprovalRequested = "approval.requested"
+
+	RunStatusAwaitingApproval = "awaiting_approval"
+	RunStatusRunning          = "running"
+)
+
+// Approvals returns the engine's approval broker, which channels use to list
+// and decide tool calls paused in ask mode.
+func (e *Engine) Approvals() (*approval.Broker, error) {
+	cfg, err := config.LoadOrDefault(filepath.Join(e.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("runtime: load config: %w", err)
+	}
+	return e.approvalBroker(cfg)
+}
+
+// approvalBroker is created once per engine so pending requests raised by any
+// run are visible to every channel; the rules file is read from the first
+// config seen.
+func (e *Engine) approvalBroker(cfg config.Config) (*approval.Broker, error) {
+	e.approvalsMu.Lock()
+	defer e.approvalsMu.Unlock()
+	if e.approvals != nil {
+		return e.approvals, nil
+	}
+	path := cfg.Approvals.RulesFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.rootDir, path)
+	}
+	broker, err := approval.NewBroker(path)
+	if err != nil {
+		return nil, fmt.Errorf("runtime: init approvals: %w", err)
+	}
+	e.approvals = broker
+	return broker, nil
+}
+
+// enableApprovals puts the configured tools in ask mode for agentID and
+// attaches an approver that pauses on the engine's broker.
+func (e *Engine) enableApprovals(cfg config.Config, agentID string, enforcer *policy.Enforcer, registry *tools.Registry, aud *audit.Logger, onProgress func(string, map[string]any)) error {
+	if !cfg.Approvals.Enabled {
+		return nil
+	}
+	broker, err := e.approvalBroker(cfg)
+	if err != nil {
+		return err
+	}
+	enforcer.SetApprovalRequired(agentID, cfg.Approvals.Tools)
+	registry.SetApprover(&runApprover{
+		broker:         broker,
+		timeout:        time.Duration(cfg.Approvals.TimeoutSeconds) * time.Second,
+		timeoutApprove: cfg.Approvals.TimeoutDecision == "allow",
+		aud:            aud,
+		onProgress:     onProgress,
+	})
+	return nil
+}
+
+// runApprover pauses a run on the broker, audits the request and reports the
+// awaiting_approval/running status transitions as progress events.
+type runApprover struct {
+	broker         *approval.Broker
+	timeout        time.Duration
+	timeoutApprove bool
+	aud            *audit.Logger
+	onProgress     func(string, map[string]any)
+}
+
+func (a *runApprover) RequestApproval(ctx context.Context, req tools.ApprovalRequest) (tools.ApprovalDecision, error) {
+	paused := false
+	pending, decision, err := a.broker.Await(ctx, approval.Request{
+		AgentID:   req.AgentID,
+		RunID:     req.RunID,
+		SessionID: req.SessionID,
+		Tool:      req.Tool,
+		Args:      req.Args,
+		Reason:    req.Reason,
+	}, a.timeout, a.timeoutApprove, func(pending approval.Request) {
+		paused = true
+		fields := map[string]any{
+			"agent_id":    pending.AgentID,
+			"run_id":      pending.RunID,
+			"tool":        pending.Tool,
+			"approval_id": pending.ID,
+			"subject":     pending.Subject,
+			"expires_at":  pending.ExpiresAt.Format(time.RFC3339),
+		}
+		status := map[string]any{
+			"status":      RunStatusAwaitingApproval,
+			"approval_id": pending.ID,
+			"tool":        pending.Tool,
+			"subject":     pending.Subject,
+			"expires_at":  pending.ExpiresAt.Format(time.RFC3339),
+		}
+		if pending.Reason != "" {
+			fields["reason"] = pending.Reason
+			status["reason"] = pending.Reason
+		}
+		_ = a.aud.LogEvent(ctx, eventApprovalRequested, fields)
+		safeEmitProgress(a.onProgress, "status", status)
+	})
+	if paused {
+		safeEmitProgress(a.onProgress, "status", map[string]any{
+			"status":      RunStatusRunning,
+			"approval_id": pending.ID,
+			"tool":        pending.Tool,
+			"approved":    err == nil && decision.Approved,
+		})
+	}
+	if err != nil {
+		return tools.ApprovalDecision{ApprovalID: pending.ID}, err
+	}
+	return tools.ApprovalDecision{
+		ApprovalID: pending.ID,
+		Approved:   decision.Approved,
+		Approver:   decision.Approver,
+		Reason:     decision.Reason,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"openclawssy/internal/approval"
+	"openclawssy/internal/config"
+)
+
+func TestExecuteWithInputPausesForApprovalAndRemembersRule(t *testing.T) {
+	root := t.TempDir()
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	if err := e.Init("default", false); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(root, "workspace", name), []byte("x"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/event-stream")
+		content := "done"
+		switch calls {
+		case 1:
+			content = "```json\n{\"tool_name\":\"fs.delete\",\"arguments\":{\"path\":\"a.txt\"}}\n```"
+		case 2:
+			content = "```json\n{\"tool_name\":\"fs.delete\",\"arguments\":{\"path\":\"b.txt\"}}\n```"
+		}
+		chunk, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]string{"content": content}}}})
+		_, _ = io.WriteString(w, "data: "+string(chunk)+"\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	cfgPath := filepath.Join(root, ".openclawssy", "config.json")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Model.Provider = "generic"
+	cfg.Model.Name = "test-model"
+	cfg.Providers.Generic.BaseURL = server.URL
+	cfg.Providers.Generic.APIKey = "test-key"
+	cfg.Providers.Generic.APIKeyEnv = ""
+	cfg.Approvals.Enabled = true
+	cfg.Approvals.Tools = []string{"fs.delete"}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	broker, err := e.Approvals()
+	if err != nil {
+		t.Fatalf("approvals: %v", err)
+	}
+	var mu sync.Mutex
+	var statuses []string
+	decided := make(chan error, 1)
+	res, err := e.ExecuteWithInput(context.Background(), ExecuteInput{
+		AgentID: "default",
+		Message: "clean up",
+		Source:  "dashboard",
+		OnProgress: func(eventType string, data map[string]any) {
+			if eventType != "status" {
+				return
+			}
+			status, _ := data["status"].(string)
+			mu.Lock()
+			statuses = append(statuses, status)
+			mu.Unlock()
+			if status == RunStatusAwaitingApproval {
+				id, _ := data["approval_id"].(string)
+				go func() {
+					_, err := broker.Decide(id, "", approval.Decision{Approved: true, Approver: "dashboard", Remember: true, Pattern: "*.txt"})
+					decided <- err
+				}()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if err := <-decided; err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if strings.TrimSpace(res.FinalText) != "done" {
+		t.Fatalf("unexpected final text %q", res.FinalText)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(root, "workspace", name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be deleted, stat err=%v", name, err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(statuses, ",") != RunStatusAwaitingApproval+","+RunStatusRunning {
+		t.Fatalf("expected one pause for the remembered pattern, got %v", statuses)
+	}
+	if rules := broker.Rules(); len(rules) != 1 || rules[0].Tool != "fs.delete" || rules[0].AgentID != "default" {
+		t.Fatalf("unexpected remembered rules %+v", rules)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(root, ".openclawssy", "agents", "default", "audit", "events.jsonl"))
+	if err != nil {
+		t.Fatalf("read audit: %v", err)
+	}
+	for _, event := range []string{"approval.requested", "approval.granted"} {
+		if !strings.Contains(string(raw), event) {
+			t.Fatalf("expected %s in audit log", event)
+		}
+	}
+}
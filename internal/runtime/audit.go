@@ -0,0 +1,195 @@
+package runtime
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"openclawssy/internal/audit"
+	"openclawssy/internal/config"
+	"openclawssy/internal/policy"
+)
+
+// AuditPath is the active audit log of agentID; rotated files sit next to it.
+func (e *Engine) AuditPath(agentID string) string {
+	return filepath.Join(e.agentsDir, agentID, "audit", "events.jsonl")
+}
+
+// OpenAuditLogger opens agentID's audit log with the current config, for
+// channels that audit outside of runs.
+func (e *Engine) OpenAuditLogger(agentID string) (*audit.Logger, error) {
+	cfg, err := config.LoadOrDefault(filepath.Join(e.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("runtime: load config: %w", err)
+	}
+	return e.auditLogger(cfg, agentID)
+}
+
+// auditLogger opens the agent's chained audit log with the configured
+// rotation, retention, checkpoint signing and export.
+func (e *Engine) auditLogger(cfg config.Config, agentID string) (*audit.Logger, error) {
+	opts := audit.Options{
+		MaxFileBytes: cfg.Audit.MaxFileBytes,
+		MaxFileAge:   time.Duration(cfg.Audit.MaxFileAgeHours) * time.Hour,
+		Compress:     cfg.Audit.Compress,
+		RetainFiles:  cfg.Audit.RetainFiles,
+		RetainAge:    time.Duration(cfg.Audit.RetainDays) * 24 * time.Hour,
+	}
+	exporters, err := e.auditExporters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts.Exporters = exporters
+	if cfg.Audit.Checkpoints {
+		key, err := audit.LoadOrCreateSigningKey(e.rootPath(cfg.Audit.SigningKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("runtime: load audit signing key: %w", err)
+		}
+		opts.Signer = key
+		opts.CheckpointEvery = cfg.Audit.CheckpointEvery
+	}
+	aud, err := audit.NewLoggerWithOptions(e.AuditPath(agentID), policy.RedactValue, opts)
+	if err != nil {
+		return nil, fmt.Errorf("runtime: init audit logger: %w", err)
+	}
+	return aud, nil
+}
+
+// auditExporters are created once per engine from the first config seen, so
+// every agent log shares one queue and connection per destination.
+func (e *Engine) auditExporters(cfg config.Config) ([]audit.Exporter, error) {
+	e.auditExportMu.Lock()
+	defer e.auditExportMu.Unlock()
+	if !e.auditExportInit {
+		forwarders, err := newAuditForwarders(cfg.Audit.Export)
+		if err != nil {
+			return nil, fmt.Errorf("runtime: init audit export: %w", err)
+		}
+		e.auditForwarders = forwarders
+		e.auditExportInit = true
+	}
+	exporters := make([]audit.Exporter, 0, len(e.auditForwarders))
+	for _, forwarder := range e.auditForwarders {
+		exporters = append(exporters, forwarder)
+	}
+	return exporters, nil
+}
+
+func newAuditForwarders(cfg config.AuditExportConfig) ([]*audit.Forwarder, error) {
+	var forwarders []*audit.Forwarder
+	if cfg.Syslog.Enabled {
+		forwarder, err := audit.NewSyslogExporter(audit.SyslogConfig{
+			Network:  cfg.Syslog.Network,
+			Address:  cfg.Syslog.Address,
+			AppName:  cfg.Syslog.AppName,
+			Facility: cfg.Syslog.Facility,
+		})
+		if err != nil {
+			return nil, err
+		}
+		forwarders = append(forwarders, forwarder)
+	}
+	if cfg.HTTP.Enabled {
+		forwarder, err := audit.NewHTTPExporter(audit.HTTPExportConfig{
+			URL:           cfg.HTTP.URL,
+			Headers:       cfg.HTTP.Headers,
+			BatchSize:     cfg.HTTP.BatchSize,
+			FlushInterval: time.Duration(cfg.HTTP.FlushSeconds) * time.Second,
+			Timeout:       time.Duration(cfg.HTTP.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			for _, started := range forwarders {
+				_ = started.Close(context.Background())
+			}
+			return nil, err
+		}
+		forwarders = append(forwarders, forwarder)
+	}
+	return forwarders, nil
+}
+
+// AuditExportStats reports delivery counters per export destination.
+func (e *Engine) AuditExportStats() map[string]audit.ExportStats {
+	e.auditExportMu.Lock()
+	defer e.auditExportMu.Unlock()
+	stats := make(map[string]audit.ExportStats, len(e.auditForwarders))
+	for _, forwarder := range e.auditForwarders {
+		stats[forwarder.Name()] = forwarder.Stats()
+	}
+	return stats
+}
+
+// Close delivers queued audit exports, waiting until ctx is done.
+func (e *Engine) Close(ctx context.Context) error {
+	e.auditExportMu.Lock()
+	forwarders := e.auditForwarders
+	e.auditForwarders = nil
+	e.auditExportInit = false
+	e.auditExportMu.Unlock()
+	var errs []error
+	for _, forwarder := range forwarders {
+		if err := forwarder.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("runtime: flush %s audit export: %w", forwarder.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyAudit checks the audit chains of agentIDs, or of every agent with an
+// audit log when none are given. pubKeyPath overrides the `<signing key>.pub`
+// used for checkpoint signatures; without either, signatures are not checked.
+func (e *Engine) VerifyAudit(agentIDs []string, pubKeyPath string) (map[string]audit.Report, error) {
+	cfg, err := config.LoadOrDefault(filepath.Join(e.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("runtime: load config: %w", err)
+	}
+	pub, err := e.auditVerifyKey(cfg, pubKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(agentIDs) == 0 {
+		entries, err := os.ReadDir(e.agentsDir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if _, err := os.Stat(e.AuditPath(entry.Name())); entry.IsDir() && err == nil {
+				agentIDs = append(agentIDs, entry.Name())
+			}
+		}
+	}
+	sort.Strings(agentIDs)
+	reports := make(map[string]audit.Report, len(agentIDs))
+	for _, agentID := range agentIDs {
+		report, err := audit.Verify(e.AuditPath(agentID), pub)
+		if err != nil {
+			return nil, fmt.Errorf("runtime: verify audit for %s: %w", agentID, err)
+		}
+		reports[agentID] = report
+	}
+	return reports, nil
+}
+
+func (e *Engine) auditVerifyKey(cfg config.Config, pubKeyPath string) (ed25519.PublicKey, error) {
+	if strings.TrimSpace(pubKeyPath) != "" {
+		return audit.LoadPublicKey(pubKeyPath)
+	}
+	pub, err := audit.LoadPublicKey(e.rootPath(cfg.Audit.SigningKeyFile) + ".pub")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return pub, err
+}
+
+func (e *Engine) rootPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(e.rootDir, path)
+}
@@ -14,10 +14,12 @@ import (
 	"time"
 
 	"openclawssy/internal/agent"
+	"openclawssy/internal/approval"
 	"openclawssy/internal/artifacts"
 	"openclawssy/internal/audit"
 	"openclawssy/internal/chatstore"
 	"openclawssy/internal/config"
+	"openclawssy/internal/mcp"
 	"openclawssy/internal/memory"
 	memorystore "openclawssy/internal/memory/store"
 	"openclawssy/internal/policy"
@@ -38,6 +40,13 @@ type Engine struct {
 	runLimitMu  sync.Mutex
 	runLimitCap int
 	runSlots    chan struct{}
+
+	approvalsMu sync.Mutex
+	approvals   *approval.Broker
+
+	auditExportMu   sync.Mutex
+	auditExportInit bool
+	auditForwarders []*audit.Forwarder
 }
 
 type RunResult struct {
@@ -144,7 +153,7 @@ func (e *Engine) Init(agentID string, force bool) error {
 	files := map[string]string{
 		"SOUL.md":     "# SOUL\n\nYou are Openclawssy, a high-accountability software engineering agent.\n\n## Mission\n- Deliver correct, verifiable outcomes with minimal user friction.\n- Prefer concrete execution and evidence over speculation.\n- Keep users informed with concise, actionable updates.\n\n## Quality Bar\n- Validate assumptions against repository context before making changes.\n- Preserve user intent and existing architecture unless directed otherwise.\n- When uncertain, pick the safest reasonable default and explain tradeoffs.\n",
 		"RULES.md":    "# RULES\n\n- Follow workspace-only write policy and capability boundaries.\n- Never expose secrets in plain text output.\n- Keep responses concise, factual, and directly tied to user goals.\n- Run targeted verification for non-trivial changes whenever feasible.\n- If blocked by missing credentials or irreversible choices, ask one precise question with a recommended default.\n",
-		"TOOLS.md":    "# TOOLS\n\nEnabled core tools: fs.read, fs.list, fs.write, fs.append, fs.delete, fs.move, fs.edit, code.search, config.get, config.set, secrets.get, secrets.set, secrets.list, skill.list, skill.read, scheduler.list, scheduler.add, scheduler.remove, scheduler.pause, scheduler.resume, session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now.\n",
+		"TOOLS.md":    "# TOOLS\n\nEnabled core tools: fs.read, fs.list, fs.write, fs.append, fs.delete, fs.move, fs.edit, code.search, config.get, config.set, secrets.get, secrets.set, secrets.list, skill.list, skill.read, scheduler.list, scheduler.add, scheduler.remove, scheduler.pause, scheduler.resume, session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now.\n",
 		"SPECPLAN.md": "# SPECPLAN\n\nDescribe specs and acceptance requirements before coding.\n",
 		"DEVPLAN.md":  "# DEVPLAN\n\n- [ ] Implement task\n- [ ] Add tests\n- [ ] Update handoff\n",
 		"HANDOFF.md":  "# HANDOFF\n\nStatus: initialized\n\nNext:\n- Define first run objective.\n",
@@ -254,10 +263,9 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 		return RunResult{}, err
 	}
 
-	auditPath := filepath.Join(e.agentsDir, agentID, "audit", "events.jsonl")
-	aud, err := audit.NewLogger(auditPath, policy.RedactValue)
+	aud, err := e.auditLogger(cfg, agentID)
 	if err != nil {
-		return RunResult{}, fmt.Errorf("runtime: init audit logger: %w", err)
+		return RunResult{}, err
 	}
 	defer func() {
 		_ = aud.Close()
@@ -281,27 +289,6 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 		maxToolIterations = 1
 		runMessage = "Scheduled proactive delivery. Respond with exactly one concise assistant message that delivers this content to the user. Do not call tools. Do not ask follow-up questions. Content: " + message
 	}
-	effectiveCaps := e.effectiveCapabilities(agentID, allowedTools)
-	traceCollector := newRunTraceCollector(runID, sessionID, source, message)
-	runCtx = withRunTraceCollector(runCtx, traceCollector)
-	enforcer := policy.NewEnforcer(e.workspaceDir, map[string][]string{agentID: effectiveCaps})
-	registry := tools.NewRegistry(enforcer, aud)
-	if err := tools.RegisterCoreWithOptions(registry, tools.CoreOptions{
-		EnableShellExec: cfg.Shell.EnableExec && cfg.Sandbox.Active && strings.ToLower(cfg.Sandbox.Provider) != "none",
-		ConfigPath:      filepath.Join(e.rootDir, ".openclawssy", "config.json"),
-		AgentsPath:      e.agentsDir,
-		SchedulerPath:   filepath.Join(e.rootDir, ".openclawssy", "scheduler", "jobs.json"),
-		ChatstorePath:   e.agentsDir,
-		PolicyPath:      filepath.Join(e.rootDir, ".openclawssy", "policy", "capabilities.json"),
-		DefaultGrants:   allowedTools,
-		RunsPath:        filepath.Join(e.rootDir, ".openclawssy", "runs.json"),
-		RunTracker:      e.runTracker,
-		WorkspaceRoot:   e.workspaceDir,
-		AgentRunner:     &subAgentRunner{engine: e},
-	}); err != nil {
-		return RunResult{}, fmt.Errorf("runtime: register core tools: %w", err)
-	}
-	registry.SetShellAllowedCommands(cfg.Shell.AllowedCommands)
 
 	var provider sandbox.Provider
 	if cfg.Sandbox.Active {
@@ -313,17 +300,61 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 			return RunResult{}, fmt.Errorf("runtime: start sandbox provider: %w", err)
 		}
 		defer provider.Stop()
-		if cfg.Shell.EnableExec && sandbox.ShellExecAllowed(provider) {
-			registry.SetShellExecutor(&sandboxShellExecutor{provider: provider})
-		}
+	}
+	var mcpTools []mcp.MountedTool
+	if len(allowedTools) > 0 {
+		var mcpClients []*mcp.Client
+		mcpClients, mcpTools = mountMCPServers(runCtx, cfg, provider, aud)
+		defer func() {
+			for _, client := range mcpClients {
+				_ = client.Close()
+			}
+		}()
+	}
+	evaluator, err := e.policyEvaluator(cfg, allowedTools, "")
+	if err != nil {
+		return RunResult{}, err
+	}
+	// Mounted MCP tools come from outside the trust boundary, so an agent
+	// only gets them through an explicit grant.
+	evaluator.Env.GrantOnlyCapabilities = mountedToolNames(mcpTools)
+	traceCollector := newRunTraceCollector(runID, sessionID, source, message)
+	runCtx = withRunTraceCollector(runCtx, traceCollector)
+	runCtx = tools.WithRunContext(runCtx, tools.RunContext{RunID: runID, SessionID: sessionID})
+	enforcer := evaluator.Enforcer(agentID)
+	mcpTools = grantedMountedTools(enforcer, agentID, mcpTools)
+	if doc := mcp.PromptDoc(mcpTools); doc != "" {
+		docs = append(docs, agent.ArtifactDoc{Name: "MCP_TOOLS.md", Content: doc})
+	}
+	registry := tools.NewRegistry(enforcer, aud)
+	if err := tools.RegisterCoreWithOptions(registry, e.coreToolOptions(cfg, allowedTools)); err != nil {
+		return RunResult{}, fmt.Errorf("runtime: register core tools: %w", err)
+	}
+	registry.SetShellAllowedCommands(cfg.Shell.AllowedCommands)
+
+	if cfg.Shell.EnableExec && sandbox.ShellExecAllowed(provider) {
+		registry.SetShellExecutor(&sandboxShellExecutor{provider: provider})
+	}
+	if err := mcp.Register(registry, mcpTools); err != nil {
+		return RunResult{}, fmt.Errorf("runtime: register mcp tools: %w", err)
+	}
+	if err := e.enableApprovals(cfg, agentID, enforcer, registry, aud, in.OnProgress); err != nil {
+		return RunResult{}, err
 	}
 
 	secretStore, _ := secrets.NewStore(cfg)
+	if secretStore != nil {
+		_ = secretStore.RefreshRedaction()
+	}
 	lookup := func(name string) (string, bool, error) {
 		if secretStore == nil {
 			return "", false, nil
 		}
-		return secretStore.Get(name)
+		value, found, err := secretStore.Get(name)
+		if found {
+			_ = aud.LogEvent(runCtx, audit.EventSecretRead, map[string]any{"agent_id": agentID, "key": name, "reader": "provider"})
+		}
+		return value, found, err
 	}
 
 	model, err := NewProviderModelForConfig(cfg, selectedModel, lookup)
@@ -331,9 +362,18 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 		return RunResult{}, err
 	}
 
+	executor := &RegistryExecutor{Registry: registry, AgentID: agentID, Workspace: e.workspaceDir}
+	if taint := cfg.TaintFor(agentID); taint.Enabled {
+		executor.Taint = policy.NewTaintTracker(policy.TaintRules{
+			Action:         taint.Action,
+			UntrustedTools: taint.UntrustedTools,
+			UntrustedPaths: taint.UntrustedPaths,
+			HighRiskTools:  taint.HighRiskTools,
+		})
+	}
 	runner := agent.Runner{
 		Model:             model,
-		ToolExecutor:      &RegistryExecutor{Registry: registry, AgentID: agentID, Workspace: e.workspaceDir},
+		ToolExecutor:      executor,
 		MaxToolIterations: agent.DefaultToolIterationCap,
 	}
 
@@ -420,7 +460,7 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 		PerFileByteLimit:  16 * 1024,
 		MaxToolIterations: maxToolIterations,
 		ToolTimeoutMS:     int(agent.DefaultToolTimeout / time.Millisecond),
-		AllowedTools:      allowedTools,
+		AllowedTools:      append(allowedTools, mountedToolNames(mcpTools)...),
 		OnToolCall:        onToolCall,
 		OnTextDelta:       onTextDelta,
 		SystemPromptExt:   e.memoryPromptExtender(cfg, agentID, runID),
@@ -445,7 +485,7 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 				runErr = mErr
 				break
 			}
-			toolLines = append(toolLines, string(b))
+			toolLines = append(toolLines, policy.RedactSecrets(string(b)))
 		}
 		if runErr == nil {
 			finalOutput := policy.RedactString(out.FinalText)
@@ -454,7 +494,7 @@ func (e *Engine) ExecuteWithInput(ctx context.Context, in ExecuteInput) (RunResu
 			}
 			artifactPath, err = artifacts.WriteRunBundleV1(e.rootDir, agentID, runID, artifacts.BundleV1Input{
 				Input:     map[string]any{"agent_id": agentID, "message": message},
-				PromptMD:  out.Prompt,
+				PromptMD:  policy.RedactSecrets(out.Prompt),
 				ToolCalls: toolLines,
 				OutputMD:  finalOutput,
 				Meta: map[string]any{
@@ -913,6 +953,7 @@ func buildToolContextMessage(msg chatstore.Message) string {
 	summary := ""
 	output := ""
 	errText := ""
+	taint := ""
 
 	payload := map[string]any{}
 	if err := json.Unmarshal([]byte(msg.Content), &payload); err == nil {
@@ -925,6 +966,7 @@ func buildToolContextMessage(msg chatstore.Message) string {
 		summary = fieldString(payload, "summary")
 		output = fieldString(payload, "output")
 		errText = fieldString(payload, "error")
+		taint = fieldString(payload, "taint")
 	} else {
 		output = strings.TrimSpace(msg.Content)
 	}
@@ -946,13 +988,18 @@ func buildToolContextMessage(msg chatstore.Message) string {
 	}
 
 	lines := []string{header}
-	if summary != "" {
+	// A summary of untrusted output would repeat it outside its block.
+	if summary != "" && taint == "" {
 		lines = append(lines, "summary: "+summary)
 	}
-	if errText != "" {
+	if errText != "" && taint != "" {
+		lines = append(lines, "untrusted error:\n"+untrustedBlock(taint, errText))
+	} else if errText != "" {
 		lines = append(lines, "error: "+errText)
 	}
-	if output != "" {
+	if output != "" && taint != "" {
+		lines = append(lines, "untrusted output:\n"+untrustedBlock(taint, output))
+	} else if output != "" {
 		lines = append(lines, "output: "+output)
 	}
 
@@ -1074,7 +1121,7 @@ func appendToolCallMessage(store *chatstore.Store, sessionID, runID string, rec
 	}
 	summary := summarizeToolExecution(rec.Request.Name, rec.Result.Output, rec.Result.Error)
 	errCode, errMessage := splitToolError(rec.Result.Error)
-	payload, marshalErr := json.Marshal(map[string]any{
+	fields := map[string]any{
 		"tool":          rec.Request.Name,
 		"id":            rec.Request.ID,
 		"summary":       summary,
@@ -1082,7 +1129,11 @@ func appendToolCallMessage(store *chatstore.Store, sessionID, runID string, rec
 		"error":         rec.Result.Error,
 		"error_code":    errCode,
 		"error_message": errMessage,
-	})
+	}
+	if rec.Result.Taint != "" {
+		fields["taint"] = rec.Result.Taint
+	}
+	payload, marshalErr := json.Marshal(fields)
 	if marshalErr != nil {
 		return fmt.Errorf("runtime: marshal tool result: %w", marshalErr)
 	}
@@ -1139,7 +1190,7 @@ func runtimeContextDoc(workspaceDir string) string {
 	)
 	doc = strings.Replace(doc,
 		"- Secret tools (secrets.get/secrets.set/secrets.list) use encrypted secret storage; secret values are never written to audit fields in plaintext.",
-		"- Secret tools (secrets.get/secrets.set/secrets.list) use encrypted secret storage; secret values are never written to audit fields in plaintext.\n- Skill tools (skill.list/skill.read) discover workspace skills under skills/ and report required secret keys with missing-secret diagnostics.\n- Memory tools (memory.search/memory.write/memory.update/memory.forget/memory.health/memory.checkpoint/memory.maintenance/decision.log) persist structured per-agent working memory in .openclawssy/agents/<agent>/memory/memory.db.",
+		"- Secret tools (secrets.get/secrets.set/secrets.list) use encrypted secret storage; secret values are never written to audit fields in plaintext.\n- Skill tools (skill.list/skill.read) discover workspace skills under skills/ and report required secret keys with missing-secret diagnostics; skill.run executes a skill's manifest entrypoint in the sandbox when shell execution is enabled.\n- Memory tools (memory.search/memory.write/memory.update/memory.forget/memory.health/memory.checkpoint/memory.maintenance/memory.link/memory.graph.query/decision.log) persist structured per-agent working memory in .openclawssy/agents/<agent>/memory/memory.db.",
 		1,
 	)
 	return doc
@@ -1153,12 +1204,12 @@ func toolCallingBestPracticesDocWithAgentTools() string {
 	doc := toolCallingBestPracticesDoc()
 	doc = strings.Replace(doc,
 		"secrets.get, secrets.set, secrets.list, scheduler.list, scheduler.add, scheduler.remove, scheduler.pause, scheduler.resume, session.list, session.close, run.list, run.get, http.request, time.now, shell.exec.",
-		"secrets.get, secrets.set, secrets.list, skill.list, skill.read, scheduler.list, scheduler.add, scheduler.remove, scheduler.pause, scheduler.resume, session.list, session.close, run.list, run.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
+		"secrets.get, secrets.set, secrets.list, skill.list, skill.read, scheduler.list, scheduler.add, scheduler.remove, scheduler.pause, scheduler.resume, session.list, session.close, run.list, run.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
 		1,
 	)
 	doc = strings.Replace(doc,
 		"- Use secrets.set for secret writes and secrets.get for reads; never echo secret values in plain text summaries.",
-		"- Use secrets.set for secret writes and secrets.get for reads; never echo secret values in plain text summaries.\n- Use skill.list and skill.read to discover workspace skills under skills/ and validate required secret keys before execution.",
+		"- Use secrets.set for secret writes and secrets.get for reads; never echo secret values in plain text summaries.\n- Use skill.list and skill.read to discover workspace skills under skills/ and validate required secret keys before execution. Run skills marked runnable with skill.run and pass arguments as inputs.",
 		1,
 	)
 	doc = strings.Replace(doc,
@@ -1168,7 +1219,7 @@ func toolCallingBestPracticesDocWithAgentTools() string {
 	)
 	doc = strings.Replace(doc,
 		"session.list, session.close, run.list, run.get, memory.search, memory.write, memory.update, memory.forget, memory.health, http.request, time.now, shell.exec.",
-		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
 		1,
 	)
 	doc = strings.Replace(doc,
@@ -1177,8 +1228,8 @@ func toolCallingBestPracticesDocWithAgentTools() string {
 		1,
 	)
 	doc = strings.Replace(doc,
-		"session.list, session.close, run.list, run.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
-		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, run.list, run.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
 		1,
 	)
 	doc = strings.Replace(doc,
@@ -1188,17 +1239,17 @@ func toolCallingBestPracticesDocWithAgentTools() string {
 	)
 	doc = strings.Replace(doc,
 		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, http.request, time.now, shell.exec.",
-		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
 		1,
 	)
 	doc = strings.Replace(doc,
 		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, decision.log, http.request, time.now, shell.exec.",
-		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
 		1,
 	)
 	doc = strings.Replace(doc,
-		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
-		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, run.list, run.get, run.cancel, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
+		"session.list, session.close, agent.list, agent.create, agent.switch, agent.profile.get, agent.profile.set, agent.message.send, agent.message.inbox, agent.run, agent.prompt.read, agent.prompt.update, agent.prompt.suggest, policy.list, policy.grant, policy.revoke, run.list, run.get, run.cancel, metrics.get, memory.search, memory.write, memory.update, memory.forget, memory.health, memory.checkpoint, memory.maintenance, memory.link, memory.graph.query, decision.log, http.request, time.now, shell.exec.",
 		1,
 	)
 	doc = strings.Replace(doc,
@@ -1213,6 +1264,9 @@ type RegistryExecutor struct {
 	Registry  *tools.Registry
 	AgentID   string
 	Workspace string
+	// Taint, when set, marks untrusted output and gates high-risk calls
+	// once the run has seen any.
+	Taint *policy.TaintTracker
 }
 
 func (r *RegistryExecutor) Execute(ctx context.Context, call agent.ToolCallRequest) (agent.ToolCallResult, error) {
@@ -1228,15 +1282,60 @@ func (r *RegistryExecutor) Execute(ctx context.Context, call agent.ToolCallReque
 	}
 	args = normalizeToolArgs(call.Name, args)
 
+	if r.Taint != nil {
+		if action, reason := r.Taint.Check(call.Name, r.Workspace, args); action != "" {
+			runTraceCollectorFromContext(ctx).RecordTaint(taintTraceEvent{Event: "gated", Tool: call.Name, ToolCallID: call.ID, Source: r.Taint.Source(), Action: action, Reason: reason})
+			ctx = tools.WithCallGate(ctx, tools.CallGate{Deny: action == policy.TaintActionDeny, Reason: reason})
+		}
+	}
+
 	res, err := r.Registry.Execute(ctx, r.AgentID, call.Name, r.Workspace, args)
 	if err != nil {
-		return agent.ToolCallResult{ID: call.ID}, err
+		// A failed call can still carry untrusted content in its error, such
+		// as a response body, so it is observed like a successful one.
+		return agent.ToolCallResult{ID: call.ID, Taint: r.observeTaint(ctx, call, args)}, redactSecretsError(err)
 	}
 	b, err := json.Marshal(res)
 	if err != nil {
 		return agent.ToolCallResult{ID: call.ID}, err
 	}
-	return agent.ToolCallResult{ID: call.ID, Output: string(b)}, nil
+	output := string(b)
+	// secrets.get is the one capability-gated way to disclose a value to the
+	// model; everything else is scrubbed of known secret values.
+	if call.Name != "secrets.get" {
+		output = policy.RedactSecrets(output)
+	}
+	return agent.ToolCallResult{ID: call.ID, Output: output, Taint: r.observeTaint(ctx, call, args)}, nil
+}
+
+// observeTaint records call with the taint tracker and returns the source
+// that marks its result untrusted, if any.
+func (r *RegistryExecutor) observeTaint(ctx context.Context, call agent.ToolCallRequest, args map[string]any) string {
+	if r.Taint == nil {
+		return ""
+	}
+	wasTainted := r.Taint.Source() != ""
+	taint := r.Taint.Observe(call.Name, r.Workspace, args)
+	if taint != "" && !wasTainted {
+		runTraceCollectorFromContext(ctx).RecordTaint(taintTraceEvent{Event: "tainted", Tool: call.Name, ToolCallID: call.ID, Source: taint})
+	}
+	return taint
+}
+
+type secretsRedactedError struct {
+	msg string
+	err error
+}
+
+func (e *secretsRedactedError) Error() string { return e.msg }
+func (e *secretsRedactedError) Unwrap() error { return e.err }
+
+func redactSecretsError(err error) error {
+	msg := err.Error()
+	if redacted := policy.RedactSecrets(msg); redacted != msg {
+		return &secretsRedactedError{msg: redacted, err: err}
+	}
+	return err
 }
 
 func normalizeToolArgs(toolName string, args map[string]any) map[string]any {
@@ -1360,7 +1459,7 @@ func normalizeToolArgs(toolName string, args map[string]any) map[string]any {
 		for _, alias := range aliasKeys {
 			delete(args, alias)
 		}
-	case "skill.read":
+	case "skill.read", "skill.run":
 		if getStringArg(args, "name") == "" {
 			for _, key := range []string{"skill", "skill_name", "skillName", "id"} {
 				if value := getStringArg(args, key); value != "" {
@@ -1548,53 +1647,30 @@ func sanitizePathArg(path string) string {
 }
 
 func (e *Engine) allowedTools(cfg config.Config) []string {
-	toolsList := []string{"fs.read", "fs.list", "fs.write", "fs.append", "fs.delete", "fs.move", "fs.edit", "code.search", "config.get", "config.set", "secrets.get", "secrets.set", "secrets.list", "skill.list", "skill.read", "scheduler.list", "scheduler.add", "scheduler.remove", "scheduler.pause", "scheduler.resume", "session.list", "session.close", "agent.list", "agent.create", "agent.switch", "agent.profile.get", "agent.profile.set", "agent.message.send", "agent.message.inbox", "agent.run", "agent.prompt.read", "agent.prompt.update", "agent.prompt.suggest", "policy.list", "policy.grant", "policy.revoke", "run.list", "run.get", "run.cancel", "metrics.get", "memory.search", "memory.write", "memory.update", "memory.forget", "memory.health", "memory.checkpoint", "memory.maintenance", "decision.log", "time.now"}
+	toolsList := []string{"fs.read", "fs.list", "fs.write", "fs.append", "fs.delete", "fs.move", "fs.edit", "code.search", "config.get", "config.set", "secrets.get", "secrets.set", "secrets.list", "skill.list", "skill.read", "scheduler.list", "scheduler.add", "scheduler.remove", "scheduler.pause", "scheduler.resume", "session.list", "session.close", "agent.list", "agent.create", "agent.switch", "agent.profile.get", "agent.profile.set", "agent.message.send", "agent.message.inbox", "agent.run", "agent.prompt.read", "agent.prompt.update", "agent.prompt.suggest", "policy.list", "policy.grant", "policy.revoke", "run.list", "run.get", "run.cancel", "metrics.get", "memory.search", "memory.write", "memory.update", "memory.forget", "memory.health", "memory.checkpoint", "memory.maintenance", "memory.link", "memory.graph.query", "decision.log", "time.now"}
 	if cfg.Network.Enabled {
 		toolsList = append(toolsList, "http.request")
 	}
 	if cfg.Shell.EnableExec && cfg.Sandbox.Active && strings.ToLower(cfg.Sandbox.Provider) != "none" {
-		toolsList = append(toolsList, "shell.exec")
+		toolsList = append(toolsList, "shell.exec", "skill.run")
 	}
 	return toolsList
 }
 
-func (e *Engine) effectiveCapabilities(agentID string, allowedTools []string) []string {
-	base := append([]string(nil), allowedTools...)
-	if agentID == "default" {
-		base = append(base, "policy.admin")
-	}
-	base = policy.NormalizeCapabilities(base)
-
-	grantsPath := filepath.Join(e.rootDir, ".openclawssy", "policy", "capabilities.json")
-	persisted, err := policy.LoadGrants(grantsPath)
-	if err != nil {
-		return base
-	}
-	stored, ok := persisted[agentID]
-	if !ok {
-		return base
-	}
-
-	allowedSet := make(map[string]bool, len(allowedTools))
-	for _, tool := range allowedTools {
-		canonical := policy.CanonicalCapability(tool)
-		if canonical == "" {
-			continue
-		}
-		allowedSet[canonical] = true
-	}
-
-	out := make([]string, 0, len(stored))
-	for _, capability := range stored {
-		canonical := policy.CanonicalCapability(capability)
-		if canonical == "" {
-			continue
-		}
-		if canonical == "policy.admin" || allowedSet[canonical] {
-			out = append(out, canonical)
-		}
+func (e *Engine) coreToolOptions(cfg config.Config, allowedTools []string) tools.CoreOptions {
+	return tools.CoreOptions{
+		EnableShellExec: cfg.Shell.EnableExec && cfg.Sandbox.Active && strings.ToLower(cfg.Sandbox.Provider) != "none",
+		ConfigPath:      filepath.Join(e.rootDir, ".openclawssy", "config.json"),
+		AgentsPath:      e.agentsDir,
+		SchedulerPath:   filepath.Join(e.rootDir, ".openclawssy", "scheduler", "jobs.json"),
+		ChatstorePath:   e.agentsDir,
+		PolicyPath:      filepath.Join(e.rootDir, ".openclawssy", "policy", "capabilities.json"),
+		DefaultGrants:   allowedTools,
+		RunsPath:        filepath.Join(e.rootDir, ".openclawssy", "runs.json"),
+		RunTracker:      e.runTracker,
+		WorkspaceRoot:   e.workspaceDir,
+		AgentRunner:     &subAgentRunner{engine: e},
 	}
-	return policy.NormalizeCapabilities(out)
 }
 
 func isAgentEnabled(cfg config.Config, agentID string) bool {
@@ -1683,6 +1759,15 @@ func (s *sandboxShellExecutor) Exec(_ context.Context, command string, args []st
 	return result.Stdout, result.Stderr, result.ExitCode, err
 }
 
+func (s *sandboxShellExecutor) ExecWithEnv(ctx context.Context, command string, args []string, env []string) (string, string, int, error) {
+	cmd := sandbox.Command{Name: command, Args: args, Env: env}
+	if deadline, ok := ctx.Deadline(); ok {
+		cmd.Timeout = time.Until(deadline)
+	}
+	result, err := s.provider.Exec(cmd)
+	return result.Stdout, result.Stderr, result.ExitCode, err
+}
+
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
@@ -1747,7 +1832,12 @@ func (e *Engine) buildMemoryRecallBlock(ctx context.Context, cfg config.Config,
 			return "", err
 		}
 	}
-	if len(items) == 0 {
+	relations, err := recallGraphNeighborhood(ctx, store, query)
+	if err != nil {
+		log.Printf("runtime: memory graph recall unavailable (agent=%s): %v", agentID, err)
+		relations = nil
+	}
+	if len(items) == 0 && len(relations) == 0 {
 		return "", nil
 	}
 
@@ -1755,7 +1845,61 @@ func (e *Engine) buildMemoryRecallBlock(ctx context.Context, cfg config.Config,
 	if maxChars <= 0 {
 		maxChars = 4800
 	}
-	return formatRecallBlock(items, maxChars), nil
+	block := formatRecallBlock(items, memory.DecayPoliciesFromConfig(cfg.Memory), maxChars)
+	remaining := maxChars
+	if block != "" {
+		remaining -= len(block) + 1
+	}
+	graphBlock := formatRelationsBlock(relations, remaining)
+	switch {
+	case block == "":
+		return graphBlock, nil
+	case graphBlock == "":
+		return block, nil
+	default:
+		return block + "\n" + graphBlock, nil
+	}
+}
+
+func recallGraphNeighborhood(ctx context.Context, store *memorystore.SQLiteStore, query string) ([]memory.Relation, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	entities, err := store.MatchEntities(ctx, query, 6)
+	if err != nil || len(entities) == 0 {
+		return nil, err
+	}
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+	return store.RelationsForEntities(ctx, ids, "", 24)
+}
+
+func formatRelationsBlock(relations []memory.Relation, maxChars int) string {
+	if len(relations) == 0 || maxChars <= 0 {
+		return ""
+	}
+	header := "--- KNOWN RELATIONS ---"
+	footer := "------------------------"
+	lines := []string{header}
+	used := len(header) + 1 + len(footer)
+	for _, rel := range relations {
+		line := policy.RedactString(fmt.Sprintf("[REL] %s %s %s", rel.SourceName, rel.Relation, rel.TargetName))
+		if len(line) > 240 {
+			line = line[:240] + "..."
+		}
+		if used+len(line)+1 > maxChars {
+			break
+		}
+		lines = append(lines, line)
+		used += len(line) + 1
+	}
+	if len(lines) == 1 {
+		return ""
+	}
+	lines = append(lines, footer)
+	return strings.Join(lines, "\n")
 }
 
 func recallQueryFromMessages(message string, messages []agent.ChatMessage) string {
@@ -1777,15 +1921,24 @@ func recallQueryFromMessages(message string, messages []agent.ChatMessage) strin
 	return joined
 }
 
-func formatRecallBlock(items []memory.MemoryItem, maxChars int) string {
+func formatRecallBlock(items []memory.MemoryItem, policies memory.DecayPolicies, maxChars int) string {
 	if len(items) == 0 {
 		return ""
 	}
-	sorted := append([]memory.MemoryItem(nil), items...)
 	now := time.Now().UTC()
+	sorted := make([]memory.MemoryItem, 0, len(items))
+	for _, item := range items {
+		if policies.For(item.Kind).Expired(item, now) {
+			continue
+		}
+		sorted = append(sorted, item)
+	}
+	score := func(item memory.MemoryItem) float64 {
+		return float64(item.Importance)*2*policies.For(item.Kind).Weight(item, now) + recencyBoost(now, item.UpdatedAt)
+	}
 	sort.Slice(sorted, func(i, j int) bool {
-		si := float64(sorted[i].Importance)*2 + recencyBoost(now, sorted[i].UpdatedAt)
-		sj := float64(sorted[j].Importance)*2 + recencyBoost(now, sorted[j].UpdatedAt)
+		si := score(sorted[i])
+		sj := score(sorted[j])
 		if si == sj {
 			return sorted[i].UpdatedAt.After(sorted[j].UpdatedAt)
 		}
@@ -1810,6 +1963,7 @@ func formatRecallBlock(items []memory.MemoryItem, maxChars int) string {
 		if len(line) > 420 {
 			line = line[:420] + "..."
 		}
+		line += recallCitation(item)
 		if used+len(line)+1 > maxChars {
 			break
 		}
@@ -1829,6 +1983,26 @@ func formatRecallBlock(items []memory.MemoryItem, maxChars int) string {
 	return strings.Join(lines, "\n")
 }
 
+func recallCitation(item memory.MemoryItem) string {
+	parts := []string{}
+	if source := strings.TrimSpace(item.Provenance.Source); source != "" {
+		parts = append(parts, "src: "+source)
+	}
+	if n := len(item.Provenance.RunIDs); n > 0 {
+		parts = append(parts, "run "+item.Provenance.RunIDs[n-1])
+	}
+	if n := len(item.Provenance.SessionIDs); n > 0 {
+		parts = append(parts, "session "+item.Provenance.SessionIDs[n-1])
+	}
+	if !item.UpdatedAt.IsZero() {
+		parts = append(parts, item.UpdatedAt.UTC().Format("2006-01-02"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + policy.RedactString(strings.Join(parts, ", ")) + ")"
+}
+
 func recencyBoost(now, ts time.Time) float64 {
 	if ts.IsZero() {
 		return 0
@@ -0,0 +1,67 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"openclawssy/internal/audit"
+	"openclawssy/internal/config"
+	"openclawssy/internal/mcp"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/sandbox"
+)
+
+const eventMCPUnavailable = "mcp.unavailable"
+
+// mountMCPServers connects the configured MCP servers for one run and lists
+// their tools. A server that cannot be reached is audited and skipped, so one
+// broken server does not fail every run. Callers must close the returned
+// clients when the run ends.
+func mountMCPServers(ctx context.Context, cfg config.Config, provider sandbox.Provider, aud *audit.Logger) ([]*mcp.Client, []mcp.MountedTool) {
+	if !cfg.MCP.Enabled {
+		return nil, nil
+	}
+	timeout := time.Duration(cfg.MCP.TimeoutSeconds) * time.Second
+	var clients []*mcp.Client
+	var mounted []mcp.MountedTool
+	for _, server := range cfg.MCP.Servers {
+		if server.Disabled {
+			continue
+		}
+		client, err := mcp.Dial(ctx, server, provider, timeout)
+		if err != nil {
+			_ = aud.LogEvent(ctx, eventMCPUnavailable, map[string]any{"server": server.Name, "error": err.Error()})
+			continue
+		}
+		tools, err := mcp.Mount(ctx, client)
+		if err != nil {
+			_ = client.Close()
+			_ = aud.LogEvent(ctx, eventMCPUnavailable, map[string]any{"server": server.Name, "error": err.Error()})
+			continue
+		}
+		clients = append(clients, client)
+		mounted = append(mounted, tools...)
+	}
+	return clients, mounted
+}
+
+func mountedToolNames(mounted []mcp.MountedTool) []string {
+	names := make([]string, 0, len(mounted))
+	for _, tool := range mounted {
+		names = append(names, strings.ToLower(tool.Spec.Name))
+	}
+	return names
+}
+
+// grantedMountedTools keeps the mounted tools the run's agent may call, so the
+// model is not offered MCP tools it has no grant for.
+func grantedMountedTools(enforcer *policy.Enforcer, agentID string, mounted []mcp.MountedTool) []mcp.MountedTool {
+	var out []mcp.MountedTool
+	for _, tool := range mounted {
+		if enforcer.CheckTool(agentID, strings.ToLower(tool.Spec.Name)) == nil {
+			out = append(out, tool)
+		}
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"openclawssy/internal/audit"
+	"openclawssy/internal/chatstore"
+	"openclawssy/internal/config"
+	"openclawssy/internal/mcp"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/sandbox"
+	"openclawssy/internal/tools"
+)
+
+const mcpServeSource = "mcp"
+
+var mcpAgentRunSpec = tools.ToolSpec{
+	Name:        "agent.run",
+	Description: "Run the Openclawssy agent on a message and return its reply. Pass session_id to continue a conversation, or new_session=true to start one.",
+	Required:    []string{"message"},
+	ArgTypes: map[string]tools.ArgType{
+		"message":       tools.ArgTypeString,
+		"session_id":    tools.ArgTypeString,
+		"new_session":   tools.ArgTypeBool,
+		"thinking_mode": tools.ArgTypeString,
+	},
+}
+
+// MCPServeBackend exposes one agent to MCP hosts: an agent.run entry point
+// plus a selected subset of the agent's tools. Calls go through a registry
+// built from the agent's effective capabilities, so grants, workspace path
+// guards and audit logging apply exactly as they do inside a run.
+type MCPServeBackend struct {
+	engine   *Engine
+	agentID  string
+	exposed  []string
+	enforcer *policy.Enforcer
+	registry *tools.Registry
+	runs     *tools.Registry
+	aud      *audit.Logger
+	provider sandbox.Provider
+}
+
+func (e *Engine) NewMCPServeBackend(ctx context.Context, agentID string, exposed []string) (*MCPServeBackend, error) {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == "" {
+		return nil, errors.New("runtime: agent id is required")
+	}
+	cfg, err := config.LoadOrDefault(filepath.Join(e.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("runtime: load config: %w", err)
+	}
+	if !isAgentEnabled(cfg, agentID) {
+		return nil, fmt.Errorf("runtime: agent %q is inactive by configuration", agentID)
+	}
+
+	allowedTools := e.allowedTools(cfg)
+	evaluator, err := e.policyEvaluator(cfg, allowedTools, "")
+	if err != nil {
+		return nil, err
+	}
+	enforcer := evaluator.Enforcer(agentID)
+	aud, err := e.auditLogger(cfg, agentID)
+	if err != nil {
+		return nil, err
+	}
+	b := &MCPServeBackend{engine: e, agentID: agentID, enforcer: enforcer, aud: aud}
+
+	b.registry = tools.NewRegistry(enforcer, aud)
+	if err := tools.RegisterCoreWithOptions(b.registry, e.coreToolOptions(cfg, allowedTools)); err != nil {
+		_ = aud.Close()
+		return nil, fmt.Errorf("runtime: register core tools: %w", err)
+	}
+	b.registry.SetShellAllowedCommands(cfg.Shell.AllowedCommands)
+	if cfg.Sandbox.Active {
+		provider, err := sandbox.NewProvider(cfg.Sandbox.Provider, e.workspaceDir)
+		if err != nil {
+			_ = aud.Close()
+			return nil, fmt.Errorf("runtime: create sandbox provider: %w", err)
+		}
+		if err := provider.Start(ctx); err != nil {
+			_ = aud.Close()
+			return nil, fmt.Errorf("runtime: start sandbox provider: %w", err)
+		}
+		b.provider = provider
+		if cfg.Shell.EnableExec && sandbox.ShellExecAllowed(provider) {
+			b.registry.SetShellExecutor(&sandboxShellExecutor{provider: provider})
+		}
+	}
+
+	if err := e.enableApprovals(cfg, agentID, enforcer, b.registry, aud, nil); err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+
+	b.runs = tools.NewRegistry(enforcer, aud)
+	if err := b.runs.Register(mcpAgentRunSpec, b.agentRun); err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(exposed))
+	for _, name := range exposed {
+		canonical := policy.CanonicalCapability(name)
+		if canonical == "" || seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		b.exposed = append(b.exposed, canonical)
+	}
+	return b, nil
+}
+
+// Tools lists the exposed tools the agent currently holds a capability for.
+func (b *MCPServeBackend) Tools() []tools.ToolSpec {
+	specs := make(map[string]tools.ToolSpec)
+	for _, spec := range b.registry.List() {
+		specs[spec.Name] = spec
+	}
+	specs[mcpAgentRunSpec.Name] = mcpAgentRunSpec
+
+	out := make([]tools.ToolSpec, 0, len(b.exposed))
+	for _, name := range b.exposed {
+		spec, ok := specs[name]
+		if !ok || !b.enforcer.HasCapability(b.agentID, name) {
+			continue
+		}
+		out = append(out, spec)
+	}
+	return out
+}
+
+// CallTool runs an exposed tool. Tools outside the exposed set are unknown to
+// the host; exposed tools the agent lacks a grant for are denied (and audited)
+// by the registry.
+func (b *MCPServeBackend) CallTool(ctx context.Context, name string, args map[string]any) (map[string]any, error) {
+	canonical := policy.CanonicalCapability(name)
+	if !b.isExposed(canonical) {
+		return nil, mcp.ErrUnknownTool
+	}
+	if canonical == mcpAgentRunSpec.Name {
+		return b.runs.Execute(ctx, b.agentID, canonical, b.engine.workspaceDir, args)
+	}
+	return b.registry.Execute(ctx, b.agentID, canonical, b.engine.workspaceDir, normalizeToolArgs(canonical, args))
+}
+
+func (b *MCPServeBackend) Close() error {
+	if b.provider != nil {
+		_ = b.provider.Stop()
+	}
+	return b.aud.Close()
+}
+
+func (b *MCPServeBackend) isExposed(name string) bool {
+	for _, exposed := range b.exposed {
+		if exposed == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *MCPServeBackend) agentRun(ctx context.Context, req tools.Request) (map[string]any, error) {
+	message := strings.TrimSpace(getStringArg(req.Args, "message"))
+	if message == "" {
+		return nil, errors.New("message is required")
+	}
+	sessionID := strings.TrimSpace(getStringArg(req.Args, "session_id"))
+	if newSession, _ := req.Args["new_session"].(bool); newSession && sessionID == "" {
+		session, err := b.engine.chatStore.CreateSession(chatstore.CreateSessionInput{
+			AgentID: b.agentID,
+			Channel: mcpServeSource,
+			UserID:  "mcp",
+			RoomID:  "host",
+			Title:   summarizeTitle(message),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create session: %w", err)
+		}
+		sessionID = session.SessionID
+	}
+	if sessionID != "" {
+		session, err := b.engine.chatStore.GetSession(sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("session %q: %w", sessionID, err)
+		}
+		if session.AgentID != b.agentID {
+			return nil, fmt.Errorf("session %q belongs to agent %q", sessionID, session.AgentID)
+		}
+		if session.IsClosed() {
+			return nil, fmt.Errorf("session %q is closed", sessionID)
+		}
+		if err := b.engine.chatStore.AppendMessage(sessionID, chatstore.Message{Role: "user", Content: message, TS: time.Now().UTC()}); err != nil {
+			return nil, fmt.Errorf("record message: %w", err)
+		}
+	}
+
+	res, err := b.engine.ExecuteWithInput(ctx, ExecuteInput{
+		AgentID:      b.agentID,
+		Message:      message,
+		Source:       mcpServeSource,
+		SessionID:    sessionID,
+		ThinkingMode: strings.TrimSpace(getStringArg(req.Args, "thinking_mode")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{
+		"agent_id":      b.agentID,
+		"run_id":        res.RunID,
+		"output":        res.FinalText,
+		"artifact_path": res.ArtifactPath,
+		"duration_ms":   res.DurationMS,
+		"tool_calls":    res.ToolCalls,
+	}
+	if sessionID != "" {
+		out["session_id"] = sessionID
+	}
+	return out, nil
+}
+
+func summarizeTitle(message string) string {
+	title := strings.Join(strings.Fields(message), " ")
+	if len(title) > 60 {
+		title = strings.TrimSpace(title[:60]) + "..."
+	}
+	return title
+}
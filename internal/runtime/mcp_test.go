@@ -0,0 +1,158 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"openclawssy/internal/audit"
+	"openclawssy/internal/config"
+	"openclawssy/internal/mcp"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/tools"
+)
+
+func TestMountMCPServersSkipsUnavailableServers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if len(req.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		result := `{}`
+		switch req.Method {
+		case "initialize":
+			result = `{"protocolVersion":"2025-06-18","capabilities":{},"serverInfo":{"name":"docs"}}`
+		case "tools/list":
+			result = `{"tools":[{"name":"lookup","inputSchema":{"type":"object","properties":{"q":{"type":"string"}}}}]}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + result + `}`))
+	}))
+	defer srv.Close()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	aud, err := audit.NewLogger(auditPath, policy.RedactValue)
+	if err != nil {
+		t.Fatalf("new audit logger: %v", err)
+	}
+	defer aud.Close()
+
+	cfg := config.Default()
+	cfg.MCP.Enabled = true
+	cfg.MCP.Servers = []config.MCPServerConfig{
+		{Name: "docs", Transport: "http", URL: srv.URL},
+		{Name: "local", Transport: "stdio", Command: "mcp-local"},
+		{Name: "off", Transport: "http", URL: srv.URL, Disabled: true},
+	}
+
+	clients, mounted := mountMCPServers(context.Background(), cfg, nil, aud)
+	defer func() {
+		for _, client := range clients {
+			_ = client.Close()
+		}
+	}()
+	names := mountedToolNames(mounted)
+	if len(clients) != 1 || len(names) != 1 || names[0] != "mcp.docs.lookup" {
+		t.Fatalf("expected only the reachable server's tools, got %v", names)
+	}
+
+	_ = aud.Close()
+	raw, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if !strings.Contains(string(raw), eventMCPUnavailable) || !strings.Contains(string(raw), `"server":"local"`) {
+		t.Fatalf("expected stdio server without sandbox to be audited as unavailable, got %s", raw)
+	}
+
+	cfg.MCP.Enabled = false
+	if clients, mounted := mountMCPServers(context.Background(), cfg, nil, aud); clients != nil || mounted != nil {
+		t.Fatal("expected nothing mounted when mcp is disabled")
+	}
+}
+
+func TestMountedMCPToolsRequireExplicitGrant(t *testing.T) {
+	mounted := []mcp.MountedTool{
+		{Spec: tools.ToolSpec{Name: "mcp.docs.lookup"}},
+		{Spec: tools.ToolSpec{Name: "mcp.files.read"}},
+	}
+	ev := policy.Evaluator{
+		Env: policy.Environment{
+			Workspace:             t.TempDir(),
+			DefaultCapabilities:   []string{"fs.read"},
+			GrantOnlyCapabilities: mountedToolNames(mounted),
+		},
+		Grants: map[string][]string{"docs": {"fs.read", "mcp.docs.*"}},
+	}
+	if got := grantedMountedTools(ev.Enforcer("default"), "default", mounted); len(got) != 0 {
+		t.Fatalf("expected no mcp tools without a grant, got %+v", got)
+	}
+	got := grantedMountedTools(ev.Enforcer("docs"), "docs", mounted)
+	if len(got) != 1 || got[0].Spec.Name != "mcp.docs.lookup" {
+		t.Fatalf("expected only the granted server's tools, got %+v", got)
+	}
+}
+
+func TestMCPServeBackendEnforcesExposureAndPathGuards(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("ZAI_API_KEY", "test-key")
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	if err := e.Init("default", false); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "workspace", "notes.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write workspace file: %v", err)
+	}
+
+	b, err := e.NewMCPServeBackend(context.Background(), "default", []string{"agent.run", "fs.read", "fs.list", "fs.read"})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	defer b.Close()
+
+	names := make([]string, 0)
+	for _, spec := range b.Tools() {
+		names = append(names, spec.Name)
+	}
+	if strings.Join(names, ",") != "agent.run,fs.read,fs.list" {
+		t.Fatalf("unexpected exposed tools: %v", names)
+	}
+
+	res, err := b.CallTool(context.Background(), "fs.read", map[string]any{"path": "notes.txt"})
+	if err != nil || !strings.Contains(res["content"].(string), "hello") {
+		t.Fatalf("expected workspace read, got %+v %v", res, err)
+	}
+	if _, err := b.CallTool(context.Background(), "fs.read", map[string]any{"path": "../.openclawssy/config.json"}); err == nil {
+		t.Fatal("expected path guard to deny reads outside the workspace")
+	}
+	if _, err := b.CallTool(context.Background(), "fs.write", map[string]any{"path": "x.txt", "content": "x"}); !errors.Is(err, mcp.ErrUnknownTool) {
+		t.Fatalf("expected unexposed tool to be unknown, got %v", err)
+	}
+
+	run, err := b.CallTool(context.Background(), "agent.run", map[string]any{"message": `/tool fs.list {"path":"."}`, "new_session": true})
+	if err != nil {
+		t.Fatalf("agent.run: %v", err)
+	}
+	sessionID, _ := run["session_id"].(string)
+	if run["run_id"] == "" || sessionID == "" {
+		t.Fatalf("expected run and session ids, got %+v", run)
+	}
+	session, err := e.chatStore.GetSession(sessionID)
+	if err != nil || session.Channel != "mcp" {
+		t.Fatalf("expected mcp session, got %+v %v", session, err)
+	}
+}
@@ -0,0 +1,247 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/memory"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/tools"
+)
+
+const (
+	memoryHousekeepingBaseBackoff = time.Minute
+	memoryHousekeepingMaxBackoff  = 6 * time.Hour
+	memoryHousekeepingToolTimeout = 5 * time.Minute
+)
+
+type memoryToolFunc func(ctx context.Context, agentID, toolName string, args map[string]any) (map[string]any, error)
+
+type MemoryHousekeeper struct {
+	engine   *Engine
+	ticker   *time.Ticker
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	nowFn    func() time.Time
+	runTool  memoryToolFunc
+	interval time.Duration
+}
+
+func NewMemoryHousekeeper(engine *Engine, tickInterval time.Duration) *MemoryHousekeeper {
+	if tickInterval <= 0 {
+		tickInterval = time.Minute
+	}
+	h := &MemoryHousekeeper{
+		engine:   engine,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+		nowFn:    time.Now,
+		interval: tickInterval,
+	}
+	h.runTool = engine.executeMemoryTool
+	return h
+}
+
+func (h *MemoryHousekeeper) Start() {
+	h.ticker = time.NewTicker(h.interval)
+	go func() {
+		defer close(h.doneCh)
+		for {
+			select {
+			case <-h.stopCh:
+				return
+			case <-h.ticker.C:
+				h.RunOnce(context.Background())
+			}
+		}
+	}()
+}
+
+func (h *MemoryHousekeeper) Stop() {
+	close(h.stopCh)
+	if h.ticker != nil {
+		h.ticker.Stop()
+		<-h.doneCh
+	}
+}
+
+func (h *MemoryHousekeeper) RunOnce(ctx context.Context) {
+	cfg, err := config.LoadOrDefault(filepath.Join(h.engine.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		log.Printf("runtime: memory housekeeping config unavailable: %v", err)
+		return
+	}
+	if !cfg.Memory.Enabled {
+		return
+	}
+	for _, agentID := range h.memoryAgents(cfg) {
+		if err := h.checkAgent(ctx, cfg, agentID, h.nowFn().UTC()); err != nil {
+			log.Printf("runtime: memory housekeeping failed (agent=%s): %v", agentID, err)
+		}
+	}
+}
+
+func (h *MemoryHousekeeper) memoryAgents(cfg config.Config) []string {
+	entries, err := os.ReadDir(h.engine.agentsDir)
+	if err != nil {
+		return nil
+	}
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || !isAgentEnabled(cfg, entry.Name()) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(h.engine.agentsDir, entry.Name(), "memory")); err != nil || !info.IsDir() {
+			continue
+		}
+		out = append(out, entry.Name())
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (h *MemoryHousekeeper) checkAgent(ctx context.Context, cfg config.Config, agentID string, now time.Time) error {
+	agentsDir := h.engine.agentsDir
+	status, _, err := memory.LoadHousekeepingStatus(agentsDir, agentID)
+	if err != nil {
+		status = memory.HousekeepingStatus{}
+	}
+	status.UpdatedAt = now
+	if status.NextMaintenanceAt.IsZero() {
+		status.NextMaintenanceAt = now.Add(time.Duration(cfg.Memory.Housekeeping.MaintenanceIntervalHours) * time.Hour)
+	}
+
+	pending, err := pendingCheckpointEvents(agentsDir, agentID, cfg.Memory.Housekeeping.CheckpointEventThreshold)
+	if err != nil {
+		return err
+	}
+	status.PendingEvents = len(pending)
+	if len(pending) > 0 {
+		status.LastEventAt = pending[len(pending)-1].Timestamp
+	}
+
+	if now.Before(status.BackoffUntil) {
+		return memory.WriteHousekeepingStatus(agentsDir, agentID, status)
+	}
+
+	if cfg.Memory.AutoCheckpoint && len(pending) > 0 {
+		trigger := ""
+		switch {
+		case len(pending) >= cfg.Memory.Housekeeping.CheckpointEventThreshold:
+			trigger = "event_threshold"
+		case now.Sub(status.LastEventAt) >= time.Duration(cfg.Memory.Housekeeping.CheckpointIdleMinutes)*time.Minute:
+			trigger = "idle"
+		}
+		if trigger != "" {
+			res, err := h.callTool(ctx, agentID, "memory.checkpoint")
+			status.LastCheckpointAt = now
+			status.LastCheckpointTrigger = trigger
+			switch {
+			case err != nil:
+				status.LastCheckpointResult = "error"
+				recordHousekeepingFailure(&status, now, err)
+				return memory.WriteHousekeepingStatus(agentsDir, agentID, status)
+			case res["distillation_mode"] == "deterministic_fallback":
+				status.LastCheckpointResult = "deterministic_fallback"
+				status.PendingEvents = 0
+				recordHousekeepingFailure(&status, now, fmt.Errorf("model distillation failed; used deterministic fallback"))
+				return memory.WriteHousekeepingStatus(agentsDir, agentID, status)
+			default:
+				status.LastCheckpointResult = "ok"
+				status.PendingEvents = 0
+				status.ConsecutiveFailures = 0
+				status.BackoffUntil = time.Time{}
+				status.LastError = ""
+			}
+		}
+	}
+
+	if !now.Before(status.NextMaintenanceAt) {
+		if _, err := h.callTool(ctx, agentID, "memory.maintenance"); err != nil {
+			recordHousekeepingFailure(&status, now, err)
+			return memory.WriteHousekeepingStatus(agentsDir, agentID, status)
+		}
+		status.LastMaintenanceAt = now
+		status.NextMaintenanceAt = now.Add(time.Duration(cfg.Memory.Housekeeping.MaintenanceIntervalHours) * time.Hour)
+		status.ConsecutiveFailures = 0
+		status.BackoffUntil = time.Time{}
+		status.LastError = ""
+	}
+	return memory.WriteHousekeepingStatus(agentsDir, agentID, status)
+}
+
+func (h *MemoryHousekeeper) callTool(ctx context.Context, agentID, toolName string) (map[string]any, error) {
+	toolCtx, cancel := context.WithTimeout(ctx, memoryHousekeepingToolTimeout)
+	defer cancel()
+	return h.runTool(toolCtx, agentID, toolName, map[string]any{})
+}
+
+func pendingCheckpointEvents(agentsDir, agentID string, limit int) ([]memory.Event, error) {
+	cursor := memory.EventCursor{}
+	if latest, ok, err := memory.LoadLatestCheckpointRecord(agentsDir, agentID); err != nil {
+		return nil, err
+	} else if ok {
+		cursor = latest.NextCursor()
+	}
+	events, _, err := memory.ReadEventsFrom(agentsDir, agentID, cursor, limit+2)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]memory.Event, 0, len(events))
+	for _, evt := range events {
+		if evt.Type == memory.EventTypeCheckpoint || evt.Type == memory.EventTypeMaintenance {
+			continue
+		}
+		out = append(out, evt)
+	}
+	return out, nil
+}
+
+func recordHousekeepingFailure(status *memory.HousekeepingStatus, now time.Time, err error) {
+	status.ConsecutiveFailures++
+	backoff := memoryHousekeepingBaseBackoff
+	for i := 1; i < status.ConsecutiveFailures && backoff < memoryHousekeepingMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > memoryHousekeepingMaxBackoff {
+		backoff = memoryHousekeepingMaxBackoff
+	}
+	status.BackoffUntil = now.Add(backoff)
+	status.LastError = policy.RedactString(strings.TrimSpace(err.Error()))
+}
+
+func (e *Engine) executeMemoryTool(ctx context.Context, agentID, toolName string, args map[string]any) (map[string]any, error) {
+	cfg, err := config.LoadOrDefault(filepath.Join(e.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("runtime: load config: %w", err)
+	}
+	aud, err := e.auditLogger(cfg, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = aud.Close() }()
+
+	// Housekeeping acts for the agent, so it gets the agent's own grants,
+	// rules and policy document: an agent denied memory.checkpoint is not
+	// checkpointed behind its back.
+	evaluator, err := e.policyEvaluator(cfg, e.allowedTools(cfg), "")
+	if err != nil {
+		return nil, err
+	}
+	registry := tools.NewRegistry(evaluator.Enforcer(agentID), aud)
+	if err := tools.RegisterCoreWithOptions(registry, tools.CoreOptions{
+		ConfigPath:    filepath.Join(e.rootDir, ".openclawssy", "config.json"),
+		AgentsPath:    e.agentsDir,
+		WorkspaceRoot: e.workspaceDir,
+	}); err != nil {
+		return nil, fmt.Errorf("runtime: register core tools: %w", err)
+	}
+	return registry.Execute(ctx, agentID, toolName, e.workspaceDir, args)
+}
@@ -0,0 +1,137 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/memory"
+	"openclawssy/internal/policy"
+)
+
+func TestMemoryHousekeeperCheckpointsOnThresholdAndBacksOff(t *testing.T) {
+	root := t.TempDir()
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Memory.Enabled = true
+	cfg.Memory.AutoCheckpoint = true
+	cfg.Memory.Housekeeping.CheckpointEventThreshold = 2
+	if err := config.Save(filepath.Join(root, ".openclawssy", "config.json"), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for i := 0; i < 2; i++ {
+		if err := memory.AppendEvent(e.agentsDir, "default", memory.Event{Type: memory.EventTypeUserMessage, Text: "hello", Timestamp: now.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+	}
+
+	calls := []string{}
+	checkpointResult := map[string]any{"checkpoint_created": true, "distillation_mode": "deterministic_fallback"}
+	h := NewMemoryHousekeeper(e, time.Minute)
+	h.nowFn = func() time.Time { return now.Add(time.Minute) }
+	h.runTool = func(ctx context.Context, agentID, toolName string, args map[string]any) (map[string]any, error) {
+		calls = append(calls, toolName)
+		if toolName == "memory.maintenance" {
+			return nil, errors.New("unexpected maintenance")
+		}
+		return checkpointResult, nil
+	}
+
+	h.RunOnce(context.Background())
+	if len(calls) != 1 || calls[0] != "memory.checkpoint" {
+		t.Fatalf("expected one checkpoint call, got %#v", calls)
+	}
+	status, ok, err := memory.LoadHousekeepingStatus(e.agentsDir, "default")
+	if err != nil || !ok {
+		t.Fatalf("load housekeeping status: ok=%v err=%v", ok, err)
+	}
+	if status.LastCheckpointTrigger != "event_threshold" || status.LastCheckpointResult != "deterministic_fallback" {
+		t.Fatalf("unexpected checkpoint status: %#v", status)
+	}
+	if status.ConsecutiveFailures != 1 || !status.BackoffUntil.After(now.Add(time.Minute)) {
+		t.Fatalf("expected model fallback to trigger backoff, got %#v", status)
+	}
+
+	h.RunOnce(context.Background())
+	if len(calls) != 1 {
+		t.Fatalf("expected no tool calls during backoff, got %#v", calls)
+	}
+
+	checkpointResult = map[string]any{"checkpoint_created": true, "distillation_mode": "model"}
+	h.nowFn = func() time.Time { return status.BackoffUntil.Add(time.Second) }
+	h.RunOnce(context.Background())
+	if len(calls) != 2 {
+		t.Fatalf("expected checkpoint retry after backoff, got %#v", calls)
+	}
+	status, _, _ = memory.LoadHousekeepingStatus(e.agentsDir, "default")
+	if status.ConsecutiveFailures != 0 || status.LastError != "" || status.LastCheckpointResult != "ok" {
+		t.Fatalf("expected recovered status, got %#v", status)
+	}
+	if status.NextMaintenanceAt.IsZero() || !status.LastMaintenanceAt.IsZero() {
+		t.Fatalf("expected maintenance to be scheduled but not yet run, got %#v", status)
+	}
+}
+
+func TestMemoryHousekeeperRunsDueMaintenance(t *testing.T) {
+	root := t.TempDir()
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Memory.Enabled = true
+	if err := config.Save(filepath.Join(root, ".openclawssy", "config.json"), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := memory.WriteHousekeepingStatus(e.agentsDir, "default", memory.HousekeepingStatus{NextMaintenanceAt: now.Add(-time.Minute)}); err != nil {
+		t.Fatalf("write housekeeping status: %v", err)
+	}
+
+	calls := []string{}
+	h := NewMemoryHousekeeper(e, time.Minute)
+	h.nowFn = func() time.Time { return now }
+	h.runTool = func(ctx context.Context, agentID, toolName string, args map[string]any) (map[string]any, error) {
+		calls = append(calls, toolName)
+		return map[string]any{"ok": true}, nil
+	}
+	h.RunOnce(context.Background())
+	if len(calls) != 1 || calls[0] != "memory.maintenance" {
+		t.Fatalf("expected maintenance call, got %#v", calls)
+	}
+	status, _, _ := memory.LoadHousekeepingStatus(e.agentsDir, "default")
+	if !status.LastMaintenanceAt.Equal(now) || !status.NextMaintenanceAt.Equal(now.Add(168*time.Hour)) {
+		t.Fatalf("unexpected maintenance schedule: %#v", status)
+	}
+}
+
+func TestMemoryHousekeeperToolCallsUseAgentGrants(t *testing.T) {
+	root := t.TempDir()
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Memory.Enabled = true
+	if err := config.Save(filepath.Join(root, ".openclawssy", "config.json"), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	grantsPath := filepath.Join(root, ".openclawssy", "policy", "capabilities.json")
+	if err := policy.SaveGrants(grantsPath, map[string][]string{"default": {"memory.search"}}); err != nil {
+		t.Fatalf("save grants: %v", err)
+	}
+
+	_, err = e.executeMemoryTool(context.Background(), "default", "memory.checkpoint", map[string]any{})
+	var capErr *policy.CapabilityError
+	if !errors.As(err, &capErr) {
+		t.Fatalf("expected the agent's grants to deny memory.checkpoint, got %v", err)
+	}
+}
@@ -48,8 +48,59 @@ func TestBuildMemoryRecallBlockIncludesRelevantItems(t *testing.T) {
 
 func TestBuildMemoryRecallBlockRespectsSizeCap(t *testing.T) {
 	items := []memory.MemoryItem{{ID: "mem_123456789", Content: strings.Repeat("x", 500), Importance: 5, UpdatedAt: time.Now().UTC()}}
-	block := formatRecallBlock(items, 80)
+	block := formatRecallBlock(items, nil, 80)
 	if len(block) > 80 {
 		t.Fatalf("expected block length <= 80, got %d", len(block))
 	}
 }
+
+func TestFormatRecallBlockCitesProvenanceAndDropsExpired(t *testing.T) {
+	now := time.Now().UTC()
+	policies := memory.DecayPoliciesFromConfig(config.MemoryConfig{KindPolicies: config.DefaultMemoryKindPolicies()})
+	items := []memory.MemoryItem{
+		{ID: "mem_keep", Kind: "decision", Content: "Deploys happen on Tuesdays.", Importance: 4, UpdatedAt: now, Provenance: memory.Provenance{Source: "checkpoint", RunIDs: []string{"run_1"}, SessionIDs: []string{"sess_1"}}},
+		{ID: "mem_old", Kind: "incident", Content: "Disk filled up on build host.", Importance: 5, UpdatedAt: now.Add(-60 * 24 * time.Hour)},
+	}
+	block := formatRecallBlock(items, policies, 4000)
+	if !strings.Contains(block, "(src: checkpoint, run run_1, session sess_1, "+now.Format("2006-01-02")+")") {
+		t.Fatalf("expected provenance citation, got %q", block)
+	}
+	if strings.Contains(block, "Disk filled up") {
+		t.Fatalf("expected expired incident to be excluded, got %q", block)
+	}
+}
+
+func TestBuildMemoryRecallBlockExpandsGraphNeighbors(t *testing.T) {
+	root := t.TempDir()
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	dbPath := filepath.Join(root, ".openclawssy", "agents", "default", "memory", "memory.db")
+	store, err := memorystore.OpenSQLite(dbPath, "default")
+	if err != nil {
+		t.Fatalf("open memory store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if _, err := store.Link(context.Background(), memory.Relation{SourceName: "checkout-api", Relation: "depends_on", TargetName: "billing-db"}, "service", "database"); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if _, err := store.Link(context.Background(), memory.Relation{SourceName: "unrelated-svc", Relation: "owned_by", TargetName: "ops"}, "", ""); err != nil {
+		t.Fatalf("link unrelated: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Memory.Enabled = true
+
+	block, err := e.buildMemoryRecallBlock(context.Background(), cfg, "default", "is checkout-api healthy?", nil)
+	if err != nil {
+		t.Fatalf("build memory recall block: %v", err)
+	}
+	if !strings.Contains(block, "[REL] checkout-api depends_on billing-db") {
+		t.Fatalf("expected one-hop relation in recall block, got %q", block)
+	}
+	if strings.Contains(block, "unrelated-svc") {
+		t.Fatalf("expected unmatched entities to be excluded, got %q", block)
+	}
+}
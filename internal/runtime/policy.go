@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/policy"
+)
+
+// PolicyEvaluator returns the evaluator runs would use for the current config,
+// grants and policy document. A non-empty documentPath evaluates that document
+// instead of .openclawssy/policy/policy.json, so a candidate policy can be
+// checked before it is installed.
+func (e *Engine) PolicyEvaluator(documentPath string) (policy.Evaluator, error) {
+	cfg, err := config.LoadOrDefault(filepath.Join(e.rootDir, ".openclawssy", "config.json"))
+	if err != nil {
+		return policy.Evaluator{}, fmt.Errorf("runtime: load config: %w", err)
+	}
+	return e.policyEvaluator(cfg, e.allowedTools(cfg), documentPath)
+}
+
+// PolicyDocumentPath is where runs look for the declarative policy document.
+func (e *Engine) PolicyDocumentPath() string {
+	return filepath.Join(e.rootDir, ".openclawssy", "policy", "policy.json")
+}
+
+// policyEvaluator loads grants, argument rules and the policy document. An
+// unreadable grants file falls back to config defaults as before, but invalid
+// rules or an invalid document fail closed since ignoring them would widen
+// what agents may do.
+func (e *Engine) policyEvaluator(cfg config.Config, allowedTools []string, documentPath string) (policy.Evaluator, error) {
+	grantsPath := filepath.Join(e.rootDir, ".openclawssy", "policy", "capabilities.json")
+	grants, err := policy.LoadGrants(grantsPath)
+	if err != nil {
+		grants = map[string][]string{}
+	}
+	rules, err := policy.LoadArgRules(grantsPath)
+	if err != nil {
+		return policy.Evaluator{}, fmt.Errorf("runtime: load argument rules: %w", err)
+	}
+	if strings.TrimSpace(documentPath) == "" {
+		documentPath = e.PolicyDocumentPath()
+	}
+	doc, err := policy.LoadDocument(documentPath)
+	if err != nil {
+		return policy.Evaluator{}, fmt.Errorf("runtime: load policy: %w", err)
+	}
+	sandboxActive := cfg.Sandbox.Active && strings.ToLower(cfg.Sandbox.Provider) != "none"
+	return policy.Evaluator{
+		Env: policy.Environment{
+			Workspace:            e.workspaceDir,
+			DefaultCapabilities:  allowedTools,
+			NetworkEnabled:       cfg.Network.Enabled,
+			AllowedDomains:       cfg.Network.AllowedDomains,
+			AllowLocalhosts:      cfg.Network.AllowLocalhosts,
+			ShellEnabled:         cfg.Shell.EnableExec && sandboxActive,
+			ShellAllowedCommands: cfg.Shell.AllowedCommands,
+			SandboxActive:        sandboxActive,
+		},
+		Grants:   grants,
+		Rules:    rules,
+		Document: doc,
+	}, nil
+}
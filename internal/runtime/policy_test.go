@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicyEvaluatorLoadsDocumentAndFailsClosed(t *testing.T) {
+	root := t.TempDir()
+	e, err := NewEngine(root)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	if err := e.Init("default", false); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	docPath := e.PolicyDocumentPath()
+	if err := os.MkdirAll(filepath.Dir(docPath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	raw := `{"version":1,"agents":{"default":{"capabilities":["fs.read","policy.admin"]}}}`
+	if err := os.WriteFile(docPath, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+
+	ev, err := e.PolicyEvaluator("")
+	if err != nil {
+		t.Fatalf("policy evaluator: %v", err)
+	}
+	caps, source := ev.Capabilities("default")
+	if source != "policy" || strings.Join(caps, ",") != "fs.read,policy.admin" {
+		t.Fatalf("expected document capabilities, got %v from %s", caps, source)
+	}
+	if err := ev.Enforcer("default").CheckTool("default", "fs.write"); err == nil {
+		t.Fatal("expected fs.write denied by policy document")
+	}
+
+	if err := os.WriteFile(docPath, []byte(`{"version":1,"agents":{"default":{"capabilities":["fs.read"],"rules":[{"tool":"fs.read"}]}}}`), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	if _, err := e.PolicyEvaluator(""); err == nil || !strings.Contains(err.Error(), "agents.default.rules[0]") {
+		t.Fatalf("expected invalid rule to fail, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"openclawssy/internal/agent"
+	"openclawssy/internal/policy"
+	"openclawssy/internal/tools"
+)
+
+func TestRegistryExecutorRedactsKnownSecrets(t *testing.T) {
+	policy.SetSecretValues([]string{"pa55word-xyz"})
+	t.Cleanup(func() { policy.SetSecretValues(nil) })
+
+	workspace := t.TempDir()
+	sentinel := errors.New("boom")
+	enforcer := policy.NewEnforcer(workspace, map[string][]string{"default": {"echo.leak", "secrets.get", "fail.leak"}})
+	reg := tools.NewRegistry(enforcer, nil)
+	leak := func(ctx context.Context, req tools.Request) (map[string]any, error) {
+		return map[string]any{"value": "pa55word-xyz"}, nil
+	}
+	for _, name := range []string{"echo.leak", "secrets.get"} {
+		if err := reg.Register(tools.ToolSpec{Name: name}, leak); err != nil {
+			t.Fatalf("register %s: %v", name, err)
+		}
+	}
+	if err := reg.Register(tools.ToolSpec{Name: "fail.leak"}, func(ctx context.Context, req tools.Request) (map[string]any, error) {
+		return nil, errors.Join(sentinel, errors.New("connect with pa55word-xyz failed"))
+	}); err != nil {
+		t.Fatalf("register fail.leak: %v", err)
+	}
+	exec := &RegistryExecutor{Registry: reg, AgentID: "default", Workspace: workspace}
+
+	res, err := exec.Execute(context.Background(), agent.ToolCallRequest{ID: "1", Name: "echo.leak"})
+	if err != nil {
+		t.Fatalf("echo.leak: %v", err)
+	}
+	if strings.Contains(res.Output, "pa55word") || !strings.Contains(res.Output, "[REDACTED]") {
+		t.Fatalf("expected redacted output, got %s", res.Output)
+	}
+
+	res, err = exec.Execute(context.Background(), agent.ToolCallRequest{ID: "2", Name: "secrets.get"})
+	if err != nil {
+		t.Fatalf("secrets.get: %v", err)
+	}
+	if !strings.Contains(res.Output, "pa55word-xyz") {
+		t.Fatalf("secrets.get must return the value, got %s", res.Output)
+	}
+
+	_, err = exec.Execute(context.Background(), agent.ToolCallRequest{ID: "3", Name: "fail.leak"})
+	if err == nil || strings.Contains(err.Error(), "pa55word") {
+		t.Fatalf("expected redacted error, got %v", err)
+	}
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("redacted error lost its cause: %v", err)
+	}
+
+	trace := newRunTraceCollector("run_1", "", "", "hi")
+	trace.RecordToolExecution([]agent.ToolCallRecord{{
+		Request: agent.ToolCallRequest{ID: "2", Name: "secrets.get"},
+		Result:  res,
+	}})
+	for _, item := range trace.env.ToolExecutionResults {
+		if strings.Contains(item.Output+item.Summary, "pa55word") {
+			t.Fatalf("trace leaked secret: %+v", item)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 
 	"openclawssy/internal/agent"
+	"openclawssy/internal/policy"
 )
 
 type runTraceEnvelope struct {
@@ -23,6 +24,7 @@ type runTraceEnvelope struct {
 	ModelInputs          []modelInputTrace        `json:"model_inputs,omitempty"`
 	ExtractedToolCalls   []toolExtractionTrace    `json:"extracted_tool_calls,omitempty"`
 	ToolExecutionResults []toolExecutionResultLog `json:"tool_execution_results,omitempty"`
+	Taint                []taintTraceEvent        `json:"taint,omitempty"`
 }
 
 type modelInputTrace struct {
@@ -50,6 +52,18 @@ type toolExecutionResultLog struct {
 	Output        string `json:"output,omitempty"`
 	Error         string `json:"error,omitempty"`
 	CallbackError string `json:"callback_error,omitempty"`
+	Taint         string `json:"taint,omitempty"`
+}
+
+// taintTraceEvent records a run becoming tainted ("tainted") or a high-risk
+// call being held back because of it ("gated", with the action taken).
+type taintTraceEvent struct {
+	Event      string `json:"event"`
+	Tool       string `json:"tool"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Action     string `json:"action,omitempty"`
+	Reason     string `json:"reason,omitempty"`
 }
 
 type runTraceCollector struct {
@@ -79,10 +93,10 @@ func (c *runTraceCollector) RecordModelInput(message string, promptLength int, h
 	c.current++
 	c.env.ModelInputs = append(c.env.ModelInputs, modelInputTrace{
 		Iteration:       c.current,
-		Message:         message,
+		Message:         policy.RedactSecrets(message),
 		PromptLength:    promptLength,
 		HistoryInjected: historyInjected,
-		RequestJSON:     requestJSON,
+		RequestJSON:     policy.RedactSecrets(requestJSON),
 	})
 }
 
@@ -91,13 +105,13 @@ func (c *runTraceCollector) RecordToolExtraction(rawSnippet, parsedTool string,
 		return
 	}
 	entry := toolExtractionTrace{
-		RawSnippet:     strings.TrimSpace(rawSnippet),
+		RawSnippet:     policy.RedactSecrets(strings.TrimSpace(rawSnippet)),
 		ParsedToolName: strings.TrimSpace(parsedTool),
 		Accepted:       accepted,
 		Reason:         strings.TrimSpace(reason),
 	}
 	if len(parsedArguments) > 0 {
-		entry.ParsedArguments = strings.TrimSpace(string(parsedArguments))
+		entry.ParsedArguments = policy.RedactSecrets(strings.TrimSpace(string(parsedArguments)))
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -118,13 +132,14 @@ func (c *runTraceCollector) RecordToolExecution(records []agent.ToolCallRecord)
 			Tool:          strings.TrimSpace(rec.Request.Name),
 			ToolCallID:    strings.TrimSpace(rec.Request.ID),
 			DurationMS:    durationMS,
-			Summary:       summarizeToolExecution(rec.Request.Name, rec.Result.Output, rec.Result.Error),
-			Output:        strings.TrimSpace(rec.Result.Output),
-			Error:         strings.TrimSpace(rec.Result.Error),
-			CallbackError: strings.TrimSpace(rec.CallbackErr),
+			Summary:       policy.RedactSecrets(summarizeToolExecution(rec.Request.Name, rec.Result.Output, rec.Result.Error)),
+			Output:        policy.RedactSecrets(strings.TrimSpace(rec.Result.Output)),
+			Error:         policy.RedactSecrets(strings.TrimSpace(rec.Result.Error)),
+			CallbackError: policy.RedactSecrets(strings.TrimSpace(rec.CallbackErr)),
+			Taint:         rec.Result.Taint,
 		}
 		if len(rec.Request.Arguments) > 0 {
-			item.Arguments = strings.TrimSpace(string(rec.Request.Arguments))
+			item.Arguments = policy.RedactSecrets(strings.TrimSpace(string(rec.Request.Arguments)))
 		}
 		items = append(items, item)
 	}
@@ -133,13 +148,22 @@ func (c *runTraceCollector) RecordToolExecution(records []agent.ToolCallRecord)
 	c.env.ToolExecutionResults = append(c.env.ToolExecutionResults, items...)
 }
 
+func (c *runTraceCollector) RecordTaint(event taintTraceEvent) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.env.Taint = append(c.env.Taint, event)
+}
+
 func (c *runTraceCollector) RecordThinking(thinking string, thinkingPresent bool) {
 	if c == nil {
 		return
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.env.Thinking = strings.TrimSpace(thinking)
+	c.env.Thinking = policy.RedactSecrets(strings.TrimSpace(thinking))
 	c.env.ThinkingPresent = thinkingPresent
 }
 
@@ -5,10 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 var (
@@ -17,9 +19,25 @@ var (
 	ErrUnknownProvider = errors.New("sandbox: unknown provider")
 )
 
+// Command is a process to run in the workspace. Env is appended to the
+// parent environment unless CleanEnv is set, in which case it is the whole
+// environment.
 type Command struct {
-	Name string
-	Args []string
+	Name     string
+	Args     []string
+	Env      []string
+	CleanEnv bool
+	Timeout  time.Duration
+}
+
+func (cmd Command) environ() []string {
+	if cmd.CleanEnv {
+		return append([]string{}, cmd.Env...)
+	}
+	if len(cmd.Env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), cmd.Env...)
 }
 
 type Result struct {
@@ -50,6 +68,22 @@ func NewProvider(name string, workspace string) (Provider, error) {
 	}
 }
 
+// ProcessStarter is implemented by providers that can run long-lived child
+// processes with piped stdio, such as MCP servers speaking over stdin/stdout.
+type ProcessStarter interface {
+	StartProcess(cmd Command) (*Process, error)
+}
+
+// StartProcess starts cmd through the active provider. Providers that cannot
+// run processes deny it with ErrExecDenied.
+func StartProcess(active Provider, cmd Command) (*Process, error) {
+	starter, ok := active.(ProcessStarter)
+	if !ok || active == nil {
+		return nil, ErrExecDenied
+	}
+	return starter.StartProcess(cmd)
+}
+
 func ShellExecAllowed(active Provider) bool {
 	if active == nil {
 		return false
@@ -77,6 +111,10 @@ func (p *NoneProvider) Exec(Command) (Result, error) {
 	return Result{}, ErrExecDenied
 }
 
+func (p *NoneProvider) StartProcess(Command) (*Process, error) {
+	return nil, ErrExecDenied
+}
+
 func (p *NoneProvider) Stop() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -144,8 +182,15 @@ func (p *LocalProvider) Exec(cmd Command) (Result, error) {
 		return Result{}, errors.New("sandbox: command name is required")
 	}
 
-	proc := exec.CommandContext(runCtx, cmd.Name, cmd.Args...)
+	execCtx := runCtx
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(runCtx, cmd.Timeout)
+		defer cancel()
+	}
+	proc := exec.CommandContext(execCtx, cmd.Name, cmd.Args...)
 	proc.Dir = workspace
+	proc.Env = cmd.environ()
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -168,6 +213,39 @@ func (p *LocalProvider) Exec(cmd Command) (Result, error) {
 	return result, err
 }
 
+// StartProcess launches a long-lived process in the workspace. It is bound to
+// the provider's run context, so stopping the provider kills it as well.
+func (p *LocalProvider) StartProcess(cmd Command) (*Process, error) {
+	p.mu.RLock()
+	started := p.started
+	runCtx := p.runCtx
+	workspace := p.workspace
+	p.mu.RUnlock()
+
+	if !started {
+		return nil, ErrNotStarted
+	}
+	if cmd.Name == "" {
+		return nil, errors.New("sandbox: command name is required")
+	}
+
+	proc := exec.CommandContext(runCtx, cmd.Name, cmd.Args...)
+	proc.Dir = workspace
+	proc.Env = cmd.environ()
+	stdin, err := proc.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: stdin pipe: %w", err)
+	}
+	stdout, err := proc.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: stdout pipe: %w", err)
+	}
+	if err := proc.Start(); err != nil {
+		return nil, fmt.Errorf("sandbox: start process: %w", err)
+	}
+	return &Process{Stdin: stdin, Stdout: stdout, cmd: proc}, nil
+}
+
 func (p *LocalProvider) Stop() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -187,3 +265,32 @@ func (p *LocalProvider) isStarted() bool {
 	defer p.mu.RUnlock()
 	return p.started
 }
+
+const processStopGrace = 2 * time.Second
+
+// Process is a running child started through a provider.
+type Process struct {
+	Stdin  io.WriteCloser
+	Stdout io.ReadCloser
+
+	cmd      *exec.Cmd
+	stopOnce sync.Once
+	waitErr  error
+}
+
+// Stop closes stdin, gives the process a short grace period to exit on its
+// own and kills it otherwise.
+func (p *Process) Stop() error {
+	p.stopOnce.Do(func() {
+		_ = p.Stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- p.cmd.Wait() }()
+		select {
+		case p.waitErr = <-done:
+		case <-time.After(processStopGrace):
+			_ = p.cmd.Process.Kill()
+			p.waitErr = <-done
+		}
+	})
+	return p.waitErr
+}
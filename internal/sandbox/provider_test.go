@@ -3,7 +3,9 @@ package sandbox
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
+	"time"
 )
 
 func TestNoneProviderExecDenied(t *testing.T) {
@@ -53,3 +55,68 @@ func TestNewProviderRejectsUnsupportedProvider(t *testing.T) {
 		t.Fatalf("expected ErrUnknownProvider, got %v", err)
 	}
 }
+
+func TestNoneProviderStartProcessDenied(t *testing.T) {
+	p := &NoneProvider{}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("start none provider: %v", err)
+	}
+	if _, err := StartProcess(p, Command{Name: "cat"}); !errors.Is(err, ErrExecDenied) {
+		t.Fatalf("expected ErrExecDenied, got %v", err)
+	}
+}
+
+func TestLocalProviderStartProcessPipesStdio(t *testing.T) {
+	local, err := NewLocalProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("new local provider: %v", err)
+	}
+	if _, err := local.StartProcess(Command{Name: "cat"}); !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("expected ErrNotStarted, got %v", err)
+	}
+	if err := local.Start(context.Background()); err != nil {
+		t.Fatalf("start local provider: %v", err)
+	}
+	defer local.Stop()
+
+	proc, err := StartProcess(local, Command{Name: "cat"})
+	if err != nil {
+		t.Fatalf("start process: %v", err)
+	}
+	if _, err := proc.Stdin.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(proc.Stdout, buf); err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	if string(buf) != "ping\n" {
+		t.Fatalf("unexpected echo: %q", buf)
+	}
+	if err := proc.Stop(); err != nil {
+		t.Fatalf("stop process: %v", err)
+	}
+}
+
+func TestLocalProviderExecHonorsEnvAndTimeout(t *testing.T) {
+	p, err := NewLocalProvider(t.TempDir())
+	if err != nil {
+		t.Fatalf("new local provider: %v", err)
+	}
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("start local provider: %v", err)
+	}
+	defer p.Stop()
+
+	res, err := p.Exec(Command{Name: "sh", Args: []string{"-c", "printf %s \"$SKILL_NAME\""}, Env: []string{"SKILL_NAME=deploy"}})
+	if err != nil || res.Stdout != "deploy" {
+		t.Fatalf("expected env in child, got %+v %v", res, err)
+	}
+	start := time.Now()
+	if _, err := p.Exec(Command{Name: "sleep", Args: []string{"5"}, Timeout: 100 * time.Millisecond}); err == nil {
+		t.Fatal("expected timed out command to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("expected timeout to stop command early, took %s", elapsed)
+	}
+}
@@ -0,0 +1,166 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openclawssy/internal/config"
+)
+
+// Backend is a read-only source of secret values. Names use the store's
+// slash-separated form, e.g. provider/openai/api_key.
+type Backend interface {
+	Name() string
+	Lookup(name string) (string, bool, error)
+	List() ([]string, error)
+}
+
+// flatSeparator stands in for "/" where a name must be a single path
+// segment or environment variable.
+const flatSeparator = "__"
+
+func newBackends(cfg config.SecretsConfig, local Backend) ([]Backend, error) {
+	order := cfg.Backends
+	if len(order) == 0 {
+		order = []string{config.SecretsBackendStore}
+	}
+	out := make([]Backend, 0, len(order))
+	for _, name := range order {
+		switch name {
+		case config.SecretsBackendStore:
+			out = append(out, local)
+		case config.SecretsBackendVault:
+			out = append(out, newVaultBackend(cfg.Vault))
+		case config.SecretsBackendFiles:
+			out = append(out, &FilesBackend{Dir: cfg.Files.Dir})
+		case config.SecretsBackendEnv:
+			out = append(out, &EnvBackend{Prefix: cfg.Env.Prefix})
+		default:
+			return nil, fmt.Errorf("unknown secret backend %q", name)
+		}
+	}
+	return out, nil
+}
+
+func validSecretName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// FilesBackend reads one file per secret. provider/openai/api_key is found at
+// <Dir>/provider/openai/api_key or, for flat mounts such as Docker secrets, at
+// <Dir>/provider__openai__api_key. Trailing newlines are dropped.
+type FilesBackend struct {
+	Dir string
+}
+
+func (b *FilesBackend) Name() string { return config.SecretsBackendFiles }
+
+func (b *FilesBackend) Lookup(name string) (string, bool, error) {
+	if !validSecretName(name) {
+		return "", false, nil
+	}
+	for _, rel := range []string{filepath.FromSlash(name), strings.ReplaceAll(name, "/", flatSeparator)} {
+		path := filepath.Join(b.Dir, rel)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return strings.TrimRight(string(data), "\r\n"), true, nil
+		}
+		// Only a regular file that cannot be read is an error; anything
+		// else just means the secret is not here.
+		if info, statErr := os.Stat(path); statErr == nil && info.Mode().IsRegular() {
+			return "", false, err
+		}
+	}
+	return "", false, nil
+}
+
+func (b *FilesBackend) List() ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(b.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == b.Dir && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		// Kubernetes mounts keep their real files under ..data and friends.
+		if path != b.Dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(b.Dir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, strings.ReplaceAll(filepath.ToSlash(rel), flatSeparator, "/"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// EnvBackend reads secrets from environment variables. provider/openai/api_key
+// is OPENCLAWSSY_SECRET_PROVIDER__OPENAI__API_KEY with the default prefix;
+// other characters outside [A-Za-z0-9_] become "_".
+type EnvBackend struct {
+	Prefix string
+}
+
+func (b *EnvBackend) Name() string { return config.SecretsBackendEnv }
+
+func (b *EnvBackend) Lookup(name string) (string, bool, error) {
+	if !validSecretName(name) {
+		return "", false, nil
+	}
+	value, ok := os.LookupEnv(b.Prefix + envSecretName(name))
+	return value, ok, nil
+}
+
+// List reports names in lower case, the form they are normally stored under.
+func (b *EnvBackend) List() ([]string, error) {
+	var names []string
+	for _, kv := range os.Environ() {
+		key, _, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, b.Prefix) || key == b.Prefix {
+			continue
+		}
+		names = append(names, strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, b.Prefix), flatSeparator, "/")))
+	}
+	return names, nil
+}
+
+func envSecretName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+				return r
+			default:
+				return '_'
+			}
+		}, part)
+	}
+	return strings.Join(parts, flatSeparator)
+}
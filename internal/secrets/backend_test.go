@@ -0,0 +1,241 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/policy"
+)
+
+func TestFilesBackendNestedAndFlatNames(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "provider", "openai"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "provider", "openai", "api_key"), []byte("nested-value\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "slack__bot_token"), []byte("flat-value"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "..data"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "..data", "hidden"), []byte("x"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	b := &FilesBackend{Dir: dir}
+	if v, ok, err := b.Lookup("provider/openai/api_key"); err != nil || !ok || v != "nested-value" {
+		t.Fatalf("nested lookup: %q %v %v", v, ok, err)
+	}
+	if v, ok, err := b.Lookup("slack/bot_token"); err != nil || !ok || v != "flat-value" {
+		t.Fatalf("flat lookup: %q %v %v", v, ok, err)
+	}
+	for _, name := range []string{"missing", "../escape", "provider"} {
+		if _, ok, err := b.Lookup(name); err != nil || ok {
+			t.Fatalf("lookup %q should miss: ok=%v err=%v", name, ok, err)
+		}
+	}
+	names, err := b.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "provider/openai/api_key,slack/bot_token" {
+		t.Fatalf("unexpected names: %v", names)
+	}
+	if names, err := (&FilesBackend{Dir: filepath.Join(dir, "absent")}).List(); err != nil || len(names) != 0 {
+		t.Fatalf("missing dir should list nothing: %v %v", names, err)
+	}
+}
+
+func TestEnvBackendMapsNames(t *testing.T) {
+	t.Setenv("OPENCLAWSSY_SECRET_PROVIDER__OPENAI__API_KEY", "env-value")
+	b := &EnvBackend{Prefix: "OPENCLAWSSY_SECRET_"}
+	if v, ok, err := b.Lookup("provider/openai/api_key"); err != nil || !ok || v != "env-value" {
+		t.Fatalf("lookup: %q %v %v", v, ok, err)
+	}
+	names, err := b.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	found := false
+	for _, name := range names {
+		found = found || name == "provider/openai/api_key"
+	}
+	if !found {
+		t.Fatalf("expected listed name, got %v", names)
+	}
+}
+
+func newFakeVault(t *testing.T, token string, secrets map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secret/data/app/"):
+			value, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/app/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]any{"value": value}, "metadata": map[string]any{"version": 3}}})
+		case r.Method == "LIST" && strings.HasPrefix(r.URL.Path, "/v1/secret/metadata/app/"):
+			dir := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata/app/")
+			keys := map[string]bool{}
+			for name := range secrets {
+				if rest, ok := strings.CutPrefix(name, dir); ok {
+					if head, _, nested := strings.Cut(rest, "/"); nested {
+						keys[head+"/"] = true
+					} else {
+						keys[rest] = true
+					}
+				}
+			}
+			if len(keys) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			list := make([]string, 0, len(keys))
+			for k := range keys {
+				list = append(list, k)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"keys": list}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestVaultBackendReadsKVv2(t *testing.T) {
+	server := newFakeVault(t, "root-token", map[string]string{"provider/openai/api_key": "vault-value", "deploy": "deploy-value"})
+	defer server.Close()
+	t.Setenv("TEST_VAULT_TOKEN", "root-token")
+
+	b := newVaultBackend(config.SecretsVaultConfig{Address: server.URL, Mount: "secret", Prefix: "app", Field: "value", TokenEnv: "TEST_VAULT_TOKEN", TimeoutSeconds: 5})
+	if v, ok, err := b.Lookup("provider/openai/api_key"); err != nil || !ok || v != "vault-value" {
+		t.Fatalf("lookup: %q %v %v", v, ok, err)
+	}
+	if _, ok, err := b.Lookup("missing"); err != nil || ok {
+		t.Fatalf("missing lookup: ok=%v err=%v", ok, err)
+	}
+	names, err := b.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "deploy,provider/openai/api_key" {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	t.Setenv("TEST_VAULT_TOKEN", "wrong")
+	if _, _, err := b.Lookup("deploy"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+}
+
+func TestStoreResolvesBackendsInOrder(t *testing.T) {
+	t.Cleanup(func() { policy.SetSecretValues(nil) })
+	server := newFakeVault(t, "root-token", map[string]string{"provider/openai/api_key": "vault-openai-key", "deploy": "vault-deploy"})
+	defer server.Close()
+	t.Setenv("TEST_VAULT_TOKEN", "root-token")
+	t.Setenv("TEST_SECRET_DEPLOY", "env-deploy")
+	t.Setenv("TEST_SECRET_ONLY__ENV", "env-only")
+
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.Secrets.StoreFile = filepath.Join(dir, "secrets.enc")
+	cfg.Secrets.MasterKeyFile = filepath.Join(dir, "master.key")
+	cfg.Secrets.Backends = []string{"env", "store", "vault"}
+	cfg.Secrets.Env.Prefix = "TEST_SECRET_"
+	cfg.Secrets.Vault = config.SecretsVaultConfig{Address: server.URL, Mount: "secret", Prefix: "app", Field: "value", TokenEnv: "TEST_VAULT_TOKEN", TimeoutSeconds: 5}
+	if _, err := GenerateAndWriteMasterKey(cfg.Secrets.MasterKeyFile); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	store, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := store.Set("deploy", "store-deploy"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if v, _, _ := store.Get("deploy"); v != "env-deploy" {
+		t.Fatalf("env should win over store, got %q", v)
+	}
+	if v, _, _ := store.Get("provider/openai/api_key"); v != "vault-openai-key" {
+		t.Fatalf("expected vault fallback, got %q", v)
+	}
+	if got := policy.RedactSecrets("key vault-openai-key"); got != "key [REDACTED]" {
+		t.Fatalf("external values must be redacted once read, got %q", got)
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	backends := map[string]string{}
+	for _, meta := range metas {
+		backends[meta.Name] = meta.Backend
+	}
+	if backends["deploy"] != "env" || backends["only/env"] != "env" || backends["provider/openai/api_key"] != "vault" {
+		t.Fatalf("unexpected resolution: %v", backends)
+	}
+
+	if _, _, err := store.GetFor("only/env", Reader{AgentID: "default"}); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("expected external secret without an ACL to be denied, got %v", err)
+	}
+	if _, _, err := store.GetFor("deploy", Reader{AgentID: "default"}); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("expected empty local ACL not to open an env value, got %v", err)
+	}
+	if err := store.SetACL("only/env", ACL{Agents: []string{"*"}}); err != nil {
+		t.Fatalf("SetACL on env secret: %v", err)
+	}
+	if v, _, err := store.GetFor("only/env", Reader{AgentID: "default"}); err != nil || v != "env-only" {
+		t.Fatalf("expected explicit ACL to open env secret: %q %v", v, err)
+	}
+	if err := store.SetACL("provider/openai/api_key", ACL{Agents: []string{"ops"}}); err != nil {
+		t.Fatalf("SetACL on external secret: %v", err)
+	}
+	if _, _, err := store.GetFor("provider/openai/api_key", Reader{AgentID: "default"}); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("expected ACL to apply to vault secret, got %v", err)
+	}
+	if v, _, err := store.GetFor("provider/openai/api_key", Reader{AgentID: "ops"}); err != nil || v != "vault-openai-key" {
+		t.Fatalf("ops read: %q %v", v, err)
+	}
+}
+
+func TestStoreWithoutLocalBackendIsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("file-token"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cfg := config.Default()
+	cfg.Secrets.StoreFile = filepath.Join(dir, "state", "secrets.enc")
+	cfg.Secrets.MasterKeyFile = filepath.Join(dir, "state", "master.key")
+	cfg.Secrets.Backends = []string{"files"}
+	cfg.Secrets.Files.Dir = dir
+
+	store, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore without master key: %v", err)
+	}
+	if v, ok, err := store.Get("token"); err != nil || !ok || v != "file-token" {
+		t.Fatalf("Get: %q %v %v", v, ok, err)
+	}
+	if err := store.Set("token", "x"); !errors.Is(err, ErrReadOnlySecret) {
+		t.Fatalf("expected read-only error, got %v", err)
+	}
+}
@@ -0,0 +1,487 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/fsutil"
+)
+
+const (
+	envMasterPassphrase   = "OPENCLAWSSY_MASTER_PASSPHRASE"
+	envMasterPassphraseFD = "OPENCLAWSSY_MASTER_PASSPHRASE_FD"
+
+	wrappedKeyFormat = "openclawssy-master-key/v1"
+
+	// WrapPassphrase derives the key-encryption key from an operator
+	// passphrase with Argon2id.
+	WrapPassphrase = "passphrase"
+	// WrapCommand hands the key to secrets.key_wrap commands, e.g. a TPM or
+	// OS keyring helper.
+	WrapCommand = "command"
+
+	keyWrapCommandTimeout = 30 * time.Second
+	maxPassphraseBytes    = 4096
+)
+
+// ErrPassphraseRequired is returned when a wrapped master key has to be
+// unlocked and no passphrase source is available.
+var ErrPassphraseRequired = fmt.Errorf("master key is passphrase-wrapped: set %s or %s, or start interactively", envMasterPassphrase, envMasterPassphraseFD)
+
+// KeyWrapper seals the master key with something kept outside the state
+// directory. Plugins register one with RegisterKeyWrapper and select it by
+// name in `secrets wrap-master-key -with`.
+type KeyWrapper interface {
+	Wrap(key []byte) ([]byte, error)
+	Unwrap(blob []byte) ([]byte, error)
+}
+
+// PassphraseFunc supplies the passphrase for a wrapped master key, typically
+// by prompting on a terminal.
+type PassphraseFunc func() ([]byte, error)
+
+type argon2Params struct {
+	Salt      string `json:"salt"`
+	Time      uint32 `json:"time"`
+	MemoryKiB uint32 `json:"memory_kib"`
+	Threads   uint8  `json:"threads"`
+}
+
+// wrappedKeyFile is the on-disk form of a wrapped master key. Passphrase
+// wrapping fills KDF, Nonce and Ciphertext; other wrappers fill Blob.
+type wrappedKeyFile struct {
+	Format     string        `json:"format"`
+	Wrap       string        `json:"wrap"`
+	KDF        *argon2Params `json:"kdf,omitempty"`
+	Nonce      string        `json:"nonce,omitempty"`
+	Ciphertext string        `json:"ciphertext,omitempty"`
+	Blob       string        `json:"blob,omitempty"`
+}
+
+// unwrappedKey is a master key together with a way to wrap a replacement the
+// same way, used by RotateMasterKey.
+type unwrappedKey struct {
+	key    []byte
+	rewrap func(key []byte) ([]byte, error)
+}
+
+var (
+	keyWrappersMu sync.RWMutex
+	keyWrappers   = map[string]KeyWrapper{}
+
+	passphraseMu     sync.Mutex
+	passphraseSource PassphraseFunc
+	cachedPassphrase []byte
+
+	unwrapCacheMu sync.Mutex
+	unwrapCache   = map[[sha256.Size]byte]unwrappedKey{}
+
+	// defaultArgon2 follows the RFC 9106 second recommended option.
+	defaultArgon2 = argon2Params{Time: 3, MemoryKiB: 64 * 1024, Threads: 4}
+)
+
+func RegisterKeyWrapper(name string, w KeyWrapper) {
+	keyWrappersMu.Lock()
+	defer keyWrappersMu.Unlock()
+	keyWrappers[name] = w
+}
+
+// SetPassphraseSource sets the fallback used when neither passphrase
+// environment variable is set.
+func SetPassphraseSource(fn PassphraseFunc) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	passphraseSource = fn
+}
+
+// readPassphrase tries the environment variable, then the file descriptor
+// named by OPENCLAWSSY_MASTER_PASSPHRASE_FD, then a passphrase read earlier
+// in this process, then the registered source.
+func readPassphrase() ([]byte, error) {
+	if pass, err := environmentPassphrase(); pass != nil || err != nil {
+		return pass, err
+	}
+	passphraseMu.Lock()
+	source := passphraseSource
+	passphraseMu.Unlock()
+	if source == nil {
+		return nil, ErrPassphraseRequired
+	}
+	pass, err := source()
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	return pass, nil
+}
+
+// environmentPassphrase reads and unsets both passphrase variables so tools
+// that spawn processes (shell.exec, skill.run) cannot read them back. The
+// value is kept for later unwraps in this process, e.g. after key rotation;
+// a descriptor can only be read once anyway.
+func environmentPassphrase() ([]byte, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	if v, ok := os.LookupEnv(envMasterPassphrase); ok {
+		_ = os.Unsetenv(envMasterPassphrase)
+		if v != "" {
+			cachedPassphrase = []byte(v)
+			return cachedPassphrase, nil
+		}
+	}
+	if raw, ok := os.LookupEnv(envMasterPassphraseFD); ok {
+		_ = os.Unsetenv(envMasterPassphraseFD)
+		if raw != "" {
+			pass, err := readPassphraseFD(raw)
+			if err != nil {
+				return nil, err
+			}
+			cachedPassphrase = pass
+			return pass, nil
+		}
+	}
+	return cachedPassphrase, nil
+}
+
+func readPassphraseFD(raw string) ([]byte, error) {
+	fd, err := strconv.Atoi(raw)
+	if err != nil || fd < 0 {
+		return nil, fmt.Errorf("%s must be a file descriptor number", envMasterPassphraseFD)
+	}
+	f := os.NewFile(uintptr(fd), "passphrase")
+	if f == nil {
+		return nil, fmt.Errorf("%s: invalid file descriptor %d", envMasterPassphraseFD, fd)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxPassphraseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", envMasterPassphraseFD, err)
+	}
+	if len(data) > maxPassphraseBytes {
+		return nil, errors.New("passphrase too long")
+	}
+	pass := bytes.TrimRight(data, "\r\n")
+	if len(pass) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	return pass, nil
+}
+
+// CaptureEnvironmentPassphrase reads the passphrase variables up front so
+// they are gone from the environment before any child process starts.
+func CaptureEnvironmentPassphrase() error {
+	_, err := environmentPassphrase()
+	return err
+}
+
+// HasPassphrase reports whether a passphrase is available without prompting.
+func HasPassphrase() bool {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	return cachedPassphrase != nil || os.Getenv(envMasterPassphrase) != "" || os.Getenv(envMasterPassphraseFD) != ""
+}
+
+// forgetPassphrase drops a cached passphrase that failed to unwrap the key so
+// the next attempt asks again.
+func forgetPassphrase() {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	cachedPassphrase = nil
+}
+
+// isWrappedKey reports whether a key file holds a wrapped key rather than a
+// bare base64 key.
+func isWrappedKey(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// parseKeyFile returns the master key held in data, unwrapping it if needed.
+// Unwrapped keys are cached by file content so repeated opens in one process
+// prompt and derive once.
+func parseKeyFile(data []byte, cfg config.SecretsConfig) (unwrappedKey, error) {
+	if !isWrappedKey(data) {
+		key, err := decodeRawKey(string(data))
+		if err != nil {
+			return unwrappedKey{}, err
+		}
+		return unwrappedKey{key: key, rewrap: func(key []byte) ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(key) + "\n"), nil
+		}}, nil
+	}
+	sum := sha256.Sum256(data)
+	unwrapCacheMu.Lock()
+	cached, ok := unwrapCache[sum]
+	unwrapCacheMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var doc wrappedKeyFile
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return unwrappedKey{}, fmt.Errorf("invalid wrapped master key: %w", err)
+	}
+	if doc.Format != wrappedKeyFormat {
+		return unwrappedKey{}, fmt.Errorf("unsupported master key format %q", doc.Format)
+	}
+	var out unwrappedKey
+	switch doc.Wrap {
+	case WrapPassphrase:
+		if doc.KDF == nil {
+			return unwrappedKey{}, errors.New("wrapped master key is missing kdf parameters")
+		}
+		pass, err := readPassphrase()
+		if err != nil {
+			return unwrappedKey{}, err
+		}
+		kek, err := doc.KDF.derive(pass)
+		if err != nil {
+			return unwrappedKey{}, err
+		}
+		key, err := decrypt(kek, doc.Nonce, doc.Ciphertext)
+		if err != nil {
+			forgetPassphrase()
+			return unwrappedKey{}, errors.New("wrong passphrase for master key")
+		}
+		params := *doc.KDF
+		out = unwrappedKey{key: key, rewrap: func(key []byte) ([]byte, error) {
+			return sealWithKEK(kek, params, key)
+		}}
+	default:
+		w, err := keyWrapperFor(doc.Wrap, cfg)
+		if err != nil {
+			return unwrappedKey{}, err
+		}
+		blob, err := base64.StdEncoding.DecodeString(doc.Blob)
+		if err != nil {
+			return unwrappedKey{}, fmt.Errorf("invalid wrapped master key blob: %w", err)
+		}
+		key, err := w.Unwrap(blob)
+		if err != nil {
+			return unwrappedKey{}, fmt.Errorf("unwrap master key with %s: %w", doc.Wrap, err)
+		}
+		name := doc.Wrap
+		out = unwrappedKey{key: key, rewrap: func(key []byte) ([]byte, error) {
+			return sealWithWrapper(name, w, key)
+		}}
+	}
+	if len(out.key) != 32 {
+		return unwrappedKey{}, errors.New("master key must decode to 32 bytes")
+	}
+	cacheUnwrapped(data, out)
+	return out, nil
+}
+
+// cacheUnwrapped remembers the key behind a wrapped key file so later opens
+// in this process skip the prompt and key derivation.
+func cacheUnwrapped(data []byte, key unwrappedKey) {
+	if !isWrappedKey(data) {
+		return
+	}
+	unwrapCacheMu.Lock()
+	unwrapCache[sha256.Sum256(data)] = key
+	unwrapCacheMu.Unlock()
+}
+
+func keyWrapperFor(name string, cfg config.SecretsConfig) (KeyWrapper, error) {
+	if name == WrapCommand {
+		if len(cfg.KeyWrap.WrapCommand) == 0 || len(cfg.KeyWrap.UnwrapCommand) == 0 {
+			return nil, errors.New("master key is wrapped by a command but secrets.key_wrap commands are not configured")
+		}
+		return commandWrapper{wrap: cfg.KeyWrap.WrapCommand, unwrap: cfg.KeyWrap.UnwrapCommand}, nil
+	}
+	keyWrappersMu.RLock()
+	w, ok := keyWrappers[name]
+	keyWrappersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown master key wrapper %q", name)
+	}
+	return w, nil
+}
+
+func (p argon2Params) derive(pass []byte) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil || len(salt) < 16 {
+		return nil, errors.New("invalid kdf salt")
+	}
+	if p.Time == 0 || p.MemoryKiB < 8*uint32(p.Threads) || p.Threads == 0 {
+		return nil, errors.New("invalid kdf parameters")
+	}
+	return argon2.IDKey(pass, salt, p.Time, p.MemoryKiB, p.Threads, 32), nil
+}
+
+func sealWithKEK(kek []byte, params argon2Params, key []byte) ([]byte, error) {
+	nonce, ciphertext, err := encrypt(kek, key)
+	if err != nil {
+		return nil, err
+	}
+	return marshalKeyFile(wrappedKeyFile{Format: wrappedKeyFormat, Wrap: WrapPassphrase, KDF: &params, Nonce: nonce, Ciphertext: ciphertext})
+}
+
+func sealWithWrapper(name string, w KeyWrapper, key []byte) ([]byte, error) {
+	blob, err := w.Wrap(key)
+	if err != nil {
+		return nil, fmt.Errorf("wrap master key with %s: %w", name, err)
+	}
+	return marshalKeyFile(wrappedKeyFile{Format: wrappedKeyFormat, Wrap: name, Blob: base64.StdEncoding.EncodeToString(blob)})
+}
+
+func marshalKeyFile(doc wrappedKeyFile) ([]byte, error) {
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// WrapMasterKeyFile replaces a bare key file with one wrapped by the named
+// wrapper: WrapPassphrase (passphrase from the usual sources), WrapCommand or
+// a registered plugin. The key itself does not change, so the store is not
+// re-encrypted.
+func WrapMasterKeyFile(cfg config.SecretsConfig, wrap string) error {
+	path := cfg.MasterKeyFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if isWrappedKey(data) {
+		return errors.New("master key is already wrapped")
+	}
+	key, err := decodeRawKey(string(data))
+	if err != nil {
+		return err
+	}
+	var out []byte
+	switch wrap {
+	case WrapPassphrase:
+		pass, err := readPassphrase()
+		if err != nil {
+			return err
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		params := defaultArgon2
+		params.Salt = base64.StdEncoding.EncodeToString(salt)
+		kek, err := params.derive(pass)
+		if err != nil {
+			return err
+		}
+		out, err = sealWithKEK(kek, params, key)
+		if err != nil {
+			return err
+		}
+	default:
+		w, err := keyWrapperFor(wrap, cfg)
+		if err != nil {
+			return err
+		}
+		if out, err = sealWithWrapper(wrap, w, key); err != nil {
+			return err
+		}
+	}
+	// Prove the new file opens before replacing the old one.
+	check, err := parseKeyFile(out, cfg)
+	if err != nil {
+		return fmt.Errorf("wrapped key does not unwrap: %w", err)
+	}
+	if !bytes.Equal(check.key, key) {
+		return errors.New("wrapped key does not round-trip")
+	}
+	return fsutil.WriteFileAtomic(path, out, 0o600)
+}
+
+// KeyStatus describes the master key file for doctor.
+type KeyStatus struct {
+	FromEnv bool
+	Exists  bool
+	Wrapped bool
+	Wrap    string
+}
+
+func MasterKeyStatus(cfg config.SecretsConfig) (KeyStatus, error) {
+	if os.Getenv(envMasterKey) != "" {
+		return KeyStatus{FromEnv: true}, nil
+	}
+	data, err := os.ReadFile(cfg.MasterKeyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return KeyStatus{}, nil
+		}
+		return KeyStatus{}, err
+	}
+	status := KeyStatus{Exists: true, Wrapped: isWrappedKey(data)}
+	if status.Wrapped {
+		var doc wrappedKeyFile
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return status, fmt.Errorf("invalid wrapped master key: %w", err)
+		}
+		status.Wrap = doc.Wrap
+	}
+	return status, nil
+}
+
+// commandWrapper pipes the base64 key through external commands, e.g.
+// `systemd-creds encrypt - -` and `systemd-creds decrypt - -` for TPM sealing.
+type commandWrapper struct {
+	wrap   []string
+	unwrap []string
+}
+
+func (c commandWrapper) Wrap(key []byte) ([]byte, error) {
+	return runKeyCommand(c.wrap, []byte(base64.StdEncoding.EncodeToString(key)+"\n"))
+}
+
+func (c commandWrapper) Unwrap(blob []byte) ([]byte, error) {
+	out, err := runKeyCommand(c.unwrap, blob)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRawKey(string(out))
+}
+
+func runKeyCommand(argv []string, stdin []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyWrapCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return nil, fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return out, nil
+}
+
+func decodeRawKey(raw string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key encoding: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, errors.New("master key must decode to 32 bytes")
+	}
+	return key, nil
+}
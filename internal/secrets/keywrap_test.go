@@ -0,0 +1,224 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"openclawssy/internal/config"
+)
+
+// useFastKDF keeps Argon2 cheap and forgets unwrapped keys so each test
+// exercises the passphrase path.
+func useFastKDF(t *testing.T) {
+	t.Helper()
+	saved := defaultArgon2
+	defaultArgon2 = argon2Params{Time: 1, MemoryKiB: 64, Threads: 1}
+	resetUnwrapState := func() {
+		unwrapCacheMu.Lock()
+		unwrapCache = map[[32]byte]unwrappedKey{}
+		unwrapCacheMu.Unlock()
+		passphraseMu.Lock()
+		passphraseSource = nil
+		cachedPassphrase = nil
+		passphraseMu.Unlock()
+	}
+	resetUnwrapState()
+	t.Cleanup(func() {
+		defaultArgon2 = saved
+		resetUnwrapState()
+	})
+}
+
+func TestWrapMasterKeyWithPassphrase(t *testing.T) {
+	useFastKDF(t)
+	store, cfg := newTestStore(t)
+	if err := store.Set("api", "wrapped-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	t.Setenv(envMasterPassphrase, "correct horse")
+	if err := WrapMasterKeyFile(cfg.Secrets, WrapPassphrase); err != nil {
+		t.Fatalf("WrapMasterKeyFile: %v", err)
+	}
+	if _, ok := os.LookupEnv(envMasterPassphrase); ok {
+		t.Fatal("expected the passphrase variable to be unset once read")
+	}
+	data, _ := os.ReadFile(cfg.Secrets.MasterKeyFile)
+	if !bytes.Contains(data, []byte(`"kdf"`)) {
+		t.Fatalf("expected wrapped key file, got %s", data)
+	}
+	if status, err := MasterKeyStatus(cfg.Secrets); err != nil || !status.Wrapped || status.Wrap != WrapPassphrase {
+		t.Fatalf("unexpected status %+v %v", status, err)
+	}
+	if err := WrapMasterKeyFile(cfg.Secrets, WrapPassphrase); err == nil {
+		t.Fatal("expected wrapping twice to fail")
+	}
+
+	reopened, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if got, _, err := reopened.Get("api"); err != nil || got != "wrapped-value" {
+		t.Fatalf("Get after wrap: %q %v", got, err)
+	}
+
+	unwrapCache = map[[32]byte]unwrappedKey{}
+	t.Setenv(envMasterPassphrase, "wrong")
+	if _, err := NewStore(cfg); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+	t.Setenv(envMasterPassphrase, "")
+	if _, err := NewStore(cfg); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("expected passphrase required, got %v", err)
+	}
+
+	prompts := 0
+	SetPassphraseSource(func() ([]byte, error) {
+		prompts++
+		return []byte("correct horse"), nil
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := NewStore(cfg); err != nil {
+			t.Fatalf("NewStore with prompt: %v", err)
+		}
+	}
+	if prompts != 1 {
+		t.Fatalf("expected a single prompt per process, got %d", prompts)
+	}
+}
+
+func TestWrappedMasterKeyFromFileDescriptor(t *testing.T) {
+	useFastKDF(t)
+	store, cfg := newTestStore(t)
+	if err := store.Set("api", "fd-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	t.Setenv(envMasterPassphrase, "via-fd")
+	if err := WrapMasterKeyFile(cfg.Secrets, WrapPassphrase); err != nil {
+		t.Fatalf("WrapMasterKeyFile: %v", err)
+	}
+	unwrapCache = map[[32]byte]unwrappedKey{}
+	t.Setenv(envMasterPassphrase, "")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	defer r.Close()
+	if _, err := w.WriteString("via-fd\n"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	w.Close()
+	t.Setenv(envMasterPassphraseFD, "bogus")
+	if _, err := NewStore(cfg); err == nil {
+		t.Fatal("expected invalid fd to fail")
+	}
+	t.Setenv(envMasterPassphraseFD, strconv.Itoa(int(r.Fd())))
+	reopened, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore via fd: %v", err)
+	}
+	if got, _, err := reopened.Get("api"); err != nil || got != "fd-value" {
+		t.Fatalf("Get: %q %v", got, err)
+	}
+	if _, ok := os.LookupEnv(envMasterPassphraseFD); ok {
+		t.Fatal("expected the passphrase fd variable to be unset once read")
+	}
+}
+
+func TestRotateMasterKeyKeepsWrap(t *testing.T) {
+	useFastKDF(t)
+	store, cfg := newTestStore(t)
+	if err := store.Set("api", "rotate-wrapped"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	t.Setenv(envMasterPassphrase, "rotate-pass")
+	if err := WrapMasterKeyFile(cfg.Secrets, WrapPassphrase); err != nil {
+		t.Fatalf("WrapMasterKeyFile: %v", err)
+	}
+	wrapped, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	before, _ := os.ReadFile(cfg.Secrets.MasterKeyFile)
+	if err := wrapped.RotateMasterKey(); err != nil {
+		t.Fatalf("RotateMasterKey: %v", err)
+	}
+	after, _ := os.ReadFile(cfg.Secrets.MasterKeyFile)
+	if bytes.Equal(before, after) || !isWrappedKey(after) {
+		t.Fatalf("expected a new wrapped key, got %s", after)
+	}
+	unwrapCache = map[[32]byte]unwrappedKey{}
+	reopened, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore after rotation: %v", err)
+	}
+	if got, _, err := reopened.Get("api"); err != nil || got != "rotate-wrapped" {
+		t.Fatalf("Get after rotation: %q %v", got, err)
+	}
+}
+
+type xorWrapper struct{}
+
+func (xorWrapper) Wrap(key []byte) ([]byte, error) { return xorBytes(key), nil }
+
+func (xorWrapper) Unwrap(blob []byte) ([]byte, error) { return xorBytes(blob), nil }
+
+func xorBytes(in []byte) []byte {
+	out := make([]byte, len(in))
+	for i, b := range in {
+		out[i] = b ^ 0x5a
+	}
+	return out
+}
+
+func TestWrapMasterKeyWithPluginAndCommand(t *testing.T) {
+	useFastKDF(t)
+	RegisterKeyWrapper("test-xor", xorWrapper{})
+	store, cfg := newTestStore(t)
+	if err := store.Set("api", "plugin-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	raw, _ := os.ReadFile(cfg.Secrets.MasterKeyFile)
+	if err := WrapMasterKeyFile(cfg.Secrets, "unknown-wrapper"); err == nil {
+		t.Fatal("expected unknown wrapper to fail")
+	}
+	if err := WrapMasterKeyFile(cfg.Secrets, "test-xor"); err != nil {
+		t.Fatalf("wrap with plugin: %v", err)
+	}
+	reopened, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if got, _, _ := reopened.Get("api"); got != "plugin-value" {
+		t.Fatalf("Get with plugin wrap: %q", got)
+	}
+
+	// The command wrapper shells out; a second base64 stands in for a TPM tool.
+	if err := os.WriteFile(cfg.Secrets.MasterKeyFile, raw, 0o600); err != nil {
+		t.Fatalf("restore key: %v", err)
+	}
+	cfg.Secrets.KeyWrap = config.SecretsKeyWrapConfig{
+		WrapCommand:   []string{"base64"},
+		UnwrapCommand: []string{"base64", "-d"},
+	}
+	if err := WrapMasterKeyFile(cfg.Secrets, WrapCommand); err != nil {
+		t.Fatalf("wrap with command: %v", err)
+	}
+	unwrapCache = map[[32]byte]unwrappedKey{}
+	reopened, err = NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore with command wrap: %v", err)
+	}
+	if got, _, _ := reopened.Get("api"); got != "plugin-value" {
+		t.Fatalf("Get with command wrap: %q", got)
+	}
+	unwrapCache = map[[32]byte]unwrappedKey{}
+	cfg.Secrets.KeyWrap = config.SecretsKeyWrapConfig{}
+	if _, err := NewStore(cfg); err == nil {
+		t.Fatal("expected command-wrapped key without key_wrap config to fail")
+	}
+}
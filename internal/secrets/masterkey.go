@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"openclawssy/internal/config"
+	"openclawssy/internal/fsutil"
+)
+
+// pendingKeySuffix marks a master key written during rotation. It becomes the
+// master key once the store has been re-encrypted under it.
+const pendingKeySuffix = ".next"
+
+// RotateMasterKey re-encrypts the store under a freshly generated master key
+// and replaces the key file. The new key is written next to the old one first,
+// so an interrupted rotation is finished (or discarded) by the next NewStore.
+func (s *Store) RotateMasterKey() error {
+	if os.Getenv(envMasterKey) != "" {
+		return fmt.Errorf("master key comes from %s; unset it to rotate the key file", envMasterKey)
+	}
+	if s.keyFile == "" || s.key == nil || s.rewrap == nil {
+		return errors.New("master key file is not configured")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plaintext, err := readEncrypted(s.path, s.key)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeDoc(plaintext)
+	if err != nil {
+		return err
+	}
+	doc.Format = storeFormat
+	plaintext, err = json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	newKey := make([]byte, 32)
+	if _, err := rand.Read(newKey); err != nil {
+		return err
+	}
+	// The new key is wrapped the same way as the old one.
+	keyData, err := s.rewrap(newKey)
+	if err != nil {
+		return err
+	}
+	pending := s.keyFile + pendingKeySuffix
+	if err := os.MkdirAll(filepath.Dir(pending), 0o700); err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(pending, keyData, 0o600); err != nil {
+		return err
+	}
+	if err := writeEncrypted(s.path, newKey, plaintext); err != nil {
+		_ = os.Remove(pending)
+		return err
+	}
+	if err := os.Rename(pending, s.keyFile); err != nil {
+		return fmt.Errorf("store re-encrypted but key file not replaced (retried on next open): %w", err)
+	}
+	s.key = newKey
+	cacheUnwrapped(keyData, unwrappedKey{key: newKey, rewrap: s.rewrap})
+	return nil
+}
+
+// finishMasterKeyRotation resolves a rotation interrupted after the pending
+// key was written: if the store already decrypts under it, it replaces the key
+// file, otherwise it is discarded.
+func finishMasterKeyRotation(cfg config.SecretsConfig, storeFile string) error {
+	keyFile := cfg.MasterKeyFile
+	if keyFile == "" {
+		return nil
+	}
+	pending := keyFile + pendingKeySuffix
+	raw, err := os.ReadFile(pending)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	key, err := parseKeyFile(raw, cfg)
+	if err != nil {
+		if errors.Is(err, ErrPassphraseRequired) {
+			return err
+		}
+		return os.Remove(pending)
+	}
+	if _, err := readEncrypted(storeFile, key.key); err != nil {
+		return os.Remove(pending)
+	}
+	return os.Rename(pending, keyFile)
+}
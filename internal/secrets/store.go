@@ -10,19 +10,41 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"openclawssy/internal/config"
 	"openclawssy/internal/fsutil"
+	"openclawssy/internal/policy"
 )
 
 const envMasterKey = "OPENCLAWSSY_MASTER_KEY"
 
+// MaxVersions is how many values are kept per secret, including the current
+// one. Older versions are dropped when a new value is set.
+const MaxVersions = 10
+
+const storeFormat = 2
+
+var (
+	ErrAccessDenied   = errors.New("secret access denied")
+	ErrNoSuchSecret   = errors.New("secret not found")
+	ErrReadOnlySecret = errors.New("secret is held by a read-only backend")
+)
+
+// Store resolves secrets through the configured backends. The encrypted local
+// file also holds versions and ACLs, including ACLs for names whose values
+// live in another backend.
 type Store struct {
-	path string
-	key  []byte
-	mu   sync.Mutex
+	path     string
+	keyFile  string
+	key      []byte
+	rewrap   func(key []byte) ([]byte, error)
+	local    bool
+	backends []Backend
+	mu       sync.Mutex
 }
 
 type encryptedDoc struct {
@@ -31,86 +53,559 @@ type encryptedDoc struct {
 	UpdatedAt  string `json:"updated_at"`
 }
 
+// ACL limits which agents may read a secret. An empty ACL lets any agent read
+// it; "*" in Agents does the same explicitly. Skills are not principals: their
+// names come from the agent-writable skills directory, so a skill.run reads
+// as the calling agent.
+type ACL struct {
+	Agents []string `json:"agents,omitempty"`
+}
+
+// Reader identifies the agent reading a secret.
+type Reader struct {
+	AgentID string
+}
+
+func (a ACL) Empty() bool {
+	return len(a.Agents) == 0
+}
+
+func (a ACL) Allows(r Reader) bool {
+	if a.Empty() {
+		return true
+	}
+	for _, agentID := range a.Agents {
+		if agentID == "*" || (r.AgentID != "" && agentID == r.AgentID) {
+			return true
+		}
+	}
+	return false
+}
+
+type VersionInfo struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Metadata describes a secret without its value. Secrets from external
+// backends have no versions.
+type Metadata struct {
+	Name      string        `json:"name"`
+	Backend   string        `json:"backend"`
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	RotatedAt time.Time     `json:"rotated_at"`
+	Versions  []VersionInfo `json:"versions"`
+	ACL       ACL           `json:"acl"`
+}
+
+type secretVersion struct {
+	Version   int       `json:"version"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type secretEntry struct {
+	Current   int             `json:"current"`
+	CreatedAt time.Time       `json:"created_at"`
+	RotatedAt time.Time       `json:"rotated_at"`
+	Versions  []secretVersion `json:"versions"`
+	ACL       ACL             `json:"acl"`
+}
+
+func (e *secretEntry) current() (secretVersion, bool) {
+	for _, v := range e.Versions {
+		if v.Version == e.Current {
+			return v, true
+		}
+	}
+	return secretVersion{}, false
+}
+
+func (e *secretEntry) metadata(name string) Metadata {
+	meta := Metadata{Name: name, Backend: config.SecretsBackendStore, Version: e.Current, CreatedAt: e.CreatedAt, RotatedAt: e.RotatedAt, ACL: e.ACL}
+	meta.Versions = make([]VersionInfo, 0, len(e.Versions))
+	for _, v := range e.Versions {
+		meta.Versions = append(meta.Versions, VersionInfo{Version: v.Version, CreatedAt: v.CreatedAt})
+	}
+	return meta
+}
+
+// storeDoc is the plaintext inside the encrypted file. Stores written before
+// versioning hold a flat name->value object instead and are upgraded on the
+// next write.
+type storeDoc struct {
+	Format  int                     `json:"format"`
+	Secrets map[string]*secretEntry `json:"secrets"`
+}
+
 func NewStore(cfg config.Config) (*Store, error) {
-	key, err := loadMasterKey(cfg.Secrets.MasterKeyFile)
+	s := &Store{path: cfg.Secrets.StoreFile, keyFile: cfg.Secrets.MasterKeyFile}
+	for _, name := range cfg.Secrets.Backends {
+		s.local = s.local || name == config.SecretsBackendStore
+	}
+	if len(cfg.Secrets.Backends) == 0 {
+		s.local = true
+	}
+	if err := finishMasterKeyRotation(cfg.Secrets, cfg.Secrets.StoreFile); err != nil {
+		return nil, err
+	}
+	key, err := loadMasterKey(cfg.Secrets)
+	if err != nil && s.local {
+		return nil, err
+	}
+	// Without the store backend the key is optional: it only unlocks ACLs.
+	s.key = key.key
+	s.rewrap = key.rewrap
+	backends, err := newBackends(cfg.Secrets, localBackend{s: s})
 	if err != nil {
 		return nil, err
 	}
-	return &Store{path: cfg.Secrets.StoreFile, key: key}, nil
+	s.backends = backends
+	return s, nil
 }
 
+// Set stores value as the new current version of name in the local store.
+// Setting the value it already holds is a no-op.
 func (s *Store) Set(name, value string) error {
+	if !s.local {
+		return fmt.Errorf("%w: the local store is not an enabled backend", ErrReadOnlySecret)
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	data, err := s.readAllLocked()
+	doc, err := s.readDocLocked()
 	if err != nil {
 		return err
 	}
-	data[name] = value
-	return s.writeAllLocked(data)
+	now := time.Now().UTC()
+	entry := doc.Secrets[name]
+	if entry == nil {
+		entry = &secretEntry{CreatedAt: now}
+		doc.Secrets[name] = entry
+	} else if cur, ok := entry.current(); ok && cur.Value == value {
+		return nil
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	next := 1
+	for _, v := range entry.Versions {
+		if v.Version >= next {
+			next = v.Version + 1
+		}
+	}
+	entry.Versions = append(entry.Versions, secretVersion{Version: next, Value: value, CreatedAt: now})
+	entry.Current = next
+	entry.RotatedAt = now
+	if len(entry.Versions) > MaxVersions {
+		entry.Versions = append([]secretVersion(nil), entry.Versions[len(entry.Versions)-MaxVersions:]...)
+	}
+	if err := s.writeDocLocked(doc); err != nil {
+		return err
+	}
+	s.publishLocked(doc)
+	return nil
 }
 
+// Get returns the value of name from the first backend that holds it, without
+// an ACL check. It is meant for the runtime itself (provider keys, channel
+// tokens); agent and skill reads go through GetFor.
 func (s *Store) Get(name string) (string, bool, error) {
+	value, _, found, err := s.resolve(name)
+	return value, found, err
+}
+
+// GetFor is Get behind the name's ACL: it returns ErrAccessDenied when the ACL
+// does not allow reader. A value from an external backend is denied unless an
+// ACL lists the reader (or "*"), since env, files and Vault also hold the
+// runtime's own credentials. Missing secrets report found=false.
+func (s *Store) GetFor(name string, reader Reader) (string, bool, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	data, err := s.readAllLocked()
+	doc, err := s.readDocLocked()
+	s.mu.Unlock()
 	if err != nil {
 		return "", false, err
 	}
-	v, ok := data[name]
-	return v, ok, nil
+	value, backend, found, err := s.resolve(name)
+	if err != nil || !found {
+		return "", false, err
+	}
+	var acl ACL
+	if entry, ok := doc.Secrets[name]; ok {
+		acl = entry.ACL
+	}
+	_, isLocal := backend.(localBackend)
+	if (!isLocal && acl.Empty()) || !acl.Allows(reader) {
+		return "", false, fmt.Errorf("%w: %s", ErrAccessDenied, name)
+	}
+	return value, true, nil
 }
 
+// resolve walks the backends in order. A backend error stops the walk rather
+// than falling through to a possibly stale value further down.
+func (s *Store) resolve(name string) (string, Backend, bool, error) {
+	for _, b := range s.backends {
+		value, found, err := b.Lookup(name)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("secrets: %s backend: %w", b.Name(), err)
+		}
+		if !found {
+			continue
+		}
+		if _, isLocal := b.(localBackend); !isLocal {
+			observeExternalValue(value)
+		}
+		return value, b, true, nil
+	}
+	return "", nil, false, nil
+}
+
+// ListKeys returns the names held by any backend.
 func (s *Store) ListKeys() ([]string, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	data, err := s.readAllLocked()
+	metas, err := s.List()
 	if err != nil {
 		return nil, err
 	}
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
+	keys := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		keys = append(keys, meta.Name)
 	}
 	return keys, nil
 }
 
-func (s *Store) readAllLocked() (map[string]string, error) {
-	b, err := os.ReadFile(s.path)
+// List returns metadata for every secret any backend holds, sorted by name.
+// A name held by several backends is reported for the first in order.
+func (s *Store) List() ([]Metadata, error) {
+	s.mu.Lock()
+	doc, err := s.readDocLocked()
+	s.mu.Unlock()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return map[string]string{}, nil
-		}
 		return nil, err
 	}
-	if len(b) == 0 {
-		return map[string]string{}, nil
+	seen := map[string]bool{}
+	var out []Metadata
+	for _, b := range s.backends {
+		if _, isLocal := b.(localBackend); isLocal {
+			for name, entry := range doc.Secrets {
+				if _, ok := entry.current(); ok && !seen[name] {
+					seen[name] = true
+					out = append(out, entry.metadata(name))
+				}
+			}
+			continue
+		}
+		names, err := b.List()
+		if err != nil {
+			return nil, fmt.Errorf("secrets: %s backend: %w", b.Name(), err)
+		}
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			meta := Metadata{Name: name, Backend: b.Name()}
+			if entry, ok := doc.Secrets[name]; ok {
+				meta.ACL = entry.ACL
+			}
+			out = append(out, meta)
+		}
 	}
-	var doc encryptedDoc
-	if err := json.Unmarshal(b, &doc); err != nil {
-		return nil, err
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (s *Store) Metadata(name string) (Metadata, bool, error) {
+	s.mu.Lock()
+	doc, err := s.readDocLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return Metadata{}, false, err
+	}
+	entry := doc.Secrets[name]
+	_, b, found, err := s.resolve(name)
+	if err != nil || !found {
+		return Metadata{}, false, err
+	}
+	if _, isLocal := b.(localBackend); isLocal {
+		return entry.metadata(name), true, nil
 	}
-	plaintext, err := decrypt(s.key, doc.Nonce, doc.Ciphertext)
+	meta := Metadata{Name: name, Backend: b.Name()}
+	if entry != nil {
+		meta.ACL = entry.ACL
+	}
+	return meta, true, nil
+}
+
+// Rollback makes an earlier retained version current again. Later versions are
+// kept, so a rollback can itself be undone.
+func (s *Store) Rollback(name string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, err := s.readDocLocked()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	out := map[string]string{}
-	if len(plaintext) == 0 {
-		return out, nil
+	entry, ok := doc.Secrets[name]
+	if !ok || len(entry.Versions) == 0 {
+		return fmt.Errorf("%w: %s has no versions in the local store", ErrNoSuchSecret, name)
+	}
+	found := false
+	for _, v := range entry.Versions {
+		if v.Version == version {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("secret %s has no version %d", name, version)
+	}
+	if entry.Current == version {
+		return nil
+	}
+	entry.Current = version
+	entry.RotatedAt = time.Now().UTC()
+	if err := s.writeDocLocked(doc); err != nil {
+		return err
+	}
+	s.publishLocked(doc)
+	return nil
+}
+
+// SetACL replaces the ACL of a secret held by any backend. An empty ACL opens
+// a stored secret to every agent again and closes an external one.
+func (s *Store) SetACL(name string, acl ACL) error {
+	if s.key == nil {
+		return errors.New("secret ACLs are kept in the local store, which needs the master key")
+	}
+	if _, _, found, err := s.resolve(name); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("%w: %s", ErrNoSuchSecret, name)
 	}
-	if err := json.Unmarshal(plaintext, &out); err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, err := s.readDocLocked()
+	if err != nil {
+		return err
+	}
+	entry, ok := doc.Secrets[name]
+	if !ok {
+		entry = &secretEntry{}
+		doc.Secrets[name] = entry
+	}
+	entry.ACL = ACL{Agents: cleanNames(acl.Agents)}
+	return s.writeDocLocked(doc)
+}
+
+// localBackend exposes the encrypted store in the resolution order.
+type localBackend struct {
+	s *Store
+}
+
+func (b localBackend) Name() string { return config.SecretsBackendStore }
+
+func (b localBackend) Lookup(name string) (string, bool, error) {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+	doc, err := b.s.readDocLocked()
+	if err != nil {
+		return "", false, err
+	}
+	entry, ok := doc.Secrets[name]
+	if !ok {
+		return "", false, nil
+	}
+	cur, ok := entry.current()
+	return cur.Value, ok, nil
+}
+
+func (b localBackend) List() ([]string, error) {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+	doc, err := b.s.readDocLocked()
+	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	names := make([]string, 0, len(doc.Secrets))
+	for name, entry := range doc.Secrets {
+		if _, ok := entry.current(); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func cleanNames(names []string) []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// RefreshRedaction hands every stored value to the policy redactor so known
+// secrets are scrubbed from outputs, traces and logs. The store file is only
+// decrypted again when its size or modification time has changed since the
+// last publish.
+func (s *Store) RefreshRedaction() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sig := s.signature()
+	publishedMu.Lock()
+	current := published == sig && policy.SecretValueCount() > 0
+	publishedMu.Unlock()
+	if current {
+		return nil
+	}
+	doc, err := s.readDocLocked()
+	if err != nil {
+		return err
+	}
+	s.publishLocked(doc)
+	return nil
+}
+
+type storeSignature struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+var (
+	publishedMu    sync.Mutex
+	published      storeSignature
+	localValues    []string
+	externalValues = map[string]struct{}{}
+)
+
+func (s *Store) signature() storeSignature {
+	sig := storeSignature{path: s.path}
+	if info, err := os.Stat(s.path); err == nil {
+		sig.size = info.Size()
+		sig.modTime = info.ModTime()
+	}
+	return sig
+}
+
+// publishLocked hands every retained version to the redactor, so values that
+// were rotated out or rolled back stay scrubbed.
+func (s *Store) publishLocked(doc storeDoc) {
+	values := make([]string, 0, len(doc.Secrets))
+	for _, entry := range doc.Secrets {
+		for _, v := range entry.Versions {
+			values = append(values, v.Value)
+		}
+	}
+	publishedMu.Lock()
+	defer publishedMu.Unlock()
+	localValues = values
+	published = s.signature()
+	publishValuesLocked()
 }
 
-func (s *Store) writeAllLocked(data map[string]string) error {
-	plaintext, err := json.Marshal(data)
+// observeExternalValue adds a value read from an external backend to the
+// redactor. External backends are not enumerated up front, so their values
+// are redacted from the first time they are read.
+func observeExternalValue(value string) {
+	publishedMu.Lock()
+	defer publishedMu.Unlock()
+	if _, ok := externalValues[value]; ok {
+		return
+	}
+	externalValues[value] = struct{}{}
+	publishValuesLocked()
+}
+
+func publishValuesLocked() {
+	values := append([]string(nil), localValues...)
+	for v := range externalValues {
+		values = append(values, v)
+	}
+	policy.SetSecretValues(values)
+}
+
+func (s *Store) readDocLocked() (storeDoc, error) {
+	if s.key == nil {
+		// Only possible without the store backend: no key, no local ACLs.
+		if _, err := os.Stat(s.path); err == nil {
+			return storeDoc{}, errors.New("secret store exists but the master key is missing")
+		}
+		return storeDoc{Secrets: map[string]*secretEntry{}}, nil
+	}
+	plaintext, err := readEncrypted(s.path, s.key)
+	if err != nil {
+		return storeDoc{}, err
+	}
+	return decodeDoc(plaintext)
+}
+
+func (s *Store) writeDocLocked(doc storeDoc) error {
+	doc.Format = storeFormat
+	plaintext, err := json.Marshal(doc)
 	if err != nil {
 		return err
 	}
-	nonce, ciphertext, err := encrypt(s.key, plaintext)
+	return writeEncrypted(s.path, s.key, plaintext)
+}
+
+func decodeDoc(plaintext []byte) (storeDoc, error) {
+	doc := storeDoc{Secrets: map[string]*secretEntry{}}
+	if len(plaintext) == 0 {
+		return doc, nil
+	}
+	if err := json.Unmarshal(plaintext, &doc); err == nil && doc.Format >= storeFormat {
+		if doc.Secrets == nil {
+			doc.Secrets = map[string]*secretEntry{}
+		}
+		return doc, nil
+	}
+	legacy := map[string]string{}
+	if err := json.Unmarshal(plaintext, &legacy); err != nil {
+		return storeDoc{}, err
+	}
+	doc = storeDoc{Format: storeFormat, Secrets: make(map[string]*secretEntry, len(legacy))}
+	for name, value := range legacy {
+		doc.Secrets[name] = &secretEntry{Current: 1, Versions: []secretVersion{{Version: 1, Value: value}}}
+	}
+	return doc, nil
+}
+
+// readEncrypted returns the decrypted contents of path, or nil when the file
+// is missing or empty.
+func readEncrypted(path string, key []byte) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var doc encryptedDoc
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return decrypt(key, doc.Nonce, doc.Ciphertext)
+}
+
+func writeEncrypted(path string, key []byte, plaintext []byte) error {
+	nonce, ciphertext, err := encrypt(key, plaintext)
 	if err != nil {
 		return err
 	}
@@ -122,34 +617,28 @@ func (s *Store) writeAllLocked(data map[string]string) error {
 	out = append(out, '\n')
 
 	// Ensure directory exists with restricted permissions before atomic write
-	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
 		return err
 	}
 
-	return fsutil.WriteFileAtomic(s.path, out, 0o600)
+	return fsutil.WriteFileAtomic(path, out, 0o600)
 }
 
-func loadMasterKey(masterKeyFile string) ([]byte, error) {
-	raw := os.Getenv(envMasterKey)
-	if raw == "" {
-		data, err := os.ReadFile(masterKeyFile)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return nil, fmt.Errorf("missing master key: set %s or create %s", envMasterKey, masterKeyFile)
-			}
-			return nil, err
-		}
-		raw = string(data)
+// loadMasterKey returns the master key from OPENCLAWSSY_MASTER_KEY or the key
+// file, unwrapping the file if it is wrapped.
+func loadMasterKey(cfg config.SecretsConfig) (unwrappedKey, error) {
+	if raw := os.Getenv(envMasterKey); raw != "" {
+		key, err := decodeRawKey(raw)
+		return unwrappedKey{key: key}, err
 	}
-	raw = stringTrimSpace(raw)
-	key, err := base64.StdEncoding.DecodeString(raw)
+	data, err := os.ReadFile(cfg.MasterKeyFile)
 	if err != nil {
-		return nil, fmt.Errorf("invalid master key encoding: %w", err)
-	}
-	if len(key) != 32 {
-		return nil, errors.New("master key must decode to 32 bytes")
+		if os.IsNotExist(err) {
+			return unwrappedKey{}, fmt.Errorf("missing master key: set %s or create %s", envMasterKey, cfg.MasterKeyFile)
+		}
+		return unwrappedKey{}, err
 	}
-	return key, nil
+	return parseKeyFile(data, cfg)
 }
 
 func GenerateAndWriteMasterKey(path string) (string, error) {
@@ -203,18 +692,3 @@ func decrypt(key []byte, nonce string, ciphertext string) ([]byte, error) {
 	}
 	return gcm.Open(nil, n, ct, nil)
 }
-
-func stringTrimSpace(s string) string {
-	for len(s) > 0 && (s[0] == ' ' || s[0] == '\n' || s[0] == '\t' || s[0] == '\r') {
-		s = s[1:]
-	}
-	for len(s) > 0 {
-		l := len(s) - 1
-		if s[l] == ' ' || s[l] == '\n' || s[l] == '\t' || s[l] == '\r' {
-			s = s[:l]
-			continue
-		}
-		break
-	}
-	return s
-}
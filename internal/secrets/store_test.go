@@ -3,12 +3,15 @@ package secrets
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"openclawssy/internal/config"
+	"openclawssy/internal/policy"
 )
 
 func TestGenerateAndWriteMasterKey(t *testing.T) {
@@ -407,3 +410,211 @@ func TestStore_EmptyFile(t *testing.T) {
 		t.Errorf("Expected 0 keys from empty file, got %d", len(keys))
 	}
 }
+
+func TestStore_PublishesValuesForRedaction(t *testing.T) {
+	t.Cleanup(func() { policy.SetSecretValues(nil) })
+	tempDir := t.TempDir()
+	keyFile := filepath.Join(tempDir, "master.key")
+	if _, err := GenerateAndWriteMasterKey(keyFile); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	cfg := config.Config{}
+	cfg.Secrets.MasterKeyFile = keyFile
+	cfg.Secrets.StoreFile = filepath.Join(tempDir, "secrets.enc")
+
+	writer, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if err := writer.Set("provider/api_key", "value-one-123"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got := policy.RedactSecrets("x value-one-123 y"); got != "x [REDACTED] y" {
+		t.Fatalf("Set did not publish value, got %q", got)
+	}
+
+	policy.SetSecretValues(nil)
+	reader, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if err := reader.RefreshRedaction(); err != nil {
+		t.Fatalf("RefreshRedaction failed: %v", err)
+	}
+	if got := policy.RedactSecrets("value-one-123"); got != "[REDACTED]" {
+		t.Fatalf("RefreshRedaction did not load values, got %q", got)
+	}
+}
+
+func newTestStore(t *testing.T) (*Store, config.Config) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cfg := config.Config{}
+	cfg.Secrets.MasterKeyFile = filepath.Join(tempDir, "master.key")
+	cfg.Secrets.StoreFile = filepath.Join(tempDir, "secrets.enc")
+	if _, err := GenerateAndWriteMasterKey(cfg.Secrets.MasterKeyFile); err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	store, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	return store, cfg
+}
+
+func TestStore_VersionsAndRollback(t *testing.T) {
+	store, _ := newTestStore(t)
+	for _, v := range []string{"first-value", "second-value", "second-value", "third-value"} {
+		if err := store.Set("api", v); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	meta, found, err := store.Metadata("api")
+	if err != nil || !found {
+		t.Fatalf("Metadata failed: found=%v err=%v", found, err)
+	}
+	if meta.Version != 3 || len(meta.Versions) != 3 {
+		t.Fatalf("expected 3 versions with v3 current, got %+v", meta)
+	}
+	if meta.CreatedAt.IsZero() || meta.RotatedAt.Before(meta.CreatedAt) {
+		t.Fatalf("unexpected timestamps: %+v", meta)
+	}
+
+	if err := store.Rollback("api", 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if got, _, _ := store.Get("api"); got != "first-value" {
+		t.Fatalf("expected rolled back value, got %q", got)
+	}
+	if err := store.Set("api", "fourth-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if meta, _, _ := store.Metadata("api"); meta.Version != 4 {
+		t.Fatalf("expected new version after rollback to be 4, got %d", meta.Version)
+	}
+	if err := store.Rollback("api", 99); err == nil {
+		t.Fatal("expected rollback to unknown version to fail")
+	}
+
+	for i := 0; i < MaxVersions+5; i++ {
+		if err := store.Set("api", fmt.Sprintf("value-%d", i)); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	if meta, _, _ := store.Metadata("api"); len(meta.Versions) != MaxVersions {
+		t.Fatalf("expected history capped at %d, got %d", MaxVersions, len(meta.Versions))
+	}
+}
+
+func TestStore_ACL(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := store.Set("deploy", "deploy-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, found, err := store.GetFor("deploy", Reader{AgentID: "any"}); err != nil || !found {
+		t.Fatalf("empty ACL should allow any agent: found=%v err=%v", found, err)
+	}
+	if err := store.SetACL("deploy", ACL{Agents: []string{"ops"}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	if _, _, err := store.GetFor("deploy", Reader{AgentID: "default"}); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("expected access denied, got %v", err)
+	}
+	if v, _, err := store.GetFor("deploy", Reader{AgentID: "ops"}); err != nil || v != "deploy-value" {
+		t.Fatalf("ops should read: %q %v", v, err)
+	}
+	if err := store.Set("deploy", "deploy-value-2"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if meta, _, _ := store.Metadata("deploy"); len(meta.ACL.Agents) != 1 {
+		t.Fatalf("ACL must survive rotation, got %+v", meta.ACL)
+	}
+	if err := store.SetACL("missing", ACL{}); !errors.Is(err, ErrNoSuchSecret) {
+		t.Fatalf("expected ErrNoSuchSecret, got %v", err)
+	}
+}
+
+func TestStore_UpgradesLegacyFlatStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	if err := writeEncrypted(store.path, store.key, []byte(`{"legacy":"legacy-value"}`)); err != nil {
+		t.Fatalf("write legacy store: %v", err)
+	}
+	if got, found, err := store.Get("legacy"); err != nil || !found || got != "legacy-value" {
+		t.Fatalf("legacy read failed: %q %v %v", got, found, err)
+	}
+	if err := store.Set("legacy", "new-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if meta, _, _ := store.Metadata("legacy"); meta.Version != 2 || len(meta.Versions) != 2 {
+		t.Fatalf("expected legacy value kept as v1, got %+v", meta)
+	}
+}
+
+func TestStore_RotateMasterKey(t *testing.T) {
+	store, cfg := newTestStore(t)
+	if err := store.Set("api", "rotate-me"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	oldKey, _ := os.ReadFile(cfg.Secrets.MasterKeyFile)
+	if err := store.RotateMasterKey(); err != nil {
+		t.Fatalf("RotateMasterKey failed: %v", err)
+	}
+	newKey, _ := os.ReadFile(cfg.Secrets.MasterKeyFile)
+	if string(oldKey) == string(newKey) {
+		t.Fatal("expected key file to change")
+	}
+	reopened, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if got, _, err := reopened.Get("api"); err != nil || got != "rotate-me" {
+		t.Fatalf("read after rotation: %q %v", got, err)
+	}
+	if got, _, err := store.Get("api"); err != nil || got != "rotate-me" {
+		t.Fatalf("rotating store must keep working: %q %v", got, err)
+	}
+}
+
+func TestStore_FinishesInterruptedRotation(t *testing.T) {
+	store, cfg := newTestStore(t)
+	if err := store.Set("api", "halfway"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	pending := cfg.Secrets.MasterKeyFile + pendingKeySuffix
+
+	// Crash before the store was re-encrypted: the pending key is discarded.
+	if _, err := GenerateAndWriteMasterKey(pending); err != nil {
+		t.Fatalf("write pending key: %v", err)
+	}
+	reopened, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if got, _, err := reopened.Get("api"); err != nil || got != "halfway" {
+		t.Fatalf("read with old key: %q %v", got, err)
+	}
+	if _, err := os.Stat(pending); !os.IsNotExist(err) {
+		t.Fatalf("expected pending key to be removed, stat err=%v", err)
+	}
+
+	// Crash after the store was re-encrypted: the pending key is promoted.
+	encoded, err := GenerateAndWriteMasterKey(pending)
+	if err != nil {
+		t.Fatalf("write pending key: %v", err)
+	}
+	newKey, _ := base64.StdEncoding.DecodeString(encoded)
+	plaintext, err := readEncrypted(cfg.Secrets.StoreFile, store.key)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	if err := writeEncrypted(cfg.Secrets.StoreFile, newKey, plaintext); err != nil {
+		t.Fatalf("re-encrypt: %v", err)
+	}
+	reopened, err = NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if got, _, err := reopened.Get("api"); err != nil || got != "halfway" {
+		t.Fatalf("read with promoted key: %q %v", got, err)
+	}
+}
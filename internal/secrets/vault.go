@@ -0,0 +1,164 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"openclawssy/internal/config"
+)
+
+// maxVaultListDepth bounds the recursive metadata walk in List.
+const maxVaultListDepth = 8
+
+// VaultBackend reads a Vault compatible KV v2 secrets engine over HTTP. The
+// token is read from the environment on every request so it can be renewed
+// outside the process.
+type VaultBackend struct {
+	Address   string
+	Mount     string
+	Prefix    string
+	Field     string
+	Namespace string
+	TokenEnv  string
+	Client    *http.Client
+}
+
+func newVaultBackend(cfg config.SecretsVaultConfig) *VaultBackend {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &VaultBackend{
+		Address:   strings.TrimRight(cfg.Address, "/"),
+		Mount:     strings.Trim(cfg.Mount, "/"),
+		Prefix:    strings.Trim(cfg.Prefix, "/"),
+		Field:     cfg.Field,
+		Namespace: cfg.Namespace,
+		TokenEnv:  cfg.TokenEnv,
+		Client:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (b *VaultBackend) Name() string { return config.SecretsBackendVault }
+
+func (b *VaultBackend) Lookup(name string) (string, bool, error) {
+	if !validSecretName(name) {
+		return "", false, nil
+	}
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	found, err := b.do(http.MethodGet, b.url("data", name), &body)
+	if err != nil || !found {
+		return "", false, err
+	}
+	// A deleted latest version comes back with null data.
+	raw, ok := body.Data.Data[b.field()]
+	if !ok {
+		return "", false, nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", false, fmt.Errorf("vault secret %s: field %q is not a string", name, b.field())
+	}
+	return value, true, nil
+}
+
+func (b *VaultBackend) List() ([]string, error) {
+	var names []string
+	var walk func(dir string, depth int) error
+	walk = func(dir string, depth int) error {
+		var body struct {
+			Data struct {
+				Keys []string `json:"keys"`
+			} `json:"data"`
+		}
+		found, err := b.do("LIST", b.url("metadata", dir)+"/", &body)
+		if err != nil || !found {
+			return err
+		}
+		for _, key := range body.Data.Keys {
+			name := strings.TrimPrefix(dir+"/"+key, "/")
+			if !strings.HasSuffix(key, "/") {
+				names = append(names, name)
+				continue
+			}
+			if depth < maxVaultListDepth {
+				if err := walk(strings.TrimSuffix(name, "/"), depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk("", 0); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (b *VaultBackend) field() string {
+	if b.Field == "" {
+		return "value"
+	}
+	return b.Field
+}
+
+func (b *VaultBackend) url(kind, name string) string {
+	parts := []string{b.Address, "v1", b.Mount, kind}
+	if b.Prefix != "" {
+		parts = append(parts, b.Prefix)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment != "" {
+			parts = append(parts, url.PathEscape(segment))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// do sends a request and decodes a 2xx JSON body into out. A 404 reports
+// found=false.
+func (b *VaultBackend) do(method, target string, out any) (bool, error) {
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return false, err
+	}
+	if b.TokenEnv != "" {
+		if token := strings.TrimSpace(os.Getenv(b.TokenEnv)); token != "" {
+			req.Header.Set("X-Vault-Token", token)
+		}
+	}
+	if b.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.Namespace)
+	}
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		return false, fmt.Errorf("vault %s %s: %s", method, req.URL.Path, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(out); err != nil {
+		return false, fmt.Errorf("vault %s %s: %w", method, req.URL.Path, err)
+	}
+	return true, nil
+}
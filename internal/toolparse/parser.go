@@ -28,6 +28,7 @@ var toolAliases = map[string]string{
 	"skill.list":           "skill.list",
 	"skill.read":           "skill.read",
 	"skill.get":            "skill.read",
+	"skill.run":            "skill.run",
 	"scheduler.list":       "scheduler.list",
 	"scheduler.add":        "scheduler.add",
 	"scheduler.remove":     "scheduler.remove",
@@ -60,6 +61,9 @@ var toolAliases = map[string]string{
 	"memory.health":        "memory.health",
 	"memory.checkpoint":    "memory.checkpoint",
 	"memory.maintenance":   "memory.maintenance",
+	"memory.link":          "memory.link",
+	"memory.graph.query":   "memory.graph.query",
+	"memory.graph":         "memory.graph.query",
 	"decision.log":         "decision.log",
 	"http.request":         "http.request",
 	"net.fetch":            "http.request",
@@ -70,6 +74,10 @@ var toolAliases = map[string]string{
 	"terminal.run":         "shell.exec",
 }
 
+// mcpToolPattern matches tools mounted from MCP servers as mcp.<server>.<tool>.
+// They are discovered at run time, so they cannot be listed in toolAliases.
+var mcpToolPattern = regexp.MustCompile(`^mcp\.[a-z0-9_-]+\.[a-z0-9_.-]+$`)
+
 type Extraction struct {
 	RawSnippet      string
 	ParsedToolName  string
@@ -107,8 +115,13 @@ func CanonicalToolName(name string) (string, bool) {
 	if candidate == "" {
 		return "", false
 	}
-	toolName, ok := toolAliases[candidate]
-	return toolName, ok
+	if toolName, ok := toolAliases[candidate]; ok {
+		return toolName, true
+	}
+	if mcpToolPattern.MatchString(candidate) {
+		return candidate, true
+	}
+	return "", false
 }
 
 func IsAllowed(toolName string, allowed []string) bool {
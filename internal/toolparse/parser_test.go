@@ -282,6 +282,22 @@ func TestParseToolCallsCanonicalizesNetFetchAlias(t *testing.T) {
 	}
 }
 
+func TestParseToolCallsAcceptsMountedMCPTools(t *testing.T) {
+	text := "```json\n{\"tool_name\":\"MCP.GitHub.search_issues\",\"arguments\":{\"query\":\"bug\"}}\n```"
+	calls, _ := ParseToolCalls(text, []string{"mcp.github.search_issues"})
+	if len(calls) != 1 || calls[0].Name != "mcp.github.search_issues" {
+		t.Fatalf("expected lowercased mcp tool call, got %+v", calls)
+	}
+
+	calls, diag := ParseToolCalls(text, []string{"fs.list"})
+	if len(calls) != 0 || len(diag.Rejected) != 1 {
+		t.Fatalf("expected unmounted mcp tool to be rejected, got %+v", calls)
+	}
+	if _, ok := CanonicalToolName("mcp.github"); ok {
+		t.Fatal("expected mcp name without tool segment to be rejected")
+	}
+}
+
 func TestParseToolCallsCapsReturnedCallsAtSix(t *testing.T) {
 	var b strings.Builder
 	for i := 0; i < 8; i++ {
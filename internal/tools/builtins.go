@@ -82,7 +82,7 @@ func RegisterCoreWithOptions(reg *Registry, opts CoreOptions) error {
 	if err := registerSecretsTools(reg, opts.ConfigPath); err != nil {
 		return err
 	}
-	if err := registerSkillTools(reg, opts.ConfigPath); err != nil {
+	if err := registerSkillTools(reg, opts.ConfigPath, opts.EnableShellExec); err != nil {
 		return err
 	}
 	if err := registerSchedulerTools(reg, opts.SchedulerPath); err != nil {
@@ -574,15 +574,7 @@ func shellExec(ctx context.Context, req Request) (map[string]any, error) {
 			return nil, fmt.Errorf("args must be an array")
 		}
 	}
-	invocation := strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
-	allowed := false
-	for _, prefix := range req.ShellAllowedCommands {
-		if commandMatchesPrefix(invocation, prefix) {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
+	if !shellCommandAllowed(req.ShellAllowedCommands, command, args) {
 		return nil, &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: "command is not allowed"}
 	}
 
@@ -624,6 +616,16 @@ func shellExec(ctx context.Context, req Request) (map[string]any, error) {
 	return res, returnErr
 }
 
+func shellCommandAllowed(prefixes []string, command string, args []string) bool {
+	invocation := strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
+	for _, prefix := range prefixes {
+		if commandMatchesPrefix(invocation, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func commandMatchesPrefix(invocation, prefix string) bool {
 	invocation = strings.TrimSpace(invocation)
 	prefix = strings.TrimSpace(prefix)
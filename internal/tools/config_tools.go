@@ -292,6 +292,42 @@ func applyConfigFieldUpdate(cfg *config.Config, field string, value any) error {
 			return err
 		}
 		cfg.Memory.EventBufferSize = n
+	case "memory.event_retention.max_age_days":
+		n, err := requireInt(value, field)
+		if err != nil {
+			return err
+		}
+		cfg.Memory.EventRetention.MaxAgeDays = n
+	case "memory.event_retention.max_mb":
+		n, err := requireInt(value, field)
+		if err != nil {
+			return err
+		}
+		cfg.Memory.EventRetention.MaxMB = n
+	case "memory.event_retention.compress_after_days":
+		n, err := requireInt(value, field)
+		if err != nil {
+			return err
+		}
+		cfg.Memory.EventRetention.CompressAfterDays = n
+	case "memory.housekeeping.checkpoint_event_threshold":
+		n, err := requireInt(value, field)
+		if err != nil {
+			return err
+		}
+		cfg.Memory.Housekeeping.CheckpointEventThreshold = n
+	case "memory.housekeeping.checkpoint_idle_minutes":
+		n, err := requireInt(value, field)
+		if err != nil {
+			return err
+		}
+		cfg.Memory.Housekeeping.CheckpointIdleMinutes = n
+	case "memory.housekeeping.maintenance_interval_hours":
+		n, err := requireInt(value, field)
+		if err != nil {
+			return err
+		}
+		cfg.Memory.Housekeeping.MaintenanceIntervalHours = n
 	default:
 		return fmt.Errorf("field is not mutable: %s", field)
 	}
@@ -351,6 +387,18 @@ func configGetField(cfg config.Config, field string) (any, bool) {
 		return cfg.Memory.EmbeddingModel, true
 	case "memory.event_buffer_size":
 		return cfg.Memory.EventBufferSize, true
+	case "memory.event_retention.max_age_days":
+		return cfg.Memory.EventRetention.MaxAgeDays, true
+	case "memory.event_retention.max_mb":
+		return cfg.Memory.EventRetention.MaxMB, true
+	case "memory.event_retention.compress_after_days":
+		return cfg.Memory.EventRetention.CompressAfterDays, true
+	case "memory.housekeeping.checkpoint_event_threshold":
+		return cfg.Memory.Housekeeping.CheckpointEventThreshold, true
+	case "memory.housekeeping.checkpoint_idle_minutes":
+		return cfg.Memory.Housekeeping.CheckpointIdleMinutes, true
+	case "memory.housekeeping.maintenance_interval_hours":
+		return cfg.Memory.Housekeeping.MaintenanceIntervalHours, true
 	case "config":
 		return cfg, true
 	default:
@@ -18,22 +18,39 @@ import (
 const checkpointDistillMaxEvents = 200
 
 type checkpointDistillResult struct {
-	NewItems []checkpointNewItem `json:"new_items"`
-	Updates  []checkpointUpdate  `json:"updates"`
+	NewItems  []checkpointNewItem  `json:"new_items"`
+	Updates   []checkpointUpdate   `json:"updates"`
+	Entities  []checkpointEntity   `json:"entities,omitempty"`
+	Relations []checkpointRelation `json:"relations,omitempty"`
 }
 
 type checkpointNewItem struct {
-	Kind       string  `json:"kind"`
-	Title      string  `json:"title"`
-	Content    string  `json:"content"`
-	Importance int     `json:"importance"`
-	Confidence float64 `json:"confidence"`
+	Kind           string   `json:"kind"`
+	Title          string   `json:"title"`
+	Content        string   `json:"content"`
+	Importance     int      `json:"importance"`
+	Confidence     float64  `json:"confidence"`
+	SourceEventIDs []string `json:"source_event_ids,omitempty"`
 }
 
 type checkpointUpdate struct {
-	ID         string  `json:"id"`
-	NewContent string  `json:"new_content"`
-	Confidence float64 `json:"confidence"`
+	ID             string   `json:"id"`
+	NewContent     string   `json:"new_content"`
+	Confidence     float64  `json:"confidence"`
+	SourceEventIDs []string `json:"source_event_ids,omitempty"`
+}
+
+type checkpointEntity struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+type checkpointRelation struct {
+	Source     string  `json:"source"`
+	Relation   string  `json:"relation"`
+	Target     string  `json:"target"`
+	Confidence float64 `json:"confidence,omitempty"`
 }
 
 func distillCheckpointWithModel(ctx context.Context, cfg config.Config, events []memory.Event) (checkpointDistillResult, error) {
@@ -118,7 +135,7 @@ func parseStrictCheckpointJSON(raw string) (checkpointDistillResult, error) {
 	if out.Updates == nil {
 		out.Updates = []checkpointUpdate{}
 	}
-	if len(out.NewItems) > 200 || len(out.Updates) > 200 {
+	if len(out.NewItems) > 200 || len(out.Updates) > 200 || len(out.Entities) > 200 || len(out.Relations) > 200 {
 		return checkpointDistillResult{}, errors.New("distillation output too large")
 	}
 	for i, item := range out.NewItems {
@@ -147,6 +164,27 @@ func parseStrictCheckpointJSON(raw string) (checkpointDistillResult, error) {
 		}
 		out.Updates[i] = upd
 	}
+	for i, entity := range out.Entities {
+		entity.Name = strings.TrimSpace(entity.Name)
+		entity.Type = strings.TrimSpace(entity.Type)
+		entity.Description = strings.TrimSpace(entity.Description)
+		if entity.Name == "" {
+			return checkpointDistillResult{}, fmt.Errorf("entities[%d] requires name", i)
+		}
+		out.Entities[i] = entity
+	}
+	for i, rel := range out.Relations {
+		rel.Source = strings.TrimSpace(rel.Source)
+		rel.Relation = strings.TrimSpace(rel.Relation)
+		rel.Target = strings.TrimSpace(rel.Target)
+		if rel.Source == "" || rel.Relation == "" || rel.Target == "" {
+			return checkpointDistillResult{}, fmt.Errorf("relations[%d] requires source/relation/target", i)
+		}
+		if rel.Confidence < 0 || rel.Confidence > 1 {
+			return checkpointDistillResult{}, fmt.Errorf("relations[%d].confidence must be 0..1", i)
+		}
+		out.Relations[i] = rel
+	}
 	return out, nil
 }
 
@@ -205,7 +243,7 @@ func buildCheckpointPrompt(events []memory.Event) string {
 		trimmed = trimmed[len(trimmed)-checkpointDistillMaxEvents:]
 	}
 	raw, _ := json.Marshal(trimmed)
-	return "Distill the following memory events into strict JSON with keys new_items and updates only.\nEvents JSON:\n" + string(raw)
+	return "Distill the following memory events into strict JSON with keys new_items, updates, entities, and relations only.\nEvents JSON:\n" + string(raw)
 }
 
-const checkpointSystemPrompt = "You are a memory distillation engine. Return exactly one JSON object with this schema: {\"new_items\":[{\"kind\":string,\"title\":string,\"content\":string,\"importance\":1..5,\"confidence\":0..1}],\"updates\":[{\"id\":string,\"new_content\":string,\"confidence\":0..1}]}. Do not include markdown or commentary."
+const checkpointSystemPrompt = "You are a memory distillation engine. Return exactly one JSON object with this schema: {\"new_items\":[{\"kind\":string,\"title\":string,\"content\":string,\"importance\":1..5,\"confidence\":0..1,\"source_event_ids\":[string]}],\"updates\":[{\"id\":string,\"new_content\":string,\"confidence\":0..1,\"source_event_ids\":[string]}],\"entities\":[{\"name\":string,\"type\":string,\"description\":string}],\"relations\":[{\"source\":string,\"relation\":string,\"target\":string,\"confidence\":0..1}]}. source_event_ids lists the ids of the events each item was derived from. Entities are named services, people, teams, or systems; relations use short snake_case verbs such as depends_on or owned_by. Do not include markdown or commentary."
@@ -19,3 +19,19 @@ func TestParseStrictCheckpointJSONRejectsUnknownFields(t *testing.T) {
 		t.Fatal("expected strict parser to reject unknown field")
 	}
 }
+
+func TestParseStrictCheckpointJSONAcceptsGraph(t *testing.T) {
+	raw := `{"new_items":[],"updates":[],"entities":[{"name":"checkout-api","type":"service"}],"relations":[{"source":"checkout-api","relation":"depends_on","target":"billing-db","confidence":0.8}]}`
+	out, err := parseStrictCheckpointJSON(raw)
+	if err != nil {
+		t.Fatalf("parse strict checkpoint json: %v", err)
+	}
+	if len(out.Entities) != 1 || len(out.Relations) != 1 {
+		t.Fatalf("unexpected parsed graph output: %+v", out)
+	}
+
+	invalid := `{"new_items":[],"updates":[],"relations":[{"source":"checkout-api","relation":"","target":"billing-db"}]}`
+	if _, err := parseStrictCheckpointJSON(invalid); err == nil {
+		t.Fatal("expected relation without name to be rejected")
+	}
+}
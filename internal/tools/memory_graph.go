@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"openclawssy/internal/memory"
+	memorystore "openclawssy/internal/memory/store"
+)
+
+var checkpointRelationPattern = regexp.MustCompile(`(?i)\b([a-z0-9][a-z0-9_./-]*)\s+(depends on|is owned by|owned by|is maintained by|maintained by|runs on|is part of|part of)\s+([a-z0-9@][a-z0-9_./@-]*)`)
+
+var checkpointRelationNames = map[string]string{
+	"depends on":       "depends_on",
+	"is owned by":      "owned_by",
+	"owned by":         "owned_by",
+	"is maintained by": "maintained_by",
+	"maintained by":    "maintained_by",
+	"runs on":          "runs_on",
+	"is part of":       "part_of",
+	"part of":          "part_of",
+}
+
+var checkpointRelationStopwords = map[string]bool{
+	"it": true, "this": true, "that": true, "which": true, "who": true, "he": true, "she": true, "they": true,
+	"we": true, "i": true, "you": true, "everything": true, "something": true, "nothing": true, "also": true,
+}
+
+func memoryLink(agentsPath, configPath string) Handler {
+	return func(ctx context.Context, req Request) (map[string]any, error) {
+		store, closeFn, err := openAgentMemoryStore(req, agentsPath, configPath)
+		if err != nil {
+			return nil, err
+		}
+		defer closeFn()
+
+		source := strings.TrimSpace(valueString(req.Args, "source"))
+		relation := strings.TrimSpace(valueString(req.Args, "relation"))
+		target := strings.TrimSpace(valueString(req.Args, "target"))
+		if source == "" || relation == "" || target == "" {
+			return nil, errors.New("source, relation, and target are required")
+		}
+		if memory.NormalizeRelationName(relation) == "" {
+			return nil, errors.New("relation must contain at least one word")
+		}
+		saved, err := store.Link(ctx, memory.Relation{
+			SourceName: source,
+			Relation:   relation,
+			TargetName: target,
+			Confidence: getFloatArg(req.Args, "confidence", 0.85),
+		}, valueString(req.Args, "source_type"), valueString(req.Args, "target_type"))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"relation": saved, "linked": true}, nil
+	}
+}
+
+func memoryGraphQuery(agentsPath, configPath string) Handler {
+	return func(ctx context.Context, req Request) (map[string]any, error) {
+		store, closeFn, err := openAgentMemoryStore(req, agentsPath, configPath)
+		if err != nil {
+			return nil, err
+		}
+		defer closeFn()
+
+		params := memory.NormalizeGraphQueryParams(memory.GraphQueryParams{
+			Entity:   valueString(req.Args, "entity"),
+			Relation: valueString(req.Args, "relation"),
+			Depth:    getIntArg(req.Args, "depth", 1),
+			Limit:    getIntArg(req.Args, "limit", 50),
+		})
+		result, err := store.QueryGraph(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"entity":         params.Entity,
+			"relation":       params.Relation,
+			"depth":          params.Depth,
+			"entities":       result.Entities,
+			"relations":      result.Relations,
+			"entity_count":   len(result.Entities),
+			"relation_count": len(result.Relations),
+		}, nil
+	}
+}
+
+func applyCheckpointGraph(ctx context.Context, store *memorystore.SQLiteStore, entities []checkpointEntity, relations []checkpointRelation) (int, int, error) {
+	entityCount := 0
+	for _, entity := range entities {
+		if _, err := store.UpsertEntity(ctx, memory.Entity{Name: entity.Name, Type: entity.Type, Description: entity.Description}); err != nil {
+			return entityCount, 0, err
+		}
+		entityCount++
+	}
+	relationCount := 0
+	for _, rel := range relations {
+		if memory.NormalizeRelationName(rel.Relation) == "" {
+			continue
+		}
+		if _, err := store.Link(ctx, memory.Relation{
+			SourceName: rel.Source,
+			Relation:   rel.Relation,
+			TargetName: rel.Target,
+			Confidence: rel.Confidence,
+		}, "", ""); err != nil {
+			return entityCount, relationCount, err
+		}
+		relationCount++
+	}
+	return entityCount, relationCount, nil
+}
+
+func distillCheckpointRelations(events []memory.Event) []checkpointRelation {
+	seen := map[string]checkpointRelation{}
+	for _, evt := range events {
+		switch evt.Type {
+		case memory.EventTypeUserMessage, memory.EventTypeDecisionLog, memory.EventTypeAssistantOutput:
+		default:
+			continue
+		}
+		for _, match := range checkpointRelationPattern.FindAllStringSubmatch(evt.Text, -1) {
+			source := strings.Trim(match[1], "./")
+			target := strings.Trim(match[3], "./")
+			relation := checkpointRelationNames[strings.ToLower(strings.Join(strings.Fields(match[2]), " "))]
+			if source == "" || target == "" || relation == "" {
+				continue
+			}
+			if checkpointRelationStopwords[strings.ToLower(source)] || checkpointRelationStopwords[strings.ToLower(target)] {
+				continue
+			}
+			key := memory.EntityKey(source) + "|" + relation + "|" + memory.EntityKey(target)
+			seen[key] = checkpointRelation{Source: source, Relation: relation, Target: target, Confidence: 0.6}
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]checkpointRelation, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, seen[key])
+	}
+	return out
+}
@@ -108,6 +108,33 @@ func registerMemoryTools(reg *Registry, agentsPath, configPath string) error {
 	}, memoryMaintenance(agentsPath, configPath)); err != nil {
 		return err
 	}
+	if err := reg.Register(ToolSpec{
+		Name:        "memory.link",
+		Description: "Link two entities with a named relation in the memory graph",
+		Required:    []string{"source", "relation", "target"},
+		ArgTypes: map[string]ArgType{
+			"source":      ArgTypeString,
+			"relation":    ArgTypeString,
+			"target":      ArgTypeString,
+			"source_type": ArgTypeString,
+			"target_type": ArgTypeString,
+			"confidence":  ArgTypeNumber,
+		},
+	}, memoryLink(agentsPath, configPath)); err != nil {
+		return err
+	}
+	if err := reg.Register(ToolSpec{
+		Name:        "memory.graph.query",
+		Description: "Query entities and relations in the memory graph",
+		ArgTypes: map[string]ArgType{
+			"entity":   ArgTypeString,
+			"relation": ArgTypeString,
+			"depth":    ArgTypeNumber,
+			"limit":    ArgTypeNumber,
+		},
+	}, memoryGraphQuery(agentsPath, configPath)); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -171,16 +198,18 @@ func memoryWrite(agentsPath, configPath string) Handler {
 			Importance: getIntArg(req.Args, "importance", 3),
 			Confidence: getFloatArg(req.Args, "confidence", 0.85),
 			Status:     strings.TrimSpace(valueString(req.Args, "status")),
+			Provenance: requestProvenance(req, "memory.write"),
 		}
 		if item.Kind == "" || item.Title == "" || item.Content == "" {
 			return nil, errors.New("kind, title, and content are required")
 		}
+		item.Status = memory.DecayPoliciesFromConfig(cfg.Memory).ReviewStatus(item)
 		saved, err := store.Upsert(ctx, item)
 		if err != nil {
 			return nil, err
 		}
 		_ = maybeSyncMemoryEmbedding(ctx, cfg, store, saved)
-		return map[string]any{"item": saved, "written": true}, nil
+		return map[string]any{"item": saved, "written": true, "pending_review": saved.Status == memory.MemoryStatusPendingReview}, nil
 	}
 }
 
@@ -230,6 +259,11 @@ func memoryUpdate(agentsPath, configPath string) Handler {
 		if strings.TrimSpace(item.Kind) == "" || strings.TrimSpace(item.Title) == "" || strings.TrimSpace(item.Content) == "" {
 			return nil, errors.New("kind, title, and content cannot be empty")
 		}
+		item.Provenance = memory.MergeProvenance(item.Provenance, requestProvenance(req, "memory.update"))
+		if existing.Status == memory.MemoryStatusPendingReview && memory.NormalizeItem(item).Status == memory.MemoryStatusActive {
+			item.Status = memory.MemoryStatusPendingReview
+		}
+		item.Status = memory.DecayPoliciesFromConfig(cfg.Memory).ReviewStatus(item)
 		saved, err := store.Update(ctx, item)
 		if err != nil {
 			if errors.Is(err, memorystore.ErrNotFound) {
@@ -238,7 +272,7 @@ func memoryUpdate(agentsPath, configPath string) Handler {
 			return nil, err
 		}
 		_ = maybeSyncMemoryEmbedding(ctx, cfg, store, saved)
-		return map[string]any{"item": saved, "updated": true, "found": true}, nil
+		return map[string]any{"item": saved, "updated": true, "found": true, "pending_review": saved.Status == memory.MemoryStatusPendingReview}, nil
 	}
 }
 
@@ -280,6 +314,10 @@ func memoryHealth(agentsPath, configPath string) Handler {
 
 func decisionLog(agentsPath, configPath string) Handler {
 	return func(ctx context.Context, req Request) (map[string]any, error) {
+		cfg, err := loadMemoryConfigForRequest(req.Workspace, configPath)
+		if err != nil {
+			return nil, err
+		}
 		store, closeFn, err := openAgentMemoryStore(req, agentsPath, configPath)
 		if err != nil {
 			return nil, err
@@ -291,6 +329,10 @@ func decisionLog(agentsPath, configPath string) Handler {
 		if title == "" || content == "" {
 			return nil, errors.New("title and content are required")
 		}
+		now := time.Now().UTC()
+		eventID := fmt.Sprintf("evt_%d", now.UnixNano())
+		provenance := requestProvenance(req, "decision.log")
+		provenance.EventIDs = []string{eventID}
 		item := memory.MemoryItem{
 			Kind:       "decision",
 			Title:      title,
@@ -298,7 +340,9 @@ func decisionLog(agentsPath, configPath string) Handler {
 			Importance: getIntArg(req.Args, "importance", 4),
 			Confidence: getFloatArg(req.Args, "confidence", 0.9),
 			Status:     memory.MemoryStatusActive,
+			Provenance: provenance,
 		}
+		item.Status = memory.DecayPoliciesFromConfig(cfg.Memory).ReviewStatus(item)
 		saved, err := store.Upsert(ctx, item)
 		if err != nil {
 			return nil, err
@@ -311,14 +355,17 @@ func decisionLog(agentsPath, configPath string) Handler {
 				meta["metadata"] = rawMeta
 			}
 			_ = memory.AppendEvent(agentsRoot, req.AgentID, memory.Event{
+				ID:        eventID,
 				Type:      memory.EventTypeDecisionLog,
 				Text:      content,
-				Timestamp: time.Now().UTC(),
+				SessionID: req.SessionID,
+				RunID:     req.RunID,
+				Timestamp: now,
 				Metadata:  meta,
 			})
 		}
 
-		return map[string]any{"logged": true, "item": saved}, nil
+		return map[string]any{"logged": true, "item": saved, "pending_review": saved.Status == memory.MemoryStatusPendingReview}, nil
 	}
 }
 
@@ -347,11 +394,13 @@ func memoryCheckpoint(agentsPath, configPath string) Handler {
 			return nil, err
 		}
 		since := time.Time{}
+		cursor := memory.EventCursor{}
 		if foundLatest {
 			since = latest.ToTimestamp
+			cursor = latest.NextCursor()
 		}
 		maxEvents := getIntArg(req.Args, "max_events", 250)
-		events, err := memory.ReadEventsSince(agentsRoot, agentID, since, maxEvents)
+		events, cursor, err := memory.ReadEventsFrom(agentsRoot, agentID, cursor, maxEvents)
 		if err != nil {
 			return nil, err
 		}
@@ -372,9 +421,18 @@ func memoryCheckpoint(agentsPath, configPath string) Handler {
 			distilledOut.NewItems = checkpointItemsToNew(distillCheckpointItems(events))
 			distilledOut.Updates = []checkpointUpdate{}
 		}
+		if len(distilledOut.Relations) == 0 {
+			distilledOut.Relations = distillCheckpointRelations(events)
+		}
 
+		reviewPolicies := memory.DecayPoliciesFromConfig(cfg.Memory)
+		pendingReview := 0
 		upserted := make([]memory.MemoryItem, 0, len(distilledOut.NewItems))
-		for _, item := range checkpointNewToMemory(distilledOut.NewItems) {
+		for _, item := range checkpointNewToMemory(distilledOut.NewItems, events) {
+			item.Status = reviewPolicies.ReviewStatus(item)
+			if item.Status == memory.MemoryStatusPendingReview {
+				pendingReview++
+			}
 			saved, err := store.Upsert(ctx, item)
 			if err != nil {
 				return nil, err
@@ -393,16 +451,27 @@ func memoryCheckpoint(agentsPath, configPath string) Handler {
 			}
 			existing.Content = upd.NewContent
 			existing.Confidence = upd.Confidence
+			existing.Provenance = memory.MergeProvenance(existing.Provenance, checkpointProvenance(upd.SourceEventIDs, events))
+			existing.Status = reviewPolicies.ReviewStatus(existing)
+			if existing.Status == memory.MemoryStatusPendingReview {
+				pendingReview++
+			}
 			if _, err := store.Update(ctx, existing); err != nil {
 				return nil, err
 			}
 			_ = maybeSyncMemoryEmbedding(ctx, cfg, store, existing)
 			updatedCount++
 		}
+		entityCount, relationCount, err := applyCheckpointGraph(ctx, store, distilledOut.Entities, distilledOut.Relations)
+		if err != nil {
+			return nil, err
+		}
 
 		result := checkpointDistillResult{
-			NewItems: distilledOut.NewItems,
-			Updates:  distilledOut.Updates,
+			NewItems:  distilledOut.NewItems,
+			Updates:   distilledOut.Updates,
+			Entities:  distilledOut.Entities,
+			Relations: distilledOut.Relations,
 		}
 		resultRaw, _ := json.Marshal(result)
 
@@ -413,9 +482,12 @@ func memoryCheckpoint(agentsPath, configPath string) Handler {
 			CreatedAt:        createdAt,
 			FromTimestamp:    since,
 			ToTimestamp:      events[len(events)-1].Timestamp,
+			Cursor:           cursor,
 			EventCount:       len(events),
 			NewItemCount:     len(upserted),
 			UpdatedItemCount: updatedCount,
+			EntityCount:      entityCount,
+			RelationCount:    relationCount,
 			Summary:          fmt.Sprintf("Distilled %d events into %d new and %d updated memory items", len(events), len(upserted), updatedCount),
 		}
 		checkpointPath, err := memory.WriteCheckpointRecord(agentsRoot, agentID, record)
@@ -431,18 +503,22 @@ func memoryCheckpoint(agentsPath, configPath string) Handler {
 				"event_count":        len(events),
 				"new_item_count":     len(upserted),
 				"updated_item_count": updatedCount,
+				"relation_count":     relationCount,
 				"mode":               distillationMode,
 			},
 		})
 
 		return map[string]any{
-			"checkpoint_created": true,
-			"checkpoint_path":    checkpointPath,
-			"event_count":        len(events),
-			"new_item_count":     len(upserted),
-			"updated_item_count": updatedCount,
-			"distillation_mode":  distillationMode,
-			"result":             result,
+			"checkpoint_created":   true,
+			"checkpoint_path":      checkpointPath,
+			"event_count":          len(events),
+			"new_item_count":       len(upserted),
+			"updated_item_count":   updatedCount,
+			"entity_count":         entityCount,
+			"relation_count":       relationCount,
+			"pending_review_count": pendingReview,
+			"distillation_mode":    distillationMode,
+			"result":               result,
 		}, nil
 	}
 }
@@ -463,6 +539,11 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 			return nil, err
 		}
 
+		cfg, err := loadMemoryConfigForRequest(req.Workspace, configPath)
+		if err != nil {
+			return nil, err
+		}
+
 		dryRun := getBoolArg(req.Args, "dry_run", false)
 		staleDays := getIntArg(req.Args, "stale_days", 45)
 		if staleDays < 7 {
@@ -478,12 +559,16 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 			return nil, err
 		}
 
+		policies := memory.DecayPoliciesFromConfig(cfg.Memory)
+		now := time.Now().UTC()
 		dupIDs := duplicateItemIDs(items)
-		staleIDs := staleArchiveIDs(items, staleDays)
+		expiredIDs := expiredArchiveIDs(items, policies, now)
+		staleIDs := staleArchiveIDs(items, staleDays, policies)
 		verifyIDs := verificationNeededIDs(items)
 
 		deduped := 0
 		archivedStale := 0
+		archivedExpired := 0
 		if !dryRun {
 			for _, id := range dupIDs {
 				ok, err := store.Archive(ctx, id)
@@ -503,11 +588,28 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 					archivedStale++
 				}
 			}
+			for _, id := range expiredIDs {
+				ok, err := store.Archive(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					archivedExpired++
+				}
+			}
 			if err := store.Vacuum(ctx); err != nil {
 				return nil, err
 			}
 		}
 
+		eventLog := memory.EventRetentionReport{}
+		if !dryRun {
+			eventLog, err = maintainEventLog(agentsRoot, agentID, memory.EventRetentionFromConfig(cfg.Memory), now)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		after, err := store.Health(ctx)
 		if err != nil {
 			return nil, err
@@ -518,6 +620,7 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 			CreatedAt:            time.Now().UTC(),
 			DeduplicatedCount:    deduped,
 			ArchivedStaleCount:   archivedStale,
+			ArchivedExpiredCount: archivedExpired,
 			VerificationCount:    len(verifyIDs),
 			Compacted:            !dryRun,
 			Before:               before,
@@ -525,6 +628,8 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 			VerificationItemIDs:  verifyIDs,
 			ArchivedDuplicateIDs: dupIDs,
 			ArchivedStaleIDs:     staleIDs,
+			ArchivedExpiredIDs:   expiredIDs,
+			EventLog:             eventLog,
 			Metadata:             map[string]any{"dry_run": dryRun, "stale_days": staleDays},
 		}
 		reportPath, err := memory.WriteMaintenanceReport(agentsRoot, agentID, report)
@@ -534,7 +639,7 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 		if !dryRun {
 			_ = memory.AppendEvent(agentsRoot, agentID, memory.Event{
 				Type:      memory.EventTypeMaintenance,
-				Text:      fmt.Sprintf("maintenance completed: deduped=%d stale_archived=%d verify=%d", deduped, archivedStale, len(verifyIDs)),
+				Text:      fmt.Sprintf("maintenance completed: deduped=%d stale_archived=%d expired_archived=%d verify=%d", deduped, archivedStale, archivedExpired, len(verifyIDs)),
 				Timestamp: time.Now().UTC(),
 				Metadata: map[string]any{
 					"report_path": reportPath,
@@ -544,18 +649,38 @@ func memoryMaintenance(agentsPath, configPath string) Handler {
 		}
 
 		return map[string]any{
-			"ok":                   true,
-			"dry_run":              dryRun,
-			"report_path":          reportPath,
-			"deduplicated_count":   deduped,
-			"archived_stale_count": archivedStale,
-			"verification_count":   len(verifyIDs),
-			"before":               before,
-			"after":                after,
+			"ok":                     true,
+			"dry_run":                dryRun,
+			"report_path":            reportPath,
+			"deduplicated_count":     deduped,
+			"archived_stale_count":   archivedStale,
+			"archived_expired_count": archivedExpired,
+			"verification_count":     len(verifyIDs),
+			"event_log":              eventLog,
+			"before":                 before,
+			"after":                  after,
 		}, nil
 	}
 }
 
+func maintainEventLog(agentsRoot, agentID string, retention memory.EventRetention, now time.Time) (memory.EventRetentionReport, error) {
+	compacted := 0
+	if record, ok, err := memory.LoadLatestCheckpointRecord(agentsRoot, agentID); err != nil {
+		return memory.EventRetentionReport{}, err
+	} else if ok {
+		compacted, err = memory.CompactEvents(agentsRoot, agentID, record.Cursor, now)
+		if err != nil {
+			return memory.EventRetentionReport{}, err
+		}
+	}
+	report, err := memory.ApplyEventRetention(agentsRoot, agentID, retention, now)
+	if err != nil {
+		return memory.EventRetentionReport{}, err
+	}
+	report.CompactedEvents = compacted
+	return report, nil
+}
+
 func openAgentMemoryStore(req Request, configuredAgentsPath, configuredConfigPath string) (*memorystore.SQLiteStore, func(), error) {
 	if req.Policy == nil {
 		return nil, nil, errors.New("policy is required")
@@ -631,20 +756,21 @@ func distillCheckpointItems(events []memory.Event) []memory.MemoryItem {
 		if text == "" {
 			continue
 		}
+		provenance := memory.ProvenanceFromEvents("checkpoint", []memory.Event{evt})
 		switch evt.Type {
 		case memory.EventTypeDecisionLog:
 			title := metadataString(evt.Metadata, "title")
 			if title == "" {
 				title = "Decision noted"
 			}
-			titleToItem["decision:"+title] = memory.MemoryItem{Kind: "decision", Title: title, Content: text, Importance: 4, Confidence: 0.9, Status: memory.MemoryStatusActive}
+			titleToItem["decision:"+title] = memory.MemoryItem{Kind: "decision", Title: title, Content: text, Importance: 4, Confidence: 0.9, Status: memory.MemoryStatusActive, Provenance: provenance}
 		case memory.EventTypeError:
 			title := "Recent error"
-			titleToItem["error:"+text] = memory.MemoryItem{Kind: "issue", Title: title, Content: text, Importance: 4, Confidence: 0.85, Status: memory.MemoryStatusActive}
+			titleToItem["error:"+text] = memory.MemoryItem{Kind: "issue", Title: title, Content: text, Importance: 4, Confidence: 0.85, Status: memory.MemoryStatusActive, Provenance: provenance}
 		case memory.EventTypeUserMessage:
 			if looksLikePreference(text) {
 				title := "User preference"
-				titleToItem["pref:"+text] = memory.MemoryItem{Kind: "preference", Title: title, Content: text, Importance: 3, Confidence: 0.75, Status: memory.MemoryStatusActive}
+				titleToItem["pref:"+text] = memory.MemoryItem{Kind: "preference", Title: title, Content: text, Importance: 3, Confidence: 0.75, Status: memory.MemoryStatusActive, Provenance: provenance}
 			}
 		}
 	}
@@ -653,7 +779,7 @@ func distillCheckpointItems(events []memory.Event) []memory.MemoryItem {
 		if strings.TrimSpace(summary) == "" {
 			return nil
 		}
-		return []memory.MemoryItem{{Kind: "summary", Title: "Checkpoint summary", Content: summary, Importance: 2, Confidence: 0.65, Status: memory.MemoryStatusActive}}
+		return []memory.MemoryItem{{Kind: "summary", Title: "Checkpoint summary", Content: summary, Importance: 2, Confidence: 0.65, Status: memory.MemoryStatusActive, Provenance: memory.ProvenanceFromEvents("checkpoint", events)}}
 	}
 	keys := make([]string, 0, len(titleToItem))
 	for key := range titleToItem {
@@ -713,17 +839,18 @@ func checkpointItemsToNew(items []memory.MemoryItem) []checkpointNewItem {
 	out := make([]checkpointNewItem, 0, len(items))
 	for _, item := range items {
 		out = append(out, checkpointNewItem{
-			Kind:       item.Kind,
-			Title:      item.Title,
-			Content:    item.Content,
-			Importance: item.Importance,
-			Confidence: item.Confidence,
+			Kind:           item.Kind,
+			Title:          item.Title,
+			Content:        item.Content,
+			Importance:     item.Importance,
+			Confidence:     item.Confidence,
+			SourceEventIDs: item.Provenance.EventIDs,
 		})
 	}
 	return out
 }
 
-func checkpointNewToMemory(items []checkpointNewItem) []memory.MemoryItem {
+func checkpointNewToMemory(items []checkpointNewItem, events []memory.Event) []memory.MemoryItem {
 	out := make([]memory.MemoryItem, 0, len(items))
 	for _, item := range items {
 		out = append(out, memory.MemoryItem{
@@ -733,11 +860,42 @@ func checkpointNewToMemory(items []checkpointNewItem) []memory.MemoryItem {
 			Importance: item.Importance,
 			Confidence: item.Confidence,
 			Status:     memory.MemoryStatusActive,
+			Provenance: checkpointProvenance(item.SourceEventIDs, events),
 		})
 	}
 	return out
 }
 
+func checkpointProvenance(sourceEventIDs []string, events []memory.Event) memory.Provenance {
+	if len(sourceEventIDs) > 0 {
+		wanted := make(map[string]bool, len(sourceEventIDs))
+		for _, id := range sourceEventIDs {
+			wanted[strings.TrimSpace(id)] = true
+		}
+		matched := make([]memory.Event, 0, len(sourceEventIDs))
+		for _, evt := range events {
+			if wanted[evt.ID] {
+				matched = append(matched, evt)
+			}
+		}
+		if len(matched) > 0 {
+			return memory.ProvenanceFromEvents("checkpoint", matched)
+		}
+	}
+	return memory.ProvenanceFromEvents("checkpoint", events)
+}
+
+func requestProvenance(req Request, source string) memory.Provenance {
+	out := memory.Provenance{Source: source}
+	if runID := strings.TrimSpace(req.RunID); runID != "" {
+		out.RunIDs = []string{runID}
+	}
+	if sessionID := strings.TrimSpace(req.SessionID); sessionID != "" {
+		out.SessionIDs = []string{sessionID}
+	}
+	return out
+}
+
 func duplicateItemIDs(items []memory.MemoryItem) []string {
 	if len(items) == 0 {
 		return nil
@@ -770,14 +928,14 @@ func duplicateItemIDs(items []memory.MemoryItem) []string {
 	return uniqueSortedStrings(dups)
 }
 
-func staleArchiveIDs(items []memory.MemoryItem, staleDays int) []string {
+func staleArchiveIDs(items []memory.MemoryItem, staleDays int, policies memory.DecayPolicies) []string {
 	if len(items) == 0 {
 		return nil
 	}
 	threshold := time.Now().UTC().Add(-time.Duration(staleDays) * 24 * time.Hour)
 	ids := []string{}
 	for _, item := range items {
-		if item.UpdatedAt.IsZero() {
+		if item.UpdatedAt.IsZero() || policies.For(item.Kind).NeverExpire {
 			continue
 		}
 		if item.UpdatedAt.Before(threshold) && item.Importance <= 2 {
@@ -787,6 +945,16 @@ func staleArchiveIDs(items []memory.MemoryItem, staleDays int) []string {
 	return uniqueSortedStrings(ids)
 }
 
+func expiredArchiveIDs(items []memory.MemoryItem, policies memory.DecayPolicies, now time.Time) []string {
+	ids := []string{}
+	for _, item := range items {
+		if policies.For(item.Kind).Expired(item, now) {
+			ids = append(ids, item.ID)
+		}
+	}
+	return uniqueSortedStrings(ids)
+}
+
 func verificationNeededIDs(items []memory.MemoryItem) []string {
 	ids := []string{}
 	threshold := time.Now().UTC().Add(-30 * 24 * time.Hour)
@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -66,6 +68,31 @@ func policyList(configuredPath string, defaultGrants []string) Handler {
 		if err != nil {
 			return nil, err
 		}
+		rules, err := policy.LoadArgRules(path)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := loadPolicyDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		describe := func(id string) map[string]any {
+			if agent, ok := doc.Agents[id]; ok {
+				return map[string]any{
+					"agent_id":     id,
+					"capabilities": policy.NormalizeCapabilities(agent.Capabilities),
+					"source":       "policy",
+					"rules":        policyArgRules(map[string][]policy.ArgRule{id: agent.Rules}, id),
+				}
+			}
+			caps, source := effectivePolicyCapabilities(id, defaultGrants, grants)
+			return map[string]any{
+				"agent_id":     id,
+				"capabilities": caps,
+				"source":       source,
+				"rules":        policyArgRules(rules, id),
+			}
+		}
 
 		agentID := strings.TrimSpace(valueString(req.Args, "agent_id"))
 		if agentID != "" {
@@ -73,18 +100,19 @@ func policyList(configuredPath string, defaultGrants []string) Handler {
 			if err != nil {
 				return nil, err
 			}
-			caps, source := effectivePolicyCapabilities(agentID, defaultGrants, grants)
-			return map[string]any{
-				"agent_id":     agentID,
-				"capabilities": caps,
-				"source":       source,
-			}, nil
+			return describe(agentID), nil
 		}
 
 		idsSet := map[string]struct{}{"default": {}}
 		for id := range grants {
 			idsSet[id] = struct{}{}
 		}
+		for id := range rules {
+			idsSet[id] = struct{}{}
+		}
+		for id := range doc.Agents {
+			idsSet[id] = struct{}{}
+		}
 		ids := make([]string, 0, len(idsSet))
 		for id := range idsSet {
 			ids = append(ids, id)
@@ -112,12 +140,7 @@ func policyList(configuredPath string, defaultGrants []string) Handler {
 
 		items := make([]map[string]any, 0, end-offset)
 		for _, id := range ids[offset:end] {
-			caps, source := effectivePolicyCapabilities(id, defaultGrants, grants)
-			items = append(items, map[string]any{
-				"agent_id":     id,
-				"capabilities": caps,
-				"source":       source,
-			})
+			items = append(items, describe(id))
 		}
 
 		return map[string]any{
@@ -130,6 +153,13 @@ func policyList(configuredPath string, defaultGrants []string) Handler {
 	}
 }
 
+func policyArgRules(rules map[string][]policy.ArgRule, agentID string) []policy.ArgRule {
+	if len(rules[agentID]) == 0 {
+		return []policy.ArgRule{}
+	}
+	return rules[agentID]
+}
+
 func policyGrant(configuredPath string, defaultGrants []string) Handler {
 	return func(_ context.Context, req Request) (map[string]any, error) {
 		if err := requirePolicyAdmin(req); err != nil {
@@ -143,6 +173,9 @@ func policyGrant(configuredPath string, defaultGrants []string) Handler {
 		if err != nil {
 			return nil, err
 		}
+		if err := requireGrantsManaged(req, path, targetAgent); err != nil {
+			return nil, err
+		}
 		grants, err := policy.LoadGrants(path)
 		if err != nil {
 			return nil, err
@@ -183,6 +216,9 @@ func policyRevoke(configuredPath string, defaultGrants []string) Handler {
 		if err != nil {
 			return nil, err
 		}
+		if err := requireGrantsManaged(req, path, targetAgent); err != nil {
+			return nil, err
+		}
 		grants, err := policy.LoadGrants(path)
 		if err != nil {
 			return nil, err
@@ -218,6 +254,29 @@ func policyRevoke(configuredPath string, defaultGrants []string) Handler {
 	}
 }
 
+// loadPolicyDocument reads the policy document stored next to the grants file.
+// A missing document is returned as an empty one.
+func loadPolicyDocument(grantsPath string) (policy.Document, error) {
+	doc, err := policy.LoadDocument(filepath.Join(filepath.Dir(grantsPath), "policy.json"))
+	if err != nil || doc == nil {
+		return policy.Document{}, err
+	}
+	return *doc, nil
+}
+
+// requireGrantsManaged rejects grant changes for agents defined in the policy
+// document, whose persisted grants would otherwise be silently ignored.
+func requireGrantsManaged(req Request, grantsPath, agentID string) error {
+	doc, err := loadPolicyDocument(grantsPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := doc.Agents[agentID]; ok {
+		return &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: fmt.Sprintf("agent %q is managed by policy.json; edit the policy document instead", agentID)}
+	}
+	return nil
+}
+
 func requirePolicyAdmin(req Request) error {
 	if req.Policy == nil {
 		return &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: "policy enforcer is required"}
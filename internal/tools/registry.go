@@ -39,18 +39,119 @@ type ShellExecutor interface {
 	Exec(ctx context.Context, command string, args []string) (stdout string, stderr string, exitCode int, err error)
 }
 
+// EnvShellExecutor is a ShellExecutor that can pass extra environment
+// variables to the command; skill.run requires it to inject inputs and secrets.
+type EnvShellExecutor interface {
+	ExecWithEnv(ctx context.Context, command string, args []string, env []string) (stdout string, stderr string, exitCode int, err error)
+}
+
+// ArgumentPolicy is implemented by policies that constrain the arguments of
+// granted tools (path globs, domains, commands); Registry.Execute consults it
+// after CheckTool and before any approval or handler runs.
+type ArgumentPolicy interface {
+	CheckArgs(agentID, tool, workspace string, args map[string]any) error
+}
+
+// ApprovalPolicy is implemented by policies that can put granted tools in ask
+// mode; Registry.Execute consults it after CheckTool succeeds.
+type ApprovalPolicy interface {
+	RequiresApproval(agentID, tool string) bool
+}
+
+// ApprovalRequest describes a tool call awaiting an operator decision. Args are
+// already sanitized for audit (secret values are redacted).
+type ApprovalRequest struct {
+	AgentID   string
+	Tool      string
+	RunID     string
+	SessionID string
+	Args      map[string]any
+	Reason    string
+}
+
+type ApprovalDecision struct {
+	ApprovalID string
+	Approved   bool
+	Approver   string
+	Reason     string
+}
+
+// Approver blocks until a tool call in ask mode is approved or denied.
+type Approver interface {
+	RequestApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+}
+
 type Handler func(ctx context.Context, req Request) (map[string]any, error)
 
 type Request struct {
 	AgentID              string
 	Tool                 string
 	Workspace            string
+	RunID                string
+	SessionID            string
 	Args                 map[string]any
 	Policy               Policy
+	Audit                Auditor
 	Shell                ShellExecutor
 	ShellAllowedCommands []string
 }
 
+// LogEvent records an audit event on behalf of the handler, if the registry
+// has an auditor.
+func (req Request) LogEvent(ctx context.Context, eventType string, fields map[string]any) {
+	if req.Audit == nil {
+		return
+	}
+	_ = req.Audit.LogEvent(ctx, eventType, fields)
+}
+
+type RunContext struct {
+	RunID     string
+	SessionID string
+}
+
+type runContextKey struct{}
+
+// CallGate tightens a single call beyond what policy allows, e.g. once a run
+// has read untrusted content. Deny refuses the call; otherwise it needs an
+// operator's approval. Reason is shown to the operator and the model.
+type CallGate struct {
+	Deny   bool
+	Reason string
+}
+
+type callGateKey struct{}
+
+func WithCallGate(ctx context.Context, gate CallGate) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, callGateKey{}, gate)
+}
+
+func callGateFromContext(ctx context.Context) (CallGate, bool) {
+	if ctx == nil {
+		return CallGate{}, false
+	}
+	gate, ok := ctx.Value(callGateKey{}).(CallGate)
+	return gate, ok
+}
+
+func WithRunContext(ctx context.Context, rc RunContext) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, runContextKey{}, rc)
+}
+
+func RunContextFromContext(ctx context.Context) RunContext {
+	if ctx == nil {
+		return RunContext{}
+	}
+	rc, _ := ctx.Value(runContextKey{}).(RunContext)
+	return rc
+}
+
 type registryItem struct {
 	spec    ToolSpec
 	handler Handler
@@ -61,6 +162,7 @@ type Registry struct {
 	audit                Auditor
 	shell                ShellExecutor
 	shellAllowedCommands []string
+	approver             Approver
 	mu                   sync.RWMutex
 	tools                map[string]registryItem
 }
@@ -85,6 +187,12 @@ func (r *Registry) SetShellAllowedCommands(prefixes []string) {
 	r.shellAllowedCommands = append([]string(nil), prefixes...)
 }
 
+func (r *Registry) SetApprover(approver Approver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.approver = approver
+}
+
 func (r *Registry) Register(spec ToolSpec, handler Handler) error {
 	if spec.Name == "" {
 		return errors.New("tool name is required")
@@ -160,7 +268,11 @@ func (r *Registry) Execute(ctx context.Context, agentID, name, workspace string,
 	}
 
 	if r.policy != nil {
-		if err := r.policy.CheckTool(agentID, name); err != nil {
+		err := r.policy.CheckTool(agentID, name)
+		if argPolicy, ok := r.policy.(ArgumentPolicy); ok && err == nil {
+			err = argPolicy.CheckArgs(agentID, name, workspace, args)
+		}
+		if err != nil {
 			denied := wrapError(ErrCodePolicyDenied, name, err)
 			_ = r.emit(ctx, "policy.denied", map[string]any{
 				"agent_id": agentID,
@@ -172,12 +284,33 @@ func (r *Registry) Execute(ctx context.Context, agentID, name, workspace string,
 		}
 	}
 
+	gate, gated := callGateFromContext(ctx)
+	if gated && gate.Deny {
+		denied := &ToolError{Code: ErrCodePolicyDenied, Tool: name, Message: gate.Reason}
+		_ = r.emit(ctx, "policy.denied", map[string]any{
+			"agent_id": agentID,
+			"tool":     name,
+			"error":    denied.Error(),
+		})
+		_ = r.emit(ctx, "tool.result", map[string]any{"agent_id": agentID, "tool": name, "error": denied.Error()})
+		return nil, denied
+	}
+
+	runCtx := RunContextFromContext(ctx)
+	if err := r.awaitApproval(ctx, agentID, name, runCtx, args, gate, gated); err != nil {
+		_ = r.emit(ctx, "tool.result", map[string]any{"agent_id": agentID, "tool": name, "error": err.Error()})
+		return nil, err
+	}
+
 	res, err := item.handler(ctx, Request{
 		AgentID:              agentID,
 		Tool:                 name,
 		Workspace:            workspace,
+		RunID:                runCtx.RunID,
+		SessionID:            runCtx.SessionID,
 		Args:                 args,
 		Policy:               r.policy,
+		Audit:                r.audit,
 		Shell:                r.shell,
 		ShellAllowedCommands: append([]string(nil), r.shellAllowedCommands...),
 	})
@@ -196,6 +329,61 @@ func (r *Registry) Execute(ctx context.Context, agentID, name, workspace string,
 	return res, nil
 }
 
+// awaitApproval holds calls to tools in ask mode, and gated calls, until the
+// approver decides. Without an approver such calls are denied rather than run
+// unattended.
+func (r *Registry) awaitApproval(ctx context.Context, agentID, name string, runCtx RunContext, args map[string]any, gate CallGate, gated bool) error {
+	askPolicy, ok := r.policy.(ApprovalPolicy)
+	if !gated && (!ok || !askPolicy.RequiresApproval(agentID, name)) {
+		return nil
+	}
+	r.mu.RLock()
+	approver := r.approver
+	r.mu.RUnlock()
+	if approver == nil {
+		message := "approval required but no approver is configured"
+		if gate.Reason != "" {
+			message += " (" + gate.Reason + ")"
+		}
+		err := &ToolError{Code: ErrCodePolicyDenied, Tool: name, Message: message}
+		_ = r.emit(ctx, "approval.denied", map[string]any{"agent_id": agentID, "tool": name, "error": err.Error()})
+		return err
+	}
+
+	decision, err := approver.RequestApproval(ctx, ApprovalRequest{
+		AgentID:   agentID,
+		Tool:      name,
+		RunID:     runCtx.RunID,
+		SessionID: runCtx.SessionID,
+		Args:      sanitizeAuditArgs(name, args),
+		Reason:    gate.Reason,
+	})
+	if err != nil {
+		denied := wrapError(ErrCodePolicyDenied, name, fmt.Errorf("approval failed: %w", err))
+		_ = r.emit(ctx, "approval.denied", map[string]any{"agent_id": agentID, "tool": name, "error": denied.Error()})
+		return denied
+	}
+	fields := map[string]any{
+		"agent_id":    agentID,
+		"tool":        name,
+		"approval_id": decision.ApprovalID,
+		"approver":    decision.Approver,
+	}
+	if decision.Reason != "" {
+		fields["reason"] = decision.Reason
+	}
+	if !decision.Approved {
+		_ = r.emit(ctx, "approval.denied", fields)
+		message := "denied by " + decision.Approver
+		if decision.Reason != "" {
+			message += ": " + decision.Reason
+		}
+		return &ToolError{Code: ErrCodePolicyDenied, Tool: name, Message: message}
+	}
+	_ = r.emit(ctx, "approval.granted", fields)
+	return nil
+}
+
 func sanitizeAuditArgs(tool string, args map[string]any) map[string]any {
 	if !strings.HasPrefix(strings.TrimSpace(tool), "secrets.") {
 		return args
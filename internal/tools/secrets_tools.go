@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -68,7 +69,7 @@ func secretsSet(configuredPath string) Handler {
 }
 
 func secretsGet(configuredPath string) Handler {
-	return func(_ context.Context, req Request) (map[string]any, error) {
+	return func(ctx context.Context, req Request) (map[string]any, error) {
 		key, err := getString(req.Args, "key")
 		if err != nil {
 			return nil, err
@@ -82,7 +83,8 @@ func secretsGet(configuredPath string) Handler {
 		if err != nil {
 			return nil, err
 		}
-		value, found, err := store.Get(key)
+		value, found, err := store.GetFor(key, secrets.Reader{AgentID: req.AgentID})
+		logSecretRead(ctx, req, key, "", err)
 		if err != nil {
 			return nil, err
 		}
@@ -108,6 +110,21 @@ func secretsList(configuredPath string) Handler {
 	}
 }
 
+// logSecretRead audits a secret read by name. Values never reach the log.
+func logSecretRead(ctx context.Context, req Request, key, skill string, err error) {
+	fields := map[string]any{"agent_id": req.AgentID, "tool": req.Tool, "key": key}
+	if skill != "" {
+		fields["skill"] = skill
+	}
+	eventType := "secret.read"
+	if errors.Is(err, secrets.ErrAccessDenied) {
+		eventType = "secret.denied"
+	} else if err != nil {
+		fields["error"] = err.Error()
+	}
+	req.LogEvent(ctx, eventType, fields)
+}
+
 func openSecretsStore(workspace, configuredPath string) (*secrets.Store, error) {
 	path, err := resolveOpenClawssyPath(workspace, configuredPath, "config", "config.json")
 	if err != nil {
@@ -0,0 +1,426 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSkillTimeoutSeconds = 60
+	maxSkillTimeoutSeconds     = 600
+)
+
+var skillInputNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var skillInputTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"boolean": true,
+	"array":   true,
+	"object":  true,
+}
+
+// skillManifest is the frontmatter block at the top of a skill file. It turns
+// a skill from reference text into a procedure skill.run can execute.
+type skillManifest struct {
+	Name           string                `json:"name,omitempty"`
+	Description    string                `json:"description,omitempty"`
+	Inputs         map[string]skillInput `json:"inputs,omitempty"`
+	Capabilities   []string              `json:"capabilities,omitempty"`
+	Secrets        []string              `json:"secrets,omitempty"`
+	Entrypoint     string                `json:"entrypoint,omitempty"`
+	Interpreter    string                `json:"interpreter,omitempty"`
+	TimeoutSeconds int                   `json:"timeout_seconds,omitempty"`
+}
+
+type skillInput struct {
+	Type        string   `json:"type,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Default     any      `json:"default,omitempty"`
+}
+
+// parseSkillManifest splits a leading `---` frontmatter block from content.
+// The block is JSON when it starts with `{` and a small YAML subset otherwise
+// (mappings, lists, flow lists and scalars). Content without frontmatter
+// returns a nil manifest.
+func parseSkillManifest(content string) (*skillManifest, error) {
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return nil, nil
+	}
+	rest := normalized[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, fmt.Errorf("frontmatter is not terminated by ---")
+	}
+	block := rest[:end]
+	if after := rest[end+len("\n---"):]; after != "" && after[0] != '\n' {
+		return nil, fmt.Errorf("frontmatter is not terminated by ---")
+	}
+
+	raw := []byte(strings.TrimSpace(block))
+	if len(raw) > 0 && raw[0] != '{' {
+		value, err := parseYAMLSubset(block)
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	manifest := &skillManifest{}
+	if len(raw) > 0 {
+		decoder := json.NewDecoder(strings.NewReader(string(raw)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (m *skillManifest) validate() error {
+	for name, input := range m.Inputs {
+		if !skillInputNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid input name %q (use letters, digits and _)", name)
+		}
+		if input.Type == "" {
+			input.Type = "string"
+			m.Inputs[name] = input
+		}
+		if !skillInputTypes[input.Type] {
+			return fmt.Errorf("input %s has unsupported type %q", name, input.Type)
+		}
+		if len(input.Enum) > 0 && input.Type != "string" {
+			return fmt.Errorf("input %s: enum is only supported for string inputs", name)
+		}
+	}
+	for _, capability := range m.Capabilities {
+		if strings.TrimSpace(capability) == "" {
+			return fmt.Errorf("capabilities cannot contain empty entries")
+		}
+	}
+	for _, key := range m.Secrets {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("secrets cannot contain empty entries")
+		}
+	}
+	if entry := strings.TrimSpace(m.Entrypoint); entry != "" {
+		cleaned := filepath.Clean(entry)
+		if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("entrypoint must be relative to the skill directory")
+		}
+		m.Entrypoint = cleaned
+	} else if strings.TrimSpace(m.Interpreter) != "" {
+		return fmt.Errorf("interpreter requires an entrypoint")
+	}
+	if m.TimeoutSeconds < 0 || m.TimeoutSeconds > maxSkillTimeoutSeconds {
+		return fmt.Errorf("timeout_seconds must be in range 0..%d", maxSkillTimeoutSeconds)
+	}
+	return nil
+}
+
+// bindInputs validates args against the declared inputs and fills defaults.
+func (m *skillManifest) bindInputs(args map[string]any) (map[string]any, error) {
+	bound := make(map[string]any, len(m.Inputs))
+	unknown := make([]string, 0)
+	for name, value := range args {
+		if _, ok := m.Inputs[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		bound[name] = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown skill inputs: %s", strings.Join(unknown, ", "))
+	}
+
+	names := make([]string, 0, len(m.Inputs))
+	for name := range m.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		input := m.Inputs[name]
+		value, ok := bound[name]
+		if !ok || value == nil {
+			if input.Default != nil {
+				bound[name] = input.Default
+				continue
+			}
+			if input.Required {
+				return nil, fmt.Errorf("skill input %s is required", name)
+			}
+			delete(bound, name)
+			continue
+		}
+		if !skillInputMatchesType(value, input.Type) {
+			return nil, fmt.Errorf("skill input %s must be %s", name, input.Type)
+		}
+		if len(input.Enum) > 0 {
+			allowed := false
+			for _, option := range input.Enum {
+				if value == option {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil, fmt.Errorf("skill input %s must be one of: %s", name, strings.Join(input.Enum, ", "))
+			}
+		}
+	}
+	return bound, nil
+}
+
+func skillInputMatchesType(value any, inputType string) bool {
+	switch inputType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		switch value.(type) {
+		case float64, float32, int, int64, int32:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		switch value.(type) {
+		case []any, []string:
+			return true
+		}
+		return false
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+// skillInputEnvValue renders an input for the SKILL_INPUT_<NAME> variable.
+// Arrays and objects are passed as JSON.
+func skillInputEnvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(raw)
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+func parseYAMLSubset(block string) (any, error) {
+	lines := make([]yamlLine, 0)
+	for i, raw := range strings.Split(block, "\n") {
+		if leading := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]; strings.Contains(leading, "\t") {
+			return nil, fmt.Errorf("frontmatter line %d: tabs are not allowed for indentation", i+1)
+		}
+		text := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("frontmatter line %d: unexpected indentation", lines[next].num)
+	}
+	return value, nil
+}
+
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if lines[i].text == "-" || strings.HasPrefix(lines[i].text, "- ") {
+		list := make([]any, 0)
+		for i < len(lines) && lines[i].indent == indent {
+			line := lines[i]
+			if line.text != "-" && !strings.HasPrefix(line.text, "- ") {
+				return nil, i, fmt.Errorf("frontmatter line %d: expected list item", line.num)
+			}
+			item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+			i++
+			if item == "" {
+				if i >= len(lines) || lines[i].indent <= indent {
+					list = append(list, nil)
+					continue
+				}
+				value, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+				if err != nil {
+					return nil, next, err
+				}
+				list = append(list, value)
+				i = next
+				continue
+			}
+			value, err := parseYAMLScalar(item, line.num)
+			if err != nil {
+				return nil, i, err
+			}
+			list = append(list, value)
+		}
+		return list, i, nil
+	}
+
+	mapping := make(map[string]any)
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, i, fmt.Errorf("frontmatter line %d: expected key: value", line.num)
+		}
+		if _, dup := mapping[key]; dup {
+			return nil, i, fmt.Errorf("frontmatter line %d: duplicate key %q", line.num, key)
+		}
+		i++
+		if rest != "" {
+			value, err := parseYAMLScalar(rest, line.num)
+			if err != nil {
+				return nil, i, err
+			}
+			mapping[key] = value
+			continue
+		}
+		if i >= len(lines) || lines[i].indent <= indent {
+			mapping[key] = nil
+			continue
+		}
+		value, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+		if err != nil {
+			return nil, next, err
+		}
+		mapping[key] = value
+		i = next
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, i, fmt.Errorf("frontmatter line %d: unexpected indentation", lines[i].num)
+	}
+	return mapping, i, nil
+}
+
+func splitYAMLKey(text string) (string, string, bool) {
+	var key, rest string
+	if idx := strings.Index(text, ": "); idx > 0 {
+		key, rest = text[:idx], strings.TrimSpace(text[idx+2:])
+	} else if strings.HasSuffix(text, ":") {
+		key = strings.TrimSuffix(text, ":")
+	} else {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if unquoted, err := strconv.Unquote(key); err == nil {
+		key = unquoted
+	}
+	return key, stripYAMLComment(rest), key != ""
+}
+
+func stripYAMLComment(value string) string {
+	if value == "" {
+		return value
+	}
+	start := 0
+	if quote := value[0]; quote == '"' || quote == '\'' {
+		for i := 1; i < len(value); i++ {
+			if quote == '"' && value[i] == '\\' {
+				i++
+				continue
+			}
+			if value[i] == quote {
+				if quote == '\'' && i+1 < len(value) && value[i+1] == '\'' {
+					i++
+					continue
+				}
+				start = i + 1
+				break
+			}
+		}
+		if start == 0 {
+			return value
+		}
+	}
+	if idx := strings.Index(value[start:], " #"); idx >= 0 {
+		return strings.TrimSpace(value[:start+idx])
+	}
+	return value
+}
+
+func parseYAMLScalar(value string, lineNum int) (any, error) {
+	value = stripYAMLComment(strings.TrimSpace(value))
+	switch {
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("frontmatter line %d: unterminated flow list", lineNum)
+		}
+		inner := strings.TrimSpace(value[1 : len(value)-1])
+		list := make([]any, 0)
+		if inner == "" {
+			return list, nil
+		}
+		for _, part := range strings.Split(inner, ",") {
+			item, err := parseYAMLScalar(part, lineNum)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case value == "{}":
+		return map[string]any{}, nil
+	case strings.HasPrefix(value, "{"):
+		return nil, fmt.Errorf("frontmatter line %d: flow mappings are not supported; use JSON frontmatter", lineNum)
+	case strings.HasPrefix(value, "\""):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("frontmatter line %d: invalid quoted string", lineNum)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return nil, fmt.Errorf("frontmatter line %d: invalid quoted string", lineNum)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number, nil
+	}
+	return value, nil
+}
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -10,6 +11,9 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
+
+	"openclawssy/internal/secrets"
 )
 
 const (
@@ -37,9 +41,11 @@ type workspaceSkill struct {
 	Content         string
 	Truncated       bool
 	RequiredSecrets []string
+	Manifest        *skillManifest
+	ManifestError   string
 }
 
-func registerSkillTools(reg *Registry, configuredPath string) error {
+func registerSkillTools(reg *Registry, configuredPath string, enableRun bool) error {
 	if err := reg.Register(ToolSpec{
 		Name:        "skill.list",
 		Description: "List workspace skills rooted under skills/ and report required secrets",
@@ -61,7 +67,19 @@ func registerSkillTools(reg *Registry, configuredPath string) error {
 	}, skillRead(configuredPath)); err != nil {
 		return err
 	}
-	return nil
+	if !enableRun {
+		return nil
+	}
+	return reg.Register(ToolSpec{
+		Name:        "skill.run",
+		Description: "Run a skill's manifest entrypoint in the sandbox with validated inputs and its declared secrets as env vars",
+		ArgTypes: map[string]ArgType{
+			"name":   ArgTypeString,
+			"path":   ArgTypeString,
+			"root":   ArgTypeString,
+			"inputs": ArgTypeObject,
+		},
+	}, skillRun(configuredPath))
 }
 
 func skillList(configuredPath string) Handler {
@@ -90,13 +108,21 @@ func skillList(configuredPath string) Handler {
 		for _, skill := range skills {
 			missing := missingSecrets(skill.RequiredSecrets, secretFound)
 			required := standardizeRequiredSecrets(skill.RequiredSecrets)
-			items = append(items, map[string]any{
+			item := map[string]any{
 				"name":             skill.Name,
 				"path":             skill.Path,
 				"required_secrets": required,
 				"missing_secrets":  missing,
-				"ready":            len(missing) == 0,
-			})
+				"ready":            len(missing) == 0 && skill.ManifestError == "",
+				"runnable":         skill.Manifest != nil && skill.Manifest.Entrypoint != "",
+			}
+			if skill.Manifest != nil && skill.Manifest.Description != "" {
+				item["description"] = skill.Manifest.Description
+			}
+			if skill.ManifestError != "" {
+				item["manifest_error"] = skill.ManifestError
+			}
+			items = append(items, item)
 		}
 
 		res := map[string]any{
@@ -159,6 +185,13 @@ func skillRead(configuredPath string) Handler {
 			"missing_secrets":  []string{},
 			"ready":            true,
 		}
+		if selected.Manifest != nil {
+			res["manifest"] = selected.Manifest
+			res["runnable"] = selected.Manifest.Entrypoint != ""
+		}
+		if selected.ManifestError != "" {
+			res["manifest_error"] = selected.ManifestError
+		}
 		if selected.Truncated {
 			res["summary"] = fmt.Sprintf("loaded skill %s (truncated to %d bytes)", selected.Name, maxSkillFileBytes)
 		} else {
@@ -168,6 +201,218 @@ func skillRead(configuredPath string) Handler {
 	}
 }
 
+func skillRun(configuredPath string) Handler {
+	return func(ctx context.Context, req Request) (map[string]any, error) {
+		root := strings.TrimSpace(getStringOrDefault(req.Args, "root", defaultSkillsRoot))
+		name := strings.TrimSpace(getStringOrDefault(req.Args, "name", ""))
+		skillPath := strings.TrimSpace(getStringOrDefault(req.Args, "path", ""))
+		if name == "" && skillPath == "" {
+			return nil, fmt.Errorf("name or path is required")
+		}
+		inputArgs, _ := req.Args["inputs"].(map[string]any)
+
+		skills, err := discoverWorkspaceSkills(req, root, false, defaultSkillsListSize*4)
+		if err != nil {
+			return nil, err
+		}
+		selected, err := selectWorkspaceSkill(skills, name, skillPath)
+		if err != nil {
+			return nil, err
+		}
+		if selected.ManifestError != "" {
+			return nil, fmt.Errorf("skill %s has an invalid manifest: %s", selected.Name, selected.ManifestError)
+		}
+		manifest := selected.Manifest
+		if manifest == nil || manifest.Entrypoint == "" {
+			return nil, fmt.Errorf("skill %s has no entrypoint; use skill.read and follow it instead", selected.Name)
+		}
+		inputs, err := manifest.bindInputs(inputArgs)
+		if err != nil {
+			return nil, &ToolError{Code: ErrCodeInvalidInput, Tool: req.Tool, Message: err.Error()}
+		}
+		if req.Policy != nil {
+			for _, capability := range manifest.Capabilities {
+				if err := req.Policy.CheckTool(req.AgentID, strings.TrimSpace(capability)); err != nil {
+					return nil, &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: fmt.Sprintf("skill %s requires capability %s", selected.Name, capability), Cause: err}
+				}
+			}
+		}
+
+		rootRel, _, err := normalizeSkillsRoot(req.Workspace, root)
+		if err != nil {
+			return nil, err
+		}
+		skillDir := filepath.Dir(filepath.FromSlash(selected.Path))
+		entryRel := filepath.Join(skillDir, manifest.Entrypoint)
+		if rel, err := filepath.Rel(rootRel, entryRel); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: "entrypoint must stay within the skills root"}
+		}
+		entryAbs := filepath.Join(req.Workspace, entryRel)
+		if req.Policy != nil {
+			if entryAbs, err = req.Policy.ResolveReadPath(req.Workspace, entryRel); err != nil {
+				return nil, err
+			}
+		}
+		if info, err := os.Stat(entryAbs); err != nil || !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("skill %s entrypoint not found: %s", selected.Name, filepath.ToSlash(entryRel))
+		}
+
+		command := "./" + filepath.ToSlash(entryRel)
+		var args []string
+		if interpreter := strings.TrimSpace(manifest.Interpreter); interpreter != "" {
+			command, args = interpreter, []string{filepath.ToSlash(entryRel)}
+		}
+		if !shellCommandAllowed(req.ShellAllowedCommands, command, args) {
+			return nil, &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: "skill entrypoint command is not allowed"}
+		}
+		executor, ok := req.Shell.(EnvShellExecutor)
+		if !ok {
+			return nil, errors.New("shell executor is not configured")
+		}
+
+		env, err := skillRunEnv(selected, inputs)
+		if err != nil {
+			return nil, err
+		}
+		injected := make([]string, 0, len(manifest.Secrets))
+		if len(manifest.Secrets) > 0 {
+			store, err := openSecretsStore(req.Workspace, configuredPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open secret store: %w", err)
+			}
+			missing := make([]string, 0)
+			for _, key := range manifest.Secrets {
+				// The manifest is agent-writable, so each injected secret needs
+				// the same grant a direct secrets.get would.
+				if err := checkSkillSecretGrant(req, canonicalSecretKey(key)); err != nil {
+					return nil, &ToolError{Code: ErrCodePolicyDenied, Tool: req.Tool, Message: fmt.Sprintf("skill %s requires secrets.get for %s", selected.Name, canonicalSecretKey(key)), Cause: err}
+				}
+				value, found, err := lookupSkillSecret(store, secrets.Reader{AgentID: req.AgentID}, key)
+				if found || err != nil {
+					logSecretRead(ctx, req, canonicalSecretKey(key), selected.Name, err)
+				}
+				if err != nil {
+					return nil, err
+				}
+				if !found {
+					missing = append(missing, canonicalSecretKey(key))
+					continue
+				}
+				envName := skillSecretEnvName(key)
+				env = append(env, envName+"="+value)
+				injected = append(injected, envName)
+			}
+			if len(missing) > 0 {
+				return nil, fmt.Errorf("missing required secrets for skill %s: %s (set via /api/admin/secrets or secrets.set)", selected.Name, strings.Join(missing, ", "))
+			}
+		}
+
+		timeoutSeconds := manifest.TimeoutSeconds
+		if timeoutSeconds == 0 {
+			timeoutSeconds = defaultSkillTimeoutSeconds
+		}
+		execCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+		defer cancel()
+		started := time.Now()
+		stdout, stderr, exitCode, execErr := executor.ExecWithEnv(execCtx, command, args, env)
+
+		res := map[string]any{
+			"name":            selected.Name,
+			"path":            selected.Path,
+			"entrypoint":      filepath.ToSlash(entryRel),
+			"inputs":          inputs,
+			"secrets":         injected,
+			"stdout":          stdout,
+			"stderr":          stderr,
+			"exit_code":       exitCode,
+			"duration_ms":     time.Since(started).Milliseconds(),
+			"timeout_seconds": timeoutSeconds,
+		}
+		if execErr != nil {
+			res["error"] = execErr.Error()
+			if !isProcessExitStatusError(execErr) {
+				return res, execErr
+			}
+		}
+		return res, nil
+	}
+}
+
+func checkSkillSecretGrant(req Request, key string) error {
+	if req.Policy == nil {
+		return nil
+	}
+	if err := req.Policy.CheckTool(req.AgentID, "secrets.get"); err != nil {
+		return err
+	}
+	if argPolicy, ok := req.Policy.(ArgumentPolicy); ok {
+		return argPolicy.CheckArgs(req.AgentID, "secrets.get", req.Workspace, map[string]any{"key": key})
+	}
+	return nil
+}
+
+// skillRunEnv builds the entrypoint environment: SKILL_NAME, SKILL_DIR,
+// SKILL_INPUTS as a JSON object and one SKILL_INPUT_<NAME> per bound input.
+func skillRunEnv(skill workspaceSkill, inputs map[string]any) ([]string, error) {
+	rawInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, err
+	}
+	env := []string{
+		"SKILL_NAME=" + skill.Name,
+		"SKILL_DIR=" + filepath.ToSlash(filepath.Dir(filepath.FromSlash(skill.Path))),
+		"SKILL_INPUTS=" + string(rawInputs),
+	}
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, "SKILL_INPUT_"+strings.ToUpper(name)+"="+skillInputEnvValue(inputs[name]))
+	}
+	return env, nil
+}
+
+func lookupSkillSecret(store interface {
+	GetFor(name string, reader secrets.Reader) (string, bool, error)
+}, reader secrets.Reader, key string) (string, bool, error) {
+	candidates := secretKeyCandidates(key)
+	for _, candidate := range candidates {
+		value, found, err := store.GetFor(candidate, reader)
+		if err != nil {
+			return "", false, err
+		}
+		if found {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// skillSecretEnvName maps a secret key to its environment variable name:
+// provider/<name>/api_key becomes <NAME>_API_KEY and other keys are
+// uppercased with non-alphanumerics replaced by underscores.
+func skillSecretEnvName(key string) string {
+	trimmed := strings.TrimSpace(key)
+	lower := strings.ToLower(trimmed)
+	if providerSecretPattern.MatchString(lower) {
+		parts := strings.Split(lower, "/")
+		if len(parts) >= 3 {
+			trimmed = parts[1] + "_api_key"
+		}
+	}
+	var b strings.Builder
+	for _, r := range strings.ToUpper(trimmed) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteByte('_')
+	}
+	return b.String()
+}
+
 func discoverWorkspaceSkills(req Request, root string, includeContent bool, limit int) ([]workspaceSkill, error) {
 	if strings.TrimSpace(req.Workspace) == "" {
 		return nil, fmt.Errorf("workspace is required")
@@ -239,6 +484,15 @@ func discoverWorkspaceSkills(req Request, root string, includeContent bool, limi
 			Truncated:       truncated,
 			RequiredSecrets: detectRequiredSecrets(content),
 		}
+		if manifest, err := parseSkillManifest(content); err != nil {
+			skill.ManifestError = err.Error()
+		} else if manifest != nil {
+			skill.Manifest = manifest
+			if name := strings.TrimSpace(manifest.Name); name != "" {
+				skill.Name = name
+			}
+			skill.RequiredSecrets = append(skill.RequiredSecrets, manifest.Secrets...)
+		}
 		if includeContent {
 			skill.Content = content
 		}
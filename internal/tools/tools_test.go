@@ -233,6 +233,151 @@ func TestRegistryPolicyDenied(t *testing.T) {
 	}
 }
 
+func TestRegistryArgumentRulesDenyBeforeHandler(t *testing.T) {
+	a := &memAudit{}
+	enforcer := policy.NewEnforcer(t.TempDir(), map[string][]string{"docs": {"fs.write"}})
+	enforcer.SetArgRules("docs", []policy.ArgRule{{Tool: "fs.write", Paths: []string{"docs/**"}}})
+	reg := NewRegistry(enforcer, a)
+	calls := 0
+	if err := reg.Register(ToolSpec{Name: "fs.write"}, func(ctx context.Context, req Request) (map[string]any, error) {
+		calls++
+		return map[string]any{"ok": true}, nil
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if _, err := reg.Execute(context.Background(), "docs", "fs.write", ".", map[string]any{"path": "docs/a.md"}); err != nil {
+		t.Fatalf("expected docs/a.md allowed, got %v", err)
+	}
+	_, err := reg.Execute(context.Background(), "docs", "fs.write", ".", map[string]any{"path": "cmd/main.go"})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrCodePolicyDenied {
+		t.Fatalf("expected policy denied ToolError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "outside allowed paths docs/**") {
+		t.Fatalf("expected rule explanation in error, got %q", err.Error())
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if a.events[len(a.events)-2] != "policy.denied" {
+		t.Fatalf("expected policy.denied audit event, got %#v", a.events)
+	}
+}
+
+type fakeApprover struct {
+	decision ApprovalDecision
+	requests []ApprovalRequest
+}
+
+func (a *fakeApprover) RequestApproval(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	_ = ctx
+	a.requests = append(a.requests, req)
+	return a.decision, nil
+}
+
+func TestRegistryApprovalGate(t *testing.T) {
+	enforcer := policy.NewEnforcer(t.TempDir(), map[string][]string{"agent": {"secrets.set", "fs.read"}})
+	enforcer.SetApprovalRequired("agent", []string{"secrets.set"})
+	a := &memAudit{}
+	reg := NewRegistry(enforcer, a)
+	calls := 0
+	for _, name := range []string{"secrets.set", "fs.read"} {
+		if err := reg.Register(ToolSpec{Name: name}, func(ctx context.Context, req Request) (map[string]any, error) {
+			calls++
+			return map[string]any{"ok": true}, nil
+		}); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	args := map[string]any{"key": "provider/x/api_key", "value": "sk-live"}
+	_, err := reg.Execute(context.Background(), "agent", "secrets.set", ".", args)
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrCodePolicyDenied || !strings.Contains(toolErr.Message, "no approver") {
+		t.Fatalf("expected denial without approver, got %v", err)
+	}
+
+	approver := &fakeApprover{decision: ApprovalDecision{ApprovalID: "apr_1", Approved: false, Approver: "chat:alice", Reason: "not now"}}
+	reg.SetApprover(approver)
+	ctx := WithRunContext(context.Background(), RunContext{RunID: "run_1", SessionID: "chat_1"})
+	if _, err := reg.Execute(ctx, "agent", "secrets.set", ".", args); err == nil || !strings.Contains(err.Error(), "denied by chat:alice") {
+		t.Fatalf("expected operator denial, got %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected handler not to run before approval, ran %d times", calls)
+	}
+	if len(approver.requests) != 1 || approver.requests[0].SessionID != "chat_1" || approver.requests[0].Args["value"] != "[REDACTED]" {
+		t.Fatalf("unexpected approval request: %+v", approver.requests)
+	}
+
+	approver.decision = ApprovalDecision{ApprovalID: "apr_2", Approved: true, Approver: "dashboard"}
+	if _, err := reg.Execute(ctx, "agent", "secrets.set", ".", args); err != nil {
+		t.Fatalf("expected approved call to run: %v", err)
+	}
+	if _, err := reg.Execute(ctx, "agent", "fs.read", ".", map[string]any{"path": "x"}); err != nil {
+		t.Fatalf("fs.read should not need approval: %v", err)
+	}
+	if calls != 2 || len(approver.requests) != 2 {
+		t.Fatalf("expected 2 handler calls and 2 approval requests, got %d and %d", calls, len(approver.requests))
+	}
+
+	var granted, denied int
+	for _, rec := range a.recs {
+		switch rec.eventType {
+		case "approval.granted":
+			granted++
+			if rec.fields["approver"] != "dashboard" || rec.fields["approval_id"] != "apr_2" {
+				t.Fatalf("unexpected approval.granted fields: %+v", rec.fields)
+			}
+		case "approval.denied":
+			denied++
+		}
+	}
+	if granted != 1 || denied != 2 {
+		t.Fatalf("expected 1 granted and 2 denied audit events, got %d and %d", granted, denied)
+	}
+}
+
+func TestRegistryCallGate(t *testing.T) {
+	enforcer := policy.NewEnforcer(t.TempDir(), map[string][]string{"agent": {"shell.exec"}})
+	a := &memAudit{}
+	reg := NewRegistry(enforcer, a)
+	calls := 0
+	if err := reg.Register(ToolSpec{Name: "shell.exec"}, func(ctx context.Context, req Request) (map[string]any, error) {
+		calls++
+		return map[string]any{"ok": true}, nil
+	}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	denyCtx := WithCallGate(context.Background(), CallGate{Deny: true, Reason: "run is tainted by http.request"})
+	_, err := reg.Execute(denyCtx, "agent", "shell.exec", ".", nil)
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != ErrCodePolicyDenied || !strings.Contains(toolErr.Message, "tainted") {
+		t.Fatalf("expected gated denial, got %v", err)
+	}
+
+	askCtx := WithCallGate(context.Background(), CallGate{Reason: "run is tainted by http.request"})
+	if _, err := reg.Execute(askCtx, "agent", "shell.exec", ".", nil); err == nil || !strings.Contains(err.Error(), "no approver") {
+		t.Fatalf("expected gated call without approver to be denied, got %v", err)
+	}
+	approver := &fakeApprover{decision: ApprovalDecision{ApprovalID: "apr_1", Approved: true, Approver: "dashboard"}}
+	reg.SetApprover(approver)
+	if _, err := reg.Execute(askCtx, "agent", "shell.exec", ".", nil); err != nil {
+		t.Fatalf("expected approved gated call to run: %v", err)
+	}
+	if len(approver.requests) != 1 || approver.requests[0].Reason != "run is tainted by http.request" {
+		t.Fatalf("unexpected approval requests: %+v", approver.requests)
+	}
+	if _, err := reg.Execute(context.Background(), "agent", "shell.exec", ".", nil); err != nil || len(approver.requests) != 1 {
+		t.Fatalf("ungated call should not ask: %v %d", err, len(approver.requests))
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 handler calls, got %d", calls)
+	}
+}
+
 func TestRegistryTimeoutIsStructuredAndAudited(t *testing.T) {
 	a := &memAudit{}
 	reg := NewRegistry(fakePolicy{}, a)
@@ -1060,7 +1205,7 @@ func setupMemoryToolRegistry(t *testing.T) (string, string, string, *Registry) {
 		t.Fatalf("save config fixture: %v", err)
 	}
 
-	enforcer := policy.NewEnforcer(ws, map[string][]string{"agent": {"memory.search", "memory.write", "memory.update", "memory.forget", "memory.health", "memory.checkpoint", "memory.maintenance", "decision.log"}})
+	enforcer := policy.NewEnforcer(ws, map[string][]string{"agent": {"memory.search", "memory.write", "memory.update", "memory.forget", "memory.health", "memory.checkpoint", "memory.maintenance", "memory.link", "memory.graph.query", "decision.log"}})
 	reg := NewRegistry(enforcer, nil)
 	if err := RegisterCoreWithOptions(reg, CoreOptions{EnableShellExec: true, ConfigPath: cfgPath, AgentsPath: agentsPath}); err != nil {
 		t.Fatalf("register core: %v", err)
@@ -1258,6 +1403,72 @@ func TestDecisionLogAndCheckpointTools(t *testing.T) {
 	}
 }
 
+func TestMemoryGraphToolsLinkAndQuery(t *testing.T) {
+	ws, _, _, reg := setupMemoryToolRegistry(t)
+
+	if _, err := reg.Execute(context.Background(), "agent", "memory.link", ws, map[string]any{
+		"source":      "checkout-api",
+		"relation":    "depends on",
+		"target":      "billing-db",
+		"source_type": "service",
+	}); err != nil {
+		t.Fatalf("memory.link: %v", err)
+	}
+	if _, err := reg.Execute(context.Background(), "agent", "memory.link", ws, map[string]any{
+		"source":   "billing-db",
+		"relation": "owned_by",
+		"target":   "payments-team",
+	}); err != nil {
+		t.Fatalf("memory.link owner: %v", err)
+	}
+
+	res, err := reg.Execute(context.Background(), "agent", "memory.graph.query", ws, map[string]any{"entity": "checkout-api", "depth": 2})
+	if err != nil {
+		t.Fatalf("memory.graph.query: %v", err)
+	}
+	relations, ok := res["relations"].([]memory.Relation)
+	if !ok {
+		t.Fatalf("expected relations slice, got %#v", res["relations"])
+	}
+	if len(relations) != 2 {
+		t.Fatalf("expected two relations within depth 2, got %+v", relations)
+	}
+
+	if _, err := reg.Execute(context.Background(), "agent", "memory.link", ws, map[string]any{
+		"source":   "a",
+		"relation": " - ",
+		"target":   "b",
+	}); err == nil {
+		t.Fatal("expected blank relation to be rejected")
+	}
+}
+
+func TestMemoryCheckpointExtractsRelationsWithFallback(t *testing.T) {
+	ws, _, _, reg := setupMemoryToolRegistry(t)
+
+	if _, err := reg.Execute(context.Background(), "agent", "decision.log", ws, map[string]any{
+		"title":   "Ownership",
+		"content": "checkout-api depends on billing-db, and billing-db is owned by payments-team.",
+	}); err != nil {
+		t.Fatalf("decision.log: %v", err)
+	}
+	chk, err := reg.Execute(context.Background(), "agent", "memory.checkpoint", ws, map[string]any{})
+	if err != nil {
+		t.Fatalf("memory.checkpoint: %v", err)
+	}
+	if count, _ := chk["relation_count"].(int); count != 2 {
+		t.Fatalf("expected two extracted relations, got %#v", chk["relation_count"])
+	}
+
+	res, err := reg.Execute(context.Background(), "agent", "memory.graph.query", ws, map[string]any{"entity": "billing-db"})
+	if err != nil {
+		t.Fatalf("memory.graph.query: %v", err)
+	}
+	if count, _ := res["relation_count"].(int); count != 2 {
+		t.Fatalf("expected billing-db to have two direct relations, got %#v", res)
+	}
+}
+
 func TestMemoryMaintenanceToolGeneratesReport(t *testing.T) {
 	ws, _, agentsPath, reg := setupMemoryToolRegistry(t)
 
@@ -1298,6 +1509,104 @@ func TestMemoryMaintenanceToolGeneratesReport(t *testing.T) {
 	}
 }
 
+func TestMemoryWriteRecordsProvenanceAndMaintenanceExpiresByKind(t *testing.T) {
+	ws, _, _, reg := setupMemoryToolRegistry(t)
+
+	ctx := WithRunContext(context.Background(), RunContext{RunID: "run_1", SessionID: "sess_1"})
+	res, err := reg.Execute(ctx, "agent", "memory.write", ws, map[string]any{
+		"kind":    "decision",
+		"title":   "Deploy window",
+		"content": "Deploys happen on Tuesdays.",
+	})
+	if err != nil {
+		t.Fatalf("memory.write: %v", err)
+	}
+	item, ok := res["item"].(memory.MemoryItem)
+	if !ok {
+		t.Fatalf("expected memory item, got %#v", res["item"])
+	}
+	if item.Provenance.Source != "memory.write" || len(item.Provenance.RunIDs) != 1 || item.Provenance.RunIDs[0] != "run_1" || item.Provenance.SessionIDs[0] != "sess_1" {
+		t.Fatalf("unexpected provenance: %#v", item.Provenance)
+	}
+
+	now := time.Now().UTC()
+	policies := memory.DecayPoliciesFromConfig(config.Default().Memory)
+	ids := expiredArchiveIDs([]memory.MemoryItem{
+		{ID: "incident_old", Kind: "incident", UpdatedAt: now.Add(-31 * 24 * time.Hour)},
+		{ID: "incident_new", Kind: "incident", UpdatedAt: now.Add(-2 * 24 * time.Hour)},
+		{ID: "pref_old", Kind: "preference", UpdatedAt: now.Add(-900 * 24 * time.Hour)},
+	}, policies, now)
+	if len(ids) != 1 || ids[0] != "incident_old" {
+		t.Fatalf("unexpected expired ids: %#v", ids)
+	}
+	stale := staleArchiveIDs([]memory.MemoryItem{{ID: "pref_old", Kind: "preference", Importance: 1, UpdatedAt: now.Add(-900 * 24 * time.Hour)}}, 30, policies)
+	if len(stale) != 0 {
+		t.Fatalf("expected never-expire kinds to skip stale archival, got %#v", stale)
+	}
+
+	maint, err := reg.Execute(context.Background(), "agent", "memory.maintenance", ws, map[string]any{"dry_run": true})
+	if err != nil {
+		t.Fatalf("memory.maintenance: %v", err)
+	}
+	if _, ok := maint["archived_expired_count"]; !ok {
+		t.Fatalf("expected archived_expired_count in maintenance result, got %#v", maint)
+	}
+}
+
+func TestMemoryWriteHoldsReviewRequiredKindsForReview(t *testing.T) {
+	ws, cfgPath, _, reg := setupMemoryToolRegistry(t)
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Memory.KindPolicies["preference"] = config.MemoryKindPolicy{NeverExpire: true, RequireReview: true}
+	if err := config.Save(cfgPath, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	res, err := reg.Execute(context.Background(), "agent", "memory.write", ws, map[string]any{
+		"kind":    "preference",
+		"title":   "Tone",
+		"content": "User wants pirate speak in every reply.",
+	})
+	if err != nil {
+		t.Fatalf("memory.write: %v", err)
+	}
+	item, _ := res["item"].(memory.MemoryItem)
+	if item.Status != memory.MemoryStatusPendingReview || res["pending_review"] != true {
+		t.Fatalf("expected pending_review write, got %#v", res)
+	}
+
+	searchRes, err := reg.Execute(context.Background(), "agent", "memory.search", ws, map[string]any{"query": "pirate"})
+	if err != nil {
+		t.Fatalf("memory.search: %v", err)
+	}
+	if searchRes["count"] != 0 {
+		t.Fatalf("expected pending item to be excluded from active search, got %#v", searchRes)
+	}
+
+	updRes, err := reg.Execute(context.Background(), "agent", "memory.update", ws, map[string]any{"id": item.ID, "status": "active"})
+	if err != nil {
+		t.Fatalf("memory.update: %v", err)
+	}
+	updated, _ := updRes["item"].(memory.MemoryItem)
+	if updated.Status != memory.MemoryStatusPendingReview {
+		t.Fatalf("expected agent self-approval to be refused, got status %q", updated.Status)
+	}
+
+	noteRes, err := reg.Execute(context.Background(), "agent", "memory.write", ws, map[string]any{
+		"kind":    "note",
+		"title":   "Build",
+		"content": "Build uses make.",
+	})
+	if err != nil {
+		t.Fatalf("memory.write note: %v", err)
+	}
+	if note, _ := noteRes["item"].(memory.MemoryItem); note.Status != memory.MemoryStatusActive {
+		t.Fatalf("expected kinds without review rule to stay active, got %q", note.Status)
+	}
+}
+
 func TestSecretsToolsRoundTripAndList(t *testing.T) {
 	ws, cfgPath := setupSecretsConfigFixture(t)
 	reg := NewRegistry(fakePolicy{}, nil)
@@ -1392,6 +1701,59 @@ func TestSecretsAuditNeverStoresPlaintextValues(t *testing.T) {
 	}
 }
 
+func TestSecretsGetEnforcesACLAndAuditsReads(t *testing.T) {
+	ws, cfgPath := setupSecretsConfigFixture(t)
+	audit := &memAudit{}
+	reg := NewRegistry(fakePolicy{}, audit)
+	if err := RegisterCoreWithOptions(reg, CoreOptions{EnableShellExec: true, ConfigPath: cfgPath}); err != nil {
+		t.Fatalf("register core: %v", err)
+	}
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	store, err := secrets.NewStore(cfg)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	if err := store.Set("deploy/token", "deploy-token-value"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.SetACL("deploy/token", secrets.ACL{Agents: []string{"ops"}}); err != nil {
+		t.Fatalf("set acl: %v", err)
+	}
+
+	if _, err := reg.Execute(context.Background(), "agent", "secrets.get", ws, map[string]any{"key": "deploy/token"}); !errors.Is(err, secrets.ErrAccessDenied) {
+		t.Fatalf("expected access denied for agent outside ACL, got %v", err)
+	}
+	res, err := reg.Execute(context.Background(), "ops", "secrets.get", ws, map[string]any{"key": "deploy/token"})
+	if err != nil {
+		t.Fatalf("secrets.get as ops: %v", err)
+	}
+	if res["value"] != "deploy-token-value" {
+		t.Fatalf("unexpected value: %#v", res)
+	}
+
+	var denied, read int
+	for _, rec := range audit.recs {
+		if strings.Contains(fmt.Sprintf("%v", rec.fields), "deploy-token-value") && strings.HasPrefix(rec.eventType, "secret.") {
+			t.Fatalf("secret value leaked into %s: %#v", rec.eventType, rec.fields)
+		}
+		switch rec.eventType {
+		case "secret.denied":
+			denied++
+		case "secret.read":
+			read++
+			if rec.fields["key"] != "deploy/token" || rec.fields["agent_id"] != "ops" {
+				t.Fatalf("unexpected read event: %#v", rec.fields)
+			}
+		}
+	}
+	if denied != 1 || read != 1 {
+		t.Fatalf("expected one denied and one read event, got denied=%d read=%d", denied, read)
+	}
+}
+
 func setupSecretsConfigFixture(t *testing.T) (string, string) {
 	t.Helper()
 	root := t.TempDir()
@@ -1527,6 +1889,155 @@ func TestSkillReadAcceptsProviderSecretAlias(t *testing.T) {
 	}
 }
 
+type envShell struct {
+	command string
+	args    []string
+	env     []string
+}
+
+func (s *envShell) Exec(ctx context.Context, command string, args []string) (string, string, int, error) {
+	return s.ExecWithEnv(ctx, command, args, nil)
+}
+
+func (s *envShell) ExecWithEnv(_ context.Context, command string, args []string, env []string) (string, string, int, error) {
+	s.command, s.args, s.env = command, args, env
+	return "deployed", "", 0, nil
+}
+
+type capabilityPolicy struct {
+	fakePolicy
+	denied map[string]bool
+}
+
+func (p capabilityPolicy) CheckTool(agentID, tool string) error {
+	if p.denied[tool] {
+		return &ToolError{Code: ErrCodePolicyDenied, Tool: tool, Message: "blocked"}
+	}
+	return nil
+}
+
+func TestParseSkillManifestYAMLAndJSON(t *testing.T) {
+	yamlSkill := "---\nname: deploy\ndescription: \"Deploy a service\" # shown in skill.list\ninputs:\n  service:\n    type: string\n    required: true\n    enum: [api, web]\n  dry_run:\n    type: boolean\n    default: true\ncapabilities:\n  - http.request\nsecrets: [DEPLOY_TOKEN]\nentrypoint: run.sh\ninterpreter: sh\ntimeout_seconds: 30\n---\n# Deploy\n"
+	manifest, err := parseSkillManifest(yamlSkill)
+	if err != nil {
+		t.Fatalf("parse yaml manifest: %v", err)
+	}
+	if manifest.Name != "deploy" || manifest.Description != "Deploy a service" || manifest.Entrypoint != "run.sh" || manifest.TimeoutSeconds != 30 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if in := manifest.Inputs["service"]; !in.Required || len(in.Enum) != 2 || manifest.Inputs["dry_run"].Default != true {
+		t.Fatalf("unexpected inputs: %+v", manifest.Inputs)
+	}
+	if len(manifest.Capabilities) != 1 || manifest.Secrets[0] != "DEPLOY_TOKEN" {
+		t.Fatalf("unexpected capabilities/secrets: %+v", manifest)
+	}
+
+	jsonSkill := "---\n{\"name\":\"lint\",\"inputs\":{\"paths\":{\"type\":\"array\"}},\"entrypoint\":\"lint.sh\"}\n---\nbody"
+	manifest, err = parseSkillManifest(jsonSkill)
+	if err != nil || manifest.Inputs["paths"].Type != "array" {
+		t.Fatalf("parse json manifest: %+v %v", manifest, err)
+	}
+
+	if manifest, err := parseSkillManifest("# plain skill\n"); manifest != nil || err != nil {
+		t.Fatalf("expected no manifest for plain skill, got %+v %v", manifest, err)
+	}
+	for _, bad := range []string{
+		"---\nentrypoint: ../escape.sh\n---\n",
+		"---\ninputs:\n  count:\n    type: integer\n---\n",
+		"---\nunknown_field: 1\n---\n",
+		"---\nname: open\n",
+	} {
+		if _, err := parseSkillManifest(bad); err == nil {
+			t.Fatalf("expected manifest error for %q", bad)
+		}
+	}
+}
+
+func TestSkillRunValidatesInputsAndInjectsSecrets(t *testing.T) {
+	ws, cfgPath := setupSecretsConfigFixture(t)
+	skillDir := filepath.Join(ws, "skills", "deploy")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatalf("mkdir skill dir: %v", err)
+	}
+	manifest := "---\nname: deploy\ninputs:\n  service:\n    required: true\n    enum: [api, web]\n  replicas:\n    type: number\n    default: 2\ncapabilities: [http.request]\nsecrets: [provider/acme/api_key]\nentrypoint: run.sh\ninterpreter: sh\n---\n# Deploy\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(manifest), 0o600); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "run.sh"), []byte("echo deploying\n"), 0o700); err != nil {
+		t.Fatalf("write entrypoint: %v", err)
+	}
+
+	shell := &envShell{}
+	reg := NewRegistry(fakePolicy{}, nil)
+	reg.SetShellExecutor(shell)
+	reg.SetShellAllowedCommands([]string{"sh skills/deploy/run.sh"})
+	if err := RegisterCoreWithOptions(reg, CoreOptions{EnableShellExec: true, ConfigPath: cfgPath}); err != nil {
+		t.Fatalf("register core: %v", err)
+	}
+
+	if _, err := reg.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "db"}}); err == nil || !strings.Contains(err.Error(), "must be one of") {
+		t.Fatalf("expected enum validation error, got %v", err)
+	}
+	if _, err := reg.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "api", "force": true}}); err == nil || !strings.Contains(err.Error(), "unknown skill inputs: force") {
+		t.Fatalf("expected unknown input error, got %v", err)
+	}
+	if _, err := reg.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "api"}}); err == nil || !strings.Contains(err.Error(), "provider/acme/api_key") {
+		t.Fatalf("expected missing secret error, got %v", err)
+	}
+	if _, err := reg.Execute(context.Background(), "agent", "secrets.set", ws, map[string]any{"key": "provider/acme/api_key", "value": "s3cret"}); err != nil {
+		t.Fatalf("secrets.set: %v", err)
+	}
+
+	res, err := reg.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "api"}})
+	if err != nil {
+		t.Fatalf("skill.run: %v", err)
+	}
+	if shell.command != "sh" || len(shell.args) != 1 || shell.args[0] != "skills/deploy/run.sh" {
+		t.Fatalf("unexpected invocation: %s %v", shell.command, shell.args)
+	}
+	env := strings.Join(shell.env, "\n")
+	for _, want := range []string{"SKILL_NAME=deploy", "SKILL_DIR=skills/deploy", "SKILL_INPUT_SERVICE=api", "SKILL_INPUT_REPLICAS=2", "ACME_API_KEY=s3cret"} {
+		if !strings.Contains(env, want) {
+			t.Fatalf("expected %q in env, got %v", want, shell.env)
+		}
+	}
+	if res["stdout"] != "deployed" || res["exit_code"] != 0 {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+	if secretsOut, _ := res["secrets"].([]string); len(secretsOut) != 1 || secretsOut[0] != "ACME_API_KEY" {
+		t.Fatalf("expected injected secret names only, got %#v", res["secrets"])
+	}
+
+	denied := NewRegistry(capabilityPolicy{denied: map[string]bool{"http.request": true}}, nil)
+	denied.SetShellExecutor(shell)
+	denied.SetShellAllowedCommands([]string{"sh skills/deploy/run.sh"})
+	if err := RegisterCoreWithOptions(denied, CoreOptions{EnableShellExec: true, ConfigPath: cfgPath}); err != nil {
+		t.Fatalf("register core: %v", err)
+	}
+	if _, err := denied.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "api"}}); err == nil || !strings.Contains(err.Error(), "requires capability http.request") {
+		t.Fatalf("expected capability denial, got %v", err)
+	}
+
+	noSecrets := NewRegistry(capabilityPolicy{denied: map[string]bool{"secrets.get": true}}, nil)
+	noSecrets.SetShellExecutor(shell)
+	noSecrets.SetShellAllowedCommands([]string{"sh skills/deploy/run.sh"})
+	if err := RegisterCoreWithOptions(noSecrets, CoreOptions{EnableShellExec: true, ConfigPath: cfgPath}); err != nil {
+		t.Fatalf("register core: %v", err)
+	}
+	shell.env = nil
+	if _, err := noSecrets.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "api"}}); err == nil || !strings.Contains(err.Error(), "requires secrets.get for provider/acme/api_key") {
+		t.Fatalf("expected secrets.get denial, got %v", err)
+	}
+	if len(shell.env) != 0 {
+		t.Fatalf("expected entrypoint not to run without secrets.get, got env %v", shell.env)
+	}
+
+	reg.SetShellAllowedCommands([]string{"bash"})
+	if _, err := reg.Execute(context.Background(), "agent", "skill.run", ws, map[string]any{"name": "deploy", "inputs": map[string]any{"service": "api"}}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected allowlist denial, got %v", err)
+	}
+}
+
 func TestHTTPRequestToolAllowsLocalhostAndTruncatesResponse(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -2152,6 +2663,69 @@ func TestPolicyToolsGrantRevokeLifecycle(t *testing.T) {
 	}
 }
 
+func TestPolicyListIncludesArgumentRules(t *testing.T) {
+	ws, policyPath, reg := setupPolicyToolRegistry(t, policy.NewEnforcer("", map[string][]string{
+		"admin": {"policy.list", "policy.admin"},
+	}))
+	raw := `{"agents":{},"rules":{"ops":[{"tool":"shell.exec","commands":["go test ./..."]}]}}`
+	if err := os.MkdirAll(filepath.Dir(policyPath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(policyPath, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+
+	res, err := reg.Execute(context.Background(), "admin", "policy.list", ws, map[string]any{"agent_id": "ops"})
+	if err != nil {
+		t.Fatalf("policy.list: %v", err)
+	}
+	rules, ok := res["rules"].([]policy.ArgRule)
+	if !ok || len(rules) != 1 || rules[0].Commands[0] != "go test ./..." {
+		t.Fatalf("expected ops rules, got %#v", res["rules"])
+	}
+
+	res, err = reg.Execute(context.Background(), "admin", "policy.list", ws, nil)
+	if err != nil {
+		t.Fatalf("policy.list all: %v", err)
+	}
+	items := res["items"].([]map[string]any)
+	found := false
+	for _, item := range items {
+		if item["agent_id"] == "ops" {
+			found = len(item["rules"].([]policy.ArgRule)) == 1
+		}
+	}
+	if !found {
+		t.Fatalf("expected ops listed with rules, got %#v", items)
+	}
+}
+
+func TestPolicyToolsRespectPolicyDocument(t *testing.T) {
+	ws, policyPath, reg := setupPolicyToolRegistry(t, policy.NewEnforcer("", map[string][]string{
+		"admin": {"policy.list", "policy.grant", "policy.admin"},
+	}))
+	if err := os.MkdirAll(filepath.Dir(policyPath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	doc := `{"version":1,"agents":{"ops":{"capabilities":["fs.read"],"rules":[{"tool":"fs.read","paths":["docs/**"]}]}}}`
+	if err := os.WriteFile(filepath.Join(filepath.Dir(policyPath), "policy.json"), []byte(doc), 0o600); err != nil {
+		t.Fatalf("write policy document: %v", err)
+	}
+
+	res, err := reg.Execute(context.Background(), "admin", "policy.list", ws, map[string]any{"agent_id": "ops"})
+	if err != nil {
+		t.Fatalf("policy.list: %v", err)
+	}
+	if res["source"] != "policy" || len(res["rules"].([]policy.ArgRule)) != 1 {
+		t.Fatalf("expected document-managed agent, got %#v", res)
+	}
+
+	_, err = reg.Execute(context.Background(), "admin", "policy.grant", ws, map[string]any{"agent_id": "ops", "capability": "fs.write"})
+	if err == nil || !strings.Contains(err.Error(), "managed by policy.json") {
+		t.Fatalf("expected grant for document-managed agent to fail, got %v", err)
+	}
+}
+
 func TestPolicyToolsRequirePolicyAdmin(t *testing.T) {
 	ws, _, reg := setupPolicyToolRegistry(t, policy.NewEnforcer("", map[string][]string{
 		"agent": {"policy.list", "policy.grant", "policy.revoke"},